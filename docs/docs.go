@@ -507,7 +507,6 @@ const docTemplate = `{
         "providers.LangModelConfig": {
             "type": "object",
             "required": [
-                "enabled",
                 "id"
             ],
             "properties": {
@@ -573,7 +572,6 @@ const docTemplate = `{
         "routers.LangRouterConfig": {
             "type": "object",
             "required": [
-                "enabled",
                 "models",
                 "retry",
                 "routers",
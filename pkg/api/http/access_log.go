@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+const (
+	accessLogRouterKey   = "access_log_router"
+	accessLogResponseKey = "access_log_response"
+	accessLogUserKey     = "access_log_user"
+)
+
+// AccessLogConfig enables one structured log line per API request for auditing.
+// Message content is never logged, only request metadata & token usage
+type AccessLogConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Encoding string `yaml:"encoding" validate:"oneof=json console"`
+}
+
+func DefaultAccessLogConfig() *AccessLogConfig {
+	return &AccessLogConfig{
+		Enabled:  true,
+		Encoding: "json",
+	}
+}
+
+func (cfg *AccessLogConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*cfg = *DefaultAccessLogConfig()
+
+	type plain AccessLogConfig // to avoid recursion
+
+	return unmarshal((*plain)(cfg))
+}
+
+// NewAccessLogger derives the access logger from the telemetry logging config.
+// Access logs are written at the info level, so they are kept even when the gateway logs only warnings & errors
+func NewAccessLogger(cfg *AccessLogConfig, logConfig *telemetry.LogConfig) (*zap.Logger, error) {
+	accessLogConfig := *logConfig
+	accessLogConfig.Encoding = cfg.Encoding
+	accessLogConfig.Level = zap.InfoLevel
+
+	// access logs are not sampled, so every request is audited
+	logger, err := accessLogConfig.ToZapConfig().Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return logger.Named("access"), nil
+}
+
+// AccessLogMiddleware logs every request once it's handled. It goes after the request ID middleware and before
+// the recovery one, so failed & panicked requests are logged with their final status code
+func AccessLogMiddleware(logger *zap.Logger) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		start := time.Now()
+
+		c.Next(ctx)
+
+		fields := []zap.Field{
+			zap.String("requestID", RequestID(c)),
+			zap.String("method", string(c.Request.Method())),
+			zap.String("path", string(c.Request.URI().Path())),
+			zap.String("routerID", accessLogRouter(c)),
+			zap.Int("status", c.Response.StatusCode()),
+			zap.Duration("latency", time.Since(start)),
+		}
+
+		if userHash := c.GetString(accessLogUserKey); userHash != "" {
+			fields = append(fields, zap.String("userHash", userHash))
+		}
+
+		if resp := accessLogResponse(c); resp != nil {
+			usage := resp.ModelResponse.TokenUsage
+
+			fields = append(
+				fields,
+				zap.String("modelID", resp.ModelID),
+				zap.String("provider", resp.Provider),
+				zap.Float64("promptTokens", usage.PromptTokens),
+				zap.Float64("responseTokens", usage.ResponseTokens),
+				zap.Float64("totalTokens", usage.TotalTokens),
+			)
+
+			if usage.CacheCreationTokens > 0 || usage.CacheReadTokens > 0 {
+				fields = append(
+					fields,
+					zap.Float64("cacheCreationTokens", usage.CacheCreationTokens),
+					zap.Float64("cacheReadTokens", usage.CacheReadTokens),
+				)
+			}
+
+			if usage.Estimated {
+				fields = append(fields, zap.Bool("tokensEstimated", true))
+			}
+
+			if resp.Degraded {
+				fields = append(fields, zap.Bool("degraded", true))
+			}
+		}
+
+		logger.Info("request handled", fields...)
+	}
+}
+
+// setAccessLogRouter records the router that served the request when it's not given in the path
+func setAccessLogRouter(c *app.RequestContext, routerID string) {
+	c.Set(accessLogRouterKey, routerID)
+}
+
+// setAccessLogResponse records the model that served the request & its token usage
+func setAccessLogResponse(c *app.RequestContext, resp *schemas.UnifiedChatResponse) {
+	c.Set(accessLogResponseKey, resp)
+}
+
+// setAccessLogUser records the hashed end user of the request (raw user IDs are never logged)
+func setAccessLogUser(c *app.RequestContext, request *schemas.UnifiedChatRequest) {
+	if userHash := request.UserHash(); userHash != "" {
+		c.Set(accessLogUserKey, userHash)
+	}
+}
+
+func accessLogRouter(c *app.RequestContext) string {
+	if routerID := c.GetString(accessLogRouterKey); routerID != "" {
+		return routerID
+	}
+
+	return c.Param("router")
+}
+
+func accessLogResponse(c *app.RequestContext) *schemas.UnifiedChatResponse {
+	if resp, found := c.Get(accessLogResponseKey); found {
+		if chatResp, ok := resp.(*schemas.UnifiedChatResponse); ok {
+			return chatResp
+		}
+	}
+
+	return nil
+}
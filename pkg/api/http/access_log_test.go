@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLogMiddleware_LogsRequestMetadata(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(RequestIDMiddleware(), AccessLogMiddleware(zap.New(core)))
+	engine.POST("/v1/language/:router/chat", func(_ context.Context, c *app.RequestContext) {
+		resp := &schemas.UnifiedChatResponse{
+			Provider: "openai",
+			ModelID:  "openai-gpt4",
+			ModelResponse: schemas.ProviderResponse{
+				Message:    schemas.ChatMessage{Role: "assistant", Content: "secret answer"},
+				TokenUsage: schemas.TokenUsage{PromptTokens: 10, ResponseTokens: 5, TotalTokens: 15},
+			},
+		}
+
+		setAccessLogUser(c, &schemas.UnifiedChatRequest{User: "secret-user"})
+		setAccessLogResponse(c, resp)
+		c.JSON(consts.StatusOK, resp)
+	})
+
+	ut.PerformRequest(
+		engine,
+		consts.MethodPost,
+		"/v1/language/myrouter/chat",
+		&ut.Body{Body: strings.NewReader("secret prompt"), Len: -1},
+		ut.Header{Key: RequestIDHeader, Value: "req-123"},
+	)
+
+	require.Equal(t, 1, logs.Len())
+
+	fields := logs.All()[0].ContextMap()
+
+	require.Equal(t, "req-123", fields["requestID"])
+	require.Equal(t, "POST", fields["method"])
+	require.Equal(t, "/v1/language/myrouter/chat", fields["path"])
+	require.Equal(t, "myrouter", fields["routerID"])
+	require.Equal(t, "openai-gpt4", fields["modelID"])
+	require.Equal(t, "openai", fields["provider"])
+	require.Equal(t, int64(consts.StatusOK), fields["status"])
+	require.Equal(t, 15.0, fields["totalTokens"])
+	require.Len(t, fields["userHash"], 16)
+
+	for _, value := range fields {
+		if strValue, ok := value.(string); ok {
+			require.NotContains(t, strValue, "secret")
+		}
+	}
+}
+
+func TestAccessLogMiddleware_LogsFailedRequests(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(
+		RequestIDMiddleware(),
+		AccessLogMiddleware(zap.New(core)),
+		RecoveryMiddleware(telemetry.NewTelemetryMock()),
+	)
+	engine.GET("/unavailable", func(_ context.Context, c *app.RequestContext) {
+		abortWithError(c, errors.New("something went wrong"))
+	})
+	engine.GET("/panic", func(_ context.Context, _ *app.RequestContext) {
+		panic("something went wrong")
+	})
+
+	ut.PerformRequest(engine, consts.MethodGet, "/unavailable", nil)
+	ut.PerformRequest(engine, consts.MethodGet, "/panic", nil)
+
+	require.Equal(t, 2, logs.Len())
+
+	for _, entry := range logs.All() {
+		require.Equal(t, int64(consts.StatusInternalServerError), entry.ContextMap()["status"])
+	}
+}
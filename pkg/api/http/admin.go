@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"glide/pkg/api/schemas"
+	"glide/pkg/config/fields"
+	"glide/pkg/routers"
+)
+
+var ErrAdminTokenMissing = errors.New("admin endpoints could not be enabled without a token")
+
+// AdminConfig exposes operational endpoints (e.g. /admin/state) protected by a bearer token. It's disabled by default
+type AdminConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Token   fields.Secret `yaml:"token"` // clients send it as "Authorization: Bearer <token>"
+}
+
+// Validate checks settings the struct validation could not. Secret references are checked once they are resolved
+func (cfg *AdminConfig) Validate() error {
+	if cfg.Enabled && cfg.Token.Value() == "" {
+		return ErrAdminTokenMissing
+	}
+
+	return nil
+}
+
+// AdminAuthMiddleware lets through requests with the admin bearer token only.
+// The token is read on each request, so rotated secrets are picked up without restarts
+func AdminAuthMiddleware(cfg *AdminConfig) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		token := cfg.Token.Value()
+		scheme, credentials, found := strings.Cut(string(c.GetHeader("Authorization")), " ")
+
+		// the empty token (e.g. the secret has failed to resolve) never lets anyone in
+		if !found || token == "" || !strings.EqualFold(scheme, "Bearer") ||
+			subtle.ConstantTimeCompare([]byte(credentials), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(
+				consts.StatusUnauthorized,
+				newErrorResponse(c, schemas.ErrorCodeUnauthorized, "admin token is missing or invalid"),
+			)
+
+			return
+		}
+
+		c.Next(ctx)
+	}
+}
+
+// AdminStateHandler
+//
+//	@id				glide-admin-state
+//	@Summary		Live Router State
+//	@Description	Inspect routing strategies of routers & health, latency and limits of their models
+//	@tags			Operations
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	http.AdminStateSchema
+//	@Failure		401	{object}	schemas.ErrorResponse
+//	@Router			/admin/state [GET]
+func AdminStateHandler(routerManager *routers.RouterManager) Handler {
+	return func(ctx context.Context, c *app.RequestContext) {
+		c.JSON(consts.StatusOK, AdminStateSchema{Routers: routerManager.State()})
+	}
+}
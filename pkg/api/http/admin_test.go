@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/config/fields"
+)
+
+func TestAdminConfig_RequiresToken(t *testing.T) {
+	require.ErrorIs(t, (&AdminConfig{Enabled: true}).Validate(), ErrAdminTokenMissing)
+	require.NoError(t, (&AdminConfig{}).Validate())
+	require.NoError(t, (&AdminConfig{Enabled: true, Token: "secret"}).Validate())
+
+	// references are checked by their resolved values
+	unresolvedToken := fields.Secret("vault://secret/data/glide#unresolved_admin_token")
+	require.ErrorIs(t, (&AdminConfig{Enabled: true, Token: unresolvedToken}).Validate(), ErrAdminTokenMissing)
+}
+
+func TestAdminAuthMiddleware_ChecksBearerToken(t *testing.T) {
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(RequestIDMiddleware())
+	engine.GET("/admin/state", AdminAuthMiddleware(&AdminConfig{Enabled: true, Token: "secret"}), func(_ context.Context, c *app.RequestContext) {
+		c.JSON(consts.StatusOK, AdminStateSchema{})
+	})
+
+	resp := ut.PerformRequest(engine, consts.MethodGet, "/admin/state", nil).Result()
+	require.Equal(t, consts.StatusUnauthorized, resp.StatusCode())
+	require.Contains(t, string(resp.Body()), "unauthorized")
+
+	resp = ut.PerformRequest(engine, consts.MethodGet, "/admin/state", nil, ut.Header{Key: "Authorization", Value: "Bearer wrong"}).Result()
+	require.Equal(t, consts.StatusUnauthorized, resp.StatusCode())
+
+	resp = ut.PerformRequest(engine, consts.MethodGet, "/admin/state", nil, ut.Header{Key: "Authorization", Value: "Bearer secret"}).Result()
+	require.Equal(t, consts.StatusOK, resp.StatusCode())
+}
+
+func TestAdminAuthMiddleware_ChecksResolvedTokenValue(t *testing.T) {
+	token := fields.Secret("vault://secret/data/glide#admin_token")
+	fields.SetResolvedValue(token, "first-secret")
+
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(RequestIDMiddleware())
+	engine.GET("/admin/state", AdminAuthMiddleware(&AdminConfig{Enabled: true, Token: token}), func(_ context.Context, c *app.RequestContext) {
+		c.JSON(consts.StatusOK, AdminStateSchema{})
+	})
+
+	requestWith := func(credentials string) int {
+		header := ut.Header{Key: "Authorization", Value: "Bearer " + credentials}
+
+		return ut.PerformRequest(engine, consts.MethodGet, "/admin/state", nil, header).Result().StatusCode()
+	}
+
+	// the reference itself is not the token
+	require.Equal(t, consts.StatusUnauthorized, requestWith(string(token)))
+	require.Equal(t, consts.StatusOK, requestWith("first-secret"))
+
+	// rotated tokens are picked up right away
+	fields.SetResolvedValue(token, "second-secret")
+
+	require.Equal(t, consts.StatusUnauthorized, requestWith("first-secret"))
+	require.Equal(t, consts.StatusOK, requestWith("second-secret"))
+
+	// tokens that resolve to nothing let nobody in
+	fields.SetResolvedValue(token, "")
+
+	require.Equal(t, consts.StatusUnauthorized, requestWith(""))
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers"
+)
+
+const openAIChatCompletionsPath = "/v1/chat/completions"
+
+// BodyLimitMiddleware rejects request bodies bigger than the limit with the request_too_large error (413).
+// The server streams bodies (see ServerConfig.ToServer), so bodies are never read past the limit,
+// while the error is reported in the shape of the API the request was sent to rather than by the plain server response
+func BodyLimitMiddleware(maxBodySize int) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if c.Request.Header.ContentLength() > maxBodySize {
+			abortWithBodyTooLarge(c, maxBodySize)
+
+			return
+		}
+
+		if c.Request.IsBodyStream() {
+			// bodies of unknown size (e.g. chunked ones) are checked while being read
+			body, err := io.ReadAll(io.LimitReader(c.RequestBodyStream(), int64(maxBodySize)+1))
+			if err != nil {
+				abortWithUnreadableBody(c)
+
+				return
+			}
+
+			if len(body) > maxBodySize {
+				abortWithBodyTooLarge(c, maxBodySize)
+
+				return
+			}
+
+			c.Request.SetBody(body)
+		}
+
+		c.Next(ctx)
+	}
+}
+
+func abortWithBodyTooLarge(c *app.RequestContext, maxBodySize int) {
+	err := fmt.Errorf("%w: the body is bigger than %v bytes", routers.ErrRequestTooLarge, maxBodySize)
+
+	// the rest of the body is not read, so the connection could not be reused
+	c.Response.SetConnectionClose()
+
+	if c.FullPath() == openAIChatCompletionsPath {
+		abortWithOpenAIError(c, err)
+
+		return
+	}
+
+	abortWithError(c, err)
+}
+
+func abortWithUnreadableBody(c *app.RequestContext) {
+	message := "failed to read the request body"
+
+	if c.FullPath() == openAIChatCompletionsPath {
+		c.AbortWithStatusJSON(
+			consts.StatusBadRequest,
+			newOpenAIErrorResponse(consts.StatusBadRequest, schemas.ErrorCodeInvalidRequest, message),
+		)
+
+		return
+	}
+
+	c.AbortWithStatusJSON(consts.StatusBadRequest, newErrorResponse(c, schemas.ErrorCodeInvalidRequest, message))
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers"
+	"glide/pkg/telemetry"
+	"gopkg.in/yaml.v3"
+)
+
+const testMaxBodySize = 512
+
+// newMockRouterManager serves the "myrouter" router by the mock model with the given latency
+func newMockRouterManager(t *testing.T, latency time.Duration) *routers.RouterManager {
+	t.Helper()
+
+	var routersCfg routers.Config
+
+	require.NoError(t, yaml.Unmarshal([]byte(fmt.Sprintf(`
+language:
+  - id: myrouter
+    models:
+      - id: mocked
+        mock:
+          responses: ["Hi there"]
+          latency:
+            mean: %v
+`, latency)), &routersCfg))
+
+	routerManager, err := routers.NewManager(&routersCfg, telemetry.NewTelemetryMock(), nil)
+	require.NoError(t, err)
+
+	return routerManager
+}
+
+func newBodyLimitEngine(t *testing.T) *route.Engine {
+	t.Helper()
+
+	routerManager := newMockRouterManager(t, 0)
+
+	// bodies are streamed the same way ServerConfig.ToServer sets up the server
+	options := config.NewOptions(nil)
+	options.StreamRequestBody = true
+	options.MaxRequestBodySize = testMaxBodySize
+
+	engine := route.NewEngine(options)
+	engine.Use(RequestIDMiddleware(), BodyLimitMiddleware(testMaxBodySize))
+	engine.POST("/v1/language/:router/chat/", LangChatHandler(routerManager))
+	engine.POST(openAIChatCompletionsPath, OpenAIChatCompletionsHandler(routerManager, nil))
+
+	return engine
+}
+
+func chatRequestBody(t *testing.T, model string, content string) []byte {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{
+		"model":    model,
+		"messages": []map[string]string{{"role": "user", "content": content}},
+	})
+	require.NoError(t, err)
+
+	return body
+}
+
+func TestBodyLimitMiddleware_PassesBodiesUnderLimit(t *testing.T) {
+	engine := newBodyLimitEngine(t)
+
+	body := chatRequestBody(t, "", "Hello")
+
+	resp := ut.PerformRequest(
+		engine,
+		consts.MethodPost,
+		"/v1/language/myrouter/chat/",
+		&ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"},
+	).Result()
+
+	require.Equal(t, consts.StatusOK, resp.StatusCode(), string(resp.Body()))
+
+	var chatResponse schemas.UnifiedChatResponse
+
+	require.NoError(t, json.Unmarshal(resp.Body(), &chatResponse))
+	require.Equal(t, "Hi there", chatResponse.ModelResponse.Message.Content)
+}
+
+func TestBodyLimitMiddleware_RejectsOversizedBodies(t *testing.T) {
+	engine := newBodyLimitEngine(t)
+
+	body := chatRequestBody(t, "", strings.Repeat("a", testMaxBodySize))
+
+	tests := map[string]int{
+		"known size":   len(body),
+		"unknown size": -1, // sent in chunks, so the limit is checked while reading
+	}
+
+	for name, bodyLen := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := ut.PerformRequest(
+				engine,
+				consts.MethodPost,
+				"/v1/language/myrouter/chat/",
+				&ut.Body{Body: bytes.NewReader(body), Len: bodyLen},
+				ut.Header{Key: "Content-Type", Value: "application/json"},
+			).Result()
+
+			require.Equal(t, consts.StatusRequestEntityTooLarge, resp.StatusCode())
+
+			var errResponse schemas.ErrorResponse
+
+			require.NoError(t, json.Unmarshal(resp.Body(), &errResponse))
+			require.Equal(t, schemas.ErrorCodeRequestTooLarge, errResponse.Code)
+			require.NotEmpty(t, errResponse.RequestID)
+		})
+	}
+}
+
+func TestBodyLimitMiddleware_RejectsOversizedOpenAIBodies(t *testing.T) {
+	engine := newBodyLimitEngine(t)
+
+	body := chatRequestBody(t, "myrouter", strings.Repeat("a", testMaxBodySize))
+
+	resp := ut.PerformRequest(
+		engine,
+		consts.MethodPost,
+		openAIChatCompletionsPath,
+		&ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"},
+	).Result()
+
+	require.Equal(t, consts.StatusRequestEntityTooLarge, resp.StatusCode())
+
+	var errResponse schemas.OpenAIErrorResponse
+
+	require.NoError(t, json.Unmarshal(resp.Body(), &errResponse))
+	require.Equal(t, schemas.ErrorCodeRequestTooLarge, errResponse.Error.Code)
+	require.Equal(t, "invalid_request_error", errResponse.Error.Type)
+}
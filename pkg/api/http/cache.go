@@ -0,0 +1,52 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"glide/pkg/api/schemas"
+)
+
+const (
+	CacheStatusHeader = "X-Glide-Cache"
+	// SemanticCacheHeader set to "off" makes the router serve only exact cache matches
+	SemanticCacheHeader = "X-Glide-Semantic-Cache"
+	// IdempotencyKeyHeader makes the router replay the response to requests repeated with the same key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	cacheHit             = "HIT"
+	cacheSemanticHit     = "SEMANTIC-HIT" // the response was cached for a similar prompt
+	cacheMiss            = "MISS"
+)
+
+// noCache checks if the client asked to skip & refresh the cached response via the Cache-Control header
+func noCache(c *app.RequestContext) bool {
+	for _, directive := range strings.Split(string(c.GetHeader("Cache-Control")), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// noSemanticCache checks if the client asked to skip the semantic cache
+func noSemanticCache(c *app.RequestContext) bool {
+	return strings.EqualFold(strings.TrimSpace(string(c.GetHeader(SemanticCacheHeader))), "off")
+}
+
+// idempotencyKey returns the key the client has given to the request to make its retries safe
+func idempotencyKey(c *app.RequestContext) string {
+	return strings.TrimSpace(string(c.GetHeader(IdempotencyKeyHeader)))
+}
+
+// setCacheStatus tells the client whether the response was served from the router cache
+func setCacheStatus(c *app.RequestContext, resp *schemas.UnifiedChatResponse) {
+	switch {
+	case resp.SemanticCache != nil:
+		c.Header(CacheStatusHeader, cacheSemanticHit)
+	case resp.Cached:
+		c.Header(CacheStatusHeader, cacheHit)
+	default:
+		c.Header(CacheStatusHeader, cacheMiss)
+	}
+}
@@ -1,9 +1,12 @@
 package http
 
 import (
+	"crypto/tls"
 	"fmt"
 	"time"
 
+	"github.com/cloudwego/hertz/pkg/network/standard"
+
 	"github.com/cloudwego/hertz/pkg/common/config"
 
 	"github.com/cloudwego/hertz/pkg/app/server"
@@ -11,12 +14,58 @@ import (
 )
 
 type ServerConfig struct {
-	Host               string         `yaml:"host"`
-	Port               int            `yaml:"port"`
-	ReadTimeout        *time.Duration `yaml:"read_timeout"`
-	WriteTimeout       *time.Duration `yaml:"write_timeout"`
-	IdleTimeout        *time.Duration `yaml:"idle_timeout"`
-	MaxRequestBodySize *int           `yaml:"max_request_body_size"`
+	Host               string                `yaml:"host"`
+	Port               int                   `yaml:"port"`
+	ReadTimeout        *time.Duration        `yaml:"read_timeout"`
+	WriteTimeout       *time.Duration        `yaml:"write_timeout"`
+	IdleTimeout        *time.Duration        `yaml:"idle_timeout"`
+	MaxRequestBodySize *int                  `yaml:"max_request_body_size" validate:"omitempty,min=1"` // Max request body size in bytes. Bigger bodies are rejected with the request_too_large error (413) while reading, before being buffered or decoded
+	TLS                *TLSConfig            `yaml:"tls,omitempty"`
+	HealthListener     *HealthListenerConfig `yaml:"health_listener,omitempty"` // plaintext listener serving health checks only
+	OpenAICompat       *OpenAICompatConfig   `yaml:"openai_compat,omitempty"`
+	Streaming          *StreamingConfig      `yaml:"streaming" validate:"required"`
+	Shutdown           *ShutdownConfig       `yaml:"shutdown" validate:"required"`
+	AccessLog          *AccessLogConfig      `yaml:"access_log,omitempty"` // one structured log line per request (disabled by default)
+	CORS               *CORSConfig           `yaml:"cors,omitempty"`       // let browser apps call the API from other origins (disabled by default)
+	Admin              *AdminConfig          `yaml:"admin,omitempty"`      // operational endpoints like /admin/state (disabled by default)
+}
+
+// OpenAICompatConfig defines how the OpenAI-compatible endpoint (/v1/chat/completions) picks routers
+type OpenAICompatConfig struct {
+	// Models maps model names clients send to router IDs. Unmapped model names are taken as router IDs
+	Models map[string]string `yaml:"models,omitempty"`
+}
+
+// RouterID returns the router that serves requests to the model
+func (cfg *OpenAICompatConfig) RouterID(model string) string {
+	if cfg != nil {
+		if routerID, found := cfg.Models[model]; found {
+			return routerID
+		}
+	}
+
+	return model
+}
+
+// HealthListenerConfig defines a plaintext listener that serves the health endpoint only.
+// It's useful for in-pod probes when the main API server terminates TLS
+type HealthListenerConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port" validate:"required"`
+}
+
+func (cfg *HealthListenerConfig) Address() string {
+	return fmt.Sprintf("%s:%v", cfg.Host, cfg.Port)
+}
+
+func (cfg *HealthListenerConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*cfg = HealthListenerConfig{
+		Host: "127.0.0.1",
+	}
+
+	type plain HealthListenerConfig // to avoid recursion
+
+	return unmarshal((*plain)(cfg))
 }
 
 func DefaultServerConfig() *ServerConfig {
@@ -32,6 +81,8 @@ func DefaultServerConfig() *ServerConfig {
 		ReadTimeout:        &readTimeout,
 		WriteTimeout:       &writeTimeout,
 		MaxRequestBodySize: &maxReqBodySize,
+		Streaming:          DefaultStreamingConfig(),
+		Shutdown:           DefaultShutdownConfig(),
 	}
 }
 
@@ -39,11 +90,44 @@ func (cfg *ServerConfig) Address() string {
 	return fmt.Sprintf("%s:%v", cfg.Host, cfg.Port)
 }
 
-func (cfg *ServerConfig) ToServer() *server.Hertz {
+// maxRequestBodySize returns the configured request body size limit or the default one
+func (cfg *ServerConfig) maxRequestBodySize() int {
+	if cfg.MaxRequestBodySize != nil {
+		return *cfg.MaxRequestBodySize
+	}
+
+	return *DefaultServerConfig().MaxRequestBodySize
+}
+
+// maxMessageSize limits the size of messages of streaming connections the same way the request body size is limited
+func (cfg *ServerConfig) maxMessageSize() int {
+	return cfg.maxRequestBodySize()
+}
+
+// Scheme returns the URL scheme the server is reachable by
+func (cfg *ServerConfig) Scheme() string {
+	if cfg.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+func (cfg *ServerConfig) ToServer(tlsConfig *tls.Config) *server.Hertz {
 	// More configs are listed on https://www.cloudwego.io/docs/hertz/tutorials/basic-feature/engine/
 	serverOptions := []config.Option{
 		server.WithHostPorts(cfg.Address()),
-		server.WithTransport(netpoll.NewTransporter),
+	}
+
+	if tlsConfig != nil {
+		// netpoll transport doesn't support TLS, so we have to fall back to the standard one
+		serverOptions = append(
+			serverOptions,
+			server.WithTransport(standard.NewTransporter),
+			server.WithTLS(tlsConfig),
+		)
+	} else {
+		serverOptions = append(serverOptions, server.WithTransport(netpoll.NewTransporter))
 	}
 
 	if cfg.IdleTimeout != nil {
@@ -58,6 +142,24 @@ func (cfg *ServerConfig) ToServer() *server.Hertz {
 		serverOptions = append(serverOptions, server.WithWriteTimeout(*cfg.WriteTimeout))
 	}
 
+	// bodies are streamed, so BodyLimitMiddleware rejects ones over the limit with the unified error
+	// instead of the plain 413 response the server sends when it's the one to enforce the limit
+	serverOptions = append(
+		serverOptions,
+		server.WithStreamBody(true),
+		server.WithMaxRequestBodySize(cfg.maxRequestBodySize()),
+	)
+
+	return server.Default(serverOptions...)
+}
+
+// ToHealthServer creates a plaintext server for the health listener
+func (cfg *ServerConfig) ToHealthServer() *server.Hertz {
+	serverOptions := []config.Option{
+		server.WithHostPorts(cfg.HealthListener.Address()),
+		server.WithTransport(netpoll.NewTransporter),
+	}
+
 	if cfg.MaxRequestBodySize != nil {
 		serverOptions = append(serverOptions, server.WithMaxRequestBodySize(*cfg.MaxRequestBodySize))
 	}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"glide/pkg/api/schemas"
+)
+
+var ErrCORSWildcardCredentials = errors.New("CORS could not allow credentials for all origins (\"*\"), list the allowed origins instead")
+
+// corsExposedHeaders are Glide response headers browser apps could read
+var corsExposedHeaders = strings.Join([]string{
+	RequestIDHeader,
+	CacheStatusHeader,
+	IgnoredParamsHeader,
+	DroppedStopSequencesHeader,
+}, ", ")
+
+// CORSConfig lets browser apps (e.g. playgrounds) call the API from other origins. It's disabled unless configured
+type CORSConfig struct {
+	// AllowedOrigins are origins like "https://app.example.com". "*" allows any origin,
+	// while "https://*.example.com" allows all subdomains of the domain
+	AllowedOrigins   []string      `yaml:"allowed_origins" validate:"required,min=1"`
+	AllowedMethods   []string      `yaml:"allowed_methods,omitempty" validate:"omitempty,min=1"`
+	AllowedHeaders   []string      `yaml:"allowed_headers,omitempty"`
+	MaxAge           time.Duration `yaml:"max_age,omitempty" validate:"gte=0"` // how long browsers could cache preflight responses
+	AllowCredentials bool          `yaml:"allow_credentials,omitempty"`        // let browsers send cookies & auth headers (allowed origins must be listed explicitly)
+}
+
+func DefaultCORSConfig() *CORSConfig {
+	return &CORSConfig{
+		AllowedMethods: []string{consts.MethodGet, consts.MethodPost},
+		AllowedHeaders: []string{
+			"Content-Type",
+			"Authorization",
+			"Cache-Control",
+			RequestIDHeader,
+			SemanticCacheHeader,
+			IdempotencyKeyHeader,
+			SessionHeader,
+		},
+		MaxAge: 10 * time.Minute,
+	}
+}
+
+func (cfg *CORSConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*cfg = *DefaultCORSConfig()
+
+	type plain CORSConfig // to avoid recursion
+
+	return unmarshal((*plain)(cfg))
+}
+
+// Validate checks settings the struct validation could not
+func (cfg *CORSConfig) Validate() error {
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowedOrigins, "*") {
+		return ErrCORSWildcardCredentials
+	}
+
+	return nil
+}
+
+// allowsOrigin checks if the origin matches any of the allowed origins
+func (cfg *CORSConfig) allowsOrigin(origin string) bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		prefix, suffix, wildcard := strings.Cut(allowed, "*")
+
+		if wildcard &&
+			len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+			strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CORSMiddleware answers preflight requests and sets CORS headers on responses to allowed origins.
+// It goes before the rest of handlers, so preflight requests are answered without going further.
+// Browsers don't apply CORS to WebSocket connections, so upgrades from not allowed origins are rejected right away
+func CORSMiddleware(cfg *CORSConfig) app.HandlerFunc {
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge / time.Second))
+	allowsAnyOrigin := slices.Contains(cfg.AllowedOrigins, "*") && !cfg.AllowCredentials
+
+	return func(ctx context.Context, c *app.RequestContext) {
+		origin := string(c.GetHeader("Origin"))
+		if origin == "" {
+			// not a cross-origin request
+			c.Next(ctx)
+
+			return
+		}
+
+		c.Response.Header.Add("Vary", "Origin")
+
+		preflight := string(c.Request.Method()) == consts.MethodOptions && len(c.GetHeader("Access-Control-Request-Method")) > 0
+
+		if !cfg.allowsOrigin(origin) {
+			if preflight || headerHasToken(c, "Upgrade", "websocket") {
+				c.AbortWithStatusJSON(
+					consts.StatusForbidden,
+					newErrorResponse(c, schemas.ErrorCodeInvalidRequest, "the origin is not allowed"),
+				)
+
+				return
+			}
+
+			// browsers block responses without CORS headers
+			c.Next(ctx)
+
+			return
+		}
+
+		if allowsAnyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Response.Header.Add("Vary", "Access-Control-Request-Method")
+			c.Response.Header.Add("Vary", "Access-Control-Request-Headers")
+
+			c.Header("Access-Control-Allow-Methods", allowedMethods)
+			c.Header("Access-Control-Allow-Headers", allowedHeaders)
+			c.Header("Access-Control-Max-Age", maxAge)
+
+			c.AbortWithStatus(consts.StatusNoContent)
+
+			return
+		}
+
+		c.Header("Access-Control-Expose-Headers", corsExposedHeaders)
+
+		c.Next(ctx)
+	}
+}
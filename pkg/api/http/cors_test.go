@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/require"
+)
+
+func newCORSEngine(cfg *CORSConfig) *route.Engine {
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(RequestIDMiddleware(), CORSMiddleware(cfg))
+	engine.POST("/v1/language/:router/chat", func(_ context.Context, c *app.RequestContext) {
+		c.JSON(consts.StatusOK, HealthSchema{Healthy: true})
+	})
+
+	return engine
+}
+
+func TestCORSConfig_AllowsOrigin(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://playground.example.com", "https://*.glide.dev"}
+
+	require.True(t, cfg.allowsOrigin("https://playground.example.com"))
+	require.True(t, cfg.allowsOrigin("https://app.glide.dev"))
+	require.False(t, cfg.allowsOrigin("https://glide.dev"))
+	require.False(t, cfg.allowsOrigin("http://app.glide.dev"))
+	require.False(t, cfg.allowsOrigin("https://evil.com"))
+
+	cfg.AllowedOrigins = []string{"*"}
+	require.True(t, cfg.allowsOrigin("https://evil.com"))
+
+	cfg.AllowCredentials = true
+	require.ErrorIs(t, cfg.Validate(), ErrCORSWildcardCredentials)
+}
+
+func TestCORSMiddleware_AnswersPreflight(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://playground.example.com"}
+	cfg.AllowCredentials = true
+
+	engine := newCORSEngine(cfg)
+
+	resp := ut.PerformRequest(
+		engine,
+		consts.MethodOptions,
+		"/v1/language/myrouter/chat",
+		nil,
+		ut.Header{Key: "Origin", Value: "https://playground.example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: consts.MethodPost},
+	).Result()
+
+	require.Equal(t, consts.StatusNoContent, resp.StatusCode())
+	require.Equal(t, "https://playground.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+	require.Equal(t, "GET, POST", resp.Header.Get("Access-Control-Allow-Methods"))
+	require.Contains(t, resp.Header.Get("Access-Control-Allow-Headers"), IdempotencyKeyHeader)
+	require.Equal(t, "600", resp.Header.Get("Access-Control-Max-Age"))
+
+	resp = ut.PerformRequest(
+		engine,
+		consts.MethodOptions,
+		"/v1/language/myrouter/chat",
+		nil,
+		ut.Header{Key: "Origin", Value: "https://evil.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: consts.MethodPost},
+	).Result()
+
+	require.Equal(t, consts.StatusForbidden, resp.StatusCode())
+	require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_SetsHeadersOnResponses(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"*"}
+
+	engine := newCORSEngine(cfg)
+
+	resp := ut.PerformRequest(
+		engine,
+		consts.MethodPost,
+		"/v1/language/myrouter/chat",
+		nil,
+		ut.Header{Key: "Origin", Value: "https://playground.example.com"},
+	).Result()
+
+	require.Equal(t, consts.StatusOK, resp.StatusCode())
+	require.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+	require.Contains(t, resp.Header.Get("Access-Control-Expose-Headers"), RequestIDHeader)
+
+	// same-origin requests are left as is
+	resp = ut.PerformRequest(engine, consts.MethodPost, "/v1/language/myrouter/chat", nil).Result()
+
+	require.Equal(t, consts.StatusOK, resp.StatusCode())
+	require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_RejectsWebSocketsFromOtherOrigins(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://playground.example.com"}
+
+	engine := newCORSEngine(cfg)
+
+	resp := ut.PerformRequest(
+		engine,
+		consts.MethodGet,
+		"/v1/language/myrouter/chatStream",
+		nil,
+		ut.Header{Key: "Origin", Value: "https://evil.com"},
+		ut.Header{Key: "Connection", Value: "Upgrade"},
+		ut.Header{Key: "Upgrade", Value: "websocket"},
+	).Result()
+
+	require.Equal(t, consts.StatusForbidden, resp.StatusCode())
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/prometheus/client_golang/prometheus"
+	"glide/pkg/api/schemas"
+)
+
+const drainPollInterval = 50 * time.Millisecond
+
+// ShutdownConfig defines how the server drains in-flight requests on shutdown
+type ShutdownConfig struct {
+	// GracePeriod is how long to wait for in-flight requests to finish before cancelling them
+	GracePeriod time.Duration `yaml:"grace_period"`
+	// AbortTimeout is how long to wait for cancelled requests to return after the grace period is over
+	AbortTimeout time.Duration `yaml:"abort_timeout"`
+}
+
+func DefaultShutdownConfig() *ShutdownConfig {
+	return &ShutdownConfig{
+		GracePeriod:  30 * time.Second,
+		AbortTimeout: 2 * time.Second,
+	}
+}
+
+func (c *ShutdownConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultShutdownConfig()
+
+	type plain ShutdownConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// Drainer tracks in-flight requests, so the server could let them finish before shutting down.
+// Once draining has started, the server is not ready anymore and new requests are rejected
+type Drainer struct {
+	draining       atomic.Bool
+	inFlight       atomic.Int64
+	abortCtx       context.Context
+	abort          context.CancelFunc
+	abortedCounter prometheus.Counter
+}
+
+func NewDrainer(abortedCounter prometheus.Counter) *Drainer {
+	abortCtx, abort := context.WithCancel(context.Background())
+
+	return &Drainer{
+		abortCtx:       abortCtx,
+		abort:          abort,
+		abortedCounter: abortedCounter,
+	}
+}
+
+// Ready is false once the server has started draining
+func (d *Drainer) Ready() bool {
+	return !d.draining.Load()
+}
+
+func (d *Drainer) InFlight() int64 {
+	return d.inFlight.Load()
+}
+
+// Middleware rejects new requests during draining and makes in-flight requests cancellable on shutdown
+func (d *Drainer) Middleware() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		d.inFlight.Add(1)
+		defer d.inFlight.Add(-1)
+
+		if d.draining.Load() {
+			c.AbortWithStatusJSON(
+				consts.StatusServiceUnavailable,
+				newErrorResponse(c, schemas.ErrorCodeGatewayUnavailable, "gateway is shutting down"),
+			)
+
+			return
+		}
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stopAbortPropagation := context.AfterFunc(d.abortCtx, cancel)
+
+		c.Next(reqCtx)
+
+		// the abort could come right after the handler has returned, so only requests it has reached while being served count.
+		// Those that have still managed to respond successfully are kept as they are
+		cutOff := !stopAbortPropagation() && c.Response.StatusCode() >= consts.StatusBadRequest
+
+		if cutOff {
+			// the request has been cut off by shutdown, so its actual result doesn't matter anymore
+			d.abortedCounter.Inc()
+
+			c.Response.Reset()
+			c.JSON(consts.StatusServiceUnavailable, newErrorResponse(
+				c,
+				schemas.ErrorCodeGatewayUnavailable,
+				"request has been aborted because gateway is shutting down",
+			))
+		}
+	}
+}
+
+// Track counts the in-flight request that outlives its handler (e.g. a stream over the hijacked connection).
+// The returned context is cancelled once in-flight requests are aborted on shutdown, while the release func
+// must be called when the request is over. It returns false if the server is draining, so the request should be rejected
+func (d *Drainer) Track(ctx context.Context) (context.Context, func(), bool) {
+	if d.draining.Load() {
+		return nil, nil, false
+	}
+
+	d.inFlight.Add(1)
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	stopAbortPropagation := context.AfterFunc(d.abortCtx, cancel)
+
+	return reqCtx, func() {
+		stopAbortPropagation()
+		cancel()
+		d.inFlight.Add(-1)
+	}, true
+}
+
+// Drain flips readiness, waits for in-flight requests to finish up to the grace period,
+// and then cancels the remaining ones. Cancellation of the given context cuts the grace period short
+func (d *Drainer) Drain(ctx context.Context, cfg *ShutdownConfig) (aborted bool) {
+	d.draining.Store(true)
+
+	if d.wait(ctx, cfg.GracePeriod) {
+		return false
+	}
+
+	d.abort()
+
+	// give cancelled requests a chance to respond before closing connections
+	d.wait(context.Background(), cfg.AbortTimeout) //nolint:contextcheck
+
+	return true
+}
+
+// wait returns true when there are no in-flight requests left
+func (d *Drainer) wait(ctx context.Context, timeout time.Duration) bool {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for d.inFlight.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return d.inFlight.Load() == 0
+		case <-ctx.Done():
+			return d.inFlight.Load() == 0
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,269 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+)
+
+// newDrainEngine serves chat requests that block until they are released or cancelled
+func newDrainEngine(drainer *Drainer, release <-chan struct{}) *route.Engine {
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(RequestIDMiddleware())
+
+	engine.GET("/v1/health/", HealthHandler(drainer))
+	engine.POST("/v1/language/:router/chat", drainer.Middleware(), func(ctx context.Context, c *app.RequestContext) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			abortWithError(c, ctx.Err())
+
+			return
+		}
+
+		c.JSON(consts.StatusOK, HealthSchema{Healthy: true})
+	})
+
+	// finishes requests no matter if they have been cancelled
+	engine.POST("/v1/language/:router/chat/stubborn", drainer.Middleware(), func(ctx context.Context, c *app.RequestContext) {
+		<-ctx.Done()
+
+		c.JSON(consts.StatusOK, HealthSchema{Healthy: true})
+	})
+
+	return engine
+}
+
+func newTestDrainer() (*Drainer, prometheus.Counter) {
+	abortedCounter := prometheus.NewCounter(prometheus.CounterOpts{Name: "api_requests_aborted_on_shutdown_total"})
+
+	return NewDrainer(abortedCounter), abortedCounter
+}
+
+// sendChat sends the chat request in background & returns the channel its response is delivered to
+func sendChat(engine *route.Engine) <-chan *protocol.Response {
+	respC := make(chan *protocol.Response, 1)
+
+	go func() {
+		respC <- ut.PerformRequest(engine, consts.MethodPost, "/v1/language/myrouter/chat", nil).Result()
+	}()
+
+	return respC
+}
+
+func requireErrorCode(t *testing.T, resp *protocol.Response, code schemas.ErrorCode) {
+	t.Helper()
+
+	var errResp schemas.ErrorResponse
+
+	require.NoError(t, json.Unmarshal(resp.Body(), &errResp))
+	require.Equal(t, code, errResp.Code)
+}
+
+func TestDrainer_InFlightRequestsFinishInGracePeriod(t *testing.T) {
+	drainer, abortedCounter := newTestDrainer()
+	release := make(chan struct{})
+	engine := newDrainEngine(drainer, release)
+
+	respC := sendChat(engine)
+
+	require.Eventually(t, func() bool { return drainer.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	abortedC := make(chan bool, 1)
+
+	go func() {
+		abortedC <- drainer.Drain(context.Background(), &ShutdownConfig{GracePeriod: 5 * time.Second, AbortTimeout: time.Second})
+	}()
+
+	require.Eventually(t, func() bool { return !drainer.Ready() }, time.Second, time.Millisecond)
+
+	close(release)
+
+	require.Equal(t, consts.StatusOK, (<-respC).StatusCode())
+	require.False(t, <-abortedC)
+	require.Zero(t, drainer.InFlight())
+	require.InDelta(t, 0, testutil.ToFloat64(abortedCounter), 0)
+}
+
+func TestDrainer_AbortsRequestsAfterGracePeriod(t *testing.T) {
+	drainer, abortedCounter := newTestDrainer()
+	engine := newDrainEngine(drainer, make(chan struct{}))
+
+	respC := sendChat(engine)
+
+	require.Eventually(t, func() bool { return drainer.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	aborted := drainer.Drain(context.Background(), &ShutdownConfig{GracePeriod: 10 * time.Millisecond, AbortTimeout: time.Second})
+	require.True(t, aborted)
+
+	resp := <-respC
+	require.Equal(t, consts.StatusServiceUnavailable, resp.StatusCode())
+	requireErrorCode(t, resp, schemas.ErrorCodeGatewayUnavailable)
+
+	require.Zero(t, drainer.InFlight())
+	require.InDelta(t, 1, testutil.ToFloat64(abortedCounter), 0)
+}
+
+func TestDrainer_KeepsResponsesOfRequestsFinishedDespiteAbort(t *testing.T) {
+	drainer, abortedCounter := newTestDrainer()
+	engine := newDrainEngine(drainer, make(chan struct{}))
+
+	respC := make(chan *protocol.Response, 1)
+
+	go func() {
+		respC <- ut.PerformRequest(engine, consts.MethodPost, "/v1/language/myrouter/chat/stubborn", nil).Result()
+	}()
+
+	require.Eventually(t, func() bool { return drainer.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	aborted := drainer.Drain(context.Background(), &ShutdownConfig{GracePeriod: 10 * time.Millisecond, AbortTimeout: time.Second})
+	require.True(t, aborted)
+
+	// the handler has responded successfully, so there is nothing to replace
+	require.Equal(t, consts.StatusOK, (<-respC).StatusCode())
+	require.InDelta(t, 0, testutil.ToFloat64(abortedCounter), 0)
+}
+
+func TestDrainer_RejectsNewRequestsWhileDraining(t *testing.T) {
+	drainer, abortedCounter := newTestDrainer()
+	release := make(chan struct{})
+	engine := newDrainEngine(drainer, release)
+
+	health := ut.PerformRequest(engine, consts.MethodGet, "/v1/health/", nil).Result()
+	require.Equal(t, consts.StatusOK, health.StatusCode())
+
+	// the in-flight request keeps the drain going
+	respC := sendChat(engine)
+
+	require.Eventually(t, func() bool { return drainer.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	drainedC := make(chan bool, 1)
+
+	go func() {
+		drainedC <- drainer.Drain(context.Background(), &ShutdownConfig{GracePeriod: 5 * time.Second, AbortTimeout: time.Second})
+	}()
+
+	require.Eventually(t, func() bool { return !drainer.Ready() }, time.Second, time.Millisecond)
+
+	// readiness flips, so load balancers stop sending traffic
+	health = ut.PerformRequest(engine, consts.MethodGet, "/v1/health/", nil).Result()
+	require.Equal(t, consts.StatusServiceUnavailable, health.StatusCode())
+
+	// requests that still come are rejected before reaching handlers
+	resp := ut.PerformRequest(engine, consts.MethodPost, "/v1/language/myrouter/chat", nil).Result()
+	require.Equal(t, consts.StatusServiceUnavailable, resp.StatusCode())
+	requireErrorCode(t, resp, schemas.ErrorCodeGatewayUnavailable)
+
+	_, _, tracked := drainer.Track(context.Background())
+	require.False(t, tracked)
+
+	close(release)
+
+	require.Equal(t, consts.StatusOK, (<-respC).StatusCode())
+	require.False(t, <-drainedC)
+	require.InDelta(t, 0, testutil.ToFloat64(abortedCounter), 0)
+}
+
+func TestDrainer_TracksRequestsOutlivingHandlers(t *testing.T) {
+	drainer, _ := newTestDrainer()
+
+	streamCtx, release, tracked := drainer.Track(context.Background())
+	require.True(t, tracked)
+	require.Equal(t, int64(1), drainer.InFlight())
+
+	aborted := drainer.Drain(context.Background(), &ShutdownConfig{GracePeriod: 10 * time.Millisecond, AbortTimeout: 10 * time.Millisecond})
+	require.True(t, aborted)
+
+	// the stream is cancelled, so it could wind down
+	require.ErrorIs(t, streamCtx.Err(), context.Canceled)
+
+	release()
+	require.Zero(t, drainer.InFlight())
+}
+
+func TestServer_ClosesListenerWhenDrainingStarts(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	cfg := DefaultServerConfig()
+	cfg.Port = port
+
+	srv, err := NewServer(cfg, telemetry.NewTelemetryMock(), newMockRouterManager(t, time.Second))
+	require.NoError(t, err)
+
+	go func() { _ = srv.Run() }()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", cfg.Address())
+		if err != nil {
+			return false
+		}
+
+		_ = conn.Close()
+
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	respC := make(chan *http.Response, 1)
+
+	go func() {
+		resp, err := http.Post(
+			fmt.Sprintf("http://%v/v1/language/myrouter/chat/", cfg.Address()),
+			"application/json",
+			strings.NewReader(`{"message": {"role": "user", "content": "Hello"}}`),
+		)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+
+		respC <- resp
+	}()
+
+	require.Eventually(t, func() bool { return srv.drainer.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	shutdownC := make(chan error, 1)
+
+	go func() {
+		shutdownC <- srv.Shutdown(context.Background())
+	}()
+
+	// new connections are refused while the in-flight request is still being served
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", cfg.Address())
+		if err != nil {
+			return true
+		}
+
+		_ = conn.Close()
+
+		return false
+	}, 500*time.Millisecond, 10*time.Millisecond)
+
+	require.Equal(t, int64(1), srv.drainer.InFlight())
+
+	resp := <-respC
+	require.NotNil(t, resp)
+	require.Equal(t, consts.StatusOK, resp.StatusCode)
+
+	require.NoError(t, <-shutdownC)
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"glide/pkg/api/schemas"
+)
+
+// msgPackMediaTypes are media types clients could accept MessagePack responses by
+var msgPackMediaTypes = []string{schemas.MsgPackContentType, "application/x-msgpack"}
+
+// acceptsMsgPack checks if the Accept header asks for MessagePack. Media types with zero quality are refused ones
+func acceptsMsgPack(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		if !slices.Contains(msgPackMediaTypes, mediaType) {
+			continue
+		}
+
+		return quality(params) > 0
+	}
+
+	return false
+}
+
+// quality returns the q parameter of the media range (1 if it's not set or malformed)
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(name, "q") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 1
+		}
+
+		return q
+	}
+
+	return 1
+}
+
+// respond encodes the response as MessagePack if the client accepts it, otherwise it falls back to JSON
+func respond(c *app.RequestContext, status int, obj interface{}) {
+	if acceptsMsgPack(string(c.GetHeader("Accept"))) {
+		if payload, err := schemas.MarshalMsgPack(obj); err == nil {
+			c.Data(status, schemas.MsgPackContentType, payload)
+
+			return
+		}
+	}
+
+	c.JSON(status, obj)
+}
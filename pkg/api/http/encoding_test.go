@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+)
+
+func TestAcceptsMsgPack(t *testing.T) {
+	tests := map[string]bool{
+		"":                                      false,
+		"*/*":                                   false,
+		"application/json":                      false,
+		"application/msgpack":                   true,
+		"application/x-msgpack":                 true,
+		"Application/MsgPack":                   true,
+		"application/json, application/msgpack": true,
+		"application/msgpack;q=0.5, */*;q=0.1":  true,
+		"application/msgpack;q=0, application/json": false,
+	}
+
+	for accept, expected := range tests {
+		require.Equal(t, expected, acceptsMsgPack(accept), accept)
+	}
+}
+
+func TestRespond_NegotiatesEncoding(t *testing.T) {
+	resp := &schemas.UnifiedChatResponse{
+		ID:            "rsp0001",
+		ModelResponse: schemas.ProviderResponse{Message: schemas.ChatMessage{Role: "assistant", Content: "Hello"}},
+	}
+
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.GET("/chat", func(_ context.Context, c *app.RequestContext) {
+		respond(c, consts.StatusOK, resp)
+	})
+
+	result := ut.PerformRequest(engine, consts.MethodGet, "/chat", nil, ut.Header{Key: "Accept", Value: "application/msgpack"}).Result()
+	require.Equal(t, consts.StatusOK, result.StatusCode())
+	require.Equal(t, schemas.MsgPackContentType, string(result.Header.ContentType()))
+
+	var decoded schemas.UnifiedChatResponse
+
+	require.NoError(t, schemas.UnmarshalMsgPack(result.Body(), &decoded))
+	require.Equal(t, resp, &decoded)
+
+	result = ut.PerformRequest(engine, consts.MethodGet, "/chat", nil, ut.Header{Key: "Accept", Value: "application/json"}).Result()
+	require.Equal(t, consts.StatusOK, result.StatusCode())
+	require.Contains(t, string(result.Header.ContentType()), "application/json")
+	require.JSONEq(t, `{"id": "rsp0001", "modelResponse": {"message": {"role": "assistant", "content": "Hello"}, "tokenCount": {"promptTokens": 0, "responseTokens": 0, "totalTokens": 0}}}`, string(result.Body()))
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+)
+
+// ErrorReportingMiddleware sends unexpected internal errors to the error tracker (panics are reported by RecoveryMiddleware).
+// Errors caused by clients (e.g. invalid requests) or by unavailable providers are not reported
+func ErrorReportingMiddleware(tel *telemetry.Telemetry) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		c.Next(ctx)
+
+		lastErr := c.Errors.Last()
+		if lastErr == nil {
+			return
+		}
+
+		if _, code := errorStatus(lastErr.Err); code == schemas.ErrorCodeInternalError {
+			tel.Errors.CaptureError(ctx, lastErr.Err, newErrorContext(c))
+		}
+	}
+}
+
+func newErrorContext(c *app.RequestContext) *telemetry.ErrorContext {
+	errCtx := &telemetry.ErrorContext{
+		RequestID: RequestID(c),
+		Method:    string(c.Request.Method()),
+		Path:      string(c.Request.URI().Path()),
+	}
+
+	if routerID := c.Param("router"); routerID != "" {
+		errCtx.Tags = map[string]string{"router": routerID}
+	}
+
+	return errCtx
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers"
+	"glide/pkg/routers/cache"
+	"glide/pkg/routers/prompts"
+	"glide/pkg/routers/routing"
+)
+
+// errorStatus maps errors to the HTTP status and the error code they should be reported with
+func errorStatus(err error) (int, schemas.ErrorCode) {
+	var rateLimitErr *clients.RateLimitError
+
+	var routerRateLimitErr *routers.RateLimitedError
+
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, routers.ErrRequestRejected):
+		// a hook has refused to serve the request (e.g. it mentions a blocked keyword)
+		return consts.StatusForbidden, schemas.ErrorCodeRequestRejected
+	case errors.Is(err, routers.ErrResponseRejected):
+		// a hook has refused to return the model response (e.g. it matches a banned phrase)
+		return consts.StatusForbidden, schemas.ErrorCodeResponseRejected
+	case errors.Is(err, routers.ErrContentPolicyViolation):
+		// the moderation has flagged user messages
+		return consts.StatusBadRequest, schemas.ErrorCodeContentPolicy
+	case errors.Is(err, routers.ErrModerationUnavailable):
+		return consts.StatusServiceUnavailable, schemas.ErrorCodeGatewayUnavailable
+	case errors.Is(err, providers.ErrUnsupportedParams):
+		return consts.StatusBadRequest, schemas.ErrorCodeUnsupportedParams
+	case errors.Is(err, prompts.ErrTemplateRendering), errors.Is(err, prompts.ErrPromptNotFound), errors.Is(err, prompts.ErrPromptVarMissing):
+		// e.g. the request misses variables the template refers to
+		return consts.StatusBadRequest, schemas.ErrorCodeInvalidRequest
+	case errors.Is(err, routers.ErrContextLengthExceeded):
+		return consts.StatusRequestEntityTooLarge, schemas.ErrorCodeContextTooLong
+	case errors.Is(err, routers.ErrRequestTooLarge):
+		return consts.StatusRequestEntityTooLarge, schemas.ErrorCodeRequestTooLarge
+	case errors.As(err, &routerRateLimitErr):
+		// all router models have run into rate limits, so clients should come back after the soonest reset (see setRetryAfter)
+		return consts.StatusTooManyRequests, schemas.ErrorCodeProviderRateLimited
+	case errors.Is(err, routers.ErrNoModelAvailable), errors.Is(err, routing.ErrNoHealthyModels):
+		// exhausted routers wrap the last model failure, so they are matched before the failure itself.
+		// Otherwise, the status would depend on which model has happened to fail last (e.g. a rate limit after timeouts)
+		return consts.StatusServiceUnavailable, schemas.ErrorCodeNoHealthyModels
+	case errors.Is(err, clients.ErrCapabilityNotSupported):
+		// no model of the router could serve the request (e.g. it has images, but all models are text-only)
+		return consts.StatusUnprocessableEntity, schemas.ErrorCodeMissingCapability
+	case errors.Is(err, cache.ErrIdempotencyKeyReused):
+		return consts.StatusConflict, schemas.ErrorCodeIdempotencyConflict
+	case errors.Is(err, routers.ErrRouterNotFound):
+		return consts.StatusNotFound, schemas.ErrorCodeRouterNotFound
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return consts.StatusGatewayTimeout, schemas.ErrorCodeTimeout
+	case errors.As(err, &rateLimitErr):
+		return consts.StatusTooManyRequests, schemas.ErrorCodeProviderRateLimited
+	case errors.Is(err, clients.ErrStreamInterrupted):
+		// the model has dropped the stream mid-response
+		return consts.StatusBadGateway, schemas.ErrorCodeStreamInterrupted
+	default:
+		return consts.StatusInternalServerError, schemas.ErrorCodeInternalError
+	}
+}
+
+// newErrorResponse builds the error response for the request
+func newErrorResponse(c *app.RequestContext, code schemas.ErrorCode, message string) schemas.ErrorResponse {
+	return schemas.ErrorResponse{
+		Code:      code,
+		Message:   message,
+		RequestID: RequestID(c),
+	}
+}
+
+// abortWithError responds with the error shape and the status derived from the error.
+// The error is attached to the request context, so ErrorReportingMiddleware could report it
+func abortWithError(c *app.RequestContext, err error) {
+	status, code := errorStatus(err)
+
+	_ = c.Error(err)
+	setRetryAfter(c, err)
+
+	c.AbortWithStatusJSON(status, newErrorResponse(c, code, err.Error()))
+}
+
+// setRetryAfter tells clients of rate limited routers when the soonest rate limit resets (in whole seconds rounded up)
+func setRetryAfter(c *app.RequestContext, err error) {
+	var rateLimitedErr *routers.RateLimitedError
+
+	if !errors.As(err, &rateLimitedErr) || rateLimitedErr.UntilReset <= 0 {
+		return
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitedErr.UntilReset.Seconds()))))
+}
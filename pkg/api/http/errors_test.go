@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers"
+	"glide/pkg/routers/cache"
+	"glide/pkg/routers/prompts"
+	"glide/pkg/routers/routing"
+)
+
+// timeoutErr is a network error of the request that has timed out
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestErrorStatus(t *testing.T) {
+	rateLimitErr := clients.NewRateLimitError(nil)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   schemas.ErrorCode
+	}{
+		{"rejected request", routers.ErrRequestRejected, consts.StatusForbidden, schemas.ErrorCodeRequestRejected},
+		{"rejected response", routers.ErrResponseRejected, consts.StatusForbidden, schemas.ErrorCodeResponseRejected},
+		{"content policy", routers.ErrContentPolicyViolation, consts.StatusBadRequest, schemas.ErrorCodeContentPolicy},
+		{"moderation unavailable", routers.ErrModerationUnavailable, consts.StatusServiceUnavailable, schemas.ErrorCodeGatewayUnavailable},
+		{"unsupported params", fmt.Errorf("%w: logprobs", providers.ErrUnsupportedParams), consts.StatusBadRequest, schemas.ErrorCodeUnsupportedParams},
+		{"unknown prompt", prompts.ErrPromptNotFound, consts.StatusBadRequest, schemas.ErrorCodeInvalidRequest},
+		{"context too long", routers.ErrContextLengthExceeded, consts.StatusRequestEntityTooLarge, schemas.ErrorCodeContextTooLong},
+		{"request too large", routers.ErrRequestTooLarge, consts.StatusRequestEntityTooLarge, schemas.ErrorCodeRequestTooLarge},
+		{"no capable model", routers.ErrNoCapableModel, consts.StatusUnprocessableEntity, schemas.ErrorCodeMissingCapability},
+		{"idempotency key reused", cache.ErrIdempotencyKeyReused, consts.StatusConflict, schemas.ErrorCodeIdempotencyConflict},
+		{"router not found", routers.ErrRouterNotFound, consts.StatusNotFound, schemas.ErrorCodeRouterNotFound},
+		{"request timeout", fmt.Errorf("%w: %w", routers.ErrRequestTimeout, context.DeadlineExceeded), consts.StatusGatewayTimeout, schemas.ErrorCodeTimeout},
+		{"network timeout", timeoutErr{}, consts.StatusGatewayTimeout, schemas.ErrorCodeTimeout},
+		{"rate limit", rateLimitErr, consts.StatusTooManyRequests, schemas.ErrorCodeProviderRateLimited},
+		{"stream interrupted", clients.ErrStreamInterrupted, consts.StatusBadGateway, schemas.ErrorCodeStreamInterrupted},
+		{"no healthy models", routing.ErrNoHealthyModels, consts.StatusServiceUnavailable, schemas.ErrorCodeNoHealthyModels},
+		{"exhausted router", routers.ErrNoModelAvailable, consts.StatusServiceUnavailable, schemas.ErrorCodeNoHealthyModels},
+		{"router rate limited", &routers.RateLimitedError{RouterID: "myrouter", UntilReset: time.Second}, consts.StatusTooManyRequests, schemas.ErrorCodeProviderRateLimited},
+		// exhausted routers wrap the last model failure, but the status doesn't depend on it (e.g. rate limits after other failures)
+		{"exhausted with the last rate limit", fmt.Errorf("%w: %w", routers.ErrNoModelAvailable, rateLimitErr), consts.StatusServiceUnavailable, schemas.ErrorCodeNoHealthyModels},
+		{"exhausted by deadline", fmt.Errorf("%w: %w", routers.ErrNoModelAvailable, context.DeadlineExceeded), consts.StatusServiceUnavailable, schemas.ErrorCodeNoHealthyModels},
+		{"exhausted by network timeout", fmt.Errorf("%w: %w", routers.ErrNoModelAvailable, timeoutErr{}), consts.StatusServiceUnavailable, schemas.ErrorCodeNoHealthyModels},
+		{"exhausted by capability", fmt.Errorf("%w: %w", routers.ErrNoModelAvailable, clients.ErrCapabilityNotSupported), consts.StatusServiceUnavailable, schemas.ErrorCodeNoHealthyModels},
+		{"unknown", errors.New("boom"), consts.StatusInternalServerError, schemas.ErrorCodeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := errorStatus(tt.err)
+
+			require.Equal(t, tt.status, status)
+			require.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestAbortWithError_RetryAfterOfRateLimitedRouters(t *testing.T) {
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.GET("/limited", func(_ context.Context, c *app.RequestContext) {
+		abortWithError(c, &routers.RateLimitedError{RouterID: "myrouter", UntilReset: 1500 * time.Millisecond})
+	})
+	engine.GET("/exhausted", func(_ context.Context, c *app.RequestContext) {
+		abortWithError(c, routers.ErrNoModelAvailable)
+	})
+
+	resp := ut.PerformRequest(engine, consts.MethodGet, "/limited", nil).Result()
+	require.Equal(t, consts.StatusTooManyRequests, resp.StatusCode())
+	require.Equal(t, "2", string(resp.Header.Peek("Retry-After")))
+
+	resp = ut.PerformRequest(engine, consts.MethodGet, "/exhausted", nil).Result()
+	require.Equal(t, consts.StatusServiceUnavailable, resp.StatusCode())
+	require.Empty(t, resp.Header.Peek("Retry-After"))
+}
@@ -3,12 +3,15 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"strconv"
 
 	"glide/pkg/api/schemas"
 	"glide/pkg/routers"
+	"glide/pkg/routers/cache"
+	"glide/pkg/telemetry"
 
 	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/adaptor"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
@@ -25,23 +28,47 @@ type Handler = func(ctx context.Context, c *app.RequestContext)
 //	@tags			Language
 //	@Param			router	path	string						true	"Router ID"
 //	@Param			payload	body	schemas.UnifiedChatRequest	true	"Request Data"
+//	@Param			Idempotency-Key	header	string						false	"Replays the response to requests repeated with the same key"
+//	@Param			X-Glide-Session	header	string						false	"Pins requests of the same session to the same model"
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/msgpack
 //	@Success		200	{object}	schemas.UnifiedChatResponse
-//	@Failure		400	{object}	http.ErrorSchema
-//	@Failure		404	{object}	http.ErrorSchema
+//	@Failure		400	{object}	schemas.ErrorResponse
+//	@Failure		403	{object}	schemas.ErrorResponse
+//	@Failure		404	{object}	schemas.ErrorResponse
+//	@Failure		409	{object}	schemas.ErrorResponse
+//	@Failure		413	{object}	schemas.ErrorResponse
+//	@Failure		422	{object}	schemas.ErrorResponse
+//	@Failure		429	{object}	schemas.ErrorResponse
+//	@Failure		500	{object}	schemas.ErrorResponse
+//	@Failure		503	{object}	schemas.ErrorResponse
+//	@Failure		504	{object}	schemas.ErrorResponse
 //	@Router			/v1/language/{router}/chat [POST]
 func LangChatHandler(routerManager *routers.RouterManager) Handler {
 	return func(ctx context.Context, c *app.RequestContext) {
+		// Get router ID from path
+		routerID := c.Param("router")
+		router, err := routerManager.GetLangRouter(routerID)
+		if err != nil {
+			abortWithError(c, err)
+
+			return
+		}
+
+		// the body size is checked before decoding, so oversized requests are not parsed
+		if err = router.CheckRequestBodySize(len(c.Request.Body())); err != nil {
+			abortWithError(c, err)
+
+			return
+		}
+
 		// Unmarshal request body
 		var req *schemas.UnifiedChatRequest
 
-		err := json.Unmarshal(c.Request.Body(), &req)
+		err = json.Unmarshal(c.Request.Body(), &req)
 		if err != nil {
 			// Return bad request error
-			c.JSON(consts.StatusBadRequest, ErrorSchema{
-				Message: err.Error(),
-			})
+			c.JSON(consts.StatusBadRequest, newErrorResponse(c, schemas.ErrorCodeInvalidRequest, err.Error()))
 
 			return
 		}
@@ -50,42 +77,160 @@ func LangChatHandler(routerManager *routers.RouterManager) Handler {
 		err = c.BindJSON(&req)
 		if err != nil {
 			// Return bad request error
-			c.JSON(consts.StatusBadRequest, ErrorSchema{
-				Message: err.Error(),
-			})
+			c.JSON(consts.StatusBadRequest, newErrorResponse(c, schemas.ErrorCodeInvalidRequest, err.Error()))
 
 			return
 		}
 
-		// Get router ID from path
+		if err = req.Validate(); err != nil {
+			c.JSON(consts.StatusBadRequest, newErrorResponse(c, schemas.ErrorCodeInvalidRequest, err.Error()))
+
+			return
+		}
+
+		if noCache(c) {
+			ctx = cache.WithRefresh(ctx)
+		}
+
+		if noSemanticCache(c) {
+			ctx = cache.WithoutSemantic(ctx)
+		}
+
+		if key := idempotencyKey(c); key != "" {
+			ctx = cache.WithIdempotencyKey(ctx, key)
+		}
+
+		applySession(c, req)
+		setAccessLogUser(c, req)
+		checkRouteHint(c, router, req)
+
+		// Chat with router
+		resp, err := router.Chat(ctx, req)
+		if err != nil {
+			abortWithError(c, err)
+
+			return
+		}
+
+		setAccessLogResponse(c, resp)
+		setDroppedStopSequences(c, resp)
+
+		if router.Config.Cache != nil {
+			setCacheStatus(c, resp)
+		}
+
+		// Return chat response in the format the client accepts
+		respond(c, consts.StatusOK, resp)
+	}
+}
+
+// LangChatBatchHandler
+//
+//	@id				glide-language-chat-batch
+//	@Summary		Language Chat Batch
+//	@Description	Serve a batch of chat requests concurrently. Results keep the order of requests, and each one has either the response or the error
+//	@tags			Language
+//	@Param			router			path	string						true	"Router ID"
+//	@Param			maxConcurrency	query	int							false	"Max number of requests served at the same time (one per router model by default)"
+//	@Param			payload			body	[]schemas.UnifiedChatRequest	true	"Request Data"
+//	@Param			X-Glide-Session	header	string						false	"Pins requests of the same session to the same model"
+//	@Accept			json
+//	@Produce		json,application/msgpack
+//	@Success		200	{array}		schemas.ChatBatchResult
+//	@Failure		400	{object}	schemas.ErrorResponse
+//	@Failure		404	{object}	schemas.ErrorResponse
+//	@Failure		413	{object}	schemas.ErrorResponse
+//	@Router			/v1/language/{router}/chat/batch [POST]
+func LangChatBatchHandler(routerManager *routers.RouterManager) Handler {
+	return func(ctx context.Context, c *app.RequestContext) {
 		routerID := c.Param("router")
 		router, err := routerManager.GetLangRouter(routerID)
+		if err != nil {
+			abortWithError(c, err)
 
-		if errors.Is(err, routers.ErrRouterNotFound) {
-			// Return not found error
-			c.JSON(consts.StatusNotFound, ErrorSchema{
-				Message: err.Error(),
-			})
+			return
+		}
+
+		maxConcurrency := 0
+
+		if param := c.Query("maxConcurrency"); param != "" {
+			maxConcurrency, err = strconv.Atoi(param)
+			if err != nil || maxConcurrency <= 0 {
+				c.JSON(consts.StatusBadRequest, newErrorResponse(c, schemas.ErrorCodeInvalidRequest, "maxConcurrency must be a positive integer"))
+
+				return
+			}
+		}
+
+		var reqs []*schemas.UnifiedChatRequest
+
+		if err = json.Unmarshal(c.Request.Body(), &reqs); err != nil {
+			c.JSON(consts.StatusBadRequest, newErrorResponse(c, schemas.ErrorCodeInvalidRequest, err.Error()))
 
 			return
 		}
 
-		// Chat with router
-		resp, err := router.Chat(ctx, req)
+		if noCache(c) {
+			ctx = cache.WithRefresh(ctx)
+		}
+
+		if noSemanticCache(c) {
+			ctx = cache.WithoutSemantic(ctx)
+		}
+
+		results := make([]schemas.ChatBatchResult, len(reqs))
+		// invalid requests fail on their own, so only valid ones are sent to the router
+		validReqs := make([]*schemas.UnifiedChatRequest, 0, len(reqs))
+		validIdx := make([]int, 0, len(reqs))
+
+		for idx, req := range reqs {
+			if req == nil {
+				results[idx].Error = batchError(c, schemas.ErrorCodeInvalidRequest, "the request is empty")
+
+				continue
+			}
+
+			if err = req.Validate(); err != nil {
+				results[idx].Error = batchError(c, schemas.ErrorCodeInvalidRequest, err.Error())
+
+				continue
+			}
+
+			applySession(c, req)
+
+			validReqs = append(validReqs, req)
+			validIdx = append(validIdx, idx)
+		}
+
+		batchResults, err := router.ChatBatch(ctx, validReqs, maxConcurrency)
 		if err != nil {
-			// Return internal server error
-			c.JSON(consts.StatusInternalServerError, ErrorSchema{
-				Message: err.Error(),
-			})
+			abortWithError(c, err)
 
 			return
 		}
 
-		// Return chat response
-		c.JSON(consts.StatusOK, resp)
+		for idx, result := range batchResults {
+			if result.Err != nil {
+				_, code := errorStatus(result.Err)
+				results[validIdx[idx]].Error = batchError(c, code, result.Err.Error())
+
+				continue
+			}
+
+			results[validIdx[idx]].Response = result.Response
+		}
+
+		respond(c, consts.StatusOK, results)
 	}
 }
 
+// batchError builds the error of the failed request of the batch
+func batchError(c *app.RequestContext, code schemas.ErrorCode, message string) *schemas.ErrorResponse {
+	errResp := newErrorResponse(c, code, message)
+
+	return &errResp
+}
+
 // LangRoutersHandler
 //
 //	@id				glide-language-routers
@@ -118,7 +263,33 @@ func LangRoutersHandler(routerManager *routers.RouterManager) Handler {
 //	@Accept		json
 //	@Produce	json
 //	@Success	200	{object}	http.HealthSchema
+//	@Failure	503	{object}	http.HealthSchema
 //	@Router		/v1/health/ [get]
-func HealthHandler(_ context.Context, c *app.RequestContext) {
-	c.JSON(consts.StatusOK, HealthSchema{Healthy: true})
+func HealthHandler(drainer *Drainer) Handler {
+	return func(_ context.Context, c *app.RequestContext) {
+		if !drainer.Ready() {
+			// the gateway is shutting down, so it should not receive new traffic
+			c.JSON(consts.StatusServiceUnavailable, HealthSchema{Healthy: false})
+
+			return
+		}
+
+		c.JSON(consts.StatusOK, HealthSchema{Healthy: true})
+	}
+}
+
+// MetricsHandler exposes gateway metrics in the Prometheus format
+func MetricsHandler(metrics *telemetry.Metrics) Handler {
+	metricsHandler := metrics.Handler()
+
+	return func(_ context.Context, c *app.RequestContext) {
+		req, err := adaptor.GetCompatRequest(&c.Request)
+		if err != nil {
+			abortWithError(c, err)
+
+			return
+		}
+
+		metricsHandler.ServeHTTP(adaptor.GetCompatResponseWriter(&c.Response), req)
+	}
 }
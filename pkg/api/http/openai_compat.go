@@ -0,0 +1,253 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers"
+	"glide/pkg/routers/cache"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
+)
+
+// IgnoredParamsHeader lists OpenAI request params that have been dropped as Glide can't translate them
+const IgnoredParamsHeader = "X-Glide-Ignored-Params"
+
+// OpenAIChatCompletionsHandler
+//
+//	@id				glide-openai-chat-completions
+//	@Summary		OpenAI-compatible Chat
+//	@Description	Talk to Glide routers via the OpenAI Chat Completions API. The model name is mapped to the router ID.
+//	@Description	Requests with "stream": true are answered with server-sent chat.completion.chunk events ending with "data: [DONE]"
+//	@tags			Language
+//	@Param			payload	body	schemas.OpenAICompatChatRequest	true	"Request Data"
+//	@Param			X-Glide-Session	header	string	false	"Pins requests of the same session to the same model"
+//	@Accept			json
+//	@Produce		json
+//	@Produce		text/event-stream
+//	@Success		200	{object}	schemas.OpenAIChatCompletion
+//	@Failure		400	{object}	schemas.OpenAIErrorResponse
+//	@Failure		403	{object}	schemas.OpenAIErrorResponse
+//	@Failure		404	{object}	schemas.OpenAIErrorResponse
+//	@Failure		413	{object}	schemas.OpenAIErrorResponse
+//	@Failure		422	{object}	schemas.OpenAIErrorResponse
+//	@Failure		429	{object}	schemas.OpenAIErrorResponse
+//	@Failure		500	{object}	schemas.OpenAIErrorResponse
+//	@Failure		503	{object}	schemas.OpenAIErrorResponse
+//	@Failure		504	{object}	schemas.OpenAIErrorResponse
+//	@Router			/v1/chat/completions [POST]
+func OpenAIChatCompletionsHandler(routerManager *routers.RouterManager, cfg *OpenAICompatConfig) Handler {
+	return func(ctx context.Context, c *app.RequestContext) {
+		var openAIReq schemas.OpenAICompatChatRequest
+
+		if err := json.Unmarshal(c.Request.Body(), &openAIReq); err != nil {
+			c.JSON(consts.StatusBadRequest, newOpenAIErrorResponse(consts.StatusBadRequest, schemas.ErrorCodeInvalidRequest, err.Error()))
+
+			return
+		}
+
+		if openAIReq.Model == "" {
+			c.JSON(consts.StatusBadRequest, newOpenAIErrorResponse(consts.StatusBadRequest, schemas.ErrorCodeInvalidRequest, "model is required"))
+
+			return
+		}
+
+		req, err := openAIReq.ToUnifiedRequest()
+		if err == nil {
+			err = req.Validate()
+		}
+
+		if err != nil {
+			c.JSON(consts.StatusBadRequest, newOpenAIErrorResponse(consts.StatusBadRequest, schemas.ErrorCodeInvalidRequest, err.Error()))
+
+			return
+		}
+
+		if len(openAIReq.IgnoredParams) > 0 {
+			c.Header(IgnoredParamsHeader, strings.Join(openAIReq.IgnoredParams, ", "))
+		}
+
+		routerID := cfg.RouterID(openAIReq.Model)
+		setAccessLogRouter(c, routerID)
+
+		router, err := routerManager.GetLangRouter(routerID)
+		if err != nil {
+			abortWithOpenAIError(c, err)
+
+			return
+		}
+
+		if err = router.CheckRequestBodySize(len(c.Request.Body())); err != nil {
+			abortWithOpenAIError(c, err)
+
+			return
+		}
+
+		if noCache(c) {
+			ctx = cache.WithRefresh(ctx)
+		}
+
+		if noSemanticCache(c) {
+			ctx = cache.WithoutSemantic(ctx)
+		}
+
+		applySession(c, req)
+		setAccessLogUser(c, req)
+
+		if openAIReq.Stream {
+			streamOpenAIChatCompletion(ctx, c, router, &openAIReq, req)
+
+			return
+		}
+
+		resp, err := router.Chat(ctx, req)
+		if err != nil {
+			abortWithOpenAIError(c, err)
+
+			return
+		}
+
+		setAccessLogResponse(c, resp)
+		setDroppedStopSequences(c, resp)
+
+		if router.Config.Cache != nil {
+			setCacheStatus(c, resp)
+		}
+
+		c.JSON(consts.StatusOK, schemas.NewOpenAICompatChatCompletion(openAIReq.Model, resp))
+	}
+}
+
+// streamOpenAIChatCompletion sends response chunks to the client as server-sent events like OpenAI does
+func streamOpenAIChatCompletion(
+	ctx context.Context,
+	c *app.RequestContext,
+	router *routers.LangRouter,
+	openAIReq *schemas.OpenAICompatChatRequest,
+	req *schemas.UnifiedChatRequest,
+) {
+	// the upstream stream is released as soon as the client is gone
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	streamC, err := router.ChatStream(ctx, req)
+	if err != nil {
+		abortWithOpenAIError(c, err)
+
+		return
+	}
+
+	c.SetStatusCode(consts.StatusOK)
+	c.SetContentType("text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+
+	if err := writeOpenAIStream(ctx, c, openAIReq.Model, openAIReq.IncludeUsage(), streamC); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// eventWriter sends server-sent events to the client as soon as they are written
+type eventWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// writeOpenAIStream writes stream results as OpenAI chunks. Successful streams end with the [DONE] event.
+// Headers are sent with the first chunk, so the error that interrupts the stream comes as the event in the OpenAI error shape
+func writeOpenAIStream(
+	ctx context.Context,
+	w eventWriter,
+	model string,
+	includeUsage bool,
+	streamC <-chan *schemas.ChatStreamResult,
+) error {
+	var lastChunk *schemas.UnifiedChatStreamChunk
+
+	for result := range streamC {
+		if result.Err != nil {
+			status, code := errorStatus(result.Err)
+
+			return writeEvent(w, newOpenAIErrorResponse(status, code, result.Err.Error()))
+		}
+
+		if err := writeEvent(w, schemas.NewOpenAICompatChatCompletionChunk(model, result.Chunk, lastChunk == nil)); err != nil {
+			// the client is gone
+			return err
+		}
+
+		lastChunk = result.Chunk
+	}
+
+	if ctx.Err() != nil {
+		// the stream was cut short, so it must not look complete
+		return ctx.Err()
+	}
+
+	if includeUsage && lastChunk != nil && lastChunk.ModelResponse.TokenUsage != nil {
+		usageChunk := schemas.NewOpenAICompatUsageChunk(model, lastChunk, lastChunk.ModelResponse.TokenUsage)
+
+		if err := writeEvent(w, usageChunk); err != nil {
+			return err
+		}
+	}
+
+	return writeEventData(w, []byte(schemas.OpenAIStreamDone))
+}
+
+func writeEvent(w eventWriter, event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return writeEventData(w, data)
+}
+
+func writeEventData(w eventWriter, data []byte) error {
+	event := make([]byte, 0, len(data)+8)
+	event = append(event, "data: "...)
+	event = append(event, data...)
+	event = append(event, "\n\n"...)
+
+	if _, err := w.Write(event); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// newOpenAIErrorResponse builds the error in the OpenAI shape, so OpenAI clients could surface its message
+func newOpenAIErrorResponse(status int, code schemas.ErrorCode, message string) schemas.OpenAIErrorResponse {
+	errType := "api_error"
+
+	switch {
+	case status == consts.StatusTooManyRequests:
+		errType = "rate_limit_error"
+	case status < consts.StatusInternalServerError:
+		errType = "invalid_request_error"
+	}
+
+	return schemas.OpenAIErrorResponse{
+		Error: schemas.OpenAIError{
+			Message: message,
+			Type:    errType,
+			Code:    code,
+		},
+	}
+}
+
+// abortWithOpenAIError is abortWithError that responds with the OpenAI error shape
+func abortWithOpenAIError(c *app.RequestContext, err error) {
+	status, code := errorStatus(err)
+
+	_ = c.Error(err)
+	setRetryAfter(c, err)
+
+	c.AbortWithStatusJSON(status, newOpenAIErrorResponse(status, code, err.Error()))
+}
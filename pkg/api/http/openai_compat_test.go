@@ -0,0 +1,132 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+)
+
+type bufferedEventWriter struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (w *bufferedEventWriter) Flush() error {
+	w.flushes++
+
+	return nil
+}
+
+func newStreamResults(results ...*schemas.ChatStreamResult) <-chan *schemas.ChatStreamResult {
+	streamC := make(chan *schemas.ChatStreamResult, len(results))
+
+	for _, result := range results {
+		streamC <- result
+	}
+
+	close(streamC)
+
+	return streamC
+}
+
+func newTextChunk(content string, finishReason string) *schemas.ChatStreamResult {
+	return &schemas.ChatStreamResult{
+		Chunk: &schemas.UnifiedChatStreamChunk{
+			ID:            "rsp0001",
+			Created:       1700000000,
+			ModelResponse: schemas.ProviderChunkResponse{Message: schemas.ChatMessage{Role: schemas.RoleAssistant, Content: content}},
+			FinishReason:  finishReason,
+		},
+	}
+}
+
+// eventsOf splits the SSE stream into data payloads of its events
+func eventsOf(t *testing.T, stream string) []string {
+	t.Helper()
+
+	require.True(t, strings.HasSuffix(stream, "\n\n"))
+
+	var events []string
+
+	for _, event := range strings.Split(strings.TrimSuffix(stream, "\n\n"), "\n\n") {
+		data, found := strings.CutPrefix(event, "data: ")
+		require.True(t, found, event)
+
+		events = append(events, data)
+	}
+
+	return events
+}
+
+func TestWriteOpenAIStream_EndsWithDone(t *testing.T) {
+	var w bufferedEventWriter
+
+	lastChunk := newTextChunk("lo", schemas.FinishReasonStop)
+	lastChunk.Chunk.ModelResponse.TokenUsage = &schemas.TokenUsage{PromptTokens: 3, ResponseTokens: 2, TotalTokens: 5}
+
+	err := writeOpenAIStream(
+		context.Background(),
+		&w,
+		"my-router",
+		true,
+		newStreamResults(newTextChunk("Hel", ""), lastChunk),
+	)
+	require.NoError(t, err)
+
+	events := eventsOf(t, w.String())
+	require.Len(t, events, 4)
+	require.Equal(t, 4, w.flushes)
+
+	// the stream is terminated like OpenAI streams are
+	require.Equal(t, schemas.OpenAIStreamDone, events[3])
+	require.True(t, strings.HasSuffix(w.String(), "data: [DONE]\n\n"))
+
+	var firstChunk schemas.OpenAIChatCompletionChunk
+
+	require.NoError(t, json.Unmarshal([]byte(events[0]), &firstChunk))
+	require.Equal(t, "chat.completion.chunk", firstChunk.Object)
+	require.Equal(t, "my-router", firstChunk.Model)
+	require.Equal(t, schemas.RoleAssistant, firstChunk.Choices[0].Delta.Role)
+	require.Equal(t, "Hel", firstChunk.Choices[0].Delta.Content)
+	require.Nil(t, firstChunk.Choices[0].FinishReason)
+
+	var finalChunk schemas.OpenAIChatCompletionChunk
+
+	require.NoError(t, json.Unmarshal([]byte(events[1]), &finalChunk))
+	require.Empty(t, finalChunk.Choices[0].Delta.Role)
+	require.Equal(t, schemas.FinishReasonStop, *finalChunk.Choices[0].FinishReason)
+
+	var usageChunk schemas.OpenAIChatCompletionChunk
+
+	require.NoError(t, json.Unmarshal([]byte(events[2]), &usageChunk))
+	require.Empty(t, usageChunk.Choices)
+	require.InDelta(t, 5, usageChunk.Usage.TotalTokens, 0)
+}
+
+func TestWriteOpenAIStream_ErrorInterruptsStream(t *testing.T) {
+	var w bufferedEventWriter
+
+	err := writeOpenAIStream(
+		context.Background(),
+		&w,
+		"my-router",
+		false,
+		newStreamResults(newTextChunk("Hel", ""), &schemas.ChatStreamResult{Err: clients.ErrProviderUnavailable}),
+	)
+	require.NoError(t, err)
+
+	events := eventsOf(t, w.String())
+	require.Len(t, events, 2)
+	require.NotContains(t, events, schemas.OpenAIStreamDone)
+
+	var errResp schemas.OpenAIErrorResponse
+
+	require.NoError(t, json.Unmarshal([]byte(events[1]), &errResp))
+	require.Contains(t, errResp.Error.Message, clients.ErrProviderUnavailable.Error())
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+// RecoveryMiddleware catches panics in handlers, so one bad request doesn't take the connection down.
+// Panics are logged with the stack trace, sent to the error tracker and answered with the structured 500 error
+func RecoveryMiddleware(tel *telemetry.Telemetry) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			tel.Logger.Error(
+				"recovered from panic while handling the request",
+				zap.String("requestID", RequestID(c)),
+				zap.String("method", string(c.Request.Method())),
+				zap.String("path", string(c.Request.URI().Path())),
+				zap.Any("panic", recovered),
+				zap.Stack("stack"),
+			)
+
+			tel.Errors.CapturePanic(ctx, recovered, newErrorContext(c))
+
+			// panic details may be sensitive, so they are kept in logs only
+			c.AbortWithStatusJSON(
+				consts.StatusInternalServerError,
+				newErrorResponse(c, schemas.ErrorCodeInternalError, "internal error while handling the request"),
+			)
+		}()
+
+		c.Next(ctx)
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+)
+
+type errorReporterMock struct {
+	telemetry.NoopErrorReporter
+	panics []interface{}
+}
+
+func (r *errorReporterMock) CapturePanic(_ context.Context, recovered interface{}, _ *telemetry.ErrorContext) {
+	r.panics = append(r.panics, recovered)
+}
+
+func TestRecoveryMiddleware_HandlerPanicYieldsInternalError(t *testing.T) {
+	errorReporter := &errorReporterMock{}
+
+	tel := telemetry.NewTelemetryMock()
+	tel.Errors = errorReporter
+
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(RequestIDMiddleware(), RecoveryMiddleware(tel))
+	engine.GET("/panic", func(_ context.Context, _ *app.RequestContext) {
+		panic("something went wrong")
+	})
+
+	resp := ut.PerformRequest(engine, consts.MethodGet, "/panic", nil, ut.Header{Key: RequestIDHeader, Value: "req-123"}).Result()
+
+	require.Equal(t, consts.StatusInternalServerError, resp.StatusCode())
+
+	var errResponse schemas.ErrorResponse
+
+	require.NoError(t, json.Unmarshal(resp.Body(), &errResponse))
+	require.Equal(t, schemas.ErrorCodeInternalError, errResponse.Code)
+	require.Equal(t, "req-123", errResponse.RequestID)
+	require.NotContains(t, errResponse.Message, "something went wrong")
+
+	// the panic is not swallowed silently
+	require.Equal(t, []interface{}{"something went wrong"}, errorReporter.panics)
+
+	// the server keeps serving requests
+	resp = ut.PerformRequest(engine, consts.MethodGet, "/panic", nil).Result()
+	require.Equal(t, consts.StatusInternalServerError, resp.StatusCode())
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// RequestIDMiddleware assigns every request an ID (or keeps the one passed by the client via the X-Request-ID header),
+// so responses & errors can be correlated with gateway logs
+func RequestIDMiddleware() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		requestID := string(c.GetHeader(RequestIDHeader))
+
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next(ctx)
+	}
+}
+
+// RequestID returns the ID assigned to the request by RequestIDMiddleware
+func RequestID(c *app.RequestContext) string {
+	return c.GetString(requestIDKey)
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,16 @@
+package http
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers"
+)
+
+// UnknownRouteHintHeader warns that the router doesn't know the route hint, so the request was routed over all its models
+const UnknownRouteHintHeader = "X-Glide-Unknown-Route-Hint"
+
+func checkRouteHint(c *app.RequestContext, router *routers.LangRouter, req *schemas.UnifiedChatRequest) {
+	if req.RouteHint != "" && !router.HasRouteHint(req.RouteHint) {
+		c.Header(UnknownRouteHintHeader, req.RouteHint)
+	}
+}
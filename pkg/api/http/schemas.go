@@ -2,10 +2,6 @@ package http
 
 import "glide/pkg/routers"
 
-type ErrorSchema struct {
-	Message string `json:"message"`
-}
-
 type HealthSchema struct {
 	Healthy bool `json:"healthy"`
 }
@@ -13,3 +9,8 @@ type HealthSchema struct {
 type RouterListSchema struct {
 	Routers []*routers.LangRouterConfig `json:"routers"`
 }
+
+// AdminStateSchema is the live state of routers & their models
+type AdminStateSchema struct {
+	Routers []routers.RouterState `json:"routers"`
+}
@@ -2,12 +2,15 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
 	"github.com/hertz-contrib/swagger"
 	swaggerFiles "github.com/swaggo/files"
 	_ "glide/docs" // importing docs package to include them into the binary
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
 
 	"glide/pkg/routers"
 
@@ -21,42 +24,187 @@ type Server struct {
 	telemetry     *telemetry.Telemetry
 	routerManager *routers.RouterManager
 	server        *server.Hertz
+	healthServer  *server.Hertz
+	certReloader  *CertReloader
+	drainer       *Drainer
+	accessLogger  *zap.Logger
 }
 
 func NewServer(config *ServerConfig, tel *telemetry.Telemetry, routerManager *routers.RouterManager) (*Server, error) {
-	srv := config.ToServer()
+	if config.CORS != nil {
+		if err := config.CORS.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
-	return &Server{
+	if config.Admin != nil {
+		if err := config.Admin.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	var certReloader *CertReloader
+
+	var tlsConfig *tls.Config
+
+	if config.TLS != nil {
+		reloader, err := NewCertReloader(config.TLS, tel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init TLS: %w", err)
+		}
+
+		certReloader = reloader
+		tlsConfig = reloader.TLSConfig()
+	}
+
+	srv := &Server{
 		config:        config,
 		telemetry:     tel,
 		routerManager: routerManager,
-		server:        srv,
-	}, nil
+		server:        config.ToServer(tlsConfig),
+		certReloader:  certReloader,
+		drainer: NewDrainer(tel.Metrics.Counter(
+			"api_requests_aborted_on_shutdown_total",
+			"Number of in-flight requests cancelled because the shutdown grace period was over",
+		)),
+	}
+
+	if config.AccessLog != nil && config.AccessLog.Enabled {
+		accessLogger, err := NewAccessLogger(config.AccessLog, tel.Config.LogConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init access logger: %w", err)
+		}
+
+		srv.accessLogger = accessLogger
+	}
+
+	if config.HealthListener != nil {
+		srv.healthServer = config.ToHealthServer()
+	}
+
+	return srv, nil
 }
 
 func (srv *Server) Run() error {
+	// the request ID goes first, so panics & errors are reported with it
+	srv.server.Use(RequestIDMiddleware())
+
+	if srv.accessLogger != nil {
+		// access logs go before the recovery, so panicked requests are logged with the final status code
+		srv.server.Use(AccessLogMiddleware(srv.accessLogger))
+	}
+
+	if srv.config.CORS != nil {
+		// preflight requests are answered before reaching handlers
+		srv.server.Use(CORSMiddleware(srv.config.CORS))
+	}
+
+	srv.server.Use(BodyLimitMiddleware(srv.config.maxRequestBodySize()))
+	srv.server.Use(RecoveryMiddleware(srv.telemetry), ErrorReportingMiddleware(srv.telemetry))
+
 	defaultGroup := srv.server.Group("/v1")
 
-	defaultGroup.GET("/language/", LangRoutersHandler(srv.routerManager))
-	defaultGroup.POST("/language/:router/chat/", LangChatHandler(srv.routerManager))
+	langGroup := defaultGroup.Group("/language", srv.drainer.Middleware())
+
+	langGroup.GET("/", LangRoutersHandler(srv.routerManager))
+	langGroup.POST("/:router/chat/", LangChatHandler(srv.routerManager))
+	langGroup.POST("/:router/chat/batch", LangChatBatchHandler(srv.routerManager))
+	langGroup.GET("/:router/chatStream", LangStreamHandler(
+		srv.routerManager,
+		srv.drainer,
+		srv.telemetry,
+		srv.config.Streaming,
+		srv.config.maxMessageSize(),
+	))
 
-	defaultGroup.GET("/health/", HealthHandler)
+	defaultGroup.POST("/chat/completions", srv.drainer.Middleware(), OpenAIChatCompletionsHandler(srv.routerManager, srv.config.OpenAICompat))
 
-	schemaDocURL := swagger.URL(fmt.Sprintf("http://%v/v1/swagger/doc.json", srv.config.Address()))
+	defaultGroup.GET("/health/", HealthHandler(srv.drainer))
+
+	schemaDocURL := swagger.URL(fmt.Sprintf("%v://%v/v1/swagger/doc.json", srv.config.Scheme(), srv.config.Address()))
 	defaultGroup.GET("/swagger/*any", swagger.WrapHandler(swaggerFiles.Handler, schemaDocURL))
 
+	srv.server.GET("/metrics", MetricsHandler(srv.telemetry.Metrics))
+
+	if srv.config.Admin != nil && srv.config.Admin.Enabled {
+		srv.server.GET("/admin/state", AdminAuthMiddleware(srv.config.Admin), AdminStateHandler(srv.routerManager))
+	}
+
+	if srv.certReloader != nil {
+		if err := srv.certReloader.Watch(); err != nil {
+			return err
+		}
+	}
+
+	if srv.healthServer != nil {
+		srv.healthServer.GET("/v1/health/", HealthHandler(srv.drainer))
+
+		go func() {
+			if err := srv.healthServer.Run(); err != nil {
+				srv.telemetry.Logger.Error("health listener has stopped with error", zap.Error(err))
+			}
+		}()
+	}
+
 	return srv.server.Run()
 }
 
-func (srv *Server) Shutdown(_ context.Context) error {
+// Shutdown drains in-flight requests and stops the server.
+// Cancellation of the given context skips waiting for in-flight requests
+func (srv *Server) Shutdown(ctx context.Context) error {
+	srv.telemetry.Logger.Info(
+		"draining in-flight requests",
+		zap.Int64("inFlight", srv.drainer.InFlight()),
+		zap.Duration("gracePeriod", srv.config.Shutdown.GracePeriod),
+	)
+
+	// the listener is closed right away, so new connections go to other instances while in-flight requests are drained.
+	// Open connections are closed once their requests are served
+	stopCtx, stop := context.WithCancel(context.Background()) //nolint:contextcheck
+	defer stop()
+
+	stoppedC := make(chan error, 1)
+
+	go func() {
+		stoppedC <- srv.server.Shutdown(stopCtx) //nolint:contextcheck
+	}()
+
+	if aborted := srv.drainer.Drain(ctx, srv.config.Shutdown); aborted {
+		srv.telemetry.Logger.Warn("in-flight requests were not finished in the grace period and have been aborted")
+	}
+
 	exitWaitTime := srv.server.GetOptions().ExitWaitTimeout
 
 	srv.telemetry.Logger.Info(
 		fmt.Sprintf("Begin graceful shutdown, wait at most %d seconds...", exitWaitTime/time.Second),
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), exitWaitTime)
+	ctx, cancel := context.WithTimeout(context.Background(), exitWaitTime) //nolint:contextcheck
 	defer cancel()
 
-	return srv.server.Shutdown(ctx) //nolint:contextcheck
+	// connections are waited for until the exit wait time is over
+	stopAfterExitWait := context.AfterFunc(ctx, stop)
+	defer stopAfterExitWait()
+
+	if srv.certReloader != nil {
+		srv.certReloader.Stop()
+	}
+
+	var errs error
+
+	if err := <-stoppedC; err != nil {
+		errs = multierr.Append(errs, err)
+	}
+
+	if srv.accessLogger != nil {
+		_ = srv.accessLogger.Sync()
+	}
+
+	if srv.healthServer != nil {
+		if err := srv.healthServer.Shutdown(ctx); err != nil { //nolint:contextcheck
+			errs = multierr.Append(errs, fmt.Errorf("failed to shutdown health listener: %w", err))
+		}
+	}
+
+	return errs
 }
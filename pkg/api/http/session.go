@@ -0,0 +1,20 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"glide/pkg/api/schemas"
+)
+
+// SessionHeader pins requests of the same session to the same model on routers with session affinity
+const SessionHeader = "X-Glide-Session"
+
+// applySession takes the session from the header unless the request body sets it
+func applySession(c *app.RequestContext, req *schemas.UnifiedChatRequest) {
+	if req.SessionID != "" {
+		return
+	}
+
+	req.SessionID = strings.TrimSpace(string(c.GetHeader(SessionHeader)))
+}
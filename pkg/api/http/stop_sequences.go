@@ -0,0 +1,18 @@
+package http
+
+import (
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"glide/pkg/api/schemas"
+)
+
+// DroppedStopSequencesHeader warns that some stop sequences of the request have not been sent to the model,
+// as there were more of them than the provider takes. It holds the number of dropped sequences
+const DroppedStopSequencesHeader = "X-Glide-Dropped-Stop-Sequences"
+
+func setDroppedStopSequences(c *app.RequestContext, resp *schemas.UnifiedChatResponse) {
+	if dropped := resp.ModelResponse.DroppedStopSequences; dropped > 0 {
+		c.Header(DroppedStopSequencesHeader, strconv.Itoa(dropped))
+	}
+}
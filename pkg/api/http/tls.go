@@ -0,0 +1,228 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidClientCA = errors.New("no valid certificates found in the client CA bundle")
+
+// TLSConfig defines TLS termination settings for the API server
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file" validate:"required"` // Path to the PEM-encoded server certificate (chain)
+	KeyFile      string `yaml:"key_file" validate:"required"`  // Path to the PEM-encoded private key of the certificate
+	ClientCAFile string `yaml:"client_ca_file,omitempty"`      // Path to the CA bundle to verify client certificates with (enables mTLS)
+}
+
+// MutualTLS is true when clients are required to present certificates signed by the configured CA
+func (c *TLSConfig) MutualTLS() bool {
+	return c.ClientCAFile != ""
+}
+
+// tlsState is an immutable snapshot of loaded TLS materials
+type tlsState struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// CertReloader loads TLS certificates and reloads them when files change or SIGHUP is received,
+// so certificate rotations don't require a restart
+type CertReloader struct {
+	config    *TLSConfig
+	telemetry *telemetry.Telemetry
+	state     atomic.Pointer[tlsState]
+	watcher   *fsnotify.Watcher
+	signalC   chan os.Signal
+	stopC     chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewCertReloader loads TLS materials from the config. It fails if the cert/key pair or the client CA bundle can't be parsed
+func NewCertReloader(cfg *TLSConfig, tel *telemetry.Telemetry) (*CertReloader, error) {
+	reloader := &CertReloader{
+		config:    cfg,
+		telemetry: tel,
+		signalC:   make(chan os.Signal, 1),
+		stopC:     make(chan struct{}),
+	}
+
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// Reload reads TLS materials from the disk. The previously loaded materials are kept in case of error
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf(
+			"unable to load TLS cert/key pair (cert: %v, key: %v): %w",
+			r.config.CertFile,
+			r.config.KeyFile,
+			err,
+		)
+	}
+
+	state := &tlsState{
+		cert: &cert,
+	}
+
+	if r.config.MutualTLS() {
+		rawCA, err := os.ReadFile(filepath.Clean(r.config.ClientCAFile))
+		if err != nil {
+			return fmt.Errorf("unable to read client CA bundle %v: %w", r.config.ClientCAFile, err)
+		}
+
+		clientCAs := x509.NewCertPool()
+
+		if !clientCAs.AppendCertsFromPEM(rawCA) {
+			return fmt.Errorf("unable to parse client CA bundle %v: %w", r.config.ClientCAFile, ErrInvalidClientCA)
+		}
+
+		state.clientCAs = clientCAs
+	}
+
+	r.state.Store(state)
+
+	return nil
+}
+
+// TLSConfig returns the server TLS config that always serves the most recently loaded materials
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+			state := r.state.Load()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*state.cert},
+			}
+
+			if state.clientCAs != nil {
+				cfg.ClientCAs = state.clientCAs
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+
+			return cfg, nil
+		},
+	}
+}
+
+// Watch starts watching TLS files & SIGHUP signal in background and reloads TLS materials on changes
+func (r *CertReloader) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to init TLS file watcher: %w", err)
+	}
+
+	// watch directories rather than files as rotations are often done by swapping symlinks (e.g. k8s secret volumes)
+	watchedDirs := make(map[string]bool, 3)
+
+	for _, path := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+
+		dir := filepath.Dir(path)
+
+		if watchedDirs[dir] {
+			continue
+		}
+
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+
+			return fmt.Errorf("unable to watch TLS file directory %v: %w", dir, err)
+		}
+
+		watchedDirs[dir] = true
+	}
+
+	r.watcher = watcher
+
+	signal.Notify(r.signalC, syscall.SIGHUP)
+
+	go r.watch()
+
+	return nil
+}
+
+func (r *CertReloader) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if !r.inTLSDir(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			r.reload("file changed")
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			r.telemetry.Logger.Warn("TLS file watcher error", zap.Error(err))
+		case <-r.signalC:
+			r.reload("SIGHUP received")
+		case <-r.stopC:
+			return
+		}
+	}
+}
+
+func (r *CertReloader) reload(reason string) {
+	if err := r.Reload(); err != nil {
+		r.telemetry.Logger.Error(
+			"failed to reload TLS certificates, keep serving the previous ones",
+			zap.String("reason", reason),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	r.telemetry.Logger.Info("TLS certificates reloaded", zap.String("reason", reason))
+}
+
+func (r *CertReloader) inTLSDir(path string) bool {
+	dir := filepath.Clean(filepath.Dir(path))
+
+	for _, tlsFile := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if tlsFile != "" && filepath.Clean(filepath.Dir(tlsFile)) == dir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Stop stops watching for TLS file changes
+func (r *CertReloader) Stop() {
+	r.stopOnce.Do(func() {
+		signal.Stop(r.signalC)
+		close(r.stopC)
+
+		if r.watcher != nil {
+			_ = r.watcher.Close()
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/telemetry"
+)
+
+// testCert is a certificate issued by the test CA (or self-signed if it's the CA itself)
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, commonName string, issuer *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	parent, signer := template, key
+
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, signer = issuer.cert, issuer.key
+	}
+
+	rawCert, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(rawCert)
+	require.NoError(t, err)
+
+	rawKey, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rawCert}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawKey}),
+	}
+}
+
+func writeTLSFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
+
+// writeServerCert writes the cert/key pair to the TLS config files
+func writeServerCert(t *testing.T, cfg *TLSConfig, cert *testCert) {
+	t.Helper()
+
+	writeTLSFile(t, cfg.CertFile, cert.certPEM)
+	writeTLSFile(t, cfg.KeyFile, cert.keyPEM)
+}
+
+func newTestTLSConfig(t *testing.T) *TLSConfig {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	return &TLSConfig{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+	}
+}
+
+// servedCert returns the leaf certificate the reloader serves to new connections
+func servedCert(t *testing.T, reloader *CertReloader) []byte {
+	t.Helper()
+
+	cfg, err := reloader.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Len(t, cfg.Certificates, 1)
+
+	return cfg.Certificates[0].Certificate[0]
+}
+
+// serveTLS accepts connections with the reloader TLS config & echoes a byte back once the handshake is done
+func serveTLS(t *testing.T, reloader *CertReloader) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", reloader.TLSConfig())
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				if err := conn.(*tls.Conn).Handshake(); err != nil {
+					return
+				}
+
+				_, _ = conn.Write([]byte{1})
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// dialTLS completes the handshake & reads the byte the server sends back to it
+func dialTLS(addr string, cfg *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, cfg)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	// TLS 1.3 clients finish the handshake before the server verifies them, so rejections show up on reads
+	_, err = conn.Read(make([]byte, 1))
+
+	return err
+}
+
+func TestNewCertReloader_InvalidPairFails(t *testing.T) {
+	ca := newTestCert(t, "Glide Test CA", nil)
+	cfg := newTestTLSConfig(t)
+
+	// the key doesn't belong to the certificate
+	writeTLSFile(t, cfg.CertFile, newTestCert(t, "localhost", ca).certPEM)
+	writeTLSFile(t, cfg.KeyFile, newTestCert(t, "localhost", ca).keyPEM)
+
+	_, err := NewCertReloader(cfg, telemetry.NewTelemetryMock())
+	require.ErrorContains(t, err, "unable to load TLS cert/key pair")
+
+	// the client CA bundle has no certificates
+	writeServerCert(t, cfg, newTestCert(t, "localhost", ca))
+
+	cfg.ClientCAFile = filepath.Join(filepath.Dir(cfg.CertFile), "client-ca.crt")
+	writeTLSFile(t, cfg.ClientCAFile, []byte("not a certificate"))
+
+	_, err = NewCertReloader(cfg, telemetry.NewTelemetryMock())
+	require.ErrorIs(t, err, ErrInvalidClientCA)
+}
+
+func TestCertReloader_KeepsPreviousCertOnBadReload(t *testing.T) {
+	ca := newTestCert(t, "Glide Test CA", nil)
+	cfg := newTestTLSConfig(t)
+
+	serverCert := newTestCert(t, "localhost", ca)
+	writeServerCert(t, cfg, serverCert)
+
+	reloader, err := NewCertReloader(cfg, telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	require.Equal(t, serverCert.cert.Raw, servedCert(t, reloader))
+
+	// the rotation has been interrupted half way
+	writeTLSFile(t, cfg.CertFile, []byte("-----BEGIN CERTIFICATE-----\ntruncated"))
+	reloader.reload("file changed")
+
+	require.Equal(t, serverCert.cert.Raw, servedCert(t, reloader))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	addr := serveTLS(t, reloader)
+	require.NoError(t, dialTLS(addr, &tls.Config{RootCAs: clientCAs, MinVersion: tls.VersionTLS12}))
+
+	// the rotation is picked up once it's done
+	rotatedCert := newTestCert(t, "localhost", ca)
+	writeServerCert(t, cfg, rotatedCert)
+	reloader.reload("file changed")
+
+	require.Equal(t, rotatedCert.cert.Raw, servedCert(t, reloader))
+}
+
+func TestCertReloader_MutualTLSRejectsClientsWithoutCerts(t *testing.T) {
+	ca := newTestCert(t, "Glide Test CA", nil)
+	cfg := newTestTLSConfig(t)
+
+	writeServerCert(t, cfg, newTestCert(t, "localhost", ca))
+
+	cfg.ClientCAFile = filepath.Join(filepath.Dir(cfg.CertFile), "client-ca.crt")
+	writeTLSFile(t, cfg.ClientCAFile, ca.certPEM)
+
+	reloader, err := NewCertReloader(cfg, telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	addr := serveTLS(t, reloader)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.cert)
+
+	require.Error(t, dialTLS(addr, &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}))
+
+	// certificates of other CAs are rejected too
+	otherCA := newTestCert(t, "Other CA", nil)
+	otherClientCert := newTestCert(t, "client", otherCA)
+
+	otherClientPair, err := tls.X509KeyPair(otherClientCert.certPEM, otherClientCert.keyPEM)
+	require.NoError(t, err)
+
+	require.Error(t, dialTLS(addr, &tls.Config{
+		RootCAs:      rootCAs,
+		Certificates: []tls.Certificate{otherClientPair},
+		MinVersion:   tls.VersionTLS12,
+	}))
+
+	clientCert := newTestCert(t, "client", ca)
+
+	clientPair, err := tls.X509KeyPair(clientCert.certPEM, clientCert.keyPEM)
+	require.NoError(t, err)
+
+	require.NoError(t, dialTLS(addr, &tls.Config{
+		RootCAs:      rootCAs,
+		Certificates: []tls.Certificate{clientPair},
+		MinVersion:   tls.VersionTLS12,
+	}))
+}
@@ -0,0 +1,427 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // the WebSocket handshake is defined over SHA-1 (RFC 6455)
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers"
+	"glide/pkg/telemetry"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"go.uber.org/zap"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	// wsAcceptGUID is concatenated with the client key to accept the WebSocket handshake (RFC 6455)
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+var (
+	errNotWebSocketUpgrade = errors.New("the request is not a WebSocket upgrade")
+	errWSBinaryMessage     = errors.New("only text messages are supported")
+)
+
+// StreamingConfig defines limits of WebSocket streaming connections
+type StreamingConfig struct {
+	// MaxConcurrentRequests is how many requests one connection could stream at once. Extra requests are rejected
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests" validate:"min=1"`
+	// PingInterval is how often clients are pinged to keep connections alive.
+	// Connections of clients that haven't sent anything (including pongs) for two intervals are closed
+	PingInterval time.Duration `yaml:"ping_interval" validate:"min=1s"`
+}
+
+func DefaultStreamingConfig() *StreamingConfig {
+	return &StreamingConfig{
+		MaxConcurrentRequests: 8,
+		PingInterval:          30 * time.Second,
+	}
+}
+
+func (c *StreamingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultStreamingConfig()
+
+	type plain StreamingConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// LangStreamHandler
+//
+//	@id				glide-language-chat-stream
+//	@Summary		Language Chat Stream
+//	@Description	Stream chat responses over WebSocket. Clients send schemas.ChatStreamRequest messages
+//	@Description	and receive typed schemas.ChatStreamMessage messages: response chunks as they are generated,
+//	@Description	then either the completion or the error message. Closing the socket cancels all in-flight requests of the connection
+//	@tags			Language
+//	@Param			router	path	string	true	"Router ID"
+//	@Success		101
+//	@Failure		400	{object}	schemas.ErrorResponse
+//	@Failure		404	{object}	schemas.ErrorResponse
+//	@Router			/v1/language/{router}/chatStream [GET]
+func LangStreamHandler(
+	routerManager *routers.RouterManager,
+	drainer *Drainer,
+	tel *telemetry.Telemetry,
+	cfg *StreamingConfig,
+	maxMessageSize int,
+) Handler {
+	return func(_ context.Context, c *app.RequestContext) {
+		routerID := c.Param("router")
+
+		router, err := routerManager.GetLangRouter(routerID)
+		if err != nil {
+			abortWithError(c, err)
+
+			return
+		}
+
+		acceptKey, err := wsAcceptKey(c)
+		if err != nil {
+			c.JSON(consts.StatusBadRequest, newErrorResponse(c, schemas.ErrorCodeInvalidRequest, err.Error()))
+
+			return
+		}
+
+		session := &wsSession{
+			router:         router,
+			drainer:        drainer,
+			logger:         tel.Logger.With(zap.String("routerID", routerID), zap.String("requestID", RequestID(c))),
+			requestID:      RequestID(c),
+			cfg:            cfg,
+			maxMessageSize: int64(maxMessageSize),
+			slots:          make(chan struct{}, cfg.MaxConcurrentRequests),
+		}
+
+		c.Response.Header.Set("Upgrade", "websocket")
+		c.Response.Header.Set("Connection", "Upgrade")
+		c.Response.Header.Set("Sec-WebSocket-Accept", acceptKey)
+		c.SetStatusCode(consts.StatusSwitchingProtocols)
+
+		c.Hijack(session.serve)
+	}
+}
+
+// wsAcceptKey validates the WebSocket handshake and returns the key that accepts it
+func wsAcceptKey(c *app.RequestContext) (string, error) {
+	if !headerHasToken(c, "Connection", "upgrade") || !headerHasToken(c, "Upgrade", "websocket") {
+		return "", errNotWebSocketUpgrade
+	}
+
+	if version := string(c.GetHeader("Sec-WebSocket-Version")); version != "13" {
+		return "", fmt.Errorf("%w: unsupported WebSocket version %q (supported: 13)", errNotWebSocketUpgrade, version)
+	}
+
+	key := strings.TrimSpace(string(c.GetHeader("Sec-WebSocket-Key")))
+	if key == "" {
+		return "", fmt.Errorf("%w: Sec-WebSocket-Key header is missing", errNotWebSocketUpgrade)
+	}
+
+	hash := sha1.Sum([]byte(key + wsAcceptGUID)) //nolint:gosec
+
+	return base64.StdEncoding.EncodeToString(hash[:]), nil
+}
+
+func headerHasToken(c *app.RequestContext, header string, token string) bool {
+	for _, value := range strings.Split(string(c.GetHeader(header)), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wsSession serves chat requests of one WebSocket connection.
+// Requests are served concurrently and their chunks are told apart by request IDs
+type wsSession struct {
+	router         *routers.LangRouter
+	drainer        *Drainer
+	logger         *zap.Logger
+	requestID      string
+	cfg            *StreamingConfig
+	maxMessageSize int64
+
+	// slots limits the number of requests streamed at once
+	slots   chan struct{}
+	conn    network.Conn
+	writeMu sync.Mutex
+	streams sync.WaitGroup
+}
+
+func (s *wsSession) serve(conn network.Conn) {
+	s.conn = conn
+
+	// closing the socket cancels in-flight requests, so upstream provider connections are released
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go s.keepAlive(ctx)
+
+	err := s.read(ctx)
+
+	cancel()
+	s.streams.Wait()
+
+	var closedErr wsutil.ClosedError
+
+	switch {
+	case err == nil, errors.As(err, &closedErr), errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+		s.logger.Debug("websocket connection is closed")
+	default:
+		s.logger.Debug("websocket connection is closed with error", zap.Error(err))
+
+		_ = s.writeFrame(ws.NewCloseFrame(ws.NewCloseFrameBody(wsCloseStatus(err), err.Error())))
+	}
+}
+
+// read handles client frames until the connection is closed
+func (s *wsSession) read(ctx context.Context) error {
+	reader := &wsutil.Reader{
+		Source:         s.conn,
+		State:          ws.StateServerSide,
+		CheckUTF8:      true,
+		MaxFrameSize:   s.maxMessageSize,
+		OnIntermediate: s.handleControl,
+	}
+
+	for {
+		if err := s.conn.SetReadDeadline(time.Now().Add(2 * s.cfg.PingInterval)); err != nil {
+			return err
+		}
+
+		header, err := reader.NextFrame()
+		if err != nil {
+			return err
+		}
+
+		if header.OpCode.IsControl() {
+			if err := s.handleControl(header, reader); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if header.OpCode != ws.OpText {
+			return errWSBinaryMessage
+		}
+
+		message, err := io.ReadAll(io.LimitReader(reader, s.maxMessageSize+1))
+		if err != nil {
+			return err
+		}
+
+		if int64(len(message)) > s.maxMessageSize {
+			return wsutil.ErrFrameTooLarge
+		}
+
+		s.handleRequest(ctx, message)
+	}
+}
+
+// handleControl answers pings and close frames. Pongs just keep the connection alive by resetting the read deadline
+func (s *wsSession) handleControl(header ws.Header, reader io.Reader) error {
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	switch header.OpCode { //nolint:exhaustive
+	case ws.OpPing:
+		return s.writeFrame(ws.NewPongFrame(payload))
+	case ws.OpClose:
+		code, reason := ws.ParseCloseFrameData(payload)
+
+		_ = s.writeFrame(ws.NewCloseFrame(ws.NewCloseFrameBody(code, "")))
+
+		return wsutil.ClosedError{Code: code, Reason: reason}
+	default:
+		return nil
+	}
+}
+
+func (s *wsSession) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.writeFrame(ws.NewPingFrame(nil)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *wsSession) handleRequest(ctx context.Context, message []byte) {
+	var req schemas.ChatStreamRequest
+
+	if err := json.Unmarshal(message, &req); err != nil {
+		s.writeError(req.ID, schemas.ErrorCodeInvalidRequest, err.Error())
+
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.writeError(req.ID, schemas.ErrorCodeInvalidRequest, err.Error())
+
+		return
+	}
+
+	if err := s.router.CheckRequestBodySize(len(message)); err != nil {
+		_, code := errorStatus(err)
+		s.writeError(req.ID, code, err.Error())
+
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.writeError(
+			req.ID,
+			schemas.ErrorCodeTooManyRequests,
+			fmt.Sprintf("the connection is already streaming %v requests, wait for some of them to complete", cap(s.slots)),
+		)
+
+		return
+	}
+
+	streamCtx, release, ok := s.drainer.Track(ctx)
+	if !ok {
+		<-s.slots
+		s.writeError(req.ID, schemas.ErrorCodeGatewayUnavailable, "gateway is shutting down")
+
+		return
+	}
+
+	s.streams.Add(1)
+
+	go func() {
+		defer s.streams.Done()
+		defer func() { <-s.slots }()
+		defer release()
+
+		s.stream(streamCtx, &req)
+	}()
+}
+
+// stream sends response chunks to the client as they arrive
+func (s *wsSession) stream(ctx context.Context, req *schemas.ChatStreamRequest) {
+	streamC, err := s.router.ChatStream(ctx, &req.UnifiedChatRequest)
+	if err != nil {
+		_, code := errorStatus(err)
+		s.writeError(req.ID, code, err.Error())
+
+		return
+	}
+
+	for result := range streamC {
+		if result.Err != nil {
+			_, code := errorStatus(result.Err)
+
+			if result.Chunk != nil {
+				// the final chunk lets clients finalize the partial response before the error comes
+				result.Chunk.ErrorCode = code
+
+				_ = s.writeMessage(&schemas.ChatStreamMessage{Type: schemas.ChatStreamMessageChunk, ID: req.ID, Chunk: result.Chunk})
+			}
+
+			s.writeError(req.ID, code, result.Err.Error())
+
+			return
+		}
+
+		message := &schemas.ChatStreamMessage{Type: schemas.ChatStreamMessageChunk, ID: req.ID, Chunk: result.Chunk}
+
+		if err := s.writeMessage(message); err != nil {
+			// the client is gone, the read loop is going to cancel the stream
+			return
+		}
+	}
+
+	if ctx.Err() != nil {
+		// the stream was cut short by the client disconnect or the shutdown
+		s.writeError(req.ID, schemas.ErrorCodeGatewayUnavailable, "the stream has been cancelled")
+
+		return
+	}
+
+	_ = s.writeMessage(&schemas.ChatStreamMessage{Type: schemas.ChatStreamMessageComplete, ID: req.ID})
+}
+
+func (s *wsSession) writeError(id string, code schemas.ErrorCode, message string) {
+	_ = s.writeMessage(&schemas.ChatStreamMessage{
+		Type: schemas.ChatStreamMessageError,
+		ID:   id,
+		Error: &schemas.ErrorResponse{
+			Code:      code,
+			Message:   message,
+			RequestID: s.requestID,
+		},
+	})
+}
+
+func (s *wsSession) writeMessage(message *schemas.ChatStreamMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return s.writeFrame(ws.NewTextFrame(payload))
+}
+
+// writeFrame writes the whole frame at once, so frames of concurrent streams & control frames don't interleave
+func (s *wsSession) writeFrame(frame ws.Frame) error {
+	var buf bytes.Buffer
+
+	if err := ws.WriteFrame(&buf, frame); err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+
+	_, err := s.conn.Write(buf.Bytes())
+
+	return err
+}
+
+// wsCloseStatus tells the client why the server closes the connection
+func wsCloseStatus(err error) ws.StatusCode {
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ws.StatusGoingAway
+	case errors.Is(err, errWSBinaryMessage):
+		return ws.StatusUnsupportedData
+	case errors.Is(err, wsutil.ErrFrameTooLarge):
+		return ws.StatusMessageTooBig
+	default:
+		return ws.StatusProtocolError
+	}
+}
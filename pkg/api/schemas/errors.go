@@ -0,0 +1,32 @@
+package schemas
+
+// ErrorCode is a machine-readable error reason clients can branch on
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest      ErrorCode = "invalid_request"
+	ErrorCodeUnsupportedParams   ErrorCode = "unsupported_params"
+	ErrorCodeMissingCapability   ErrorCode = "missing_capability"
+	ErrorCodeContextTooLong      ErrorCode = "context_length_exceeded"
+	ErrorCodeRequestTooLarge     ErrorCode = "request_too_large"
+	ErrorCodeRouterNotFound      ErrorCode = "router_not_found"
+	ErrorCodeNoHealthyModels     ErrorCode = "no_healthy_models"
+	ErrorCodeProviderRateLimited ErrorCode = "provider_rate_limited"
+	ErrorCodeTimeout             ErrorCode = "timeout"
+	ErrorCodeGatewayUnavailable  ErrorCode = "gateway_unavailable"
+	ErrorCodeTooManyRequests     ErrorCode = "too_many_requests"
+	ErrorCodeIdempotencyConflict ErrorCode = "idempotency_conflict"
+	ErrorCodeRequestRejected     ErrorCode = "request_rejected"
+	ErrorCodeResponseRejected    ErrorCode = "response_rejected"
+	ErrorCodeStreamInterrupted   ErrorCode = "stream_interrupted"
+	ErrorCodeContentPolicy       ErrorCode = "content_policy"
+	ErrorCodeUnauthorized        ErrorCode = "unauthorized"
+	ErrorCodeInternalError       ErrorCode = "internal_error"
+)
+
+// ErrorResponse defines Glide's error schema returned by all API endpoints
+type ErrorResponse struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"request_id,omitempty"`
+}
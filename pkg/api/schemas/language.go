@@ -1,51 +1,419 @@
 package schemas
 
-// UnifiedChatRequest defines Glide's Chat Request Schema unified across all language models
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// UnifiedChatRequest defines Glide's Chat Request Schema unified across all language models.
+// The conversation is passed either as the full Messages list or as the Message along with its MessageHistory
 type UnifiedChatRequest struct {
 	Message        ChatMessage         `json:"message"`
 	MessageHistory []ChatMessage       `json:"messageHistory"`
+	Messages       []ChatMessage       `json:"messages,omitempty"` // the whole conversation, the new message goes last
 	Override       OverrideChatRequest `json:"override,omitempty"`
+	Seed           *int                `json:"seed,omitempty"` // makes sampling deterministic (in best effort) on models that support seeding
+	// Repetition control (-2..2): positive values penalize tokens that already appeared in the text (presence)
+	// or proportionally to how often they appeared so far (frequency)
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	// LogitBias maps token IDs (of the model tokenizer) to biases (-100..100) added to their logits before sampling
+	LogitBias map[string]float64 `json:"logit_bias,omitempty"`
+	// Logprobs asks for log probabilities of output tokens along with TopLogprobs most likely alternatives of each (0..20)
+	Logprobs       bool             `json:"logprobs,omitempty"`
+	TopLogprobs    *int             `json:"top_logprobs,omitempty"`
+	Tools          []ToolDefinition `json:"tools,omitempty"` // requests are routed only to models that support tool calling
+	ToolChoice     *ToolChoice      `json:"tool_choice,omitempty"`
+	ResponseFormat *ResponseFormat  `json:"response_format,omitempty"` // JSON mode & structured output
+	N              int              `json:"n,omitempty"`               // number of completions to generate (1 by default)
+	// IncludeRouting asks to list model attempts the router made in the response (e.g. to see why it fell back)
+	IncludeRouting bool `json:"include_routing,omitempty"`
+	// Prompt refers to the named system prompt of the gateway config. It's put at the beginning of the conversation
+	Prompt *PromptRef `json:"prompt,omitempty"`
+	// TemplateVars are variables the prompt template of the router or model refers to
+	TemplateVars map[string]string `json:"template_vars,omitempty"`
+	// SkipTemplate sends the conversation as is (for callers that manage prompts themselves)
+	SkipTemplate bool `json:"skip_template,omitempty"`
+	// SessionID pins requests of the same session to the same model on routers with session affinity
+	// (could be passed via the X-Glide-Session header too)
+	SessionID string `json:"session_id,omitempty"`
+	// RouteHint routes the request over the subset of router models the hint maps to (e.g. cheap or quality).
+	// Requests with unknown hints are routed over all models
+	RouteHint string `json:"route_hint,omitempty"`
+	// User identifies the end user for provider abuse monitoring. It's passed to providers that support it,
+	// while telemetry sees only its hash
+	User string `json:"user,omitempty"`
+}
+
+// PromptRef refers to the named system prompt along with values of its {{var}} placeholders
+type PromptRef struct {
+	Name string            `json:"name"`
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// UserHash returns the hash of the end user ID, so usage could be correlated without recording raw user IDs
+// (empty if the request has no user)
+func (r *UnifiedChatRequest) UserHash() string {
+	if r.User == "" {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(r.User))
+
+	return hex.EncodeToString(hash[:8])
+}
+
+// Roles of chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool" // results of tool calls sent back to the model
+)
+
+var ErrInvalidMessages = errors.New("invalid chat messages")
+
+// ChatMessages returns the whole conversation with the new message last
+func (r *UnifiedChatRequest) ChatMessages() []ChatMessage {
+	if len(r.Messages) > 0 {
+		return r.Messages
+	}
+
+	messages := make([]ChatMessage, 0, len(r.MessageHistory)+1)
+	messages = append(messages, r.MessageHistory...)
+
+	return append(messages, r.Message)
+}
+
+// WithOverriddenMessage returns the copy of the request with the new message of the conversation replaced.
+// The request itself is not modified, as it's shared by fallback models (and callers)
+func (r *UnifiedChatRequest) WithOverriddenMessage(message ChatMessage) *UnifiedChatRequest {
+	overridden := *r
+
+	if len(r.Messages) > 0 {
+		overridden.Messages = slices.Clone(r.Messages)
+		overridden.Messages[len(overridden.Messages)-1] = message
+
+		return &overridden
+	}
+
+	overridden.Message = message
+
+	return &overridden
+}
+
+// Optional params of the unified chat request that not all providers could translate
+const (
+	ParamSeed             = "seed"
+	ParamPresencePenalty  = "presence_penalty"
+	ParamFrequencyPenalty = "frequency_penalty"
+	ParamN                = "n" // multiple completions (models that don't support it are asked several times)
+	ParamLogitBias        = "logit_bias"
+	ParamLogprobs         = "logprobs" // set along with top_logprobs too
+)
+
+// MaxTopLogprobs limits the number of alternatives returned for each output token (the same way OpenAI does)
+const MaxTopLogprobs = 20
+
+// OptionalParams returns names of the optional params set in the request
+func (r *UnifiedChatRequest) OptionalParams() []string {
+	var params []string
+
+	if r.Seed != nil {
+		params = append(params, ParamSeed)
+	}
+
+	if r.PresencePenalty != nil {
+		params = append(params, ParamPresencePenalty)
+	}
+
+	if r.FrequencyPenalty != nil {
+		params = append(params, ParamFrequencyPenalty)
+	}
+
+	if r.N > 1 {
+		params = append(params, ParamN)
+	}
+
+	if len(r.LogitBias) > 0 {
+		params = append(params, ParamLogitBias)
+	}
+
+	if r.LogprobsRequested() {
+		params = append(params, ParamLogprobs)
+	}
+
+	return params
+}
+
+// LogprobsRequested checks if the request asks for log probabilities of output tokens
+func (r *UnifiedChatRequest) LogprobsRequested() bool {
+	return r.Logprobs || r.TopLogprobs != nil
 }
 
 type OverrideChatRequest struct {
 	Model   string      `json:"model_id"`
 	Message ChatMessage `json:"message"`
+	// Params are merged over default params of whichever model serves the request (unlike the message, they are not scoped to model_id)
+	Params *ChatParams `json:"params,omitempty"`
+}
+
+var ErrInvalidChatParams = errors.New("invalid chat params")
+
+// MaxCompletions limits the number of completions per request (the same way OpenAI does)
+const MaxCompletions = 128
+
+// ChatParams is a provider-agnostic set of sampling params.
+// Providers clamp values to their own bounds and drop params they don't support
+type ChatParams struct {
+	Temperature *float64 `json:"temperature,omitempty"` // 0..2
+	TopP        *float64 `json:"top_p,omitempty"`       // 0..1
+	TopK        *int     `json:"top_k,omitempty"`       // >=1
+	MaxTokens   *int     `json:"max_tokens,omitempty"`  // >=1
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// Validate checks that params are within ranges that make sense for any provider
+func (p *ChatParams) Validate() error {
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		return fmt.Errorf("%w: temperature must be between 0 and 2 (got: %v)", ErrInvalidChatParams, *p.Temperature)
+	}
+
+	if p.TopP != nil && (*p.TopP < 0 || *p.TopP > 1) {
+		return fmt.Errorf("%w: top_p must be between 0 and 1 (got: %v)", ErrInvalidChatParams, *p.TopP)
+	}
+
+	if p.TopK != nil && *p.TopK < 1 {
+		return fmt.Errorf("%w: top_k must be positive (got: %v)", ErrInvalidChatParams, *p.TopK)
+	}
+
+	if p.MaxTokens != nil && *p.MaxTokens < 1 {
+		return fmt.Errorf("%w: max_tokens must be positive (got: %v)", ErrInvalidChatParams, *p.MaxTokens)
+	}
+
+	return nil
+}
+
+// Validate checks the request fields that could be validated independently of the model serving the request
+func (r *UnifiedChatRequest) Validate() error {
+	if err := r.validateMessages(); err != nil {
+		return err
+	}
+
+	if err := r.validateTools(); err != nil {
+		return err
+	}
+
+	if r.ResponseFormat != nil {
+		if err := r.ResponseFormat.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validatePenalty(ParamPresencePenalty, r.PresencePenalty); err != nil {
+		return err
+	}
+
+	if err := validatePenalty(ParamFrequencyPenalty, r.FrequencyPenalty); err != nil {
+		return err
+	}
+
+	if r.N < 0 || r.N > MaxCompletions {
+		return fmt.Errorf("%w: n must be between 1 and %v (got: %v)", ErrInvalidChatParams, MaxCompletions, r.N)
+	}
+
+	if err := validateLogitBias(r.LogitBias); err != nil {
+		return err
+	}
+
+	if r.TopLogprobs != nil && (*r.TopLogprobs < 0 || *r.TopLogprobs > MaxTopLogprobs) {
+		return fmt.Errorf("%w: top_logprobs must be between 0 and %v (got: %v)", ErrInvalidChatParams, MaxTopLogprobs, *r.TopLogprobs)
+	}
+
+	if r.Override.Params != nil {
+		return r.Override.Params.Validate()
+	}
+
+	return nil
+}
+
+func validatePenalty(param string, penalty *float64) error {
+	if penalty != nil && (*penalty < -2 || *penalty > 2) {
+		return fmt.Errorf("%w: %v must be between -2 and 2 (got: %v)", ErrInvalidChatParams, param, *penalty)
+	}
+
+	return nil
+}
+
+func validateLogitBias(logitBias map[string]float64) error {
+	for tokenID, bias := range logitBias {
+		if id, err := strconv.Atoi(tokenID); err != nil || id < 0 {
+			return fmt.Errorf("%w: logit_bias keys must be token IDs (got: %q)", ErrInvalidChatParams, tokenID)
+		}
+
+		if bias < -100 || bias > 100 {
+			return fmt.Errorf("%w: logit_bias of token %v must be between -100 and 100 (got: %v)", ErrInvalidChatParams, tokenID, bias)
+		}
+	}
+
+	return nil
+}
+
+func (r *UnifiedChatRequest) validateMessages() error {
+	conversational := false
+
+	for idx, message := range r.Messages {
+		switch message.Role {
+		case RoleUser, RoleAssistant:
+			conversational = true
+		case RoleTool:
+			if message.ToolCallID == "" {
+				return fmt.Errorf("%w: messages[%d] is a tool result without tool_call_id", ErrInvalidMessages, idx)
+			}
+		case RoleSystem:
+		default:
+			return fmt.Errorf(
+				"%w: messages[%d] has unknown role %q (allowed: system, user, assistant, tool)",
+				ErrInvalidMessages,
+				idx,
+				message.Role,
+			)
+		}
+
+		if err := message.validateContentParts(); err != nil {
+			return fmt.Errorf("%w: messages[%d]: %v", ErrInvalidMessages, idx, err)
+		}
+	}
+
+	if len(r.Messages) > 0 && !conversational {
+		return fmt.Errorf("%w: at least one user or assistant message is required", ErrInvalidMessages)
+	}
+
+	return nil
 }
 
 func NewChatFromStr(message string) *UnifiedChatRequest {
 	return &UnifiedChatRequest{
 		Message: ChatMessage{
-			"human",
-			message,
-			"roma",
+			Role:    "human",
+			Content: message,
+			Name:    "roma",
 		},
 	}
 }
 
 // UnifiedChatResponse defines Glide's Chat Response Schema unified across all language models
 type UnifiedChatResponse struct {
-	ID            string           `json:"id,omitempty"`
-	Created       int              `json:"created,omitempty"`
-	Provider      string           `json:"provider,omitempty"`
-	RouterID      string           `json:"router,omitempty"`
-	ModelID       string           `json:"model_id,omitempty"`
-	Model         string           `json:"model,omitempty"`
-	Cached        bool             `json:"cached,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Created  int    `json:"created,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	RouterID string `json:"router,omitempty"`
+	ModelID  string `json:"model_id,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Cached   bool   `json:"cached,omitempty"`
+	// Degraded is set when no model could serve the request, so the router has responded with its degraded response
+	Degraded bool `json:"degraded,omitempty"`
+	// SemanticCache is set when the response was cached for a similar (but not the same) prompt
+	SemanticCache *SemanticCacheHit `json:"semantic_cache,omitempty"`
+	// Routing lists model attempts made to serve the request (only if the request asked for it)
+	Routing       *RoutingTrace    `json:"routing,omitempty"`
 	ModelResponse ProviderResponse `json:"modelResponse,omitempty"`
 }
 
+// ChatBatchResult is the outcome of one request of the chat batch. Either the response or the error is set
+type ChatBatchResult struct {
+	Response *UnifiedChatResponse `json:"response,omitempty"`
+	Error    *ErrorResponse       `json:"error,omitempty"`
+}
+
+// Outcomes of model attempts
+const (
+	AttemptSucceeded = "success"
+	AttemptFailed    = "failure"
+)
+
+// RoutingTrace describes how the router picked the model that served the request
+type RoutingTrace struct {
+	Attempts      []RoutingAttempt `json:"attempts"`
+	SelectedModel string           `json:"selected_model"`
+}
+
+// RoutingAttempt is one try of a router model to serve the request
+type RoutingAttempt struct {
+	ModelID   string  `json:"model_id"`
+	Provider  string  `json:"provider"`
+	Outcome   string  `json:"outcome"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// AddAttempt records the model attempt. It's no-op on the nil trace, so routing could be traced only on demand
+func (t *RoutingTrace) AddAttempt(modelID string, provider string, latency time.Duration, err error) {
+	if t == nil {
+		return
+	}
+
+	attempt := RoutingAttempt{
+		ModelID:   modelID,
+		Provider:  provider,
+		Outcome:   AttemptSucceeded,
+		LatencyMs: float64(latency) / float64(time.Millisecond),
+	}
+
+	if err != nil {
+		attempt.Outcome = AttemptFailed
+		attempt.Error = err.Error()
+	}
+
+	t.Attempts = append(t.Attempts, attempt)
+}
+
+// SemanticCacheHit describes the prompt the cached response was given to, so callers could decide whether to accept it
+type SemanticCacheHit struct {
+	Prompt     string  `json:"prompt"`
+	Similarity float64 `json:"similarity"` // cosine similarity of the prompts (up to 1)
+}
+
 // ProviderResponse is the unified response from the provider.
 
 type ProviderResponse struct {
-	SystemID   map[string]string `json:"responseId,omitempty"`
-	Message    ChatMessage       `json:"message"`
-	TokenUsage TokenUsage        `json:"tokenCount"`
+	SystemID map[string]string `json:"responseId,omitempty"`
+	Message  ChatMessage       `json:"message"`
+	// ReasoningContent is the chain of thought reasoning models generate before the message (e.g. DeepSeek reasoner),
+	// so clients could show it apart from the response
+	ReasoningContent string `json:"reasoningContent,omitempty"`
+	// Choices are all completions when several are requested (the message is the first one)
+	Choices    []ChatMessage `json:"choices,omitempty"`
+	TokenUsage TokenUsage    `json:"tokenCount"` // the total across all completions
+	// SystemFingerprint identifies the backend configuration of the provider that served the request (if the provider reports it),
+	// so changes that affect reproducibility of seeded requests could be told apart
+	SystemFingerprint string `json:"systemFingerprint,omitempty"`
+	// Logprobs are log probabilities of output tokens in the provider format (only if they have been requested & the provider supports them)
+	Logprobs json.RawMessage `json:"logprobs,omitempty"`
+	// FinishReason is the unified reason the model has stopped generating for (e.g. "length" when the response has been cut at max tokens).
+	// It's set by providers that report it and by the output guard of the model
+	FinishReason string `json:"finishReason,omitempty"`
+	// StopSequence is the stop sequence the response has been finished at (excluded from the message)
+	StopSequence string `json:"stopSequence,omitempty"`
+	// DroppedStopSequences is the number of request stop sequences beyond the provider limit that have not been sent to the model
+	DroppedStopSequences int `json:"droppedStopSequences,omitempty"`
 }
 
 type TokenUsage struct {
 	PromptTokens   float64 `json:"promptTokens"`
 	ResponseTokens float64 `json:"responseTokens"`
 	TotalTokens    float64 `json:"totalTokens"`
+	// Prompt tokens written to & read from the provider prompt cache (they are a part of prompt tokens, but billed differently)
+	CacheCreationTokens float64 `json:"cacheCreationTokens,omitempty"`
+	CacheReadTokens     float64 `json:"cacheReadTokens,omitempty"`
+	// Estimated is set when the provider has not reported (some of) the usage, so it's counted by the model tokenizer
+	Estimated bool `json:"estimated,omitempty"`
 }
 
 // ChatMessage is a message in a chat request.
@@ -57,6 +425,14 @@ type ChatMessage struct {
 	// The name of the author of this message. May contain a-z, A-Z, 0-9, and underscores,
 	// with a maximum length of 64 characters.
 	Name string `json:"name,omitempty"`
+	// Tool calls requested by the model (assistant messages only).
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// The tool call this message is the result of (tool messages only).
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// ContentParts is the multimodal content (text and images). It's passed as the list in the "content" field
+	ContentParts []ContentPart `json:"-"`
+	// Cache marks the end of the conversation prefix providers with prompt caching could cache (e.g. a long shared system prompt)
+	Cache bool `json:"cache,omitempty"`
 }
 
 // OpenAI Chat Response (also used by Azure OpenAI and OctoML)
@@ -72,10 +448,10 @@ type OpenAIChatCompletion struct {
 }
 
 type Choice struct {
-	Index        int         `json:"index"`
-	Message      ChatMessage `json:"message"`
-	Logprobs     interface{} `json:"logprobs"`
-	FinishReason string      `json:"finish_reason"`
+	Index        int             `json:"index"`
+	Message      ChatMessage     `json:"message"`
+	Logprobs     json.RawMessage `json:"logprobs"`
+	FinishReason string          `json:"finish_reason"`
 }
 
 type Usage struct {
@@ -152,16 +528,28 @@ type ConnectorsResponse struct {
 
 // Anthropic Chat Response
 type AnthropicChatCompletion struct {
-	ID           string    `json:"id"`
-	Type         string    `json:"type"`
-	Model        string    `json:"model"`
-	Role         string    `json:"role"`
-	Content      []Content `json:"content"`
-	StopReason   string    `json:"stop_reason"`
-	StopSequence string    `json:"stop_sequence"`
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Model        string         `json:"model"`
+	Role         string         `json:"role"`
+	Content      []Content      `json:"content"`
+	StopReason   string         `json:"stop_reason"`
+	StopSequence string         `json:"stop_sequence"`
+	Usage        AnthropicUsage `json:"usage"`
+}
+
+// AnthropicUsage reports input tokens apart from those written to & read from the prompt cache
+type AnthropicUsage struct {
+	InputTokens              float64 `json:"input_tokens"`
+	OutputTokens             float64 `json:"output_tokens"`
+	CacheCreationInputTokens float64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     float64 `json:"cache_read_input_tokens"`
 }
 
 type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	ID    string          `json:"id,omitempty"`    // tool_use blocks only
+	Name  string          `json:"name,omitempty"`  // tool_use blocks only
+	Input json.RawMessage `json:"input,omitempty"` // tool_use blocks only
 }
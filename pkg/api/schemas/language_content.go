@@ -0,0 +1,160 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Content part types
+const (
+	ContentPartText        = "text"
+	ContentPartImageURL    = "image_url"
+	ContentPartImageBase64 = "image_base64"
+)
+
+// ContentPart is a part of multimodal message content (text or image)
+type ContentPart struct {
+	Type        string       `json:"type"`
+	Text        string       `json:"text,omitempty"`
+	ImageURL    *ImageURL    `json:"image_url,omitempty"`
+	ImageBase64 *ImageBase64 `json:"image_base64,omitempty"`
+	// CacheControl marks the end of the prompt prefix providers with prompt caching could cache (others ignore it)
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// CacheControlEphemeral is the only cache type supported so far
+const CacheControlEphemeral = "ephemeral"
+
+// CacheControl is the cache breakpoint of the content part
+type CacheControl struct {
+	Type string `json:"type"`
+}
+
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // image resolution hint (low, high or auto), not all providers support it
+}
+
+type ImageBase64 struct {
+	MediaType string `json:"media_type"` // e.g. image/png
+	Data      string `json:"data"`
+}
+
+// HasImages checks if the message content has image parts
+func (m *ChatMessage) HasImages() bool {
+	for _, part := range m.ContentParts {
+		if part.Type == ContentPartImageURL || part.Type == ContentPartImageBase64 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasCachedParts checks if the message content has parts marked as cache breakpoints
+func (m *ChatMessage) HasCachedParts() bool {
+	for _, part := range m.ContentParts {
+		if part.CacheControl != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasImages checks if any message of the conversation has images
+func (r *UnifiedChatRequest) HasImages() bool {
+	for _, message := range r.ChatMessages() {
+		if message.HasImages() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarshalJSON renders the content as a string or as a list of parts if the message is multimodal
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	type plain ChatMessage // to avoid recursion
+
+	if len(m.ContentParts) == 0 {
+		return json.Marshal(plain(m))
+	}
+
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain(m), m.ContentParts})
+}
+
+// UnmarshalJSON accepts the content either as a string or as a list of parts.
+// Text of content parts is also joined into the Content, so text-only translations keep working
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	type plain ChatMessage // to avoid recursion
+
+	var raw struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = ChatMessage(raw.plain)
+
+	content := bytes.TrimSpace(raw.Content)
+
+	switch {
+	case len(content) == 0 || bytes.Equal(content, []byte("null")):
+		return nil
+	case content[0] == '[':
+		if err := json.Unmarshal(content, &m.ContentParts); err != nil {
+			return err
+		}
+
+		texts := make([]string, 0, len(m.ContentParts))
+
+		for _, part := range m.ContentParts {
+			if part.Type == ContentPartText {
+				texts = append(texts, part.Text)
+			}
+		}
+
+		m.Content = strings.Join(texts, "\n")
+
+		return nil
+	default:
+		return json.Unmarshal(content, &m.Content)
+	}
+}
+
+func (m *ChatMessage) validateContentParts() error {
+	for idx, part := range m.ContentParts {
+		if part.CacheControl != nil && part.CacheControl.Type != CacheControlEphemeral {
+			return fmt.Errorf("content part #%d has unknown cache control type %q (allowed: ephemeral)", idx, part.CacheControl.Type)
+		}
+
+		switch part.Type {
+		case ContentPartText:
+		case ContentPartImageURL:
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				return fmt.Errorf("content part #%d has no image URL", idx)
+			}
+		case ContentPartImageBase64:
+			if part.ImageBase64 == nil || part.ImageBase64.MediaType == "" || part.ImageBase64.Data == "" {
+				return fmt.Errorf("content part #%d requires the image media type and data", idx)
+			}
+		default:
+			return fmt.Errorf(
+				"content part #%d has unknown type %q (allowed: text, image_url, image_base64)",
+				idx,
+				part.Type,
+			)
+		}
+	}
+
+	return nil
+}
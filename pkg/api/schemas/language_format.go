@@ -0,0 +1,52 @@
+package schemas
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidResponseFormat = errors.New("invalid response format")
+
+// Response formats
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object" // any valid JSON object
+	ResponseFormatJSONSchema = "json_schema" // JSON object that follows the given schema
+)
+
+// ResponseFormat makes the model respond with JSON (structured output). It follows the OpenAI format
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+type JSONSchemaFormat struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+	Strict      bool                   `json:"strict,omitempty"`
+}
+
+// StructuredOutput checks if the request asks for a JSON response
+func (r *UnifiedChatRequest) StructuredOutput() bool {
+	return r.ResponseFormat != nil && r.ResponseFormat.Type != ResponseFormatText
+}
+
+func (f *ResponseFormat) Validate() error {
+	switch f.Type {
+	case ResponseFormatText, ResponseFormatJSONObject:
+		return nil
+	case ResponseFormatJSONSchema:
+		if f.JSONSchema == nil || f.JSONSchema.Name == "" || len(f.JSONSchema.Schema) == 0 {
+			return fmt.Errorf("%w: json_schema format requires the schema name and the schema itself", ErrInvalidResponseFormat)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf(
+			"%w: unknown type %q (allowed: text, json_object, json_schema)",
+			ErrInvalidResponseFormat,
+			f.Type,
+		)
+	}
+}
@@ -0,0 +1,59 @@
+package schemas
+
+// UnifiedChatStreamChunk defines Glide's Chat Stream Chunk Schema unified across all language models
+type UnifiedChatStreamChunk struct {
+	ID            string                `json:"id,omitempty"`
+	Created       int                   `json:"created,omitempty"`
+	Provider      string                `json:"provider,omitempty"`
+	RouterID      string                `json:"router,omitempty"`
+	ModelID       string                `json:"model_id,omitempty"`
+	Model         string                `json:"model,omitempty"`
+	ModelResponse ProviderChunkResponse `json:"modelResponse,omitempty"`
+	FinishReason  string                `json:"finishReason,omitempty"` // set on the last chunk of the stream
+	ErrorCode     ErrorCode             `json:"error_code,omitempty"`   // set on the last chunk when the stream has failed
+	Degraded      bool                  `json:"degraded,omitempty"`     // set when the chunk is the degraded response of the router
+}
+
+// Finish reasons Glide sets on its own
+const (
+	FinishReasonLength = "length" // the response has been cut at the max output tokens of the model
+	FinishReasonError  = "error"  // the stream has been interrupted by an error
+)
+
+// ProviderChunkResponse is the unified chunk of the streamed provider response
+type ProviderChunkResponse struct {
+	SystemID map[string]string `json:"responseId,omitempty"`
+	// Message contains the text generated since the previous chunk
+	Message ChatMessage `json:"message"`
+	// TokenUsage is only known once the whole response is generated, so it's set on the last chunk only
+	TokenUsage *TokenUsage `json:"tokenCount,omitempty"`
+}
+
+// ChatStreamResult is either the next stream chunk or the error that has interrupted the stream.
+// The router sets the chunk with the error finish reason along with the error, so clients could finalize partial responses
+type ChatStreamResult struct {
+	Chunk *UnifiedChatStreamChunk
+	Err   error
+}
+
+// ChatStreamRequest is the chat request sent over the streaming connection
+type ChatStreamRequest struct {
+	// ID is echoed in messages of the response, so several requests could be streamed over one connection at once
+	ID string `json:"id,omitempty"`
+	UnifiedChatRequest
+}
+
+// Types of messages sent to clients of the streaming endpoint
+const (
+	ChatStreamMessageChunk    = "chunk"    // the next chunk of the response
+	ChatStreamMessageComplete = "complete" // the response is over, no more messages are sent for the request
+	ChatStreamMessageError    = "error"    // the request has failed, no more messages are sent for the request
+)
+
+// ChatStreamMessage is sent to clients of the streaming endpoint. Its type tells which of the fields is set
+type ChatStreamMessage struct {
+	Type  string                  `json:"type"`
+	ID    string                  `json:"id,omitempty"` // the ID of the request the message belongs to
+	Chunk *UnifiedChatStreamChunk `json:"chunk,omitempty"`
+	Error *ErrorResponse          `json:"error,omitempty"`
+}
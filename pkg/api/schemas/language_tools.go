@@ -0,0 +1,96 @@
+package schemas
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidTools = errors.New("invalid tools")
+
+// ToolTypeFunction is the only tool type supported by providers at the moment
+const ToolTypeFunction = "function"
+
+// Tool choice modes
+const (
+	ToolChoiceAuto     = "auto"     // the model decides whether to call tools
+	ToolChoiceNone     = "none"     // the model must not call tools
+	ToolChoiceRequired = "required" // the model must call one or more tools
+	ToolChoiceFunction = "function" // the model must call the given function
+)
+
+// ToolDefinition describes a tool (function) the model may call
+type ToolDefinition struct {
+	Type     string             `json:"type" yaml:"type"`
+	Function FunctionDefinition `json:"function" yaml:"function"`
+}
+
+type FunctionDefinition struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Parameters is a JSON Schema object that describes the function arguments
+	Parameters map[string]interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// ToolChoice controls whether and which tools the model calls
+type ToolChoice struct {
+	Type string `json:"type"`           // one of auto, none, required or function
+	Name string `json:"name,omitempty"` // the function to call when the type is function
+}
+
+// ToolCall is a tool (function) call requested by the model
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name string `json:"name"`
+	// Arguments are JSON-encoded function arguments as generated by the model (they may be malformed)
+	Arguments string `json:"arguments"`
+}
+
+// HasTools checks if the request defines tools the model could call
+func (r *UnifiedChatRequest) HasTools() bool {
+	return len(r.Tools) > 0
+}
+
+func (r *UnifiedChatRequest) validateTools() error {
+	toolNames := make(map[string]struct{}, len(r.Tools))
+
+	for idx, tool := range r.Tools {
+		if tool.Type != ToolTypeFunction {
+			return fmt.Errorf("%w: tools[%d] has unknown type %q (allowed: function)", ErrInvalidTools, idx, tool.Type)
+		}
+
+		if tool.Function.Name == "" {
+			return fmt.Errorf("%w: tools[%d] has no function name", ErrInvalidTools, idx)
+		}
+
+		toolNames[tool.Function.Name] = struct{}{}
+	}
+
+	if r.ToolChoice == nil {
+		return nil
+	}
+
+	switch r.ToolChoice.Type {
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+	case ToolChoiceFunction:
+		if _, found := toolNames[r.ToolChoice.Name]; !found {
+			return fmt.Errorf("%w: tool choice refers to undefined function %q", ErrInvalidTools, r.ToolChoice.Name)
+		}
+	default:
+		return fmt.Errorf(
+			"%w: unknown tool choice %q (allowed: auto, none, required, function)",
+			ErrInvalidTools,
+			r.ToolChoice.Type,
+		)
+	}
+
+	if !r.HasTools() {
+		return fmt.Errorf("%w: tool choice is given without tools", ErrInvalidTools)
+	}
+
+	return nil
+}
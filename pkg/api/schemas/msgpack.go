@@ -0,0 +1,41 @@
+package schemas
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPackContentType is the media type of MessagePack-encoded payloads
+const MsgPackContentType = "application/msgpack"
+
+// MarshalMsgPack encodes the value as MessagePack. Fields are named the same way as in JSON (by json tags), so both formats share the schema.
+// Raw JSON fields (e.g. logprobs) are passed as binary strings
+func MarshalMsgPack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	enc.UseCompactFloats(true)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgPack decodes the MessagePack payload encoded by MarshalMsgPack
+func UnmarshalMsgPack(data []byte, v interface{}) error {
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+
+	dec.Reset(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+
+	return dec.Decode(v)
+}
@@ -0,0 +1,96 @@
+package schemas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestChatResponse() *UnifiedChatResponse {
+	return &UnifiedChatResponse{
+		ID:       "chatcmpl-123",
+		Created:  1700000000,
+		Provider: "openai",
+		RouterID: "myrouter",
+		ModelID:  "openai-gpt4o",
+		Model:    "gpt-4o",
+		Routing: &RoutingTrace{
+			Attempts:      []RoutingAttempt{{ModelID: "openai-gpt4o", Provider: "openai", Outcome: AttemptSucceeded}},
+			SelectedModel: "openai-gpt4o",
+		},
+		ModelResponse: ProviderResponse{
+			SystemID: map[string]string{"system_fingerprint": "fp_123"},
+			Message: ChatMessage{
+				Role:    RoleAssistant,
+				Content: "The blue whale is the biggest animal on the Earth. It could be up to 30 meters long and weigh up to 200 tonnes.",
+				ToolCalls: []ToolCall{{
+					ID:       "call_1",
+					Type:     "function",
+					Function: FunctionCall{Name: "get_weather", Arguments: `{"city": "Kyiv"}`},
+				}},
+			},
+			TokenUsage:        TokenUsage{PromptTokens: 12, ResponseTokens: 31, TotalTokens: 43, Estimated: true},
+			SystemFingerprint: "fp_123",
+			Logprobs:          json.RawMessage(`{"content":[{"token":"The","logprob":-0.01}]}`),
+			FinishReason:      FinishReasonStop,
+		},
+	}
+}
+
+func TestMsgPack_ChatResponseRoundTrip(t *testing.T) {
+	resp := newTestChatResponse()
+
+	payload, err := MarshalMsgPack(resp)
+	require.NoError(t, err)
+
+	var decoded UnifiedChatResponse
+
+	require.NoError(t, UnmarshalMsgPack(payload, &decoded))
+	require.Equal(t, resp, &decoded)
+
+	// the same response round-trips via JSON too
+	jsonPayload, err := json.Marshal(resp)
+	require.NoError(t, err)
+
+	decoded = UnifiedChatResponse{}
+
+	require.NoError(t, json.Unmarshal(jsonPayload, &decoded))
+	require.Equal(t, resp, &decoded)
+
+	require.Less(t, len(payload), len(jsonPayload))
+}
+
+func BenchmarkChatResponse_JSON(b *testing.B) {
+	resp := newTestChatResponse()
+
+	var size int
+
+	for i := 0; i < b.N; i++ {
+		payload, err := json.Marshal(resp)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		size = len(payload)
+	}
+
+	b.ReportMetric(float64(size), "bytes")
+}
+
+func BenchmarkChatResponse_MsgPack(b *testing.B) {
+	resp := newTestChatResponse()
+
+	var size int
+
+	for i := 0; i < b.N; i++ {
+		payload, err := MarshalMsgPack(resp)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		size = len(payload)
+	}
+
+	b.ReportMetric(float64(size), "bytes")
+}
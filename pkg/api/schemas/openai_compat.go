@@ -0,0 +1,361 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// OpenAICompatChatRequest is the OpenAI Chat Completions request (https://platform.openai.com/docs/api-reference/chat/create)
+// accepted by the OpenAI-compatible endpoint, so tools that only speak the OpenAI wire format could use Glide routers
+type OpenAICompatChatRequest struct {
+	Model               string               `json:"model"`
+	Messages            []ChatMessage        `json:"messages"`
+	Temperature         *float64             `json:"temperature,omitempty"`
+	TopP                *float64             `json:"top_p,omitempty"`
+	MaxTokens           *int                 `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int                 `json:"max_completion_tokens,omitempty"`
+	Stop                OpenAIStop           `json:"stop,omitempty"`
+	N                   int                  `json:"n,omitempty"`
+	Seed                *int                 `json:"seed,omitempty"`
+	PresencePenalty     *float64             `json:"presence_penalty,omitempty"`
+	FrequencyPenalty    *float64             `json:"frequency_penalty,omitempty"`
+	LogitBias           map[string]float64   `json:"logit_bias,omitempty"`
+	Logprobs            bool                 `json:"logprobs,omitempty"`
+	TopLogprobs         *int                 `json:"top_logprobs,omitempty"`
+	Tools               []ToolDefinition     `json:"tools,omitempty"`
+	ToolChoice          *OpenAIToolChoice    `json:"tool_choice,omitempty"`
+	ResponseFormat      *ResponseFormat      `json:"response_format,omitempty"`
+	Stream              bool                 `json:"stream,omitempty"`
+	StreamOptions       *OpenAIStreamOptions `json:"stream_options,omitempty"`
+	User                string               `json:"user,omitempty"`
+	// IgnoredParams are request fields Glide doesn't translate (e.g. logprobs), so they are dropped
+	IgnoredParams []string `json:"-"`
+}
+
+// openAICompatParams are the request fields that have unified counterparts
+var openAICompatParams = []string{
+	"model",
+	"messages",
+	"temperature",
+	"top_p",
+	"max_tokens",
+	"max_completion_tokens",
+	"stop",
+	"n",
+	"seed",
+	"presence_penalty",
+	"frequency_penalty",
+	"logit_bias",
+	"logprobs",
+	"top_logprobs",
+	"tools",
+	"tool_choice",
+	"response_format",
+	"stream",
+	"stream_options",
+	"user",
+}
+
+// UnmarshalJSON decodes the request collecting fields that have no unified counterparts
+func (r *OpenAICompatChatRequest) UnmarshalJSON(data []byte) error {
+	type plain OpenAICompatChatRequest // to avoid recursion
+
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	r.IgnoredParams = nil
+
+	for field := range fields {
+		if !slices.Contains(openAICompatParams, field) {
+			r.IgnoredParams = append(r.IgnoredParams, field)
+		}
+	}
+
+	slices.Sort(r.IgnoredParams)
+
+	return nil
+}
+
+// OpenAIStreamOptions tunes streamed responses
+type OpenAIStreamOptions struct {
+	// IncludeUsage asks for the extra chunk with the token usage of the whole response before the stream is over
+	IncludeUsage bool `json:"include_usage,omitempty"`
+}
+
+// IncludeUsage tells if the streamed response should end with the usage chunk
+func (r *OpenAICompatChatRequest) IncludeUsage() bool {
+	return r.StreamOptions != nil && r.StreamOptions.IncludeUsage
+}
+
+// OpenAIStop is the stop sequence list that OpenAI also takes as a single string
+type OpenAIStop []string
+
+func (s *OpenAIStop) UnmarshalJSON(data []byte) error {
+	var stop string
+
+	if err := json.Unmarshal(data, &stop); err == nil {
+		*s = OpenAIStop{stop}
+
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// OpenAIToolChoice is the tool choice given either as the mode string or as the function object
+type OpenAIToolChoice struct {
+	ToolChoice
+}
+
+func (c *OpenAIToolChoice) UnmarshalJSON(data []byte) error {
+	var mode string
+
+	if err := json.Unmarshal(data, &mode); err == nil {
+		c.ToolChoice = ToolChoice{Type: mode}
+
+		return nil
+	}
+
+	var function struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+
+	if err := json.Unmarshal(data, &function); err != nil {
+		return err
+	}
+
+	if function.Type != ToolTypeFunction {
+		return fmt.Errorf("%w: unknown tool choice type %q", ErrInvalidTools, function.Type)
+	}
+
+	c.ToolChoice = ToolChoice{Type: ToolChoiceFunction, Name: function.Function.Name}
+
+	return nil
+}
+
+// ToUnifiedRequest translates the OpenAI request into the unified one. Sampling params are passed as overrides,
+// so they take precedence over default params of whichever model serves the request
+func (r *OpenAICompatChatRequest) ToUnifiedRequest() (*UnifiedChatRequest, error) {
+	if len(r.Messages) == 0 {
+		return nil, fmt.Errorf("%w: at least one message is required", ErrInvalidMessages)
+	}
+
+	request := &UnifiedChatRequest{
+		Messages:         r.Messages,
+		Seed:             r.Seed,
+		PresencePenalty:  r.PresencePenalty,
+		FrequencyPenalty: r.FrequencyPenalty,
+		LogitBias:        r.LogitBias,
+		Logprobs:         r.Logprobs,
+		TopLogprobs:      r.TopLogprobs,
+		Tools:            r.Tools,
+		ResponseFormat:   r.ResponseFormat,
+		N:                r.N,
+		User:             r.User,
+	}
+
+	if r.ToolChoice != nil {
+		request.ToolChoice = &r.ToolChoice.ToolChoice
+	}
+
+	maxTokens := r.MaxTokens
+	if r.MaxCompletionTokens != nil {
+		// the newer name of max_tokens
+		maxTokens = r.MaxCompletionTokens
+	}
+
+	if r.Temperature != nil || r.TopP != nil || maxTokens != nil || len(r.Stop) > 0 {
+		request.Override.Params = &ChatParams{
+			Temperature: r.Temperature,
+			TopP:        r.TopP,
+			MaxTokens:   maxTokens,
+			Stop:        r.Stop,
+		}
+	}
+
+	return request, nil
+}
+
+// Finish reasons of OpenAI completions
+const (
+	FinishReasonStop      = "stop"
+	FinishReasonToolCalls = "tool_calls"
+)
+
+// NewOpenAICompatChatCompletion translates the unified response into the OpenAI one.
+// The model is reported as requested, so clients could match responses to their requests
+func NewOpenAICompatChatCompletion(model string, resp *UnifiedChatResponse) *OpenAIChatCompletion {
+	messages := resp.ModelResponse.Choices
+	if len(messages) == 0 {
+		messages = []ChatMessage{resp.ModelResponse.Message}
+	}
+
+	choices := make([]Choice, 0, len(messages))
+
+	for idx, message := range messages {
+		message.Role = RoleAssistant
+
+		// not all providers report finish reasons, so it's inferred from the message otherwise
+		finishReason := FinishReasonStop
+		if len(message.ToolCalls) > 0 {
+			finishReason = FinishReasonToolCalls
+		}
+
+		if idx == 0 && resp.ModelResponse.FinishReason != "" {
+			finishReason = resp.ModelResponse.FinishReason
+		}
+
+		choice := Choice{
+			Index:        idx,
+			Message:      message,
+			FinishReason: finishReason,
+		}
+
+		if idx == 0 {
+			choice.Logprobs = resp.ModelResponse.Logprobs
+		}
+
+		choices = append(choices, choice)
+	}
+
+	created := resp.Created
+	if created == 0 {
+		created = int(time.Now().Unix())
+	}
+
+	usage := resp.ModelResponse.TokenUsage
+
+	systemFingerprint := resp.ModelResponse.SystemFingerprint
+	if systemFingerprint == "" {
+		systemFingerprint = resp.ModelResponse.SystemID["system_fingerprint"]
+	}
+
+	return &OpenAIChatCompletion{
+		ID:                resp.ID,
+		Object:            "chat.completion",
+		Created:           created,
+		Model:             model,
+		SystemFingerprint: systemFingerprint,
+		Choices:           choices,
+		Usage: Usage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.ResponseTokens,
+			TotalTokens:      usage.TotalTokens,
+		},
+	}
+}
+
+// OpenAIErrorResponse is the error shape OpenAI clients expect
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+type OpenAIError struct {
+	Message string    `json:"message"`
+	Type    string    `json:"type"`
+	Code    ErrorCode `json:"code"`
+}
+
+// OpenAIStreamDone is the data of the last event of OpenAI streams
+const OpenAIStreamDone = "[DONE]"
+
+// OpenAIChatCompletionChunk is the streamed chunk of the OpenAI chat completion
+// (https://platform.openai.com/docs/api-reference/chat/streaming)
+type OpenAIChatCompletionChunk struct {
+	ID                string              `json:"id"`
+	Object            string              `json:"object"`
+	Created           int                 `json:"created"`
+	Model             string              `json:"model"`
+	SystemFingerprint string              `json:"system_fingerprint"`
+	Choices           []OpenAIChunkChoice `json:"choices"`
+	Usage             *Usage              `json:"usage,omitempty"` // only set on the usage chunk if the client has asked for it
+}
+
+type OpenAIChunkChoice struct {
+	Index        int              `json:"index"`
+	Delta        OpenAIChunkDelta `json:"delta"`
+	Logprobs     json.RawMessage  `json:"logprobs"`
+	FinishReason *string          `json:"finish_reason"` // null until the last chunk
+}
+
+// OpenAIChunkDelta is the part of the message generated since the previous chunk
+type OpenAIChunkDelta struct {
+	Role      string                `json:"role,omitempty"` // only set on the first chunk
+	Content   string                `json:"content,omitempty"`
+	ToolCalls []OpenAIChunkToolCall `json:"tool_calls,omitempty"`
+}
+
+// OpenAIChunkToolCall is the tool call in the streamed chunk. The index tells which tool call the delta belongs to
+type OpenAIChunkToolCall struct {
+	Index int `json:"index"`
+	ToolCall
+}
+
+// NewOpenAICompatChatCompletionChunk translates the unified stream chunk into the OpenAI one.
+// The role is only sent with the first chunk of the stream like OpenAI does
+func NewOpenAICompatChatCompletionChunk(model string, chunk *UnifiedChatStreamChunk, first bool) *OpenAIChatCompletionChunk {
+	message := chunk.ModelResponse.Message
+
+	delta := OpenAIChunkDelta{Content: message.Content}
+
+	if first {
+		delta.Role = RoleAssistant
+	}
+
+	for idx, toolCall := range message.ToolCalls {
+		delta.ToolCalls = append(delta.ToolCalls, OpenAIChunkToolCall{Index: idx, ToolCall: toolCall})
+	}
+
+	var finishReason *string
+
+	if chunk.FinishReason != "" {
+		finishReason = &chunk.FinishReason
+	}
+
+	created := chunk.Created
+	if created == 0 {
+		created = int(time.Now().Unix())
+	}
+
+	return &OpenAIChatCompletionChunk{
+		ID:                chunk.ID,
+		Object:            "chat.completion.chunk",
+		Created:           created,
+		Model:             model,
+		SystemFingerprint: chunk.ModelResponse.SystemID["system_fingerprint"],
+		Choices: []OpenAIChunkChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	}
+}
+
+// NewOpenAICompatUsageChunk is the chunk OpenAI sends after the last one when clients ask for the token usage.
+// It has no choices
+func NewOpenAICompatUsageChunk(model string, chunk *UnifiedChatStreamChunk, usage *TokenUsage) *OpenAIChatCompletionChunk {
+	usageChunk := NewOpenAICompatChatCompletionChunk(model, chunk, false)
+
+	usageChunk.Choices = []OpenAIChunkChoice{}
+	usageChunk.Usage = &Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.ResponseTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+
+	return usageChunk
+}
@@ -0,0 +1,136 @@
+package schemas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAICompatChatRequest_ToUnifiedRequest(t *testing.T) {
+	payload := `{
+		"model": "gpt-4o",
+		"messages": [
+			{"role": "system", "content": "You are a helpful assistant."},
+			{"role": "user", "content": [{"type": "text", "text": "What's on the image?"}, {"type": "image_url", "image_url": {"url": "https://example.com/cat.png"}}]}
+		],
+		"temperature": 0.2,
+		"max_completion_tokens": 100,
+		"stop": "\n",
+		"seed": 42,
+		"tools": [{"type": "function", "function": {"name": "get_weather"}}],
+		"tool_choice": {"type": "function", "function": {"name": "get_weather"}},
+		"logit_bias": {"50256": -100},
+		"logprobs": true,
+		"top_logprobs": 3,
+		"parallel_tool_calls": false,
+		"user": "user-1"
+	}`
+
+	var openAIReq OpenAICompatChatRequest
+
+	require.NoError(t, json.Unmarshal([]byte(payload), &openAIReq))
+	require.Equal(t, []string{"parallel_tool_calls"}, openAIReq.IgnoredParams)
+
+	req, err := openAIReq.ToUnifiedRequest()
+	require.NoError(t, err)
+	require.NoError(t, req.Validate())
+
+	require.Len(t, req.Messages, 2)
+	require.True(t, req.HasImages())
+	require.Equal(t, 42, *req.Seed)
+	require.Equal(t, "user-1", req.User)
+	require.Equal(t, ToolChoice{Type: ToolChoiceFunction, Name: "get_weather"}, *req.ToolChoice)
+	require.Equal(t, map[string]float64{"50256": -100}, req.LogitBias)
+	require.True(t, req.Logprobs)
+	require.Equal(t, 3, *req.TopLogprobs)
+
+	require.NotNil(t, req.Override.Params)
+	require.InDelta(t, 0.2, *req.Override.Params.Temperature, 0.0001)
+	require.Equal(t, 100, *req.Override.Params.MaxTokens)
+	require.Equal(t, []string{"\n"}, req.Override.Params.Stop)
+}
+
+func TestOpenAICompatChatRequest_Streaming(t *testing.T) {
+	var openAIReq OpenAICompatChatRequest
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"model": "gpt-4o",
+		"messages": [{"role": "user", "content": "hi"}],
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"tool_choice": "auto"
+	}`), &openAIReq))
+	require.Empty(t, openAIReq.IgnoredParams)
+	require.Equal(t, ToolChoiceAuto, openAIReq.ToolChoice.Type)
+	require.True(t, openAIReq.Stream)
+	require.True(t, openAIReq.IncludeUsage())
+
+	req, err := openAIReq.ToUnifiedRequest()
+	require.NoError(t, err)
+	require.Equal(t, "hi", req.Messages[0].Content)
+}
+
+func TestNewOpenAICompatChatCompletionChunk(t *testing.T) {
+	chunk := &UnifiedChatStreamChunk{
+		ID:      "rsp0001",
+		Created: 1700000000,
+		ModelID: "first",
+		ModelResponse: ProviderChunkResponse{
+			SystemID: map[string]string{"system_fingerprint": "fp_0001"},
+			Message:  ChatMessage{Role: RoleAssistant, Content: "Hel"},
+		},
+	}
+
+	rawChunk, err := json.Marshal(NewOpenAICompatChatCompletionChunk("my-router", chunk, true))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": "rsp0001",
+		"object": "chat.completion.chunk",
+		"created": 1700000000,
+		"model": "my-router",
+		"system_fingerprint": "fp_0001",
+		"choices": [{"index": 0, "delta": {"role": "assistant", "content": "Hel"}, "logprobs": null, "finish_reason": null}]
+	}`, string(rawChunk))
+
+	chunk.ModelResponse.Message.Content = "lo"
+	chunk.FinishReason = FinishReasonStop
+
+	lastChunk := NewOpenAICompatChatCompletionChunk("my-router", chunk, false)
+	require.Empty(t, lastChunk.Choices[0].Delta.Role)
+	require.Equal(t, "lo", lastChunk.Choices[0].Delta.Content)
+	require.Equal(t, FinishReasonStop, *lastChunk.Choices[0].FinishReason)
+	require.Nil(t, lastChunk.Usage)
+
+	usageChunk := NewOpenAICompatUsageChunk("my-router", chunk, &TokenUsage{PromptTokens: 3, ResponseTokens: 2, TotalTokens: 5})
+	require.Empty(t, usageChunk.Choices)
+	require.Equal(t, Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}, *usageChunk.Usage)
+}
+
+func TestNewOpenAICompatChatCompletion(t *testing.T) {
+	resp := &UnifiedChatResponse{
+		ID:      "rsp0001",
+		ModelID: "first",
+		ModelResponse: ProviderResponse{
+			SystemID: map[string]string{"system_fingerprint": "fp_0001"},
+			Message: ChatMessage{
+				ToolCalls: []ToolCall{{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: "{}"}}},
+			},
+			TokenUsage: TokenUsage{PromptTokens: 10, ResponseTokens: 5, TotalTokens: 15},
+		},
+	}
+
+	completion := NewOpenAICompatChatCompletion("gpt-4o", resp)
+
+	require.Equal(t, "rsp0001", completion.ID)
+	require.Equal(t, "chat.completion", completion.Object)
+	require.Equal(t, "gpt-4o", completion.Model)
+	require.Equal(t, "fp_0001", completion.SystemFingerprint)
+	require.NotZero(t, completion.Created)
+
+	require.Len(t, completion.Choices, 1)
+	require.Equal(t, RoleAssistant, completion.Choices[0].Message.Role)
+	require.Equal(t, FinishReasonToolCalls, completion.Choices[0].FinishReason)
+
+	require.Equal(t, Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, completion.Usage)
+}
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers/cache"
+	"go.uber.org/zap"
+)
+
+// localCache is the replica cache of values of the given type (e.g. cache.Cache or cache.IdempotencyCache)
+type localCache[V any] interface {
+	Get(ctx context.Context, key string) (*V, bool)
+	Set(ctx context.Context, key string, value *V)
+}
+
+// sharedCache layers the local router cache over Redis, so replicas share cached values.
+// Local hits are served without a Redis round trip & values found in Redis are kept locally for next hits.
+// The local cache takes over while Redis is unavailable, so requests don't wait for Redis timeouts during outages
+type sharedCache[V any] struct {
+	cluster *Cluster
+	local   localCache[V]
+	ttl     time.Duration
+	shared  func(value *V) // marks values fetched from Redis
+}
+
+// Cache shares the local router cache with other replicas
+func (c *Cluster) Cache(local cache.Cache, ttl time.Duration) cache.Cache {
+	return &sharedCache[schemas.UnifiedChatResponse]{
+		cluster: c,
+		local:   local,
+		ttl:     ttl,
+		shared: func(response *schemas.UnifiedChatResponse) {
+			response.Cached = true
+		},
+	}
+}
+
+// IdempotencyCache shares the local idempotency cache with other replicas,
+// so a request repeated on another replica is still replayed
+func (c *Cluster) IdempotencyCache(local cache.IdempotencyCache, ttl time.Duration) cache.IdempotencyCache {
+	return &sharedCache[cache.IdempotencyEntry]{
+		cluster: c,
+		local:   local,
+		ttl:     ttl,
+		shared:  func(*cache.IdempotencyEntry) {},
+	}
+}
+
+func (c *sharedCache[V]) Get(ctx context.Context, key string) (*V, bool) {
+	if value, found := c.local.Get(ctx, key); found {
+		return value, true
+	}
+
+	if !c.cluster.available.Load() {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cluster.config.Redis.Timeout)
+	defer cancel()
+
+	rawValue, err := c.cluster.client.Get(ctx, c.cluster.key("cache:"+key)).Bytes()
+	c.cluster.track("cache get", err)
+
+	if err != nil {
+		return nil, false
+	}
+
+	var value V
+
+	if err := json.Unmarshal(rawValue, &value); err != nil {
+		c.cluster.logger.Warn("failed to decode the cached value, skipping it", zap.Error(err))
+
+		return nil, false
+	}
+
+	c.local.Set(ctx, key, &value)
+	c.shared(&value)
+
+	return &value, true
+}
+
+func (c *sharedCache[V]) Set(ctx context.Context, key string, value *V) {
+	c.local.Set(ctx, key, value)
+
+	rawValue, err := json.Marshal(value)
+	if err != nil {
+		c.cluster.logger.Warn("failed to encode the value for the shared cache", zap.Error(err))
+
+		return
+	}
+
+	// the response is already served, so the request doesn't wait for Redis
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.cluster.config.Redis.Timeout)
+		defer cancel()
+
+		err := c.cluster.client.Set(ctx, c.cluster.key("cache:"+key), rawValue, c.ttl).Err()
+		c.cluster.track("cache set", err)
+	}()
+}
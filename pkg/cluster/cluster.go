@@ -0,0 +1,200 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidRedisCA = errors.New("no valid certificates found in the Redis CA bundle")
+
+const (
+	minProbeInterval = time.Second
+	maxProbeInterval = 30 * time.Second
+)
+
+// Cluster shares state between gateway replicas via Redis.
+// Redis failures never fail requests, replicas just fall back to their local state until Redis is back
+type Cluster struct {
+	config *Config
+	client *redis.Client
+	// replicaID tells events of this replica apart from events of other ones
+	replicaID string
+	logger    *zap.Logger
+	// available is false after Redis calls have started to fail, so the outage is logged once rather than per call
+	// and reads on the request path skip Redis until it's back
+	available atomic.Bool
+	// probeInterval is the first delay between pings while Redis is unavailable (it's doubled up to maxProbeInterval)
+	probeInterval time.Duration
+	subscription  *redis.PubSub
+	closed        chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewCluster connects to Redis. Redis being unavailable on startup is not an error, the connection is probed in the background
+func NewCluster(cfg *Config, tel *telemetry.Telemetry) (*Cluster, error) {
+	options := &redis.Options{
+		Addr:     cfg.Redis.Address,
+		Username: cfg.Redis.Username,
+		Password: cfg.Redis.Password.Value(),
+		DB:       cfg.Redis.DB,
+		// calls on the request path are bounded by context deadlines
+		ContextTimeoutEnabled: true,
+	}
+
+	if cfg.Redis.TLS != nil {
+		tlsConfig, err := newTLSConfig(cfg.Redis.TLS)
+		if err != nil {
+			return nil, err
+		}
+
+		options.TLSConfig = tlsConfig
+	}
+
+	cluster := &Cluster{
+		config:        cfg,
+		client:        redis.NewClient(options),
+		replicaID:     newReplicaID(),
+		logger:        tel.Logger.With(zap.String("redis", cfg.Redis.Address)),
+		probeInterval: minProbeInterval,
+		closed:        make(chan struct{}),
+	}
+
+	cluster.available.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+	defer cancel()
+
+	cluster.track("ping", cluster.client.Ping(ctx).Err())
+
+	return cluster, nil
+}
+
+// SharesCache checks if router response caches should be shared between replicas
+func (c *Cluster) SharesCache() bool {
+	return c != nil && c.config.ShareCache
+}
+
+// SharesRateLimits checks if model rate limits should be shared between replicas
+func (c *Cluster) SharesRateLimits() bool {
+	return c != nil && c.config.ShareRateLimits
+}
+
+// Close stops the rate limit subscription & closes Redis connections
+func (c *Cluster) Close() error {
+	var err error
+
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		if c.subscription != nil {
+			_ = c.subscription.Close()
+		}
+
+		err = c.client.Close()
+	})
+
+	return err
+}
+
+// key namespaces the Redis key (or channel) with the configured prefix
+func (c *Cluster) key(name string) string {
+	return c.config.Redis.KeyPrefix + name
+}
+
+// track logs Redis outages & recoveries once per transition rather than on every call
+func (c *Cluster) track(operation string, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		if c.available.CompareAndSwap(false, true) {
+			c.logger.Info("redis is available again, sharing state with other replicas")
+		}
+
+		return
+	}
+
+	if c.available.CompareAndSwap(true, false) {
+		c.logger.Warn(
+			"redis is unavailable, falling back to the local state",
+			zap.String("operation", operation),
+			zap.Error(err),
+		)
+
+		go c.probe(c.probeInterval)
+
+		return
+	}
+
+	c.logger.Debug("redis call failed", zap.String("operation", operation), zap.Error(err))
+}
+
+// probe pings Redis on a backoff until it's available again, since reads skip Redis in the meantime
+// and so could not tell when it's back
+func (c *Cluster) probe(interval time.Duration) {
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-time.After(interval):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.config.Redis.Timeout)
+		c.track("ping", c.client.Ping(ctx).Err())
+		cancel()
+
+		if c.available.Load() {
+			return
+		}
+
+		interval = min(2*interval, maxProbeInterval)
+	}
+}
+
+func newTLSConfig(cfg *RedisTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if cfg.CAFile == "" {
+		return tlsConfig, nil
+	}
+
+	rawCA, err := os.ReadFile(filepath.Clean(cfg.CAFile))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read Redis CA bundle %v: %w", cfg.CAFile, err)
+	}
+
+	rootCAs := x509.NewCertPool()
+
+	if !rootCAs.AppendCertsFromPEM(rawCA) {
+		return nil, fmt.Errorf("unable to parse Redis CA bundle %v: %w", cfg.CAFile, ErrInvalidRedisCA)
+	}
+
+	tlsConfig.RootCAs = rootCAs
+
+	return tlsConfig, nil
+}
+
+func newReplicaID() string {
+	id := make([]byte, 8)
+
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,173 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers/cache"
+	"glide/pkg/telemetry"
+)
+
+func newTestCluster(t *testing.T, address string) *Cluster {
+	cfg := DefaultConfig()
+	cfg.Redis = DefaultRedisConfig()
+	cfg.Redis.Address = address
+
+	cluster, err := NewCluster(cfg, telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = cluster.Close() })
+
+	return cluster
+}
+
+func TestCluster_SharesCachedResponses(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	ctx := context.Background()
+
+	firstReplica := newTestCluster(t, redisServer.Addr()).Cache(cache.NewMemoryCache(time.Minute, 10), time.Minute)
+	secondReplica := newTestCluster(t, redisServer.Addr()).Cache(cache.NewMemoryCache(time.Minute, 10), time.Minute)
+
+	firstReplica.Set(ctx, "key", &schemas.UnifiedChatResponse{ID: "resp", RouterID: "router"})
+
+	require.Eventually(t, func() bool {
+		return redisServer.Exists("glide:cache:key")
+	}, time.Second, 5*time.Millisecond)
+
+	require.Equal(t, time.Minute, redisServer.TTL("glide:cache:key"))
+
+	resp, found := secondReplica.Get(ctx, "key")
+	require.True(t, found)
+	require.True(t, resp.Cached)
+	require.Equal(t, "resp", resp.ID)
+	require.Equal(t, "router", resp.RouterID)
+
+	// the response found in Redis is kept by the local cache of the replica
+	redisServer.FlushAll()
+
+	resp, found = secondReplica.Get(ctx, "key")
+	require.True(t, found)
+	require.Equal(t, "resp", resp.ID)
+}
+
+func TestCluster_SharesIdempotencyEntries(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	ctx := context.Background()
+
+	firstReplica := newTestCluster(t, redisServer.Addr()).IdempotencyCache(cache.NewMemoryIdempotencyCache(time.Minute, 10), time.Minute)
+	secondReplica := newTestCluster(t, redisServer.Addr()).IdempotencyCache(cache.NewMemoryIdempotencyCache(time.Minute, 10), time.Minute)
+
+	firstReplica.Set(ctx, "key", &cache.IdempotencyEntry{
+		Fingerprint: "fingerprint",
+		Response:    &schemas.UnifiedChatResponse{ID: "resp"},
+	})
+
+	require.Eventually(t, func() bool {
+		return redisServer.Exists("glide:cache:key")
+	}, time.Second, 5*time.Millisecond)
+
+	entry, found := secondReplica.Get(ctx, "key")
+	require.True(t, found)
+	require.Equal(t, "fingerprint", entry.Fingerprint)
+	require.Equal(t, "resp", entry.Response.ID)
+}
+
+func TestCluster_CacheFallsBackToLocalWhenRedisIsDown(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	ctx := context.Background()
+
+	cluster := newTestCluster(t, redisServer.Addr())
+	sharedCache := cluster.Cache(cache.NewMemoryCache(time.Minute, 10), time.Minute)
+
+	redisServer.Close()
+
+	sharedCache.Set(ctx, "key", &schemas.UnifiedChatResponse{ID: "resp"})
+
+	resp, found := sharedCache.Get(ctx, "key")
+	require.True(t, found)
+	require.Equal(t, "resp", resp.ID)
+
+	startedAt := time.Now()
+
+	_, found = sharedCache.Get(ctx, "missing")
+	require.False(t, found)
+	require.Less(t, time.Since(startedAt), time.Second)
+
+	require.Eventually(t, func() bool {
+		return !cluster.available.Load()
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCluster_CacheSkipsRedisUntilItIsBack(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	ctx := context.Background()
+
+	cluster := newTestCluster(t, redisServer.Addr())
+	cluster.probeInterval = time.Hour
+	sharedCache := cluster.Cache(cache.NewMemoryCache(time.Minute, 10), time.Minute)
+
+	redisServer.Close()
+
+	_, found := sharedCache.Get(ctx, "key")
+	require.False(t, found)
+	require.False(t, cluster.available.Load())
+
+	require.NoError(t, redisServer.Restart())
+
+	rawResponse, err := json.Marshal(&schemas.UnifiedChatResponse{ID: "resp"})
+	require.NoError(t, err)
+	require.NoError(t, redisServer.Set("glide:cache:key", string(rawResponse)))
+
+	// Redis is not asked until the probe finds it's back
+	_, found = sharedCache.Get(ctx, "key")
+	require.False(t, found)
+
+	go cluster.probe(5 * time.Millisecond)
+
+	require.Eventually(t, cluster.available.Load, time.Second, 5*time.Millisecond)
+
+	resp, found := sharedCache.Get(ctx, "key")
+	require.True(t, found)
+	require.Equal(t, "resp", resp.ID)
+}
+
+func TestCluster_SharesRateLimits(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+
+	firstReplica := newTestCluster(t, redisServer.Addr())
+	secondReplica := newTestCluster(t, redisServer.Addr())
+
+	firstEvents := make(chan *RateLimitEvent, 1)
+	secondEvents := make(chan *RateLimitEvent, 1)
+
+	firstReplica.SubscribeRateLimits(func(event *RateLimitEvent) { firstEvents <- event })
+	secondReplica.SubscribeRateLimits(func(event *RateLimitEvent) { secondEvents <- event })
+
+	require.Eventually(t, func() bool {
+		return len(redisServer.PubSubNumSub("glide:ratelimits")) == 1 &&
+			redisServer.PubSubNumSub("glide:ratelimits")["glide:ratelimits"] == 2
+	}, time.Second, 5*time.Millisecond)
+
+	firstReplica.PublishRateLimit("router", "model", time.Minute)
+
+	select {
+	case event := <-secondEvents:
+		require.Equal(t, "router", event.RouterID)
+		require.Equal(t, "model", event.ModelID)
+		require.InDelta(t, time.Minute, event.UntilReset(), float64(time.Second))
+	case <-time.After(time.Second):
+		t.Fatal("the rate limit has not been shared with the other replica")
+	}
+
+	// replicas don't receive their own events
+	select {
+	case <-firstEvents:
+		t.Fatal("the replica has received its own rate limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
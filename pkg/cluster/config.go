@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"time"
+
+	"glide/pkg/config/fields"
+)
+
+// Config defines state shared between gateway replicas
+type Config struct {
+	Redis *RedisConfig `yaml:"redis" json:"redis" validate:"required"`
+	// ShareCache makes router response caches shared, so a response cached by one replica is served by all of them
+	ShareCache bool `yaml:"share_cache" json:"share_cache"`
+	// ShareRateLimits makes replicas back off from models rate limited on any replica
+	ShareRateLimits bool `yaml:"share_rate_limits" json:"share_rate_limits"`
+}
+
+// RedisConfig defines how to connect to Redis
+type RedisConfig struct {
+	Address   string          `yaml:"address" json:"address" validate:"required"` // host:port
+	Username  string          `yaml:"username,omitempty" json:"username,omitempty"`
+	Password  fields.Secret   `yaml:"password,omitempty" json:"-"`
+	DB        int             `yaml:"db,omitempty" json:"db" validate:"gte=0"`
+	TLS       *RedisTLSConfig `yaml:"tls,omitempty" json:"tls,omitempty"`                                     // connect over TLS (disabled by default)
+	KeyPrefix string          `yaml:"key_prefix" json:"key_prefix"`                                           // namespaces keys & channels, so Redis could be shared with other apps
+	Timeout   time.Duration   `yaml:"timeout" json:"timeout" swaggertype:"primitive,integer" validate:"gt=0"` // Redis calls on the request path never take longer than that
+}
+
+// RedisTLSConfig defines how to verify the Redis server
+type RedisTLSConfig struct {
+	CAFile             string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"` // Path to the CA bundle to verify the server with (system CAs by default)
+	ServerName         string `yaml:"server_name,omitempty" json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		ShareCache:      true,
+		ShareRateLimits: true,
+	}
+}
+
+func DefaultRedisConfig() *RedisConfig {
+	return &RedisConfig{
+		KeyPrefix: "glide:",
+		Timeout:   100 * time.Millisecond,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+func (c *RedisConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultRedisConfig()
+
+	type plain RedisConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const rateLimitChannel = "ratelimits"
+
+// RateLimitEvent tells replicas that the model has been rate limited by its provider
+type RateLimitEvent struct {
+	ReplicaID string    `json:"replica_id"`
+	RouterID  string    `json:"router_id"`
+	ModelID   string    `json:"model_id"`
+	ResetAt   time.Time `json:"reset_at"` // absolute, so the delivery delay doesn't prolong the limit
+}
+
+// UntilReset returns how long the model stays rate limited
+func (e *RateLimitEvent) UntilReset() time.Duration {
+	return time.Until(e.ResetAt)
+}
+
+// PublishRateLimit notifies other replicas about the model rate limit. It doesn't wait for Redis
+func (c *Cluster) PublishRateLimit(routerID string, modelID string, untilReset time.Duration) {
+	event, err := json.Marshal(RateLimitEvent{
+		ReplicaID: c.replicaID,
+		RouterID:  routerID,
+		ModelID:   modelID,
+		ResetAt:   time.Now().Add(untilReset),
+	})
+	if err != nil {
+		c.logger.Warn("failed to encode the rate limit event", zap.Error(err))
+
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.config.Redis.Timeout)
+		defer cancel()
+
+		err := c.client.Publish(ctx, c.key(rateLimitChannel), event).Err()
+		c.track("rate limit publish", err)
+	}()
+}
+
+// SubscribeRateLimits passes rate limits published by other replicas to the handler until the cluster is closed.
+// The subscription is restored automatically after Redis outages
+func (c *Cluster) SubscribeRateLimits(handler func(event *RateLimitEvent)) {
+	// Redis may be unavailable on startup, then the subscription is made once it's back
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Redis.Timeout)
+	defer cancel()
+
+	c.subscription = c.client.Subscribe(ctx, c.key(rateLimitChannel))
+
+	messages := c.subscription.Channel()
+
+	go func() {
+		for message := range messages {
+			var event RateLimitEvent
+
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				c.logger.Warn("failed to decode the rate limit event, skipping it", zap.Error(err))
+
+				continue
+			}
+
+			if event.ReplicaID == c.replicaID || event.UntilReset() <= 0 {
+				continue
+			}
+
+			handler(&event)
+		}
+	}()
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"time"
+
 	"glide/pkg"
 	"glide/pkg/config"
 
 	"github.com/spf13/cobra"
 )
 
-var cfgFile string
+var (
+	cfgFiles           []string
+	configPollInterval time.Duration
+)
 
 const Description = `
  ██████╗ ██╗     ██╗██████╗ ███████╗
@@ -36,7 +41,7 @@ func NewCLI() *cobra.Command {
 		Long:    Description,
 		Version: pkg.FullVersion,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			configProvider, err := config.NewProvider().Load(cfgFile)
+			configProvider, err := config.NewProvider().WithPollInterval(configPollInterval).Load(cfgFiles...)
 			if err != nil {
 				return err
 			}
@@ -52,8 +57,25 @@ func NewCLI() *cobra.Command {
 		SilenceErrors: true,
 	}
 
-	cli.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file")
+	cli.PersistentFlags().StringArrayVarP(
+		&cfgFiles,
+		"config",
+		"c",
+		nil,
+		"config file path, directory, HTTP(S) URL or S3 location (s3://bucket/key). "+
+			"Repeat the flag to deep-merge several configs, later ones override earlier ones",
+	)
+	cli.Flags().DurationVar(
+		&configPollInterval,
+		"config-poll-interval",
+		config.DefaultPollInterval,
+		"how often remote configs are checked for changes",
+	)
 	_ = cli.MarkPersistentFlagRequired("config")
 
+	cli.AddCommand(NewValidateCmd())
+	cli.AddCommand(NewPingCmd())
+	cli.AddCommand(NewSchemaCmd())
+
 	return cli
 }
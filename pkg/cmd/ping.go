@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/config"
+	"glide/pkg/providers"
+	"glide/pkg/telemetry"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrPingFailed         = errors.New("some models failed to respond")
+	ErrNoModelsToPing     = errors.New("no configured models match the filters")
+	ErrPingTargetRequired = errors.New("specify models to ping with --provider, --model or --all")
+)
+
+// pingMaxTokens keeps ping responses (and their costs) minimal
+const pingMaxTokens = 5
+
+type pingOptions struct {
+	provider string
+	modelID  string
+	all      bool
+	timeout  time.Duration
+	message  string
+}
+
+// NewPingCmd creates a command that checks credentials & connectivity of configured models by sending them a tiny chat request
+func NewPingCmd() *cobra.Command {
+	opts := pingOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "ping",
+		Short: "Send a minimal chat request to configured models",
+		Long: "Build models out of the config the same way the gateway does and send each of them a minimal chat request " +
+			"to verify credentials and connectivity. Exits with non-zero code if any model fails",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pingModels(cmd.Context(), cmd.OutOrStdout(), cfgFiles, &opts)
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().StringVar(&opts.provider, "provider", "", "ping models of the provider (e.g. openai)")
+	cmd.Flags().StringVar(&opts.modelID, "model", "", "ping the model with the ID")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "ping all matching models instead of the first one")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of each ping")
+	cmd.Flags().StringVar(&opts.message, "message", "ping", "content of the message sent to models")
+
+	return cmd
+}
+
+// pingTarget is a model picked for ping along with the router it's configured in
+type pingTarget struct {
+	routerID string
+	model    providers.LanguageModel
+}
+
+func pingModels(ctx context.Context, out io.Writer, configPaths []string, opts *pingOptions) error {
+	if opts.provider == "" && opts.modelID == "" && !opts.all {
+		return ErrPingTargetRequired
+	}
+
+	configProvider, err := config.NewProvider().Load(configPaths...)
+	if err != nil {
+		return reportProblems(out, strings.Join(configPaths, ", "), config.Problems(err))
+	}
+
+	targets, err := pingTargets(configProvider.Get(), opts)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, target := range targets {
+		if err := ping(ctx, out, target, opts); err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w (%d of %d)", ErrPingFailed, failed, len(targets))
+	}
+
+	return nil
+}
+
+// pingTargets builds enabled models matching the filters. Only the first one is picked unless all are requested
+func pingTargets(cfg *config.Config, opts *pingOptions) ([]pingTarget, error) {
+	tel := telemetry.NewTelemetryMock()
+
+	var targets []pingTarget
+
+	for _, routerConfig := range cfg.Routers.LanguageRouters {
+		if !routerConfig.Enabled {
+			continue
+		}
+
+		for _, modelConfig := range routerConfig.Models {
+			if !modelConfig.Enabled || (opts.modelID != "" && modelConfig.ID != opts.modelID) {
+				continue
+			}
+
+			model, err := modelConfig.ToModel(tel)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build model %q of router %q: %w", modelConfig.ID, routerConfig.ID, err)
+			}
+
+			if opts.provider != "" && model.Provider() != opts.provider {
+				continue
+			}
+
+			targets = append(targets, pingTarget{routerID: routerConfig.ID, model: model})
+
+			if !opts.all {
+				return targets, nil
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, ErrNoModelsToPing
+	}
+
+	return targets, nil
+}
+
+func ping(ctx context.Context, out io.Writer, target pingTarget, opts *pingOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	maxTokens := pingMaxTokens
+
+	request := &schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{{Role: schemas.RoleUser, Content: opts.message}},
+		Override: schemas.OverrideChatRequest{
+			Params: &schemas.ChatParams{MaxTokens: &maxTokens},
+		},
+	}
+
+	name := fmt.Sprintf("%v/%v (%v)", target.routerID, target.model.ID(), target.model.Provider())
+
+	startedAt := time.Now()
+	resp, err := target.model.Chat(ctx, request)
+	latency := time.Since(startedAt).Round(time.Millisecond)
+
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "❌ %v failed in %v: %v\n", name, latency, err)
+
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "✅ %v responded in %v (model: %v)\n", name, latency, resp.Model)
+
+	return nil
+}
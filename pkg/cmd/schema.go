@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+
+	"glide/pkg/config"
+	"glide/pkg/config/jsonschema"
+
+	"github.com/spf13/cobra"
+)
+
+// NewSchemaCmd creates a command that prints the JSON Schema of the config (e.g. for editor autocompletion or CI checks)
+func NewSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the config file",
+		Long: "Print the JSON Schema describing the whole config structure. The schema is derived from the config structs " +
+			"and their validation rules, so it's always in sync with the gateway version",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printSchema(cmd.OutOrStdout())
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	// the schema doesn't depend on any config, so the required config flag of the root command is shadowed
+	cmd.Flags().StringArrayVarP(&cfgFiles, "config", "c", nil, "not used")
+	_ = cmd.Flags().MarkHidden("config")
+
+	return cmd
+}
+
+func printSchema(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(jsonschema.Generate(config.DefaultConfig()))
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"glide/pkg"
+	"glide/pkg/config"
+	"glide/pkg/routers"
+	"glide/pkg/telemetry"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+)
+
+var ErrConfigInvalid = errors.New("config is invalid")
+
+// NewValidateCmd creates a command that checks the config file without starting the gateway (e.g. in CI before deploys)
+func NewValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the config file and exit",
+		Long: "Load the config file, run all validations and try to build routers without starting servers. " +
+			"Exits with non-zero code if any problems are found",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return validateConfig(cmd.OutOrStdout(), cfgFiles)
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+}
+
+func validateConfig(out io.Writer, configPaths []string) error {
+	configPath := strings.Join(configPaths, ", ")
+
+	configProvider, err := config.NewProvider().Load(configPaths...)
+	if err != nil {
+		return reportProblems(out, configPath, config.Problems(err))
+	}
+
+	// routers & servers are built the same way the gateway does, so configs it rejects on startup don't pass
+	if err := pkg.CheckConfig(configProvider.Get(), telemetry.NewTelemetryMock()); err != nil {
+		return reportProblems(out, configPath, startupProblems(err))
+	}
+
+	_, _ = fmt.Fprintf(out, "✅ %v is valid\n", configPath)
+
+	return nil
+}
+
+// startupProblems points errors of building routers to the routers & models they have come from
+func startupProblems(err error) []config.Problem {
+	var problems []config.Problem
+
+	for _, err := range multierr.Errors(err) {
+		var routerErr *routers.RouterConfigError
+
+		if !errors.As(err, &routerErr) {
+			problems = append(problems, config.Problem{Message: err.Error()})
+
+			continue
+		}
+
+		routerPath := fmt.Sprintf("routers.language[%d]", routerErr.Index)
+
+		for _, err := range multierr.Errors(routerErr.Err) {
+			var modelErr *routers.ModelConfigError
+
+			if errors.As(err, &modelErr) {
+				problems = append(problems, config.Problem{
+					Path:    fmt.Sprintf("%v.models[%d]", routerPath, modelErr.Index),
+					Message: modelErr.Err.Error(),
+				})
+
+				continue
+			}
+
+			problems = append(problems, config.Problem{Path: routerPath, Message: err.Error()})
+		}
+	}
+
+	return problems
+}
+
+func reportProblems(out io.Writer, configPath string, problems []config.Problem) error {
+	_, _ = fmt.Fprintf(out, "❌ found %d problem(s) in %v:\n", len(problems), configPath)
+
+	for _, problem := range problems {
+		_, _ = fmt.Fprintf(out, "  - %v\n", problem)
+	}
+
+	return ErrConfigInvalid
+}
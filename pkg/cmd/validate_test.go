@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func runValidate(t *testing.T, configPath string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	cli := NewCLI()
+	cli.SetOut(&out)
+	cli.SetArgs([]string{"validate", "--config", configPath})
+
+	err := cli.Execute()
+
+	return out.String(), err
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	// models refer to global aliases & routers could serve global prompts
+	out, err := runValidate(t, "./testdata/validate.valid.yaml")
+	require.NoError(t, err, out)
+	require.Contains(t, out, "is valid")
+}
+
+func TestValidate_ReportsSchemaProblems(t *testing.T) {
+	out, err := runValidate(t, "./testdata/validate.schema.yaml")
+	require.ErrorIs(t, err, ErrConfigInvalid)
+	require.Contains(t, out, "routers.language[0].models[1].anthropic.api_key")
+}
+
+func TestValidate_ReportsModelProblems(t *testing.T) {
+	out, err := runValidate(t, "./testdata/validate.model.yaml")
+	require.ErrorIs(t, err, ErrConfigInvalid)
+	require.Contains(t, out, "found 1 problem(s)")
+	require.Contains(t, out, "routers.language[0].models[1]: ")
+	require.Contains(t, out, "unknown-encoding")
+}
+
+func TestValidate_ReportsServerProblems(t *testing.T) {
+	// the gateway fails to start without TLS certificates, so the config should not pass either
+	out, err := runValidate(t, "./testdata/validate.tls.yaml")
+	require.ErrorIs(t, err, ErrConfigInvalid)
+	require.Contains(t, out, "unable to load TLS cert/key pair")
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"glide/pkg/api"
+	"glide/pkg/cluster"
+	"glide/pkg/config/secrets"
 	"glide/pkg/routers"
 	"glide/pkg/telemetry"
 )
@@ -11,12 +13,31 @@ type Config struct {
 	Telemetry *telemetry.Config `yaml:"telemetry" validate:"required"`
 	API       *api.Config       `yaml:"api" validate:"required"`
 	Routers   routers.Config    `yaml:"routers" validate:"required"`
+	Secrets   *secrets.Config   `yaml:"secrets,omitempty"`
+	Cluster   *cluster.Config   `yaml:"cluster,omitempty"` // shares state between gateway replicas (disabled by default)
+	// Startup defines checks run before the gateway serves traffic (provider verification is disabled by default)
+	Startup *routers.StartupConfig `yaml:"startup,omitempty"`
+	// Experimental enables features that are not meant for production (disabled by default)
+	Experimental *ExperimentalConfig `yaml:"experimental,omitempty"`
+}
+
+// ExperimentalConfig gates features that could harm production traffic
+type ExperimentalConfig struct {
+	// Chaos allows models to inject faults into requests (e.g. for resilience testing in staging).
+	// It could not be enabled by config reloads, so it has to be set when the gateway starts
+	Chaos bool `yaml:"chaos"`
+}
+
+// ChaosEnabled tells if models are allowed to inject faults
+func (c *Config) ChaosEnabled() bool {
+	return c.Experimental != nil && c.Experimental.Chaos
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		Telemetry: telemetry.DefaultConfig(),
 		API:       api.DefaultConfig(),
+		Secrets:   secrets.DefaultConfig(),
 		// Routers should be defined by users
 	}
 }
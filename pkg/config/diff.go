@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lineDiff renders a unified-like line diff between two config revisions.
+// Unchanged lines are omitted, so only removed (-) and added (+) lines are listed along with their line numbers
+func lineDiff(before, after string) string {
+	beforeLines := strings.Split(before, "\n")
+	afterLines := strings.Split(after, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of beforeLines[i:] and afterLines[j:]
+	lcs := make([][]int, len(beforeLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(afterLines)+1)
+	}
+
+	for i := len(beforeLines) - 1; i >= 0; i-- {
+		for j := len(afterLines) - 1; j >= 0; j-- {
+			if beforeLines[i] == afterLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+				continue
+			}
+
+			lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+		}
+	}
+
+	var diff strings.Builder
+
+	i, j := 0, 0
+
+	for i < len(beforeLines) || j < len(afterLines) {
+		switch {
+		case i < len(beforeLines) && j < len(afterLines) && beforeLines[i] == afterLines[j]:
+			i++
+			j++
+		case i < len(beforeLines) && (j == len(afterLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&diff, "- %4d | %s\n", i+1, beforeLines[i])
+			i++
+		default:
+			fmt.Fprintf(&diff, "+ %4d | %s\n", j+1, afterLines[j])
+			j++
+		}
+	}
+
+	return diff.String()
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidConfig      = errors.New("invalid config file")
+	ErrChaosEnabledReload = errors.New("experimental.chaos could not be enabled by config reloads, please restart the gateway")
+)
+
+// Problem is one issue found in the config file
+type Problem struct {
+	Path    string // YAML path to the problematic field (e.g. routers.language[0].models[2].anthropic.api_key), empty if unknown
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+
+	return fmt.Sprintf("%v: %v", p.Path, p.Message)
+}
+
+// ValidationError lists all problems found in the config file, so they could be reported at once
+type ValidationError struct {
+	ConfigPath string
+	Problems   []Problem
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+
+	for _, problem := range e.Problems {
+		problems = append(problems, fmt.Sprintf("- ❌ %v", problem))
+	}
+
+	return fmt.Sprintf(
+		"%v %v:\n%v\nPlease make sure the config file is properly formatted",
+		ErrInvalidConfig,
+		e.ConfigPath,
+		strings.Join(problems, "\n"),
+	)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// Problems extracts the list of problems out of config loading errors
+func Problems(err error) []Problem {
+	var validationErr *ValidationError
+
+	if errors.As(err, &validationErr) {
+		return validationErr.Problems
+	}
+
+	var refsErr *UnresolvedReferencesError
+
+	if errors.As(err, &refsErr) {
+		problems := make([]Problem, 0, len(refsErr.References))
+
+		for _, ref := range refsErr.References {
+			problems = append(problems, Problem{Message: fmt.Sprintf("%v %v", ErrUnresolvedReference, ref)})
+		}
+
+		return problems
+	}
+
+	return []Problem{{Message: err.Error()}}
+}
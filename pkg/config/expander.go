@@ -1,82 +1,134 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strings"
 )
 
-// Expander finds special directives like ${env:ENV_VAR} in the config file and fill them with actual values
-type Expander struct{}
+var ErrUnresolvedReference = errors.New("unresolved config reference")
 
-func (e *Expander) Expand(content []byte) []byte {
-	expandedContent := string(content)
+// UnresolvedReferencesError lists all references that could not be expanded in the config file
+type UnresolvedReferencesError struct {
+	References []string
+}
 
-	expandedContent = e.expandEnvVarDirectives(expandedContent)
-	expandedContent = e.expandFileDirectives(expandedContent)
-	expandedContent = e.expandEnvVars(expandedContent)
+func (e *UnresolvedReferencesError) Error() string {
+	refs := make([]string, 0, len(e.References))
 
-	return []byte(expandedContent)
-}
+	for _, ref := range e.References {
+		refs = append(refs, fmt.Sprintf("- ❌ %v", ref))
+	}
 
-// expandEnvVars expands $ENVAR
-func (e *Expander) expandEnvVars(content string) string {
-	return os.Expand(content, func(str string) string {
-		// This allows escaping environment variable substitution via $$, e.g.
-		// - $FOO will be substituted with env var FOO
-		// - $$FOO will be replaced with $FOO
-		// - $$$FOO will be replaced with $ + substituted env var FOO
-		if str == "$" {
-			return "$"
-		}
+	return fmt.Sprintf(
+		"%v(s):\n%v\nPlease make sure referenced env vars are set and files exist or provide defaults via ${VAR:-default}",
+		ErrUnresolvedReference,
+		strings.Join(refs, "\n"),
+	)
+}
 
-		return os.Getenv(str)
-	})
+func (e *UnresolvedReferencesError) Unwrap() error {
+	return ErrUnresolvedReference
 }
 
-// expandEnvVarDirectives expands ${env:ENVAR} directives
-func (e *Expander) expandEnvVarDirectives(content string) string {
-	dirMatcher := regexp.MustCompile(`\$\{env:(.+?)\}`)
+// Expander finds references like ${ENV_VAR}, ${ENV_VAR:-default}, ${env:ENV_VAR}, ${file:/path/to/file}
+// or $ENV_VAR in the config file and fill them with actual values.
+// Braced references without defaults are required, so all of them that could not be resolved are reported at once
+type Expander struct{}
+
+func (e *Expander) Expand(content []byte) ([]byte, error) {
+	var (
+		expanded   strings.Builder
+		unresolved []string
+	)
 
-	return dirMatcher.ReplaceAllStringFunc(content, func(match string) string {
-		matches := dirMatcher.FindStringSubmatch(match)
+	str := string(content)
+	expanded.Grow(len(str))
 
-		if len(matches) != 2 {
-			return match // No replacement if the pattern is not matched
+	for i := 0; i < len(str); i++ {
+		if str[i] != '$' || i+1 == len(str) {
+			expanded.WriteByte(str[i])
+			continue
 		}
 
-		envVarName := matches[1]
-		value, exists := os.LookupEnv(envVarName)
+		next := str[i+1]
+
+		switch {
+		case next == '$':
+			// This allows escaping substitution via $$, e.g.
+			// - $FOO will be substituted with env var FOO
+			// - $$FOO will be replaced with $FOO
+			// - $$$FOO will be replaced with $ + substituted env var FOO
+			expanded.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(str[i+2:], '}')
+			if end == -1 {
+				expanded.WriteString(str[i:])
+				i = len(str)
+
+				continue
+			}
+
+			ref := str[i+2 : i+2+end]
+
+			value, err := e.resolve(ref)
+			if err != nil {
+				unresolved = append(unresolved, fmt.Sprintf("${%v}: %v", ref, err))
+			}
+
+			expanded.WriteString(value)
+			i += end + 2
+		case isEnvVarNameChar(next):
+			end := i + 1
+			for end < len(str) && isEnvVarNameChar(str[end]) {
+				end++
+			}
+
+			// bare references are optional for the sake of backward compatibility
+			expanded.WriteString(os.Getenv(str[i+1 : end]))
+			i = end - 1
+		default:
+			expanded.WriteByte('$')
+		}
+	}
 
-		if !exists {
-			log.Printf("could not expand the env var directive: \"%s\" variable is not found", envVarName)
+	if len(unresolved) > 0 {
+		return nil, &UnresolvedReferencesError{References: unresolved}
+	}
+
+	return []byte(expanded.String()), nil
+}
 
-			return ""
+// resolve expands the content of a braced reference
+func (e *Expander) resolve(ref string) (string, error) {
+	if filePath, found := strings.CutPrefix(ref, "file:"); found {
+		content, err := os.ReadFile(filepath.Clean(filePath))
+		if err != nil {
+			return "", fmt.Errorf("could not read file: %w", err)
 		}
 
-		return value
-	})
-}
+		return string(content), nil
+	}
 
-// expandFileDirectives expands ${file:/path/to/file} directives
-func (e *Expander) expandFileDirectives(content string) string {
-	dirMatcher := regexp.MustCompile(`\$\{file:(.+?)\}`)
+	ref = strings.TrimPrefix(ref, "env:")
+	envVarName, defaultValue, hasDefault := strings.Cut(ref, ":-")
 
-	return dirMatcher.ReplaceAllStringFunc(content, func(match string) string {
-		matches := dirMatcher.FindStringSubmatch(match)
+	value, exists := os.LookupEnv(envVarName)
 
-		if len(matches) != 2 {
-			return match // No replacement if the pattern is not matched
-		}
+	if hasDefault && value == "" {
+		return defaultValue, nil
+	}
 
-		filePath := matches[1]
-		content, err := os.ReadFile(filepath.Clean(filePath))
-		if err != nil {
-			log.Printf("could not expand the file directive (${file:%s}): %v", filePath, err)
-			return match // Return original match if there's an error
-		}
+	if !exists {
+		return "", errors.New("env var is not set")
+	}
+
+	return value, nil
+}
 
-		return string(content)
-	})
+func isEnvVarNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
 }
@@ -46,7 +46,8 @@ func TestExpander_EnvVarExpanded(t *testing.T) {
 	require.NoError(t, err)
 
 	expander := Expander{}
-	updatedContent := expander.Expand(content)
+	updatedContent, err := expander.Expand(content)
+	require.NoError(t, err)
 
 	var cfg *sampleConfig
 
@@ -62,3 +63,33 @@ func TestExpander_EnvVarExpanded(t *testing.T) {
 	assert.Equal(t, topP, cfg.Params[0].Value)
 	assert.Equal(t, fmt.Sprintf("$%v", budget), cfg.Params[1].Value)
 }
+
+func TestExpander_DefaultsApplied(t *testing.T) {
+	t.Setenv("GLIDE_EMPTY_VAR", "")
+
+	expander := Expander{}
+	updatedContent, err := expander.Expand([]byte("name: ${GLIDE_UNSET_VAR:-OpenAI}\napi_key: ${env:GLIDE_EMPTY_VAR:-ABC}"))
+	require.NoError(t, err)
+
+	var cfg *sampleConfig
+
+	err = yaml.Unmarshal(updatedContent, &cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "OpenAI", cfg.Name)
+	assert.Equal(t, "ABC", cfg.APIKey)
+}
+
+func TestExpander_UnresolvedReferencesAggregated(t *testing.T) {
+	expander := Expander{}
+	_, err := expander.Expand([]byte("name: ${GLIDE_UNSET_NAME}\napi_key: ${env:GLIDE_UNSET_KEY}\nseeds: [\"${file:./testdata/doesntexist}\"]"))
+
+	var refsErr *UnresolvedReferencesError
+
+	require.ErrorIs(t, err, ErrUnresolvedReference)
+	require.ErrorAs(t, err, &refsErr)
+	require.Len(t, refsErr.References, 3)
+	require.ErrorContains(t, err, "GLIDE_UNSET_NAME")
+	require.ErrorContains(t, err, "GLIDE_UNSET_KEY")
+	require.ErrorContains(t, err, "doesntexist")
+}
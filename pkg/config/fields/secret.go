@@ -2,16 +2,93 @@ package fields
 
 import (
 	"encoding"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // Secret is a string that is marshaled in an opaque way, so we are not leaking sensitive information
 type Secret string
 
-const maskedSecret = "[REDACTED]"
+const (
+	maskedSecret = "[REDACTED]"
+	// fileSecretPrefix allows to read secrets from files (e.g. Kubernetes secret volumes) like file:///var/run/secrets/openai
+	fileSecretPrefix = "file://"
+)
 
-var _ encoding.TextMarshaler = Secret("")
+var (
+	_ encoding.TextMarshaler   = Secret("")
+	_ encoding.TextUnmarshaler = (*Secret)(nil)
+)
 
 // MarshalText marshals the secret as `[REDACTED]`.
 func (s Secret) MarshalText() ([]byte, error) {
 	return []byte(maskedSecret), nil
 }
+
+// UnmarshalText reads the secret value. Values prefixed with file:// are read from the referenced file
+func (s *Secret) UnmarshalText(text []byte) error {
+	value := string(text)
+
+	secretPath, isFile := strings.CutPrefix(value, fileSecretPrefix)
+	if !isFile {
+		*s = Secret(value)
+
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Clean(secretPath))
+	if err != nil {
+		return fmt.Errorf("unable to read secret file %v: %w", secretPath, err)
+	}
+
+	// mounted secrets often end with a newline which is not a part of the secret
+	*s = Secret(strings.TrimSpace(string(content)))
+
+	return nil
+}
+
+// resolvedSecrets holds values of secret references (e.g. vault://secret/data/glide#openai_api_key)
+// fetched by secret resolvers. Values are updated in place when secrets get rotated
+var resolvedSecrets sync.Map
+
+// IsReference is true when the secret points to an external secret store rather than holds the value itself
+func (s Secret) IsReference() bool {
+	scheme, _, found := strings.Cut(string(s), "://")
+
+	return found && scheme != "" && !strings.ContainsAny(scheme, " \t\n")
+}
+
+// Scheme returns the secret store scheme of the reference (e.g. vault)
+func (s Secret) Scheme() string {
+	scheme, _, _ := strings.Cut(string(s), "://")
+
+	return scheme
+}
+
+// Ref returns the reference without the scheme (e.g. secret/data/glide#openai_api_key)
+func (s Secret) Ref() string {
+	_, ref, _ := strings.Cut(string(s), "://")
+
+	return ref
+}
+
+// Value returns the actual secret value. References return the most recently resolved value
+func (s Secret) Value() string {
+	if !s.IsReference() {
+		return string(s)
+	}
+
+	value, _ := resolvedSecrets.Load(s)
+
+	valueStr, _ := value.(string)
+
+	return valueStr
+}
+
+// SetResolvedValue stores the value fetched for the secret reference
+func SetResolvedValue(s Secret, value string) {
+	resolvedSecrets.Store(s, value)
+}
@@ -1,6 +1,8 @@
 package fields
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -30,3 +32,40 @@ func TestSecret_OpaqueOnMarshaling(t *testing.T) {
 	assert.Contains(t, rawConfigStr, maskedSecret)
 	assert.Contains(t, rawConfigStr, name)
 }
+
+func TestSecret_ReadFromFile(t *testing.T) {
+	secretValue := "ABCDE123"
+	secretPath := filepath.Join(t.TempDir(), "openai")
+
+	require.NoError(t, os.WriteFile(secretPath, []byte(secretValue+"\n"), 0o600))
+
+	var config struct {
+		APIKey Secret `yaml:"api_key"`
+	}
+
+	err := yaml.Unmarshal([]byte("api_key: file://"+secretPath), &config)
+	require.NoError(t, err)
+
+	assert.Equal(t, Secret(secretValue), config.APIKey)
+
+	err = yaml.Unmarshal([]byte("api_key: file://"+secretPath+".missing"), &config)
+	require.ErrorContains(t, err, "unable to read secret file")
+}
+
+func TestSecret_ReferenceValue(t *testing.T) {
+	plainSecret := Secret("ABCDE123")
+
+	assert.False(t, plainSecret.IsReference())
+	assert.Equal(t, "ABCDE123", plainSecret.Value())
+
+	refSecret := Secret("vault://secret/data/glide#openai_api_key")
+
+	assert.True(t, refSecret.IsReference())
+	assert.Equal(t, "vault", refSecret.Scheme())
+	assert.Equal(t, "secret/data/glide#openai_api_key", refSecret.Ref())
+	assert.Empty(t, refSecret.Value())
+
+	SetResolvedValue(refSecret, "rotated")
+
+	assert.Equal(t, "rotated", refSecret.Value())
+}
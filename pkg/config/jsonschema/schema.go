@@ -0,0 +1,379 @@
+package jsonschema
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Draft is the JSON Schema version schemas are generated for
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a subset of JSON Schema that is enough to describe config structures
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"` // either a type name or the list of them
+	Format               string             `json:"format,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // either the schema of values or false
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// AlternativeForms is implemented by config types that could be given in other forms besides the mapping
+// (e.g. a model alias could be just the target name). Values of the forms are used to derive their schemas
+type AlternativeForms interface {
+	AlternativeForms() []interface{}
+}
+
+// ExtraProperties is implemented by config types that take properties besides their fields
+// (e.g. model configs take configs of providers registered at runtime). Values are used to derive schemas of the properties
+type ExtraProperties interface {
+	ExtraProperties() map[string]interface{}
+}
+
+var (
+	durationType         = reflect.TypeOf(time.Duration(0))
+	timeType             = reflect.TypeOf(time.Time{})
+	textUnmarshalerType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	alternativeFormsType = reflect.TypeOf((*AlternativeForms)(nil)).Elem()
+	extraPropertiesType  = reflect.TypeOf((*ExtraProperties)(nil)).Elem()
+)
+
+// Generate derives the schema of the config from its Go structure: YAML tags name properties, validation tags
+// turn into required properties & constraints, and defaults are taken from the given value (for the root)
+// or from the YAML unmarshaling of empty mappings (for nested structs), so properties that have defaults are not required
+func Generate(config interface{}) *Schema {
+	g := &generator{defs: make(map[string]*Schema)}
+
+	value := reflect.Indirect(reflect.ValueOf(config))
+
+	schema := g.structSchema(value.Type(), value)
+	schema.Schema = Draft
+	schema.Defs = g.defs
+
+	return schema
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+func (g *generator) typeSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		// durations are given either as strings (e.g. 2s) or as nanoseconds
+		return &Schema{Type: []string{"string", "integer"}}
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		// interfaces could hold anything
+		return &Schema{}
+	}
+}
+
+// structRef refers to the struct definition, so structs used in several places are described once
+func (g *generator) structRef(t reflect.Type) *Schema {
+	name := strings.ReplaceAll(t.String(), "*", "")
+	ref := &Schema{Ref: "#/$defs/" + name}
+
+	if _, found := g.defs[name]; found {
+		return ref
+	}
+
+	g.defs[name] = nil // reserved, so recursive structs refer to themselves
+
+	schema := g.structSchema(t, defaultValue(t))
+
+	if t.Implements(alternativeFormsType) || reflect.PointerTo(t).Implements(alternativeFormsType) {
+		forms := reflect.New(t).Interface().(AlternativeForms).AlternativeForms()
+		schema = &Schema{OneOf: []*Schema{schema}}
+
+		for _, form := range forms {
+			schema.OneOf = append(schema.OneOf, g.typeSchema(reflect.TypeOf(form)))
+		}
+	}
+
+	g.defs[name] = schema
+
+	return ref
+}
+
+// structSchema describes struct fields as properties. Unknown properties are not allowed, so typos are caught
+func (g *generator) structSchema(t reflect.Type, defaults reflect.Value) *Schema {
+	schema := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	g.addFields(schema, t, defaults)
+
+	if t.Implements(extraPropertiesType) || reflect.PointerTo(t).Implements(extraPropertiesType) {
+		for name, value := range reflect.New(t).Interface().(ExtraProperties).ExtraProperties() {
+			schema.Properties[name] = g.typeSchema(reflect.TypeOf(value))
+		}
+	}
+
+	return schema
+}
+
+func (g *generator) addFields(schema *Schema, t reflect.Type, defaults reflect.Value) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := yamlName(field)
+		if skip {
+			continue
+		}
+
+		fieldDefault := defaults.Field(idx)
+
+		if inline {
+			g.addFields(schema, field.Type, fieldDefault)
+
+			continue
+		}
+
+		fieldSchema := g.typeSchema(field.Type)
+		required := applyRules(fieldSchema, field.Type, field.Tag.Get("validate"))
+
+		if fieldDefault.IsZero() {
+			if required {
+				schema.Required = append(schema.Required, name)
+			}
+		} else {
+			fieldSchema.Default = scalarDefault(fieldDefault)
+		}
+
+		schema.Properties[name] = fieldSchema
+	}
+}
+
+// yamlName returns the property name the field is given under the same way yaml.v3 does
+func yamlName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	inline := strings.Contains(options, "inline")
+
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name, inline, false
+}
+
+// defaultValue returns defaults the struct gets when it's unmarshaled from the empty mapping.
+// Errors are ignored, as the empty mapping doesn't have to be a valid config
+func defaultValue(t reflect.Type) reflect.Value {
+	value := reflect.New(t)
+	_ = yaml.Unmarshal([]byte("{}"), value.Interface())
+
+	return value.Elem()
+}
+
+// scalarDefault returns the default of scalar fields (nested structures are described by their own schemas)
+func scalarDefault(value reflect.Value) interface{} {
+	value = reflect.Indirect(value)
+
+	if value.Type() == durationType {
+		return time.Duration(value.Int()).String()
+	}
+
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		if text, err := marshaler.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value.Uint()
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.String:
+		return value.String()
+	default:
+		return nil
+	}
+}
+
+// applyRules translates validation rules into schema constraints. Rules after "dive" apply to elements of slices & maps.
+// It returns true if the field is required
+func applyRules(schema *Schema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	rules := strings.Split(tag, ",")
+	required := false
+	inKeys := false
+
+	for idx, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch {
+		case name == "keys":
+			inKeys = true
+		case name == "endkeys":
+			inKeys = false
+		case inKeys:
+			continue
+		case name == "dive":
+			elemSchema := schema.Items
+
+			if t.Kind() == reflect.Map {
+				elemSchema, _ = schema.AdditionalProperties.(*Schema)
+			}
+
+			if elemSchema != nil && elemSchema.Ref == "" {
+				applyRules(elemSchema, t.Elem(), strings.Join(rules[idx+1:], ","))
+			}
+
+			return required
+		case name == "required":
+			required = true
+		default:
+			applyConstraint(schema, t, name, param)
+		}
+	}
+
+	return required
+}
+
+// applyConstraint translates the rule into the schema constraint. Rules that have no counterparts are skipped
+func applyConstraint(schema *Schema, t reflect.Type, name string, param string) {
+	if name == "oneof" {
+		for _, option := range strings.Fields(param) {
+			schema.Enum = append(schema.Enum, enumValue(t, option))
+		}
+
+		return
+	}
+
+	if name == "url" {
+		schema.Format = "uri"
+
+		return
+	}
+
+	if t == durationType {
+		// duration bounds can't be expressed for string durations
+		return
+	}
+
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		applyCount(&schema.MinLength, &schema.MaxLength, name, int(bound))
+	case reflect.Slice, reflect.Array:
+		applyCount(&schema.MinItems, &schema.MaxItems, name, int(bound))
+	case reflect.Map:
+		applyCount(&schema.MinProperties, &schema.MaxProperties, name, int(bound))
+	default:
+		switch name {
+		case "min", "gte":
+			schema.Minimum = &bound
+		case "max", "lte":
+			schema.Maximum = &bound
+		case "gt":
+			schema.ExclusiveMinimum = &bound
+		case "lt":
+			schema.ExclusiveMaximum = &bound
+		}
+	}
+}
+
+func applyCount(minCount **int, maxCount **int, name string, bound int) {
+	switch name {
+	case "min", "gte":
+		*minCount = &bound
+	case "gt":
+		*minCount = intPtr(bound + 1)
+	case "max", "lte":
+		*maxCount = &bound
+	case "lt":
+		*maxCount = intPtr(bound - 1)
+	}
+}
+
+func enumValue(t reflect.Type, option string) interface{} {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value, err := strconv.ParseInt(option, 10, 64); err == nil {
+			return value
+		}
+	case reflect.Float32, reflect.Float64:
+		if value, err := strconv.ParseFloat(option, 64); err == nil {
+			return value
+		}
+	}
+
+	return option
+}
+
+func intPtr(value int) *int {
+	return &value
+}
@@ -0,0 +1,93 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type retryConfig struct {
+	MaxRetries int           `yaml:"max_retries" validate:"required,gte=0"`
+	Backoff    time.Duration `yaml:"backoff,omitempty" validate:"gt=0"`
+}
+
+type modelConfig struct {
+	ID       string             `yaml:"id" validate:"required"`
+	Enabled  bool               `yaml:"enabled" validate:"required"`
+	Strategy string             `yaml:"strategy,omitempty" validate:"oneof=priority round_robin"`
+	Weights  map[string]float64 `yaml:"weights,omitempty" validate:"omitempty,dive,gt=0,lte=1"`
+	Retry    *retryConfig       `yaml:"retry,omitempty"`
+	internal string
+}
+
+func (c *modelConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = modelConfig{Enabled: true, Strategy: "priority"}
+
+	type plain modelConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+type aliasConfig struct {
+	Target string `yaml:"target" validate:"required"`
+}
+
+func (c *aliasConfig) AlternativeForms() []interface{} {
+	return []interface{}{""}
+}
+
+type httpConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type rootConfig struct {
+	Models  []modelConfig           `yaml:"models" validate:"required,min=1"`
+	Aliases map[string]*aliasConfig `yaml:"aliases,omitempty"`
+	Retry   *retryConfig            `yaml:"retry" validate:"required"`
+	HTTP    httpConfig              `yaml:",inline"`
+	Ignored string                  `yaml:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Generate(&rootConfig{Retry: &retryConfig{MaxRetries: 3}})
+
+	require.Equal(t, Draft, schema.Schema)
+	require.Equal(t, false, schema.AdditionalProperties)
+
+	// properties with defaults are not required
+	require.Equal(t, []string{"models"}, schema.Required)
+	require.Equal(t, 1, *schema.Properties["models"].MinItems)
+	require.Equal(t, "#/$defs/jsonschema.modelConfig", schema.Properties["models"].Items.Ref)
+
+	// inlined structs add their properties & durations could be given as strings
+	require.Equal(t, []string{"string", "integer"}, schema.Properties["timeout"].Type)
+	require.NotContains(t, schema.Properties, "Ignored")
+	require.NotContains(t, schema.Properties, "ignored")
+
+	// nested structs take defaults from their YAML unmarshaling
+	model := schema.Defs["jsonschema.modelConfig"]
+	require.Equal(t, []string{"id"}, model.Required)
+	require.Equal(t, true, model.Properties["enabled"].Default)
+	require.Equal(t, "priority", model.Properties["strategy"].Default)
+	require.Equal(t, []interface{}{"priority", "round_robin"}, model.Properties["strategy"].Enum)
+	require.NotContains(t, model.Properties, "internal")
+
+	weight := model.Properties["weights"].AdditionalProperties.(*Schema)
+	require.Equal(t, 0.0, *weight.ExclusiveMinimum)
+	require.Equal(t, 1.0, *weight.Maximum)
+
+	retry := schema.Defs["jsonschema.retryConfig"]
+	require.Equal(t, []string{"max_retries"}, retry.Required)
+	require.Equal(t, 0.0, *retry.Properties["max_retries"].Minimum)
+
+	// types given in several forms are described by all of them
+	alias := schema.Defs["jsonschema.aliasConfig"]
+	require.Len(t, alias.OneOf, 2)
+	require.Equal(t, "object", alias.OneOf[0].Type)
+	require.Equal(t, "string", alias.OneOf[1].Type)
+
+	_, err := json.Marshal(schema)
+	require.NoError(t, err)
+}
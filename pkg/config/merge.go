@@ -0,0 +1,248 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrMergeConflict is returned when config files define the same key as values of different kinds
+var ErrMergeConflict = errors.New("config files have conflicting values")
+
+// mergeKey is the field list items are matched by when merging lists (e.g. routers & models)
+const mergeKey = "id"
+
+// MergedSource deep-merges configs from several sources. Later sources override earlier ones:
+//   - maps are merged key-wise
+//   - lists of items with IDs (e.g. routers & models) are merged by item IDs, new items are appended
+//   - other lists & scalars are replaced
+//
+// So a model could be turned off by an override that sets "enabled: false" for its ID,
+// and conflicting scalar values (e.g. router strategies) are taken from the last source.
+// Values of different kinds (e.g. a map overridden by a scalar) can't be merged, so they fail with ErrMergeConflict
+type MergedSource struct {
+	sources []Source
+}
+
+func NewMergedSource(sources ...Source) *MergedSource {
+	return &MergedSource{
+		sources: sources,
+	}
+}
+
+func (s *MergedSource) Fetch(ctx context.Context) ([]byte, error) {
+	var merged *yaml.Node
+
+	for _, source := range s.sources {
+		content, err := source.Fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var doc yaml.Node
+
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("unable to parse config file %v: %w", source.Location(), err)
+		}
+
+		if len(doc.Content) == 0 {
+			// empty file
+			continue
+		}
+
+		if merged == nil {
+			merged = doc.Content[0]
+			continue
+		}
+
+		merged, err = mergeNodes("", merged, doc.Content[0])
+		if err != nil {
+			return nil, fmt.Errorf("unable to merge config file %v: %w", source.Location(), err)
+		}
+	}
+
+	if merged == nil {
+		return []byte{}, nil
+	}
+
+	return yaml.Marshal(merged)
+}
+
+func (s *MergedSource) Location() string {
+	locations := make([]string, 0, len(s.sources))
+
+	for _, source := range s.sources {
+		locations = append(locations, source.Location())
+	}
+
+	return strings.Join(locations, ", ")
+}
+
+// Sources returns the merged sources in the merge order
+func (s *MergedSource) Sources() []Source {
+	return s.sources
+}
+
+// mergeNodes deep-merges the override node into the base node. The path is used to point to conflicting values
+func mergeNodes(path string, base *yaml.Node, override *yaml.Node) (*yaml.Node, error) {
+	base, override = resolveAlias(base), resolveAlias(override)
+
+	switch {
+	case base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode:
+		return mergeMappings(path, base, override)
+	case base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode && hasMergeKeys(base) && hasMergeKeys(override):
+		return mergeSequences(path, base, override)
+	case base.Kind != override.Kind && !isNull(base) && !isNull(override):
+		return nil, fmt.Errorf(
+			"%w: %v is a %v, while earlier config files define it as a %v",
+			ErrMergeConflict,
+			path,
+			nodeKind(override),
+			nodeKind(base),
+		)
+	default:
+		return override, nil
+	}
+}
+
+func mergeMappings(path string, base *yaml.Node, override *yaml.Node) (*yaml.Node, error) {
+	// mapping nodes keep keys & values as alternating items of the content
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+
+		if baseIdx := mappingValueIdx(base, key.Value); baseIdx >= 0 {
+			merged, err := mergeNodes(joinPath(path, key.Value), base.Content[baseIdx], value)
+			if err != nil {
+				return nil, err
+			}
+
+			base.Content[baseIdx] = merged
+
+			continue
+		}
+
+		base.Content = append(base.Content, key, value)
+	}
+
+	return base, nil
+}
+
+func mergeSequences(path string, base *yaml.Node, override *yaml.Node) (*yaml.Node, error) {
+	for _, item := range override.Content {
+		id := mergeKeyValue(item)
+
+		merged := false
+
+		for idx, baseItem := range base.Content {
+			if mergeKeyValue(baseItem) == id {
+				mergedItem, err := mergeNodes(fmt.Sprintf("%v[%d]", path, idx), baseItem, item)
+				if err != nil {
+					return nil, err
+				}
+
+				base.Content[idx] = mergedItem
+				merged = true
+
+				break
+			}
+		}
+
+		if !merged {
+			base.Content = append(base.Content, item)
+		}
+	}
+
+	return base, nil
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return node.Alias
+	}
+
+	return node
+}
+
+// isNull checks if the node has no value (e.g. a key without value), so it could be merged with values of any kind
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "map"
+	case yaml.SequenceNode:
+		return "list"
+	default:
+		return "scalar"
+	}
+}
+
+// hasMergeKeys checks if all list items are maps with IDs, so they could be merged item-wise
+func hasMergeKeys(node *yaml.Node) bool {
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode || mappingValueIdx(item, mergeKey) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mergeKeyValue(node *yaml.Node) string {
+	return node.Content[mappingValueIdx(node, mergeKey)].Value
+}
+
+// mappingValueIdx returns index of the value under the key in the mapping node or -1 if there is no such key
+func mappingValueIdx(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// configFilesInDir lists YAML files in the directory in the lexical order which is also the merge order
+func configFilesInDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config directory %v: %w", dir, err)
+	}
+
+	var files []string
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no YAML config files found in directory %v", dir)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type mergedRouter struct {
+	ID       string `yaml:"id"`
+	Strategy string `yaml:"strategy"`
+	Models   []struct {
+		ID      string         `yaml:"id"`
+		Enabled *bool          `yaml:"enabled"`
+		OpenAI  map[string]any `yaml:"openai"`
+		Cohere  map[string]any `yaml:"cohere"`
+	} `yaml:"models"`
+}
+
+type mergedConfig struct {
+	Telemetry struct {
+		Logging map[string]string `yaml:"logging"`
+	} `yaml:"telemetry"`
+	Routers struct {
+		Language []mergedRouter `yaml:"language"`
+	} `yaml:"routers"`
+}
+
+func fetchMerged(t *testing.T, locations ...string) mergedConfig {
+	sources := make([]Source, 0, len(locations))
+
+	for _, location := range locations {
+		source, err := NewSource(location)
+		require.NoError(t, err)
+
+		sources = append(sources, source)
+	}
+
+	content, err := NewMergedSource(sources...).Fetch(context.Background())
+	require.NoError(t, err)
+
+	var cfg mergedConfig
+
+	require.NoError(t, yaml.Unmarshal(content, &cfg))
+
+	return cfg
+}
+
+func TestMergedSource_MergesRoutersAndModelsByID(t *testing.T) {
+	cfg := fetchMerged(t, "./testdata/merge.routing.yaml", "./testdata/merge.credentials.yaml")
+
+	require.Equal(t, map[string]string{"level": "info", "encoding": "json"}, cfg.Telemetry.Logging)
+	require.Len(t, cfg.Routers.Language, 1)
+
+	router := cfg.Routers.Language[0]
+	require.Equal(t, "priority", router.Strategy)
+	require.Len(t, router.Models, 2)
+
+	require.Equal(t, "openai", router.Models[0].ID)
+	require.Equal(t, map[string]any{"model": "gpt-3.5-turbo", "api_key": "openai-key"}, router.Models[0].OpenAI)
+	require.Equal(t, "cohere", router.Models[1].ID)
+	require.Equal(t, map[string]any{"model": "command-light", "api_key": "cohere-key"}, router.Models[1].Cohere)
+}
+
+func TestMergedSource_LaterSourcesOverride(t *testing.T) {
+	cfg := fetchMerged(
+		t,
+		"./testdata/merge.routing.yaml",
+		"./testdata/merge.credentials.yaml",
+		"./testdata/merge.overrides.yaml",
+	)
+
+	// scalars are taken from the latest source, other keys of the same map are kept
+	require.Equal(t, map[string]string{"level": "debug", "encoding": "json"}, cfg.Telemetry.Logging)
+	require.Len(t, cfg.Routers.Language, 2)
+
+	// conflicting strategies are resolved in favor of the latest source
+	router := cfg.Routers.Language[0]
+	require.Equal(t, "round_robin", router.Strategy)
+
+	// models are turned off via the enabled flag rather than removed
+	require.Len(t, router.Models, 2)
+	require.Nil(t, router.Models[0].Enabled)
+	require.False(t, *router.Models[1].Enabled)
+	require.Equal(t, "command-light", router.Models[1].Cohere["model"])
+
+	require.Equal(t, "secondrouter", cfg.Routers.Language[1].ID)
+}
+
+func TestMergedSource_ReplacesListsWithoutIDs(t *testing.T) {
+	base := &yaml.Node{}
+	override := &yaml.Node{}
+
+	require.NoError(t, yaml.Unmarshal([]byte("stop: [a, b]\nname: base"), base))
+	require.NoError(t, yaml.Unmarshal([]byte("stop: [c]"), override))
+
+	mergedNode, err := mergeNodes("", base.Content[0], override.Content[0])
+	require.NoError(t, err)
+
+	merged, err := yaml.Marshal(mergedNode)
+	require.NoError(t, err)
+
+	require.Equal(t, "stop: [c]\nname: base\n", string(merged))
+}
+
+func TestMergedSource_ConflictingKindsRejected(t *testing.T) {
+	base := &yaml.Node{}
+	override := &yaml.Node{}
+
+	require.NoError(t, yaml.Unmarshal([]byte("routers:\n  language:\n    - id: myrouter\n      retry:\n        max_retries: 3"), base))
+	require.NoError(t, yaml.Unmarshal([]byte("routers:\n  language:\n    - id: myrouter\n      retry: 3"), override))
+
+	_, err := mergeNodes("", base.Content[0], override.Content[0])
+	require.ErrorIs(t, err, ErrMergeConflict)
+	require.ErrorContains(t, err, "routers.language[0].retry is a scalar, while earlier config files define it as a map")
+
+	// keys without values could be overridden by values of any kind
+	require.NoError(t, yaml.Unmarshal([]byte("cache:\nname: base"), base))
+	require.NoError(t, yaml.Unmarshal([]byte("cache:\n  ttl: 5m"), override))
+
+	_, err = mergeNodes("", base.Content[0], override.Content[0])
+	require.NoError(t, err)
+}
+
+func TestNewSource_MergesConfigDirectory(t *testing.T) {
+	source, err := NewSource("./testdata/merge.d")
+	require.NoError(t, err)
+
+	mergedSource, ok := source.(*MergedSource)
+	require.True(t, ok)
+	require.Len(t, mergedSource.Sources(), 2)
+
+	cfg := fetchMerged(t, "./testdata/merge.d")
+
+	require.Equal(t, "openai-key", cfg.Routers.Language[0].Models[0].OpenAI["api_key"])
+}
@@ -1,22 +1,62 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"glide/pkg/config/secrets"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
 
 	"github.com/go-playground/validator/v10"
 
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	// reloadDebounce groups bursts of file events (e.g. editors writing files in a few steps) into one reload
+	reloadDebounce = 200 * time.Millisecond
+	// DefaultPollInterval is how often remote configs are checked for changes
+	DefaultPollInterval = 30 * time.Second
+	// maxPollBackoff limits how long polling backs off when the remote config is unavailable
+	maxPollBackoff = 5 * time.Minute
+	// chaosRateTolerance absorbs float rounding of chaos rates adding up to exactly 1 (e.g. 0.1 + 0.2 + 0.7)
+	chaosRateTolerance = 1e-9
+)
+
 // Provider reads, collects, validates and process config files
 type Provider struct {
-	expander  *Expander
-	Config    *Config
-	validator *validator.Validate
+	expander   *Expander
+	Config     *Config
+	validator  *validator.Validate
+	mu         sync.RWMutex
+	configPath string
+	source     Source
+	configDirs map[string]bool
+	// pollInterval is how often remote configs are checked for changes
+	pollInterval time.Duration
+	// secretManager refreshes secrets referenced from external secret stores (e.g. Vault)
+	secretManager *secrets.Manager
+	rawConfig     []byte
+	logger        *zap.Logger
+	telemetry     *telemetry.Telemetry
+	updatedC      chan *Config
+	watcher       *fsnotify.Watcher
+	signalC       chan os.Signal
+	stopC         chan struct{}
+	stopOnce      sync.Once
 }
 
 // NewProvider creates a instance of Config Provider
@@ -33,100 +73,529 @@ func NewProvider() *Provider {
 		return name
 	})
 
+	_ = configValidator.RegisterValidation("overridable_header", func(fl validator.FieldLevel) bool {
+		return !clients.IsReservedHeader(fl.Field().String())
+	})
+
 	return &Provider{
-		expander:  &Expander{},
-		Config:    nil,
-		validator: configValidator,
+		expander:     &Expander{},
+		Config:       nil,
+		validator:    configValidator,
+		pollInterval: DefaultPollInterval,
+		updatedC:     make(chan *Config, 1),
+		signalC:      make(chan os.Signal, 1),
+		stopC:        make(chan struct{}),
 	}
 }
 
-func (p *Provider) Load(configPath string) (*Provider, error) {
-	content, err := os.ReadFile(filepath.Clean(configPath))
+// WithPollInterval sets how often remote configs are checked for changes
+func (p *Provider) WithPollInterval(interval time.Duration) *Provider {
+	p.pollInterval = interval
+
+	return p
+}
+
+// Load reads the config from the given locations.
+// A location is a local file path, a directory with YAML files, an HTTP(S) URL or an S3 object location (s3://bucket/key).
+// Configs from several locations are deep-merged in the given order (see MergedSource)
+func (p *Provider) Load(configPaths ...string) (*Provider, error) {
+	if len(configPaths) == 0 {
+		return p, ErrNoConfigLocations
+	}
+
+	sources := make([]Source, 0, len(configPaths))
+
+	for _, configPath := range configPaths {
+		source, err := NewSource(configPath)
+		if err != nil {
+			return p, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	if len(sources) == 1 {
+		return p.LoadFrom(sources[0])
+	}
+
+	return p.LoadFrom(NewMergedSource(sources...))
+}
+
+// LoadFrom reads the config from the given source
+func (p *Provider) LoadFrom(source Source) (*Provider, error) {
+	configPath := source.Location()
+
+	rawContent, err := p.read(source)
+	if err != nil {
+		return p, err
+	}
+
+	cfg, secretManager, err := p.parse(configPath, rawContent)
 	if err != nil {
-		return p, fmt.Errorf("unable to read config file %v: %w", configPath, err)
+		return p, err
 	}
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Config = cfg
+	p.configPath = configPath
+	p.source = source
+	p.rawConfig = rawContent
+	p.secretManager = secretManager
+
+	return p, nil
+}
+
+func (p *Provider) read(source Source) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	return source.Fetch(ctx)
+}
+
+func (p *Provider) parse(configPath string, rawContent []byte) (*Config, *secrets.Manager, error) {
 	// process raw config
-	content = p.expander.Expand(content)
+	content, err := p.expander.Expand(rawContent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to expand config file %v: %w", configPath, err)
+	}
 
 	// validate the config structure
 	cfg := DefaultConfig()
 
 	if err := yaml.Unmarshal(content, &cfg); err != nil {
-		return p, fmt.Errorf("unable to parse config file %v: %w", configPath, err)
+		return nil, nil, fmt.Errorf("unable to parse config file %v: %w", configPath, err)
 	}
 
 	err = p.validator.Struct(cfg)
 	if err != nil {
-		return p, p.formatValidationError(configPath, err)
+		return nil, nil, p.formatValidationError(configPath, err)
 	}
 
-	p.Config = cfg
+	// fetch secrets referenced from external secret stores
+	secretManager := secrets.NewManager(cfg.Secrets)
 
-	return p, nil
+	if err := secretManager.ResolveAll(context.Background(), cfg); err != nil {
+		return nil, nil, fmt.Errorf("unable to resolve secrets in config file %v: %w", configPath, err)
+	}
+
+	// keys are checked once they are resolved, so the gateway doesn't start with models that fail all requests
+	if err := validateAPIKeys(configPath, cfg); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateChaos(configPath, cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, secretManager, nil
+}
+
+// validateAPIKeys reports enabled models which providers require API keys, but got empty ones
+// (e.g. from env vars that are set to empty strings or secrets that resolved to nothing)
+func validateAPIKeys(configPath string, cfg *Config) error {
+	var problems []Problem
+
+	for routerIdx, routerConfig := range cfg.Routers.LanguageRouters {
+		if !routerConfig.Enabled {
+			continue
+		}
+
+		for modelIdx, modelConfig := range routerConfig.Models {
+			if !modelConfig.Enabled {
+				continue
+			}
+
+			provider, missing := modelConfig.MissingAPIKey()
+			if !missing {
+				continue
+			}
+
+			problems = append(problems, Problem{
+				Path: fmt.Sprintf("routers.language[%d].models[%d].%v.api_key", routerIdx, modelIdx, provider),
+				Message: fmt.Sprintf(
+					"API key of the %v provider is empty (router: %v, model: %v)",
+					provider,
+					routerConfig.ID,
+					modelConfig.ID,
+				),
+			})
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{
+		ConfigPath: configPath,
+		Problems:   problems,
+	}
+}
+
+// validateChaos makes sure fault injection is never configured by accident, as it's only allowed with experimental.chaos enabled,
+// and that injected faults don't fail more requests than there are
+func validateChaos(configPath string, cfg *Config) error {
+	var problems []Problem
+
+	for routerIdx, routerConfig := range cfg.Routers.LanguageRouters {
+		for modelIdx, modelConfig := range routerConfig.Models {
+			if modelConfig.Chaos == nil {
+				continue
+			}
+
+			path := fmt.Sprintf("routers.language[%d].models[%d].chaos", routerIdx, modelIdx)
+
+			if !cfg.ChaosEnabled() {
+				problems = append(problems, Problem{
+					Path:    path,
+					Message: "fault injection requires experimental.chaos to be enabled",
+				})
+			}
+
+			// faults are picked by one dice roll, so their rates could not add up to more than all requests
+			faultRate := modelConfig.Chaos.ErrorRate + modelConfig.Chaos.TimeoutRate + modelConfig.Chaos.RateLimitRate
+			if faultRate > 1+chaosRateTolerance {
+				problems = append(problems, Problem{
+					Path:    path,
+					Message: fmt.Sprintf("error_rate, timeout_rate & rate_limit_rate add up to %v, while they could not exceed 1", faultRate),
+				})
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{
+		ConfigPath: configPath,
+		Problems:   problems,
+	}
 }
 
 func (p *Provider) formatValidationError(configPath string, err error) error {
 	// this check is only needed when your code could produce
 	// an invalid value for validation such as interface with nil
 	// value most including myself do not usually have code like this.
-	if _, ok := err.(*validator.InvalidValidationError); ok {
-		return fmt.Errorf("invalid config file %v: %v", configPath, err)
+	var fieldErrs validator.ValidationErrors
+
+	if !errors.As(err, &fieldErrs) {
+		return &ValidationError{
+			ConfigPath: configPath,
+			Problems:   []Problem{{Message: err.Error()}},
+		}
 	}
 
-	errors := make([]string, 0, len(err.(validator.ValidationErrors)))
+	problems := make([]Problem, 0, len(fieldErrs))
 
-	for _, fieldErr := range err.(validator.ValidationErrors) {
-		errors = append(
-			errors,
-			fmt.Sprintf(
-				"- ❌ %v", p.formatFieldError(fieldErr),
-			),
-		)
+	for _, fieldErr := range fieldErrs {
+		problems = append(problems, Problem{
+			Path:    strings.TrimPrefix(fieldErr.Namespace(), "Config."),
+			Message: p.formatFieldError(fieldErr),
+		})
 	}
 
-	// from here you can create your own error messages in whatever language you wish
-	return fmt.Errorf(
-		"invalid config file %v:\n%v\nPlease make sure the config file is properly formatted",
-		configPath,
-		strings.Join(errors, "\n"),
-	)
+	return &ValidationError{
+		ConfigPath: configPath,
+		Problems:   problems,
+	}
 }
 
 func (p *Provider) formatFieldError(fieldErr validator.FieldError) string {
-	namespace := strings.TrimLeft(fieldErr.Namespace(), "Config.")
-
 	switch fieldErr.Tag() {
 	case "required":
-		return fmt.Sprintf(
-			"\"%v\"field is required, \"%v\" provided",
-			namespace,
-			fieldErr.Value(),
-		)
+		return "required"
 	case "min":
 		if fieldErr.Kind() == reflect.Map || fieldErr.Kind() == reflect.Slice {
-			return fmt.Sprintf("\"%v\" field must have at least %s element(s)", namespace, fieldErr.Param())
+			return fmt.Sprintf("must have at least %s element(s)", fieldErr.Param())
 		}
 
-		return fmt.Sprintf("\"%v\" field must have minimum value: %q", namespace, fieldErr.Param())
+		return fmt.Sprintf("must have minimum value: %q", fieldErr.Param())
+	case "overridable_header":
+		return fmt.Sprintf("%q header is set by the provider client and could not be overridden", fieldErr.Value())
 	default:
-		return fmt.Sprintf(
-			"\"%v\"field: %v",
-			namespace,
-			fieldErr.Tag(),
-		)
+		return fmt.Sprintf("%v validation failed (value: %v)", fieldErr.Tag(), fieldErr.Value())
 	}
 }
 
 func (p *Provider) Get() *Config {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	return p.Config
 }
 
 func (p *Provider) GetStr() string {
-	loadedConfig, _ := yaml.Marshal(p.Config)
+	loadedConfig, _ := yaml.Marshal(p.Get())
 
 	return string(loadedConfig)
 }
 
-func (p *Provider) Start() {
+// Updates returns a channel that receives new config revisions once they are successfully reloaded
+func (p *Provider) Updates() <-chan *Config {
+	return p.updatedC
+}
+
+// Reload re-reads the config. Invalid or unavailable configs are rejected and the previously loaded config is kept.
+// The returned config is nil if the config has not changed since the last load
+func (p *Provider) Reload() (*Config, error) {
+	p.mu.RLock()
+	configPath, source, prevRawContent, prevConfig := p.configPath, p.source, p.rawConfig, p.Config
+	p.mu.RUnlock()
+
+	rawContent, err := p.read(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(prevRawContent, rawContent) {
+		return nil, nil
+	}
+
+	cfg, secretManager, err := p.parse(configPath, rawContent)
+	if err != nil {
+		return nil, fmt.Errorf("%w\nConfig changes:\n%v", err, lineDiff(string(prevRawContent), string(rawContent)))
+	}
+
+	// fault injection must not make its way to production by a config change
+	if cfg.ChaosEnabled() && (prevConfig == nil || !prevConfig.ChaosEnabled()) {
+		return nil, ErrChaosEnabledReload
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Config = cfg
+	p.rawConfig = rawContent
+
+	p.secretManager.Stop()
+	p.secretManager = secretManager
+
+	if p.telemetry != nil {
+		p.secretManager.Start(p.telemetry)
+	}
+
+	return cfg, nil
+}
+
+// Start watches the loaded config file (or polls the remote config) & SIGHUP signal in background
+// and reloads the config on changes. Successfully reloaded configs are published via the Updates() channel
+func (p *Provider) Start(tel *telemetry.Telemetry) {
+	logger := tel.Logger
+
+	p.logger = logger
+	p.telemetry = tel
+
+	p.mu.RLock()
+	p.secretManager.Start(tel)
+	p.mu.RUnlock()
+
+	signal.Notify(p.signalC, syscall.SIGHUP)
+
+	configFiles, allLocal := localFiles(p.source)
+	if !allLocal {
+		go p.poll()
+
+		return
+	}
+
+	p.configDirs = make(map[string]bool, len(configFiles))
+
+	for _, configFile := range configFiles {
+		p.configDirs[filepath.Clean(filepath.Dir(configFile))] = true
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Warn("unable to init config file watcher, the config is reloaded only on SIGHUP", zap.Error(err))
+	}
+
+	// watch directories rather than files as configs are often updated by swapping symlinks (e.g. k8s config maps)
+	for configDir := range p.configDirs {
+		if watcher == nil {
+			break
+		}
+
+		if err := watcher.Add(configDir); err != nil {
+			logger.Warn(
+				"unable to watch config directory, the config is reloaded only on SIGHUP",
+				zap.String("dir", configDir),
+				zap.Error(err),
+			)
+
+			_ = watcher.Close()
+			watcher = nil
+		}
+	}
+
+	p.watcher = watcher
+
+	go p.watch()
+}
+
+// poll periodically checks the remote config for changes.
+// The polling backs off while the config is unavailable, the current config keeps serving meanwhile
+func (p *Provider) poll() {
+	failures := 0
+
+	pollTimer := time.NewTimer(p.pollInterval)
+	defer pollTimer.Stop()
+
+	for {
+		reason := "remote config polled"
+
+		select {
+		case <-pollTimer.C:
+		case <-p.signalC:
+			reason = "SIGHUP received"
+
+			if !pollTimer.Stop() {
+				<-pollTimer.C
+			}
+		case <-p.stopC:
+			return
+		}
+
+		if p.reload(reason) {
+			failures = 0
+		} else {
+			failures++
+		}
+
+		pollTimer.Reset(p.pollDelay(failures))
+	}
+}
+
+// pollDelay doubles the poll interval with every consecutive failure up to maxPollBackoff
+func (p *Provider) pollDelay(failures int) time.Duration {
+	delay := p.pollInterval
+
+	for i := 0; i < failures && delay < maxPollBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, max(p.pollInterval, maxPollBackoff))
+}
+
+func (p *Provider) watch() {
+	var (
+		fileEvents  chan fsnotify.Event
+		watchErrors chan error
+		debounceC   <-chan time.Time
+	)
+
+	if p.watcher != nil {
+		fileEvents, watchErrors = p.watcher.Events, p.watcher.Errors
+	}
+
+	for {
+		select {
+		case event, ok := <-fileEvents:
+			if !ok {
+				fileEvents = nil
+				continue
+			}
+
+			if !p.inConfigDir(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			debounceC = time.After(reloadDebounce)
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+
+			p.logger.Warn("config file watcher error", zap.Error(err))
+		case <-debounceC:
+			debounceC = nil
+			p.reload("file changed")
+		case <-p.signalC:
+			p.reload("SIGHUP received")
+		case <-p.stopC:
+			return
+		}
+	}
+}
+
+// reload returns false if the config could not be fetched
+func (p *Provider) reload(reason string) bool {
+	cfg, err := p.Reload()
+
+	if errors.Is(err, ErrConfigUnavailable) {
+		p.logger.Warn("failed to fetch config, keep serving the previous one", zap.String("reason", reason), zap.Error(err))
+
+		return false
+	}
+
+	if err != nil {
+		p.logger.Error(
+			"failed to reload config, keep serving the previous one\n"+err.Error(),
+			zap.String("reason", reason),
+		)
+
+		return true
+	}
+
+	if cfg == nil {
+		p.logger.Debug("config has not changed, skipping reload", zap.String("reason", reason))
+
+		return true
+	}
+
+	p.logger.Info("config reloaded", zap.String("reason", reason))
+
+	select {
+	case p.updatedC <- cfg:
+	case <-p.stopC:
+	}
+
+	return true
+}
+
+func (p *Provider) inConfigDir(path string) bool {
+	// symlink swaps produce events for other files in the directory, so any change there triggers (deduplicated) reload
+	return p.configDirs[filepath.Clean(filepath.Dir(path))]
+}
+
+// localFiles lists config files the source reads. It returns false if the source is not local (so it can't be watched)
+func localFiles(source Source) ([]string, bool) {
+	switch s := source.(type) {
+	case *FileSource:
+		return []string{s.path}, true
+	case *MergedSource:
+		var files []string
+
+		for _, mergedSource := range s.Sources() {
+			mergedFiles, allLocal := localFiles(mergedSource)
+			if !allLocal {
+				return nil, false
+			}
+
+			files = append(files, mergedFiles...)
+		}
+
+		return files, true
+	default:
+		return nil, false
+	}
+}
+
+// Stop stops watching for config changes
+func (p *Provider) Stop() {
+	p.stopOnce.Do(func() {
+		signal.Stop(p.signalC)
+		close(p.stopC)
+
+		p.mu.RLock()
+		p.secretManager.Stop()
+		p.mu.RUnlock()
+
+		if p.watcher != nil {
+			_ = p.watcher.Close()
+		}
+	})
 }
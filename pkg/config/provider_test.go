@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -64,3 +67,139 @@ func TestConfigProvider_NoProvider(t *testing.T) {
 	require.Error(t, err)
 	require.ErrorContains(t, err, "none is configured")
 }
+
+func TestConfigProvider_ReservedExtraHeaderRejected(t *testing.T) {
+	_, err := NewProvider().Load("./testdata/provider.reservedheader.yaml")
+
+	require.Error(t, err)
+	require.ErrorContains(t, err, "invalid config file")
+	require.ErrorContains(t, err, `"authorization" header is set by the provider client and could not be overridden`)
+}
+
+func TestConfigProvider_BlankAPIKeyRejected(t *testing.T) {
+	t.Setenv("GLIDE_TEST_OPENAI_API_KEY", " ")
+
+	_, err := NewProvider().Load("./testdata/provider.blankapikey.yaml")
+
+	require.Error(t, err)
+	require.ErrorContains(t, err, "invalid config file")
+	require.ErrorContains(t, err, "routers.language[0].models[0].openai.api_key")
+	require.ErrorContains(t, err, "API key of the openai provider is empty (router: simplerouter, model: openai-boring)")
+	// self-hosted models don't require keys
+	require.NotContains(t, err.Error(), "local-llama")
+}
+
+func TestConfigProvider_InvalidReloadKeepsConfig(t *testing.T) {
+	validConfig, err := os.ReadFile("./testdata/provider.fullconfig.yaml")
+	require.NoError(t, err)
+
+	invalidConfig, err := os.ReadFile("./testdata/provider.nolangrouters.yaml")
+	require.NoError(t, err)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, validConfig, 0o600))
+
+	configProvider, err := NewProvider().Load(configPath)
+	require.NoError(t, err)
+
+	prevConfig := configProvider.Get()
+
+	cfg, err := configProvider.Reload()
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	require.NoError(t, os.WriteFile(configPath, invalidConfig, 0o600))
+
+	_, err = configProvider.Reload()
+	require.ErrorContains(t, err, "invalid config file")
+	require.ErrorContains(t, err, "Config changes:")
+	require.Same(t, prevConfig, configProvider.Get())
+}
+
+func TestConfigProvider_ChaosRequiresExperimentalFlag(t *testing.T) {
+	chaosConfig, err := os.ReadFile("./testdata/provider.chaos.yaml")
+	require.NoError(t, err)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	noFlagConfig := strings.Replace(string(chaosConfig), "chaos: true", "chaos: false", 1)
+	require.NoError(t, os.WriteFile(configPath, []byte(noFlagConfig), 0o600))
+
+	_, err = NewProvider().Load(configPath)
+	require.ErrorContains(t, err, "routers.language[0].models[0].chaos: fault injection requires experimental.chaos to be enabled")
+
+	configProvider, err := NewProvider().Load("./testdata/provider.chaos.yaml")
+	require.NoError(t, err)
+	require.True(t, configProvider.Get().ChaosEnabled())
+	require.InEpsilon(t, 0.1, configProvider.Get().Routers.LanguageRouters[0].Models[0].Chaos.ErrorRate, 0.0001)
+}
+
+func TestConfigProvider_ChaosRatesExceedingAllRequestsRejected(t *testing.T) {
+	chaosConfig, err := os.ReadFile("./testdata/provider.chaos.yaml")
+	require.NoError(t, err)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	writeRates := func(rates string) {
+		config := strings.Replace(string(chaosConfig), "error_rate: 0.1", rates, 1)
+		require.NoError(t, os.WriteFile(configPath, []byte(config), 0o600))
+	}
+
+	writeRates("error_rate: 0.5\n            timeout_rate: 0.3\n            rate_limit_rate: 0.3")
+
+	_, err = NewProvider().Load(configPath)
+	require.ErrorContains(t, err, "routers.language[0].models[0].chaos: error_rate, timeout_rate & rate_limit_rate add up to 1.1")
+
+	// rates could fail all requests
+	writeRates("error_rate: 0.1\n            timeout_rate: 0.2\n            rate_limit_rate: 0.7")
+
+	_, err = NewProvider().Load(configPath)
+	require.NoError(t, err)
+}
+
+func TestConfigProvider_ChaosNotEnabledByReload(t *testing.T) {
+	validConfig, err := os.ReadFile("./testdata/provider.fullconfig.yaml")
+	require.NoError(t, err)
+
+	chaosConfig, err := os.ReadFile("./testdata/provider.chaos.yaml")
+	require.NoError(t, err)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, validConfig, 0o600))
+
+	configProvider, err := NewProvider().Load(configPath)
+	require.NoError(t, err)
+
+	prevConfig := configProvider.Get()
+
+	require.NoError(t, os.WriteFile(configPath, chaosConfig, 0o600))
+
+	_, err = configProvider.Reload()
+	require.ErrorIs(t, err, ErrChaosEnabledReload)
+	require.Same(t, prevConfig, configProvider.Get())
+}
+
+func TestConfigProvider_LineDiff(t *testing.T) {
+	diff := lineDiff("a\nb\nc", "a\nx\nc\nd")
+
+	require.Equal(t, "-    2 | b\n+    2 | x\n+    4 | d\n", diff)
+}
+
+func TestConfigProvider_MergesConfigs(t *testing.T) {
+	configProvider, err := NewProvider().Load(
+		"./testdata/merge.routing.yaml",
+		"./testdata/merge.credentials.yaml",
+		"./testdata/merge.overrides.yaml",
+	)
+	require.NoError(t, err)
+
+	cfg := configProvider.Get()
+
+	langRouters := cfg.Routers.LanguageRouters
+	require.Len(t, langRouters, 2)
+	require.Equal(t, "round_robin", string(langRouters[0].RoutingStrategy))
+
+	models := langRouters[0].Models
+	require.Len(t, models, 2)
+	require.True(t, models[0].Enabled)
+	require.False(t, models[1].Enabled)
+}
@@ -0,0 +1,284 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+const (
+	AWSSecretsManagerScheme = "aws-sm"
+	// AWSSecretsManagerAltScheme is the alternative spelling of Secrets Manager references (e.g. awssm://glide#openai_api_key)
+	AWSSecretsManagerAltScheme = "awssm"
+	AWSParameterStoreScheme    = "aws-ssm"
+)
+
+var (
+	ErrAWSRefInvalid   = errors.New("AWS secret reference must have the following format: <scheme>://<secret name or ARN>[?region=<region>][#<json key>]")
+	ErrAWSKeyNotFound  = errors.New("key is not found in the AWS secret")
+	ErrAWSSecretBinary = errors.New("binary AWS secrets are not supported")
+)
+
+// AWSConfig defines how to access AWS Secrets Manager & SSM Parameter Store.
+// Credentials are resolved via the AWS SDK default credential chain
+type AWSConfig struct {
+	Region          string        `yaml:"region,omitempty" json:"region,omitempty"`                                           // Default region for references without explicit region (defaults to the SDK region resolution)
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty" json:"refresh_interval" swaggertype:"primitive,integer"` // How long fetched secrets are cached (defaults to secrets TTL)
+}
+
+// awsRef is a parsed AWS secret reference like my-secret?region=eu-west-1#api_key
+type awsRef struct {
+	id     string // secret name or ARN
+	region string
+	key    string // JSON key to extract from the secret value
+}
+
+func parseAWSRef(ref string) (awsRef, error) {
+	ref, key, _ := strings.Cut(ref, "#")
+	id, query, _ := strings.Cut(ref, "?")
+
+	if id == "" {
+		return awsRef{}, fmt.Errorf("%w (got: %v)", ErrAWSRefInvalid, ref)
+	}
+
+	parsedRef := awsRef{
+		id:  id,
+		key: key,
+	}
+
+	if query != "" {
+		params, err := url.ParseQuery(query)
+		if err != nil {
+			return awsRef{}, fmt.Errorf("%w (got: %v): %v", ErrAWSRefInvalid, ref, err)
+		}
+
+		parsedRef.region = params.Get("region")
+	}
+
+	// ARNs carry the region of the secret (arn:aws:secretsmanager:<region>:<account>:secret:<name>)
+	if arnParts := strings.Split(id, ":"); parsedRef.region == "" && len(arnParts) > 3 && arnParts[0] == "arn" {
+		parsedRef.region = arnParts[3]
+	}
+
+	return parsedRef, nil
+}
+
+// awsFetcher fetches the raw secret value from the AWS secret store in the given region
+type awsFetcher func(ctx context.Context, region string, id string) (string, error)
+
+type cachedSecret struct {
+	value    string
+	expireAt time.Time
+}
+
+// AWSResolver resolves references to AWS secret stores.
+// Fetched secrets are cached, so multiple keys of the same JSON secret are fetched once per refresh interval
+type AWSResolver struct {
+	fetch awsFetcher
+	ttl   time.Duration
+	mu    sync.Mutex
+	cache map[awsRef]cachedSecret
+}
+
+func newAWSResolver(fetch awsFetcher, ttl time.Duration) *AWSResolver {
+	return &AWSResolver{
+		fetch: fetch,
+		ttl:   ttl,
+		cache: make(map[awsRef]cachedSecret),
+	}
+}
+
+// NewAWSSecretsManagerResolver creates a resolver for aws-sm:// references
+func NewAWSSecretsManagerResolver(clients *AWSClients, ttl time.Duration) *AWSResolver {
+	return newAWSResolver(func(ctx context.Context, region string, id string) (string, error) {
+		client, err := clients.SecretsManager(ctx, region)
+		if err != nil {
+			return "", err
+		}
+
+		secret, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+		if err != nil {
+			return "", err
+		}
+
+		if secret.SecretString == nil {
+			return "", ErrAWSSecretBinary
+		}
+
+		return *secret.SecretString, nil
+	}, ttl)
+}
+
+// NewAWSParameterStoreResolver creates a resolver for aws-ssm:// references
+func NewAWSParameterStoreResolver(clients *AWSClients, ttl time.Duration) *AWSResolver {
+	return newAWSResolver(func(ctx context.Context, region string, id string) (string, error) {
+		client, err := clients.ParameterStore(ctx, region)
+		if err != nil {
+			return "", err
+		}
+
+		param, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(id),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return aws.ToString(param.Parameter.Value), nil
+	}, ttl)
+}
+
+func (r *AWSResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	parsedRef, err := parseAWSRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := r.fetchCached(ctx, parsedRef)
+	if err != nil {
+		return "", err
+	}
+
+	if parsedRef.key == "" {
+		return value, nil
+	}
+
+	var secretData map[string]interface{}
+
+	if err := json.Unmarshal([]byte(value), &secretData); err != nil {
+		return "", fmt.Errorf("unable to extract key %v as the secret %v is not a JSON object: %w", parsedRef.key, parsedRef.id, err)
+	}
+
+	keyValue, ok := secretData[parsedRef.key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w (secret: %v, key: %v)", ErrAWSKeyNotFound, parsedRef.id, parsedRef.key)
+	}
+
+	return keyValue, nil
+}
+
+func (r *AWSResolver) fetchCached(ctx context.Context, ref awsRef) (string, error) {
+	// the whole secret is cached regardless of the key
+	secretRef := awsRef{id: ref.id, region: ref.region}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, found := r.cache[secretRef]; found && time.Now().Before(cached.expireAt) {
+		return cached.value, nil
+	}
+
+	value, err := r.fetch(ctx, secretRef.region, secretRef.id)
+	if err != nil {
+		return "", err
+	}
+
+	r.cache[secretRef] = cachedSecret{
+		value:    value,
+		expireAt: time.Now().Add(r.ttl),
+	}
+
+	return value, nil
+}
+
+// AWSClients lazily creates AWS SDK clients per region.
+// The SDK config is loaded on the first use, so AWS credentials are not required unless AWS references are used
+type AWSClients struct {
+	config         *AWSConfig
+	mu             sync.Mutex
+	sdkConfig      *aws.Config
+	secretsManager map[string]*secretsmanager.Client
+	parameterStore map[string]*ssm.Client
+}
+
+func NewAWSClients(cfg *AWSConfig) *AWSClients {
+	if cfg == nil {
+		cfg = &AWSConfig{}
+	}
+
+	return &AWSClients{
+		config:         cfg,
+		secretsManager: make(map[string]*secretsmanager.Client),
+		parameterStore: make(map[string]*ssm.Client),
+	}
+}
+
+func (c *AWSClients) SecretsManager(ctx context.Context, region string) (*secretsmanager.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, found := c.secretsManager[region]; found {
+		return client, nil
+	}
+
+	sdkConfig, err := c.loadSDKConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := secretsmanager.NewFromConfig(sdkConfig, func(o *secretsmanager.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	})
+
+	c.secretsManager[region] = client
+
+	return client, nil
+}
+
+func (c *AWSClients) ParameterStore(ctx context.Context, region string) (*ssm.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, found := c.parameterStore[region]; found {
+		return client, nil
+	}
+
+	sdkConfig, err := c.loadSDKConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := ssm.NewFromConfig(sdkConfig, func(o *ssm.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	})
+
+	c.parameterStore[region] = client
+
+	return client, nil
+}
+
+func (c *AWSClients) loadSDKConfig(ctx context.Context) (aws.Config, error) {
+	if c.sdkConfig != nil {
+		return *c.sdkConfig, nil
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+
+	if c.config.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(c.config.Region))
+	}
+
+	sdkConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("unable to load AWS SDK config: %w", err)
+	}
+
+	c.sdkConfig = &sdkConfig
+
+	return sdkConfig, nil
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/config/fields"
+	"glide/pkg/telemetry"
+)
+
+func TestAWSRef_Parse(t *testing.T) {
+	tests := map[string]awsRef{
+		"my-secret":                    {id: "my-secret"},
+		"my-secret#api_key":            {id: "my-secret", key: "api_key"},
+		"my-secret?region=eu-west-1#k": {id: "my-secret", region: "eu-west-1", key: "k"},
+		"/glide/openai/api_key":        {id: "/glide/openai/api_key"},
+		"arn:aws:secretsmanager:us-west-2:123456789012:secret:glide-AbCdEf#api_key": {
+			id:     "arn:aws:secretsmanager:us-west-2:123456789012:secret:glide-AbCdEf",
+			region: "us-west-2",
+			key:    "api_key",
+		},
+	}
+
+	for ref, expectedRef := range tests {
+		t.Run(ref, func(t *testing.T) {
+			parsedRef, err := parseAWSRef(ref)
+			require.NoError(t, err)
+			require.Equal(t, expectedRef, parsedRef)
+		})
+	}
+
+	_, err := parseAWSRef("#api_key")
+	require.ErrorIs(t, err, ErrAWSRefInvalid)
+}
+
+func TestAWSResolver_JSONKeyExtractionAndCaching(t *testing.T) {
+	fetches := 0
+
+	resolver := newAWSResolver(func(_ context.Context, region string, id string) (string, error) {
+		fetches++
+
+		require.Equal(t, "eu-west-1", region)
+		require.Equal(t, "glide", id)
+
+		return `{"openai_api_key": "sk-123", "cohere_api_key": "co-123"}`, nil
+	}, time.Minute)
+
+	value, err := resolver.Resolve(context.Background(), "glide?region=eu-west-1#openai_api_key")
+	require.NoError(t, err)
+	require.Equal(t, "sk-123", value)
+
+	value, err = resolver.Resolve(context.Background(), "glide?region=eu-west-1#cohere_api_key")
+	require.NoError(t, err)
+	require.Equal(t, "co-123", value)
+
+	_, err = resolver.Resolve(context.Background(), "glide?region=eu-west-1#anthropic_api_key")
+	require.ErrorIs(t, err, ErrAWSKeyNotFound)
+
+	require.Equal(t, 1, fetches)
+}
+
+func TestSecretManager_AWSSecretsManagerAltScheme(t *testing.T) {
+	manager := NewManager(DefaultConfig())
+
+	require.Same(t, manager.resolvers[AWSSecretsManagerScheme], manager.resolvers[AWSSecretsManagerAltScheme])
+}
+
+func TestSecretManager_FailuresCounted(t *testing.T) {
+	tel := telemetry.NewTelemetryMock()
+
+	cfg := configMock{
+		Providers: []*providerConfigMock{
+			{APIKey: "aws-sm://glide#openai_api_key"},
+		},
+	}
+
+	resolver := newAWSResolver(func(_ context.Context, _ string, _ string) (string, error) {
+		return `{"openai_api_key": "sk-123"}`, nil
+	}, 0)
+
+	manager := NewManager(DefaultConfig())
+	manager.RegisterResolver(AWSSecretsManagerScheme, resolver)
+
+	require.NoError(t, manager.ResolveAll(context.Background(), &cfg))
+	require.Equal(t, "sk-123", fields.Secret("aws-sm://glide#openai_api_key").Value())
+
+	manager.Start(tel)
+	defer manager.Stop()
+
+	resolver.fetch = func(_ context.Context, _ string, _ string) (string, error) {
+		return "", errors.New("access denied")
+	}
+
+	manager.Refresh(context.Background())
+
+	require.InDelta(t, 1.0, testutil.ToFloat64(manager.failures.WithLabelValues(AWSSecretsManagerScheme)), 0.0001)
+	require.Equal(t, "sk-123", cfg.Providers[0].APIKey.Value())
+}
@@ -0,0 +1,26 @@
+package secrets
+
+import (
+	"time"
+)
+
+// Config defines external secret stores that secret references in the config file are resolved from
+type Config struct {
+	TTL   time.Duration `yaml:"ttl" json:"ttl" swaggertype:"primitive,integer"` // how often resolved secrets are re-fetched to pick up rotations (zero disables refresh)
+	Vault *VaultConfig  `yaml:"vault,omitempty" json:"vault,omitempty"`
+	AWS   *AWSConfig    `yaml:"aws,omitempty" json:"aws,omitempty"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		TTL: 5 * time.Minute,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
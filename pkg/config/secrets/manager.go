@@ -0,0 +1,211 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"glide/pkg/config/fields"
+	"glide/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+var ErrResolverNotFound = errors.New("no secret resolver is configured for the scheme")
+
+// Resolver fetches secret values from an external secret store
+type Resolver interface {
+	// Resolve fetches the secret by its reference without the scheme (e.g. secret/data/glide#openai_api_key)
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Manager resolves secret references found in the config and periodically re-fetches them to pick up rotated secrets.
+// Resolved values are updated in place, so provider clients start using new values without restart
+type Manager struct {
+	resolvers map[string]Resolver
+	ttl       time.Duration
+	refs      []fields.Secret
+	logger    *zap.Logger
+	failures  *prometheus.CounterVec
+	stopC     chan struct{}
+	stopOnce  sync.Once
+}
+
+func NewManager(cfg *Config) *Manager {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	resolvers := make(map[string]Resolver)
+
+	if cfg.Vault != nil {
+		resolvers[VaultScheme] = NewVaultResolver(cfg.Vault)
+	}
+
+	// AWS references work without any config via the SDK default credential chain
+	awsClients := NewAWSClients(cfg.AWS)
+	// by default, AWS secrets are cached for half of TTL, so they are re-fetched once on each refresh,
+	// but multiple keys of the same secret are fetched once
+	awsCacheTTL := cfg.TTL / 2
+
+	if cfg.AWS != nil && cfg.AWS.RefreshInterval > 0 {
+		awsCacheTTL = cfg.AWS.RefreshInterval
+	}
+
+	secretsManager := NewAWSSecretsManagerResolver(awsClients, awsCacheTTL)
+
+	resolvers[AWSSecretsManagerScheme] = secretsManager
+	resolvers[AWSSecretsManagerAltScheme] = secretsManager
+	resolvers[AWSParameterStoreScheme] = NewAWSParameterStoreResolver(awsClients, awsCacheTTL)
+
+	return &Manager{
+		resolvers: resolvers,
+		ttl:       cfg.TTL,
+		stopC:     make(chan struct{}),
+	}
+}
+
+// RegisterResolver adds a resolver for secret references with the given scheme
+func (m *Manager) RegisterResolver(scheme string, resolver Resolver) {
+	m.resolvers[scheme] = resolver
+}
+
+// ResolveAll finds all secret references in the given config and resolves them.
+// All failed references are reported at once
+func (m *Manager) ResolveAll(ctx context.Context, cfg interface{}) error {
+	refs := collectReferences(reflect.ValueOf(cfg), nil)
+	seenRefs := make(map[fields.Secret]bool, len(refs))
+
+	m.refs = make([]fields.Secret, 0, len(refs))
+
+	for _, ref := range refs {
+		if !seenRefs[ref] {
+			seenRefs[ref] = true
+			m.refs = append(m.refs, ref)
+		}
+	}
+
+	var errs error
+
+	for _, ref := range m.refs {
+		if err := m.resolve(ctx, ref); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func (m *Manager) resolve(ctx context.Context, secret fields.Secret) error {
+	resolver, found := m.resolvers[secret.Scheme()]
+	if !found {
+		return fmt.Errorf("%w \"%v\" (reference: %v)", ErrResolverNotFound, secret.Scheme(), secret.Ref())
+	}
+
+	value, err := resolver.Resolve(ctx, secret.Ref())
+	if err != nil {
+		if m.failures != nil {
+			m.failures.WithLabelValues(secret.Scheme()).Inc()
+		}
+
+		return fmt.Errorf("unable to resolve %v secret %v: %w", secret.Scheme(), secret.Ref(), err)
+	}
+
+	fields.SetResolvedValue(secret, value)
+
+	return nil
+}
+
+// Start periodically re-fetches resolved secrets in background
+func (m *Manager) Start(tel *telemetry.Telemetry) {
+	m.logger = tel.Logger
+	m.failures = tel.Metrics.CounterVec(
+		"secret_resolution_failures_total",
+		"Number of failed attempts to fetch secrets from external secret stores",
+		"scheme",
+	)
+
+	if len(m.refs) == 0 || m.ttl <= 0 {
+		return
+	}
+
+	go m.refreshLoop()
+}
+
+func (m *Manager) refreshLoop() {
+	ticker := time.NewTicker(m.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Refresh(context.Background())
+		case <-m.stopC:
+			return
+		}
+	}
+}
+
+// Refresh re-fetches all resolved secrets. The last good value is kept for secrets that failed to refresh
+func (m *Manager) Refresh(ctx context.Context) {
+	for _, ref := range m.refs {
+		if err := m.resolve(ctx, ref); err != nil {
+			m.logger.Error("failed to refresh secret, keep using the last good value", zap.Error(err))
+		}
+	}
+}
+
+// Stop stops refreshing secrets
+func (m *Manager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopC)
+	})
+}
+
+var (
+	secretType       = reflect.TypeOf(fields.Secret(""))
+	secretConfigType = reflect.TypeOf(Config{})
+)
+
+// collectReferences walks through the config and collects all secret references
+func collectReferences(value reflect.Value, refs []fields.Secret) []fields.Secret {
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			refs = collectReferences(value.Elem(), refs)
+		}
+	case reflect.Struct:
+		if value.Type() == secretConfigType {
+			// secret store configs are not resolved via themselves
+			return refs
+		}
+
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				refs = collectReferences(value.Field(i), refs)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			refs = collectReferences(value.Index(i), refs)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			refs = collectReferences(iter.Value(), refs)
+		}
+	case reflect.String:
+		if value.Type() == secretType {
+			if secret := fields.Secret(value.String()); secret.IsReference() {
+				refs = append(refs, secret)
+			}
+		}
+	}
+
+	return refs
+}
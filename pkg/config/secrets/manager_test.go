@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/config/fields"
+	"go.uber.org/zap"
+)
+
+type resolverMock struct {
+	values map[string]string
+	err    error
+}
+
+func (r *resolverMock) Resolve(_ context.Context, ref string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+
+	return r.values[ref], nil
+}
+
+type providerConfigMock struct {
+	APIKey fields.Secret
+}
+
+type configMock struct {
+	Providers []*providerConfigMock
+	Secrets   *Config
+}
+
+func TestSecretManager_ResolveAndRefresh(t *testing.T) {
+	resolver := &resolverMock{values: map[string]string{"secret/glide#openai": "sk-1"}}
+
+	cfg := configMock{
+		Providers: []*providerConfigMock{
+			{APIKey: "mock://secret/glide#openai"},
+			{APIKey: "plain-key"},
+		},
+		Secrets: DefaultConfig(),
+	}
+
+	manager := NewManager(cfg.Secrets)
+	manager.RegisterResolver("mock", resolver)
+	manager.logger = zap.NewNop()
+
+	require.NoError(t, manager.ResolveAll(context.Background(), &cfg))
+	require.Equal(t, "sk-1", cfg.Providers[0].APIKey.Value())
+	require.Equal(t, "plain-key", cfg.Providers[1].APIKey.Value())
+
+	// rotated secret is picked up in place
+	resolver.values["secret/glide#openai"] = "sk-2"
+	manager.Refresh(context.Background())
+	require.Equal(t, "sk-2", cfg.Providers[0].APIKey.Value())
+
+	// the last good value is kept on refresh failures
+	resolver.err = errors.New("vault is down")
+	manager.Refresh(context.Background())
+	require.Equal(t, "sk-2", cfg.Providers[0].APIKey.Value())
+}
+
+func TestSecretManager_UnknownScheme(t *testing.T) {
+	cfg := configMock{
+		Providers: []*providerConfigMock{
+			{APIKey: "vault://secret/data/glide#openai"},
+			{APIKey: "aws://glide/anthropic"},
+		},
+	}
+
+	err := NewManager(nil).ResolveAll(context.Background(), &cfg)
+
+	require.ErrorIs(t, err, ErrResolverNotFound)
+	require.ErrorContains(t, err, "vault")
+	require.ErrorContains(t, err, "aws")
+}
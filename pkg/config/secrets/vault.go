@@ -0,0 +1,231 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"glide/pkg/config/fields"
+)
+
+const VaultScheme = "vault"
+
+var (
+	ErrVaultRefInvalid       = errors.New("vault secret reference must have the following format: vault://<path>#<key>")
+	ErrVaultKeyNotFound      = errors.New("key is not found in the vault secret")
+	ErrVaultRequestFailed    = errors.New("vault request failed")
+	ErrVaultAuthNotSupported = errors.New("vault auth method is not supported")
+)
+
+type VaultAuthMethod string
+
+const (
+	VaultTokenAuth      VaultAuthMethod = "token"
+	VaultKubernetesAuth VaultAuthMethod = "kubernetes"
+)
+
+// VaultConfig defines how to connect to HashiCorp Vault
+type VaultConfig struct {
+	Address   string           `yaml:"address" json:"address" validate:"required"`
+	Namespace string           `yaml:"namespace,omitempty" json:"namespace,omitempty"` // Vault Enterprise namespace
+	Timeout   time.Duration    `yaml:"timeout" json:"timeout" swaggertype:"primitive,integer"`
+	Auth      *VaultAuthConfig `yaml:"auth" json:"auth" validate:"required"`
+}
+
+// VaultAuthConfig defines how to authenticate in Vault
+type VaultAuthConfig struct {
+	Method    VaultAuthMethod `yaml:"method" json:"method" validate:"required,oneof=token kubernetes"`
+	Token     fields.Secret   `yaml:"token,omitempty" json:"-" validate:"required_if=Method token"`
+	Role      string          `yaml:"role,omitempty" json:"role,omitempty" validate:"required_if=Method kubernetes"` // Vault role bound to the service account
+	MountPath string          `yaml:"mount_path" json:"mount_path"`                                                  // Path the Kubernetes auth method is mounted on
+	JWTPath   string          `yaml:"jwt_path" json:"jwt_path"`                                                      // Path to the service account token
+}
+
+func DefaultVaultConfig() *VaultConfig {
+	return &VaultConfig{
+		Timeout: 10 * time.Second,
+		Auth: &VaultAuthConfig{
+			Method:    VaultTokenAuth,
+			MountPath: "kubernetes",
+			JWTPath:   "/var/run/secrets/kubernetes.io/serviceaccount/token",
+		},
+	}
+}
+
+func (c *VaultConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultVaultConfig()
+
+	type plain VaultConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+func (c *VaultAuthConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultVaultConfig().Auth
+
+	type plain VaultAuthConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// VaultResolver fetches secrets from Vault KV secret engines (both v1 and v2) via Vault HTTP API
+type VaultResolver struct {
+	config        *VaultConfig
+	httpClient    *http.Client
+	mu            sync.Mutex
+	token         string
+	tokenExpireAt time.Time
+}
+
+func NewVaultResolver(cfg *VaultConfig) *VaultResolver {
+	return &VaultResolver{
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+	}
+}
+
+// Resolve reads the key of the secret by the reference like secret/data/glide#openai_api_key
+func (r *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	secretPath, key, found := strings.Cut(ref, "#")
+	if !found || secretPath == "" || key == "" {
+		return "", fmt.Errorf("%w (got: %v)", ErrVaultRefInvalid, ref)
+	}
+
+	token, err := r.authToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	if err := r.do(ctx, http.MethodGet, secretPath, token, nil, &secret); err != nil {
+		return "", err
+	}
+
+	data := secret.Data
+
+	// KV v2 engine nests the secret data along with its metadata
+	if nestedData, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nestedData
+		}
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w (path: %v, key: %v)", ErrVaultKeyNotFound, secretPath, key)
+	}
+
+	return value, nil
+}
+
+func (r *VaultResolver) authToken(ctx context.Context) (string, error) {
+	switch r.config.Auth.Method {
+	case VaultTokenAuth:
+		return r.config.Auth.Token.Value(), nil
+	case VaultKubernetesAuth:
+		return r.kubernetesLogin(ctx)
+	default:
+		return "", fmt.Errorf("%w: %v", ErrVaultAuthNotSupported, r.config.Auth.Method)
+	}
+}
+
+// kubernetesLogin exchanges the service account token for Vault token. The Vault token is reused until it expires
+func (r *VaultResolver) kubernetesLogin(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.token != "" && time.Now().Before(r.tokenExpireAt) {
+		return r.token, nil
+	}
+
+	jwt, err := os.ReadFile(filepath.Clean(r.config.Auth.JWTPath))
+	if err != nil {
+		return "", fmt.Errorf("unable to read service account token %v: %w", r.config.Auth.JWTPath, err)
+	}
+
+	loginReq := map[string]string{
+		"role": r.config.Auth.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}
+
+	var loginResp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+
+	loginPath := fmt.Sprintf("auth/%v/login", strings.Trim(r.config.Auth.MountPath, "/"))
+
+	if err := r.do(ctx, http.MethodPost, loginPath, "", loginReq, &loginResp); err != nil {
+		return "", fmt.Errorf("unable to login to vault via kubernetes auth: %w", err)
+	}
+
+	leaseDuration := time.Duration(loginResp.Auth.LeaseDuration) * time.Second
+
+	r.token = loginResp.Auth.ClientToken
+	// renew the token a bit earlier than it actually expires
+	r.tokenExpireAt = time.Now().Add(leaseDuration - leaseDuration/10)
+
+	return r.token, nil
+}
+
+func (r *VaultResolver) do(ctx context.Context, method string, path string, token string, payload interface{}, result interface{}) error {
+	reqURL, err := url.JoinPath(r.config.Address, "v1", path)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+
+	if payload != nil {
+		rawPayload, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(rawPayload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	if r.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.config.Namespace)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVaultRequestFailed, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// the response body is not included as it may contain sensitive data
+		return fmt.Errorf("%w: %v %v responded with %v status code", ErrVaultRequestFailed, method, path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
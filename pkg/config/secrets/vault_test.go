@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultResolver_TokenAuthKVv2(t *testing.T) {
+	vaultMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/secret/data/glide", r.URL.Path)
+		require.Equal(t, "root", r.Header.Get("X-Vault-Token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"data": {"openai_api_key": "sk-123"}, "metadata": {"version": 2}}}`))
+	})
+
+	vaultServer := httptest.NewServer(vaultMock)
+	defer vaultServer.Close()
+
+	cfg := DefaultVaultConfig()
+	cfg.Address = vaultServer.URL
+	cfg.Auth.Token = "root"
+
+	resolver := NewVaultResolver(cfg)
+
+	value, err := resolver.Resolve(context.Background(), "secret/data/glide#openai_api_key")
+	require.NoError(t, err)
+	require.Equal(t, "sk-123", value)
+
+	_, err = resolver.Resolve(context.Background(), "secret/data/glide#anthropic_api_key")
+	require.ErrorIs(t, err, ErrVaultKeyNotFound)
+
+	_, err = resolver.Resolve(context.Background(), "secret/data/glide")
+	require.ErrorIs(t, err, ErrVaultRefInvalid)
+}
+
+func TestVaultResolver_KubernetesAuthKVv1(t *testing.T) {
+	logins := 0
+
+	vaultMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			logins++
+
+			var loginReq map[string]string
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&loginReq))
+			require.Equal(t, "glide", loginReq["role"])
+			require.Equal(t, "sa-jwt", loginReq["jwt"])
+
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "k8s-token", "lease_duration": 3600}}`))
+		case "/v1/kv/glide":
+			require.Equal(t, "k8s-token", r.Header.Get("X-Vault-Token"))
+
+			_, _ = w.Write([]byte(`{"data": {"cohere_api_key": "co-123"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	vaultServer := httptest.NewServer(vaultMock)
+	defer vaultServer.Close()
+
+	jwtPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(jwtPath, []byte("sa-jwt\n"), 0o600))
+
+	cfg := DefaultVaultConfig()
+	cfg.Address = vaultServer.URL
+	cfg.Auth.Method = VaultKubernetesAuth
+	cfg.Auth.Role = "glide"
+	cfg.Auth.JWTPath = jwtPath
+
+	resolver := NewVaultResolver(cfg)
+
+	for i := 0; i < 2; i++ {
+		value, err := resolver.Resolve(context.Background(), "kv/glide#cohere_api_key")
+		require.NoError(t, err)
+		require.Equal(t, "co-123", value)
+	}
+
+	require.Equal(t, 1, logins)
+
+	_, err := resolver.Resolve(context.Background(), "kv/missing#cohere_api_key")
+	require.ErrorIs(t, err, ErrVaultRequestFailed)
+}
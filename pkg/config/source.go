@@ -0,0 +1,267 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fetchTimeout limits how long one remote config fetch may take
+const fetchTimeout = 10 * time.Second
+
+var (
+	ErrConfigUnavailable = errors.New("unable to fetch config")
+	ErrNoConfigLocations = errors.New("no config locations are given")
+	ErrS3LocationInvalid = errors.New("S3 config location must have the following format: s3://<bucket>/<key>[?region=<region>]")
+)
+
+// Source is where the config is fetched from (a local file, an HTTP endpoint or an S3 object)
+type Source interface {
+	// Fetch returns the current config content
+	Fetch(ctx context.Context) ([]byte, error)
+	// Location is the config location as it was passed by the user
+	Location() string
+}
+
+// NewSource picks the config source by the location scheme. Locations without a scheme are treated as local files.
+// YAML files of local directories are merged in the lexical order
+func NewSource(location string) (Source, error) {
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return NewHTTPSource(location, &http.Client{Timeout: fetchTimeout}), nil
+	case strings.HasPrefix(location, "s3://"):
+		return NewS3Source(location)
+	}
+
+	if info, err := os.Stat(location); err == nil && info.IsDir() {
+		configFiles, err := configFilesInDir(location)
+		if err != nil {
+			return nil, err
+		}
+
+		sources := make([]Source, 0, len(configFiles))
+
+		for _, configFile := range configFiles {
+			sources = append(sources, &FileSource{path: configFile})
+		}
+
+		return NewMergedSource(sources...), nil
+	}
+
+	return &FileSource{path: location}, nil
+}
+
+// FileSource reads the config from the local file
+type FileSource struct {
+	path string
+}
+
+func (s *FileSource) Fetch(_ context.Context) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Clean(s.path))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %v: %w", s.path, err)
+	}
+
+	return content, nil
+}
+
+func (s *FileSource) Location() string {
+	return s.path
+}
+
+// conditionalCache keeps the last fetched content & its ETag, so unchanged configs are not transferred again
+type conditionalCache struct {
+	mu      sync.Mutex
+	etag    string
+	content []byte
+}
+
+func (c *conditionalCache) get() (string, []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.etag, c.content
+}
+
+func (c *conditionalCache) set(etag string, content []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.etag = etag
+	c.content = content
+}
+
+// HTTPSource fetches the config from the HTTP(S) URL. It polls with If-None-Match, so unchanged configs are not downloaded
+type HTTPSource struct {
+	url    string
+	client *http.Client
+	cache  conditionalCache
+}
+
+func NewHTTPSource(url string, client *http.Client) *HTTPSource {
+	return &HTTPSource{
+		url:    url,
+		client: client,
+	}
+}
+
+func (s *HTTPSource) Fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create config request to %v: %w", s.url, err)
+	}
+
+	etag, cachedContent := s.cache.get()
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w from %v: %v", ErrConfigUnavailable, s.url, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return cachedContent, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w from %v: unexpected status code %v", ErrConfigUnavailable, s.url, resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w from %v: %v", ErrConfigUnavailable, s.url, err)
+	}
+
+	s.cache.set(resp.Header.Get("ETag"), content)
+
+	return content, nil
+}
+
+func (s *HTTPSource) Location() string {
+	return s.url
+}
+
+// s3Getter is the part of S3 API the config source relies on
+type s3Getter interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Source fetches the config from the S3 object. Credentials are resolved via the AWS SDK default credential chain
+type S3Source struct {
+	location string
+	bucket   string
+	key      string
+	region   string
+	initOnce sync.Once
+	client   s3Getter
+	initErr  error
+	cache    conditionalCache
+}
+
+func NewS3Source(location string) (*S3Source, error) {
+	parsedURL, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("%w (got: %v): %v", ErrS3LocationInvalid, location, err)
+	}
+
+	key := strings.TrimPrefix(parsedURL.Path, "/")
+
+	if parsedURL.Host == "" || key == "" {
+		return nil, fmt.Errorf("%w (got: %v)", ErrS3LocationInvalid, location)
+	}
+
+	return &S3Source{
+		location: location,
+		bucket:   parsedURL.Host,
+		key:      key,
+		region:   parsedURL.Query().Get("region"),
+	}, nil
+}
+
+func (s *S3Source) s3Client(ctx context.Context) (s3Getter, error) {
+	s.initOnce.Do(func() {
+		if s.client != nil {
+			return
+		}
+
+		var optFns []func(*awsconfig.LoadOptions) error
+
+		if s.region != "" {
+			optFns = append(optFns, awsconfig.WithRegion(s.region))
+		}
+
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx, optFns...)
+		if err != nil {
+			s.initErr = fmt.Errorf("unable to load AWS config: %w", err)
+
+			return
+		}
+
+		s.client = s3.NewFromConfig(awsCfg)
+	})
+
+	return s.client, s.initErr
+}
+
+func (s *S3Source) Fetch(ctx context.Context) ([]byte, error) {
+	client, err := s.s3Client(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w from %v: %v", ErrConfigUnavailable, s.location, err)
+	}
+
+	etag, cachedContent := s.cache.get()
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	}
+
+	if etag != "" {
+		input.IfNoneMatch = aws.String(etag)
+	}
+
+	obj, err := client.GetObject(ctx, input)
+
+	var respErr *awshttp.ResponseError
+
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotModified {
+		return cachedContent, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w from %v: %v", ErrConfigUnavailable, s.location, err)
+	}
+
+	defer obj.Body.Close()
+
+	content, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w from %v: %v", ErrConfigUnavailable, s.location, err)
+	}
+
+	s.cache.set(aws.ToString(obj.ETag), content)
+
+	return content, nil
+}
+
+func (s *S3Source) Location() string {
+	return s.location
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource_PicksSourceByScheme(t *testing.T) {
+	fileSource, err := NewSource("./config.yaml")
+	require.NoError(t, err)
+	require.IsType(t, &FileSource{}, fileSource)
+
+	httpSource, err := NewSource("https://config.example.com/glide.yaml")
+	require.NoError(t, err)
+	require.IsType(t, &HTTPSource{}, httpSource)
+
+	s3Source, err := NewSource("s3://configs/glide/config.yaml?region=eu-west-1")
+	require.NoError(t, err)
+	require.IsType(t, &S3Source{}, s3Source)
+
+	parsedS3Source := s3Source.(*S3Source)
+	require.Equal(t, "configs", parsedS3Source.bucket)
+	require.Equal(t, "glide/config.yaml", parsedS3Source.key)
+	require.Equal(t, "eu-west-1", parsedS3Source.region)
+
+	_, err = NewSource("s3://configs")
+	require.ErrorIs(t, err, ErrS3LocationInvalid)
+}
+
+func TestHTTPSource_UsesETag(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("routers: {}"))
+	}))
+	defer server.Close()
+
+	source := NewHTTPSource(server.URL, server.Client())
+
+	for i := 0; i < 2; i++ {
+		content, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "routers: {}", string(content))
+	}
+
+	require.Equal(t, 2, requests)
+}
+
+func TestHTTPSource_UnavailableConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPSource(server.URL, server.Client()).Fetch(context.Background())
+	require.ErrorIs(t, err, ErrConfigUnavailable)
+}
+
+type s3Mock struct {
+	etag string
+}
+
+func (m *s3Mock) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if aws.ToString(params.IfNoneMatch) == m.etag {
+		return nil, &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusNotModified}},
+			},
+		}
+	}
+
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(strings.NewReader("routers: {}")),
+		ETag: aws.String(m.etag),
+	}, nil
+}
+
+func TestS3Source_UsesETag(t *testing.T) {
+	source, err := NewS3Source("s3://configs/config.yaml")
+	require.NoError(t, err)
+
+	source.client = &s3Mock{etag: `"v1"`}
+
+	for i := 0; i < 2; i++ {
+		content, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "routers: {}", string(content))
+	}
+}
+
+func TestConfigProvider_PollBacksOff(t *testing.T) {
+	provider := NewProvider().WithPollInterval(time.Minute)
+
+	require.Equal(t, time.Minute, provider.pollDelay(0))
+	require.Equal(t, 4*time.Minute, provider.pollDelay(2))
+	require.Equal(t, maxPollBackoff, provider.pollDelay(10))
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
+	"time"
 
+	"glide/pkg/cluster"
 	"glide/pkg/routers"
 
 	"glide/pkg/config"
@@ -18,6 +21,9 @@ import (
 	"go.uber.org/multierr"
 )
 
+// errorReportsFlushTimeout limits how long the shutdown waits for buffered error reports to be sent
+const errorReportsFlushTimeout = 2 * time.Second
+
 // Gateway represents an instance of running Glide gateway.
 // It loads configs, start API server(s), and listen to termination signals to shut down
 type Gateway struct {
@@ -25,6 +31,12 @@ type Gateway struct {
 	configProvider *config.Provider
 	// telemetry holds logger, meter, and tracer
 	telemetry *telemetry.Telemetry
+	// cluster shares router state with other gateway replicas (nil if the gateway runs alone)
+	cluster *cluster.Cluster
+	// routerManager holds routers and swaps them on config reloads
+	routerManager *routers.RouterManager
+	// currentConfig is the most recently applied config
+	currentConfig *config.Config
 	// serverManager controls API over different protocols
 	serverManager *api.ServerManager
 	// signalChannel is used to receive termination signals from the OS.
@@ -36,7 +48,10 @@ type Gateway struct {
 func NewGateway(configProvider *config.Provider) (*Gateway, error) {
 	cfg := configProvider.Get()
 
-	tel, err := telemetry.NewTelemetry(&telemetry.Config{LogConfig: cfg.Telemetry.LogConfig})
+	tel, err := telemetry.NewTelemetry(&telemetry.Config{
+		LogConfig:      cfg.Telemetry.LogConfig,
+		ErrorReporting: cfg.Telemetry.ErrorReporting,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -44,37 +59,82 @@ func NewGateway(configProvider *config.Provider) (*Gateway, error) {
 	tel.Logger.Info("🐦Glide is starting up", zap.String("version", FullVersion))
 	tel.Logger.Debug("config loaded successfully:\n" + configProvider.GetStr())
 
-	routerManager, err := routers.NewManager(&cfg.Routers, tel)
-	if err != nil {
-		return nil, err
+	var cl *cluster.Cluster
+
+	if cfg.Cluster != nil {
+		cl, err = cluster.NewCluster(cfg.Cluster, tel)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	serverManager, err := api.NewServerManager(cfg.API, tel, routerManager)
+	routerManager, serverManager, err := newManagers(cfg, tel, cl)
 	if err != nil {
-		return nil, err
+		return nil, multierr.Append(err, closeCluster(cl))
+	}
+
+	if _, err := routerManager.Verify(context.Background(), cfg.Startup); err != nil {
+		// the gateway is not going to run, so the manager & cluster connections should not outlive the failed startup
+		routerManager.Close()
+
+		return nil, multierr.Append(err, closeCluster(cl))
 	}
 
 	return &Gateway{
 		configProvider: configProvider,
 		telemetry:      tel,
+		cluster:        cl,
+		routerManager:  routerManager,
+		currentConfig:  cfg,
 		serverManager:  serverManager,
 		signalC:        make(chan os.Signal, 3), // equal to number of signal types we expect to receive
 		shutdownC:      make(chan struct{}),
 	}, nil
 }
 
+// newManagers builds routers & API servers out of the config without starting them
+func newManagers(
+	cfg *config.Config,
+	tel *telemetry.Telemetry,
+	cl *cluster.Cluster,
+) (*routers.RouterManager, *api.ServerManager, error) {
+	routerManager, err := routers.NewManager(&cfg.Routers, tel, cl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serverManager, err := api.NewServerManager(cfg.API, tel, routerManager)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return routerManager, serverManager, nil
+}
+
+// CheckConfig builds routers & API servers out of the config the same way the gateway does on startup,
+// but without connecting to the cluster, calling providers or starting servers (e.g. to validate configs in CI)
+func CheckConfig(cfg *config.Config, tel *telemetry.Telemetry) error {
+	_, _, err := newManagers(cfg, tel, nil)
+
+	return err
+}
+
 // Run starts and runs the gateway according to given configuration
 func (gw *Gateway) Run(ctx context.Context) error {
-	gw.configProvider.Start()
+	gw.configProvider.Start(gw.telemetry)
+	gw.routerManager.Start(ctx)
 	gw.serverManager.Start()
 
+	defer gw.routerManager.Close()
+
 	signal.Notify(gw.signalC, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	defer signal.Stop(gw.signalC)
 
 LOOP:
 	for {
 		select {
-		// TODO: Watch for config updates
+		case cfg := <-gw.configProvider.Updates():
+			gw.applyConfig(cfg)
 		case sig := <-gw.signalC:
 			gw.telemetry.Logger.Info("received signal from os", zap.String("signal", sig.String()))
 			break LOOP
@@ -91,16 +151,82 @@ LOOP:
 	return gw.shutdown(ctx)
 }
 
+// applyConfig applies the reloaded config to the running gateway.
+// Only routers are reloaded in place, other sections require restart to take effect
+func (gw *Gateway) applyConfig(cfg *config.Config) {
+	prevCfg := gw.currentConfig
+
+	if err := gw.routerManager.Reload(&cfg.Routers); err != nil {
+		gw.telemetry.Logger.Error("failed to apply reloaded router config, keep serving the previous one", zap.Error(err))
+		return
+	}
+
+	if !reflect.DeepEqual(prevCfg.API, cfg.API) ||
+		!reflect.DeepEqual(prevCfg.Telemetry, cfg.Telemetry) ||
+		!reflect.DeepEqual(prevCfg.Cluster, cfg.Cluster) {
+		gw.telemetry.Logger.Warn("API, telemetry and cluster config changes are not applied until the gateway is restarted")
+	}
+
+	gw.currentConfig = cfg
+}
+
 func (gw *Gateway) Shutdown() {
 	close(gw.shutdownC)
 }
 
+// shutdown gracefully stops the gateway. Receiving another termination signal while shutting down
+// skips draining of in-flight requests and stops the gateway immediately
 func (gw *Gateway) shutdown(ctx context.Context) error {
+	shutdownCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	doneC := make(chan error, 1)
+
+	go func() {
+		doneC <- gw.stopServers(shutdownCtx)
+	}()
+
+	for {
+		select {
+		case err := <-doneC:
+			return err
+		case sig := <-gw.signalC:
+			gw.telemetry.Logger.Warn(
+				"received another signal from os while shutting down, terminating immediately",
+				zap.String("signal", sig.String()),
+			)
+			cancel()
+		}
+	}
+}
+
+func (gw *Gateway) stopServers(ctx context.Context) error {
 	var errs error
 
+	gw.configProvider.Stop()
+
 	if err := gw.serverManager.Shutdown(ctx); err != nil {
 		errs = multierr.Append(errs, fmt.Errorf("failed to shutdown servers: %w", err))
 	}
 
+	errs = multierr.Append(errs, closeCluster(gw.cluster))
+
+	if !gw.telemetry.Errors.Flush(errorReportsFlushTimeout) {
+		gw.telemetry.Logger.Warn("some error reports have not been sent before the shutdown")
+	}
+
 	return errs
 }
+
+// closeCluster closes connections to other gateway replicas (if the gateway is clustered)
+func closeCluster(cl *cluster.Cluster) error {
+	if cl == nil {
+		return nil
+	}
+
+	if err := cl.Close(); err != nil {
+		return fmt.Errorf("failed to close cluster connections: %w", err)
+	}
+
+	return nil
+}
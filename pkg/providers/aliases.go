@@ -0,0 +1,149 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"glide/pkg/telemetry"
+)
+
+// ModelAlias points to the concrete provider model, so models could refer to the alias (e.g. gpt4) instead of snapshots
+// that churn (e.g. gpt-4o-2024-08-06). The alias could switch to new targets at scheduled cutovers without a deploy
+type ModelAlias struct {
+	Target   string         `yaml:"target" json:"target" validate:"required"`
+	Cutovers []ModelCutover `yaml:"cutovers,omitempty" json:"cutovers,omitempty" validate:"omitempty,dive"`
+}
+
+// ModelCutover switches the alias to the target once the time has come
+type ModelCutover struct {
+	Target        string    `yaml:"target" json:"target" validate:"required"`
+	EffectiveFrom time.Time `yaml:"effective_from" json:"effective_from" validate:"required"`
+}
+
+// ModelAliases maps alias names to their targets
+type ModelAliases map[string]*ModelAlias
+
+// UnmarshalYAML accepts either the target name (e.g. gpt4: gpt-4o-2024-08-06) or the target with cutovers
+func (a *ModelAlias) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var target string
+
+	if err := unmarshal(&target); err == nil {
+		*a = ModelAlias{Target: target}
+
+		return nil
+	}
+
+	type plain ModelAlias // to avoid recursion
+
+	if err := unmarshal((*plain)(a)); err != nil {
+		return err
+	}
+
+	sort.SliceStable(a.Cutovers, func(i, j int) bool {
+		return a.Cutovers[i].EffectiveFrom.Before(a.Cutovers[j].EffectiveFrom)
+	})
+
+	return nil
+}
+
+// AlternativeForms lists forms the alias could be given in besides the mapping (used by the config schema)
+func (a *ModelAlias) AlternativeForms() []interface{} {
+	return []interface{}{""}
+}
+
+// Resolve returns the target of the alias at the given time
+func (a *ModelAlias) Resolve(at time.Time) string {
+	target := a.Target
+
+	for _, cutover := range a.Cutovers {
+		if at.Before(cutover.EffectiveFrom) {
+			break
+		}
+
+		target = cutover.Target
+	}
+
+	return target
+}
+
+// Targets lists the current and all scheduled targets of the alias
+func (a *ModelAlias) Targets() []string {
+	targets := make([]string, 0, len(a.Cutovers)+1)
+	targets = append(targets, a.Target)
+
+	for _, cutover := range a.Cutovers {
+		targets = append(targets, cutover.Target)
+	}
+
+	return targets
+}
+
+// Aliased is implemented by models that could refer to model aliases
+type Aliased interface {
+	Alias() (string, string)
+}
+
+// modelAlias keeps clients of all alias targets, so the model switches to the new target right at the cutover
+type modelAlias struct {
+	name    string
+	config  *ModelAlias
+	clients map[string]LangModelProvider
+}
+
+// Alias returns the alias the model refers to and the target it resolves to at the moment (empty if the model is not aliased)
+func (m *LangModel) Alias() (string, string) {
+	if m.alias == nil {
+		return "", ""
+	}
+
+	return m.alias.name, m.alias.config.Resolve(time.Now())
+}
+
+// activeClient returns the client of the current alias target or the only client of not aliased models
+func (m *LangModel) activeClient() LangModelProvider {
+	if m.alias == nil {
+		return m.client
+	}
+
+	return m.alias.clients[m.alias.config.Resolve(time.Now())]
+}
+
+// ToAliasedModel creates the model resolving its provider model name by aliases.
+// Models that don't refer to any alias are created as is
+func (c *LangModelConfig) ToAliasedModel(aliases ModelAliases, tel *telemetry.Telemetry) (*LangModel, error) {
+	aliasName := c.ProviderModel()
+
+	alias, found := aliases[aliasName]
+	if !found || aliasName == "" {
+		return c.ToModel(tel)
+	}
+
+	model, err := c.withProviderModel(alias.Target).ToModel(tel)
+	if err != nil {
+		return nil, err
+	}
+
+	targetClients := map[string]LangModelProvider{alias.Target: model.client}
+
+	for _, cutover := range alias.Cutovers {
+		if _, found := targetClients[cutover.Target]; found {
+			continue
+		}
+
+		client, err := c.withProviderModel(cutover.Target).initClient(tel)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing client of %q alias target %q: %v", aliasName, cutover.Target, err)
+		}
+
+		targetClients[cutover.Target] = client
+	}
+
+	model.alias = &modelAlias{
+		name:    aliasName,
+		config:  alias,
+		clients: targetClients,
+	}
+
+	return model, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"glide/pkg/providers/clients"
@@ -16,29 +17,83 @@ import (
 )
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string
+	Content string
+	// Blocks are sent instead of the plain text content when the message has tool calls or results
+	Blocks []ContentBlock
+}
+
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Blocks) > 0 {
+		return json.Marshal(struct {
+			Role    string         `json:"role"`
+			Content []ContentBlock `json:"content"`
+		}{m.Role, m.Blocks})
+	}
+
+	return json.Marshal(struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}{m.Role, m.Content})
+}
+
+// contentBlocks returns the message content as a list of blocks
+func (m *ChatMessage) contentBlocks() []ContentBlock {
+	if len(m.Blocks) > 0 || m.Content == "" {
+		return m.Blocks
+	}
+
+	return []ContentBlock{{Type: TextBlock, Text: m.Content}}
+}
+
+// markCached puts the cache breakpoint on the last content block of the message
+func (m *ChatMessage) markCached() {
+	blocks := m.contentBlocks()
+	if len(blocks) == 0 {
+		return
+	}
+
+	blocks[len(blocks)-1].CacheControl = ephemeralCache
+
+	m.Blocks = blocks
+	m.Content = ""
+}
+
+// merge appends content of the message of the same role
+func (m *ChatMessage) merge(message ChatMessage) {
+	if len(m.Blocks) == 0 && len(message.Blocks) == 0 {
+		m.Content += "\n\n" + message.Content
+
+		return
+	}
+
+	m.Blocks = append(m.contentBlocks(), message.contentBlocks()...)
+	m.Content = ""
 }
 
 // ChatRequest is an Anthropic-specific request schema
 type ChatRequest struct {
-	Model         string        `json:"model"`
-	Messages      []ChatMessage `json:"messages"`
-	System        string        `json:"system,omitempty"`
-	Temperature   float64       `json:"temperature,omitempty"`
-	TopP          float64       `json:"top_p,omitempty"`
-	TopK          int           `json:"top_k,omitempty"`
-	MaxTokens     int           `json:"max_tokens,omitempty"`
-	Stream        bool          `json:"stream,omitempty"`
-	Metadata      *string       `json:"metadata,omitempty"`
-	StopSequences []string      `json:"stop_sequences,omitempty"`
+	Model         string         `json:"model"`
+	Messages      []ChatMessage  `json:"messages"`
+	System        []ContentBlock `json:"system,omitempty"` // text blocks of the system prompt
+	Temperature   float64        `json:"temperature,omitempty"`
+	TopP          float64        `json:"top_p,omitempty"`
+	TopK          int            `json:"top_k,omitempty"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	Metadata      *Metadata      `json:"metadata,omitempty"`
+	StopSequences []string       `json:"stop_sequences,omitempty"`
+	Tools         []Tool         `json:"tools,omitempty"`
+	ToolChoice    *ToolChoice    `json:"tool_choice,omitempty"`
+	// responseTool is the tool the model is forced to call to respond with JSON (if any)
+	responseTool string
 }
 
 // NewChatRequestFromConfig fills the struct from the config. Not using reflection because of performance penalty it gives
 func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
 	return &ChatRequest{
 		Model:         cfg.Model,
-		System:        cfg.DefaultParams.System,
+		System:        newSystemBlocks(cfg.DefaultParams.System),
 		Temperature:   cfg.DefaultParams.Temperature,
 		TopP:          cfg.DefaultParams.TopP,
 		TopK:          cfg.DefaultParams.TopK,
@@ -49,42 +104,169 @@ func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
 	}
 }
 
-func NewChatMessagesFromUnifiedRequest(request *schemas.UnifiedChatRequest) []ChatMessage {
-	messages := make([]ChatMessage, 0, len(request.MessageHistory)+1)
+// NewChatMessagesFromUnifiedRequest translates the conversation into Anthropic messages.
+// System messages are moved to the top-level system prompt (a text block per message) and consecutive messages of the same role are coalesced,
+// as Anthropic requires user & assistant messages to alternate starting with the user one.
+// Tool calls become tool_use blocks of assistant messages, while tool results are sent as tool_result blocks of user messages
+func NewChatMessagesFromUnifiedRequest(request *schemas.UnifiedChatRequest) ([]ContentBlock, []ChatMessage, error) {
+	chatMessages := request.ChatMessages()
+
+	var system []ContentBlock
+
+	messages := make([]ChatMessage, 0, len(chatMessages))
+
+	for _, message := range chatMessages {
+		if message.Role == schemas.RoleSystem {
+			system = append(system, newSystemPartBlocks(message)...)
+
+			if message.Cache && len(system) > 0 {
+				system[len(system)-1].CacheControl = ephemeralCache
+			}
+
+			continue
+		}
+
+		chatMessage, err := newChatMessage(message)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	// Add items from messageHistory first and the new chat message last
-	for _, message := range request.MessageHistory {
-		messages = append(messages, ChatMessage{Role: message.Role, Content: message.Content})
+		if message.Cache {
+			chatMessage.markCached()
+		}
+
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == chatMessage.Role {
+			messages[last].merge(chatMessage)
+			continue
+		}
+
+		messages = append(messages, chatMessage)
 	}
 
-	messages = append(messages, ChatMessage{Role: request.Message.Role, Content: request.Message.Content})
+	if len(messages) > 0 && messages[0].Role == schemas.RoleAssistant {
+		return nil, nil, fmt.Errorf("%w: anthropic requires the conversation to start with a user message", ErrInvalidMessageOrder)
+	}
+
+	return system, messages, nil
+}
+
+// newSystemBlocks wraps the system prompt into the text block. Empty prompts are not sent
+func newSystemBlocks(prompt string) []ContentBlock {
+	if prompt == "" {
+		return nil
+	}
 
-	return messages
+	return []ContentBlock{{Type: TextBlock, Text: prompt}}
+}
+
+// newSystemPartBlocks keeps text parts of the system message apart if some of them are cache breakpoints
+func newSystemPartBlocks(message schemas.ChatMessage) []ContentBlock {
+	if !message.HasCachedParts() {
+		return newSystemBlocks(message.Content)
+	}
+
+	blocks := make([]ContentBlock, 0, len(message.ContentParts))
+
+	for _, part := range message.ContentParts {
+		if part.Type != schemas.ContentPartText {
+			continue
+		}
+
+		block := ContentBlock{Type: TextBlock, Text: part.Text}
+
+		if part.CacheControl != nil {
+			block.CacheControl = ephemeralCache
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+func newChatMessage(message schemas.ChatMessage) (ChatMessage, error) {
+	switch {
+	case message.Role == schemas.RoleTool:
+		return ChatMessage{
+			Role: schemas.RoleUser,
+			Blocks: []ContentBlock{{
+				Type:      ToolResultBlock,
+				ToolUseID: message.ToolCallID,
+				Content:   message.Content,
+			}},
+		}, nil
+	case len(message.ToolCalls) > 0:
+		chatMessage := ChatMessage{Role: message.Role, Content: message.Content}
+
+		toolUseBlocks, err := newToolUseBlocks(message.ToolCalls)
+		if err != nil {
+			return ChatMessage{}, err
+		}
+
+		chatMessage.Blocks = append(chatMessage.contentBlocks(), toolUseBlocks...)
+
+		return chatMessage, nil
+	case message.HasImages() || message.HasCachedParts():
+		blocks, err := newContentBlocks(message.ContentParts)
+		if err != nil {
+			return ChatMessage{}, err
+		}
+
+		return ChatMessage{Role: message.Role, Blocks: blocks}, nil
+	default:
+		return ChatMessage{Role: message.Role, Content: message.Content}, nil
+	}
 }
 
 // Chat sends a chat request to the specified anthropic model.
 func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	request, err := c.inlineImages(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a new chat request
-	chatRequest := c.createChatRequestSchema(request)
+	chatRequest, err := c.createChatRequestSchema(request)
+	if err != nil {
+		return nil, err
+	}
 
 	chatResponse, err := c.doChatRequest(ctx, chatRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(chatResponse.ModelResponse.Message.Content) == 0 {
+	if len(chatResponse.ModelResponse.Message.Content) == 0 && len(chatResponse.ModelResponse.Message.ToolCalls) == 0 {
 		return nil, ErrEmptyResponse
 	}
 
 	return chatResponse, nil
 }
 
-func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *ChatRequest {
+func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) (*ChatRequest, error) {
+	system, messages, err := NewChatMessagesFromUnifiedRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: consider using objectpool to optimize memory allocation
-	chatRequest := c.chatRequestTemplate // hoping to get a copy of the template
-	chatRequest.Messages = NewChatMessagesFromUnifiedRequest(request)
+	chatRequest := *c.chatRequestTemplate // copy the template
+	chatRequest.Messages = messages
+
+	if len(system) > 0 {
+		// system messages of the request take precedence over the configured system prompt
+		chatRequest.System = system
+	}
 
-	return chatRequest
+	if request.User != "" {
+		chatRequest.Metadata = &Metadata{UserID: request.User}
+	}
+
+	applyTools(&chatRequest, request)
+	applyResponseFormat(&chatRequest, request)
+	c.applyParamOverrides(&chatRequest, request.Override.Params)
+
+	return &chatRequest, nil
 }
 
 func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*schemas.UnifiedChatResponse, error) {
@@ -99,7 +281,8 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, fmt.Errorf("unable to create anthropic chat request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+string(c.config.APIKey))
+	req.Header.Set("X-Api-Key", c.config.APIKey.Value())
+	req.Header.Set("Anthropic-Version", c.config.APIVersion)
 	req.Header.Set("Content-Type", "application/json")
 
 	// TODO: this could leak information from messages which may not be a desired thing to have
@@ -130,20 +313,11 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		)
 
 		if resp.StatusCode == http.StatusTooManyRequests {
-			// Read the value of the "Retry-After" header to get the cooldown delay
-			retryAfter := resp.Header.Get("Retry-After")
-
-			// Parse the value to get the duration
-			cooldownDelay, err := time.ParseDuration(retryAfter)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse cooldown delay from headers: %w", err)
-			}
-
-			return nil, clients.NewRateLimitError(&cooldownDelay)
+			return nil, clients.NewRetryAfterError(resp.Header)
 		}
 
 		// Server & client errors result in the same error to keep gateway resilient
-		return nil, clients.ErrProviderUnavailable
+		return nil, clients.NewProviderError(resp.StatusCode)
 	}
 
 	// Read the response body into a byte slice
@@ -162,6 +336,27 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, err
 	}
 
+	if len(anthropicCompletion.Content) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	var text strings.Builder
+
+	toolCalls := make([]schemas.Content, 0, len(anthropicCompletion.Content))
+
+	for _, block := range anthropicCompletion.Content {
+		switch {
+		case block.Type == ToolUseBlock && payload.responseTool != "" && block.Name == payload.responseTool:
+			// the input of the forced response tool is the structured response itself
+			text.Reset()
+			text.Write(block.Input)
+		case block.Type == ToolUseBlock:
+			toolCalls = append(toolCalls, block)
+		case block.Type == TextBlock && payload.responseTool == "":
+			text.WriteString(block.Text)
+		}
+	}
+
 	// Map response to UnifiedChatResponse schema
 	response := schemas.UnifiedChatResponse{
 		ID:       anthropicCompletion.ID,
@@ -174,17 +369,79 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 				"system_fingerprint": anthropicCompletion.ID,
 			},
 			Message: schemas.ChatMessage{
-				Role:    anthropicCompletion.Content[0].Type,
-				Content: anthropicCompletion.Content[0].Text,
-				Name:    "",
-			},
-			TokenUsage: schemas.TokenUsage{
-				PromptTokens:   0, // Anthropic doesn't send prompt tokens
-				ResponseTokens: 0,
-				TotalTokens:    0,
+				Role:      anthropicCompletion.Role,
+				Content:   text.String(),
+				Name:      "",
+				ToolCalls: newToolCalls(toolCalls),
 			},
+			FinishReason: newFinishReason(anthropicCompletion.StopReason),
+			StopSequence: anthropicCompletion.StopSequence,
+			TokenUsage:   newTokenUsage(anthropicCompletion.Usage),
 		},
 	}
 
 	return &response, nil
 }
+
+// newTokenUsage counts cached input tokens as prompt tokens too, as Anthropic reports them apart from the rest of the input
+func newTokenUsage(usage schemas.AnthropicUsage) schemas.TokenUsage {
+	promptTokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+
+	return schemas.TokenUsage{
+		PromptTokens:        promptTokens,
+		ResponseTokens:      usage.OutputTokens,
+		TotalTokens:         promptTokens + usage.OutputTokens,
+		CacheCreationTokens: usage.CacheCreationInputTokens,
+		CacheReadTokens:     usage.CacheReadInputTokens,
+	}
+}
+
+// Anthropic stop reasons (https://docs.anthropic.com/claude/reference/messages_post)
+const (
+	StopReasonEndTurn      = "end_turn"
+	StopReasonMaxTokens    = "max_tokens"
+	StopReasonToolUse      = "tool_use"
+	StopReasonStopSequence = "stop_sequence"
+)
+
+// newFinishReason maps the Anthropic stop reason into the unified finish reason. Unknown reasons are passed as is
+func newFinishReason(stopReason string) string {
+	switch stopReason {
+	case StopReasonEndTurn, StopReasonStopSequence:
+		return schemas.FinishReasonStop
+	case StopReasonMaxTokens:
+		return schemas.FinishReasonLength
+	case StopReasonToolUse:
+		return schemas.FinishReasonToolCalls
+	default:
+		return stopReason
+	}
+}
+
+// applyParamOverrides merges per-request params over the default ones
+func (c *Client) applyParamOverrides(chatRequest *ChatRequest, params *schemas.ChatParams) {
+	if params == nil {
+		return
+	}
+
+	if params.Temperature != nil {
+		// Anthropic accepts a narrower temperature range than other providers
+		chatRequest.Temperature = min(*params.Temperature, 1)
+	}
+
+	if params.TopP != nil {
+		chatRequest.TopP = *params.TopP
+	}
+
+	if params.TopK != nil {
+		chatRequest.TopK = *params.TopK
+	}
+
+	if params.MaxTokens != nil {
+		chatRequest.MaxTokens = *params.MaxTokens
+	}
+
+	if params.Stop != nil {
+		chatRequest.StopSequences = params.Stop
+	}
+}
@@ -1,6 +1,7 @@
 package anthropic
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/url"
@@ -15,7 +16,9 @@ const (
 
 // ErrEmptyResponse is returned when the OpenAI API returns an empty response.
 var (
-	ErrEmptyResponse = errors.New("empty response")
+	ErrEmptyResponse       = errors.New("empty response")
+	ErrInvalidMessageOrder = errors.New("invalid order of chat messages")
+	ErrImageFetch          = errors.New("unable to fetch the image")
 )
 
 // Client is a client for accessing OpenAI API
@@ -35,20 +38,18 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 		return nil, err
 	}
 
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Client{
 		baseURL:             providerConfig.BaseURL,
 		chatURL:             chatURL,
 		config:              providerConfig,
 		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
-		httpClient: &http.Client{
-			Timeout: *clientConfig.Timeout,
-			// TODO: use values from the config
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 2,
-			},
-		},
-		telemetry: tel,
+		httpClient:          httpClient,
+		telemetry:           tel,
 	}
 
 	return c, nil
@@ -57,3 +58,23 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 func (c *Client) Provider() string {
 	return providerName
 }
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsTools reports whether the client could translate tools of the unified chat request
+func (c *Client) SupportsTools() bool {
+	return true
+}
+
+// SupportsVision reports whether the client could translate images of the unified chat request
+func (c *Client) SupportsVision() bool {
+	return true
+}
+
+// SupportsResponseFormat reports whether the client could translate the response format (JSON mode & structured output)
+func (c *Client) SupportsResponseFormat() bool {
+	return true
+}
@@ -22,15 +22,22 @@ import (
 func TestAnthropicClient_ChatRequest(t *testing.T) {
 	// Anthropic Messages API: https://docs.anthropic.com/claude/reference/messages_post
 	AnthropicMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "2023-06-01", r.Header.Get("Anthropic-Version"))
+		require.Equal(t, "test-key", r.Header.Get("X-Api-Key"))
+
 		rawPayload, _ := io.ReadAll(r.Body)
 
-		var data interface{}
+		var data struct {
+			System json.RawMessage `json:"system"`
+		}
 		// Parse the JSON body
 		err := json.Unmarshal(rawPayload, &data)
 		if err != nil {
 			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
 		}
 
+		require.JSONEq(t, `[{"type": "text", "text": "You are a helpful assistant."}]`, string(data.System))
+
 		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.success.json"))
 		if err != nil {
 			t.Errorf("error reading openai chat mock response: %v", err)
@@ -51,6 +58,7 @@ func TestAnthropicClient_ChatRequest(t *testing.T) {
 	clientCfg := clients.DefaultClientConfig()
 
 	providerCfg.BaseURL = AnthropicServer.URL
+	providerCfg.APIKey = "test-key"
 
 	client, err := NewClient(providerCfg, clientCfg, telemetry.NewTelemetryMock())
 	require.NoError(t, err)
@@ -64,6 +72,13 @@ func TestAnthropicClient_ChatRequest(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Equal(t, "msg_013Zva2CMHLNnXjNJJKqJ2EF", response.ID)
+	require.Equal(t, schemas.FinishReasonStop, response.ModelResponse.FinishReason)
+	require.Equal(t, schemas.TokenUsage{
+		PromptTokens:    1036,
+		ResponseTokens:  11,
+		TotalTokens:     1047,
+		CacheReadTokens: 1024,
+	}, response.ModelResponse.TokenUsage)
 }
 
 func TestAnthropicClient_BadChatRequest(t *testing.T) {
@@ -101,3 +116,378 @@ func TestAnthropicClient_BadChatRequest(t *testing.T) {
 	// Assert that the response is nil
 	require.Nil(t, response)
 }
+
+func TestAnthropicClient_ParamOverridesClamped(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	temperature, topK := 1.5, 10
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.Override.Params = &schemas.ChatParams{
+		Temperature: &temperature,
+		TopK:        &topK,
+		Stop:        []string{"\n\nHuman:"},
+	}
+
+	chatRequest, err := client.createChatRequestSchema(request)
+	require.NoError(t, err)
+
+	require.Equal(t, 1.0, chatRequest.Temperature)
+	require.Equal(t, 10, chatRequest.TopK)
+	require.Equal(t, []string{"\n\nHuman:"}, chatRequest.StopSequences)
+}
+
+func TestAnthropicClient_UserForwarded(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DefaultParams.Metadata = &Metadata{UserID: "default-user"}
+
+	client, err := NewClient(cfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+
+	chatRequest, err := client.createChatRequestSchema(request)
+	require.NoError(t, err)
+	require.Equal(t, &Metadata{UserID: "default-user"}, chatRequest.Metadata)
+
+	request.User = "user-42"
+
+	chatRequest, err = client.createChatRequestSchema(request)
+	require.NoError(t, err)
+	require.Equal(t, &Metadata{UserID: "user-42"}, chatRequest.Metadata)
+
+	payload, err := json.Marshal(chatRequest)
+	require.NoError(t, err)
+	require.Contains(t, string(payload), `"metadata":{"user_id":"user-42"}`)
+}
+
+func TestAnthropicClient_MessagesTranslated(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := &schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{
+			{Role: schemas.RoleSystem, Content: "You are a zoologist."},
+			{Role: schemas.RoleSystem, Content: "Answer briefly."},
+			{Role: schemas.RoleUser, Content: "Hi!"},
+			{Role: schemas.RoleUser, Content: "What's the biggest animal?"},
+			{Role: schemas.RoleAssistant, Content: "The blue whale."},
+			{Role: schemas.RoleUser, Content: "How big is it?"},
+		},
+	}
+
+	chatRequest, err := client.createChatRequestSchema(request)
+	require.NoError(t, err)
+
+	require.Equal(t, []ContentBlock{
+		{Type: TextBlock, Text: "You are a zoologist."},
+		{Type: TextBlock, Text: "Answer briefly."},
+	}, chatRequest.System)
+	require.Equal(t, []ChatMessage{
+		{Role: schemas.RoleUser, Content: "Hi!\n\nWhat's the biggest animal?"},
+		{Role: schemas.RoleAssistant, Content: "The blue whale."},
+		{Role: schemas.RoleUser, Content: "How big is it?"},
+	}, chatRequest.Messages)
+
+	request.Messages = []schemas.ChatMessage{
+		{Role: schemas.RoleAssistant, Content: "How can I help?"},
+		{Role: schemas.RoleUser, Content: "What's the biggest animal?"},
+	}
+
+	_, err = client.createChatRequestSchema(request)
+	require.ErrorIs(t, err, ErrInvalidMessageOrder)
+}
+
+func TestAnthropicClient_CacheBreakpoints(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := &schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{
+			{Role: schemas.RoleSystem, Content: "You are a zoologist.", Cache: true},
+			{Role: schemas.RoleUser, Content: "Here is the encyclopedia of animals.", Cache: true},
+			{Role: schemas.RoleUser, Content: "What's the biggest animal?"},
+		},
+	}
+
+	chatRequest, err := client.createChatRequestSchema(request)
+	require.NoError(t, err)
+
+	require.Equal(t, []ContentBlock{
+		{Type: TextBlock, Text: "You are a zoologist.", CacheControl: ephemeralCache},
+	}, chatRequest.System)
+
+	rawMessages, err := json.Marshal(chatRequest.Messages)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `[{"role": "user", "content": [
+		{"type": "text", "text": "Here is the encyclopedia of animals.", "cache_control": {"type": "ephemeral"}},
+		{"type": "text", "text": "What's the biggest animal?"}
+	]}]`, string(rawMessages))
+}
+
+func TestAnthropicClient_CachedContentParts(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	var request schemas.UnifiedChatRequest
+
+	err = json.Unmarshal([]byte(`{"messages": [
+		{"role": "system", "content": [
+			{"type": "text", "text": "You are a zoologist.", "cache_control": {"type": "ephemeral"}},
+			{"type": "text", "text": "Answer briefly."}
+		]},
+		{"role": "user", "content": [
+			{"type": "text", "text": "Here is the encyclopedia of animals.", "cache_control": {"type": "ephemeral"}},
+			{"type": "text", "text": "What's the biggest animal?"}
+		]}
+	]}`), &request)
+	require.NoError(t, err)
+	require.NoError(t, request.Validate())
+
+	chatRequest, err := client.createChatRequestSchema(&request)
+	require.NoError(t, err)
+
+	require.Equal(t, []ContentBlock{
+		{Type: TextBlock, Text: "You are a zoologist.", CacheControl: ephemeralCache},
+		{Type: TextBlock, Text: "Answer briefly."},
+	}, chatRequest.System)
+
+	rawMessages, err := json.Marshal(chatRequest.Messages)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `[{"role": "user", "content": [
+		{"type": "text", "text": "Here is the encyclopedia of animals.", "cache_control": {"type": "ephemeral"}},
+		{"type": "text", "text": "What's the biggest animal?"}
+	]}]`, string(rawMessages))
+}
+
+func TestAnthropicClient_ToolUse(t *testing.T) {
+	AnthropicMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data struct {
+			Messages []struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+			Tools      []Tool      `json:"tools"`
+			ToolChoice *ToolChoice `json:"tool_choice"`
+		}
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Equal(t, []Tool{{
+			Name:        "get_current_weather",
+			InputSchema: map[string]interface{}{"type": "object"},
+		}}, data.Tools)
+		require.Equal(t, &ToolChoice{Type: "any"}, data.ToolChoice)
+
+		// tool results go back in the user message
+		require.Len(t, data.Messages, 3)
+		require.JSONEq(t, `"What's the weather like in Boston?"`, string(data.Messages[0].Content))
+		require.JSONEq(
+			t,
+			`[{"type": "tool_use", "id": "toolu_000", "name": "get_current_weather", "input": {"location": "Boston"}}]`,
+			string(data.Messages[1].Content),
+		)
+		require.Equal(t, schemas.RoleUser, data.Messages[2].Role)
+		require.JSONEq(
+			t,
+			`[{"type": "tool_result", "tool_use_id": "toolu_000", "content": "Not found, please specify the state"}]`,
+			string(data.Messages[2].Content),
+		)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.tool_use.json"))
+		if err != nil {
+			t.Errorf("error reading anthropic chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	AnthropicServer := httptest.NewServer(AnthropicMock)
+	defer AnthropicServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = AnthropicServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{
+			{Role: schemas.RoleUser, Content: "What's the weather like in Boston?"},
+			{Role: schemas.RoleAssistant, ToolCalls: []schemas.ToolCall{{
+				ID:       "toolu_000",
+				Type:     schemas.ToolTypeFunction,
+				Function: schemas.FunctionCall{Name: "get_current_weather", Arguments: `{"location": "Boston"}`},
+			}}},
+			{Role: schemas.RoleTool, ToolCallID: "toolu_000", Content: "Not found, please specify the state"},
+		},
+		Tools: []schemas.ToolDefinition{{
+			Type: schemas.ToolTypeFunction,
+			Function: schemas.FunctionDefinition{
+				Name:       "get_current_weather",
+				Parameters: map[string]interface{}{"type": "object"},
+			},
+		}},
+		ToolChoice: &schemas.ToolChoice{Type: schemas.ToolChoiceRequired},
+	}
+
+	response, err := client.Chat(context.Background(), &request)
+	require.NoError(t, err)
+
+	require.Equal(t, schemas.RoleAssistant, response.ModelResponse.Message.Role)
+	require.Equal(t, "I need to check the weather in Boston.", response.ModelResponse.Message.Content)
+	require.Equal(t, []schemas.ToolCall{{
+		ID:       "toolu_01A09q90qw90lq917835lq9",
+		Type:     schemas.ToolTypeFunction,
+		Function: schemas.FunctionCall{Name: "get_current_weather", Arguments: `{"location": "Boston, MA"}`},
+	}}, response.ModelResponse.Message.ToolCalls)
+	require.Equal(t, schemas.FinishReasonToolCalls, response.ModelResponse.FinishReason)
+}
+
+func TestAnthropicClient_StopReasonsMapped(t *testing.T) {
+	require.Equal(t, schemas.FinishReasonStop, newFinishReason(StopReasonEndTurn))
+	require.Equal(t, schemas.FinishReasonStop, newFinishReason(StopReasonStopSequence))
+	require.Equal(t, schemas.FinishReasonLength, newFinishReason(StopReasonMaxTokens))
+	require.Equal(t, schemas.FinishReasonToolCalls, newFinishReason(StopReasonToolUse))
+	require.Equal(t, "refusal", newFinishReason("refusal"))
+}
+
+func TestAnthropicClient_StructuredOutput(t *testing.T) {
+	AnthropicMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data ChatRequest
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Len(t, data.Tools, 1)
+		require.Equal(t, "get_current_weather", data.Tools[0].Name)
+		require.Equal(t, &ToolChoice{Type: "tool", Name: "get_current_weather"}, data.ToolChoice)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.tool_use.json"))
+		if err != nil {
+			t.Errorf("error reading anthropic chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	AnthropicServer := httptest.NewServer(AnthropicMock)
+	defer AnthropicServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = AnthropicServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.NewChatFromStr("What's the weather like in Boston?")
+	request.ResponseFormat = &schemas.ResponseFormat{
+		Type: schemas.ResponseFormatJSONSchema,
+		JSONSchema: &schemas.JSONSchemaFormat{
+			Name:   "get_current_weather",
+			Schema: map[string]interface{}{"type": "object"},
+		},
+	}
+
+	response, err := client.Chat(context.Background(), request)
+	require.NoError(t, err)
+
+	// the forced tool input is the response itself
+	require.JSONEq(t, `{"location": "Boston, MA"}`, response.ModelResponse.Message.Content)
+	require.Empty(t, response.ModelResponse.Message.ToolCalls)
+}
+
+func TestAnthropicClient_ImagesInlined(t *testing.T) {
+	image := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(image)
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data struct {
+			Messages []struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Len(t, data.Messages, 1)
+		require.JSONEq(t, `[
+			{"type": "text", "text": "What's in these images?"},
+			{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgoAAAANSUhEUg=="}},
+			{"type": "image", "source": {"type": "base64", "media_type": "image/jpeg", "data": "/9j/4AAQ"}}
+		]`, string(data.Messages[0].Content))
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.success.json"))
+		if err != nil {
+			t.Errorf("error reading anthropic chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	AnthropicServer := httptest.NewServer(mux)
+	defer AnthropicServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = AnthropicServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{{
+			Role: schemas.RoleUser,
+			ContentParts: []schemas.ContentPart{
+				{Type: schemas.ContentPartText, Text: "What's in these images?"},
+				{Type: schemas.ContentPartImageURL, ImageURL: &schemas.ImageURL{URL: AnthropicServer.URL + "/image"}},
+				{Type: schemas.ContentPartImageURL, ImageURL: &schemas.ImageURL{URL: "data:image/jpeg;base64,/9j/4AAQ"}},
+			},
+		}},
+	}
+
+	_, err = client.Chat(context.Background(), &request)
+	require.NoError(t, err)
+
+	// the request is not changed, so other providers get original image URLs on retries
+	require.Equal(t, schemas.ContentPartImageURL, request.Messages[0].ContentParts[1].Type)
+
+	// images over the size limit are not downloaded
+	providerCfg.MaxImageSize = int64(len(image) - 1)
+
+	_, err = client.Chat(context.Background(), &request)
+	require.ErrorIs(t, err, ErrImageFetch)
+}
@@ -7,16 +7,23 @@ import (
 // Params defines OpenAI-specific model params with the specific validation of values
 // TODO: Add validations
 type Params struct {
-	System        string   `yaml:"system,omitempty" json:"system"`
-	Temperature   float64  `yaml:"temperature,omitempty" json:"temperature"`
-	TopP          float64  `yaml:"top_p,omitempty" json:"top_p"`
-	TopK          int      `yaml:"top_k,omitempty" json:"top_k"`
-	MaxTokens     int      `yaml:"max_tokens,omitempty" json:"max_tokens"`
-	StopSequences []string `yaml:"stop,omitempty" json:"stop"`
-	Metadata      *string  `yaml:"metadata,omitempty" json:"metadata"`
+	// System is the default system prompt. It's sent as the top-level system text block unless the request has system messages
+	System        string    `yaml:"system,omitempty" json:"system"`
+	Temperature   float64   `yaml:"temperature,omitempty" json:"temperature"`
+	TopP          float64   `yaml:"top_p,omitempty" json:"top_p"`
+	TopK          int       `yaml:"top_k,omitempty" json:"top_k"`
+	MaxTokens     int       `yaml:"max_tokens,omitempty" json:"max_tokens"`
+	StopSequences []string  `yaml:"stop,omitempty" json:"stop"`
+	Metadata      *Metadata `yaml:"metadata,omitempty" json:"metadata"`
 	// Stream           bool             `json:"stream,omitempty"` // TODO: we are not supporting this at the moment
 }
 
+// Metadata describes the request for Anthropic
+type Metadata struct {
+	// UserID is the default end user ID Anthropic attributes abuse to (the user of the request takes precedence)
+	UserID string `yaml:"user_id,omitempty" json:"user_id,omitempty"`
+}
+
 func DefaultParams() Params {
 	return Params{
 		Temperature:   1,
@@ -37,11 +44,15 @@ func (p *Params) UnmarshalYAML(unmarshal func(interface{}) error) error {
 }
 
 type Config struct {
-	BaseURL       string        `yaml:"baseUrl" json:"baseUrl" validate:"required"`
-	ChatEndpoint  string        `yaml:"chatEndpoint" json:"chatEndpoint" validate:"required"`
-	Model         string        `yaml:"model" json:"model" validate:"required"`
-	APIKey        fields.Secret `yaml:"api_key" json:"-" validate:"required"`
-	DefaultParams *Params       `yaml:"defaultParams,omitempty" json:"defaultParams"`
+	BaseURL      string        `yaml:"baseUrl" json:"baseUrl" validate:"required"`
+	ChatEndpoint string        `yaml:"chatEndpoint" json:"chatEndpoint" validate:"required"`
+	Model        string        `yaml:"model" json:"model" validate:"required"`
+	APIKey       fields.Secret `yaml:"api_key" json:"-" validate:"required"`
+	// APIVersion is sent as the anthropic-version header (https://docs.anthropic.com/claude/reference/versions)
+	APIVersion    string  `yaml:"api_version" json:"api_version" validate:"required,datetime=2006-01-02"`
+	DefaultParams *Params `yaml:"defaultParams,omitempty" json:"defaultParams"`
+	// MaxImageSize limits the size of images Glide downloads to pass them inline (Anthropic takes base64-encoded images only)
+	MaxImageSize int64 `yaml:"max_image_size" json:"max_image_size" validate:"gt=0"`
 }
 
 // DefaultConfig for OpenAI models
@@ -51,8 +62,10 @@ func DefaultConfig() *Config {
 	return &Config{
 		BaseURL:       "https://api.anthropic.com/v1",
 		ChatEndpoint:  "/messages",
+		APIVersion:    "2023-06-01",
 		Model:         "claude-instant-1.2",
 		DefaultParams: &defaultParams,
+		MaxImageSize:  5 * 1024 * 1024, // the Anthropic limit
 	}
 }
 
@@ -0,0 +1,176 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"glide/pkg/api/schemas"
+)
+
+// ImageBlock is the content block type of images
+const ImageBlock = "image"
+
+// ImageSource is a base64-encoded image of the image block
+type ImageSource struct {
+	Type      string `json:"type"` // base64 is the only option
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// supportedMediaTypes lists image types Anthropic accepts (https://docs.anthropic.com/claude/docs/vision)
+var supportedMediaTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+	"image/gif":  {},
+	"image/webp": {},
+}
+
+// inlineImages replaces image URLs of the request with base64-encoded images, as Anthropic doesn't take links.
+// The original request is left intact, so it could be retried with other providers
+func (c *Client) inlineImages(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatRequest, error) {
+	if !request.HasImages() {
+		return request, nil
+	}
+
+	chatMessages := request.ChatMessages()
+	messages := make([]schemas.ChatMessage, 0, len(chatMessages))
+
+	for _, message := range chatMessages {
+		if message.HasImages() {
+			parts := make([]schemas.ContentPart, 0, len(message.ContentParts))
+
+			for _, part := range message.ContentParts {
+				if part.Type == schemas.ContentPartImageURL {
+					image, err := c.fetchImage(ctx, part.ImageURL.URL)
+					if err != nil {
+						return nil, err
+					}
+
+					part = schemas.ContentPart{Type: schemas.ContentPartImageBase64, ImageBase64: image, CacheControl: part.CacheControl}
+				}
+
+				parts = append(parts, part)
+			}
+
+			message.ContentParts = parts
+		}
+
+		messages = append(messages, message)
+	}
+
+	inlinedRequest := *request
+	inlinedRequest.Messages = messages
+
+	return &inlinedRequest, nil
+}
+
+// fetchImage downloads the image (or decodes the data URL) up to the configured size
+func (c *Client) fetchImage(ctx context.Context, imageURL string) (*schemas.ImageBase64, error) {
+	if strings.HasPrefix(imageURL, "data:") {
+		return parseDataURL(imageURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageFetch, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageFetch, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %v responded with status %d", ErrImageFetch, imageURL, resp.StatusCode)
+	}
+
+	if resp.ContentLength > c.config.MaxImageSize {
+		return nil, fmt.Errorf("%w: %v is larger than %d bytes", ErrImageFetch, imageURL, c.config.MaxImageSize)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, c.config.MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageFetch, err)
+	}
+
+	if int64(len(image)) > c.config.MaxImageSize {
+		return nil, fmt.Errorf("%w: %v is larger than %d bytes", ErrImageFetch, imageURL, c.config.MaxImageSize)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !isSupportedMediaType(mediaType) {
+		// servers often respond with a generic content type, so let's sniff it
+		mediaType = http.DetectContentType(image)
+	}
+
+	if !isSupportedMediaType(mediaType) {
+		return nil, fmt.Errorf("%w: %v has unsupported media type %q", ErrImageFetch, imageURL, mediaType)
+	}
+
+	return &schemas.ImageBase64{
+		MediaType: mediaType,
+		Data:      base64.StdEncoding.EncodeToString(image),
+	}, nil
+}
+
+// parseDataURL takes the base64-encoded image out of the data URL (e.g. data:image/png;base64,iVBORw0KGgo...)
+func parseDataURL(dataURL string) (*schemas.ImageBase64, error) {
+	header, data, found := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !found || !strings.HasSuffix(header, ";base64") {
+		return nil, fmt.Errorf("%w: only base64-encoded data URLs are supported", ErrImageFetch)
+	}
+
+	mediaType := strings.TrimSuffix(header, ";base64")
+	if !isSupportedMediaType(mediaType) {
+		return nil, fmt.Errorf("%w: unsupported media type %q", ErrImageFetch, mediaType)
+	}
+
+	return &schemas.ImageBase64{MediaType: mediaType, Data: data}, nil
+}
+
+func isSupportedMediaType(mediaType string) bool {
+	_, supported := supportedMediaTypes[mediaType]
+
+	return supported
+}
+
+// newContentBlocks translates the multimodal content into text & image blocks
+func newContentBlocks(parts []schemas.ContentPart) ([]ContentBlock, error) {
+	blocks := make([]ContentBlock, 0, len(parts))
+
+	for _, part := range parts {
+		var block ContentBlock
+
+		switch part.Type {
+		case schemas.ContentPartText:
+			block = ContentBlock{Type: TextBlock, Text: part.Text}
+		case schemas.ContentPartImageBase64:
+			block = ContentBlock{
+				Type: ImageBlock,
+				Source: &ImageSource{
+					Type:      "base64",
+					MediaType: part.ImageBase64.MediaType,
+					Data:      part.ImageBase64.Data,
+				},
+			}
+		default:
+			// image URLs are inlined before the translation
+			return nil, fmt.Errorf("%w: anthropic doesn't take %v content parts", schemas.ErrInvalidMessages, part.Type)
+		}
+
+		if part.CacheControl != nil {
+			block.CacheControl = ephemeralCache
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
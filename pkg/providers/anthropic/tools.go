@@ -0,0 +1,174 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"glide/pkg/api/schemas"
+)
+
+// Anthropic content block types (https://docs.anthropic.com/claude/docs/tool-use)
+const (
+	TextBlock       = "text"
+	ToolUseBlock    = "tool_use"
+	ToolResultBlock = "tool_result"
+)
+
+// ContentBlock is a part of the message content. Tool calls and their results are passed as content blocks
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`          // tool_use blocks only
+	Name      string          `json:"name,omitempty"`        // tool_use blocks only
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use blocks only
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result blocks only
+	Content   string          `json:"content,omitempty"`     // tool_result blocks only
+	Source    *ImageSource    `json:"source,omitempty"`      // image blocks only
+	// CacheControl marks the end of the prompt prefix Anthropic caches (https://docs.anthropic.com/claude/docs/prompt-caching)
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// CacheControl is the cache breakpoint of the content block
+type CacheControl struct {
+	Type string `json:"type"` // ephemeral is the only option
+}
+
+// ephemeralCache is the cache control of cached blocks
+var ephemeralCache = &CacheControl{Type: "ephemeral"}
+
+// Tool is an Anthropic tool definition
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type ToolChoice struct {
+	Type string `json:"type"` // one of auto, any or tool
+	Name string `json:"name,omitempty"`
+}
+
+// applyTools translates tools of the unified request into Anthropic ones.
+// Anthropic has no way to forbid tool calls, so tools are not passed at all when the tool choice is none
+func applyTools(chatRequest *ChatRequest, request *schemas.UnifiedChatRequest) {
+	if !request.HasTools() {
+		return
+	}
+
+	if request.ToolChoice != nil && request.ToolChoice.Type == schemas.ToolChoiceNone {
+		return
+	}
+
+	tools := make([]Tool, 0, len(request.Tools))
+
+	for _, tool := range request.Tools {
+		inputSchema := tool.Function.Parameters
+		if inputSchema == nil {
+			// input schema is required by Anthropic
+			inputSchema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+
+		tools = append(tools, Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: inputSchema,
+		})
+	}
+
+	chatRequest.Tools = tools
+
+	if request.ToolChoice == nil {
+		return
+	}
+
+	switch request.ToolChoice.Type {
+	case schemas.ToolChoiceAuto:
+		chatRequest.ToolChoice = &ToolChoice{Type: "auto"}
+	case schemas.ToolChoiceRequired:
+		chatRequest.ToolChoice = &ToolChoice{Type: "any"}
+	case schemas.ToolChoiceFunction:
+		chatRequest.ToolChoice = &ToolChoice{Type: "tool", Name: request.ToolChoice.Name}
+	}
+}
+
+// newToolUseBlocks translates tool calls of the assistant message into tool_use blocks
+func newToolUseBlocks(toolCalls []schemas.ToolCall) ([]ContentBlock, error) {
+	blocks := make([]ContentBlock, 0, len(toolCalls))
+
+	for _, toolCall := range toolCalls {
+		input := json.RawMessage(toolCall.Function.Arguments)
+
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+
+		if !json.Valid(input) {
+			return nil, fmt.Errorf(
+				"%w: arguments of tool call %q are not valid JSON",
+				schemas.ErrInvalidMessages,
+				toolCall.ID,
+			)
+		}
+
+		blocks = append(blocks, ContentBlock{
+			Type:  ToolUseBlock,
+			ID:    toolCall.ID,
+			Name:  toolCall.Function.Name,
+			Input: input,
+		})
+	}
+
+	return blocks, nil
+}
+
+// newToolCalls collects tool_use blocks of the response into unified tool calls
+func newToolCalls(content []schemas.Content) []schemas.ToolCall {
+	var toolCalls []schemas.ToolCall
+
+	for _, block := range content {
+		if block.Type != ToolUseBlock {
+			continue
+		}
+
+		toolCalls = append(toolCalls, schemas.ToolCall{
+			ID:   block.ID,
+			Type: schemas.ToolTypeFunction,
+			Function: schemas.FunctionCall{
+				Name:      block.Name,
+				Arguments: string(block.Input),
+			},
+		})
+	}
+
+	return toolCalls
+}
+
+// defaultResponseTool is the name of the tool used to get JSON responses when the response format has no schema name
+const defaultResponseTool = "json_response"
+
+// applyResponseFormat makes the model respond with JSON by forcing it to call a tool with the response schema as its input schema.
+// The tool input is returned as the response content then. Tools of the request are not called in this case, as the response tool is forced
+func applyResponseFormat(chatRequest *ChatRequest, request *schemas.UnifiedChatRequest) {
+	if !request.StructuredOutput() {
+		return
+	}
+
+	responseTool := Tool{
+		Name:        defaultResponseTool,
+		Description: "Respond with a JSON object",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}
+
+	if format := request.ResponseFormat; format.Type == schemas.ResponseFormatJSONSchema {
+		responseTool.Name = format.JSONSchema.Name
+		responseTool.InputSchema = format.JSONSchema.Schema
+
+		if format.JSONSchema.Description != "" {
+			responseTool.Description = format.JSONSchema.Description
+		}
+	}
+
+	chatRequest.Tools = append(chatRequest.Tools, responseTool)
+	chatRequest.ToolChoice = &ToolChoice{Type: "tool", Name: responseTool.Name}
+	chatRequest.responseTool = responseTool.Name
+}
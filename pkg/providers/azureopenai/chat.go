@@ -7,36 +7,53 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 
 	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
 
 	"glide/pkg/api/schemas"
 	"go.uber.org/zap"
 )
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role         string               `json:"role"`
+	Content      string               `json:"content"`
+	ToolCalls    []schemas.ToolCall   `json:"tool_calls,omitempty"`
+	ToolCallID   string               `json:"tool_call_id,omitempty"`
+	ContentParts []openai.ContentPart `json:"-"` // replaces the content in the payload if the message has images
+}
+
+// MarshalJSON passes the content as the list of parts if the message is multimodal (Azure follows the OpenAI format)
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	type plain ChatMessage // to avoid recursion
+
+	if len(m.ContentParts) == 0 {
+		return json.Marshal(plain(m))
+	}
+
+	return json.Marshal(struct {
+		plain
+		Content []openai.ContentPart `json:"content"`
+	}{plain(m), m.ContentParts})
 }
 
 // ChatRequest is an Azure openai-specific request schema
 type ChatRequest struct {
-	Messages         []ChatMessage    `json:"messages"`
-	Temperature      float64          `json:"temperature,omitempty"`
-	TopP             float64          `json:"top_p,omitempty"`
-	MaxTokens        int              `json:"max_tokens,omitempty"`
-	N                int              `json:"n,omitempty"`
-	StopWords        []string         `json:"stop,omitempty"`
-	Stream           bool             `json:"stream,omitempty"`
-	FrequencyPenalty int              `json:"frequency_penalty,omitempty"`
-	PresencePenalty  int              `json:"presence_penalty,omitempty"`
-	LogitBias        *map[int]float64 `json:"logit_bias,omitempty"`
-	User             *string          `json:"user,omitempty"`
-	Seed             *int             `json:"seed,omitempty"`
-	Tools            []string         `json:"tools,omitempty"`
-	ToolChoice       interface{}      `json:"tool_choice,omitempty"`
-	ResponseFormat   interface{}      `json:"response_format,omitempty"`
+	Messages         []ChatMessage            `json:"messages"`
+	Temperature      float64                  `json:"temperature,omitempty"`
+	TopP             float64                  `json:"top_p,omitempty"`
+	MaxTokens        int                      `json:"max_tokens,omitempty"`
+	N                int                      `json:"n,omitempty"`
+	StopWords        []string                 `json:"stop,omitempty"`
+	Stream           bool                     `json:"stream,omitempty"`
+	FrequencyPenalty float64                  `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64                  `json:"presence_penalty,omitempty"`
+	LogitBias        *map[int]float64         `json:"logit_bias,omitempty"`
+	User             *string                  `json:"user,omitempty"`
+	Seed             *int                     `json:"seed,omitempty"`
+	Tools            []schemas.ToolDefinition `json:"tools,omitempty"`
+	ToolChoice       interface{}              `json:"tool_choice,omitempty"`
+	ResponseFormat   interface{}              `json:"response_format,omitempty"`
 }
 
 // NewChatRequestFromConfig fills the struct from the config. Not using reflection because of performance penalty it gives
@@ -60,15 +77,19 @@ func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
 }
 
 func NewChatMessagesFromUnifiedRequest(request *schemas.UnifiedChatRequest) []ChatMessage {
-	messages := make([]ChatMessage, 0, len(request.MessageHistory)+1)
-
-	// Add items from messageHistory first and the new chat message last
-	for _, message := range request.MessageHistory {
-		messages = append(messages, ChatMessage{Role: message.Role, Content: message.Content})
+	chatMessages := request.ChatMessages()
+	messages := make([]ChatMessage, 0, len(chatMessages))
+
+	for _, message := range chatMessages {
+		messages = append(messages, ChatMessage{
+			Role:         message.Role,
+			Content:      message.Content,
+			ToolCalls:    message.ToolCalls,
+			ToolCallID:   message.ToolCallID,
+			ContentParts: openai.NewContentParts(message.ContentParts),
+		})
 	}
 
-	messages = append(messages, ChatMessage{Role: request.Message.Role, Content: request.Message.Content})
-
 	return messages
 }
 
@@ -82,7 +103,7 @@ func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest)
 		return nil, err
 	}
 
-	if len(chatResponse.ModelResponse.Message.Content) == 0 {
+	if len(chatResponse.ModelResponse.Message.Content) == 0 && len(chatResponse.ModelResponse.Message.ToolCalls) == 0 {
 		return nil, ErrEmptyResponse
 	}
 
@@ -91,10 +112,49 @@ func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest)
 
 func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *ChatRequest {
 	// TODO: consider using objectpool to optimize memory allocation
-	chatRequest := c.chatRequestTemplate // hoping to get a copy of the template
+	chatRequest := *c.chatRequestTemplate // copy the template
 	chatRequest.Messages = NewChatMessagesFromUnifiedRequest(request)
 
-	return chatRequest
+	if request.Seed != nil {
+		chatRequest.Seed = request.Seed
+	}
+
+	if request.User != "" {
+		chatRequest.User = &request.User
+	}
+
+	if request.PresencePenalty != nil {
+		chatRequest.PresencePenalty = *request.PresencePenalty
+	}
+
+	if request.FrequencyPenalty != nil {
+		chatRequest.FrequencyPenalty = *request.FrequencyPenalty
+	}
+
+	if request.N > 0 {
+		chatRequest.N = request.N
+	}
+
+	if len(request.LogitBias) > 0 {
+		chatRequest.LogitBias = openai.NewLogitBias(request.LogitBias)
+	}
+
+	if request.HasTools() {
+		chatRequest.Tools = request.Tools
+
+		if request.ToolChoice != nil {
+			chatRequest.ToolChoice = openai.NewToolChoice(request.ToolChoice)
+		}
+	}
+
+	if request.ResponseFormat != nil {
+		// the unified response format follows the OpenAI one
+		chatRequest.ResponseFormat = request.ResponseFormat
+	}
+
+	c.applyParamOverrides(&chatRequest, request.Override.Params)
+
+	return &chatRequest
 }
 
 func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*schemas.UnifiedChatResponse, error) {
@@ -109,7 +169,7 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, fmt.Errorf("unable to create azure openai chat request: %w", err)
 	}
 
-	req.Header.Set("api-key", string(c.config.APIKey))
+	req.Header.Set("api-key", c.config.APIKey.Value())
 	req.Header.Set("Content-Type", "application/json")
 
 	// TODO: this could leak information from messages which may not be a desired thing to have
@@ -140,20 +200,11 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		)
 
 		if resp.StatusCode == http.StatusTooManyRequests {
-			// Read the value of the "Retry-After" header to get the cooldown delay
-			retryAfter := resp.Header.Get("Retry-After")
-
-			// Parse the value to get the duration
-			cooldownDelay, err := time.ParseDuration(retryAfter)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse cooldown delay from headers: %w", err)
-			}
-
-			return nil, clients.NewRateLimitError(&cooldownDelay)
+			return nil, clients.NewRetryAfterError(resp.Header)
 		}
 
 		// Server & client errors result in the same error to keep gateway resilient
-		return nil, clients.ErrProviderUnavailable
+		return nil, clients.NewProviderError(resp.StatusCode)
 	}
 
 	// Read the response body into a byte slice
@@ -172,8 +223,6 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, err
 	}
 
-	openAICompletion.SystemFingerprint = "" // Azure OpenAI doesn't return this
-
 	// Map response to UnifiedChatResponse schema
 	response := schemas.UnifiedChatResponse{
 		ID:       openAICompletion.ID,
@@ -186,10 +235,12 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 				"system_fingerprint": openAICompletion.SystemFingerprint,
 			},
 			Message: schemas.ChatMessage{
-				Role:    openAICompletion.Choices[0].Message.Role,
-				Content: openAICompletion.Choices[0].Message.Content,
-				Name:    "",
+				Role:      openAICompletion.Choices[0].Message.Role,
+				Content:   openAICompletion.Choices[0].Message.Content,
+				Name:      "",
+				ToolCalls: openAICompletion.Choices[0].Message.ToolCalls,
 			},
+			Choices: openai.NewChoices(&openAICompletion),
 			TokenUsage: schemas.TokenUsage{
 				PromptTokens:   openAICompletion.Usage.PromptTokens,
 				ResponseTokens: openAICompletion.Usage.CompletionTokens,
@@ -200,3 +251,30 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 
 	return &response, nil
 }
+
+// applyParamOverrides merges per-request params over the default ones. Params the provider doesn't support are dropped
+func (c *Client) applyParamOverrides(chatRequest *ChatRequest, params *schemas.ChatParams) {
+	if params == nil {
+		return
+	}
+
+	if params.Temperature != nil {
+		chatRequest.Temperature = min(*params.Temperature, 2)
+	}
+
+	if params.TopP != nil {
+		chatRequest.TopP = *params.TopP
+	}
+
+	if params.MaxTokens != nil {
+		chatRequest.MaxTokens = *params.MaxTokens
+	}
+
+	if params.Stop != nil {
+		chatRequest.StopWords = params.Stop
+	}
+
+	if params.TopK != nil {
+		c.telemetry.Logger.Debug("top_k param is not supported by the provider, dropping it", zap.String("provider", providerName))
+	}
+}
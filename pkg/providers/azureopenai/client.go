@@ -1,10 +1,12 @@
 package azureopenai
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 
+	"glide/pkg/api/schemas"
 	"glide/pkg/providers/clients"
 	"glide/pkg/telemetry"
 )
@@ -25,6 +27,7 @@ type Client struct {
 	chatRequestTemplate *ChatRequest
 	config              *Config
 	httpClient          *http.Client
+	tokenizer           clients.Tokenizer
 	telemetry           *telemetry.Telemetry
 }
 
@@ -37,20 +40,19 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 		providerConfig.APIVersion,
 	)
 
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Client{
 		baseURL:             providerConfig.BaseURL,
 		chatURL:             chatURL,
 		config:              providerConfig,
 		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
-		httpClient: &http.Client{
-			// TODO: use values from the config
-			Timeout: *clientConfig.Timeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 2,
-			},
-		},
-		telemetry: tel,
+		httpClient:          httpClient,
+		tokenizer:           clients.NewTiktokenTokenizer(providerConfig.Model), // deployment names usually follow model names, otherwise the default encoding is used
+		telemetry:           tel,
 	}
 
 	return c, nil
@@ -59,3 +61,43 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 func (c *Client) Provider() string {
 	return providerName
 }
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	switch param {
+	case schemas.ParamSeed, schemas.ParamPresencePenalty, schemas.ParamFrequencyPenalty, schemas.ParamN, schemas.ParamLogitBias:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaxStopSequences is the number of stop sequences Azure OpenAI takes per request
+func (c *Client) MaxStopSequences() int {
+	return 4
+}
+
+// Tokenizer returns the tiktoken tokenizer of the model
+func (c *Client) Tokenizer() clients.Tokenizer {
+	return c.tokenizer
+}
+
+// SupportsTools reports whether the client could translate tools of the unified chat request
+func (c *Client) SupportsTools() bool {
+	return true
+}
+
+// SupportsVision reports whether the client could translate images of the unified chat request
+func (c *Client) SupportsVision() bool {
+	return true
+}
+
+// SupportsResponseFormat reports whether the client could translate the response format (JSON mode & structured output)
+func (c *Client) SupportsResponseFormat() bool {
+	return true
+}
@@ -0,0 +1,208 @@
+package providers
+
+import (
+	"fmt"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+)
+
+// CapabilitiesConfig declares what the model can do. Undeclared capabilities are inferred from the provider,
+// so the declaration is mostly needed to turn off capabilities the model lacks (e.g. vision of text-only OpenAI models)
+type CapabilitiesConfig struct {
+	Streaming *bool `yaml:"streaming,omitempty" json:"streaming,omitempty"`
+	Tools     *bool `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Vision    *bool `yaml:"vision,omitempty" json:"vision,omitempty"`
+	JSONMode  *bool `yaml:"json_mode,omitempty" json:"json_mode,omitempty"`
+	// MaxContextTokens is the size of the model context window (zero means unknown, so any request is routed to the model)
+	MaxContextTokens int `yaml:"max_context_tokens,omitempty" json:"max_context_tokens,omitempty" validate:"gte=0"`
+}
+
+// declared returns the declared value of the capability or nil if it should be inferred
+func (c *CapabilitiesConfig) declared(capability string) *bool {
+	if c == nil {
+		return nil
+	}
+
+	switch capability {
+	case clients.CapabilityStreaming:
+		return c.Streaming
+	case clients.CapabilityTools:
+		return c.Tools
+	case clients.CapabilityVision:
+		return c.Vision
+	case clients.CapabilityJSONMode:
+		return c.JSONMode
+	default:
+		return nil
+	}
+}
+
+// ContextLimiter is implemented by models with the known context window size
+type ContextLimiter interface {
+	MaxContextTokens() int
+}
+
+// MaxContextTokens returns the context window size of the model (zero if it's unknown)
+func MaxContextTokens(model interface{}) int {
+	contextLimiter, ok := model.(ContextLimiter)
+	if !ok {
+		return 0
+	}
+
+	return contextLimiter.MaxContextTokens()
+}
+
+// Chat formatting overhead (https://github.com/openai/openai-cookbook/blob/main/examples/How_to_count_tokens_with_tiktoken.ipynb)
+const (
+	messageOverheadTokens = 4 // role & message delimiters
+	replyPrimingTokens    = 3 // every reply is primed with the assistant role
+)
+
+// TokenizerProvider is implemented by provider clients that know the tokenizer of their model
+type TokenizerProvider interface {
+	Tokenizer() clients.Tokenizer
+}
+
+// ModelTokenizer returns the tokenizer of the model or the heuristic one if it's unknown
+func ModelTokenizer(model interface{}) clients.Tokenizer {
+	tokenizerProvider, ok := model.(TokenizerProvider)
+	if !ok {
+		return clients.HeuristicTokenizer
+	}
+
+	return tokenizerProvider.Tokenizer()
+}
+
+// CountMessageTokens counts tokens the message takes in the model context
+func CountMessageTokens(tokenizer clients.Tokenizer, message *schemas.ChatMessage) int {
+	tokens := messageOverheadTokens + tokenizer.CountTokens(message.Content)
+
+	for _, toolCall := range message.ToolCalls {
+		tokens += tokenizer.CountTokens(toolCall.Function.Name) + tokenizer.CountTokens(toolCall.Function.Arguments)
+	}
+
+	return tokens
+}
+
+// CountContextTokens counts tokens the conversation of the request takes in the model context
+func CountContextTokens(tokenizer clients.Tokenizer, request *schemas.UnifiedChatRequest) int {
+	tokens := replyPrimingTokens
+
+	for _, message := range request.ChatMessages() {
+		tokens += CountMessageTokens(tokenizer, &message)
+	}
+
+	return tokens
+}
+
+// SetCapabilities overrides capabilities inferred from the provider by the declared ones.
+// Capabilities the provider client can't translate could not be declared
+func (m *LangModel) SetCapabilities(capabilities *CapabilitiesConfig) error {
+	for _, capability := range clients.Capabilities {
+		declared := capabilities.declared(capability)
+		if declared == nil || !*declared {
+			continue
+		}
+
+		if capability == clients.CapabilityJSONMode && m.structuredOutputFallback {
+			continue
+		}
+
+		if !HasCapability(m.activeClient(), capability) {
+			return fmt.Errorf(
+				"model %q declares %v capability, but %v provider doesn't support it",
+				m.ID(),
+				capability,
+				m.Provider(),
+			)
+		}
+	}
+
+	m.capabilities = capabilities
+
+	return nil
+}
+
+// supports checks the declared capability falling back to the inferred one
+func (m *LangModel) supports(capability string, inferred bool) bool {
+	if declared := m.capabilities.declared(capability); declared != nil {
+		return *declared
+	}
+
+	return inferred
+}
+
+// SupportsStreaming checks if the model could stream chat responses
+func (m *LangModel) SupportsStreaming() bool {
+	return m.supports(clients.CapabilityStreaming, HasCapability(m.activeClient(), clients.CapabilityStreaming))
+}
+
+// SupportsTools checks if the model could serve chat requests with tools
+func (m *LangModel) SupportsTools() bool {
+	return m.supports(clients.CapabilityTools, HasCapability(m.activeClient(), clients.CapabilityTools))
+}
+
+// SupportsResponseFormat checks if the model could serve chat requests with the response format (natively or via the fallback)
+func (m *LangModel) SupportsResponseFormat() bool {
+	return m.supports(
+		clients.CapabilityJSONMode,
+		m.structuredOutputFallback || HasCapability(m.activeClient(), clients.CapabilityJSONMode),
+	)
+}
+
+// SupportsVision checks if the model could serve chat requests with images
+func (m *LangModel) SupportsVision() bool {
+	return m.supports(clients.CapabilityVision, HasCapability(m.activeClient(), clients.CapabilityVision))
+}
+
+// Tokenizer returns the configured tokenizer of the model or the one of its provider
+func (m *LangModel) Tokenizer() clients.Tokenizer {
+	if m.tokenizer != nil {
+		return m.tokenizer
+	}
+
+	return ModelTokenizer(m.activeClient())
+}
+
+// SetTokenizer overrides the tokenizer of the provider by the named one (empty name keeps the provider tokenizer)
+func (m *LangModel) SetTokenizer(name string) error {
+	if name == "" {
+		m.tokenizer = nil
+
+		return nil
+	}
+
+	tokenizer, err := clients.NewTokenizer(name)
+	if err != nil {
+		return err
+	}
+
+	m.tokenizer = tokenizer
+
+	return nil
+}
+
+// MaxContextTokens returns the declared context window size of the model (zero if it's unknown)
+func (m *LangModel) MaxContextTokens() int {
+	if m.capabilities == nil {
+		return 0
+	}
+
+	return m.capabilities.MaxContextTokens
+}
+
+// checkCapabilities makes sure the model could serve the request
+func (m *LangModel) checkCapabilities(request *schemas.UnifiedChatRequest) error {
+	for _, capability := range RequiredCapabilities(request) {
+		if !HasCapability(m, capability) {
+			return clients.NewCapabilityError(m.Provider(), capability)
+		}
+	}
+
+	if maxTokens := m.MaxContextTokens(); maxTokens > 0 && CountContextTokens(m.Tokenizer(), request) > maxTokens {
+		return clients.NewCapabilityError(m.Provider(), clients.CapabilityMaxContextTokens)
+	}
+
+	return nil
+}
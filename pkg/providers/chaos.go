@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"glide/pkg/providers/clients"
+)
+
+// ErrInjectedFault marks failures injected by chaos testing rather than returned by providers
+var ErrInjectedFault = errors.New("fault injected by chaos testing")
+
+// ChaosConfig injects faults into model requests before they reach the provider, so error budgets, fallbacks
+// & rate limit handling could be exercised with real configs (e.g. in staging).
+// Rates are shares of requests (from 0 to 1). Failure rates add up, while slow responses are injected independently
+type ChaosConfig struct {
+	ErrorRate     float64 `yaml:"error_rate,omitempty" json:"error_rate" validate:"gte=0,lte=1"`           // fail requests as if the provider has responded with 500
+	TimeoutRate   float64 `yaml:"timeout_rate,omitempty" json:"timeout_rate" validate:"gte=0,lte=1"`       // hang requests for the timeout & fail them
+	RateLimitRate float64 `yaml:"rate_limit_rate,omitempty" json:"rate_limit_rate" validate:"gte=0,lte=1"` // fail requests as if the provider has responded with 429
+	SlowRate      float64 `yaml:"slow_rate,omitempty" json:"slow_rate" validate:"gte=0,lte=1"`             // delay requests by the slow latency before sending them to the provider
+	// Timeout is how long timed out requests hang unless the request context is done earlier
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout" swaggertype:"primitive,integer" validate:"gt=0"`
+	// RateLimitReset is how long injected rate limits last
+	RateLimitReset time.Duration `yaml:"rate_limit_reset,omitempty" json:"rate_limit_reset" swaggertype:"primitive,integer" validate:"gt=0"`
+	// SlowLatency is the latency added to slow requests
+	SlowLatency time.Duration `yaml:"slow_latency,omitempty" json:"slow_latency" swaggertype:"primitive,integer" validate:"gt=0"`
+}
+
+func DefaultChaosConfig() *ChaosConfig {
+	return &ChaosConfig{
+		Timeout:        10 * time.Second,
+		RateLimitReset: 10 * time.Second,
+		SlowLatency:    2 * time.Second,
+	}
+}
+
+func (c *ChaosConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultChaosConfig()
+
+	type plain ChaosConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// chaos rolls the dice on each request & injects the picked fault
+type chaos struct {
+	config *ChaosConfig
+	dice   func() float64
+}
+
+// SetChaos injects faults into model requests (nil disables fault injection)
+func (m *LangModel) SetChaos(cfg *ChaosConfig) {
+	if cfg == nil {
+		m.chaos = nil
+
+		return
+	}
+
+	m.chaos = &chaos{config: cfg, dice: rand.Float64} //nolint:gosec
+}
+
+// inject delays the request or fails it with the picked fault. Nil means the request should go to the provider
+func (c *chaos) inject(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+
+	if c.dice() < c.config.SlowRate {
+		if err := sleep(ctx, c.config.SlowLatency); err != nil {
+			return err
+		}
+	}
+
+	dice := c.dice()
+
+	switch {
+	case dice < c.config.ErrorRate:
+		return fmt.Errorf("%w: %w (status code: 500)", clients.ErrProviderUnavailable, ErrInjectedFault)
+	case dice < c.config.ErrorRate+c.config.TimeoutRate:
+		if err := sleep(ctx, c.config.Timeout); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%w: the provider has not responded in %v", ErrInjectedFault, c.config.Timeout)
+	case dice < c.config.ErrorRate+c.config.TimeoutRate+c.config.RateLimitRate:
+		return fmt.Errorf("%w: %w", ErrInjectedFault, clients.NewRateLimitError(&c.config.RateLimitReset))
+	default:
+		return nil
+	}
+}
+
+// sleep waits for the given time unless the context is done earlier
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,15 +1,89 @@
 package clients
 
-import "time"
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"glide/pkg/config/fields"
+)
 
 type ClientConfig struct {
-	Timeout *time.Duration `yaml:"timeout,omitempty" json:"timeout" swaggertype:"primitive,string"`
+	Timeout     *time.Duration     `yaml:"timeout,omitempty" json:"timeout" swaggertype:"primitive,string"`
+	UserAgent   string             `yaml:"user_agent,omitempty" json:"user_agent"`   // User-Agent of provider requests (defaults to glide/<version>)
+	Attribution *AttributionConfig `yaml:"attribution,omitempty" json:"attribution"` // identifies the app to providers that ask for it (e.g. OpenRouter)
+	HTTPConfig  `yaml:",inline"`
+	// Extra headers & query params sent with every provider request (e.g. tenant IDs or API gateway keys).
+	// Values are secrets, so they are not exposed in logs or via the API
+	ExtraHeaders     map[string]fields.Secret `yaml:"extra_headers,omitempty" json:"extra_headers,omitempty" validate:"omitempty,dive,keys,overridable_header,endkeys"`
+	ExtraQueryParams map[string]fields.Secret `yaml:"extra_query_params,omitempty" json:"extra_query_params,omitempty"`
+	// RetryStatuses are HTTP statuses of transient provider errors retried on the same model up to MaxRetries times
+	// (e.g. Anthropic's 529 Overloaded). An empty list disables retries
+	RetryStatuses []int `yaml:"retry_statuses" json:"retry_statuses" validate:"omitempty,dive,min=400,max=599"`
+	MaxRetries    int   `yaml:"max_retries" json:"max_retries" validate:"gte=0"`
+	// Recording records provider traffic or replays it instead of calling the provider (disabled by default)
+	Recording *RecordingConfig `yaml:"recording,omitempty" json:"recording,omitempty"`
+}
+
+// HTTPConfig tunes the transport of provider requests. Each model keeps its own connection pool,
+// so settings (e.g. the proxy) could differ per model and limits apply per model
+type HTTPConfig struct {
+	ConnectTimeout *time.Duration `yaml:"connect_timeout,omitempty" json:"connect_timeout" swaggertype:"primitive,string" validate:"omitempty,gt=0"` // how long to wait for TCP connections to establish
+	ReadTimeout    *time.Duration `yaml:"read_timeout,omitempty" json:"read_timeout" swaggertype:"primitive,string" validate:"omitempty,gt=0"`       // how long to wait for response headers once the request is sent (the timeout bounds the whole request anyway)
+	// Keep-alive connections to the provider
+	MaxIdleConns        int               `yaml:"max_idle_conns,omitempty" json:"max_idle_conns" validate:"gte=0"`                     // idle connections across all hosts (zero means no limit)
+	MaxIdleConnsPerHost int               `yaml:"max_idle_conns_per_host,omitempty" json:"max_idle_conns_per_host" validate:"gte=0"`   // idle connections to the provider host (zero means 2)
+	IdleConnTimeout     *time.Duration    `yaml:"idle_conn_timeout,omitempty" json:"idle_conn_timeout" swaggertype:"primitive,string"` // how long idle connections are kept open (zero means forever)
+	ConnWarmup          *ConnWarmupConfig `yaml:"conn_warmup,omitempty" json:"conn_warmup,omitempty"`                                  // keep connections to the provider established (disabled by default)
+	TLS                 *TLSConfig        `yaml:"tls,omitempty" json:"tls,omitempty"`
+	// Proxy is the URL of the proxy provider requests go through. By default, the proxy is taken from
+	// HTTP_PROXY, HTTPS_PROXY & NO_PROXY env vars. Set it to "none" to send requests directly regardless of env vars
+	Proxy string `yaml:"proxy,omitempty" json:"proxy,omitempty" validate:"omitempty,url|eq=none"`
+}
+
+// ProxyNone disables proxying of provider requests
+const ProxyNone = "none"
+
+// TLSConfig defines TLS settings of provider connections (e.g. to talk to self-hosted models behind private CAs)
+type TLSConfig struct {
+	MinVersion string `yaml:"min_version,omitempty" json:"min_version" validate:"omitempty,oneof=1.2 1.3"` // the minimal TLS version (1.2 by default)
+	CAFile     string `yaml:"ca_file,omitempty" json:"ca_file,omitempty" validate:"omitempty,file"`        // PEM bundle of CAs trusted in addition to the system ones
+}
+
+// reservedHeaders are set by provider clients themselves (e.g. to authenticate), so they could not be overridden by extra headers
+var reservedHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	"Api-Key",           // Azure OpenAI
+	"X-Api-Key",         // Anthropic
+	"Anthropic-Version", // Anthropic
+}
+
+// IsReservedHeader checks if the header could not be set via extra headers
+func IsReservedHeader(name string) bool {
+	return slices.Contains(reservedHeaders, http.CanonicalHeaderKey(name))
+}
+
+// AttributionConfig defines headers that attribute traffic to the app
+type AttributionConfig struct {
+	Title   string `yaml:"title,omitempty" json:"title"`     // sent as the X-Title header
+	Referer string `yaml:"referer,omitempty" json:"referer"` // sent as the HTTP-Referer header (usually the app URL)
 }
 
 func DefaultClientConfig() *ClientConfig {
 	defaultTimeout := 10 * time.Second
+	defaultIdleConnTimeout := 90 * time.Second
+	defaultConnectTimeout := 5 * time.Second
 
 	return &ClientConfig{
-		Timeout: &defaultTimeout,
+		Timeout:       &defaultTimeout,
+		RetryStatuses: slices.Clone(DefaultRetryStatuses),
+		MaxRetries:    1,
+		HTTPConfig: HTTPConfig{
+			ConnectTimeout:      &defaultConnectTimeout,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 100, // all requests of the model go to the same host
+			IdleConnTimeout:     &defaultIdleConnTimeout,
+		},
 	}
 }
@@ -3,13 +3,36 @@ package clients
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 )
 
-var ErrProviderUnavailable = errors.New("provider is not available")
+var (
+	ErrProviderUnavailable = errors.New("provider is not available")
+	ErrResponseTooLarge    = errors.New("provider response is larger than allowed")
+	ErrStreamInterrupted   = errors.New("chat stream has ended before the response was complete")
+	ErrUnauthorized        = errors.New("provider has rejected the credentials")
+)
+
+// NewProviderError is the error of the failed provider response. Server & client errors make the provider unavailable
+// to keep the gateway resilient, but rejected credentials are told apart, so they could be reported (e.g. on startup)
+func NewProviderError(statusCode int) error {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: %w", ErrProviderUnavailable, ErrUnauthorized)
+	}
+
+	return ErrProviderUnavailable
+}
+
+// DefaultRateLimitCooldown is how long models stay rate limited when the provider doesn't tell when the limit is reset
+const DefaultRateLimitCooldown = 1 * time.Minute
 
 type RateLimitError struct {
 	untilReset time.Duration
+	// specified is true when the cooldown is known rather than the default one
+	specified bool
+	// retryAfter is true when the cooldown is reported by the provider via the Retry-After header
+	retryAfter bool
 }
 
 func (e RateLimitError) Error() string {
@@ -20,14 +43,98 @@ func (e RateLimitError) UntilReset() time.Duration {
 	return e.untilReset
 }
 
-func NewRateLimitError(untilReset *time.Duration) *RateLimitError {
-	defaultResetTime := 1 * time.Minute
+// Specified tells if the cooldown is known rather than the default one
+func (e RateLimitError) Specified() bool {
+	return e.specified
+}
+
+// FromRetryAfter tells if the cooldown is reported by the provider via the Retry-After header
+func (e RateLimitError) FromRetryAfter() bool {
+	return e.retryAfter
+}
 
+func NewRateLimitError(untilReset *time.Duration) *RateLimitError {
 	if untilReset == nil {
-		untilReset = &defaultResetTime
+		return &RateLimitError{
+			untilReset: DefaultRateLimitCooldown,
+		}
 	}
 
 	return &RateLimitError{
 		untilReset: *untilReset,
+		specified:  true,
+	}
+}
+
+// ModelLoadingError is returned when the model is not ready to serve requests yet (e.g. it's being loaded or scaled up from zero).
+// Such requests should be retried once the model is ready
+type ModelLoadingError struct {
+	untilReady time.Duration
+}
+
+func (e ModelLoadingError) Error() string {
+	return fmt.Sprintf("model is loading, please wait %v", e.untilReady)
+}
+
+func (e ModelLoadingError) UntilReady() time.Duration {
+	return e.untilReady
+}
+
+func NewModelLoadingError(untilReady *time.Duration) *ModelLoadingError {
+	defaultReadyTime := 20 * time.Second
+
+	if untilReady == nil {
+		untilReady = &defaultReadyTime
+	}
+
+	return &ModelLoadingError{
+		untilReady: *untilReady,
+	}
+}
+
+// Capabilities that not all providers have
+const (
+	CapabilityStreaming = "streaming"
+	CapabilityTools     = "tools"
+	CapabilityVision    = "vision"
+	CapabilityJSONMode  = "json_mode" // JSON mode & structured output
+	// CapabilityMultipleCompletions is generating several completions per request (n > 1) natively or via model fan-out
+	CapabilityMultipleCompletions = "multiple_completions"
+)
+
+// Capabilities lists all capabilities requests may need
+var Capabilities = []string{
+	CapabilityStreaming,
+	CapabilityTools,
+	CapabilityVision,
+	CapabilityJSONMode,
+	CapabilityMultipleCompletions,
+}
+
+// CapabilityMaxContextTokens is reported when the request doesn't fit the model context.
+// It's a limit rather than a flag, so it's not listed in Capabilities
+const CapabilityMaxContextTokens = "max_context_tokens"
+
+// ErrCapabilityNotSupported is returned when the request needs a capability the provider doesn't have (e.g. tool calling)
+var ErrCapabilityNotSupported = errors.New("capability is not supported by the provider")
+
+// CapabilityError tells which capability the provider is missing. Such requests should go to other models
+type CapabilityError struct {
+	Provider   string
+	Capability string
+}
+
+func (e *CapabilityError) Error() string {
+	return fmt.Sprintf("%v (provider: %v, capability: %v)", ErrCapabilityNotSupported, e.Provider, e.Capability)
+}
+
+func (e *CapabilityError) Unwrap() error {
+	return ErrCapabilityNotSupported
+}
+
+func NewCapabilityError(provider string, capability string) *CapabilityError {
+	return &CapabilityError{
+		Provider:   provider,
+		Capability: capability,
 	}
 }
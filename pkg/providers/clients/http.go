@@ -0,0 +1,221 @@
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"glide/pkg/config/fields"
+)
+
+// DefaultUserAgent is sent with provider requests unless the client config overrides it.
+// The version is filled in at startup
+var DefaultUserAgent = "glide"
+
+// NewHTTPClient creates an HTTP client for provider requests.
+// All provider clients use it, so they identify Glide traffic consistently.
+// Provider clients create it once and reuse it for all requests, so connections to the provider are kept alive
+func NewHTTPClient(cfg *ClientConfig) (*http.Client, error) {
+	headers := make(http.Header, 3)
+
+	headers.Set("User-Agent", DefaultUserAgent)
+
+	if cfg.UserAgent != "" {
+		headers.Set("User-Agent", cfg.UserAgent)
+	}
+
+	if cfg.Attribution != nil {
+		if cfg.Attribution.Title != "" {
+			headers.Set("X-Title", cfg.Attribution.Title)
+		}
+
+		if cfg.Attribution.Referer != "" {
+			headers.Set("HTTP-Referer", cfg.Attribution.Referer)
+		}
+	}
+
+	transport, err := newTransport(&cfg.HTTPConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var clientTransport http.RoundTripper = &headerTransport{
+		headers:          headers,
+		extraHeaders:     cfg.ExtraHeaders,
+		extraQueryParams: cfg.ExtraQueryParams,
+		retry:            newRetryPolicy(cfg.RetryStatuses, cfg.MaxRetries),
+		base:             transport,
+	}
+
+	if cfg.Recording != nil {
+		clientTransport, err = newRecordingTransport(cfg.Recording, clientTransport)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &http.Client{
+		Timeout:   *cfg.Timeout,
+		Transport: clientTransport,
+	}, nil
+}
+
+// newTransport creates a pooling transport that negotiates HTTP/2 with providers that support it
+func newTransport(cfg *HTTPConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = 0
+	// HTTP/2 is negotiated via ALPN, so providers without it are still served over HTTP/1.1
+	transport.ForceAttemptHTTP2 = true
+
+	if cfg.IdleConnTimeout != nil {
+		transport.IdleConnTimeout = *cfg.IdleConnTimeout
+	}
+
+	if cfg.ConnectTimeout != nil {
+		dialer := &net.Dialer{
+			Timeout:   *cfg.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}
+
+		transport.DialContext = dialer.DialContext
+	}
+
+	if cfg.ReadTimeout != nil {
+		transport.ResponseHeaderTimeout = *cfg.ReadTimeout
+	}
+
+	proxy, err := newProxy(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.Proxy = proxy
+
+	transport.TLSClientConfig, err = newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport, nil
+}
+
+// newProxy picks the proxy of provider requests. Env vars are respected unless the proxy is configured explicitly
+func newProxy(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyNone:
+		return nil, nil
+	default:
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+
+		return http.ProxyURL(proxyURL), nil
+	}
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func newTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg == nil {
+		return tlsConfig, nil
+	}
+
+	if cfg.MinVersion != "" {
+		minVersion, found := tlsVersions[cfg.MinVersion]
+		if !found {
+			return nil, fmt.Errorf("unsupported TLS version %q (supported: 1.2, 1.3)", cfg.MinVersion)
+		}
+
+		tlsConfig.MinVersion = minVersion
+	}
+
+	if cfg.CAFile != "" {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		caBundle, err := os.ReadFile(filepath.Clean(cfg.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file %v: %w", cfg.CAFile, err)
+		}
+
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %v", cfg.CAFile)
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	return tlsConfig, nil
+}
+
+// headerTransport adds common headers & configured extra headers and query params to all requests
+// and retries requests that have failed with retryable statuses
+type headerTransport struct {
+	headers http.Header
+	// extra values are read on each request, as secrets they reference could be rotated
+	extraHeaders     map[string]fields.Secret
+	extraQueryParams map[string]fields.Secret
+	retry            *retryPolicy // nil if retries are disabled
+	base             http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// round trippers must not modify the original request
+	req = req.Clone(req.Context())
+
+	for name, values := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = values
+		}
+	}
+
+	// extra headers are configured per model, so they take precedence over the ones set by provider clients
+	// (reserved headers like Authorization are rejected by the config validation)
+	for name, value := range t.extraHeaders {
+		if !IsReservedHeader(name) {
+			req.Header.Set(name, value.Value())
+		}
+	}
+
+	if len(t.extraQueryParams) > 0 {
+		query := req.URL.Query()
+
+		for name, value := range t.extraQueryParams {
+			query.Set(name, value.Value())
+		}
+
+		req.URL.RawQuery = query.Encode()
+	}
+
+	resp, err := t.retry.roundTrip(t.base, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := MaxResponseSize(req.Context()); limit > 0 {
+		resp.Body = newLimitedBody(resp.Body, limit)
+	}
+
+	return resp, nil
+}
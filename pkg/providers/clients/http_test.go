@@ -0,0 +1,353 @@
+package clients
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"glide/pkg/config/fields"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient_SetsCommonHeaders(t *testing.T) {
+	var headers http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		cfg       func(cfg *ClientConfig)
+		userAgent string
+		title     string
+		referer   string
+	}{
+		{"defaults", func(_ *ClientConfig) {}, DefaultUserAgent, "", ""},
+		{"custom user agent", func(cfg *ClientConfig) { cfg.UserAgent = "acme/1.0" }, "acme/1.0", "", ""},
+		{
+			"attribution",
+			func(cfg *ClientConfig) {
+				cfg.Attribution = &AttributionConfig{Title: "Acme Chat", Referer: "https://acme.example.com"}
+			},
+			DefaultUserAgent,
+			"Acme Chat",
+			"https://acme.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultClientConfig()
+			tt.cfg(cfg)
+
+			client, err := NewHTTPClient(cfg)
+			require.NoError(t, err)
+
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			require.Equal(t, tt.userAgent, headers.Get("User-Agent"))
+			require.Equal(t, tt.title, headers.Get("X-Title"))
+			require.Equal(t, tt.referer, headers.Get("HTTP-Referer"))
+		})
+	}
+}
+
+func TestHTTPClient_SetsExtraHeadersAndQueryParams(t *testing.T) {
+	var request *http.Request
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		request = r
+	}))
+	defer server.Close()
+
+	cfg := DefaultClientConfig()
+	cfg.ExtraHeaders = map[string]fields.Secret{
+		"OpenAI-Organization": "org-acme",
+		"X-Tenant-ID":         "acme",
+	}
+	cfg.ExtraQueryParams = map[string]fields.Secret{
+		"api-version": "2024-02-01",
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/chat?stream=false", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("X-Tenant-ID", "default")
+
+	client, err := NewHTTPClient(cfg)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Equal(t, "org-acme", request.Header.Get("OpenAI-Organization"))
+	require.Equal(t, "acme", request.Header.Get("X-Tenant-ID"))
+	require.Equal(t, "2024-02-01", request.URL.Query().Get("api-version"))
+	require.Equal(t, "false", request.URL.Query().Get("stream"))
+
+	// the original request is not modified
+	require.Equal(t, "default", req.Header.Get("X-Tenant-ID"))
+	require.Empty(t, req.URL.Query().Get("api-version"))
+}
+
+func TestIsReservedHeader(t *testing.T) {
+	require.True(t, IsReservedHeader("authorization"))
+	require.True(t, IsReservedHeader("Content-Type"))
+	require.True(t, IsReservedHeader("x-api-key"))
+	require.False(t, IsReservedHeader("OpenAI-Organization"))
+}
+
+func TestHTTPClient_ReusesConnections(t *testing.T) {
+	server, newConns := newConnCountingServer()
+	defer server.Close()
+
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	require.Equal(t, int64(1), newConns.Load())
+}
+
+func TestHTTPClient_NegotiatesHTTP2(t *testing.T) {
+	var protoMajor int
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		protoMajor = r.ProtoMajor
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+
+	defer server.Close()
+
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(t, err)
+	// trust the test server certificate
+	client.Transport.(*headerTransport).base.(*http.Transport).TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Equal(t, 2, protoMajor)
+}
+
+func TestHTTPClient_TrustsCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	require.NoError(t, os.WriteFile(caFile, caBundle, 0o600))
+
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(t, err)
+
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+
+	cfg := DefaultClientConfig()
+	cfg.TLS = &TLSConfig{MinVersion: "1.2", CAFile: caFile}
+
+	client, err = NewHTTPClient(cfg)
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	cfg.TLS = &TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}
+
+	_, err = NewHTTPClient(cfg)
+	require.ErrorContains(t, err, "unable to read CA file")
+}
+
+func TestHTTPClient_GoesThroughProxy(t *testing.T) {
+	var proxiedHost string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+	}))
+	defer proxy.Close()
+
+	cfg := DefaultClientConfig()
+	cfg.Proxy = proxy.URL
+
+	client, err := NewHTTPClient(cfg)
+	require.NoError(t, err)
+
+	resp, err := client.Get("http://provider.example.com/v1/chat")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Equal(t, "provider.example.com", proxiedHost)
+
+	cfg.Proxy = ProxyNone
+
+	client, err = NewHTTPClient(cfg)
+	require.NoError(t, err)
+	require.Nil(t, client.Transport.(*headerTransport).base.(*http.Transport).Proxy)
+}
+
+func TestHTTPClient_ReadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	readTimeout := 50 * time.Millisecond
+
+	cfg := DefaultClientConfig()
+	cfg.ReadTimeout = &readTimeout
+
+	client, err := NewHTTPClient(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Get(server.URL)
+	require.ErrorContains(t, err, "timeout awaiting response headers")
+}
+
+func TestHTTPClient_LimitsResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(t, err)
+
+	read := func(limit int64) ([]byte, error) {
+		req, err := http.NewRequestWithContext(WithMaxResponseSize(context.Background(), limit), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		return io.ReadAll(resp.Body)
+	}
+
+	body, err := read(10)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(body))
+
+	body, err = read(0)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(body))
+
+	body, err = read(4)
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+	require.Equal(t, "0123", string(body))
+}
+
+func BenchmarkHTTPClient_ConcurrentRequests(b *testing.B) {
+	server, newConns := newConnCountingServer()
+	defer server.Close()
+
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(b, err)
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+	})
+
+	b.ReportMetric(float64(newConns.Load()), "conns")
+}
+
+// newConnCountingServer starts a test server that counts connections opened to it
+func newConnCountingServer() (*httptest.Server, *atomic.Int64) {
+	var newConns atomic.Int64
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+
+	server.Start()
+
+	return server, &newConns
+}
+
+func TestHTTPClient_RetriesConfiguredStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		requests int32
+	}{
+		{"overloaded", StatusOverloaded, 2},
+		{"bad request", http.StatusBadRequest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"model": "claude"}`, string(body))
+
+				if requests.Add(1) == 1 {
+					w.WriteHeader(tt.status)
+
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			cfg := DefaultClientConfig()
+			cfg.RetryStatuses = []int{http.StatusTooManyRequests, http.StatusInternalServerError, StatusOverloaded}
+
+			client, err := NewHTTPClient(cfg)
+			require.NoError(t, err)
+
+			resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"model": "claude"}`))
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			require.Equal(t, tt.requests, requests.Load())
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package clients
+
+import (
+	"context"
+	"io"
+)
+
+type maxResponseSizeKey struct{}
+
+// WithMaxResponseSize bounds the size of provider responses read in scope of the context,
+// so a misbehaving provider could not make the gateway buffer huge responses. Zero size means no limit
+func WithMaxResponseSize(ctx context.Context, size int64) context.Context {
+	if size <= 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, maxResponseSizeKey{}, size)
+}
+
+// MaxResponseSize returns the response size limit set by WithMaxResponseSize (zero if there is no limit)
+func MaxResponseSize(ctx context.Context) int64 {
+	size, _ := ctx.Value(maxResponseSizeKey{}).(int64)
+
+	return size
+}
+
+// limitedBody fails reads once the response body gets bigger than the limit
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func newLimitedBody(body io.ReadCloser, limit int64) *limitedBody {
+	return &limitedBody{ReadCloser: body, remaining: limit}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	// one byte more than the limit is read to tell responses of the exact limit size from bigger ones
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrResponseTooLarge
+	}
+
+	return n, err
+}
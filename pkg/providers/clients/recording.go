@@ -0,0 +1,387 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Recording modes
+const (
+	RecordingModeRecord = "record"
+	RecordingModeReplay = "replay"
+)
+
+// Actions on requests without recordings in the replay mode
+const (
+	RecordingMissFail        = "fail"
+	RecordingMissPassthrough = "passthrough"
+)
+
+var ErrRecordingNotFound = errors.New("no recorded response matches the provider request")
+
+// RecordingConfig defines how provider traffic is recorded & replayed, so integration tests could run against realistic
+// provider payloads without calling providers. Requests are matched by the hash of their method, path & normalized body
+type RecordingConfig struct {
+	Mode string `yaml:"mode" json:"mode" validate:"oneof=record replay"`
+	Dir  string `yaml:"dir" json:"dir" validate:"required"` // where request/response pairs are kept as JSON files
+	// OnMiss is what happens to requests without recordings in the replay mode: fail them or send them to the provider
+	OnMiss string `yaml:"on_miss,omitempty" json:"on_miss" validate:"oneof=fail passthrough"`
+	// IgnoreParams are request body params that don't affect matching (e.g. "user" or "metadata.session_id")
+	IgnoreParams []string `yaml:"ignore_params,omitempty" json:"ignore_params,omitempty"`
+}
+
+func DefaultRecordingConfig() *RecordingConfig {
+	return &RecordingConfig{
+		Mode:   RecordingModeReplay,
+		OnMiss: RecordingMissFail,
+	}
+}
+
+func (c *RecordingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultRecordingConfig()
+
+	type plain RecordingConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// sensitiveHeaders carry credentials, so they are never persisted
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// sensitiveQueryParams carry credentials of providers that accept them in URLs
+var sensitiveQueryParams = []string{"key", "api_key", "api-key", "token", "access_token"}
+
+const redacted = "REDACTED"
+
+// Recording is a provider request & the response it got
+type Recording struct {
+	Hash     string           `json:"hash"`
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	// BodyText keeps bodies that are not JSON
+	BodyText string `json:"body_text,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int             `json:"status_code"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	// BodyText keeps bodies that are not JSON (e.g. event streams)
+	BodyText string `json:"body_text,omitempty"`
+}
+
+// recordingTransport persists provider traffic in the record mode and serves it back in the replay mode.
+// It wraps the whole client transport, so extra headers & query params of the client config never get recorded
+type recordingTransport struct {
+	config *RecordingConfig
+	base   http.RoundTripper
+}
+
+func newRecordingTransport(cfg *RecordingConfig, base http.RoundTripper) (*recordingTransport, error) {
+	if cfg.Mode == RecordingModeRecord {
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil { //nolint:gosec
+			return nil, fmt.Errorf("unable to create recording dir %v: %w", cfg.Dir, err)
+		}
+	}
+
+	return &recordingTransport{config: cfg, base: base}, nil
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		// round trippers must not modify the original request
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+
+	hash := RequestHash(req.Method, req.URL.Path, body, t.config.IgnoreParams)
+
+	if t.config.Mode == RecordingModeRecord {
+		return t.record(req, body, hash)
+	}
+
+	return t.replay(req, hash)
+}
+
+func (t *recordingTransport) record(req *http.Request, body []byte, hash string) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method == http.MethodHead {
+		// connection warmups are not worth recording
+		return resp, err
+	}
+
+	recording := &Recording{
+		Hash:    hash,
+		Request: newRecordedRequest(req, body),
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeaders(resp.Header),
+		},
+	}
+
+	// the response is saved once it's read in full, so streams are still passed to the client as they go
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		save: func(respBody []byte) {
+			recording.Response.Body, recording.Response.BodyText = recordedBody(respBody)
+
+			_ = t.save(recording)
+		},
+	}
+
+	return resp, nil
+}
+
+func (t *recordingTransport) replay(req *http.Request, hash string) (*http.Response, error) {
+	recording, err := t.load(hash)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if t.config.OnMiss == RecordingMissPassthrough {
+				return t.base.RoundTrip(req)
+			}
+
+			return nil, fmt.Errorf("%w: %v %v (hash: %v)", ErrRecordingNotFound, req.Method, req.URL.Path, hash)
+		}
+
+		return nil, err
+	}
+
+	respBody := []byte(recording.Response.BodyText)
+
+	if len(recording.Response.Body) > 0 {
+		respBody = recording.Response.Body
+	}
+
+	header := recording.Response.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recording.Response.StatusCode, http.StatusText(recording.Response.StatusCode)),
+		StatusCode:    recording.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+func (t *recordingTransport) path(hash string) string {
+	return filepath.Join(t.config.Dir, hash+".json")
+}
+
+func (t *recordingTransport) load(hash string) (*Recording, error) {
+	rawRecording, err := os.ReadFile(t.path(hash))
+	if err != nil {
+		return nil, err
+	}
+
+	var recording Recording
+
+	if err := json.Unmarshal(rawRecording, &recording); err != nil {
+		return nil, fmt.Errorf("invalid recording %v: %w", t.path(hash), err)
+	}
+
+	return &recording, nil
+}
+
+// save writes the recording via a temp file, so concurrent replays never read partially written recordings
+func (t *recordingTransport) save(recording *Recording) error {
+	rawRecording, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(t.config.Dir, recording.Hash+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmpFile.Write(rawRecording)
+
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), t.path(recording.Hash))
+}
+
+// RequestHash identifies provider requests regardless of hosts (e.g. test servers), key order & ignored body params
+func RequestHash(method string, path string, body []byte, ignoreParams []string) string {
+	hash := sha256.New()
+
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(normalizeBody(body, ignoreParams))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// normalizeBody drops ignored params from JSON bodies & re-encodes them with sorted keys.
+// Other bodies are used as is
+func normalizeBody(body []byte, ignoreParams []string) []byte {
+	var payload any
+
+	if len(body) == 0 || json.Unmarshal(body, &payload) != nil {
+		return body
+	}
+
+	for _, param := range ignoreParams {
+		deleteParam(payload, strings.Split(param, "."))
+	}
+
+	normalized, err := json.Marshal(payload)
+	if err != nil {
+		return body
+	}
+
+	return normalized
+}
+
+// deleteParam removes the param by its dotted path from the decoded JSON object
+func deleteParam(payload any, path []string) {
+	object, ok := payload.(map[string]any)
+	if !ok {
+		return
+	}
+
+	if len(path) == 1 {
+		delete(object, path[0])
+
+		return
+	}
+
+	deleteParam(object[path[0]], path[1:])
+}
+
+func newRecordedRequest(req *http.Request, body []byte) RecordedRequest {
+	recordedURL := *req.URL
+	query := recordedURL.Query()
+
+	for _, param := range sensitiveQueryParams {
+		if query.Has(param) {
+			query.Set(param, redacted)
+		}
+	}
+
+	recordedURL.RawQuery = query.Encode()
+	recordedURL.User = nil
+
+	request := RecordedRequest{
+		Method: req.Method,
+		URL:    recordedURL.String(),
+		Header: redactHeaders(req.Header),
+	}
+
+	request.Body, request.BodyText = recordedBody(body)
+
+	return request
+}
+
+func redactHeaders(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+
+	for _, name := range sensitiveHeaders {
+		if redactedHeader.Get(name) != "" {
+			redactedHeader.Set(name, redacted)
+		}
+	}
+
+	return redactedHeader
+}
+
+// recordedBody keeps JSON bodies as they are, so recordings are easy to read & edit
+func recordedBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+
+	if json.Valid(body) {
+		return body, ""
+	}
+
+	return nil, string(body)
+}
+
+// recordingBody tees the response body & saves the recording once the body is read to the end.
+// Bodies that fail to be read (e.g. canceled streams) are not recorded
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	save func(body []byte)
+	done bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+
+	if errors.Is(err, io.EOF) {
+		b.finish()
+	}
+
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	// clients may stop reading right after the JSON payload, so the rest of the body is read before it's saved
+	if !b.done {
+		if _, err := io.Copy(&b.buf, b.ReadCloser); err == nil {
+			b.finish()
+		}
+	}
+
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	if b.done {
+		return
+	}
+
+	b.done = true
+	b.save(b.buf.Bytes())
+}
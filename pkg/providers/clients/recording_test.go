@@ -0,0 +1,169 @@
+package clients
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func newRecordingClient(t *testing.T, recordingCfg *RecordingConfig) *http.Client {
+	t.Helper()
+
+	cfg := DefaultClientConfig()
+	cfg.Recording = recordingCfg
+
+	client, err := NewHTTPClient(cfg)
+	require.NoError(t, err)
+
+	return client
+}
+
+func postJSON(t *testing.T, client *http.Client, url string, body string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	return resp, string(respBody)
+}
+
+func TestRecording_RecordAndReplay(t *testing.T) {
+	var providerRequests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		providerRequests.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"content":"Blue whale"}}]}`))
+	}))
+
+	recordingDir := filepath.Join(t.TempDir(), "recordings")
+
+	recorder := newRecordingClient(t, &RecordingConfig{
+		Mode:         RecordingModeRecord,
+		Dir:          recordingDir,
+		IgnoreParams: []string{"user"},
+	})
+
+	resp, body := postJSON(t, recorder, server.URL+"/v1/chat/completions?api_key=sk-secret", `{"model":"gpt-4o","user":"u1"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"id":"chatcmpl-1","choices":[{"message":{"content":"Blue whale"}}]}`, body)
+
+	recordings, err := os.ReadDir(recordingDir)
+	require.NoError(t, err)
+	require.Len(t, recordings, 1)
+
+	rawRecording, err := os.ReadFile(filepath.Join(recordingDir, recordings[0].Name()))
+	require.NoError(t, err)
+	require.NotContains(t, string(rawRecording), "sk-secret")
+	require.NotContains(t, string(rawRecording), "session=secret")
+
+	// recordings are served without calling the provider
+	server.Close()
+
+	replayer := newRecordingClient(t, &RecordingConfig{
+		Mode:         RecordingModeReplay,
+		Dir:          recordingDir,
+		OnMiss:       RecordingMissFail,
+		IgnoreParams: []string{"user"},
+	})
+
+	// ignored params & the key order don't affect matching
+	resp, body = postJSON(t, replayer, "http://provider.test/v1/chat/completions", `{"user":"u2","model":"gpt-4o"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.JSONEq(t, `{"id":"chatcmpl-1","choices":[{"message":{"content":"Blue whale"}}]}`, body)
+
+	// other params do
+	resp, errMsg := postJSON(t, replayer, "http://provider.test/v1/chat/completions", `{"model":"gpt-4o-mini","user":"u1"}`)
+	require.Nil(t, resp)
+	require.Contains(t, errMsg, ErrRecordingNotFound.Error())
+
+	require.Equal(t, int32(1), providerRequests.Load())
+}
+
+func TestRecording_ReplayPassesMissesThrough(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	replayer := newRecordingClient(t, &RecordingConfig{
+		Mode:   RecordingModeReplay,
+		Dir:    t.TempDir(),
+		OnMiss: RecordingMissPassthrough,
+	})
+
+	resp, body := postJSON(t, replayer, server.URL+"/v1/chat/completions", `{"model":"gpt-4o","stream":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "data: [DONE]\n\n", body)
+}
+
+func TestRecording_RecordsNonJSONBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("data: {\"error\":\"slow down\"}\n\n"))
+	}))
+
+	recordingDir := t.TempDir()
+
+	recorder := newRecordingClient(t, &RecordingConfig{Mode: RecordingModeRecord, Dir: recordingDir})
+
+	_, _ = postJSON(t, recorder, server.URL+"/v1/chat/completions", `{"model":"gpt-4o","stream":true}`)
+
+	server.Close()
+
+	replayer := newRecordingClient(t, &RecordingConfig{Mode: RecordingModeReplay, Dir: recordingDir, OnMiss: RecordingMissFail})
+
+	resp, body := postJSON(t, replayer, server.URL+"/v1/chat/completions", `{"model":"gpt-4o","stream":true}`)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "data: {\"error\":\"slow down\"}\n\n", body)
+}
+
+func TestRequestHash_IgnoresNestedParams(t *testing.T) {
+	ignoreParams := []string{"metadata.session_id"}
+
+	require.Equal(
+		t,
+		RequestHash(http.MethodPost, "/v1/messages", []byte(`{"metadata":{"session_id":"s1","tenant":"acme"}}`), ignoreParams),
+		RequestHash(http.MethodPost, "/v1/messages", []byte(`{"metadata":{"tenant":"acme","session_id":"s2"}}`), ignoreParams),
+	)
+
+	require.NotEqual(
+		t,
+		RequestHash(http.MethodPost, "/v1/messages", []byte(`{"metadata":{"tenant":"acme"}}`), ignoreParams),
+		RequestHash(http.MethodPost, "/v1/messages", []byte(`{"metadata":{"tenant":"globex"}}`), ignoreParams),
+	)
+}
+
+func TestRecordingConfig_Defaults(t *testing.T) {
+	var cfg RecordingConfig
+
+	require.NoError(t, yaml.Unmarshal([]byte("dir: ./recordings\n"), &cfg))
+
+	require.Equal(t, RecordingModeReplay, cfg.Mode)
+	require.Equal(t, RecordingMissFail, cfg.OnMiss)
+}
@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// StatusOverloaded is returned by Anthropic when its API is temporarily overloaded
+const StatusOverloaded = 529
+
+// DefaultRetryStatuses are transient provider errors worth retrying on the same model.
+// Rate limits (429) are not retried by default, as the model is marked rate limited & requests fall back to other models instead
+var DefaultRetryStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+	StatusOverloaded,
+}
+
+// retryBackoff is the delay before the first retry. It doubles with each next retry
+const retryBackoff = 50 * time.Millisecond
+
+// retryPolicy resends requests the provider has failed with retryable statuses
+type retryPolicy struct {
+	statuses   []int
+	maxRetries int
+}
+
+// newRetryPolicy returns nil if retries are disabled
+func newRetryPolicy(statuses []int, maxRetries int) *retryPolicy {
+	if maxRetries <= 0 || len(statuses) == 0 {
+		return nil
+	}
+
+	return &retryPolicy{
+		statuses:   statuses,
+		maxRetries: maxRetries,
+	}
+}
+
+// roundTrip sends the request via the transport until it succeeds, fails with a non-retryable status or runs out of retries
+func (p *retryPolicy) roundTrip(transport http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if p == nil {
+		return transport.RoundTrip(req)
+	}
+
+	backoff := retryBackoff
+
+	for attempt := 0; ; attempt++ {
+		resp, err := transport.RoundTrip(req)
+		if err != nil || attempt >= p.maxRetries || !slices.Contains(p.statuses, resp.StatusCode) {
+			return resp, err
+		}
+
+		// requests with bodies could be resent only if the body could be read again
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		// the response is dropped, so its connection could be reused by the retry
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+
+			return nil, req.Context().Err()
+		}
+
+		backoff *= 2
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
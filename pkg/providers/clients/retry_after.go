@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseRetryAfter parses the Retry-After header value, which is either a number of seconds or an HTTP-date.
+// Dates in the past mean the request could be retried right away
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	retryAt, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(retryAt.Sub(now), 0), true
+}
+
+// NewRetryAfterError creates the rate limit error of a 429 response.
+// The cooldown is taken from the Retry-After header if the provider has sent a valid one
+func NewRetryAfterError(header http.Header) *RateLimitError {
+	if untilReset, ok := ParseRetryAfter(header.Get("Retry-After"), time.Now()); ok {
+		err := NewRateLimitError(&untilReset)
+		err.retryAfter = true
+
+		return err
+	}
+
+	return NewRateLimitError(nil)
+}
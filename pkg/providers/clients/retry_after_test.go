@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter_Seconds(t *testing.T) {
+	now := time.Now()
+
+	untilReset, ok := ParseRetryAfter("30", now)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, untilReset)
+
+	untilReset, ok = ParseRetryAfter(" 0 ", now)
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), untilReset)
+
+	_, ok = ParseRetryAfter("-5", now)
+	require.False(t, ok)
+}
+
+func TestParseRetryAfter_HTTPDate(t *testing.T) {
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	untilReset, ok := ParseRetryAfter("Fri, 10 May 2024 12:01:30 GMT", now)
+	require.True(t, ok)
+	require.Equal(t, 90*time.Second, untilReset)
+
+	// dates in the past allow retrying right away
+	untilReset, ok = ParseRetryAfter("Fri, 10 May 2024 11:59:00 GMT", now)
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), untilReset)
+}
+
+func TestParseRetryAfter_Invalid(t *testing.T) {
+	for _, value := range []string{"", "soon", "10s", "1.5"} {
+		_, ok := ParseRetryAfter(value, time.Now())
+		require.False(t, ok, value)
+	}
+}
+
+func TestNewRetryAfterError(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "20")
+
+	err := NewRetryAfterError(header)
+	require.Equal(t, 20*time.Second, err.UntilReset())
+	require.True(t, err.Specified())
+	require.True(t, err.FromRetryAfter())
+
+	header.Set("Retry-After", time.Now().Add(2*time.Minute).UTC().Format(http.TimeFormat))
+
+	err = NewRetryAfterError(header)
+	require.InDelta(t, 2*time.Minute, err.UntilReset(), float64(2*time.Second))
+	require.True(t, err.FromRetryAfter())
+
+	// the default cooldown is used if the header is missing or malformed
+	header.Set("Retry-After", "soon")
+
+	err = NewRetryAfterError(header)
+	require.Equal(t, DefaultRateLimitCooldown, err.UntilReset())
+	require.False(t, err.Specified())
+	require.False(t, err.FromRetryAfter())
+}
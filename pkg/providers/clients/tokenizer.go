@@ -0,0 +1,127 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// BPE ranks are embedded into the binary, so they are not downloaded on the first use
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// Tokenizer counts tokens the way the model does (or approximates it)
+type Tokenizer interface {
+	Name() string
+	CountTokens(text string) int
+}
+
+// HeuristicTokenizer approximates token counts for models which tokenizers are unknown
+var HeuristicTokenizer Tokenizer = heuristicTokenizer{}
+
+type heuristicTokenizer struct{}
+
+func (heuristicTokenizer) Name() string {
+	return "heuristic"
+}
+
+func (heuristicTokenizer) CountTokens(text string) int {
+	return int(EstimateTokens(text))
+}
+
+// ErrUnknownTokenizer is returned when the configured tokenizer is neither the heuristic one nor a tiktoken encoding
+var ErrUnknownTokenizer = errors.New("unknown tokenizer")
+
+// tiktokenPrefix is the prefix of tiktoken tokenizer names (e.g. tiktoken/cl100k_base)
+const tiktokenPrefix = "tiktoken/"
+
+// NewTokenizer picks the tokenizer by its name: "heuristic" or a tiktoken encoding like cl100k_base (optionally prefixed with "tiktoken/")
+func NewTokenizer(name string) (Tokenizer, error) {
+	if name == HeuristicTokenizer.Name() {
+		return HeuristicTokenizer, nil
+	}
+
+	encoding := strings.TrimPrefix(name, tiktokenPrefix)
+
+	if _, err := loadEncoding(encoding); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownTokenizer, name)
+	}
+
+	return &TiktokenTokenizer{encoding: encoding}, nil
+}
+
+// defaultEncoding is used for OpenAI-family models that tiktoken doesn't know (e.g. Azure deployments)
+const defaultEncoding = tiktoken.MODEL_CL100K_BASE
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = make(map[string]*tiktoken.Tiktoken)
+)
+
+// TiktokenTokenizer counts tokens of OpenAI-family models.
+// The encoding is loaded on the first use and shared by all tokenizers of the same encoding
+type TiktokenTokenizer struct {
+	encoding string
+}
+
+// NewTiktokenTokenizer picks the tiktoken encoding of the model
+func NewTiktokenTokenizer(model string) *TiktokenTokenizer {
+	return &TiktokenTokenizer{
+		encoding: encodingForModel(model),
+	}
+}
+
+func (t *TiktokenTokenizer) Name() string {
+	return tiktokenPrefix + t.encoding
+}
+
+func (t *TiktokenTokenizer) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	encoding, err := loadEncoding(t.encoding)
+	if err != nil {
+		// should not happen as encodings are embedded
+		return HeuristicTokenizer.CountTokens(text)
+	}
+
+	return len(encoding.EncodeOrdinary(text))
+}
+
+func encodingForModel(model string) string {
+	if encoding, found := tiktoken.MODEL_TO_ENCODING[model]; found {
+		return encoding
+	}
+
+	for prefix, encoding := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) {
+			return encoding
+		}
+	}
+
+	return defaultEncoding
+}
+
+func loadEncoding(name string) (*tiktoken.Tiktoken, error) {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if encoding, found := encodings[name]; found {
+		return encoding, nil
+	}
+
+	encoding, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+
+	encodings[name] = encoding
+
+	return encoding, nil
+}
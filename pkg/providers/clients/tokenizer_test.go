@@ -0,0 +1,39 @@
+package clients
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTiktokenTokenizer_CountTokens(t *testing.T) {
+	tokenizer := NewTiktokenTokenizer("gpt-3.5-turbo")
+
+	require.Equal(t, "tiktoken/cl100k_base", tokenizer.Name())
+	require.Equal(t, 0, tokenizer.CountTokens(""))
+	require.Equal(t, 10, tokenizer.CountTokens("The blue whale is the biggest animal on the Earth"))
+}
+
+func TestTiktokenTokenizer_Encodings(t *testing.T) {
+	require.Equal(t, "tiktoken/o200k_base", NewTiktokenTokenizer("gpt-4o-2024-05-13").Name())
+	require.Equal(t, "tiktoken/cl100k_base", NewTiktokenTokenizer("gpt-4-turbo").Name())
+	// e.g. Azure deployment names
+	require.Equal(t, "tiktoken/cl100k_base", NewTiktokenTokenizer("glide-gpt-35").Name())
+}
+
+func TestNewTokenizer(t *testing.T) {
+	tokenizer, err := NewTokenizer("heuristic")
+	require.NoError(t, err)
+	require.Equal(t, HeuristicTokenizer, tokenizer)
+
+	tokenizer, err = NewTokenizer("o200k_base")
+	require.NoError(t, err)
+	require.Equal(t, "tiktoken/o200k_base", tokenizer.Name())
+
+	tokenizer, err = NewTokenizer("tiktoken/cl100k_base")
+	require.NoError(t, err)
+	require.Equal(t, "tiktoken/cl100k_base", tokenizer.Name())
+
+	_, err = NewTokenizer("llama3")
+	require.ErrorIs(t, err, ErrUnknownTokenizer)
+}
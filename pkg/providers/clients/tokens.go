@@ -0,0 +1,26 @@
+package clients
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken is a rule-of-thumb ratio of characters to tokens for English texts with BPE tokenizers
+const charsPerToken = 4.0
+
+// wordsPerToken is a rule-of-thumb ratio of words to tokens for English texts with BPE tokenizers
+const wordsPerToken = 0.75
+
+// EstimateTokens approximates the number of tokens in the text.
+// It's used for providers that don't report token usage in their responses
+func EstimateTokens(text string) float64 {
+	if text == "" {
+		return 0
+	}
+
+	byChars := float64(utf8.RuneCountInString(text)) / charsPerToken
+	byWords := float64(len(strings.Fields(text))) / wordsPerToken
+
+	return math.Ceil(math.Max(byChars, byWords))
+}
@@ -0,0 +1,13 @@
+package clients
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	require.InDelta(t, 0.0, EstimateTokens(""), 0.0001)
+	require.InDelta(t, 2.0, EstimateTokens("Hello"), 0.0001)
+	require.InDelta(t, 14.0, EstimateTokens("The blue whale is the biggest animal on the Earth"), 0.0001)
+}
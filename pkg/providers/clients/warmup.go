@@ -0,0 +1,75 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ConnWarmupConfig defines how many connections to the provider are kept established, so requests
+// don't pay for TCP & TLS handshakes after idle periods
+type ConnWarmupConfig struct {
+	// Connections is the number of connections opened at once (HTTP/2 providers multiplex requests over one connection anyway)
+	Connections int `yaml:"connections,omitempty" json:"connections" validate:"min=1"`
+	// Interval is how often connections are re-established. It should be shorter than the idle connection timeout
+	// of both the client & the provider, so connections are not closed in between
+	Interval time.Duration `yaml:"interval,omitempty" json:"interval" swaggertype:"primitive,integer" validate:"gt=0"`
+}
+
+func DefaultConnWarmupConfig() *ConnWarmupConfig {
+	return &ConnWarmupConfig{
+		Connections: 2,
+		Interval:    30 * time.Second,
+	}
+}
+
+func (c *ConnWarmupConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConnWarmupConfig()
+
+	type plain ConnWarmupConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// WarmupConnections sends HEAD requests to the provider URL at once, so that many connections are opened
+// and kept idle in the client pool. The response status doesn't matter, only the connection does
+func WarmupConnections(ctx context.Context, httpClient *http.Client, targetURL string, connections int) error {
+	var wg sync.WaitGroup
+
+	errs := make([]error, connections)
+
+	for idx := 0; idx < connections; idx++ {
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			errs[idx] = headRequest(ctx, httpClient, targetURL)
+		}(idx)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func headRequest(ctx context.Context, httpClient *http.Client, targetURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, targetURL, nil)
+	if err != nil {
+		return fmt.Errorf("unable to create warmup request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send warmup request: %w", err)
+	}
+
+	// the body has to be drained, so the connection is returned to the pool
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.Body.Close()
+}
@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmupConnections_OpensIdleConnections(t *testing.T) {
+	server, newConns := newConnCountingServer()
+	defer server.Close()
+
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(t, err)
+
+	require.NoError(t, WarmupConnections(context.Background(), client, server.URL+"/v1/chat/completions", 3))
+	require.Equal(t, int64(3), newConns.Load())
+
+	// requests reuse the warm connections
+	var wg sync.WaitGroup
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				return
+			}
+
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}()
+	}
+
+	wg.Wait()
+
+	require.LessOrEqual(t, newConns.Load(), int64(3))
+}
+
+func TestWarmupConnections_ReportsFailures(t *testing.T) {
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(t, err)
+
+	err = WarmupConnections(context.Background(), client, "http://127.0.0.1:1", 2)
+	require.Error(t, err)
+}
+
+func TestWarmupConnections_MethodIsHead(t *testing.T) {
+	var method string
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		method = r.Method
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(DefaultClientConfig())
+	require.NoError(t, err)
+
+	require.NoError(t, WarmupConnections(context.Background(), client, server.URL, 1))
+	require.Equal(t, http.MethodHead, method)
+}
@@ -0,0 +1,280 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"glide/pkg/providers/clients"
+
+	"glide/pkg/api/schemas"
+	"go.uber.org/zap"
+)
+
+// ChatMessage is a message of the Workers AI chat request
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is a Workers AI-specific text generation request schema
+type ChatRequest struct {
+	Messages          []ChatMessage `json:"messages"`
+	Temperature       float64       `json:"temperature,omitempty"`
+	TopP              float64       `json:"top_p,omitempty"`
+	TopK              int           `json:"top_k,omitempty"`
+	MaxTokens         int           `json:"max_tokens,omitempty"`
+	RepetitionPenalty float64       `json:"repetition_penalty,omitempty"`
+	FrequencyPenalty  float64       `json:"frequency_penalty,omitempty"`
+	PresencePenalty   float64       `json:"presence_penalty,omitempty"`
+	Seed              *int          `json:"seed,omitempty"`
+}
+
+// APIResponse is the envelope Cloudflare API wraps all results & errors in
+type APIResponse struct {
+	Result   *ChatResult `json:"result"`
+	Success  bool        `json:"success"`
+	Errors   []APIError  `json:"errors"`
+	Messages []APIError  `json:"messages"`
+}
+
+// ChatResult is the text generation result of Workers AI models
+type ChatResult struct {
+	Response string `json:"response"`
+	Usage    *Usage `json:"usage,omitempty"`
+}
+
+// Usage is returned by some of Workers AI models only
+type Usage struct {
+	PromptTokens     float64 `json:"prompt_tokens"`
+	CompletionTokens float64 `json:"completion_tokens"`
+	TotalTokens      float64 `json:"total_tokens"`
+}
+
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorMessage joins errors of the envelope into one message
+func (r *APIResponse) errorMessage() string {
+	messages := make([]string, 0, len(r.Errors))
+
+	for _, apiErr := range r.Errors {
+		messages = append(messages, fmt.Sprintf("%v (code: %v)", apiErr.Message, apiErr.Code))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// NewChatRequestFromConfig fills the struct from the config. Not using reflection because of performance penalty it gives
+func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
+	return &ChatRequest{
+		Temperature:       cfg.DefaultParams.Temperature,
+		TopP:              cfg.DefaultParams.TopP,
+		TopK:              cfg.DefaultParams.TopK,
+		MaxTokens:         cfg.DefaultParams.MaxTokens,
+		RepetitionPenalty: cfg.DefaultParams.RepetitionPenalty,
+		FrequencyPenalty:  cfg.DefaultParams.FrequencyPenalty,
+		PresencePenalty:   cfg.DefaultParams.PresencePenalty,
+	}
+}
+
+// Chat sends a chat request to the specified Workers AI model.
+func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if request.HasTools() {
+		// tool calling is not supported yet, so the router should pick another model
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityTools)
+	}
+
+	if request.HasImages() {
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityVision)
+	}
+
+	// Create a new chat request
+	chatRequest := c.createChatRequestSchema(request)
+
+	chatResponse, err := c.doChatRequest(ctx, chatRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chatResponse.ModelResponse.Message.Content) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return chatResponse, nil
+}
+
+func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *ChatRequest {
+	// TODO: consider using objectpool to optimize memory allocation
+	chatRequest := *c.chatRequestTemplate // copy the template
+
+	messages := request.ChatMessages()
+	chatRequest.Messages = make([]ChatMessage, 0, len(messages))
+
+	for _, message := range messages {
+		chatRequest.Messages = append(chatRequest.Messages, ChatMessage{Role: message.Role, Content: message.Content})
+	}
+
+	if request.Seed != nil {
+		chatRequest.Seed = request.Seed
+	}
+
+	if request.PresencePenalty != nil {
+		chatRequest.PresencePenalty = *request.PresencePenalty
+	}
+
+	if request.FrequencyPenalty != nil {
+		chatRequest.FrequencyPenalty = *request.FrequencyPenalty
+	}
+
+	c.applyParamOverrides(&chatRequest, request.Override.Params)
+
+	return &chatRequest
+}
+
+func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*schemas.UnifiedChatResponse, error) {
+	// Build request payload
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal cloudflare chat request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cloudflare chat request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
+	req.Header.Set("Content-Type", "application/json")
+
+	// TODO: this could leak information from messages which may not be a desired thing to have
+	c.telemetry.Logger.Debug(
+		"cloudflare chat request",
+		zap.String("chat_url", c.chatURL),
+		zap.Any("payload", payload),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send cloudflare chat request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	// Read the response body into a byte slice
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to read cloudflare chat response", zap.Error(err))
+		return nil, err
+	}
+
+	var apiResponse APIResponse
+
+	// errors come in the same envelope as results, so the body is parsed before checking the status
+	parseErr := json.Unmarshal(bodyBytes, &apiResponse)
+
+	if resp.StatusCode != http.StatusOK || (parseErr == nil && !apiResponse.Success) {
+		return nil, c.handleErrorResponse(resp, bodyBytes, &apiResponse)
+	}
+
+	if parseErr != nil {
+		c.telemetry.Logger.Error("failed to parse cloudflare chat response", zap.Error(parseErr))
+		return nil, parseErr
+	}
+
+	if apiResponse.Result == nil {
+		return nil, ErrEmptyResponse
+	}
+
+	content := strings.TrimSpace(apiResponse.Result.Response)
+
+	// most of Workers AI models don't return token usage, so we have to estimate it
+	var promptTokens, responseTokens float64
+
+	if usage := apiResponse.Result.Usage; usage != nil && usage.TotalTokens > 0 {
+		promptTokens = usage.PromptTokens
+		responseTokens = usage.CompletionTokens
+	} else {
+		for _, message := range payload.Messages {
+			promptTokens += clients.EstimateTokens(message.Content)
+		}
+
+		responseTokens = clients.EstimateTokens(content)
+	}
+
+	// Map response to UnifiedChatResponse schema
+	response := schemas.UnifiedChatResponse{
+		ID:       "",                           // not provided by cloudflare
+		Created:  int(time.Now().UTC().Unix()), // not provided by cloudflare
+		Provider: providerName,
+		Model:    c.config.Model,
+		Cached:   false,
+		ModelResponse: schemas.ProviderResponse{
+			SystemID: map[string]string{},
+			Message: schemas.ChatMessage{
+				Role:    "assistant",
+				Content: content,
+				Name:    "",
+			},
+			TokenUsage: schemas.TokenUsage{
+				PromptTokens:   promptTokens,
+				ResponseTokens: responseTokens,
+				TotalTokens:    promptTokens + responseTokens,
+			},
+		},
+	}
+
+	return &response, nil
+}
+
+// handleErrorResponse maps Cloudflare errors to the gateway ones
+func (c *Client) handleErrorResponse(resp *http.Response, bodyBytes []byte, apiResponse *APIResponse) error {
+	c.telemetry.Logger.Error(
+		"cloudflare chat request failed",
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("response", string(bodyBytes)),
+		zap.Any("headers", resp.Header),
+	)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return clients.NewRetryAfterError(resp.Header)
+	}
+
+	errMessage := apiResponse.errorMessage()
+	if errMessage == "" {
+		errMessage = string(bodyBytes)
+	}
+
+	// Server & client errors result in the same error to keep gateway resilient
+	return fmt.Errorf("%w: %v", clients.NewProviderError(resp.StatusCode), errMessage)
+}
+
+// applyParamOverrides merges per-request params over the default ones
+func (c *Client) applyParamOverrides(chatRequest *ChatRequest, params *schemas.ChatParams) {
+	if params == nil {
+		return
+	}
+
+	if params.Temperature != nil {
+		chatRequest.Temperature = *params.Temperature
+	}
+
+	if params.TopP != nil {
+		chatRequest.TopP = *params.TopP
+	}
+
+	if params.TopK != nil {
+		chatRequest.TopK = *params.TopK
+	}
+
+	if params.MaxTokens != nil {
+		chatRequest.MaxTokens = *params.MaxTokens
+	}
+}
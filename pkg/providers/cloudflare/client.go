@@ -0,0 +1,75 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+)
+
+const (
+	providerName = "cloudflare"
+)
+
+// ErrEmptyResponse is returned when the Workers AI API returns an empty response.
+var (
+	ErrEmptyResponse = errors.New("empty response")
+)
+
+// Client is a client for accessing Cloudflare Workers AI API
+type Client struct {
+	baseURL             string
+	chatURL             string
+	chatRequestTemplate *ChatRequest
+	config              *Config
+	httpClient          *http.Client
+	telemetry           *telemetry.Telemetry
+}
+
+// NewClient creates a new Cloudflare client for the Workers AI API.
+func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (*Client, error) {
+	// model names contain slashes (e.g. "@cf/meta/llama-3-8b-instruct") that are kept as path segments
+	chatURL, err := url.JoinPath(providerConfig.BaseURL, "accounts", providerConfig.AccountID, "ai", "run", providerConfig.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		baseURL:             providerConfig.BaseURL,
+		chatURL:             chatURL,
+		config:              providerConfig,
+		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
+		httpClient:          httpClient,
+		telemetry:           tel,
+	}
+
+	return c, nil
+}
+
+func (c *Client) Provider() string {
+	return providerName
+}
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	switch param {
+	case schemas.ParamSeed, schemas.ParamPresencePenalty, schemas.ParamFrequencyPenalty:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,127 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"glide/pkg/providers/clients"
+
+	"glide/pkg/api/schemas"
+
+	"glide/pkg/telemetry"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudflareClient_ChatRequest(t *testing.T) {
+	// Workers AI text generation API: https://developers.cloudflare.com/workers-ai/models/llama-3-8b-instruct/
+	cloudflareMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data ChatRequest
+		// Parse the JSON body
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Equal(t, "/accounts/account-id/ai/run/@cf/meta/llama-3-8b-instruct", r.URL.Path)
+		require.Equal(t, "Bearer api-token", r.Header.Get("Authorization"))
+		require.Equal(t, []ChatMessage{{Role: "user", Content: "What's the biggest animal?"}}, data.Messages)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.success.json"))
+		if err != nil {
+			t.Errorf("error reading cloudflare chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	cloudflareServer := httptest.NewServer(cloudflareMock)
+	defer cloudflareServer.Close()
+
+	ctx := context.Background()
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = cloudflareServer.URL
+	providerCfg.AccountID = "account-id"
+	providerCfg.APIKey = "api-token"
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.UnifiedChatRequest{Message: schemas.ChatMessage{
+		Role:    "user",
+		Content: "What's the biggest animal?",
+	}}
+
+	response, err := client.Chat(ctx, &request)
+	require.NoError(t, err)
+
+	require.Equal(t, providerCfg.Model, response.Model)
+	require.Contains(t, response.ModelResponse.Message.Content, "The blue whale")
+	require.Greater(t, response.ModelResponse.TokenUsage.PromptTokens, 0.0)
+	require.Greater(t, response.ModelResponse.TokenUsage.ResponseTokens, 0.0)
+}
+
+func TestCloudflareClient_ChatError(t *testing.T) {
+	cloudflareMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.error.json"))
+		if err != nil {
+			t.Errorf("error reading cloudflare error mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(errResponse)
+	})
+
+	cloudflareServer := httptest.NewServer(cloudflareMock)
+	defer cloudflareServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = cloudflareServer.URL
+	providerCfg.AccountID = "account-id"
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	_, err = client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+
+	require.ErrorIs(t, err, clients.ErrProviderUnavailable)
+	require.ErrorContains(t, err, "No such model")
+}
+
+func TestCloudflareClient_RateLimit(t *testing.T) {
+	cloudflareMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	cloudflareServer := httptest.NewServer(cloudflareMock)
+	defer cloudflareServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = cloudflareServer.URL
+	providerCfg.AccountID = "account-id"
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	_, err = client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+
+	var rateLimitErr *clients.RateLimitError
+
+	require.ErrorAs(t, err, &rateLimitErr)
+	require.Equal(t, 30*time.Second, rateLimitErr.UntilReset())
+}
@@ -0,0 +1,61 @@
+// Package cloudflare is a provider for Cloudflare Workers AI (https://developers.cloudflare.com/workers-ai/)
+// that runs open-source models on the Cloudflare network. Models are referenced by their catalog names (e.g. "@cf/meta/llama-3-8b-instruct")
+package cloudflare
+
+import (
+	"glide/pkg/config/fields"
+)
+
+// Params defines Workers AI text generation params with the specific validation of values
+// TODO: Add validations
+type Params struct {
+	Temperature       float64 `yaml:"temperature,omitempty" json:"temperature"`
+	TopP              float64 `yaml:"top_p,omitempty" json:"top_p"`
+	TopK              int     `yaml:"top_k,omitempty" json:"top_k"`
+	MaxTokens         int     `yaml:"max_tokens,omitempty" json:"max_tokens"`
+	RepetitionPenalty float64 `yaml:"repetition_penalty,omitempty" json:"repetition_penalty"`
+	FrequencyPenalty  float64 `yaml:"frequency_penalty,omitempty" json:"frequency_penalty"`
+	PresencePenalty   float64 `yaml:"presence_penalty,omitempty" json:"presence_penalty"`
+}
+
+func DefaultParams() Params {
+	return Params{
+		Temperature: 0.6,
+		MaxTokens:   256,
+	}
+}
+
+func (p *Params) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*p = DefaultParams()
+
+	type plain Params // to avoid recursion
+
+	return unmarshal((*plain)(p))
+}
+
+type Config struct {
+	BaseURL       string        `yaml:"base_url" json:"baseUrl" validate:"required"`
+	AccountID     string        `yaml:"account_id" json:"accountId" validate:"required"`
+	Model         string        `yaml:"model" json:"model" validate:"required"`
+	APIKey        fields.Secret `yaml:"api_key" json:"-" validate:"required"` // API token with the Workers AI read permission
+	DefaultParams *Params       `yaml:"default_params,omitempty" json:"defaultParams"`
+}
+
+// DefaultConfig for Cloudflare Workers AI models
+func DefaultConfig() *Config {
+	defaultParams := DefaultParams()
+
+	return &Config{
+		BaseURL:       "https://api.cloudflare.com/client/v4",
+		Model:         "@cf/meta/llama-3-8b-instruct",
+		DefaultParams: &defaultParams,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
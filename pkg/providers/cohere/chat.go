@@ -31,6 +31,10 @@ type ChatRequest struct {
 	Model             string        `json:"model"`
 	Message           string        `json:"message"`
 	Temperature       float64       `json:"temperature,omitempty"`
+	MaxTokens         int           `json:"max_tokens,omitempty"`
+	P                 float64       `json:"p,omitempty"`
+	K                 int           `json:"k,omitempty"`
+	StopSequences     []string      `json:"stop_sequences,omitempty"`
 	PreambleOverride  string        `json:"preamble_override,omitempty"`
 	ChatHistory       []ChatHistory `json:"chat_history,omitempty"`
 	ConversationID    string        `json:"conversation_id,omitempty"`
@@ -38,8 +42,7 @@ type ChatRequest struct {
 	Connectors        []string      `json:"connectors,omitempty"`
 	SearchQueriesOnly bool          `json:"search_queries_only,omitempty"`
 	CitiationQuality  string        `json:"citiation_quality,omitempty"`
-
-	// Stream            bool                `json:"stream,omitempty"`
+	Stream            bool          `json:"stream,omitempty"`
 }
 
 type Connectors struct {
@@ -66,6 +69,15 @@ func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
 
 // Chat sends a chat request to the specified cohere model.
 func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if request.HasTools() {
+		// tool calling is not supported yet, so the router should pick another model
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityTools)
+	}
+
+	if request.HasImages() {
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityVision)
+	}
+
 	// Create a new chat request
 	chatRequest := c.createChatRequestSchema(request)
 
@@ -83,24 +95,42 @@ func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest)
 
 func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *ChatRequest {
 	// TODO: consider using objectpool to optimize memory allocation
-	chatRequest := c.chatRequestTemplate // hoping to get a copy of the template
-	chatRequest.Message = request.Message.Content
-
-	// Build the Cohere specific ChatHistory
-	if len(request.MessageHistory) > 0 {
-		chatRequest.ChatHistory = make([]ChatHistory, len(request.MessageHistory))
-		for i, message := range request.MessageHistory {
-			chatRequest.ChatHistory[i] = ChatHistory{
-				// Copy the necessary fields from message to ChatHistory
-				// For example, if ChatHistory has a field called "Text", you can do:
-				Role:    message.Role,
+	chatRequest := *c.chatRequestTemplate
+
+	chatMessages := request.ChatMessages()
+	lastIdx := len(chatMessages) - 1
+
+	// Cohere takes the new message separately from the chat history
+	chatRequest.Message = chatMessages[lastIdx].Content
+
+	if lastIdx > 0 {
+		chatRequest.ChatHistory = make([]ChatHistory, 0, lastIdx)
+
+		for _, message := range chatMessages[:lastIdx] {
+			chatRequest.ChatHistory = append(chatRequest.ChatHistory, ChatHistory{
+				Role:    cohereRole(message.Role),
 				Message: message.Content,
-				User:    "",
-			}
+			})
 		}
 	}
 
-	return chatRequest
+	c.applyParamOverrides(&chatRequest, request.Override.Params)
+
+	return &chatRequest
+}
+
+// cohereRole maps unified message roles to Cohere ones. Unknown roles are passed as is
+func cohereRole(role string) string {
+	switch role {
+	case schemas.RoleUser:
+		return "USER"
+	case schemas.RoleAssistant:
+		return "CHATBOT"
+	case schemas.RoleSystem:
+		return "SYSTEM"
+	default:
+		return role
+	}
 }
 
 func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*schemas.UnifiedChatResponse, error) {
@@ -115,7 +145,7 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, fmt.Errorf("unable to create cohere chat request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+string(c.config.APIKey))
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
 	req.Header.Set("Content-Type", "application/json")
 
 	// TODO: this could leak information from messages which may not be a desired thing to have
@@ -221,24 +251,42 @@ func (c *Client) handleErrorResponse(resp *http.Response) (*schemas.UnifiedChatR
 	)
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		cooldownDelay, err := c.getCooldownDelay(resp)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse cooldown delay from headers: %w", err)
-		}
-
-		return nil, clients.NewRateLimitError(&cooldownDelay)
+		return nil, clients.NewRetryAfterError(resp.Header)
 	}
 
-	return nil, clients.ErrProviderUnavailable
+	return nil, clients.NewProviderError(resp.StatusCode)
 }
 
-func (c *Client) getCooldownDelay(resp *http.Response) (time.Duration, error) {
-	retryAfter := resp.Header.Get("Retry-After")
+// Cohere bounds of the nucleus & top-k sampling params
+const (
+	minP = 0.01
+	maxP = 0.99
+	maxK = 500
+)
 
-	cooldownDelay, err := time.ParseDuration(retryAfter)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse cooldown delay from headers: %w", err)
+// applyParamOverrides merges per-request params over the default ones
+func (c *Client) applyParamOverrides(chatRequest *ChatRequest, params *schemas.ChatParams) {
+	if params == nil {
+		return
 	}
 
-	return cooldownDelay, nil
+	if params.Temperature != nil {
+		chatRequest.Temperature = *params.Temperature
+	}
+
+	if params.TopP != nil {
+		chatRequest.P = min(max(*params.TopP, minP), maxP)
+	}
+
+	if params.TopK != nil {
+		chatRequest.K = min(*params.TopK, maxK)
+	}
+
+	if params.MaxTokens != nil {
+		chatRequest.MaxTokens = *params.MaxTokens
+	}
+
+	if params.Stop != nil {
+		chatRequest.StopSequences = params.Stop
+	}
 }
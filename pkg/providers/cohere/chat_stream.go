@@ -0,0 +1,180 @@
+package cohere
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"go.uber.org/zap"
+)
+
+// Cohere stream events (https://docs.cohere.com/reference/chat)
+const (
+	StreamStartEvent    = "stream-start"
+	TextGenerationEvent = "text-generation"
+	StreamEndEvent      = "stream-end"
+)
+
+// maxStreamEventSize limits the size of one stream event. The stream-end event carries the whole response, so it may be large
+const maxStreamEventSize = 1024 * 1024
+
+// ChatStreamEvent is one JSON line of the Cohere chat stream
+type ChatStreamEvent struct {
+	EventType    string                        `json:"event_type"`
+	IsFinished   bool                          `json:"is_finished"`
+	GenerationID string                        `json:"generation_id,omitempty"`
+	Text         string                        `json:"text,omitempty"`
+	FinishReason string                        `json:"finish_reason,omitempty"`
+	Response     *schemas.CohereChatCompletion `json:"response,omitempty"`
+}
+
+// ChatStream sends a chat request to the specified cohere model and streams generated text back.
+// Events other than text generation (e.g. connector or tool events) are skipped for now
+func (c *Client) ChatStream(ctx context.Context, request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error) {
+	if request.HasTools() {
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityTools)
+	}
+
+	if request.HasImages() {
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityVision)
+	}
+
+	chatRequest := c.createChatRequestSchema(request)
+	chatRequest.Stream = true
+
+	rawPayload, err := json.Marshal(chatRequest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal cohere chat stream request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cohere chat stream request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send cohere chat stream request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		_, err := c.handleErrorResponse(resp)
+
+		return nil, err
+	}
+
+	streamC := make(chan *schemas.ChatStreamResult)
+
+	go c.readChatStream(ctx, resp, streamC)
+
+	return streamC, nil
+}
+
+func (c *Client) readChatStream(ctx context.Context, resp *http.Response, streamC chan<- *schemas.ChatStreamResult) {
+	defer close(streamC)
+	defer resp.Body.Close()
+
+	send := func(result *schemas.ChatStreamResult) bool {
+		select {
+		case streamC <- result:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamEventSize)
+
+	var generationID string
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		var event ChatStreamEvent
+
+		if err := json.Unmarshal(line, &event); err != nil {
+			c.telemetry.Logger.Error("failed to parse cohere chat stream event", zap.Error(err))
+
+			send(&schemas.ChatStreamResult{Err: fmt.Errorf("failed to parse cohere chat stream event: %w", err)})
+
+			return
+		}
+
+		switch event.EventType {
+		case StreamStartEvent:
+			generationID = event.GenerationID
+		case TextGenerationEvent:
+			if !send(&schemas.ChatStreamResult{Chunk: c.newStreamChunk(generationID, event.Text)}) {
+				return
+			}
+		case StreamEndEvent:
+			send(&schemas.ChatStreamResult{Chunk: c.newFinalStreamChunk(generationID, &event)})
+
+			return
+		default:
+			// connector, search & tool events are not supported yet
+			c.telemetry.Logger.Debug("skipping cohere chat stream event", zap.String("event_type", event.EventType))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		send(&schemas.ChatStreamResult{Err: fmt.Errorf("failed to read cohere chat stream: %w", err)})
+
+		return
+	}
+
+	send(&schemas.ChatStreamResult{Err: ErrStreamInterrupted})
+}
+
+func (c *Client) newStreamChunk(generationID string, text string) *schemas.UnifiedChatStreamChunk {
+	return &schemas.UnifiedChatStreamChunk{
+		ID:       generationID,
+		Created:  int(time.Now().UTC().Unix()), // Cohere doesn't provide this
+		Provider: providerName,
+		Model:    c.config.Model,
+		ModelResponse: schemas.ProviderChunkResponse{
+			SystemID: map[string]string{
+				"generationId": generationID,
+			},
+			Message: schemas.ChatMessage{
+				Role:    "model",
+				Content: text,
+			},
+		},
+	}
+}
+
+func (c *Client) newFinalStreamChunk(generationID string, event *ChatStreamEvent) *schemas.UnifiedChatStreamChunk {
+	chunk := c.newStreamChunk(generationID, "")
+	chunk.FinishReason = event.FinishReason
+
+	if event.Response != nil {
+		completion := event.Response
+
+		chunk.ID = completion.ResponseID
+		chunk.ModelResponse.SystemID["responseId"] = completion.ResponseID
+		chunk.ModelResponse.TokenUsage = &schemas.TokenUsage{
+			PromptTokens:   completion.TokenCount.PromptTokens,
+			ResponseTokens: completion.TokenCount.ResponseTokens,
+			TotalTokens:    completion.TokenCount.TotalTokens,
+		}
+	}
+
+	return chunk
+}
@@ -1,7 +1,9 @@
 package cohere
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -15,7 +17,8 @@ const (
 
 // ErrEmptyResponse is returned when the Cohere API returns an empty response.
 var (
-	ErrEmptyResponse = errors.New("empty response")
+	ErrEmptyResponse     = errors.New("empty response")
+	ErrStreamInterrupted = fmt.Errorf("%w (no stream-end event)", clients.ErrStreamInterrupted)
 )
 
 // Client is a client for accessing Cohere API
@@ -35,20 +38,18 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 		return nil, err
 	}
 
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Client{
 		baseURL:             providerConfig.BaseURL,
 		chatURL:             chatURL,
 		config:              providerConfig,
 		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
-		httpClient: &http.Client{
-			Timeout: *clientConfig.Timeout,
-			// TODO: use values from the config
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 2,
-			},
-		},
-		telemetry: tel,
+		httpClient:          httpClient,
+		telemetry:           tel,
 	}
 
 	return c, nil
@@ -57,3 +58,13 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 func (c *Client) Provider() string {
 	return providerName
 }
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// MaxStopSequences is the number of stop sequences Cohere takes per request
+func (c *Client) MaxStopSequences() int {
+	return 5
+}
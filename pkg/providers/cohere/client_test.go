@@ -64,3 +64,114 @@ func TestCohereClient_ChatRequest(t *testing.T) {
 
 	require.Equal(t, "ec9eb88b-2da5-462e-8f0f-0899d243aa2e", response.ID)
 }
+
+func TestCohereClient_ChatStreamRequest(t *testing.T) {
+	cohereMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data map[string]interface{}
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		if data["stream"] != true {
+			t.Errorf("chat stream request is expected to enable streaming: %v", data)
+		}
+
+		chatStream, err := os.ReadFile(filepath.Clean("./testdata/chat_stream.success.txt"))
+		if err != nil {
+			t.Errorf("error reading cohere chat stream mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/stream+json")
+		_, err = w.Write(chatStream)
+		if err != nil {
+			t.Errorf("error on sending chat stream response: %v", err)
+		}
+	})
+
+	cohereServer := httptest.NewServer(cohereMock)
+	defer cohereServer.Close()
+
+	ctx := context.Background()
+	providerCfg := DefaultConfig()
+	clientCfg := clients.DefaultClientConfig()
+	providerCfg.BaseURL = cohereServer.URL
+
+	client, err := NewClient(providerCfg, clientCfg, telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.UnifiedChatRequest{Message: schemas.ChatMessage{
+		Role:    "human",
+		Content: "What's the biggest animal?",
+	}}
+
+	streamC, err := client.ChatStream(ctx, &request)
+	require.NoError(t, err)
+
+	chunks := make([]*schemas.UnifiedChatStreamChunk, 0, 4)
+
+	for result := range streamC {
+		require.NoError(t, result.Err)
+
+		chunks = append(chunks, result.Chunk)
+	}
+
+	require.Len(t, chunks, 4)
+	require.Equal(t, "The", chunks[0].ModelResponse.Message.Content)
+	require.Equal(t, " blue whale", chunks[1].ModelResponse.Message.Content)
+	require.Equal(t, " is the biggest animal.", chunks[2].ModelResponse.Message.Content)
+	require.Nil(t, chunks[2].ModelResponse.TokenUsage)
+
+	lastChunk := chunks[3]
+	require.Equal(t, "ec9eb88b-2da5-462e-8f0f-0899d243aa2e", lastChunk.ID)
+	require.Equal(t, "COMPLETE", lastChunk.FinishReason)
+	require.Equal(t, float64(72), lastChunk.ModelResponse.TokenUsage.TotalTokens)
+}
+
+func TestCohereClient_ChatStreamInterrupted(t *testing.T) {
+	cohereMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"is_finished":false,"event_type":"text-generation","text":"The"}` + "\n"))
+	})
+
+	cohereServer := httptest.NewServer(cohereMock)
+	defer cohereServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = cohereServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	streamC, err := client.ChatStream(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+
+	result := <-streamC
+	require.NoError(t, result.Err)
+
+	result = <-streamC
+	require.ErrorIs(t, result.Err, ErrStreamInterrupted)
+}
+
+func TestCohereClient_MessagesTranslated(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	chatRequest := client.createChatRequestSchema(&schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{
+			{Role: schemas.RoleSystem, Content: "You are a zoologist."},
+			{Role: schemas.RoleUser, Content: "What's the biggest animal?"},
+			{Role: schemas.RoleAssistant, Content: "The blue whale."},
+			{Role: schemas.RoleUser, Content: "How big is it?"},
+		},
+	})
+
+	require.Equal(t, "How big is it?", chatRequest.Message)
+	require.Equal(t, []ChatHistory{
+		{Role: "SYSTEM", Message: "You are a zoologist."},
+		{Role: "USER", Message: "What's the biggest animal?"},
+		{Role: "CHATBOT", Message: "The blue whale."},
+	}, chatRequest.ChatHistory)
+}
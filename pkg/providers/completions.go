@@ -0,0 +1,120 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+)
+
+// FanOutConfig lets models without native support of multiple completions (n > 1) serve such requests
+// by sending as many requests to the provider. Each of them is billed, so fan-out is disabled by default
+type FanOutConfig struct {
+	MaxParallel int `yaml:"max_parallel" json:"max_parallel" validate:"min=1"` // max number of requests sent at once
+}
+
+func DefaultFanOutConfig() *FanOutConfig {
+	return &FanOutConfig{
+		MaxParallel: 4,
+	}
+}
+
+func (c *FanOutConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultFanOutConfig()
+
+	type plain FanOutConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// SetFanOut makes the model ask its provider several times for multiple completions if it can't generate them natively
+// (nil disables fan-out, so such requests go to other models)
+func (m *LangModel) SetFanOut(cfg *FanOutConfig) {
+	m.fanOut = cfg
+}
+
+// SupportsMultipleCompletions checks if the model could serve requests for several completions (natively or via fan-out)
+func (m *LangModel) SupportsMultipleCompletions() bool {
+	return m.fanOut != nil || HasCapability(m.activeClient(), clients.CapabilityMultipleCompletions)
+}
+
+// chatCompletions gets as many completions as the request asks for.
+// Clients that could return only one completion per request are asked several times in parallel (bounded by the fan-out config)
+func chatCompletions(
+	ctx context.Context,
+	client LangModelProvider,
+	request *schemas.UnifiedChatRequest,
+	fanOut *FanOutConfig,
+) (*schemas.UnifiedChatResponse, error) {
+	if request.N <= 1 || supportsParam(client, schemas.ParamN) || fanOut == nil {
+		return client.Chat(ctx, request)
+	}
+
+	// the rest of requests are cancelled once any of them fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*schemas.UnifiedChatResponse, request.N)
+	errs := make([]error, request.N)
+	slots := make(chan struct{}, fanOut.MaxParallel)
+
+	var wg sync.WaitGroup
+
+	for idx := 0; idx < request.N; idx++ {
+		slots <- struct{}{}
+
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if ctx.Err() != nil {
+				errs[idx] = ctx.Err()
+
+				return
+			}
+
+			responses[idx], errs[idx] = client.Chat(ctx, request)
+			if errs[idx] != nil {
+				cancel()
+			}
+		}(idx)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	response := responses[0]
+	choices := make([]schemas.ChatMessage, 0, request.N)
+
+	for idx, resp := range responses {
+		choices = append(choices, resp.ModelResponse.Message)
+
+		if idx == 0 {
+			continue
+		}
+
+		// every request is billed, so the prompt is counted as many times as it was sent
+		response.ModelResponse.TokenUsage.PromptTokens += resp.ModelResponse.TokenUsage.PromptTokens
+		response.ModelResponse.TokenUsage.ResponseTokens += resp.ModelResponse.TokenUsage.ResponseTokens
+		response.ModelResponse.TokenUsage.TotalTokens += resp.ModelResponse.TokenUsage.TotalTokens
+		response.ModelResponse.TokenUsage.CacheCreationTokens += resp.ModelResponse.TokenUsage.CacheCreationTokens
+		response.ModelResponse.TokenUsage.CacheReadTokens += resp.ModelResponse.TokenUsage.CacheReadTokens
+	}
+
+	response.ModelResponse.Choices = choices
+
+	return response, nil
+}
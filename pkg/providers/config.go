@@ -1,38 +1,97 @@
 package providers
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
 
 	"glide/pkg/routers/latency"
+	"glide/pkg/routers/prompts"
 
+	"glide/pkg/config/fields"
 	"glide/pkg/providers/clients"
 
 	"glide/pkg/routers/health"
 
 	"glide/pkg/providers/anthropic"
 	"glide/pkg/providers/azureopenai"
+	"glide/pkg/providers/cloudflare"
 	"glide/pkg/providers/cohere"
+	"glide/pkg/providers/deepseek"
+	"glide/pkg/providers/huggingface"
+	"glide/pkg/providers/mock"
 	"glide/pkg/providers/octoml"
 	"glide/pkg/providers/openai"
+	"glide/pkg/providers/openaicompat"
+	"glide/pkg/providers/openrouter"
 	"glide/pkg/telemetry"
+	"gopkg.in/yaml.v3"
 )
 
 var ErrProviderNotFound = errors.New("provider not found")
 
 type LangModelConfig struct {
-	ID          string                `yaml:"id" json:"id" validate:"required"`           // Model instance ID (unique in scope of the router)
-	Enabled     bool                  `yaml:"enabled" json:"enabled" validate:"required"` // Is the model enabled?
-	ErrorBudget *health.ErrorBudget   `yaml:"error_budget" json:"error_budget" swaggertype:"primitive,string"`
-	Latency     *latency.Config       `yaml:"latency" json:"latency"`
-	Weight      int                   `yaml:"weight" json:"weight"`
-	Client      *clients.ClientConfig `yaml:"client" json:"client"`
+	ID             string              `yaml:"id" json:"id" validate:"required"` // Model instance ID (unique in scope of the router)
+	Enabled        bool                `yaml:"enabled" json:"enabled"`           // Is the model enabled?
+	ErrorBudget    *health.ErrorBudget `yaml:"error_budget" json:"error_budget" swaggertype:"primitive,string"`
+	Latency        *latency.Config     `yaml:"latency" json:"latency"`
+	Weight         int                 `yaml:"weight" json:"weight"`
+	MaxConcurrency int                 `yaml:"max_concurrency,omitempty" json:"max_concurrency" validate:"min=0"` // Max number of in-flight requests (zero means no limit)
+	StrictParams   bool                `yaml:"strict_params,omitempty" json:"strict_params"`                      // reject requests with optional params the provider can't translate (e.g. seed) instead of ignoring them
+	// RateLimitCooldown is how long the model stays rate limited when the provider doesn't send Retry-After (one minute by default)
+	RateLimitCooldown time.Duration `yaml:"rate_limit_cooldown,omitempty" json:"rate_limit_cooldown" swaggertype:"primitive,integer" validate:"gte=0"`
+	// IgnoreRetryAfter applies RateLimitCooldown to all rate limits instead of the window the provider reports via Retry-After
+	IgnoreRetryAfter bool `yaml:"ignore_retry_after,omitempty" json:"ignore_retry_after"`
+	// RateLimitCooldownBuffer extends rate limit resets reported by the provider, so the limit is not hit again right away
+	RateLimitCooldownBuffer time.Duration `yaml:"rate_limit_cooldown_buffer,omitempty" json:"rate_limit_cooldown_buffer" swaggertype:"primitive,integer" validate:"gte=0"`
+	// RateLimitRampUp is the period after rate limit resets over which traffic is gradually sent back to the model (disabled by default)
+	RateLimitRampUp time.Duration `yaml:"rate_limit_ramp_up,omitempty" json:"rate_limit_ramp_up" swaggertype:"primitive,integer" validate:"gte=0"`
+	// serve structured output requests the provider can't handle natively by asking for JSON in the system prompt & validating responses
+	// (otherwise such models are skipped when the request has a response format)
+	StructuredOutputFallback bool `yaml:"structured_output_fallback,omitempty" json:"structured_output_fallback"`
+	// Tokenizer counts tokens of the model instead of the provider one ("heuristic" or a tiktoken encoding like cl100k_base).
+	// It's used to estimate token usage providers omit too
+	Tokenizer string `yaml:"tokenizer,omitempty" json:"tokenizer,omitempty"`
+	// Capabilities declares what the model can do (undeclared capabilities are inferred from the provider)
+	Capabilities *CapabilitiesConfig `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+	// OutputGuard cuts responses longer than the max output tokens even if the model ignores max_tokens (disabled by default)
+	OutputGuard *OutputGuardConfig `yaml:"output_guard,omitempty" json:"output_guard,omitempty"`
+	// TokenRateLimit keeps the model within the tokens-per-minute limit of its provider (disabled by default)
+	TokenRateLimit *TokenRateLimitConfig `yaml:"token_rate_limit,omitempty" json:"token_rate_limit,omitempty"`
+	// FanOut serves requests for multiple completions (n > 1) by asking providers without native support several times
+	// (disabled by default, as each request is billed, so such requests go to other models)
+	FanOut *FanOutConfig `yaml:"fan_out,omitempty" json:"fan_out,omitempty"`
+	// Chaos injects faults into requests to the model (disabled by default). It's only allowed with experimental.chaos enabled
+	Chaos *ChaosConfig `yaml:"chaos,omitempty" json:"chaos,omitempty"`
+	// PromptTemplate scaffolds requests to the model (it takes precedence over the router template)
+	PromptTemplate *prompts.Config       `yaml:"prompt_template,omitempty" json:"prompt_template,omitempty"`
+	Client         *clients.ClientConfig `yaml:"client" json:"client"`
 	// Add other providers like
-	OpenAI      *openai.Config      `yaml:"openai,omitempty" json:"openai,omitempty"`
-	AzureOpenAI *azureopenai.Config `yaml:"azureopenai,omitempty" json:"azureopenai,omitempty"`
-	Cohere      *cohere.Config      `yaml:"cohere,omitempty" json:"cohere,omitempty"`
-	OctoML      *octoml.Config      `yaml:"octoml,omitempty" json:"octoml,omitempty"`
-	Anthropic   *anthropic.Config   `yaml:"anthropic,omitempty" json:"anthropic,omitempty"`
+	OpenAI       *openai.Config       `yaml:"openai,omitempty" json:"openai,omitempty"`
+	AzureOpenAI  *azureopenai.Config  `yaml:"azureopenai,omitempty" json:"azureopenai,omitempty"`
+	Cohere       *cohere.Config       `yaml:"cohere,omitempty" json:"cohere,omitempty"`
+	OctoML       *octoml.Config       `yaml:"octoml,omitempty" json:"octoml,omitempty"`
+	Anthropic    *anthropic.Config    `yaml:"anthropic,omitempty" json:"anthropic,omitempty"`
+	HuggingFace  *huggingface.Config  `yaml:"huggingface,omitempty" json:"huggingface,omitempty"`
+	OpenAICompat *openaicompat.Config `yaml:"openaicompat,omitempty" json:"openaicompat,omitempty"`
+	OpenRouter   *openrouter.Config   `yaml:"openrouter,omitempty" json:"openrouter,omitempty"`
+	Cloudflare   *cloudflare.Config   `yaml:"cloudflare,omitempty" json:"cloudflare,omitempty"`
+	DeepSeek     *deepseek.Config     `yaml:"deepseek,omitempty" json:"deepseek,omitempty"`
+	Mock         *mock.Config         `yaml:"mock,omitempty" json:"mock,omitempty"` // serves canned responses (e.g. to try out router configs or load test the gateway)
+	// Registered is the config of a provider added via RegisterProvider (e.g. by apps embedding Glide).
+	// It's decoded from & encoded to the key of the provider name (see UnmarshalYAML & MarshalYAML)
+	Registered *RegisteredProviderConfig `yaml:"-" json:"-"`
+}
+
+// RegisteredProviderConfig is the config of a provider added via RegisterProvider
+type RegisteredProviderConfig struct {
+	Name   string // the provider name the config was decoded from
+	Config any    // the value the provider factory NewConfig has returned
 }
 
 func DefaultLangModelConfig() *LangModelConfig {
@@ -51,52 +110,249 @@ func (c *LangModelConfig) ToModel(tel *telemetry.Telemetry) (*LangModel, error)
 		return nil, fmt.Errorf("error initializing client: %v", err)
 	}
 
-	return NewLangModel(c.ID, client, *c.ErrorBudget, *c.Latency, c.Weight), nil
+	model := NewLangModel(c.ID, client, *c.ErrorBudget, *c.Latency, c.Weight)
+	model.SetMaxConcurrency(c.MaxConcurrency)
+	model.SetStrictParams(c.StrictParams)
+	model.SetRateLimitCooldown(c.RateLimitCooldownBuffer, c.RateLimitRampUp)
+	model.SetRetryAfter(c.RateLimitCooldown, !c.IgnoreRetryAfter)
+	model.SetConnWarmup(c.Client.ConnWarmup)
+	model.SetStructuredOutputFallback(c.StructuredOutputFallback)
+	model.SetOutputGuard(c.OutputGuard, tel.Logger)
+	model.SetChaos(c.Chaos)
+	model.SetTokenRateLimit(c.TokenRateLimit)
+	model.SetFanOut(c.FanOut)
+
+	if err := model.SetPromptTemplate(c.PromptTemplate); err != nil {
+		return nil, err
+	}
+
+	if err := model.SetTokenizer(c.Tokenizer); err != nil {
+		return nil, err
+	}
+
+	if err := model.SetCapabilities(c.Capabilities); err != nil {
+		return nil, err
+	}
+
+	return model, nil
 }
 
 // initClient initializes the language model client based on the provided configuration.
 // It takes a telemetry object as input and returns a LangModelProvider and an error.
 func (c *LangModelConfig) initClient(tel *telemetry.Telemetry) (LangModelProvider, error) {
-	switch {
-	case c.OpenAI != nil:
-		return openai.NewClient(c.OpenAI, c.Client, tel)
-	case c.AzureOpenAI != nil:
-		return azureopenai.NewClient(c.AzureOpenAI, c.Client, tel)
-	case c.Cohere != nil:
-		return cohere.NewClient(c.Cohere, c.Client, tel)
-	case c.OctoML != nil:
-		return octoml.NewClient(c.OctoML, c.Client, tel)
-	case c.Anthropic != nil:
-		return anthropic.NewClient(c.Anthropic, c.Client, tel)
-	default:
+	name, providerConfig := c.providerConfig()
+
+	factory, found := lookupProvider(name)
+	if !found {
 		return nil, ErrProviderNotFound
 	}
+
+	return factory.Build(providerConfig, c.Client, tel)
 }
 
-func (c *LangModelConfig) validateOneProvider() error {
-	providersConfigured := 0
+// providerField is the LangModelConfig field that holds the config of a builtin provider
+type providerField struct {
+	name  string // the YAML key of the field, which is the provider name in the registry
+	index int
+}
+
+// providerFields lists fields of builtin providers. They are resolved once builtin providers are registered
+var providerFields []providerField
+
+// builtinProviderFields finds LangModelConfig fields keyed by names of builtin providers
+func builtinProviderFields() []providerField {
+	configType := reflect.TypeOf(LangModelConfig{})
+	builtinFields := make([]providerField, 0, configType.NumField())
+
+	for idx := 0; idx < configType.NumField(); idx++ {
+		name, _, _ := strings.Cut(configType.Field(idx).Tag.Get("yaml"), ",")
+
+		if factory, found := lookupProvider(name); found && factory.builtin {
+			builtinFields = append(builtinFields, providerField{name: name, index: idx})
+		}
+	}
+
+	return builtinFields
+}
+
+// configuredProvider is the provider the model is configured with
+type configuredProvider struct {
+	name   string
+	config any // a pointer to the provider config
+	field  int // the index of the builtin provider field (-1 for registered providers)
+}
+
+// configuredProviders lists providers set in the model config (exactly one is expected)
+func (c *LangModelConfig) configuredProviders() []configuredProvider {
+	var configured []configuredProvider
+
+	configValue := reflect.ValueOf(c).Elem()
+
+	for _, field := range providerFields {
+		fieldValue := configValue.Field(field.index)
+		if fieldValue.IsNil() {
+			continue
+		}
+
+		configured = append(configured, configuredProvider{name: field.name, config: fieldValue.Interface(), field: field.index})
+	}
+
+	if c.Registered != nil {
+		configured = append(configured, configuredProvider{name: c.Registered.Name, config: c.Registered.Config, field: -1})
+	}
+
+	return configured
+}
+
+// providerConfig returns the name & config of the configured provider
+func (c *LangModelConfig) providerConfig() (string, any) {
+	configured := c.configuredProviders()
+	if len(configured) == 0 {
+		return "", nil
+	}
+
+	return configured[0].name, configured[0].config
+}
+
+// ProviderModel returns the model name of the configured provider (it could be an alias)
+func (c *LangModelConfig) ProviderModel() string {
+	_, providerConfig := c.providerConfig()
+
+	if model := stringField(providerConfig, "Model"); model != nil {
+		return *model
+	}
+
+	return ""
+}
+
+// withProviderModel copies the config with the model name of the provider replaced (e.g. by the alias target)
+func (c *LangModelConfig) withProviderModel(model string) *LangModelConfig {
+	modelConfig := *c
+
+	configured := c.configuredProviders()
+	if len(configured) == 0 {
+		return &modelConfig
+	}
+
+	provider := configured[0]
+
+	configValue := reflect.ValueOf(provider.config)
+	if configValue.Kind() != reflect.Pointer || configValue.IsNil() {
+		return &modelConfig
+	}
+
+	providerConfig := reflect.New(configValue.Elem().Type())
+	providerConfig.Elem().Set(configValue.Elem())
+
+	if providerModel := stringField(providerConfig.Interface(), "Model"); providerModel != nil {
+		*providerModel = model
+	}
+
+	if provider.field < 0 {
+		modelConfig.Registered = &RegisteredProviderConfig{Name: provider.name, Config: providerConfig.Interface()}
+	} else {
+		reflect.ValueOf(&modelConfig).Elem().Field(provider.field).Set(providerConfig)
+	}
+
+	return &modelConfig
+}
+
+// stringField returns a pointer to the string field of the provider config (e.g. Model or BaseURL) if it has one
+func stringField(providerConfig any, name string) *string {
+	configValue := reflect.ValueOf(providerConfig)
+
+	if configValue.Kind() != reflect.Pointer || configValue.IsNil() || configValue.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	field, found := configValue.Elem().Type().FieldByName(name)
+	if !found || field.Type.Kind() != reflect.String {
+		return nil
+	}
+
+	// the field could be promoted from an embedded config that is not set
+	fieldValue, err := configValue.Elem().FieldByIndexErr(field.Index)
+	if err != nil {
+		return nil
+	}
+
+	value, ok := fieldValue.Addr().Interface().(*string)
+	if !ok {
+		return nil
+	}
+
+	return value
+}
+
+// BaseURLEnvVar returns the env var that overrides base URLs of the provider (e.g. GLIDE_OPENAI_BASE_URL for openai),
+// so models could be pointed to mock servers without editing configs
+func BaseURLEnvVar(provider string) string {
+	return "GLIDE_" + strings.ToUpper(provider) + "_BASE_URL"
+}
+
+// providerBaseURL returns the config key of the configured provider and its base URL
+func (c *LangModelConfig) providerBaseURL() (string, *string) {
+	provider, providerConfig := c.providerConfig()
+
+	return provider, stringField(providerConfig, "BaseURL")
+}
+
+// applyBaseURLOverride replaces the provider base URL by the one from the env var if it's set (env > config > default)
+func (c *LangModelConfig) applyBaseURLOverride() {
+	provider, baseURL := c.providerBaseURL()
+	if baseURL == nil {
+		return
+	}
 
-	if c.OpenAI != nil {
-		providersConfigured++
+	if override := os.Getenv(BaseURLEnvVar(provider)); override != "" {
+		*baseURL = override
 	}
+}
+
+// MissingAPIKey checks if the API key of the configured provider is required, but empty once env vars & secrets are resolved
+// (e.g. the referenced env var is set to an empty string). Providers that don't require keys (e.g. self-hosted models) are never reported
+func (c *LangModelConfig) MissingAPIKey() (string, bool) {
+	provider, providerConfig := c.providerConfig()
+
+	apiKey, required := requiredAPIKey(providerConfig)
+	if !required {
+		return provider, false
+	}
+
+	return provider, strings.TrimSpace(apiKey.Value()) == ""
+}
 
-	if c.AzureOpenAI != nil {
-		providersConfigured++
+// requiredAPIKey finds the APIKey field of the provider config. Keys are required by providers that validate them as such
+func requiredAPIKey(providerConfig any) (fields.Secret, bool) {
+	configValue := reflect.ValueOf(providerConfig)
+
+	if configValue.Kind() != reflect.Pointer || configValue.IsNil() || configValue.Elem().Kind() != reflect.Struct {
+		return "", false
 	}
 
-	if c.Cohere != nil {
-		providersConfigured++
+	field, found := configValue.Elem().Type().FieldByName("APIKey")
+	if !found || field.Type != reflect.TypeOf(fields.Secret("")) {
+		return "", false
 	}
 
-	if c.OctoML != nil {
-		providersConfigured++
+	if !strings.Contains(field.Tag.Get("validate"), "required") {
+		return "", false
 	}
 
-	if c.Anthropic != nil {
-		providersConfigured++
+	// the key could be promoted from an embedded config that is not set
+	apiKeyValue, err := configValue.Elem().FieldByIndexErr(field.Index)
+	if err != nil {
+		return "", true
 	}
 
-	// check other providers here
+	apiKey, _ := apiKeyValue.Interface().(fields.Secret)
+
+	return apiKey, true
+}
+
+func (c *LangModelConfig) validateOneProvider() error {
+	providersConfigured := len(c.configuredProviders())
+
 	if providersConfigured == 0 {
 		return fmt.Errorf("exactly one provider must be cofigured for model \"%v\", none is configured", c.ID)
 	}
@@ -121,5 +377,125 @@ func (c *LangModelConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 		return err
 	}
 
-	return c.validateOneProvider()
+	if err := c.unmarshalRegisteredProvider(unmarshal); err != nil {
+		return err
+	}
+
+	if err := c.validateOneProvider(); err != nil {
+		return err
+	}
+
+	c.applyBaseURLOverride()
+
+	return nil
+}
+
+// MarshalYAML encodes the config of the registered provider under its name key, so model configs round-trip
+func (c LangModelConfig) MarshalYAML() (interface{}, error) {
+	type plain LangModelConfig // to avoid recursion
+
+	if c.Registered == nil {
+		return (*plain)(&c), nil
+	}
+
+	var configNode, providerNode yaml.Node
+
+	if err := configNode.Encode((*plain)(&c)); err != nil {
+		return nil, err
+	}
+
+	if err := providerNode.Encode(c.Registered.Config); err != nil {
+		return nil, fmt.Errorf("error encoding %v provider config of model \"%v\": %w", c.Registered.Name, c.ID, err)
+	}
+
+	configNode.Content = append(
+		configNode.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: c.Registered.Name},
+		&providerNode,
+	)
+
+	return &configNode, nil
+}
+
+// MarshalJSON encodes the config of the registered provider under its name key like MarshalYAML does
+func (c LangModelConfig) MarshalJSON() ([]byte, error) {
+	type plain LangModelConfig // to avoid recursion
+
+	rawConfig, err := json.Marshal((*plain)(&c))
+	if err != nil || c.Registered == nil {
+		return rawConfig, err
+	}
+
+	rawName, err := json.Marshal(c.Registered.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	rawProviderConfig, err := json.Marshal(c.Registered.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %v provider config of model \"%v\": %w", c.Registered.Name, c.ID, err)
+	}
+
+	// the provider config is appended to the object, so the order of other fields is kept
+	var buf bytes.Buffer
+
+	buf.Write(bytes.TrimSuffix(rawConfig, []byte("}")))
+	buf.WriteByte(',')
+	buf.Write(rawName)
+	buf.WriteByte(':')
+	buf.Write(rawProviderConfig)
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// ExtraProperties lists configs of providers added via RegisterProvider, so the config schema covers them too
+func (c LangModelConfig) ExtraProperties() map[string]interface{} {
+	properties := make(map[string]interface{})
+
+	for _, name := range RegisteredProviders() {
+		factory, found := lookupProvider(name)
+		if !found || factory.builtin {
+			continue
+		}
+
+		properties[name] = factory.NewConfig()
+	}
+
+	return properties
+}
+
+// unmarshalRegisteredProvider decodes the config of the provider added via RegisterProvider if the model is configured with one
+func (c *LangModelConfig) unmarshalRegisteredProvider(unmarshal func(interface{}) error) error {
+	var fields map[string]yaml.Node
+
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+
+	for key, node := range fields {
+		factory, found := lookupProvider(key)
+		if !found || factory.builtin {
+			continue
+		}
+
+		if c.Registered != nil {
+			return fmt.Errorf(
+				"exactly one provider must be cofigured for model \"%v\", %v & %v are configured",
+				c.ID,
+				c.Registered.Name,
+				key,
+			)
+		}
+
+		providerConfig := factory.NewConfig()
+
+		if err := node.Decode(providerConfig); err != nil {
+			return fmt.Errorf("error decoding %v provider config of model \"%v\": %w", key, c.ID, err)
+		}
+
+		c.Registered = &RegisteredProviderConfig{Name: key, Config: providerConfig}
+	}
+
+	return nil
 }
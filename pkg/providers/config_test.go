@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLangModelConfig_BaseURLOverriddenByEnv(t *testing.T) {
+	t.Setenv(BaseURLEnvVar("openai"), "http://localhost:8080/v1")
+
+	var openAIConfig LangModelConfig
+
+	err := yaml.Unmarshal([]byte(`
+id: gpt4
+openai:
+  baseUrl: https://example.com/v1
+  model: gpt-4o
+  api_key: sk-test
+`), &openAIConfig)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/v1", openAIConfig.OpenAI.BaseURL)
+
+	// the env var takes precedence over defaults too, while other providers are not affected
+	t.Setenv(BaseURLEnvVar("anthropic"), "http://localhost:8081/v1")
+
+	var anthropicConfig, cohereConfig LangModelConfig
+
+	require.NoError(t, yaml.Unmarshal([]byte(`{id: claude, anthropic: {model: claude-3-haiku, api_key: test}}`), &anthropicConfig))
+	require.Equal(t, "http://localhost:8081/v1", anthropicConfig.Anthropic.BaseURL)
+
+	require.NoError(t, yaml.Unmarshal([]byte(`{id: command, cohere: {model: command-r, api_key: test}}`), &cohereConfig))
+	require.Equal(t, "https://api.cohere.ai/v1", cohereConfig.Cohere.BaseURL)
+}
+
+func TestLangModelConfig_Mock(t *testing.T) {
+	var modelConfig LangModelConfig
+
+	require.NoError(t, yaml.Unmarshal([]byte(`{id: mocked, mock: {responses: [hi there]}}`), &modelConfig))
+	require.Equal(t, "mock", modelConfig.ProviderModel())
+
+	model, err := modelConfig.ToModel(telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	resp, err := model.Chat(context.Background(), schemas.NewChatFromStr("hello"))
+	require.NoError(t, err)
+	require.Equal(t, "hi there", resp.ModelResponse.Message.Content)
+	require.False(t, resp.ModelResponse.TokenUsage.Estimated)
+}
+
+func TestLangModelConfig_MissingAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		provider string
+		missing  bool
+	}{
+		{"key is set", `{id: gpt, openai: {api_key: sk-123}}`, "openai", false},
+		{"key is blank", `{id: gpt, openai: {api_key: "  "}}`, "openai", true},
+		{"key is empty", `{id: claude, anthropic: {api_key: ""}}`, "anthropic", true},
+		{"key is optional", `{id: llama, openaicompat: {base_url: "http://localhost:11434/v1", model: llama3}}`, "openaicompat", false},
+		{"provider has no keys", `{id: mocked, mock: {}}`, "mock", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var modelConfig LangModelConfig
+
+			require.NoError(t, yaml.Unmarshal([]byte(tt.config), &modelConfig))
+
+			provider, missing := modelConfig.MissingAPIKey()
+			require.Equal(t, tt.provider, provider)
+			require.Equal(t, tt.missing, missing)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package deepseek
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"go.uber.org/zap"
+)
+
+// ErrorResponse is returned by DeepSeek in case of errors (https://api-docs.deepseek.com/quick_start/error_codes)
+type ErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// ChatCompletion holds DeepSeek-specific fields of the OpenAI-compatible chat completion
+type ChatCompletion struct {
+	Choices []struct {
+		Message struct {
+			// ReasoningContent is the chain of thought of reasoning models generated before the response
+			ReasoningContent string `json:"reasoning_content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptCacheHitTokens float64 `json:"prompt_cache_hit_tokens"`
+	} `json:"usage"`
+}
+
+// NewChatRequestFromConfig fills the OpenAI request schema from the config. Not using reflection because of performance penalty it gives
+func NewChatRequestFromConfig(cfg *Config) *openai.ChatRequest {
+	chatRequest := openai.NewChatRequestFromParams(cfg.Model, cfg.DefaultParams)
+
+	// DeepSeek doesn't take these OpenAI params
+	chatRequest.N = 0
+	chatRequest.LogitBias = nil
+	chatRequest.Seed = nil
+
+	return chatRequest
+}
+
+// Chat sends a chat request to the specified DeepSeek model.
+func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	// Create a new chat request
+	chatRequest := c.createChatRequestSchema(request)
+
+	chatResponse, err := c.doChatRequest(ctx, chatRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chatResponse.ModelResponse.Message.Content) == 0 && len(chatResponse.ModelResponse.Message.ToolCalls) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return chatResponse, nil
+}
+
+func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *openai.ChatRequest {
+	return openai.NewChatRequest(c.chatRequestTemplate, request, c.SupportsParam, c.telemetry.Logger, providerName)
+}
+
+func (c *Client) doChatRequest(ctx context.Context, payload *openai.ChatRequest) (*schemas.UnifiedChatResponse, error) {
+	// Build request payload
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal deepseek chat request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create deepseek chat request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
+	req.Header.Set("Content-Type", "application/json")
+
+	// TODO: this could leak information from messages which may not be a desired thing to have
+	c.telemetry.Logger.Debug(
+		"deepseek chat request",
+		zap.String("chat_url", c.chatURL),
+		zap.Any("payload", payload),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send deepseek chat request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	// Read the response body into a byte slice
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to read deepseek chat response", zap.Error(err))
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp, bodyBytes)
+	}
+
+	// Parse the response JSON
+	var completion schemas.OpenAIChatCompletion
+
+	err = json.Unmarshal(bodyBytes, &completion)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to parse deepseek chat response", zap.Error(err))
+		return nil, err
+	}
+
+	if len(completion.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	// fields DeepSeek adds to the OpenAI schema
+	var deepSeekCompletion ChatCompletion
+
+	err = json.Unmarshal(bodyBytes, &deepSeekCompletion)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to parse deepseek chat response", zap.Error(err))
+		return nil, err
+	}
+
+	response := openai.NewUnifiedChatResponse(&completion, providerName)
+	response.ModelResponse.TokenUsage.CacheReadTokens = deepSeekCompletion.Usage.PromptCacheHitTokens
+
+	if len(deepSeekCompletion.Choices) > 0 {
+		response.ModelResponse.ReasoningContent = deepSeekCompletion.Choices[0].Message.ReasoningContent
+	}
+
+	return response, nil
+}
+
+// handleErrorResponse maps DeepSeek errors to the gateway ones
+func (c *Client) handleErrorResponse(resp *http.Response, bodyBytes []byte) error {
+	c.telemetry.Logger.Error(
+		"deepseek chat request failed",
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("response", string(bodyBytes)),
+		zap.Any("headers", resp.Header),
+	)
+
+	var errResp ErrorResponse
+
+	errMessage := string(bodyBytes)
+
+	if err := json.Unmarshal(bodyBytes, &errResp); err == nil && errResp.Error.Message != "" {
+		errMessage = errResp.Error.Message
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return clients.NewRetryAfterError(resp.Header)
+	case http.StatusPaymentRequired:
+		// the model could not serve requests until the balance is topped up, so requests fall back to other models
+		return fmt.Errorf("%w: %w: %v", clients.ErrProviderUnavailable, ErrInsufficientBalance, errMessage)
+	default:
+		// the rest (e.g. invalid params, auth failures or the server being overloaded) makes the model unavailable for now
+		return fmt.Errorf("%w: %v", clients.NewProviderError(resp.StatusCode), errMessage)
+	}
+}
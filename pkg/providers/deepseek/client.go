@@ -0,0 +1,102 @@
+package deepseek
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/telemetry"
+)
+
+const (
+	providerName = "deepseek"
+	// maxStopSequences is the number of stop sequences DeepSeek accepts
+	maxStopSequences = 16
+)
+
+var (
+	// ErrEmptyResponse is returned when the DeepSeek API returns an empty response.
+	ErrEmptyResponse = errors.New("empty response")
+	// ErrInsufficientBalance is returned when the DeepSeek account has run out of balance
+	ErrInsufficientBalance = errors.New("deepseek account has insufficient balance")
+)
+
+// Client is a client for accessing DeepSeek API
+type Client struct {
+	baseURL             string
+	chatURL             string
+	chatRequestTemplate *openai.ChatRequest
+	config              *Config
+	httpClient          *http.Client
+	telemetry           *telemetry.Telemetry
+}
+
+// NewClient creates a new DeepSeek client.
+func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (*Client, error) {
+	chatURL, err := url.JoinPath(providerConfig.BaseURL, providerConfig.ChatEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		baseURL:             providerConfig.BaseURL,
+		chatURL:             chatURL,
+		config:              providerConfig,
+		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
+		httpClient:          httpClient,
+		telemetry:           tel,
+	}
+
+	return c, nil
+}
+
+func (c *Client) Provider() string {
+	return providerName
+}
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	switch param {
+	case schemas.ParamPresencePenalty, schemas.ParamFrequencyPenalty:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaxStopSequences is the number of stop sequences DeepSeek accepts
+func (c *Client) MaxStopSequences() int {
+	return maxStopSequences
+}
+
+// SupportsTools reports whether the client could translate tools of the unified chat request.
+// Reasoning models don't call tools
+func (c *Client) SupportsTools() bool {
+	return !c.reasoning()
+}
+
+// SupportsResponseFormat reports whether the client could translate the response format (JSON mode).
+// Reasoning models don't have JSON mode
+func (c *Client) SupportsResponseFormat() bool {
+	return !c.reasoning()
+}
+
+// reasoning tells if the model thinks before responding (e.g. deepseek-reasoner)
+func (c *Client) reasoning() bool {
+	return strings.Contains(c.config.Model, "reasoner")
+}
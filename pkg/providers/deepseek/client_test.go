@@ -0,0 +1,171 @@
+package deepseek
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/telemetry"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newDeepSeekServer(t *testing.T, model string, responseFile string) *httptest.Server {
+	t.Helper()
+
+	// DeepSeek Chat API: https://api-docs.deepseek.com/api/create-chat-completion
+	deepSeekMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data openai.ChatRequest
+		// Parse the JSON body
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Equal(t, "/chat/completions", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.Equal(t, model, data.Model)
+
+		chatResponse, err := os.ReadFile(filepath.Clean(filepath.Join("./testdata", responseFile)))
+		if err != nil {
+			t.Errorf("error reading deepseek chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	return httptest.NewServer(deepSeekMock)
+}
+
+func TestDeepSeekClient_ChatRequest(t *testing.T) {
+	deepSeekServer := newDeepSeekServer(t, "deepseek-chat", "chat.success.json")
+	defer deepSeekServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = deepSeekServer.URL
+	providerCfg.APIKey = "secret"
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	response, err := client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+
+	require.Equal(t, "930c60df-bf64-41c9-a88e-3ec75f81e00e", response.ID)
+	require.Equal(t, providerName, response.Provider)
+	require.Equal(t, "deepseek-chat", response.Model)
+	require.Equal(t, "The blue whale is the biggest animal on Earth.", response.ModelResponse.Message.Content)
+	require.Empty(t, response.ModelResponse.ReasoningContent)
+	require.Equal(t, 25.0, response.ModelResponse.TokenUsage.TotalTokens)
+
+	require.True(t, client.SupportsTools())
+	require.True(t, client.SupportsResponseFormat())
+}
+
+func TestDeepSeekClient_ReasoningContent(t *testing.T) {
+	deepSeekServer := newDeepSeekServer(t, "deepseek-reasoner", "chat.reasoning.json")
+	defer deepSeekServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = deepSeekServer.URL
+	providerCfg.Model = "deepseek-reasoner"
+	providerCfg.APIKey = "secret"
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	response, err := client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+
+	// the chain of thought is kept apart from the response
+	require.Equal(t, "The blue whale is the biggest animal.", response.ModelResponse.Message.Content)
+	require.Contains(t, response.ModelResponse.ReasoningContent, "Blue whales reach 30 meters")
+	require.Equal(t, 8.0, response.ModelResponse.TokenUsage.CacheReadTokens)
+
+	require.False(t, client.SupportsTools())
+	require.False(t, client.SupportsResponseFormat())
+}
+
+func TestDeepSeekClient_ErrorResponses(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		body       string
+		checkErr   func(t *testing.T, err error)
+	}{
+		"rate limited": {
+			statusCode: http.StatusTooManyRequests,
+			body:       `{"error":{"message":"Rate limit reached","type":"rate_limit_error"}}`,
+			checkErr: func(t *testing.T, err error) {
+				var rateLimitErr *clients.RateLimitError
+
+				require.ErrorAs(t, err, &rateLimitErr)
+			},
+		},
+		"insufficient balance": {
+			statusCode: http.StatusPaymentRequired,
+			body:       `{"error":{"message":"Insufficient Balance","type":"unknown_error"}}`,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInsufficientBalance)
+				require.ErrorIs(t, err, clients.ErrProviderUnavailable)
+				require.ErrorContains(t, err, "Insufficient Balance")
+			},
+		},
+		"server is overloaded": {
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{"error":{"message":"Server overloaded","type":"server_error"}}`,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, clients.ErrProviderUnavailable)
+				require.ErrorContains(t, err, "Server overloaded")
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			deepSeekServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer deepSeekServer.Close()
+
+			providerCfg := DefaultConfig()
+			providerCfg.BaseURL = deepSeekServer.URL
+			providerCfg.APIKey = "secret"
+
+			clientCfg := clients.DefaultClientConfig()
+			clientCfg.MaxRetries = 0
+
+			client, err := NewClient(providerCfg, clientCfg, telemetry.NewTelemetryMock())
+			require.NoError(t, err)
+
+			_, err = client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+			test.checkErr(t, err)
+		})
+	}
+}
+
+func TestDeepSeekConfig_Defaults(t *testing.T) {
+	var cfg Config
+
+	require.NoError(t, yaml.Unmarshal([]byte("api_key: secret\n"), &cfg))
+
+	require.Equal(t, "deepseek-chat", cfg.Model)
+	require.Equal(t, "https://api.deepseek.com", cfg.BaseURL)
+	require.Equal(t, "/chat/completions", cfg.ChatEndpoint)
+}
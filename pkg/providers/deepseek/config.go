@@ -0,0 +1,36 @@
+// Package deepseek is a provider for DeepSeek (https://api-docs.deepseek.com) chat & reasoning models
+// served via the OpenAI-compatible API
+package deepseek
+
+import (
+	"glide/pkg/config/fields"
+	"glide/pkg/providers/openai"
+)
+
+type Config struct {
+	BaseURL       string         `yaml:"base_url" json:"baseUrl" validate:"required"`
+	ChatEndpoint  string         `yaml:"chat_endpoint" json:"chatEndpoint" validate:"required"`
+	Model         string         `yaml:"model" json:"model" validate:"required"` // e.g. deepseek-chat or deepseek-reasoner
+	APIKey        fields.Secret  `yaml:"api_key" json:"-" validate:"required"`
+	DefaultParams *openai.Params `yaml:"default_params,omitempty" json:"defaultParams"`
+}
+
+// DefaultConfig for DeepSeek models
+func DefaultConfig() *Config {
+	defaultParams := openai.DefaultParams()
+
+	return &Config{
+		BaseURL:       "https://api.deepseek.com",
+		ChatEndpoint:  "/chat/completions",
+		Model:         "deepseek-chat",
+		DefaultParams: &defaultParams,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
@@ -0,0 +1,42 @@
+package providers
+
+import (
+	"context"
+
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/telemetry"
+)
+
+// Embedder turns texts into embedding vectors
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// EmbedderConfig defines the embeddings model
+type EmbedderConfig struct {
+	Client *clients.ClientConfig    `yaml:"client" json:"client"`
+	OpenAI *openai.EmbeddingsConfig `yaml:"openai,omitempty" json:"openai,omitempty" validate:"required"`
+}
+
+func DefaultEmbedderConfig() *EmbedderConfig {
+	return &EmbedderConfig{
+		Client: clients.DefaultClientConfig(),
+	}
+}
+
+func (c *EmbedderConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultEmbedderConfig()
+
+	type plain EmbedderConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+func (c *EmbedderConfig) ToEmbedder(tel *telemetry.Telemetry) (Embedder, error) {
+	if c.OpenAI != nil {
+		return openai.NewEmbedder(c.OpenAI, c.Client, tel)
+	}
+
+	return nil, ErrProviderNotFound
+}
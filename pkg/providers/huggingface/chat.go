@@ -0,0 +1,282 @@
+package huggingface
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"glide/pkg/providers/clients"
+
+	"glide/pkg/api/schemas"
+	"go.uber.org/zap"
+)
+
+// TextGenerationParams is a HuggingFace-specific text generation params
+type TextGenerationParams struct {
+	Temperature       float64  `json:"temperature,omitempty"`
+	TopP              float64  `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	MaxNewTokens      int      `json:"max_new_tokens,omitempty"`
+	RepetitionPenalty float64  `json:"repetition_penalty,omitempty"`
+	StopSequences     []string `json:"stop,omitempty"`
+	Seed              *int     `json:"seed,omitempty"`
+	ReturnFullText    bool     `json:"return_full_text"`
+	Details           bool     `json:"details"`
+}
+
+type Options struct {
+	WaitForModel bool `json:"wait_for_model"`
+	UseCache     bool `json:"use_cache"`
+}
+
+// ChatRequest is a HuggingFace-specific text generation request schema
+type ChatRequest struct {
+	Inputs     string               `json:"inputs"`
+	Parameters TextGenerationParams `json:"parameters"`
+	Options    Options              `json:"options"`
+}
+
+// TextGeneration is one generated sequence returned by the HuggingFace text generation API
+type TextGeneration struct {
+	GeneratedText string          `json:"generated_text"`
+	Details       *GenerationInfo `json:"details,omitempty"`
+}
+
+type GenerationInfo struct {
+	FinishReason    string  `json:"finish_reason"`
+	GeneratedTokens float64 `json:"generated_tokens"`
+}
+
+// ErrorResponse is returned by HuggingFace in case of errors
+type ErrorResponse struct {
+	Error         string   `json:"error"`
+	EstimatedTime *float64 `json:"estimated_time,omitempty"`
+}
+
+// NewChatRequestFromConfig fills the struct from the config. Not using reflection because of performance penalty it gives
+func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
+	return &ChatRequest{
+		Parameters: TextGenerationParams{
+			Temperature:       cfg.DefaultParams.Temperature,
+			TopP:              cfg.DefaultParams.TopP,
+			TopK:              cfg.DefaultParams.TopK,
+			MaxNewTokens:      cfg.DefaultParams.MaxNewTokens,
+			RepetitionPenalty: cfg.DefaultParams.RepetitionPenalty,
+			StopSequences:     cfg.DefaultParams.StopSequences,
+			ReturnFullText:    false,
+			Details:           true,
+		},
+		Options: Options{
+			WaitForModel: cfg.DefaultParams.WaitForModel,
+			UseCache:     false,
+		},
+	}
+}
+
+// NewPromptFromUnifiedRequest renders the chat history as a plain text prompt,
+// so it could be completed by text generation models
+func NewPromptFromUnifiedRequest(request *schemas.UnifiedChatRequest) string {
+	var prompt strings.Builder
+
+	for _, message := range request.ChatMessages() {
+		prompt.WriteString(fmt.Sprintf("%s: %s\n", message.Role, message.Content))
+	}
+
+	prompt.WriteString("assistant:")
+
+	return prompt.String()
+}
+
+// Chat sends a chat request to the specified HuggingFace model.
+func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if request.HasTools() {
+		// tool calling is not supported yet, so the router should pick another model
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityTools)
+	}
+
+	if request.HasImages() {
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityVision)
+	}
+
+	// Create a new chat request
+	chatRequest := c.createChatRequestSchema(request)
+
+	chatResponse, err := c.doChatRequest(ctx, chatRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chatResponse.ModelResponse.Message.Content) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return chatResponse, nil
+}
+
+func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *ChatRequest {
+	// TODO: consider using objectpool to optimize memory allocation
+	chatRequest := *c.chatRequestTemplate // copy the template
+	chatRequest.Inputs = NewPromptFromUnifiedRequest(request)
+
+	if request.Seed != nil {
+		chatRequest.Parameters.Seed = request.Seed
+	}
+
+	c.applyParamOverrides(&chatRequest, request.Override.Params)
+
+	return &chatRequest
+}
+
+func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*schemas.UnifiedChatResponse, error) {
+	// Build request payload
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal huggingface chat request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create huggingface chat request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
+	req.Header.Set("Content-Type", "application/json")
+
+	// TODO: this could leak information from messages which may not be a desired thing to have
+	c.telemetry.Logger.Debug(
+		"huggingface chat request",
+		zap.String("chat_url", c.chatURL),
+		zap.Any("payload", payload),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send huggingface chat request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	// Read the response body into a byte slice
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to read huggingface chat response", zap.Error(err))
+		return nil, err
+	}
+
+	// Parse the response JSON
+	var generations []TextGeneration
+
+	err = json.Unmarshal(bodyBytes, &generations)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to parse huggingface chat response", zap.Error(err))
+		return nil, err
+	}
+
+	if len(generations) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	generation := generations[0]
+	content := strings.TrimSpace(generation.GeneratedText)
+
+	// HuggingFace doesn't always return token usage, so we have to estimate it
+	promptTokens := clients.EstimateTokens(payload.Inputs)
+	responseTokens := clients.EstimateTokens(content)
+
+	if generation.Details != nil && generation.Details.GeneratedTokens > 0 {
+		responseTokens = generation.Details.GeneratedTokens
+	}
+
+	// Map response to UnifiedChatResponse schema
+	response := schemas.UnifiedChatResponse{
+		ID:       "",                           // not provided by huggingface
+		Created:  int(time.Now().UTC().Unix()), // not provided by huggingface
+		Provider: providerName,
+		Model:    c.config.Model,
+		Cached:   false,
+		ModelResponse: schemas.ProviderResponse{
+			SystemID: map[string]string{},
+			Message: schemas.ChatMessage{
+				Role:    "assistant",
+				Content: content,
+				Name:    "",
+			},
+			TokenUsage: schemas.TokenUsage{
+				PromptTokens:   promptTokens,
+				ResponseTokens: responseTokens,
+				TotalTokens:    promptTokens + responseTokens,
+			},
+		},
+	}
+
+	return &response, nil
+}
+
+func (c *Client) handleErrorResponse(resp *http.Response) (*schemas.UnifiedChatResponse, error) {
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to read huggingface chat response", zap.Error(err))
+	}
+
+	c.telemetry.Logger.Error(
+		"huggingface chat request failed",
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("response", string(bodyBytes)),
+		zap.Any("headers", resp.Header),
+	)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		// HuggingFace doesn't tell how long we should wait, so the default cooldown is used
+		return nil, clients.NewRateLimitError(nil)
+	}
+
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		var errorResponse ErrorResponse
+
+		// the model is being loaded (e.g. after it was scaled to zero) and will be ready in estimated time
+		if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil && errorResponse.EstimatedTime != nil {
+			untilReady := time.Duration(*errorResponse.EstimatedTime * float64(time.Second))
+
+			return nil, clients.NewModelLoadingError(&untilReady)
+		}
+	}
+
+	// Server & client errors result in the same error to keep gateway resilient
+	return nil, clients.NewProviderError(resp.StatusCode)
+}
+
+// applyParamOverrides merges per-request params over the default ones
+func (c *Client) applyParamOverrides(chatRequest *ChatRequest, params *schemas.ChatParams) {
+	if params == nil {
+		return
+	}
+
+	if params.Temperature != nil {
+		chatRequest.Parameters.Temperature = *params.Temperature
+	}
+
+	if params.TopP != nil {
+		chatRequest.Parameters.TopP = *params.TopP
+	}
+
+	if params.TopK != nil {
+		chatRequest.Parameters.TopK = *params.TopK
+	}
+
+	if params.MaxTokens != nil {
+		chatRequest.Parameters.MaxNewTokens = *params.MaxTokens
+	}
+
+	if params.Stop != nil {
+		chatRequest.Parameters.StopSequences = params.Stop
+	}
+}
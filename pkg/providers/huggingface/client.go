@@ -0,0 +1,81 @@
+package huggingface
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+)
+
+const (
+	providerName = "huggingface"
+)
+
+// ErrEmptyResponse is returned when the HuggingFace API returns an empty response.
+var (
+	ErrEmptyResponse = errors.New("empty response")
+)
+
+// Client is a client for accessing HuggingFace Inference API
+type Client struct {
+	baseURL             string
+	chatURL             string
+	chatRequestTemplate *ChatRequest
+	config              *Config
+	httpClient          *http.Client
+	telemetry           *telemetry.Telemetry
+}
+
+// NewClient creates a new HuggingFace client for the HuggingFace Inference API.
+func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (*Client, error) {
+	chatURL := providerConfig.BaseURL
+
+	if providerConfig.BaseURL == DefaultBaseURL {
+		// the serverless API serves all models, so the model should be specified in the URL
+		modelURL, err := url.JoinPath(providerConfig.BaseURL, providerConfig.Model)
+		if err != nil {
+			return nil, err
+		}
+
+		chatURL = modelURL
+	}
+
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		baseURL:             providerConfig.BaseURL,
+		chatURL:             chatURL,
+		config:              providerConfig,
+		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
+		httpClient:          httpClient,
+		telemetry:           tel,
+	}
+
+	return c, nil
+}
+
+func (c *Client) Provider() string {
+	return providerName
+}
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	return param == schemas.ParamSeed
+}
+
+// MaxStopSequences is the default number of stop sequences Text Generation Inference takes per request
+func (c *Client) MaxStopSequences() int {
+	return 4
+}
@@ -0,0 +1,133 @@
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"glide/pkg/providers/clients"
+
+	"glide/pkg/api/schemas"
+
+	"glide/pkg/telemetry"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHuggingFaceClient_ChatRequest(t *testing.T) {
+	// HuggingFace Text Generation API: https://huggingface.co/docs/api-inference/detailed_parameters#text-generation-task
+	huggingFaceMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data ChatRequest
+		// Parse the JSON body
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Equal(t, "/mistralai/Mistral-7B-Instruct-v0.2", r.URL.Path)
+		require.Equal(t, "human: What's the biggest animal?\nassistant:", data.Inputs)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.success.json"))
+		if err != nil {
+			t.Errorf("error reading huggingface chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	huggingFaceServer := httptest.NewServer(huggingFaceMock)
+	defer huggingFaceServer.Close()
+
+	ctx := context.Background()
+	providerCfg := DefaultConfig()
+	clientCfg := clients.DefaultClientConfig()
+
+	client, err := NewClient(providerCfg, clientCfg, telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	// point the serverless API URL to the mock
+	client.chatURL = huggingFaceServer.URL + "/" + providerCfg.Model
+
+	request := schemas.UnifiedChatRequest{Message: schemas.ChatMessage{
+		Role:    "human",
+		Content: "What's the biggest animal?",
+	}}
+
+	response, err := client.Chat(ctx, &request)
+	require.NoError(t, err)
+
+	require.Equal(t, providerCfg.Model, response.Model)
+	require.Contains(t, response.ModelResponse.Message.Content, "The blue whale")
+	require.InDelta(t, 34.0, response.ModelResponse.TokenUsage.ResponseTokens, 0.0001)
+	require.Greater(t, response.ModelResponse.TokenUsage.PromptTokens, 0.0)
+}
+
+func TestHuggingFaceClient_DedicatedEndpoint(t *testing.T) {
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = "https://xyz.us-east-1.aws.endpoints.huggingface.cloud"
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	require.Equal(t, providerCfg.BaseURL, client.chatURL)
+}
+
+func TestHuggingFaceClient_ModelLoading(t *testing.T) {
+	huggingFaceMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loadingResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.loading.json"))
+		if err != nil {
+			t.Errorf("error reading huggingface loading mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write(loadingResponse)
+	})
+
+	huggingFaceServer := httptest.NewServer(huggingFaceMock)
+	defer huggingFaceServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = huggingFaceServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	_, err = client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+
+	var loadingErr *clients.ModelLoadingError
+
+	require.ErrorAs(t, err, &loadingErr)
+	require.Equal(t, 20*time.Second, loadingErr.UntilReady())
+}
+
+func TestHuggingFaceClient_ChatError(t *testing.T) {
+	huggingFaceMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	})
+
+	huggingFaceServer := httptest.NewServer(huggingFaceMock)
+	defer huggingFaceServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = huggingFaceServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	_, err = client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+
+	require.ErrorIs(t, err, clients.ErrProviderUnavailable)
+}
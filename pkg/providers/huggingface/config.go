@@ -0,0 +1,65 @@
+package huggingface
+
+import (
+	"glide/pkg/config/fields"
+)
+
+// DefaultBaseURL is the serverless HuggingFace Inference API.
+// Dedicated Inference Endpoints have their own URLs that serve one model each
+const DefaultBaseURL = "https://api-inference.huggingface.co/models"
+
+// Params defines HuggingFace-specific text generation params with the specific validation of values
+// TODO: Add validations
+type Params struct {
+	Temperature       float64  `yaml:"temperature,omitempty" json:"temperature"`
+	TopP              float64  `yaml:"top_p,omitempty" json:"top_p"`
+	TopK              int      `yaml:"top_k,omitempty" json:"top_k"`
+	MaxNewTokens      int      `yaml:"max_new_tokens,omitempty" json:"max_new_tokens"`
+	RepetitionPenalty float64  `yaml:"repetition_penalty,omitempty" json:"repetition_penalty"`
+	StopSequences     []string `yaml:"stop,omitempty" json:"stop"`
+	WaitForModel      bool     `yaml:"wait_for_model,omitempty" json:"wait_for_model"`
+}
+
+func DefaultParams() Params {
+	return Params{
+		Temperature:   0.8,
+		TopP:          0.95,
+		MaxNewTokens:  250,
+		StopSequences: []string{},
+		WaitForModel:  false,
+	}
+}
+
+func (p *Params) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*p = DefaultParams()
+
+	type plain Params // to avoid recursion
+
+	return unmarshal((*plain)(p))
+}
+
+type Config struct {
+	BaseURL       string        `yaml:"base_url" json:"baseUrl" validate:"required"` // Serverless Inference API or a dedicated Inference Endpoint URL
+	Model         string        `yaml:"model" json:"model" validate:"required"`
+	APIKey        fields.Secret `yaml:"api_key" json:"-" validate:"required"`
+	DefaultParams *Params       `yaml:"default_params,omitempty" json:"defaultParams"`
+}
+
+// DefaultConfig for HuggingFace models
+func DefaultConfig() *Config {
+	defaultParams := DefaultParams()
+
+	return &Config{
+		BaseURL:       DefaultBaseURL,
+		Model:         "mistralai/Mistral-7B-Instruct-v0.2",
+		DefaultParams: &defaultParams,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
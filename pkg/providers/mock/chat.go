@@ -0,0 +1,58 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"glide/pkg/api/schemas"
+)
+
+// Chat serves the next canned response after the simulated latency
+func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	latency := c.firstTokenLatency()
+
+	if err := c.failure(); err != nil {
+		// failed requests take time too, but they don't generate tokens
+		if waitErr := wait(ctx, latency); waitErr != nil {
+			return nil, waitErr
+		}
+
+		return nil, err
+	}
+
+	requestIdx, content := c.nextResponse(request)
+	promptTokens := c.promptTokens(request)
+	responseTokens := c.tokenizer.CountTokens(content)
+
+	if err := wait(ctx, latency+c.tokensLatency(responseTokens)); err != nil {
+		return nil, err
+	}
+
+	responseID := fmt.Sprintf("mock-%d", requestIdx)
+
+	response := schemas.UnifiedChatResponse{
+		ID:       responseID,
+		Created:  int(time.Now().UTC().Unix()),
+		Provider: providerName,
+		Model:    c.config.Model,
+		Cached:   false,
+		ModelResponse: schemas.ProviderResponse{
+			SystemID: map[string]string{
+				"id": responseID,
+			},
+			Message: schemas.ChatMessage{
+				Role:    "assistant",
+				Content: content,
+			},
+			FinishReason: "stop",
+			TokenUsage: schemas.TokenUsage{
+				PromptTokens:   float64(promptTokens),
+				ResponseTokens: float64(responseTokens),
+				TotalTokens:    float64(promptTokens + responseTokens),
+			},
+		},
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,88 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"glide/pkg/api/schemas"
+)
+
+// ChatStream streams the next canned response word by word. The first word comes after the simulated latency,
+// while the next ones take the time to generate their tokens
+func (c *Client) ChatStream(ctx context.Context, request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error) {
+	if err := c.failure(); err != nil {
+		return nil, err
+	}
+
+	requestIdx, content := c.nextResponse(request)
+
+	streamC := make(chan *schemas.ChatStreamResult)
+
+	go c.streamResponse(ctx, fmt.Sprintf("mock-%d", requestIdx), c.promptTokens(request), content, streamC)
+
+	return streamC, nil
+}
+
+func (c *Client) streamResponse(
+	ctx context.Context,
+	responseID string,
+	promptTokens int,
+	content string,
+	streamC chan<- *schemas.ChatStreamResult,
+) {
+	defer close(streamC)
+
+	if err := wait(ctx, c.firstTokenLatency()); err != nil {
+		return
+	}
+
+	// spaces are kept, so chunks add up to the whole response
+	words := strings.SplitAfter(content, " ")
+	responseTokens := 0
+
+	for idx, word := range words {
+		tokens := c.tokenizer.CountTokens(word)
+		responseTokens += tokens
+
+		if err := wait(ctx, c.tokensLatency(tokens)); err != nil {
+			return
+		}
+
+		chunk := c.newStreamChunk(responseID, word)
+
+		if idx == len(words)-1 {
+			chunk.FinishReason = "stop"
+			chunk.ModelResponse.TokenUsage = &schemas.TokenUsage{
+				PromptTokens:   float64(promptTokens),
+				ResponseTokens: float64(responseTokens),
+				TotalTokens:    float64(promptTokens + responseTokens),
+			}
+		}
+
+		select {
+		case streamC <- &schemas.ChatStreamResult{Chunk: chunk}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Client) newStreamChunk(responseID string, text string) *schemas.UnifiedChatStreamChunk {
+	return &schemas.UnifiedChatStreamChunk{
+		ID:       responseID,
+		Created:  int(time.Now().UTC().Unix()),
+		Provider: providerName,
+		Model:    c.config.Model,
+		ModelResponse: schemas.ProviderChunkResponse{
+			SystemID: map[string]string{
+				"id": responseID,
+			},
+			Message: schemas.ChatMessage{
+				Role:    "assistant",
+				Content: text,
+			},
+		},
+	}
+}
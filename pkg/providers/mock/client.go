@@ -0,0 +1,154 @@
+package mock
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+)
+
+const (
+	providerName = "mock"
+)
+
+// Client serves canned responses with simulated latencies & failures
+type Client struct {
+	config    *Config
+	tokenizer clients.Tokenizer
+	telemetry *telemetry.Telemetry
+	// requests is the number of served requests (it picks the next canned response)
+	requests atomic.Uint64
+	randMu   sync.Mutex
+	rand     *rand.Rand
+}
+
+// NewClient creates a new mock client. The client config is not used, as no requests are sent
+func NewClient(providerConfig *Config, _ *clients.ClientConfig, tel *telemetry.Telemetry) (*Client, error) {
+	seed := time.Now().UnixNano()
+
+	if providerConfig.Seed != nil {
+		seed = *providerConfig.Seed
+	}
+
+	c := &Client{
+		config:    providerConfig,
+		tokenizer: clients.HeuristicTokenizer,
+		telemetry: tel,
+		rand:      rand.New(rand.NewSource(seed)), //nolint:gosec
+	}
+
+	return c, nil
+}
+
+func (c *Client) Provider() string {
+	return providerName
+}
+
+// Tokenizer counts tokens of mock requests & responses, so the token usage looks like the one of real providers
+func (c *Client) Tokenizer() clients.Tokenizer {
+	return c.tokenizer
+}
+
+// failure picks the simulated failure of the request (nil if the request should succeed)
+func (c *Client) failure() error {
+	dice := c.float64()
+
+	switch {
+	case dice < c.config.RateLimitRate:
+		return clients.NewRateLimitError(c.config.RateLimitReset)
+	case dice < c.config.RateLimitRate+c.config.ErrorRate:
+		return clients.ErrProviderUnavailable
+	default:
+		return nil
+	}
+}
+
+// nextResponse returns the next canned response or echoes the request message back
+func (c *Client) nextResponse(request *schemas.UnifiedChatRequest) (uint64, string) {
+	requestIdx := c.requests.Add(1) - 1
+
+	if len(c.config.Responses) == 0 {
+		return requestIdx, request.Message.Content
+	}
+
+	return requestIdx, c.config.Responses[requestIdx%uint64(len(c.config.Responses))]
+}
+
+// firstTokenLatency samples the time to the first token from the configured distribution
+func (c *Client) firstTokenLatency() time.Duration {
+	latency := c.config.Latency
+	if latency == nil {
+		return 0
+	}
+
+	mean := float64(latency.Mean)
+
+	switch latency.Distribution {
+	case DistributionNormal:
+		return time.Duration(math.Max(0, mean+c.normFloat64()*float64(latency.StdDev)))
+	case DistributionPareto:
+		// the scale is picked, so the distribution has the configured mean
+		scale := mean * (latency.Shape - 1) / latency.Shape
+
+		return time.Duration(scale / math.Pow(1-c.float64(), 1/latency.Shape))
+	default:
+		return latency.Mean
+	}
+}
+
+// tokensLatency is how long it takes to generate the given number of tokens
+func (c *Client) tokensLatency(tokens int) time.Duration {
+	if c.config.Latency == nil {
+		return 0
+	}
+
+	return time.Duration(tokens) * c.config.Latency.PerToken
+}
+
+// promptTokens counts tokens of all request messages
+func (c *Client) promptTokens(request *schemas.UnifiedChatRequest) int {
+	tokens := c.tokenizer.CountTokens(request.Message.Content)
+
+	for _, message := range request.MessageHistory {
+		tokens += c.tokenizer.CountTokens(message.Content)
+	}
+
+	return tokens
+}
+
+func (c *Client) float64() float64 {
+	c.randMu.Lock()
+	defer c.randMu.Unlock()
+
+	return c.rand.Float64()
+}
+
+func (c *Client) normFloat64() float64 {
+	c.randMu.Lock()
+	defer c.randMu.Unlock()
+
+	return c.rand.NormFloat64()
+}
+
+// wait simulates the response generation
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
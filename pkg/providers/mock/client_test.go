@@ -0,0 +1,164 @@
+package mock
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+)
+
+func newTestClient(t *testing.T, config *Config) *Client {
+	t.Helper()
+
+	seed := int64(42)
+	config.Seed = &seed
+
+	client, err := NewClient(config, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestMockClient_CannedResponses(t *testing.T) {
+	config := DefaultConfig()
+	config.Responses = []string{"The blue whale", "is the biggest animal"}
+
+	client := newTestClient(t, config)
+
+	for _, expected := range []string{"The blue whale", "is the biggest animal", "The blue whale"} {
+		resp, err := client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+		require.NoError(t, err)
+
+		require.Equal(t, expected, resp.ModelResponse.Message.Content)
+		require.Equal(t, "mock", resp.Provider)
+		require.Equal(t, "mock", resp.Model)
+		// the usage is counted, so latency per token behaves like with real providers
+		require.Greater(t, resp.ModelResponse.TokenUsage.PromptTokens, 0.0)
+		require.Greater(t, resp.ModelResponse.TokenUsage.ResponseTokens, 0.0)
+		require.Equal(
+			t,
+			resp.ModelResponse.TokenUsage.PromptTokens+resp.ModelResponse.TokenUsage.ResponseTokens,
+			resp.ModelResponse.TokenUsage.TotalTokens,
+		)
+	}
+}
+
+func TestMockClient_Echo(t *testing.T) {
+	client := newTestClient(t, DefaultConfig())
+
+	resp, err := client.Chat(context.Background(), schemas.NewChatFromStr("Hello there"))
+	require.NoError(t, err)
+	require.Equal(t, "Hello there", resp.ModelResponse.Message.Content)
+}
+
+func TestMockClient_Latency(t *testing.T) {
+	config := DefaultConfig()
+	config.Latency = &LatencyConfig{Distribution: DistributionFixed, Mean: 20 * time.Millisecond, PerToken: time.Millisecond}
+
+	client := newTestClient(t, config)
+
+	startedAt := time.Now()
+
+	_, err := client.Chat(context.Background(), schemas.NewChatFromStr("Hello there"))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(startedAt), 20*time.Millisecond)
+
+	// the request is canceled while waiting for the response
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	_, err = client.Chat(ctx, schemas.NewChatFromStr("Hello there"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMockClient_LatencyDistributions(t *testing.T) {
+	const samples = 20000
+
+	for _, latency := range []*LatencyConfig{
+		{Distribution: DistributionNormal, Mean: 100 * time.Millisecond, StdDev: 20 * time.Millisecond},
+		{Distribution: DistributionPareto, Mean: 100 * time.Millisecond, Shape: 3},
+	} {
+		config := DefaultConfig()
+		config.Latency = latency
+
+		client := newTestClient(t, config)
+
+		var total time.Duration
+
+		for idx := 0; idx < samples; idx++ {
+			sample := client.firstTokenLatency()
+			require.GreaterOrEqual(t, sample, time.Duration(0))
+
+			total += sample
+		}
+
+		require.InDelta(t, float64(latency.Mean), float64(total/samples), float64(10*time.Millisecond), latency.Distribution)
+	}
+}
+
+func TestMockClient_FailureInjection(t *testing.T) {
+	config := DefaultConfig()
+	config.ErrorRate = 1
+
+	client := newTestClient(t, config)
+
+	_, err := client.Chat(context.Background(), schemas.NewChatFromStr("Hello there"))
+	require.ErrorIs(t, err, clients.ErrProviderUnavailable)
+
+	reset := 10 * time.Second
+
+	config = DefaultConfig()
+	config.RateLimitRate = 1
+	config.RateLimitReset = &reset
+
+	client = newTestClient(t, config)
+
+	_, err = client.ChatStream(context.Background(), schemas.NewChatFromStr("Hello there"))
+
+	var rateLimitErr *clients.RateLimitError
+
+	require.ErrorAs(t, err, &rateLimitErr)
+	require.Equal(t, reset, rateLimitErr.UntilReset())
+}
+
+func TestMockClient_ChatStream(t *testing.T) {
+	config := DefaultConfig()
+	config.Responses = []string{"The blue whale is the biggest animal"}
+
+	client := newTestClient(t, config)
+
+	streamC, err := client.ChatStream(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+
+	var content strings.Builder
+
+	var lastChunk *schemas.UnifiedChatStreamChunk
+
+	for result := range streamC {
+		require.NoError(t, result.Err)
+
+		content.WriteString(result.Chunk.ModelResponse.Message.Content)
+		lastChunk = result.Chunk
+	}
+
+	require.Equal(t, "The blue whale is the biggest animal", content.String())
+	require.Equal(t, "stop", lastChunk.FinishReason)
+	require.NotNil(t, lastChunk.ModelResponse.TokenUsage)
+	require.Greater(t, lastChunk.ModelResponse.TokenUsage.ResponseTokens, 0.0)
+}
+
+func TestMockConfig_Defaults(t *testing.T) {
+	var config Config
+
+	require.NoError(t, yaml.Unmarshal([]byte(`{responses: [hi], latency: {mean: 50ms}}`), &config))
+	require.Equal(t, "mock", config.Model)
+	require.Equal(t, DistributionFixed, config.Latency.Distribution)
+	require.Equal(t, 50*time.Millisecond, config.Latency.Mean)
+}
@@ -0,0 +1,68 @@
+// Package mock is a provider that serves canned responses without calling any API, so router configs, fallbacks
+// & routing strategies could be tried out without spending provider credits and the gateway itself could be load tested
+package mock
+
+import (
+	"time"
+)
+
+// Latency distributions
+const (
+	DistributionFixed  = "fixed"
+	DistributionNormal = "normal"
+	DistributionPareto = "pareto" // long-tailed latencies (most responses are fast, but some are way slower)
+)
+
+// LatencyConfig defines how long mock responses take
+type LatencyConfig struct {
+	Distribution string        `yaml:"distribution" json:"distribution" validate:"oneof=fixed normal pareto"`
+	Mean         time.Duration `yaml:"mean" json:"mean" swaggertype:"primitive,integer" validate:"gte=0"`                     // the average time to the first token
+	StdDev       time.Duration `yaml:"std_dev,omitempty" json:"std_dev" swaggertype:"primitive,integer" validate:"gte=0"`     // the standard deviation of the normal distribution
+	Shape        float64       `yaml:"shape,omitempty" json:"shape" validate:"gt=1"`                                          // the shape of the pareto distribution (the lower, the longer the tail)
+	PerToken     time.Duration `yaml:"per_token,omitempty" json:"per_token" swaggertype:"primitive,integer" validate:"gte=0"` // the time to generate each response token
+}
+
+func DefaultLatencyConfig() *LatencyConfig {
+	return &LatencyConfig{
+		Distribution: DistributionFixed,
+		Shape:        2,
+	}
+}
+
+func (c *LatencyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultLatencyConfig()
+
+	type plain LatencyConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+type Config struct {
+	Model string `yaml:"model" json:"model" validate:"required"` // reported as the model of responses
+	// Responses are served in turn. The request message is echoed back if there are no responses
+	Responses []string       `yaml:"responses,omitempty" json:"responses"`
+	Latency   *LatencyConfig `yaml:"latency,omitempty" json:"latency"` // responses are served right away if not set
+	// ErrorRate is the share of requests failing as if the provider is not available
+	ErrorRate float64 `yaml:"error_rate,omitempty" json:"error_rate" validate:"gte=0,lte=1"`
+	// RateLimitRate is the share of requests failing as if they have hit the provider rate limit
+	RateLimitRate float64 `yaml:"rate_limit_rate,omitempty" json:"rate_limit_rate" validate:"gte=0,lte=1"`
+	// RateLimitReset is how long simulated rate limits last (the default cooldown is used if not set)
+	RateLimitReset *time.Duration `yaml:"rate_limit_reset,omitempty" json:"rate_limit_reset" swaggertype:"primitive,integer"`
+	// Seed makes latencies & injected failures reproducible
+	Seed *int64 `yaml:"seed,omitempty" json:"seed"`
+}
+
+// DefaultConfig for mock models
+func DefaultConfig() *Config {
+	return &Config{
+		Model: "mock",
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
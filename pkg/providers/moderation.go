@@ -0,0 +1,43 @@
+package providers
+
+import (
+	"context"
+
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/telemetry"
+)
+
+// Moderator scores texts by moderation categories (e.g. violence or harassment).
+// Scores are from 0 to 1, the max score across texts is returned for each category
+type Moderator interface {
+	Moderate(ctx context.Context, texts []string) (map[string]float64, error)
+}
+
+// ModeratorConfig defines the moderation backend
+type ModeratorConfig struct {
+	Client *clients.ClientConfig     `yaml:"client" json:"client"`
+	OpenAI *openai.ModerationsConfig `yaml:"openai,omitempty" json:"openai,omitempty" validate:"required"`
+}
+
+func DefaultModeratorConfig() *ModeratorConfig {
+	return &ModeratorConfig{
+		Client: clients.DefaultClientConfig(),
+	}
+}
+
+func (c *ModeratorConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultModeratorConfig()
+
+	type plain ModeratorConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+func (c *ModeratorConfig) ToModerator(tel *telemetry.Telemetry) (Moderator, error) {
+	if c.OpenAI != nil {
+		return openai.NewModerator(c.OpenAI, c.Client, tel)
+	}
+
+	return nil, ErrProviderNotFound
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 
 	"glide/pkg/providers/clients"
 
@@ -29,8 +28,8 @@ type ChatRequest struct {
 	MaxTokens        int           `json:"max_tokens,omitempty"`
 	StopWords        []string      `json:"stop,omitempty"`
 	Stream           bool          `json:"stream,omitempty"`
-	FrequencyPenalty int           `json:"frequency_penalty,omitempty"`
-	PresencePenalty  int           `json:"presence_penalty,omitempty"`
+	FrequencyPenalty float64       `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64       `json:"presence_penalty,omitempty"`
 }
 
 // NewChatRequestFromConfig fills the struct from the config. Not using reflection because of performance penalty it gives
@@ -48,20 +47,27 @@ func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
 }
 
 func NewChatMessagesFromUnifiedRequest(request *schemas.UnifiedChatRequest) []ChatMessage {
-	messages := make([]ChatMessage, 0, len(request.MessageHistory)+1)
+	chatMessages := request.ChatMessages()
+	messages := make([]ChatMessage, 0, len(chatMessages))
 
-	// Add items from messageHistory first and the new chat message last
-	for _, message := range request.MessageHistory {
+	for _, message := range chatMessages {
 		messages = append(messages, ChatMessage{Role: message.Role, Content: message.Content})
 	}
 
-	messages = append(messages, ChatMessage{Role: request.Message.Role, Content: request.Message.Content})
-
 	return messages
 }
 
 // Chat sends a chat request to the specified octoml model.
 func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if request.HasTools() {
+		// tool calling is not supported yet, so the router should pick another model
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityTools)
+	}
+
+	if request.HasImages() {
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityVision)
+	}
+
 	// Create a new chat request
 	chatRequest := c.createChatRequestSchema(request)
 
@@ -79,10 +85,20 @@ func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest)
 
 func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *ChatRequest {
 	// TODO: consider using objectpool to optimize memory allocation
-	chatRequest := c.chatRequestTemplate // hoping to get a copy of the template
+	chatRequest := *c.chatRequestTemplate // copy the template
 	chatRequest.Messages = NewChatMessagesFromUnifiedRequest(request)
 
-	return chatRequest
+	if request.PresencePenalty != nil {
+		chatRequest.PresencePenalty = *request.PresencePenalty
+	}
+
+	if request.FrequencyPenalty != nil {
+		chatRequest.FrequencyPenalty = *request.FrequencyPenalty
+	}
+
+	c.applyParamOverrides(&chatRequest, request.Override.Params)
+
+	return &chatRequest
 }
 
 func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*schemas.UnifiedChatResponse, error) {
@@ -97,7 +113,7 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, fmt.Errorf("unable to create octoml chat request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+string(c.config.APIKey))
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
 	req.Header.Set("Content-Type", "application/json")
 
 	// TODO: this could leak information from messages which may not be a desired thing to have
@@ -128,20 +144,11 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		)
 
 		if resp.StatusCode == http.StatusTooManyRequests {
-			// Read the value of the "Retry-After" header to get the cooldown delay
-			retryAfter := resp.Header.Get("Retry-After")
-
-			// Parse the value to get the duration
-			cooldownDelay, err := time.ParseDuration(retryAfter)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse cooldown delay from headers: %w", err)
-			}
-
-			return nil, clients.NewRateLimitError(&cooldownDelay)
+			return nil, clients.NewRetryAfterError(resp.Header)
 		}
 
 		// Server & client errors result in the same error to keep gateway resilient
-		return nil, clients.ErrProviderUnavailable
+		return nil, clients.NewProviderError(resp.StatusCode)
 	}
 
 	// Read the response body into a byte slice
@@ -186,3 +193,30 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 
 	return &response, nil
 }
+
+// applyParamOverrides merges per-request params over the default ones. Params the provider doesn't support are dropped
+func (c *Client) applyParamOverrides(chatRequest *ChatRequest, params *schemas.ChatParams) {
+	if params == nil {
+		return
+	}
+
+	if params.Temperature != nil {
+		chatRequest.Temperature = min(*params.Temperature, 2)
+	}
+
+	if params.TopP != nil {
+		chatRequest.TopP = *params.TopP
+	}
+
+	if params.MaxTokens != nil {
+		chatRequest.MaxTokens = *params.MaxTokens
+	}
+
+	if params.Stop != nil {
+		chatRequest.StopWords = params.Stop
+	}
+
+	if params.TopK != nil {
+		c.telemetry.Logger.Debug("top_k param is not supported by the provider, dropping it", zap.String("provider", providerName))
+	}
+}
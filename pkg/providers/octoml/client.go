@@ -1,7 +1,9 @@
 package octoml
 
 import (
+	"context"
 	"errors"
+	"glide/pkg/api/schemas"
 	"net/http"
 	"net/url"
 
@@ -35,20 +37,18 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 		return nil, err
 	}
 
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Client{
 		baseURL:             providerConfig.BaseURL,
 		chatURL:             chatURL,
 		config:              providerConfig,
 		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
-		httpClient: &http.Client{
-			Timeout: *clientConfig.Timeout,
-			// TODO: use values from the config
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 2,
-			},
-		},
-		telemetry: tel,
+		httpClient:          httpClient,
+		telemetry:           tel,
 	}
 
 	return c, nil
@@ -57,3 +57,13 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 func (c *Client) Provider() string {
 	return providerName
 }
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	return param == schemas.ParamPresencePenalty || param == schemas.ParamFrequencyPenalty
+}
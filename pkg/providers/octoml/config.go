@@ -11,8 +11,8 @@ type Params struct {
 	TopP             float64  `yaml:"top_p,omitempty" json:"top_p"`
 	MaxTokens        int      `yaml:"max_tokens,omitempty" json:"max_tokens"`
 	StopWords        []string `yaml:"stop,omitempty" json:"stop"`
-	FrequencyPenalty int      `yaml:"frequency_penalty,omitempty" json:"frequency_penalty"`
-	PresencePenalty  int      `yaml:"presence_penalty,omitempty" json:"presence_penalty"`
+	FrequencyPenalty float64  `yaml:"frequency_penalty,omitempty" json:"frequency_penalty" validate:"gte=-2,lte=2"`
+	PresencePenalty  float64  `yaml:"presence_penalty,omitempty" json:"presence_penalty" validate:"gte=-2,lte=2"`
 	// Stream           bool             `json:"stream,omitempty"` // TODO: we are not supporting this at the moment
 }
 
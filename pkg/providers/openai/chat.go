@@ -7,7 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strconv"
 
 	"glide/pkg/providers/clients"
 
@@ -16,60 +16,142 @@ import (
 )
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role         string             `json:"role"`
+	Content      string             `json:"content"`
+	ToolCalls    []schemas.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID   string             `json:"tool_call_id,omitempty"`
+	ContentParts []ContentPart      `json:"-"` // replaces the content in the payload if the message has images
 }
 
 // ChatRequest is an OpenAI-specific request schema
 type ChatRequest struct {
-	Model            string           `json:"model"`
-	Messages         []ChatMessage    `json:"messages"`
-	Temperature      float64          `json:"temperature,omitempty"`
-	TopP             float64          `json:"top_p,omitempty"`
-	MaxTokens        int              `json:"max_tokens,omitempty"`
-	N                int              `json:"n,omitempty"`
-	StopWords        []string         `json:"stop,omitempty"`
-	Stream           bool             `json:"stream,omitempty"`
-	FrequencyPenalty int              `json:"frequency_penalty,omitempty"`
-	PresencePenalty  int              `json:"presence_penalty,omitempty"`
-	LogitBias        *map[int]float64 `json:"logit_bias,omitempty"`
-	User             *string          `json:"user,omitempty"`
-	Seed             *int             `json:"seed,omitempty"`
-	Tools            []string         `json:"tools,omitempty"`
-	ToolChoice       interface{}      `json:"tool_choice,omitempty"`
-	ResponseFormat   interface{}      `json:"response_format,omitempty"`
+	Model            string                   `json:"model"`
+	Messages         []ChatMessage            `json:"messages"`
+	Temperature      float64                  `json:"temperature,omitempty"`
+	TopP             float64                  `json:"top_p,omitempty"`
+	MaxTokens        int                      `json:"max_tokens,omitempty"`
+	N                int                      `json:"n,omitempty"`
+	StopWords        []string                 `json:"stop,omitempty"`
+	Stream           bool                     `json:"stream,omitempty"`
+	FrequencyPenalty float64                  `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64                  `json:"presence_penalty,omitempty"`
+	LogitBias        *map[int]float64         `json:"logit_bias,omitempty"`
+	User             *string                  `json:"user,omitempty"`
+	Seed             *int                     `json:"seed,omitempty"`
+	Logprobs         bool                     `json:"logprobs,omitempty"`
+	TopLogprobs      *int                     `json:"top_logprobs,omitempty"`
+	Tools            []schemas.ToolDefinition `json:"tools,omitempty"`
+	ToolChoice       interface{}              `json:"tool_choice,omitempty"`
+	ResponseFormat   interface{}              `json:"response_format,omitempty"`
 }
 
 // NewChatRequestFromConfig fills the struct from the config. Not using reflection because of performance penalty it gives
 func NewChatRequestFromConfig(cfg *Config) *ChatRequest {
+	return NewChatRequestFromParams(cfg.Model, cfg.DefaultParams)
+}
+
+// NewChatRequestFromParams fills the request template of OpenAI-compatible providers that share OpenAI params
+func NewChatRequestFromParams(model string, params *Params) *ChatRequest {
 	return &ChatRequest{
-		Model:            cfg.Model,
-		Temperature:      cfg.DefaultParams.Temperature,
-		TopP:             cfg.DefaultParams.TopP,
-		MaxTokens:        cfg.DefaultParams.MaxTokens,
-		N:                cfg.DefaultParams.N,
-		StopWords:        cfg.DefaultParams.StopWords,
+		Model:            model,
+		Temperature:      params.Temperature,
+		TopP:             params.TopP,
+		MaxTokens:        params.MaxTokens,
+		N:                params.N,
+		StopWords:        params.StopWords,
 		Stream:           false, // unsupported right now
-		FrequencyPenalty: cfg.DefaultParams.FrequencyPenalty,
-		PresencePenalty:  cfg.DefaultParams.PresencePenalty,
-		LogitBias:        cfg.DefaultParams.LogitBias,
-		User:             cfg.DefaultParams.User,
-		Seed:             cfg.DefaultParams.Seed,
-		Tools:            cfg.DefaultParams.Tools,
-		ToolChoice:       cfg.DefaultParams.ToolChoice,
-		ResponseFormat:   cfg.DefaultParams.ResponseFormat,
+		FrequencyPenalty: params.FrequencyPenalty,
+		PresencePenalty:  params.PresencePenalty,
+		LogitBias:        params.LogitBias,
+		User:             params.User,
+		Seed:             params.Seed,
+		Tools:            params.Tools,
+		ToolChoice:       params.ToolChoice,
+		ResponseFormat:   params.ResponseFormat,
 	}
 }
 
-func NewChatMessagesFromUnifiedRequest(request *schemas.UnifiedChatRequest) []ChatMessage {
-	messages := make([]ChatMessage, 0, len(request.MessageHistory)+1)
+// NewChatRequest fills the copy of the request template with the unified request.
+// Optional params are passed only if the provider could translate them (see SupportsParam of provider clients),
+// while per-request param overrides are merged last
+func NewChatRequest(
+	template *ChatRequest,
+	request *schemas.UnifiedChatRequest,
+	supportsParam func(param string) bool,
+	logger *zap.Logger,
+	provider string,
+) *ChatRequest {
+	// TODO: consider using objectpool to optimize memory allocation
+	chatRequest := *template // copy the template
+	chatRequest.Messages = NewChatMessagesFromUnifiedRequest(request)
+
+	if request.Seed != nil && supportsParam(schemas.ParamSeed) {
+		chatRequest.Seed = request.Seed
+	}
+
+	if request.User != "" {
+		chatRequest.User = &request.User
+	}
+
+	if request.PresencePenalty != nil && supportsParam(schemas.ParamPresencePenalty) {
+		chatRequest.PresencePenalty = *request.PresencePenalty
+	}
+
+	if request.FrequencyPenalty != nil && supportsParam(schemas.ParamFrequencyPenalty) {
+		chatRequest.FrequencyPenalty = *request.FrequencyPenalty
+	}
+
+	if request.N > 0 && supportsParam(schemas.ParamN) {
+		chatRequest.N = request.N
+	}
 
-	// Add items from messageHistory first and the new chat message last
-	for _, message := range request.MessageHistory {
-		messages = append(messages, ChatMessage{Role: message.Role, Content: message.Content})
+	if len(request.LogitBias) > 0 && supportsParam(schemas.ParamLogitBias) {
+		chatRequest.LogitBias = NewLogitBias(request.LogitBias)
 	}
 
-	messages = append(messages, ChatMessage{Role: request.Message.Role, Content: request.Message.Content})
+	if request.LogprobsRequested() && supportsParam(schemas.ParamLogprobs) {
+		// top_logprobs is only accepted along with logprobs
+		chatRequest.Logprobs = true
+		chatRequest.TopLogprobs = request.TopLogprobs
+	}
+
+	ApplyTools(&chatRequest, request)
+	if request.ResponseFormat != nil {
+		// the unified response format follows the OpenAI one
+		chatRequest.ResponseFormat = request.ResponseFormat
+	}
+
+	ApplyParamOverrides(&chatRequest, request.Override.Params, logger, provider)
+
+	return &chatRequest
+}
+
+// NewLogitBias translates the unified logit bias into the OpenAI one (keys are validated to be token IDs beforehand)
+func NewLogitBias(logitBias map[string]float64) *map[int]float64 {
+	bias := make(map[int]float64, len(logitBias))
+
+	for tokenID, value := range logitBias {
+		if id, err := strconv.Atoi(tokenID); err == nil {
+			bias[id] = value
+		}
+	}
+
+	return &bias
+}
+
+func NewChatMessagesFromUnifiedRequest(request *schemas.UnifiedChatRequest) []ChatMessage {
+	chatMessages := request.ChatMessages()
+	messages := make([]ChatMessage, 0, len(chatMessages))
+
+	for _, message := range chatMessages {
+		messages = append(messages, ChatMessage{
+			Role:         message.Role,
+			Content:      message.Content,
+			ToolCalls:    message.ToolCalls,
+			ToolCallID:   message.ToolCallID,
+			ContentParts: NewContentParts(message.ContentParts),
+		})
+	}
 
 	return messages
 }
@@ -84,7 +166,7 @@ func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest)
 		return nil, err
 	}
 
-	if len(chatResponse.ModelResponse.Message.Content) == 0 {
+	if len(chatResponse.ModelResponse.Message.Content) == 0 && len(chatResponse.ModelResponse.Message.ToolCalls) == 0 {
 		return nil, ErrEmptyResponse
 	}
 
@@ -92,11 +174,7 @@ func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest)
 }
 
 func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *ChatRequest {
-	// TODO: consider using objectpool to optimize memory allocation
-	chatRequest := c.chatRequestTemplate // hoping to get a copy of the template
-	chatRequest.Messages = NewChatMessagesFromUnifiedRequest(request)
-
-	return chatRequest
+	return NewChatRequest(c.chatRequestTemplate, request, c.SupportsParam, c.telemetry.Logger, providerName)
 }
 
 func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*schemas.UnifiedChatResponse, error) {
@@ -111,7 +189,7 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, fmt.Errorf("unable to create openai chat request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+string(c.config.APIKey))
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
 	req.Header.Set("Content-Type", "application/json")
 
 	// TODO: this could leak information from messages which may not be a desired thing to have
@@ -142,20 +220,11 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		)
 
 		if resp.StatusCode == http.StatusTooManyRequests {
-			// Read the value of the "Retry-After" header to get the cooldown delay
-			retryAfter := resp.Header.Get("Retry-After")
-
-			// Parse the value to get the duration
-			cooldownDelay, err := time.ParseDuration(retryAfter)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse cooldown delay from headers: %w", err)
-			}
-
-			return nil, clients.NewRateLimitError(&cooldownDelay)
+			return nil, clients.NewRetryAfterError(resp.Header)
 		}
 
 		// Server & client errors result in the same error to keep gateway resilient
-		return nil, clients.ErrProviderUnavailable
+		return nil, clients.NewProviderError(resp.StatusCode)
 	}
 
 	// Read the response body into a byte slice
@@ -174,29 +243,92 @@ func (c *Client) doChatRequest(ctx context.Context, payload *ChatRequest) (*sche
 		return nil, err
 	}
 
-	// Map response to UnifiedChatResponse schema
+	return NewUnifiedChatResponse(&openAICompletion, providerName), nil
+}
+
+// NewUnifiedChatResponse maps OpenAI chat completion to UnifiedChatResponse schema
+func NewUnifiedChatResponse(completion *schemas.OpenAIChatCompletion, provider string) *schemas.UnifiedChatResponse {
 	response := schemas.UnifiedChatResponse{
-		ID:       openAICompletion.ID,
-		Created:  openAICompletion.Created,
-		Provider: providerName,
-		Model:    openAICompletion.Model,
+		ID:       completion.ID,
+		Created:  completion.Created,
+		Provider: provider,
+		Model:    completion.Model,
 		Cached:   false,
 		ModelResponse: schemas.ProviderResponse{
 			SystemID: map[string]string{
-				"system_fingerprint": openAICompletion.SystemFingerprint,
+				"system_fingerprint": completion.SystemFingerprint,
 			},
 			Message: schemas.ChatMessage{
-				Role:    openAICompletion.Choices[0].Message.Role,
-				Content: openAICompletion.Choices[0].Message.Content,
-				Name:    "",
+				Role:      completion.Choices[0].Message.Role,
+				Content:   completion.Choices[0].Message.Content,
+				Name:      "",
+				ToolCalls: completion.Choices[0].Message.ToolCalls,
 			},
+			Choices:           NewChoices(completion),
+			SystemFingerprint: completion.SystemFingerprint,
+			Logprobs:          newLogprobs(completion.Choices[0].Logprobs),
 			TokenUsage: schemas.TokenUsage{
-				PromptTokens:   openAICompletion.Usage.PromptTokens,
-				ResponseTokens: openAICompletion.Usage.CompletionTokens,
-				TotalTokens:    openAICompletion.Usage.TotalTokens,
+				PromptTokens:   completion.Usage.PromptTokens,
+				ResponseTokens: completion.Usage.CompletionTokens,
+				TotalTokens:    completion.Usage.TotalTokens,
 			},
 		},
 	}
 
-	return &response, nil
+	return &response
+}
+
+// newLogprobs passes the logprobs payload as is. OpenAI sends null when logprobs have not been requested
+func newLogprobs(logprobs json.RawMessage) json.RawMessage {
+	if len(logprobs) == 0 || string(logprobs) == "null" {
+		return nil
+	}
+
+	return logprobs
+}
+
+// NewChoices maps completions when there are several of them (nil otherwise)
+func NewChoices(completion *schemas.OpenAIChatCompletion) []schemas.ChatMessage {
+	if len(completion.Choices) < 2 {
+		return nil
+	}
+
+	choices := make([]schemas.ChatMessage, 0, len(completion.Choices))
+
+	for _, choice := range completion.Choices {
+		choices = append(choices, schemas.ChatMessage{
+			Role:      choice.Message.Role,
+			Content:   choice.Message.Content,
+			ToolCalls: choice.Message.ToolCalls,
+		})
+	}
+
+	return choices
+}
+
+// ApplyParamOverrides merges per-request params over the default ones. Params the provider doesn't support are dropped
+func ApplyParamOverrides(chatRequest *ChatRequest, params *schemas.ChatParams, logger *zap.Logger, provider string) {
+	if params == nil {
+		return
+	}
+
+	if params.Temperature != nil {
+		chatRequest.Temperature = min(*params.Temperature, 2)
+	}
+
+	if params.TopP != nil {
+		chatRequest.TopP = *params.TopP
+	}
+
+	if params.MaxTokens != nil {
+		chatRequest.MaxTokens = *params.MaxTokens
+	}
+
+	if params.Stop != nil {
+		chatRequest.StopWords = params.Stop
+	}
+
+	if params.TopK != nil {
+		logger.Debug("top_k param is not supported by the provider, dropping it", zap.String("provider", provider))
+	}
 }
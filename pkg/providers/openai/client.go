@@ -1,10 +1,12 @@
 package openai
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/url"
 
+	"glide/pkg/api/schemas"
 	"glide/pkg/providers/clients"
 	"glide/pkg/telemetry"
 )
@@ -29,6 +31,7 @@ type Client struct {
 	chatRequestTemplate *ChatRequest
 	config              *Config
 	httpClient          *http.Client
+	tokenizer           clients.Tokenizer
 	telemetry           *telemetry.Telemetry
 }
 
@@ -39,20 +42,19 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 		return nil, err
 	}
 
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Client{
 		baseURL:             providerConfig.BaseURL,
 		chatURL:             chatURL,
 		config:              providerConfig,
 		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
-		httpClient: &http.Client{
-			Timeout: *clientConfig.Timeout,
-			// TODO: use values from the config
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 2,
-			},
-		},
-		telemetry: tel,
+		httpClient:          httpClient,
+		tokenizer:           clients.NewTiktokenTokenizer(providerConfig.Model),
+		telemetry:           tel,
 	}
 
 	return c, nil
@@ -61,3 +63,44 @@ func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *
 func (c *Client) Provider() string {
 	return providerName
 }
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	switch param {
+	case schemas.ParamSeed, schemas.ParamPresencePenalty, schemas.ParamFrequencyPenalty, schemas.ParamN, schemas.ParamLogitBias,
+		schemas.ParamLogprobs:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaxStopSequences is the number of stop sequences OpenAI takes per request
+func (c *Client) MaxStopSequences() int {
+	return 4
+}
+
+// Tokenizer returns the tiktoken tokenizer of the model
+func (c *Client) Tokenizer() clients.Tokenizer {
+	return c.tokenizer
+}
+
+// SupportsTools reports whether the client could translate tools of the unified chat request
+func (c *Client) SupportsTools() bool {
+	return true
+}
+
+// SupportsVision reports whether the client could translate images of the unified chat request
+func (c *Client) SupportsVision() bool {
+	return true
+}
+
+// SupportsResponseFormat reports whether the client could translate the response format (JSON mode & structured output)
+func (c *Client) SupportsResponseFormat() bool {
+	return true
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"glide/pkg/providers/clients"
 
@@ -65,3 +66,542 @@ func TestOpenAIClient_ChatRequest(t *testing.T) {
 
 	require.Equal(t, "chatcmpl-123", response.ID)
 }
+
+func TestOpenAIClient_RateLimitRetryAfter(t *testing.T) {
+	retryAt := time.Now().Add(3 * time.Minute).UTC()
+
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", retryAt.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = openAIServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	_, err = client.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+
+	var rateLimitErr *clients.RateLimitError
+
+	require.ErrorAs(t, err, &rateLimitErr)
+	require.True(t, rateLimitErr.FromRetryAfter())
+	require.InDelta(t, 3*time.Minute, rateLimitErr.UntilReset(), float64(2*time.Second))
+}
+
+func TestOpenAIClient_SeedForwarded(t *testing.T) {
+	seed := 42
+
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data ChatRequest
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.NotNil(t, data.Seed)
+		require.Equal(t, seed, *data.Seed)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.success.json"))
+		if err != nil {
+			t.Errorf("error reading openai chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = openAIServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.Seed = &seed
+
+	response, err := client.Chat(context.Background(), request)
+	require.NoError(t, err)
+
+	require.Equal(t, "fp_44709d6fcb", response.ModelResponse.SystemID["system_fingerprint"])
+	require.Equal(t, "fp_44709d6fcb", response.ModelResponse.SystemFingerprint)
+	require.Nil(t, response.ModelResponse.Logprobs)
+	require.Nil(t, client.chatRequestTemplate.Seed)
+}
+
+func TestOpenAIClient_LogprobsForwarded(t *testing.T) {
+	topLogprobs := 2
+
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data ChatRequest
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		// top_logprobs implies logprobs
+		require.True(t, data.Logprobs)
+		require.Equal(t, topLogprobs, *data.TopLogprobs)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.logprobs.json"))
+		if err != nil {
+			t.Errorf("error reading openai chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = openAIServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.NewChatFromStr("What's the biggest animal? Answer in one word")
+	request.TopLogprobs = &topLogprobs
+
+	response, err := client.Chat(context.Background(), request)
+	require.NoError(t, err)
+
+	require.Equal(t, "fp_845eaabc1f", response.ModelResponse.SystemFingerprint)
+
+	var logprobs struct {
+		Content []struct {
+			Token       string     `json:"token"`
+			TopLogprobs []struct{} `json:"top_logprobs"`
+		} `json:"content"`
+	}
+
+	require.NoError(t, json.Unmarshal(response.ModelResponse.Logprobs, &logprobs))
+	require.Len(t, logprobs.Content, 1)
+	require.Equal(t, "Whale", logprobs.Content[0].Token)
+	require.Len(t, logprobs.Content[0].TopLogprobs, 2)
+}
+
+func TestOpenAIClient_MultipleCompletions(t *testing.T) {
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data ChatRequest
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Equal(t, 2, data.N)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.n.json"))
+		if err != nil {
+			t.Errorf("error reading openai chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = openAIServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.N = 2
+
+	response, err := client.Chat(context.Background(), request)
+	require.NoError(t, err)
+
+	require.Equal(t, "The blue whale.", response.ModelResponse.Message.Content)
+	require.Len(t, response.ModelResponse.Choices, 2)
+	require.Equal(t, "It's the blue whale.", response.ModelResponse.Choices[1].Content)
+	require.Equal(t, float64(20), response.ModelResponse.TokenUsage.TotalTokens)
+}
+
+func TestOpenAIClient_ParamOverrides(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	temperature, topK, maxTokens := 1.5, 10, 100
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.Override.Params = &schemas.ChatParams{
+		Temperature: &temperature,
+		TopK:        &topK,
+		MaxTokens:   &maxTokens,
+		Stop:        []string{"\n"},
+	}
+
+	chatRequest := client.createChatRequestSchema(request)
+
+	require.Equal(t, 1.5, chatRequest.Temperature)
+	require.Equal(t, 100, chatRequest.MaxTokens)
+	require.Equal(t, []string{"\n"}, chatRequest.StopWords)
+
+	// overrides don't leak into the following requests
+	defaultRequest := client.createChatRequestSchema(schemas.NewChatFromStr("What's the biggest animal?"))
+
+	require.Equal(t, DefaultParams().Temperature, defaultRequest.Temperature)
+	require.Equal(t, DefaultParams().MaxTokens, defaultRequest.MaxTokens)
+}
+
+func TestNewChatRequest_DropsParamsProviderDoesNotSupport(t *testing.T) {
+	seed, presencePenalty := 42, 0.5
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.Seed = &seed
+	request.PresencePenalty = &presencePenalty
+	request.LogitBias = map[string]float64{"50256": -100}
+
+	template := NewChatRequestFromConfig(DefaultConfig())
+	supportsParam := func(param string) bool {
+		return param == schemas.ParamPresencePenalty
+	}
+
+	chatRequest := NewChatRequest(template, request, supportsParam, telemetry.NewTelemetryMock().Logger, providerName)
+
+	require.Equal(t, 0.5, chatRequest.PresencePenalty)
+	require.Nil(t, chatRequest.Seed)
+	require.Nil(t, chatRequest.LogitBias)
+	require.Len(t, chatRequest.Messages, 1)
+
+	// the template is copied rather than filled in
+	require.Nil(t, template.Messages)
+}
+
+func TestOpenAIClient_User(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.User = "user-123"
+
+	rawPayload, err := json.Marshal(client.createChatRequestSchema(request))
+	require.NoError(t, err)
+
+	var payload map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(rawPayload, &payload))
+	require.Equal(t, "user-123", payload["user"])
+
+	// nothing is sent when the user is not set
+	rawPayload, err = json.Marshal(client.createChatRequestSchema(schemas.NewChatFromStr("What's the biggest animal?")))
+	require.NoError(t, err)
+
+	payload = nil
+
+	require.NoError(t, json.Unmarshal(rawPayload, &payload))
+	require.NotContains(t, payload, "user")
+}
+
+func TestOpenAIClient_ToolCalls(t *testing.T) {
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data ChatRequest
+
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Len(t, data.Tools, 1)
+		require.Equal(t, "get_current_weather", data.Tools[0].Function.Name)
+		require.Equal(t, map[string]interface{}{
+			"type":     "function",
+			"function": map[string]interface{}{"name": "get_current_weather"},
+		}, data.ToolChoice)
+
+		require.Len(t, data.Messages, 3)
+		require.Equal(t, "call_abc000", data.Messages[1].ToolCalls[0].ID)
+		require.Equal(t, schemas.RoleTool, data.Messages[2].Role)
+		require.Equal(t, "call_abc000", data.Messages[2].ToolCallID)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.tool_calls.json"))
+		if err != nil {
+			t.Errorf("error reading openai chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	providerCfg := DefaultConfig()
+	providerCfg.BaseURL = openAIServer.URL
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{
+			{Role: schemas.RoleUser, Content: "What's the weather like in Boston?"},
+			{Role: schemas.RoleAssistant, ToolCalls: []schemas.ToolCall{{
+				ID:       "call_abc000",
+				Type:     schemas.ToolTypeFunction,
+				Function: schemas.FunctionCall{Name: "get_current_weather", Arguments: `{"location": "Boston"}`},
+			}}},
+			{Role: schemas.RoleTool, ToolCallID: "call_abc000", Content: "Not found, please specify the state"},
+		},
+		Tools: []schemas.ToolDefinition{{
+			Type: schemas.ToolTypeFunction,
+			Function: schemas.FunctionDefinition{
+				Name: "get_current_weather",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"location": map[string]interface{}{"type": "string"}},
+				},
+			},
+		}},
+		ToolChoice: &schemas.ToolChoice{Type: schemas.ToolChoiceFunction, Name: "get_current_weather"},
+	}
+
+	response, err := client.Chat(context.Background(), &request)
+	require.NoError(t, err)
+
+	require.Empty(t, response.ModelResponse.Message.Content)
+	require.Equal(t, []schemas.ToolCall{{
+		ID:       "call_abc123",
+		Type:     schemas.ToolTypeFunction,
+		Function: schemas.FunctionCall{Name: "get_current_weather", Arguments: "{\n\"location\": \"Boston, MA\"\n}"},
+	}}, response.ModelResponse.Message.ToolCalls)
+}
+
+func TestOpenAIClient_PenaltiesTranslated(t *testing.T) {
+	providerCfg := DefaultConfig()
+	providerCfg.DefaultParams.PresencePenalty = 0.5
+
+	client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	frequencyPenalty := 1.2
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.FrequencyPenalty = &frequencyPenalty
+
+	chatRequest := client.createChatRequestSchema(request)
+
+	// the configured penalty is kept when the request doesn't override it
+	require.InDelta(t, 0.5, chatRequest.PresencePenalty, 0.0001)
+	require.InDelta(t, 1.2, chatRequest.FrequencyPenalty, 0.0001)
+
+	rawPayload, err := json.Marshal(chatRequest)
+	require.NoError(t, err)
+	require.Contains(t, string(rawPayload), `"frequency_penalty":1.2`)
+	require.Contains(t, string(rawPayload), `"presence_penalty":0.5`)
+
+	require.True(t, client.SupportsParam(schemas.ParamPresencePenalty))
+	require.True(t, client.SupportsParam(schemas.ParamFrequencyPenalty))
+}
+
+func TestOpenAIClient_PenaltiesValidated(t *testing.T) {
+	penalty := -2.5
+
+	request := schemas.NewChatFromStr("What's the biggest animal?")
+	request.PresencePenalty = &penalty
+
+	require.ErrorIs(t, request.Validate(), schemas.ErrInvalidChatParams)
+
+	penalty = -2
+
+	require.NoError(t, request.Validate())
+}
+
+func TestOpenAIClient_LogitBiasTranslated(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.NewChatFromStr("Is the review positive? Answer yes or no")
+	request.LogitBias = map[string]float64{"9891": 100, "2201": -50.5}
+
+	require.NoError(t, request.Validate())
+
+	chatRequest := client.createChatRequestSchema(request)
+
+	require.Equal(t, map[int]float64{9891: 100, 2201: -50.5}, *chatRequest.LogitBias)
+
+	rawPayload, err := json.Marshal(chatRequest)
+	require.NoError(t, err)
+	require.Contains(t, string(rawPayload), `"logit_bias":{"2201":-50.5,"9891":100}`)
+
+	require.True(t, client.SupportsParam(schemas.ParamLogitBias))
+}
+
+func TestOpenAIClient_LogitBiasValidated(t *testing.T) {
+	request := schemas.NewChatFromStr("Is the review positive? Answer yes or no")
+
+	request.LogitBias = map[string]float64{"9891": 100.5}
+	require.ErrorIs(t, request.Validate(), schemas.ErrInvalidChatParams)
+
+	request.LogitBias = map[string]float64{"yes": 100}
+	require.ErrorIs(t, request.Validate(), schemas.ErrInvalidChatParams)
+
+	request.LogitBias = map[string]float64{"9891": -100}
+	require.NoError(t, request.Validate())
+}
+
+func TestOpenAIClient_TopLogprobsValidated(t *testing.T) {
+	request := schemas.NewChatFromStr("What's the biggest animal? Answer in one word")
+
+	topLogprobs := 21
+	request.TopLogprobs = &topLogprobs
+	require.ErrorIs(t, request.Validate(), schemas.ErrInvalidChatParams)
+
+	topLogprobs = 20
+	require.NoError(t, request.Validate())
+}
+
+func TestOpenAIClient_ContentPartsTranslated(t *testing.T) {
+	client, err := NewClient(DefaultConfig(), clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	var request schemas.UnifiedChatRequest
+
+	err = json.Unmarshal([]byte(`{"messages": [
+		{"role": "system", "content": "You are a helpful assistant."},
+		{"role": "user", "content": [
+			{"type": "text", "text": "What's in these images?"},
+			{"type": "image_url", "image_url": {"url": "https://example.com/cat.png", "detail": "low"}},
+			{"type": "image_base64", "image_base64": {"media_type": "image/png", "data": "iVBORw0KGgo="}}
+		]}
+	]}`), &request)
+	require.NoError(t, err)
+	require.NoError(t, request.Validate())
+
+	require.True(t, request.HasImages())
+	require.Equal(t, "What's in these images?", request.Messages[1].Content)
+
+	rawPayload, err := json.Marshal(client.createChatRequestSchema(&request))
+	require.NoError(t, err)
+
+	var data struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+
+	require.NoError(t, json.Unmarshal(rawPayload, &data))
+	require.JSONEq(t, `"You are a helpful assistant."`, string(data.Messages[0].Content))
+	require.JSONEq(t, `[
+		{"type": "text", "text": "What's in these images?"},
+		{"type": "image_url", "image_url": {"url": "https://example.com/cat.png", "detail": "low"}},
+		{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw0KGgo="}}
+	]`, string(data.Messages[1].Content))
+}
+
+func TestOpenAIEmbedder_Embed(t *testing.T) {
+	// OpenAI Embeddings API: https://platform.openai.com/docs/api-reference/embeddings/create
+	var embeddingsRequest EmbeddingsRequest
+
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		err := json.Unmarshal(rawPayload, &embeddingsRequest)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		embeddingsResponse, err := os.ReadFile(filepath.Clean("./testdata/embeddings.success.json"))
+		if err != nil {
+			t.Errorf("error reading openai embeddings mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(embeddingsResponse)
+		if err != nil {
+			t.Errorf("error on sending embeddings response: %v", err)
+		}
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	providerCfg := DefaultEmbeddingsConfig()
+	providerCfg.BaseURL = openAIServer.URL
+
+	embedder, err := NewEmbedder(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	vector, err := embedder.Embed(context.Background(), "What's the biggest animal?")
+	require.NoError(t, err)
+
+	require.Equal(t, []float64{0.0023064255, -0.009327292, 0.015797347}, vector)
+	require.Equal(t, "text-embedding-3-small", embeddingsRequest.Model)
+	require.Equal(t, "What's the biggest animal?", embeddingsRequest.Input)
+}
+
+func TestOpenAIModerator_Moderate(t *testing.T) {
+	// OpenAI Moderations API: https://platform.openai.com/docs/api-reference/moderations/create
+	var moderationsRequest ModerationsRequest
+
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		err := json.Unmarshal(rawPayload, &moderationsRequest)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		moderationsResponse, err := os.ReadFile(filepath.Clean("./testdata/moderations.success.json"))
+		if err != nil {
+			t.Errorf("error reading openai moderations mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(moderationsResponse)
+		if err != nil {
+			t.Errorf("error on sending moderations response: %v", err)
+		}
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	providerCfg := DefaultModerationsConfig()
+	providerCfg.BaseURL = openAIServer.URL
+
+	moderator, err := NewModerator(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	scores, err := moderator.Moderate(context.Background(), []string{"Hi there", "I'm going to hurt you"})
+	require.NoError(t, err)
+
+	// the max score of each category across all inputs
+	require.Equal(t, map[string]float64{"harassment": 0.0011, "violence": 0.91}, scores)
+	require.Equal(t, "omni-moderation-latest", moderationsRequest.Model)
+	require.Equal(t, []string{"Hi there", "I'm going to hurt you"}, moderationsRequest.Input)
+}
@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"encoding/json"
+
+	"glide/pkg/api/schemas"
+)
+
+// ContentPart is a part of OpenAI multimodal message content (https://platform.openai.com/docs/guides/vision)
+type ContentPart struct {
+	Type     string    `json:"type"` // text or image_url
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL    string `json:"url"` // a link or a base64-encoded data URL
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewContentParts translates unified content parts. OpenAI takes base64-encoded images as data URLs
+func NewContentParts(parts []schemas.ContentPart) []ContentPart {
+	if len(parts) == 0 {
+		return nil
+	}
+
+	contentParts := make([]ContentPart, 0, len(parts))
+
+	for _, part := range parts {
+		switch part.Type {
+		case schemas.ContentPartText:
+			contentParts = append(contentParts, ContentPart{Type: "text", Text: part.Text})
+		case schemas.ContentPartImageURL:
+			contentParts = append(contentParts, ContentPart{
+				Type:     "image_url",
+				ImageURL: &ImageURL{URL: part.ImageURL.URL, Detail: part.ImageURL.Detail},
+			})
+		case schemas.ContentPartImageBase64:
+			contentParts = append(contentParts, ContentPart{
+				Type: "image_url",
+				ImageURL: &ImageURL{
+					URL: "data:" + part.ImageBase64.MediaType + ";base64," + part.ImageBase64.Data,
+				},
+			})
+		}
+	}
+
+	return contentParts
+}
+
+// MarshalJSON passes the content as the list of parts if the message is multimodal
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	type plain ChatMessage // to avoid recursion
+
+	if len(m.ContentParts) == 0 {
+		return json.Marshal(plain(m))
+	}
+
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain(m), m.ContentParts})
+}
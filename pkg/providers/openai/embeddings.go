@@ -0,0 +1,133 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"glide/pkg/config/fields"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+// EmbeddingsConfig defines the OpenAI embeddings model (e.g. for the semantic cache)
+type EmbeddingsConfig struct {
+	BaseURL            string        `yaml:"baseUrl" json:"baseUrl" validate:"required"`
+	EmbeddingsEndpoint string        `yaml:"embeddingsEndpoint" json:"embeddingsEndpoint" validate:"required"`
+	Model              string        `yaml:"model" json:"model" validate:"required"`
+	APIKey             fields.Secret `yaml:"api_key" json:"-" validate:"required"`
+}
+
+func DefaultEmbeddingsConfig() *EmbeddingsConfig {
+	return &EmbeddingsConfig{
+		BaseURL:            "https://api.openai.com/v1",
+		EmbeddingsEndpoint: "/embeddings",
+		Model:              "text-embedding-3-small",
+	}
+}
+
+func (c *EmbeddingsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultEmbeddingsConfig()
+
+	type plain EmbeddingsConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// EmbeddingsRequest is an OpenAI embeddings request (https://platform.openai.com/docs/api-reference/embeddings/create)
+type EmbeddingsRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type EmbeddingsResponse struct {
+	Data []struct {
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embedder turns texts into embedding vectors via OpenAI API
+type Embedder struct {
+	embeddingsURL string
+	config        *EmbeddingsConfig
+	httpClient    *http.Client
+	telemetry     *telemetry.Telemetry
+}
+
+func NewEmbedder(providerConfig *EmbeddingsConfig, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (*Embedder, error) {
+	embeddingsURL, err := url.JoinPath(providerConfig.BaseURL, providerConfig.EmbeddingsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Embedder{
+		embeddingsURL: embeddingsURL,
+		config:        providerConfig,
+		httpClient:    httpClient,
+		telemetry:     tel,
+	}, nil
+}
+
+// Embed returns the embedding vector of the text
+func (e *Embedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	rawPayload, err := json.Marshal(EmbeddingsRequest{Model: e.config.Model, Input: text})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal openai embeddings request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.embeddingsURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create openai embeddings request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+e.config.APIKey.Value())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send openai embeddings request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openai embeddings response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		e.telemetry.Logger.Error(
+			"openai embeddings request failed",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("response", string(bodyBytes)),
+		)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, clients.NewRetryAfterError(resp.Header)
+		}
+
+		return nil, clients.NewProviderError(resp.StatusCode)
+	}
+
+	var embeddings EmbeddingsResponse
+
+	if err := json.Unmarshal(bodyBytes, &embeddings); err != nil {
+		return nil, fmt.Errorf("failed to parse openai embeddings response: %w", err)
+	}
+
+	if len(embeddings.Data) == 0 || len(embeddings.Data[0].Embedding) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return embeddings.Data[0].Embedding, nil
+}
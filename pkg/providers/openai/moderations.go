@@ -0,0 +1,144 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"glide/pkg/config/fields"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+// ModerationsConfig defines the OpenAI moderation model (e.g. for the router content moderation)
+type ModerationsConfig struct {
+	BaseURL             string        `yaml:"baseUrl" json:"baseUrl" validate:"required"`
+	ModerationsEndpoint string        `yaml:"moderationsEndpoint" json:"moderationsEndpoint" validate:"required"`
+	Model               string        `yaml:"model" json:"model" validate:"required"`
+	APIKey              fields.Secret `yaml:"api_key" json:"-" validate:"required"`
+}
+
+func DefaultModerationsConfig() *ModerationsConfig {
+	return &ModerationsConfig{
+		BaseURL:             "https://api.openai.com/v1",
+		ModerationsEndpoint: "/moderations",
+		Model:               "omni-moderation-latest",
+	}
+}
+
+func (c *ModerationsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultModerationsConfig()
+
+	type plain ModerationsConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// ModerationsRequest is an OpenAI moderation request (https://platform.openai.com/docs/api-reference/moderations/create)
+type ModerationsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ModerationsResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Results []struct {
+		Flagged        bool               `json:"flagged"`
+		CategoryScores map[string]float64 `json:"category_scores"`
+	} `json:"results"`
+}
+
+// Moderator scores texts by moderation categories via OpenAI API
+type Moderator struct {
+	moderationsURL string
+	config         *ModerationsConfig
+	httpClient     *http.Client
+	telemetry      *telemetry.Telemetry
+}
+
+func NewModerator(providerConfig *ModerationsConfig, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (*Moderator, error) {
+	moderationsURL, err := url.JoinPath(providerConfig.BaseURL, providerConfig.ModerationsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Moderator{
+		moderationsURL: moderationsURL,
+		config:         providerConfig,
+		httpClient:     httpClient,
+		telemetry:      tel,
+	}, nil
+}
+
+// Moderate returns the max score of each category across the texts
+func (m *Moderator) Moderate(ctx context.Context, texts []string) (map[string]float64, error) {
+	rawPayload, err := json.Marshal(ModerationsRequest{Model: m.config.Model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal openai moderations request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.moderationsURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create openai moderations request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+m.config.APIKey.Value())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send openai moderations request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openai moderations response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		m.telemetry.Logger.Error(
+			"openai moderations request failed",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("response", string(bodyBytes)),
+		)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, clients.NewRetryAfterError(resp.Header)
+		}
+
+		return nil, clients.NewProviderError(resp.StatusCode)
+	}
+
+	var moderations ModerationsResponse
+
+	if err := json.Unmarshal(bodyBytes, &moderations); err != nil {
+		return nil, fmt.Errorf("failed to parse openai moderations response: %w", err)
+	}
+
+	if len(moderations.Results) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	scores := make(map[string]float64)
+
+	for _, result := range moderations.Results {
+		for category, score := range result.CategoryScores {
+			scores[category] = max(scores[category], score)
+		}
+	}
+
+	return scores, nil
+}
@@ -0,0 +1,34 @@
+package openai
+
+import "glide/pkg/api/schemas"
+
+// NewToolChoice translates the unified tool choice into the OpenAI one (a mode string or the function object)
+func NewToolChoice(toolChoice *schemas.ToolChoice) interface{} {
+	if toolChoice == nil {
+		return nil
+	}
+
+	if toolChoice.Type == schemas.ToolChoiceFunction {
+		return map[string]interface{}{
+			"type": schemas.ToolTypeFunction,
+			"function": map[string]string{
+				"name": toolChoice.Name,
+			},
+		}
+	}
+
+	return toolChoice.Type
+}
+
+// ApplyTools passes tools of the unified request as is, since the unified tool schema follows the OpenAI one
+func ApplyTools(chatRequest *ChatRequest, request *schemas.UnifiedChatRequest) {
+	if !request.HasTools() {
+		return
+	}
+
+	chatRequest.Tools = request.Tools
+
+	if request.ToolChoice != nil {
+		chatRequest.ToolChoice = NewToolChoice(request.ToolChoice)
+	}
+}
@@ -0,0 +1,138 @@
+package openaicompat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"go.uber.org/zap"
+)
+
+// NewChatRequestFromConfig fills the OpenAI request schema from the config. Not using reflection because of performance penalty it gives
+func NewChatRequestFromConfig(cfg *Config) *openai.ChatRequest {
+	return &openai.ChatRequest{
+		Model:            cfg.Model,
+		Temperature:      cfg.DefaultParams.Temperature,
+		TopP:             cfg.DefaultParams.TopP,
+		MaxTokens:        cfg.DefaultParams.MaxTokens,
+		StopWords:        cfg.DefaultParams.StopWords,
+		Stream:           false, // unsupported right now
+		FrequencyPenalty: cfg.DefaultParams.FrequencyPenalty,
+		PresencePenalty:  cfg.DefaultParams.PresencePenalty,
+		Seed:             cfg.DefaultParams.Seed,
+	}
+}
+
+// Chat sends a chat request to the specified OpenAI-compatible model.
+func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if request.HasTools() {
+		// tool calling is not supported yet, so the router should pick another model
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityTools)
+	}
+
+	if request.HasImages() {
+		return nil, clients.NewCapabilityError(providerName, clients.CapabilityVision)
+	}
+
+	// Create a new chat request
+	chatRequest := c.createChatRequestSchema(request)
+
+	chatResponse, err := c.doChatRequest(ctx, chatRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chatResponse.ModelResponse.Message.Content) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return chatResponse, nil
+}
+
+func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *openai.ChatRequest {
+	return openai.NewChatRequest(c.chatRequestTemplate, request, c.SupportsParam, c.telemetry.Logger, providerName)
+}
+
+func (c *Client) doChatRequest(ctx context.Context, payload *openai.ChatRequest) (*schemas.UnifiedChatResponse, error) {
+	// Build request payload
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal openaicompat chat request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create openaicompat chat request: %w", err)
+	}
+
+	if c.config.APIKey != "" {
+		// the header is built on each request as the API key may be rotated in place
+		req.Header.Set(c.config.authHeader())
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	// TODO: this could leak information from messages which may not be a desired thing to have
+	c.telemetry.Logger.Debug(
+		"openaicompat chat request",
+		zap.String("chat_url", c.chatURL),
+		zap.Any("payload", payload),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send openaicompat chat request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			c.telemetry.Logger.Error("failed to read openaicompat chat response", zap.Error(err))
+		}
+
+		c.telemetry.Logger.Error(
+			"openaicompat chat request failed",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("response", string(bodyBytes)),
+			zap.Any("headers", resp.Header),
+		)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// the default cooldown is used if the compatible service doesn't send the standard Retry-After header
+			return nil, clients.NewRetryAfterError(resp.Header)
+		}
+
+		// Server & client errors result in the same error to keep gateway resilient
+		return nil, clients.NewProviderError(resp.StatusCode)
+	}
+
+	// Read the response body into a byte slice
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to read openaicompat chat response", zap.Error(err))
+		return nil, err
+	}
+
+	// Parse the response JSON
+	var completion schemas.OpenAIChatCompletion
+
+	err = json.Unmarshal(bodyBytes, &completion)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to parse openaicompat chat response", zap.Error(err))
+		return nil, err
+	}
+
+	if len(completion.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return openai.NewUnifiedChatResponse(&completion, providerName), nil
+}
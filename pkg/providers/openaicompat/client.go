@@ -0,0 +1,82 @@
+package openaicompat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/telemetry"
+)
+
+const (
+	providerName = "openaicompat"
+)
+
+var (
+	// ErrEmptyResponse is returned when the OpenAI-compatible API returns an empty response.
+	ErrEmptyResponse = errors.New("empty response")
+	// ErrInvalidAuthHeader is returned when the auth header has no name
+	ErrInvalidAuthHeader = errors.New("auth header name is empty")
+)
+
+// Client is a client for accessing OpenAI-compatible APIs
+type Client struct {
+	baseURL             string
+	chatURL             string
+	chatRequestTemplate *openai.ChatRequest
+	config              *Config
+	httpClient          *http.Client
+	telemetry           *telemetry.Telemetry
+}
+
+// NewClient creates a new client for the OpenAI-compatible API.
+func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (*Client, error) {
+	chatURL, err := url.JoinPath(providerConfig.BaseURL, providerConfig.ChatEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if authHeaderName, _ := providerConfig.authHeader(); authHeaderName == "" {
+		return nil, fmt.Errorf("invalid auth header \"%v\": %w", providerConfig.AuthHeader, ErrInvalidAuthHeader)
+	}
+
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		baseURL:             providerConfig.BaseURL,
+		chatURL:             chatURL,
+		config:              providerConfig,
+		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
+		httpClient:          httpClient,
+		telemetry:           tel,
+	}
+
+	return c, nil
+}
+
+func (c *Client) Provider() string {
+	return providerName
+}
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	switch param {
+	case schemas.ParamSeed, schemas.ParamPresencePenalty, schemas.ParamFrequencyPenalty:
+		return true
+	default:
+		return false
+	}
+}
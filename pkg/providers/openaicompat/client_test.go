@@ -0,0 +1,103 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"gopkg.in/yaml.v3"
+
+	"glide/pkg/api/schemas"
+
+	"glide/pkg/telemetry"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAICompatClient_ChatRequest(t *testing.T) {
+	// OpenAI-compatible Chat API: https://platform.openai.com/docs/api-reference/chat/create
+	compatMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data openai.ChatRequest
+		// Parse the JSON body
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.Equal(t, "secret", r.Header.Get("X-API-Key"))
+		require.Equal(t, "mistralai/Mixtral-8x7B-Instruct-v0.1", data.Model)
+		require.Len(t, data.Messages, 1)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.success.json"))
+		if err != nil {
+			t.Errorf("error reading openaicompat chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	compatServer := httptest.NewServer(compatMock)
+	defer compatServer.Close()
+
+	ctx := context.Background()
+	providerCfg := DefaultConfig()
+	clientCfg := clients.DefaultClientConfig()
+
+	providerCfg.BaseURL = compatServer.URL + "/v1"
+	providerCfg.Model = "mistralai/Mixtral-8x7B-Instruct-v0.1"
+	providerCfg.APIKey = "secret"
+	providerCfg.AuthHeader = "X-API-Key"
+
+	client, err := NewClient(providerCfg, clientCfg, telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.UnifiedChatRequest{Message: schemas.ChatMessage{
+		Role:    "human",
+		Content: "What's the biggest animal?",
+	}}
+
+	response, err := client.Chat(ctx, &request)
+	require.NoError(t, err)
+
+	require.Equal(t, "chatcmpl-123", response.ID)
+	require.Equal(t, providerName, response.Provider)
+}
+
+func TestOpenAICompatClient_AuthHeader(t *testing.T) {
+	providerCfg := DefaultConfig()
+	providerCfg.APIKey = "secret"
+
+	name, value := providerCfg.authHeader()
+
+	require.Equal(t, "Authorization", name)
+	require.Equal(t, "Bearer secret", value)
+}
+
+func TestOpenAICompatConfig_UnsupportedParams(t *testing.T) {
+	var cfg Config
+
+	err := yaml.Unmarshal([]byte("base_url: http://localhost:1234/v1\nmodel: local\ndefault_params:\n  temperature: 0.5\n  logit_bias: {}\n"), &cfg)
+
+	require.ErrorIs(t, err, ErrUnsupportedParam)
+	require.ErrorContains(t, err, "logit_bias")
+
+	err = yaml.Unmarshal([]byte("base_url: http://localhost:1234/v1\nmodel: local\ndefault_params:\n  temperature: 0.5\n"), &cfg)
+
+	require.NoError(t, err)
+	require.InDelta(t, 0.5, cfg.DefaultParams.Temperature, 0.0001)
+	require.Equal(t, 100, cfg.DefaultParams.MaxTokens)
+}
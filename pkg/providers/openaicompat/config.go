@@ -0,0 +1,122 @@
+// Package openaicompat is a generic provider for services that expose OpenAI-compatible Chat Completions API
+// (e.g. LM Studio, vLLM, Anyscale Endpoints, DeepInfra, Together AI, Ollama).
+//
+// The provider assumes that the service:
+//   - accepts POST requests with OpenAI chat completion payload (model, messages, sampling params) on the chat endpoint
+//   - authenticates requests via a single header carrying the API key (if any)
+//   - responds with OpenAI chat completion schema (id, created, model, choices[].message, usage)
+//   - responds with 429 status code when the request is rate limited
+//
+// Only params that are widely supported across compatible services are allowed.
+// OpenAI-specific features like n, logit_bias, user, tools, tool_choice and response_format are rejected on config loading
+package openaicompat
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"glide/pkg/config/fields"
+)
+
+var ErrUnsupportedParam = errors.New("param is not supported by OpenAI-compatible provider")
+
+// supportedParams lists YAML keys of Params
+var supportedParams = []string{
+	"temperature",
+	"top_p",
+	"max_tokens",
+	"stop",
+	"frequency_penalty",
+	"presence_penalty",
+	"seed",
+}
+
+// Params defines a subset of OpenAI params that are commonly supported by OpenAI-compatible services
+type Params struct {
+	Temperature      float64  `yaml:"temperature,omitempty" json:"temperature"`
+	TopP             float64  `yaml:"top_p,omitempty" json:"top_p"`
+	MaxTokens        int      `yaml:"max_tokens,omitempty" json:"max_tokens"`
+	StopWords        []string `yaml:"stop,omitempty" json:"stop"`
+	FrequencyPenalty float64  `yaml:"frequency_penalty,omitempty" json:"frequency_penalty" validate:"gte=-2,lte=2"`
+	PresencePenalty  float64  `yaml:"presence_penalty,omitempty" json:"presence_penalty" validate:"gte=-2,lte=2"`
+	Seed             *int     `yaml:"seed,omitempty" json:"seed"`
+}
+
+func DefaultParams() Params {
+	return Params{
+		Temperature: 0.8,
+		TopP:        1,
+		MaxTokens:   100,
+		StopWords:   []string{},
+	}
+}
+
+func (p *Params) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var rawParams map[string]interface{}
+
+	if err := unmarshal(&rawParams); err != nil {
+		return err
+	}
+
+	params := make([]string, 0, len(rawParams))
+	for param := range rawParams {
+		params = append(params, param)
+	}
+
+	sort.Strings(params)
+
+	for _, param := range params {
+		if !slices.Contains(supportedParams, param) {
+			return fmt.Errorf(
+				"%w: \"%v\" (supported params: %v)",
+				ErrUnsupportedParam,
+				param,
+				strings.Join(supportedParams, ", "),
+			)
+		}
+	}
+
+	*p = DefaultParams()
+
+	type plain Params // to avoid recursion
+
+	return unmarshal((*plain)(p))
+}
+
+type Config struct {
+	BaseURL       string        `yaml:"base_url" json:"baseUrl" validate:"required"`
+	ChatEndpoint  string        `yaml:"chat_endpoint" json:"chatEndpoint" validate:"required"`
+	Model         string        `yaml:"model" json:"model" validate:"required"`
+	APIKey        fields.Secret `yaml:"api_key,omitempty" json:"-"`                        // Optional as self-hosted services (e.g. LM Studio, vLLM) may not require auth
+	AuthHeader    string        `yaml:"auth_header" json:"authHeader" validate:"required"` // Header name and optional value prefix of the API key, e.g. "Authorization: Bearer" or "X-API-Key"
+	DefaultParams *Params       `yaml:"default_params,omitempty" json:"defaultParams"`
+}
+
+// DefaultConfig for OpenAI-compatible models
+func DefaultConfig() *Config {
+	defaultParams := DefaultParams()
+
+	return &Config{
+		ChatEndpoint:  "/chat/completions",
+		AuthHeader:    "Authorization: Bearer",
+		DefaultParams: &defaultParams,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// authHeader returns the name and the value of the header to authenticate requests with
+func (c *Config) authHeader() (string, string) {
+	name, scheme, _ := strings.Cut(c.AuthHeader, ":")
+
+	return strings.TrimSpace(name), strings.TrimSpace(strings.TrimSpace(scheme) + " " + c.APIKey.Value())
+}
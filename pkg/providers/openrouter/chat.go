@@ -0,0 +1,134 @@
+package openrouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"go.uber.org/zap"
+)
+
+// ErrorResponse is returned by OpenRouter in case of errors (https://openrouter.ai/docs#errors)
+type ErrorResponse struct {
+	Error struct {
+		Code     int                    `json:"code"`
+		Message  string                 `json:"message"`
+		Metadata map[string]interface{} `json:"metadata,omitempty"`
+	} `json:"error"`
+}
+
+// NewChatRequestFromConfig fills the OpenAI request schema from the config. Not using reflection because of performance penalty it gives
+func NewChatRequestFromConfig(cfg *Config) *openai.ChatRequest {
+	return openai.NewChatRequestFromParams(cfg.Model, cfg.DefaultParams)
+}
+
+// Chat sends a chat request to the specified OpenRouter model.
+func (c *Client) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	// Create a new chat request
+	chatRequest := c.createChatRequestSchema(request)
+
+	chatResponse, err := c.doChatRequest(ctx, chatRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chatResponse.ModelResponse.Message.Content) == 0 && len(chatResponse.ModelResponse.Message.ToolCalls) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return chatResponse, nil
+}
+
+func (c *Client) createChatRequestSchema(request *schemas.UnifiedChatRequest) *openai.ChatRequest {
+	return openai.NewChatRequest(c.chatRequestTemplate, request, c.SupportsParam, c.telemetry.Logger, providerName)
+}
+
+func (c *Client) doChatRequest(ctx context.Context, payload *openai.ChatRequest) (*schemas.UnifiedChatResponse, error) {
+	// Build request payload
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal openrouter chat request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatURL, bytes.NewBuffer(rawPayload))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create openrouter chat request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey.Value())
+	req.Header.Set("Content-Type", "application/json")
+
+	// TODO: this could leak information from messages which may not be a desired thing to have
+	c.telemetry.Logger.Debug(
+		"openrouter chat request",
+		zap.String("chat_url", c.chatURL),
+		zap.Any("payload", payload),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send openrouter chat request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	// Read the response body into a byte slice
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to read openrouter chat response", zap.Error(err))
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp, bodyBytes)
+	}
+
+	// Parse the response JSON
+	var completion schemas.OpenAIChatCompletion
+
+	err = json.Unmarshal(bodyBytes, &completion)
+	if err != nil {
+		c.telemetry.Logger.Error("failed to parse openrouter chat response", zap.Error(err))
+		return nil, err
+	}
+
+	if len(completion.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return openai.NewUnifiedChatResponse(&completion, providerName), nil
+}
+
+// handleErrorResponse maps OpenRouter errors to the gateway ones
+func (c *Client) handleErrorResponse(resp *http.Response, bodyBytes []byte) error {
+	c.telemetry.Logger.Error(
+		"openrouter chat request failed",
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("response", string(bodyBytes)),
+		zap.Any("headers", resp.Header),
+	)
+
+	var errResp ErrorResponse
+
+	errMessage := string(bodyBytes)
+
+	if err := json.Unmarshal(bodyBytes, &errResp); err == nil && errResp.Error.Message != "" {
+		errMessage = errResp.Error.Message
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return clients.NewRetryAfterError(resp.Header)
+	case http.StatusPaymentRequired:
+		return fmt.Errorf("%w: %v", ErrInsufficientCredits, errMessage)
+	default:
+		// the rest (e.g. moderation flags, model or upstream provider outages) makes the model unavailable for now
+		return fmt.Errorf("%w: %v", clients.NewProviderError(resp.StatusCode), errMessage)
+	}
+}
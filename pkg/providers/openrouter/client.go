@@ -0,0 +1,92 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/telemetry"
+)
+
+const (
+	providerName = "openrouter"
+)
+
+var (
+	// ErrEmptyResponse is returned when the OpenRouter API returns an empty response.
+	ErrEmptyResponse = errors.New("empty response")
+	// ErrInsufficientCredits is returned when the OpenRouter account has run out of credits
+	ErrInsufficientCredits = errors.New("openrouter account has insufficient credits")
+)
+
+// Client is a client for accessing OpenRouter API
+type Client struct {
+	baseURL             string
+	chatURL             string
+	chatRequestTemplate *openai.ChatRequest
+	config              *Config
+	httpClient          *http.Client
+	telemetry           *telemetry.Telemetry
+}
+
+// NewClient creates a new OpenRouter client.
+func NewClient(providerConfig *Config, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (*Client, error) {
+	chatURL, err := url.JoinPath(providerConfig.BaseURL, providerConfig.ChatEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := clients.NewHTTPClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		baseURL:             providerConfig.BaseURL,
+		chatURL:             chatURL,
+		config:              providerConfig,
+		chatRequestTemplate: NewChatRequestFromConfig(providerConfig),
+		httpClient:          httpClient,
+		telemetry:           tel,
+	}
+
+	return c, nil
+}
+
+func (c *Client) Provider() string {
+	return providerName
+}
+
+// WarmupConnections opens connections to the provider API ahead of requests
+func (c *Client) WarmupConnections(ctx context.Context, connections int) error {
+	return clients.WarmupConnections(ctx, c.httpClient, c.chatURL, connections)
+}
+
+// SupportsParam reports whether the client could translate the given optional param of the unified chat request
+func (c *Client) SupportsParam(param string) bool {
+	switch param {
+	case schemas.ParamSeed, schemas.ParamPresencePenalty, schemas.ParamFrequencyPenalty, schemas.ParamLogitBias:
+		return true
+	default:
+		return false
+	}
+}
+
+// SupportsTools reports whether the client could translate tools of the unified chat request
+func (c *Client) SupportsTools() bool {
+	return true
+}
+
+// SupportsVision reports whether the client could translate images of the unified chat request
+func (c *Client) SupportsVision() bool {
+	return true
+}
+
+// SupportsResponseFormat reports whether the client could translate the response format (JSON mode & structured output)
+func (c *Client) SupportsResponseFormat() bool {
+	return true
+}
@@ -0,0 +1,150 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/telemetry"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRouterClient_ChatRequest(t *testing.T) {
+	// OpenRouter Chat API: https://openrouter.ai/docs#requests
+	openRouterMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ := io.ReadAll(r.Body)
+
+		var data openai.ChatRequest
+		// Parse the JSON body
+		err := json.Unmarshal(rawPayload, &data)
+		if err != nil {
+			t.Errorf("error decoding payload (%q): %v", string(rawPayload), err)
+		}
+
+		require.Equal(t, "/api/v1/chat/completions", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.Equal(t, "https://myapp.example.com", r.Header.Get("HTTP-Referer"))
+		require.Equal(t, "My App", r.Header.Get("X-Title"))
+		require.Equal(t, "anthropic/claude-3-opus", data.Model)
+
+		chatResponse, err := os.ReadFile(filepath.Clean("./testdata/chat.success.json"))
+		if err != nil {
+			t.Errorf("error reading openrouter chat mock response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(chatResponse)
+		if err != nil {
+			t.Errorf("error on sending chat response: %v", err)
+		}
+	})
+
+	openRouterServer := httptest.NewServer(openRouterMock)
+	defer openRouterServer.Close()
+
+	ctx := context.Background()
+	providerCfg := DefaultConfig()
+	clientCfg := clients.DefaultClientConfig()
+	clientCfg.Attribution = &clients.AttributionConfig{Title: "My App", Referer: "https://myapp.example.com"}
+
+	providerCfg.BaseURL = openRouterServer.URL + "/api/v1"
+	providerCfg.Model = "anthropic/claude-3-opus"
+	providerCfg.APIKey = "secret"
+
+	client, err := NewClient(providerCfg, clientCfg, telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	request := schemas.UnifiedChatRequest{Message: schemas.ChatMessage{
+		Role:    "user",
+		Content: "What's the biggest animal?",
+	}}
+
+	response, err := client.Chat(ctx, &request)
+	require.NoError(t, err)
+
+	require.Equal(t, "chatcmpl-123", response.ID)
+	require.Equal(t, providerName, response.Provider)
+	require.Equal(t, "anthropic/claude-3-opus", response.Model)
+}
+
+func TestOpenRouterClient_ErrorResponses(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		body       string
+		checkErr   func(t *testing.T, err error)
+	}{
+		"rate limited": {
+			statusCode: http.StatusTooManyRequests,
+			body:       `{"error":{"code":429,"message":"Rate limit exceeded"}}`,
+			checkErr: func(t *testing.T, err error) {
+				var rateLimitErr *clients.RateLimitError
+
+				require.ErrorAs(t, err, &rateLimitErr)
+			},
+		},
+		"insufficient credits": {
+			statusCode: http.StatusPaymentRequired,
+			body:       `{"error":{"code":402,"message":"Insufficient credits"}}`,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInsufficientCredits)
+				require.ErrorContains(t, err, "Insufficient credits")
+			},
+		},
+		"upstream is down": {
+			statusCode: http.StatusBadGateway,
+			body:       `{"error":{"code":502,"message":"Model is down","metadata":{"provider":"anthropic"}}}`,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, clients.ErrProviderUnavailable)
+				require.ErrorContains(t, err, "Model is down")
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			openRouterServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer openRouterServer.Close()
+
+			providerCfg := DefaultConfig()
+			providerCfg.BaseURL = openRouterServer.URL
+			providerCfg.Model = "anthropic/claude-3-opus"
+			providerCfg.APIKey = "secret"
+
+			client, err := NewClient(providerCfg, clients.DefaultClientConfig(), telemetry.NewTelemetryMock())
+			require.NoError(t, err)
+
+			request := schemas.UnifiedChatRequest{Message: schemas.ChatMessage{
+				Role:    "user",
+				Content: "What's the biggest animal?",
+			}}
+
+			_, err = client.Chat(context.Background(), &request)
+			test.checkErr(t, err)
+		})
+	}
+}
+
+func TestOpenRouterConfig_ModelWithSlashes(t *testing.T) {
+	var cfg Config
+
+	err := yaml.Unmarshal([]byte("api_key: secret\nmodel: meta-llama/llama-3-70b-instruct:nitro\n"), &cfg)
+	require.NoError(t, err)
+
+	require.Equal(t, "meta-llama/llama-3-70b-instruct:nitro", cfg.Model)
+	require.Equal(t, "https://openrouter.ai/api/v1", cfg.BaseURL)
+	require.Equal(t, "/chat/completions", cfg.ChatEndpoint)
+}
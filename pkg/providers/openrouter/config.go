@@ -0,0 +1,36 @@
+// Package openrouter is a provider for OpenRouter (https://openrouter.ai) that gives access to many models
+// via a single OpenAI-compatible API. Models are referenced by their OpenRouter IDs (e.g. "anthropic/claude-3-opus").
+// Requests are attributed to the app by the shared client attribution config (see clients.AttributionConfig)
+package openrouter
+
+import (
+	"glide/pkg/config/fields"
+	"glide/pkg/providers/openai"
+)
+
+type Config struct {
+	BaseURL       string         `yaml:"base_url" json:"baseUrl" validate:"required"`
+	ChatEndpoint  string         `yaml:"chat_endpoint" json:"chatEndpoint" validate:"required"`
+	Model         string         `yaml:"model" json:"model" validate:"required"` // OpenRouter model ID in the "<vendor>/<model>" format
+	APIKey        fields.Secret  `yaml:"api_key" json:"-" validate:"required"`
+	DefaultParams *openai.Params `yaml:"default_params,omitempty" json:"defaultParams"`
+}
+
+// DefaultConfig for OpenRouter models
+func DefaultConfig() *Config {
+	defaultParams := openai.DefaultParams()
+
+	return &Config{
+		BaseURL:       "https://openrouter.ai/api/v1",
+		ChatEndpoint:  "/chat/completions",
+		DefaultParams: &defaultParams,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
@@ -0,0 +1,191 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"go.uber.org/zap"
+)
+
+// ErrOutputLimitExceeded is returned when the model response is longer than its output guard allows
+var ErrOutputLimitExceeded = errors.New("model response exceeds the max output tokens")
+
+// Actions on responses exceeding the output guard
+const (
+	OutputGuardTruncate = "truncate" // cut the response at the limit
+	OutputGuardError    = "error"    // fail the model, so the request falls back to other models
+)
+
+// OutputGuardConfig protects clients from runaway generations of models that ignore max_tokens
+type OutputGuardConfig struct {
+	MaxOutputTokens int `yaml:"max_output_tokens" json:"max_output_tokens" validate:"gt=0"`
+	// OnExceed applies to non-streamed responses. Streams are always cut at the limit with the "length" finish reason
+	OnExceed string `yaml:"on_exceed,omitempty" json:"on_exceed" validate:"oneof=truncate error"`
+}
+
+func DefaultOutputGuardConfig() *OutputGuardConfig {
+	return &OutputGuardConfig{
+		OnExceed: OutputGuardTruncate,
+	}
+}
+
+func (c *OutputGuardConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultOutputGuardConfig()
+
+	type plain OutputGuardConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// outputGuard counts response tokens by the model tokenizer and cuts responses at the limit
+type outputGuard struct {
+	config *OutputGuardConfig
+	logger *zap.Logger
+}
+
+// SetOutputGuard limits the number of tokens in model responses (nil disables the guard)
+func (m *LangModel) SetOutputGuard(cfg *OutputGuardConfig, logger *zap.Logger) {
+	if cfg == nil {
+		m.outputGuard = nil
+
+		return
+	}
+
+	m.outputGuard = &outputGuard{config: cfg, logger: logger}
+}
+
+// guardResponse truncates or fails responses which completions exceed the guard
+func (m *LangModel) guardResponse(resp *schemas.UnifiedChatResponse) (*schemas.UnifiedChatResponse, error) {
+	if m.outputGuard == nil {
+		return resp, nil
+	}
+
+	modelResponse := &resp.ModelResponse
+
+	var err error
+
+	if modelResponse.Message.Content, err = m.guardContent(modelResponse, modelResponse.Message.Content); err != nil {
+		return nil, err
+	}
+
+	for idx := range modelResponse.Choices {
+		choice := &modelResponse.Choices[idx]
+
+		if choice.Content, err = m.guardContent(modelResponse, choice.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// guardContent cuts the completion at the limit (marking the response as truncated) or fails it depending on the guard action
+func (m *LangModel) guardContent(modelResponse *schemas.ProviderResponse, content string) (string, error) {
+	tokenizer := m.Tokenizer()
+	maxTokens := m.outputGuard.config.MaxOutputTokens
+
+	tokens := tokenizer.CountTokens(content)
+	if tokens <= maxTokens {
+		return content, nil
+	}
+
+	m.outputGuard.triggered(m, tokens)
+
+	if m.outputGuard.config.OnExceed == OutputGuardError {
+		return "", fmt.Errorf("%w (%v > %v)", ErrOutputLimitExceeded, tokens, maxTokens)
+	}
+
+	modelResponse.FinishReason = schemas.FinishReasonLength
+
+	return truncateTokens(tokenizer, content, maxTokens), nil
+}
+
+// streamGuard counts tokens of one streamed response
+type streamGuard struct {
+	guard     *outputGuard
+	tokenizer clients.Tokenizer
+	tokens    int
+}
+
+func (m *LangModel) newStreamGuard() *streamGuard {
+	if m.outputGuard == nil {
+		return nil
+	}
+
+	return &streamGuard{guard: m.outputGuard, tokenizer: m.Tokenizer()}
+}
+
+// check cuts the chunk at the limit. It returns false once the limit is hit, so the rest of the stream should not be forwarded
+func (g *streamGuard) check(model *LangModel, chunk *schemas.UnifiedChatStreamChunk) bool {
+	if g == nil {
+		return true
+	}
+
+	content := chunk.ModelResponse.Message.Content
+	tokens := g.tokenizer.CountTokens(content)
+	maxTokens := g.guard.config.MaxOutputTokens
+
+	if g.tokens+tokens <= maxTokens {
+		g.tokens += tokens
+
+		return true
+	}
+
+	g.guard.triggered(model, g.tokens+tokens)
+
+	chunk.ModelResponse.Message.Content = truncateTokens(g.tokenizer, content, maxTokens-g.tokens)
+	chunk.FinishReason = schemas.FinishReasonLength
+
+	return false
+}
+
+func (g *outputGuard) triggered(model *LangModel, tokens int) {
+	g.logger.Warn(
+		"model response exceeds the max output tokens",
+		zap.String("modelID", model.ID()),
+		zap.String("provider", model.Provider()),
+		zap.Int("tokens", tokens),
+		zap.Int("maxOutputTokens", g.config.MaxOutputTokens),
+		zap.String("onExceed", g.config.OnExceed),
+	)
+}
+
+// truncateTokens returns the longest prefix of the text that fits the number of tokens.
+// The prefix is cut at the last whitespace if it ends in the middle of the word
+func truncateTokens(tokenizer clients.Tokenizer, text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	boundaries := make([]int, 0, len(text))
+	for idx := range text {
+		boundaries = append(boundaries, idx)
+	}
+
+	boundaries = append(boundaries, len(text))
+
+	// the number of tokens grows with the prefix length, so the longest fitting prefix is found by the binary search
+	fits := sort.Search(len(boundaries), func(i int) bool {
+		return tokenizer.CountTokens(text[:boundaries[i]]) > maxTokens
+	}) - 1
+
+	prefix := text[:boundaries[fits]]
+
+	if len(prefix) == len(text) {
+		return prefix
+	}
+
+	if next, _ := utf8.DecodeRuneInString(text[len(prefix):]); !unicode.IsSpace(next) {
+		if lastSpace := strings.LastIndexFunc(prefix, unicode.IsSpace); lastSpace > 0 {
+			prefix = prefix[:lastSpace]
+		}
+	}
+
+	return strings.TrimRightFunc(prefix, unicode.IsSpace)
+}
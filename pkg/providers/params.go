@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+)
+
+// ErrUnsupportedParams is returned when the request has optional params the model can't translate while strict params mode is on
+var ErrUnsupportedParams = errors.New("request params are not supported by the model")
+
+// ParamSupporter is implemented by provider clients that could translate optional params of the unified chat request.
+// Clients that don't implement it are considered to support none of them
+type ParamSupporter interface {
+	SupportsParam(param string) bool
+}
+
+// UnsupportedParamsError lists optional request params the provider can't translate
+type UnsupportedParamsError struct {
+	Provider string
+	Params   []string
+}
+
+func (e *UnsupportedParamsError) Error() string {
+	return fmt.Sprintf("%v (provider: %v, params: %v)", ErrUnsupportedParams, e.Provider, strings.Join(e.Params, ", "))
+}
+
+func (e *UnsupportedParamsError) Unwrap() error {
+	return ErrUnsupportedParams
+}
+
+// supportsParam checks if the client could translate the optional param of the unified chat request
+func supportsParam(client LangModelProvider, param string) bool {
+	supporter, ok := client.(ParamSupporter)
+
+	return ok && supporter.SupportsParam(param)
+}
+
+// checkParams returns UnsupportedParamsError if the client can't translate some of the optional request params
+func checkParams(client LangModelProvider, request *schemas.UnifiedChatRequest) error {
+	params := request.OptionalParams()
+	if len(params) == 0 {
+		return nil
+	}
+
+	unsupported := make([]string, 0, len(params))
+
+	for _, param := range params {
+		if !supportsParam(client, param) {
+			unsupported = append(unsupported, param)
+		}
+	}
+
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	return &UnsupportedParamsError{
+		Provider: client.Provider(),
+		Params:   unsupported,
+	}
+}
+
+// ToolCaller is implemented by provider clients that could translate tools of the unified chat request.
+// Clients that don't implement it can't call tools, so requests with tools are not routed to them
+type ToolCaller interface {
+	SupportsTools() bool
+}
+
+// ResponseFormatter is implemented by provider clients that could translate the response format
+// (JSON mode & structured output) of the unified chat request
+type ResponseFormatter interface {
+	SupportsResponseFormat() bool
+}
+
+// VisionSupporter is implemented by provider clients that could translate images of the unified chat request
+type VisionSupporter interface {
+	SupportsVision() bool
+}
+
+// StreamingSupporter is implemented by models that could tell whether they stream responses.
+// Provider clients stream if they implement ChatStreamer
+type StreamingSupporter interface {
+	SupportsStreaming() bool
+}
+
+// MultipleCompletionsSupporter is implemented by models that could generate several completions per request
+// even if their providers can't do that natively
+type MultipleCompletionsSupporter interface {
+	SupportsMultipleCompletions() bool
+}
+
+// RequiredCapabilities lists capabilities a model must have to serve the request
+func RequiredCapabilities(request *schemas.UnifiedChatRequest) []string {
+	var capabilities []string
+
+	if request.HasTools() {
+		capabilities = append(capabilities, clients.CapabilityTools)
+	}
+
+	if request.StructuredOutput() {
+		capabilities = append(capabilities, clients.CapabilityJSONMode)
+	}
+
+	if request.HasImages() {
+		capabilities = append(capabilities, clients.CapabilityVision)
+	}
+
+	if request.N > 1 {
+		capabilities = append(capabilities, clients.CapabilityMultipleCompletions)
+	}
+
+	return capabilities
+}
+
+// HasCapability checks if the model (or the provider client) has the capability
+func HasCapability(model interface{}, capability string) bool {
+	switch capability {
+	case clients.CapabilityStreaming:
+		if streamingSupporter, ok := model.(StreamingSupporter); ok {
+			return streamingSupporter.SupportsStreaming()
+		}
+
+		_, ok := model.(ChatStreamer)
+
+		return ok
+	case clients.CapabilityTools:
+		toolCaller, ok := model.(ToolCaller)
+
+		return ok && toolCaller.SupportsTools()
+	case clients.CapabilityJSONMode:
+		responseFormatter, ok := model.(ResponseFormatter)
+
+		return ok && responseFormatter.SupportsResponseFormat()
+	case clients.CapabilityVision:
+		visionSupporter, ok := model.(VisionSupporter)
+
+		return ok && visionSupporter.SupportsVision()
+	case clients.CapabilityMultipleCompletions:
+		if completionsSupporter, ok := model.(MultipleCompletionsSupporter); ok {
+			return completionsSupporter.SupportsMultipleCompletions()
+		}
+
+		paramSupporter, ok := model.(ParamSupporter)
+
+		return ok && paramSupporter.SupportsParam(schemas.ParamN)
+	default:
+		return false
+	}
+}
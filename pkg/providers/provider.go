@@ -3,21 +3,34 @@ package providers
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"glide/pkg/providers/clients"
 	"glide/pkg/routers/health"
 	"glide/pkg/routers/latency"
+	"glide/pkg/routers/prompts"
 
 	"glide/pkg/api/schemas"
 )
 
+// ErrModelSaturated is returned when the model has reached its max number of in-flight requests
+var ErrModelSaturated = errors.New("model has reached its max concurrency")
+
 // LangModelProvider defines an interface a provider should fulfill to be able to serve language chat requests
 type LangModelProvider interface {
 	Provider() string
 	Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error)
 }
 
+// ChatStreamer is implemented by providers that can stream chat responses
+type ChatStreamer interface {
+	// ChatStream returns a channel of response chunks. The channel is closed once the stream is over
+	ChatStream(ctx context.Context, request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error)
+}
+
 type Model interface {
 	ID() string
 	Healthy() bool
@@ -31,25 +44,76 @@ type LanguageModel interface {
 	LangModelProvider
 }
 
+// RateLimited is implemented by models that keep track of provider rate limits
+type RateLimited interface {
+	UntilRateLimitReset() time.Duration
+	// OnlyRateLimited tells if the rate limit is the only reason the model doesn't serve requests
+	OnlyRateLimited() bool
+}
+
+// ResponseTimed is implemented by models that track how long their responses take
+type ResponseTimed interface {
+	ExpectedResponseTime() (time.Duration, bool)
+}
+
+// InFlightGauged is implemented by models that could report the number of chat requests they are processing to metrics
+type InFlightGauged interface {
+	SetInFlightGauge(gauge prometheus.Gauge)
+}
+
+// PromptTemplated is implemented by models with their own prompt templates (they take precedence over router ones)
+type PromptTemplated interface {
+	PromptTemplate() *prompts.Template
+}
+
 // LangModel wraps provider client and expend it with health & latency tracking
 type LangModel struct {
-	modelID               string
-	weight                int
-	client                LangModelProvider
-	rateLimit             *health.RateLimitTracker
-	errorBudget           *health.TokenBucket // TODO: centralize provider API health tracking in the registry
-	latency               *latency.MovingAverage
-	latencyUpdateInterval *time.Duration
+	modelID                  string
+	weight                   int
+	client                   LangModelProvider
+	rateLimit                *health.RateLimitTracker
+	concurrency              *health.ConcurrencyLimiter
+	strictParams             bool
+	structuredOutputFallback bool
+	capabilities             *CapabilitiesConfig // declared capabilities (nil means all are inferred from the provider)
+	promptTemplate           *prompts.Template   // scaffolds requests to the model (nil if the model has no template)
+	errorBudget              *health.TokenBucket // TODO: centralize provider API health tracking in the registry
+	latency                  *latency.MovingAverage
+	latencyRecorder          *latency.Recorder // batches latency updates, so the average is updated once per the update interval
+	latencyUpdateInterval    *time.Duration
+	responseTimes            *latency.Histogram        // durations of whole chat responses (unlike latency, they are not normalized per token)
+	responseTime             *latency.MovingAverage    // the fallback for response time estimates until the histogram gets enough samples
+	connWarmup               *clients.ConnWarmupConfig // keeps connections to the provider established (nil if disabled)
+	connWarmedAt             atomic.Int64              // unix nanoseconds of the last connection warmup
+	outputGuard              *outputGuard              // cuts runaway responses (nil if disabled)
+	alias                    *modelAlias               // switches clients at alias cutovers (nil if the model is not aliased)
+	tokenRateLimit           *tokenRateLimit           // keeps token usage within the TPM limit (nil if disabled)
+	fanOut                   *FanOutConfig             // asks the provider several times for multiple completions (nil if disabled)
+	tokenizer                clients.Tokenizer         // overrides the provider tokenizer (nil if not configured)
+	rateLimitCooldown        time.Duration             // the cooldown of rate limits without Retry-After (zero means one minute)
+	ignoreRetryAfter         bool                      // applies the cooldown to all rate limits regardless of Retry-After
+	chaos                    *chaos                    // injects faults into requests (nil if disabled)
+	// onRateLimited is notified when the provider rate limits the model (e.g. to share the limit with other gateway replicas)
+	onRateLimited atomic.Pointer[RateLimitListener]
 }
 
+// RateLimitListener is notified about rate limits the model runs into
+type RateLimitListener func(untilReset time.Duration)
+
 func NewLangModel(modelID string, client LangModelProvider, budget health.ErrorBudget, latencyConfig latency.Config, weight int) *LangModel {
+	movingAverage := latency.NewMovingAverage(latencyConfig.Decay, latencyConfig.WarmupSamples)
+
 	return &LangModel{
 		modelID:               modelID,
 		client:                client,
 		rateLimit:             health.NewRateLimitTracker(),
+		concurrency:           health.NewConcurrencyLimiter(0),
 		errorBudget:           health.NewTokenBucket(budget.TimePerTokenMicro(), budget.Budget()),
-		latency:               latency.NewMovingAverage(latencyConfig.Decay, latencyConfig.WarmupSamples),
+		latency:               movingAverage,
+		latencyRecorder:       latency.NewRecorder(movingAverage, latencyConfig.UpdateInterval),
 		latencyUpdateInterval: latencyConfig.UpdateInterval,
+		responseTimes:         latency.NewHistogram(),
+		responseTime:          latency.NewMovingAverage(latencyConfig.Decay, latencyConfig.WarmupSamples),
 		weight:                weight,
 	}
 }
@@ -59,7 +123,7 @@ func (m *LangModel) ID() string {
 }
 
 func (m *LangModel) Provider() string {
-	return m.client.Provider()
+	return m.activeClient().Provider()
 }
 
 func (m *LangModel) Latency() *latency.MovingAverage {
@@ -70,8 +134,124 @@ func (m *LangModel) LatencyUpdateInterval() *time.Duration {
 	return m.latencyUpdateInterval
 }
 
+// Healthy is false when the model is rate limited, has exhausted its error budget
+// or has reached its max concurrency (so routing picks another model rather than queueing requests)
 func (m *LangModel) Healthy() bool {
-	return !m.rateLimit.Limited() && m.errorBudget.HasTokens()
+	return !m.rateLimit.Limited() && m.errorBudget.HasTokens() && !m.concurrency.Saturated()
+}
+
+// OnRateLimited sets the listener of rate limits the model runs into
+func (m *LangModel) OnRateLimited(listener RateLimitListener) {
+	m.onRateLimited.Store(&listener)
+}
+
+// SetRateLimited marks the model rate limited without notifying the listener (e.g. when the limit was hit by another replica)
+func (m *LangModel) SetRateLimited(untilReset time.Duration) {
+	m.rateLimit.SetLimited(untilReset)
+}
+
+// UntilRateLimitReset returns how long the model stays rate limited (zero if it's not limited)
+func (m *LangModel) UntilRateLimitReset() time.Duration {
+	return m.rateLimit.UntilReset()
+}
+
+// OnlyRateLimited is true when the model would be healthy if it was not rate limited
+func (m *LangModel) OnlyRateLimited() bool {
+	return m.rateLimit.Limited() && m.errorBudget.HasTokens() && !m.concurrency.Saturated()
+}
+
+// SetMaxConcurrency limits the number of simultaneous in-flight requests (zero or negative value means no limit)
+func (m *LangModel) SetMaxConcurrency(limit int) {
+	m.concurrency = health.NewConcurrencyLimiter(limit)
+}
+
+// SetRateLimitCooldown extends rate limit resets by the buffer and ramps traffic back over the ramp-up period after resets
+func (m *LangModel) SetRateLimitCooldown(buffer time.Duration, rampUp time.Duration) {
+	m.rateLimit.SetCooldown(buffer, rampUp)
+}
+
+// SetRetryAfter sets the cooldown of rate limits the provider doesn't tell the reset of (zero means one minute)
+// and whether the window the provider reports via Retry-After is honored
+func (m *LangModel) SetRetryAfter(defaultCooldown time.Duration, honor bool) {
+	m.rateLimitCooldown = defaultCooldown
+	m.ignoreRetryAfter = !honor
+}
+
+// rateLimitCooldownOf picks how long the model stays rate limited after the provider has limited it
+func (m *LangModel) rateLimitCooldownOf(err *clients.RateLimitError) time.Duration {
+	if err.Specified() && !(err.FromRetryAfter() && m.ignoreRetryAfter) {
+		return err.UntilReset()
+	}
+
+	if m.rateLimitCooldown > 0 {
+		return m.rateLimitCooldown
+	}
+
+	return clients.DefaultRateLimitCooldown
+}
+
+// SetStrictParams makes the model reject requests with optional params its provider can't translate
+// instead of silently ignoring them
+func (m *LangModel) SetStrictParams(strict bool) {
+	m.strictParams = strict
+}
+
+// SetPromptTemplate makes the model scaffold requests by its own template instead of the router one
+func (m *LangModel) SetPromptTemplate(cfg *prompts.Config) error {
+	if cfg == nil {
+		m.promptTemplate = nil
+
+		return nil
+	}
+
+	tmpl, err := prompts.NewTemplate(cfg)
+	if err != nil {
+		return err
+	}
+
+	m.promptTemplate = tmpl
+
+	return nil
+}
+
+// PromptTemplate returns the template of the model (nil if the model has no template)
+func (m *LangModel) PromptTemplate() *prompts.Template {
+	return m.promptTemplate
+}
+
+// InFlight returns the number of chat requests the model is processing at the moment
+func (m *LangModel) InFlight() int64 {
+	return m.concurrency.InFlight()
+}
+
+// SetInFlightGauge makes the model report the number of chat requests it's processing to the gauge
+func (m *LangModel) SetInFlightGauge(gauge prometheus.Gauge) {
+	m.concurrency.SetGauge(gauge)
+}
+
+// ErrorBudgetRemaining returns the number of failures the model could tolerate before it's considered unhealthy
+func (m *LangModel) ErrorBudgetRemaining() float64 {
+	return m.errorBudget.Tokens()
+}
+
+// SetStructuredOutputFallback makes the model serve structured output requests even if its provider doesn't support them
+// by instructing the model to respond with JSON in the system prompt & validating its responses
+func (m *LangModel) SetStructuredOutputFallback(fallback bool) {
+	m.structuredOutputFallback = fallback
+}
+
+// ExpectedResponseTime estimates how long the model takes to respond: the p95 of response times once there are enough samples
+// or their moving average before that. False is returned while the model has not responded enough times to tell
+func (m *LangModel) ExpectedResponseTime() (time.Duration, bool) {
+	if p95, known := m.responseTimes.Quantile(0.95); known {
+		return p95, true
+	}
+
+	if !m.responseTime.WarmedUp() {
+		return 0, false
+	}
+
+	return time.Duration(m.responseTime.Value()), true
 }
 
 func (m *LangModel) Weight() int {
@@ -79,12 +259,77 @@ func (m *LangModel) Weight() int {
 }
 
 func (m *LangModel) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if err := m.checkCapabilities(request); err != nil {
+		return nil, err
+	}
+
+	// the client is picked once, so the whole request goes to the same alias target even if it spans the cutover
+	client := m.activeClient()
+	emulateFormat := request.StructuredOutput() && !HasCapability(client, clients.CapabilityJSONMode)
+
+	clientRequest := request
+	if emulateFormat {
+		clientRequest = newStructuredOutputRequest(request)
+	}
+
+	if m.strictParams {
+		// the model is fine, it's just not a good fit for the request, so the error budget is not consumed
+		if err := checkParams(client, request); err != nil {
+			return nil, err
+		}
+	}
+
+	clientRequest, droppedStop, err := m.limitStopSequences(client, clientRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.concurrency.TryAcquire() {
+		return nil, ErrModelSaturated
+	}
+
+	defer m.concurrency.Release()
+
+	reservedTokens, err := m.reserveTokens(ctx, client, clientRequest)
+	if err != nil {
+		return nil, err
+	}
+
 	startedAt := time.Now()
-	resp, err := m.client.Chat(ctx, request)
+
+	var resp *schemas.UnifiedChatResponse
+
+	// injected faults are handled like the ones of the provider
+	err = m.chaos.inject(ctx)
+	if err == nil {
+		resp, err = chatCompletions(ctx, client, clientRequest, m.fanOut)
+	}
+
+	if err == nil && emulateFormat {
+		// the model was only asked to follow the format, so it may not
+		resp, err = validateStructuredOutput(request.ResponseFormat, resp)
+	}
+
+	if err == nil {
+		reportStopSequence(clientRequest, resp)
+		resp.ModelResponse.DroppedStopSequences = droppedStop
+
+		resp, err = m.guardResponse(resp)
+	}
 
 	if err == nil {
-		// record latency per token to normalize measurements
-		m.latency.Add(float64(time.Since(startedAt)) / resp.ModelResponse.TokenUsage.ResponseTokens)
+		responseTime := time.Since(startedAt)
+
+		m.estimateUsage(clientRequest, resp)
+
+		// record latency per token to normalize measurements (responses without tokens tell nothing about the latency)
+		if responseTokens := resp.ModelResponse.TokenUsage.ResponseTokens; responseTokens > 0 {
+			m.latencyRecorder.Add(float64(responseTime) / responseTokens)
+		}
+
+		m.responseTimes.Observe(responseTime)
+		m.responseTime.Add(float64(responseTime))
+		m.reconcileTokens(reservedTokens, &resp.ModelResponse.TokenUsage)
 
 		// successful response
 		resp.ModelID = m.modelID
@@ -92,15 +337,216 @@ func (m *LangModel) Chat(ctx context.Context, request *schemas.UnifiedChatReques
 		return resp, err
 	}
 
+	m.refundTokens(reservedTokens)
+	m.recordFailure(ctx, err)
+
+	return resp, err
+}
+
+// ChatStream streams the chat response if the provider client could stream.
+// The model is busy (in terms of its max concurrency) until the stream is over or the context is cancelled
+func (m *LangModel) ChatStream(ctx context.Context, request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error) {
+	client := m.activeClient()
+
+	streamer, ok := client.(ChatStreamer)
+	if !ok || !m.SupportsStreaming() {
+		return nil, clients.NewCapabilityError(m.Provider(), clients.CapabilityStreaming)
+	}
+
+	if err := m.checkCapabilities(request); err != nil {
+		return nil, err
+	}
+
+	if request.StructuredOutput() && !HasCapability(client, clients.CapabilityJSONMode) {
+		// partial responses can't be validated, so the structured output fallback is not applicable
+		return nil, clients.NewCapabilityError(m.Provider(), clients.CapabilityJSONMode)
+	}
+
+	if m.strictParams {
+		if err := checkParams(client, request); err != nil {
+			return nil, err
+		}
+	}
+
+	request, _, err := m.limitStopSequences(client, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.concurrency.TryAcquire() {
+		return nil, ErrModelSaturated
+	}
+
+	reservedTokens, err := m.reserveTokens(ctx, client, request)
+	if err != nil {
+		m.concurrency.Release()
+
+		return nil, err
+	}
+
+	// the provider stream is cancelled on its own when the output guard cuts the response
+	clientCtx, cancelClient := context.WithCancel(ctx)
+
+	var clientStreamC <-chan *schemas.ChatStreamResult
+
+	err = m.chaos.inject(ctx)
+	if err == nil {
+		clientStreamC, err = streamer.ChatStream(clientCtx, request)
+	}
+
+	if err != nil {
+		cancelClient()
+		m.concurrency.Release()
+		m.refundTokens(reservedTokens)
+		m.recordFailure(ctx, err)
+
+		return nil, err
+	}
+
+	streamC := make(chan *schemas.ChatStreamResult)
+	guard := m.newStreamGuard()
+
+	go func() {
+		defer close(streamC)
+		defer m.concurrency.Release()
+		defer cancelClient()
+
+		// the stream is complete once the last chunk (with the finish reason) or the error has come
+		completed := false
+
+		// the reservation is reconciled with the usage reported on the last chunk (if any)
+		var usage *schemas.TokenUsage
+
+		// the streamed content is kept to estimate the usage if the provider doesn't report it
+		var streamed strings.Builder
+
+		defer func() { m.reconcileTokens(reservedTokens, usage) }()
+
+		for result := range clientStreamC {
+			withinLimit := true
+
+			if result.Err != nil {
+				m.recordFailure(ctx, result.Err)
+
+				completed = true
+			} else {
+				result.Chunk.ModelID = m.modelID
+				withinLimit = guard.check(m, result.Chunk)
+				completed = completed || result.Chunk.FinishReason != ""
+
+				streamed.WriteString(result.Chunk.ModelResponse.Message.Content)
+
+				if result.Chunk.FinishReason != "" {
+					m.estimateStreamUsage(request, result.Chunk, streamed.String())
+				}
+
+				if result.Chunk.ModelResponse.TokenUsage != nil {
+					usage = result.Chunk.ModelResponse.TokenUsage
+				}
+			}
+
+			select {
+			case streamC <- result:
+			case <-ctx.Done():
+				// the client has gone, the provider client stops streaming on the context cancellation too
+				return
+			}
+
+			if !withinLimit {
+				// the rest of the runaway response is dropped
+				cancelClient()
+
+				go func() {
+					for range clientStreamC { //nolint:revive
+					}
+				}()
+
+				return
+			}
+		}
+
+		if completed || ctx.Err() != nil {
+			return
+		}
+
+		// the upstream has dropped the connection mid-response
+		m.recordFailure(ctx, clients.ErrStreamInterrupted)
+
+		select {
+		case streamC <- &schemas.ChatStreamResult{Err: clients.ErrStreamInterrupted}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return streamC, nil
+}
+
+// recordFailure updates the model health according to the error the provider has failed with.
+// The error budget is not charged if the caller has deferred charges (see WithDeferredErrorBudget)
+func (m *LangModel) recordFailure(ctx context.Context, err error) {
 	var rle *clients.RateLimitError
 
 	if errors.As(err, &rle) {
-		m.rateLimit.SetLimited(rle.UntilReset())
+		untilReset := m.rateLimitCooldownOf(rle)
 
-		return resp, err
+		m.rateLimit.SetLimited(untilReset)
+
+		if listener := m.onRateLimited.Load(); listener != nil {
+			(*listener)(untilReset)
+		}
+
+		return
+	}
+
+	var mle *clients.ModelLoadingError
+
+	if errors.As(err, &mle) {
+		// the model is not broken, so let's just give it some time to become ready without burning the error budget
+		m.rateLimit.SetLimited(mle.UntilReady())
+
+		return
+	}
+
+	if !countsAsFailure(err) || errorBudgetDeferred(ctx) {
+		return
 	}
 
 	_ = m.errorBudget.Take(1)
+}
 
-	return resp, err
+// countsAsFailure tells if the error burns the error budget. Rate limits & loading models are just not ready to serve,
+// while requests the model doesn't have capabilities for are just a bad fit for it, so they are not counted as model failures
+func countsAsFailure(err error) bool {
+	var rle *clients.RateLimitError
+
+	var mle *clients.ModelLoadingError
+
+	return !errors.As(err, &rle) && !errors.As(err, &mle) && !errors.Is(err, clients.ErrCapabilityNotSupported)
+}
+
+type deferredErrorBudgetKey struct{}
+
+// WithDeferredErrorBudget makes models skip charging their error budgets on failures,
+// so the caller could charge them once per request via ChargeErrorBudget (e.g. when only the final failure should count)
+func WithDeferredErrorBudget(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deferredErrorBudgetKey{}, true)
+}
+
+func errorBudgetDeferred(ctx context.Context) bool {
+	deferred, _ := ctx.Value(deferredErrorBudgetKey{}).(bool)
+
+	return deferred
+}
+
+// ErrorBudgeted is implemented by models that track their health by error budgets
+type ErrorBudgeted interface {
+	ChargeErrorBudget(err error)
+	ErrorBudgetRemaining() float64
+}
+
+// ChargeErrorBudget charges the error budget for the failure that was deferred by WithDeferredErrorBudget
+func (m *LangModel) ChargeErrorBudget(err error) {
+	if countsAsFailure(err) {
+		_ = m.errorBudget.Take(1)
+	}
 }
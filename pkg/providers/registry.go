@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"glide/pkg/providers/anthropic"
+	"glide/pkg/providers/azureopenai"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/cloudflare"
+	"glide/pkg/providers/cohere"
+	"glide/pkg/providers/deepseek"
+	"glide/pkg/providers/huggingface"
+	"glide/pkg/providers/mock"
+	"glide/pkg/providers/octoml"
+	"glide/pkg/providers/openai"
+	"glide/pkg/providers/openaicompat"
+	"glide/pkg/providers/openrouter"
+	"glide/pkg/telemetry"
+)
+
+var (
+	ErrProviderRegistered     = errors.New("provider is already registered")
+	ErrInvalidProviderFactory = errors.New("provider factory must have a name, a config constructor & a builder")
+)
+
+// ProviderFactory creates clients of a provider from its config.
+// Providers are looked up by the key of their config in model configs (e.g. "openai"),
+// so new providers (including out-of-tree ones of apps embedding Glide) don't need changes to the model config
+type ProviderFactory struct {
+	// Name is the key of the provider config in model configs
+	Name string
+	// NewConfig returns a pointer to the provider config with defaults set. The model config is decoded into it
+	NewConfig func() any
+	// Build creates the provider client from the config NewConfig has returned
+	Build func(config any, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (LangModelProvider, error)
+	// builtin providers have their own fields in the model config, so they are not decoded dynamically
+	builtin bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]ProviderFactory)
+)
+
+// RegisterProvider adds the provider, so models could be configured with it under the provider name key.
+// It's meant to be called before configs are loaded (e.g. in init functions)
+func RegisterProvider(factory ProviderFactory) error {
+	if factory.Name == "" || factory.NewConfig == nil || factory.Build == nil {
+		return ErrInvalidProviderFactory
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, found := registry[factory.Name]; found {
+		return fmt.Errorf("%w: %v", ErrProviderRegistered, factory.Name)
+	}
+
+	registry[factory.Name] = factory
+
+	return nil
+}
+
+// MustRegisterProvider is like RegisterProvider, but panics if the provider could not be registered
+func MustRegisterProvider(factory ProviderFactory) {
+	if err := RegisterProvider(factory); err != nil {
+		panic(err)
+	}
+}
+
+// RegisteredProviders lists names of all registered providers
+func RegisteredProviders() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func lookupProvider(name string) (ProviderFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	factory, found := registry[name]
+
+	return factory, found
+}
+
+// registerBuiltinProvider registers one of providers shipped with Glide
+func registerBuiltinProvider[C any, P LangModelProvider](
+	name string,
+	newConfig func() *C,
+	newClient func(*C, *clients.ClientConfig, *telemetry.Telemetry) (P, error),
+) {
+	MustRegisterProvider(ProviderFactory{
+		Name:      name,
+		NewConfig: func() any { return newConfig() },
+		Build: func(config any, clientConfig *clients.ClientConfig, tel *telemetry.Telemetry) (LangModelProvider, error) {
+			providerConfig, ok := config.(*C)
+			if !ok {
+				return nil, fmt.Errorf("unexpected config type of %v provider: %T", name, config)
+			}
+
+			client, err := newClient(providerConfig, clientConfig, tel)
+			if err != nil {
+				return nil, err
+			}
+
+			return client, nil
+		},
+		builtin: true,
+	})
+}
+
+func init() {
+	registerBuiltinProvider("openai", openai.DefaultConfig, openai.NewClient)
+	registerBuiltinProvider("azureopenai", azureopenai.DefaultConfig, azureopenai.NewClient)
+	registerBuiltinProvider("cohere", cohere.DefaultConfig, cohere.NewClient)
+	registerBuiltinProvider("octoml", octoml.DefaultConfig, octoml.NewClient)
+	registerBuiltinProvider("anthropic", anthropic.DefaultConfig, anthropic.NewClient)
+	registerBuiltinProvider("huggingface", huggingface.DefaultConfig, huggingface.NewClient)
+	registerBuiltinProvider("openaicompat", openaicompat.DefaultConfig, openaicompat.NewClient)
+	registerBuiltinProvider("openrouter", openrouter.DefaultConfig, openrouter.NewClient)
+	registerBuiltinProvider("cloudflare", cloudflare.DefaultConfig, cloudflare.NewClient)
+	registerBuiltinProvider("deepseek", deepseek.DefaultConfig, deepseek.NewClient)
+	registerBuiltinProvider("mock", mock.DefaultConfig, mock.NewClient)
+
+	providerFields = builtinProviderFields()
+}
@@ -0,0 +1,174 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/config/jsonschema"
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
+)
+
+type echoConfig struct {
+	BaseURL  string `yaml:"base_url" json:"base_url"`
+	Model    string `yaml:"model" json:"model"`
+	Greeting string `yaml:"greeting" json:"greeting"`
+}
+
+func registerEchoProvider(t *testing.T) {
+	t.Helper()
+
+	require.NoError(t, RegisterProvider(ProviderFactory{
+		Name: "echo",
+		NewConfig: func() any {
+			return &echoConfig{Greeting: "hello"}
+		},
+		Build: func(config any, _ *clients.ClientConfig, _ *telemetry.Telemetry) (LangModelProvider, error) {
+			echoCfg := config.(*echoConfig)
+
+			return NewProviderMock([]ResponseMock{{Msg: echoCfg.Greeting + " from " + echoCfg.Model}}), nil
+		},
+	}))
+
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+
+		delete(registry, "echo")
+	})
+}
+
+func TestRegisterProvider_OutOfTree(t *testing.T) {
+	registerEchoProvider(t)
+
+	var modelConfig LangModelConfig
+
+	require.NoError(t, yaml.Unmarshal([]byte(`{id: echo-model, echo: {model: echo-1}}`), &modelConfig))
+	require.NotNil(t, modelConfig.Registered)
+	require.Equal(t, "echo", modelConfig.Registered.Name)
+	// defaults come from the factory config
+	require.Equal(t, &echoConfig{Model: "echo-1", Greeting: "hello"}, modelConfig.Registered.Config)
+
+	model, err := modelConfig.ToModel(telemetry.NewTelemetryMock())
+	require.NoError(t, err)
+
+	resp, err := model.Chat(context.Background(), schemas.NewChatFromStr("hi"))
+	require.NoError(t, err)
+	require.Equal(t, "hello from echo-1", resp.ModelResponse.Message.Content)
+}
+
+func TestRegisterProvider_OneProviderPerModel(t *testing.T) {
+	registerEchoProvider(t)
+
+	var modelConfig LangModelConfig
+
+	err := yaml.Unmarshal([]byte(`{id: echo-model, echo: {model: echo-1}, openai: {model: gpt-4o, api_key: sk-test}}`), &modelConfig)
+	require.ErrorContains(t, err, "2 are configured")
+}
+
+func TestRegisterProvider_Validated(t *testing.T) {
+	require.Contains(t, RegisteredProviders(), "openai")
+	require.Contains(t, RegisteredProviders(), "cloudflare")
+
+	err := RegisterProvider(ProviderFactory{
+		Name:      "openai",
+		NewConfig: func() any { return &echoConfig{} },
+		Build: func(_ any, _ *clients.ClientConfig, _ *telemetry.Telemetry) (LangModelProvider, error) {
+			return nil, nil
+		},
+	})
+	require.ErrorIs(t, err, ErrProviderRegistered)
+
+	require.ErrorIs(t, RegisterProvider(ProviderFactory{Name: "incomplete"}), ErrInvalidProviderFactory)
+}
+
+func TestRegisterProvider_ConfigRoundTrips(t *testing.T) {
+	registerEchoProvider(t)
+
+	var modelConfig LangModelConfig
+
+	require.NoError(t, yaml.Unmarshal([]byte(`{id: echo-model, echo: {model: echo-1}}`), &modelConfig))
+
+	rawConfig, err := yaml.Marshal(&modelConfig)
+	require.NoError(t, err)
+
+	var decodedConfig LangModelConfig
+
+	require.NoError(t, yaml.Unmarshal(rawConfig, &decodedConfig))
+	require.Equal(t, modelConfig.Registered, decodedConfig.Registered)
+
+	rawConfig, err = json.Marshal(modelConfig)
+	require.NoError(t, err)
+
+	var jsonConfig map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(rawConfig, &jsonConfig))
+	require.Equal(t, "echo-model", jsonConfig["id"])
+	require.Equal(t, map[string]interface{}{"base_url": "", "model": "echo-1", "greeting": "hello"}, jsonConfig["echo"])
+}
+
+func TestRegisterProvider_ModelAliasesAndBaseURLOverrides(t *testing.T) {
+	registerEchoProvider(t)
+	t.Setenv(BaseURLEnvVar("echo"), "http://localhost:8080")
+
+	var modelConfig LangModelConfig
+
+	require.NoError(t, yaml.Unmarshal([]byte(`{id: echo-model, echo: {model: echo-1}}`), &modelConfig))
+	require.Equal(t, "echo-1", modelConfig.ProviderModel())
+	require.Equal(t, "http://localhost:8080", modelConfig.Registered.Config.(*echoConfig).BaseURL)
+
+	aliasedConfig := modelConfig.withProviderModel("echo-2")
+	require.Equal(t, "echo-2", aliasedConfig.ProviderModel())
+	// the original config is not changed
+	require.Equal(t, "echo-1", modelConfig.ProviderModel())
+}
+
+func TestRegisterProvider_CoveredBySchema(t *testing.T) {
+	registerEchoProvider(t)
+
+	schema := jsonschema.Generate(&LangModelConfig{})
+
+	require.Contains(t, schema.Properties, "echo")
+	require.Contains(t, schema.Defs[schema.Properties["echo"].Ref[len("#/$defs/"):]].Properties, "greeting")
+}
+
+func TestRegisterProvider_BuiltinProvidersHaveConfigFields(t *testing.T) {
+	fieldNames := make([]string, 0, len(providerFields))
+
+	for _, field := range providerFields {
+		fieldNames = append(fieldNames, field.name)
+	}
+
+	for _, name := range RegisteredProviders() {
+		if factory, _ := lookupProvider(name); factory.builtin {
+			require.Contains(t, fieldNames, name)
+		}
+	}
+
+	// provider configs without a builtin factory would never be resolved
+	configType := reflect.TypeOf(LangModelConfig{})
+
+	for _, field := range providerFields {
+		require.Equal(t, "Config", configType.Field(field.index).Type.Elem().Name())
+	}
+
+	providerConfigs := 0
+
+	for idx := 0; idx < configType.NumField(); idx++ {
+		fieldType := configType.Field(idx).Type
+
+		if fieldType.Kind() == reflect.Pointer && fieldType.Elem().Name() == "Config" &&
+			strings.HasPrefix(fieldType.Elem().PkgPath(), "glide/pkg/providers/") {
+			providerConfigs++
+		}
+	}
+
+	require.Len(t, providerFields, providerConfigs)
+}
@@ -0,0 +1,91 @@
+package providers
+
+import (
+	"slices"
+	"strings"
+
+	"glide/pkg/api/schemas"
+)
+
+// StopSequenceLimiter is implemented by provider clients that take a limited number of stop sequences per request
+type StopSequenceLimiter interface {
+	MaxStopSequences() int
+}
+
+// ParamStop is reported as unsupported when the request has more stop sequences than the provider takes & strict params mode is on
+const ParamStop = "stop"
+
+// limitStopSequences fits stop sequences of the request into the limit of the client.
+// Sequences beyond the limit are dropped unless strict params mode is on, in which case the request is rejected
+func (m *LangModel) limitStopSequences(client LangModelProvider, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatRequest, int, error) {
+	request, dropped := normalizeStopSequences(client, request)
+
+	if dropped > 0 && m.strictParams {
+		return nil, 0, &UnsupportedParamsError{
+			Provider: client.Provider(),
+			Params:   []string{ParamStop},
+		}
+	}
+
+	return request, dropped, nil
+}
+
+// normalizeStopSequences drops empty & repeated stop sequences of the request and truncates them to the limit of the client.
+// It returns the request with the normalized stop sequences and the number of sequences that didn't fit the limit
+func normalizeStopSequences(client LangModelProvider, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatRequest, int) {
+	params := request.Override.Params
+	if params == nil || params.Stop == nil {
+		return request, 0
+	}
+
+	stop := make([]string, 0, len(params.Stop))
+
+	for _, sequence := range params.Stop {
+		if sequence != "" && !slices.Contains(stop, sequence) {
+			stop = append(stop, sequence)
+		}
+	}
+
+	dropped := 0
+
+	if limiter, ok := client.(StopSequenceLimiter); ok && limiter.MaxStopSequences() > 0 && len(stop) > limiter.MaxStopSequences() {
+		dropped = len(stop) - limiter.MaxStopSequences()
+		stop = stop[:limiter.MaxStopSequences()]
+	}
+
+	if len(stop) == len(params.Stop) {
+		return request, 0
+	}
+
+	normalizedParams := *params
+	normalizedParams.Stop = stop
+
+	normalizedRequest := *request
+	normalizedRequest.Override.Params = &normalizedParams
+
+	return &normalizedRequest, dropped
+}
+
+// reportStopSequence makes the matched stop sequence reported the same way for all providers.
+// Some providers keep the matched sequence at the end of the response, so it's trimmed the way the rest of them do
+func reportStopSequence(request *schemas.UnifiedChatRequest, resp *schemas.UnifiedChatResponse) {
+	modelResponse := &resp.ModelResponse
+
+	if modelResponse.StopSequence != "" || request.Override.Params == nil {
+		return
+	}
+
+	if modelResponse.FinishReason != "" && modelResponse.FinishReason != schemas.FinishReasonStop {
+		return
+	}
+
+	for _, sequence := range request.Override.Params.Stop {
+		if content, found := strings.CutSuffix(modelResponse.Message.Content, sequence); found {
+			modelResponse.Message.Content = content
+			modelResponse.StopSequence = sequence
+			modelResponse.FinishReason = schemas.FinishReasonStop
+
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"glide/pkg/api/schemas"
+)
+
+// ErrInvalidStructuredOutput is returned when the model was asked to respond with JSON, but it didn't
+var ErrInvalidStructuredOutput = errors.New("model response doesn't follow the requested format")
+
+// newStructuredOutputRequest copies the request with the system instruction to respond in the requested format.
+// It's used for providers that have no native JSON mode
+func newStructuredOutputRequest(request *schemas.UnifiedChatRequest) *schemas.UnifiedChatRequest {
+	instruction := "Respond with a single valid JSON object only. Do not add any explanations or markdown formatting."
+
+	if format := request.ResponseFormat; format.Type == schemas.ResponseFormatJSONSchema {
+		schema, _ := json.Marshal(format.JSONSchema.Schema)
+
+		instruction = fmt.Sprintf(
+			"%v The JSON object must follow this JSON schema:\n%s",
+			instruction,
+			schema,
+		)
+	}
+
+	messages := make([]schemas.ChatMessage, 0, len(request.ChatMessages())+1)
+	messages = append(messages, schemas.ChatMessage{Role: schemas.RoleSystem, Content: instruction})
+	messages = append(messages, request.ChatMessages()...)
+
+	formattedRequest := *request
+	formattedRequest.Messages = messages
+	formattedRequest.ResponseFormat = nil
+
+	return &formattedRequest
+}
+
+// validateStructuredOutput checks that the response content is a JSON object with all properties the schema requires.
+// Markdown code fences models tend to wrap JSON in are stripped
+func validateStructuredOutput(format *schemas.ResponseFormat, resp *schemas.UnifiedChatResponse) (*schemas.UnifiedChatResponse, error) {
+	content := strings.TrimSpace(resp.ModelResponse.Message.Content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	content = strings.TrimSpace(content)
+
+	var object map[string]interface{}
+
+	if err := json.Unmarshal([]byte(content), &object); err != nil {
+		return nil, fmt.Errorf("%w: response is not a JSON object: %v", ErrInvalidStructuredOutput, err)
+	}
+
+	if format.Type == schemas.ResponseFormatJSONSchema {
+		required, _ := format.JSONSchema.Schema["required"].([]interface{})
+
+		for _, property := range required {
+			name, _ := property.(string)
+
+			if _, found := object[name]; !found {
+				return nil, fmt.Errorf("%w: required property %q is missing", ErrInvalidStructuredOutput, name)
+			}
+		}
+	}
+
+	resp.ModelResponse.Message.Content = content
+
+	return resp, nil
+}
@@ -2,6 +2,8 @@ package providers
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"time"
 
 	"glide/pkg/routers/latency"
@@ -12,6 +14,11 @@ import (
 type ResponseMock struct {
 	Msg string
 	Err *error
+	// DropAfter is the number of words streamed before the stream is dropped without the finish reason
+	DropAfter *int
+	// Delay is how long the response takes
+	Delay      time.Duration
+	TokenUsage schemas.TokenUsage
 }
 
 func (m *ResponseMock) Resp() *schemas.UnifiedChatResponse {
@@ -24,13 +31,16 @@ func (m *ResponseMock) Resp() *schemas.UnifiedChatResponse {
 			Message: schemas.ChatMessage{
 				Content: m.Msg,
 			},
+			TokenUsage: m.TokenUsage,
 		},
 	}
 }
 
 type ProviderMock struct {
-	idx       int
-	responses []ResponseMock
+	mu          sync.Mutex
+	idx         int
+	responses   []ResponseMock
+	lastRequest *schemas.UnifiedChatRequest
 }
 
 func NewProviderMock(responses []ResponseMock) *ProviderMock {
@@ -40,9 +50,20 @@ func NewProviderMock(responses []ResponseMock) *ProviderMock {
 	}
 }
 
-func (c *ProviderMock) Chat(_ context.Context, _ *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+func (c *ProviderMock) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	c.mu.Lock()
+	c.lastRequest = request
 	response := c.responses[c.idx]
 	c.idx++
+	c.mu.Unlock()
+
+	if response.Delay > 0 {
+		select {
+		case <-time.After(response.Delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 
 	if response.Err != nil {
 		return nil, *response.Err
@@ -51,10 +72,105 @@ func (c *ProviderMock) Chat(_ context.Context, _ *schemas.UnifiedChatRequest) (*
 	return response.Resp(), nil
 }
 
+// LastRequest returns the last chat request the mock has received
+func (c *ProviderMock) LastRequest() *schemas.UnifiedChatRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastRequest
+}
+
 func (c *ProviderMock) Provider() string {
 	return "provider_mock"
 }
 
+// ToolCallingProviderMock is a provider mock that could serve requests with tools
+type ToolCallingProviderMock struct {
+	*ProviderMock
+}
+
+func NewToolCallingProviderMock(responses []ResponseMock) *ToolCallingProviderMock {
+	return &ToolCallingProviderMock{
+		ProviderMock: NewProviderMock(responses),
+	}
+}
+
+func (c *ToolCallingProviderMock) SupportsTools() bool {
+	return true
+}
+
+// VisionProviderMock is a provider mock that could serve requests with images
+type VisionProviderMock struct {
+	*ProviderMock
+}
+
+func NewVisionProviderMock(responses []ResponseMock) *VisionProviderMock {
+	return &VisionProviderMock{
+		ProviderMock: NewProviderMock(responses),
+	}
+}
+
+func (c *VisionProviderMock) SupportsVision() bool {
+	return true
+}
+
+// StreamingProviderMock is a provider mock that streams each response as chunks of its words
+type StreamingProviderMock struct {
+	*ProviderMock
+}
+
+func NewStreamingProviderMock(responses []ResponseMock) *StreamingProviderMock {
+	return &StreamingProviderMock{
+		ProviderMock: NewProviderMock(responses),
+	}
+}
+
+func (c *StreamingProviderMock) ChatStream(ctx context.Context, request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error) {
+	c.mu.Lock()
+	c.lastRequest = request
+	response := c.responses[c.idx]
+	c.idx++
+	c.mu.Unlock()
+
+	if response.Err != nil {
+		return nil, *response.Err
+	}
+
+	streamC := make(chan *schemas.ChatStreamResult)
+
+	go func() {
+		defer close(streamC)
+
+		words := strings.Fields(response.Msg)
+		dropped := response.DropAfter != nil
+
+		if dropped {
+			words = words[:*response.DropAfter]
+		}
+
+		for idx, word := range words {
+			chunk := &schemas.UnifiedChatStreamChunk{
+				ID: "rsp0001",
+				ModelResponse: schemas.ProviderChunkResponse{
+					Message: schemas.ChatMessage{Content: word},
+				},
+			}
+
+			if idx == len(words)-1 && !dropped {
+				chunk.FinishReason = "stop"
+			}
+
+			select {
+			case streamC <- &schemas.ChatStreamResult{Chunk: chunk}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return streamC, nil
+}
+
 type LangModelMock struct {
 	modelID string
 	healthy bool
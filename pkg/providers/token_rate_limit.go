@@ -0,0 +1,109 @@
+package providers
+
+import (
+	"context"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/health"
+)
+
+// TokenRateLimitConfig keeps the model within the tokens-per-minute limit of its provider.
+// Input & output tokens of requests are estimated before sending them & reconciled with the actual usage afterwards
+type TokenRateLimitConfig struct {
+	TokensPerMinute int `yaml:"tokens_per_minute" json:"tokens_per_minute" validate:"gt=0"`
+	// ExpectedOutputTokens is the output estimate of requests without max_tokens
+	ExpectedOutputTokens int `yaml:"expected_output_tokens,omitempty" json:"expected_output_tokens" validate:"gt=0"`
+}
+
+func DefaultTokenRateLimitConfig() *TokenRateLimitConfig {
+	return &TokenRateLimitConfig{
+		ExpectedOutputTokens: 256,
+	}
+}
+
+func (c *TokenRateLimitConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultTokenRateLimitConfig()
+
+	type plain TokenRateLimitConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// tokenRateLimit tracks token usage of the model against its TPM limit
+type tokenRateLimit struct {
+	config  *TokenRateLimitConfig
+	limiter *health.TokenRateLimiter
+}
+
+// SetTokenRateLimit limits the number of tokens the model could process per minute (nil disables the limit)
+func (m *LangModel) SetTokenRateLimit(cfg *TokenRateLimitConfig) {
+	if cfg == nil {
+		m.tokenRateLimit = nil
+
+		return
+	}
+
+	m.tokenRateLimit = &tokenRateLimit{
+		config:  cfg,
+		limiter: health.NewTokenRateLimiter(cfg.TokensPerMinute),
+	}
+}
+
+// reserveTokens takes the estimated number of request tokens from the TPM budget.
+// The model is rate limited until the budget is refilled if there are not enough tokens
+func (m *LangModel) reserveTokens(ctx context.Context, client LangModelProvider, request *schemas.UnifiedChatRequest) (int, error) {
+	if m.tokenRateLimit == nil {
+		return 0, nil
+	}
+
+	tokens := m.tokenRateLimit.estimate(client, request)
+
+	reserved, untilAvailable := m.tokenRateLimit.limiter.Reserve(tokens)
+	if !reserved {
+		err := clients.NewRateLimitError(&untilAvailable)
+		m.recordFailure(ctx, err)
+
+		return 0, err
+	}
+
+	return tokens, nil
+}
+
+// reconcileTokens corrects the reservation by the actual token usage (if the provider has reported it).
+// The model is rate limited if the usage has put the budget into debt
+func (m *LangModel) reconcileTokens(reserved int, usage *schemas.TokenUsage) {
+	if m.tokenRateLimit == nil {
+		return
+	}
+
+	used := reserved
+
+	if usage != nil && usage.TotalTokens > 0 {
+		used = int(usage.TotalTokens)
+	}
+
+	if untilAvailable := m.tokenRateLimit.limiter.Reconcile(reserved, used); untilAvailable > 0 {
+		m.rateLimit.SetLimited(untilAvailable)
+	}
+}
+
+// refundTokens returns the reservation of the failed request back to the budget
+func (m *LangModel) refundTokens(reserved int) {
+	if m.tokenRateLimit == nil {
+		return
+	}
+
+	m.tokenRateLimit.limiter.Reconcile(reserved, 0)
+}
+
+// estimate counts input tokens of the request by the model tokenizer & expects the output to take max tokens of all completions
+func (l *tokenRateLimit) estimate(client LangModelProvider, request *schemas.UnifiedChatRequest) int {
+	outputTokens := l.config.ExpectedOutputTokens
+
+	if request.Override.Params != nil && request.Override.Params.MaxTokens != nil {
+		outputTokens = *request.Override.Params.MaxTokens
+	}
+
+	return CountContextTokens(ModelTokenizer(client), request) + outputTokens*max(request.N, 1)
+}
@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"glide/pkg/api/schemas"
+)
+
+// estimateUsage fills the token usage the provider has not reported by counting tokens of the request & the response
+// with the model tokenizer, so latency per token & cost accounting keep working
+func (m *LangModel) estimateUsage(request *schemas.UnifiedChatRequest, resp *schemas.UnifiedChatResponse) {
+	completions := resp.ModelResponse.Choices
+	if len(completions) == 0 {
+		completions = []schemas.ChatMessage{resp.ModelResponse.Message}
+	}
+
+	m.fillUsage(request, &resp.ModelResponse.TokenUsage, completions...)
+}
+
+// estimateStreamUsage sets the estimated token usage on the last chunk of the stream if the provider has not reported it
+func (m *LangModel) estimateStreamUsage(request *schemas.UnifiedChatRequest, chunk *schemas.UnifiedChatStreamChunk, content string) {
+	if chunk.ModelResponse.TokenUsage == nil {
+		chunk.ModelResponse.TokenUsage = &schemas.TokenUsage{}
+	}
+
+	m.fillUsage(request, chunk.ModelResponse.TokenUsage, schemas.ChatMessage{Content: content})
+}
+
+func (m *LangModel) fillUsage(request *schemas.UnifiedChatRequest, usage *schemas.TokenUsage, completions ...schemas.ChatMessage) {
+	if usage.PromptTokens > 0 && usage.ResponseTokens > 0 {
+		return
+	}
+
+	tokenizer := m.Tokenizer()
+
+	if usage.PromptTokens <= 0 {
+		usage.PromptTokens = float64(CountContextTokens(tokenizer, request))
+		usage.Estimated = true
+	}
+
+	if usage.ResponseTokens <= 0 {
+		responseTokens := 0
+
+		for _, completion := range completions {
+			// the message overhead is not a part of the output
+			responseTokens += CountMessageTokens(tokenizer, &completion) - messageOverheadTokens
+		}
+
+		if responseTokens > 0 {
+			usage.ResponseTokens = float64(responseTokens)
+			usage.Estimated = true
+		}
+	}
+
+	usage.TotalTokens = usage.PromptTokens + usage.ResponseTokens
+}
@@ -0,0 +1,154 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers/clients"
+)
+
+// Warmable is implemented by models that could be warmed up before serving real requests
+type Warmable interface {
+	Warmup(ctx context.Context) error
+}
+
+// ConnectionWarmer is implemented by provider clients that could open connections to their API ahead of requests
+type ConnectionWarmer interface {
+	WarmupConnections(ctx context.Context, connections int) error
+}
+
+// ConnWarmable is implemented by models that keep connections to their provider established
+type ConnWarmable interface {
+	ConnWarmupDue() bool
+	WarmupConnections(ctx context.Context) error
+}
+
+// LatencyRefreshable is implemented by models whose latency could be refreshed while they get no traffic
+// (e.g. least latency routing stopped picking them after they got slow)
+type LatencyRefreshable interface {
+	LatencyIdle() bool
+	ProbeLatency(ctx context.Context) error
+	DecayLatency(target float64, rate float64)
+}
+
+// newWarmupRequest builds the smallest request possible, so warming models up costs next to nothing
+func newWarmupRequest() *schemas.UnifiedChatRequest {
+	maxTokens := 1
+
+	return &schemas.UnifiedChatRequest{
+		Message: schemas.ChatMessage{
+			Role:    schemas.RoleUser,
+			Content: "ping",
+		},
+		Override: schemas.OverrideChatRequest{
+			Params: &schemas.ChatParams{MaxTokens: &maxTokens},
+		},
+	}
+}
+
+// Warmup sends a tiny request to the model, so the first real request doesn't pay for the cold start
+// (e.g. DNS lookups, TLS handshakes & tokenizer init). The latency of the request pre-seeds the moving average.
+// The model is not in use yet, so failures don't burn its error budget
+func (m *LangModel) Warmup(ctx context.Context) error {
+	request := newWarmupRequest()
+
+	CountContextTokens(m.Tokenizer(), request)
+
+	startedAt := time.Now()
+
+	resp, err := m.activeClient().Chat(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	// providers may not report the usage of such short responses, while the latency is normalized per token
+	m.latencyRecorder.Add(float64(time.Since(startedAt)) / max(resp.ModelResponse.TokenUsage.ResponseTokens, 1))
+
+	return nil
+}
+
+// Verifiable is implemented by models that could check their provider is reachable with configured credentials
+type Verifiable interface {
+	Verify(ctx context.Context) error
+}
+
+// Verify sends the warmup request to the provider to check the model could serve traffic (e.g. on startup).
+// Unlike real requests, the check doesn't count in latency & health stats, but the model that fails it starts unhealthy:
+// rate limited models wait for the limit reset, others get their error budget exhausted, so they recover over time.
+// Checks that have run out of time are not held against models, as slow providers could still serve traffic
+func (m *LangModel) Verify(ctx context.Context) error {
+	request := newWarmupRequest()
+
+	CountContextTokens(m.Tokenizer(), request)
+
+	_, err := m.activeClient().Chat(ctx, request)
+	if err == nil {
+		return nil
+	}
+
+	var rle *clients.RateLimitError
+
+	if errors.As(err, &rle) {
+		m.rateLimit.SetLimited(m.rateLimitCooldownOf(rle))
+
+		return err
+	}
+
+	if ctx.Err() == nil {
+		m.errorBudget.Drain()
+	}
+
+	return err
+}
+
+// LatencyIdle checks if the model latency has not been sampled over its update interval
+func (m *LangModel) LatencyIdle() bool {
+	return m.latencyRecorder.Idle()
+}
+
+// ProbeLatency samples the model latency by the warmup request. The model is marked as sampled upfront,
+// so failing probes are not repeated until the next update interval.
+// Probes go straight to the client, so they don't show up in router metrics & burn the error budget
+func (m *LangModel) ProbeLatency(ctx context.Context) error {
+	m.latencyRecorder.Touch()
+
+	return m.Warmup(ctx)
+}
+
+// DecayLatency moves the model latency toward the target (e.g. the router median), so idle models re-enter the rotation
+func (m *LangModel) DecayLatency(target float64, rate float64) {
+	m.latencyRecorder.Decay(target, rate)
+}
+
+// SetConnWarmup makes the model keep connections to the provider established (nil disables it)
+func (m *LangModel) SetConnWarmup(cfg *clients.ConnWarmupConfig) {
+	m.connWarmup = cfg
+}
+
+// ConnWarmupDue checks if connections to the provider are to be re-established
+func (m *LangModel) ConnWarmupDue() bool {
+	if m.connWarmup == nil {
+		return false
+	}
+
+	if _, ok := m.activeClient().(ConnectionWarmer); !ok {
+		return false
+	}
+
+	return time.Since(time.Unix(0, m.connWarmedAt.Load())) >= m.connWarmup.Interval
+}
+
+// WarmupConnections opens connections to the provider, so requests don't pay for TCP & TLS handshakes.
+// Warmup requests don't go through the chat API, so they are not counted in latency or health stats
+func (m *LangModel) WarmupConnections(ctx context.Context) error {
+	warmer, ok := m.activeClient().(ConnectionWarmer)
+	if !ok || m.connWarmup == nil {
+		return nil
+	}
+
+	m.connWarmedAt.Store(time.Now().UnixNano())
+
+	return warmer.WarmupConnections(ctx, m.connWarmup.Connections)
+}
@@ -0,0 +1,78 @@
+package routers
+
+import (
+	"context"
+	"sync"
+
+	"glide/pkg/api/schemas"
+)
+
+// MaxBatchConcurrency bounds how many requests of one batch are served at the same time
+const MaxBatchConcurrency = 64
+
+// BatchResult is the outcome of one request of the batch: either the response or the error it has failed with
+type BatchResult struct {
+	Response *schemas.UnifiedChatResponse
+	Err      error
+}
+
+// ChatBatch serves requests of the batch concurrently, so offline jobs don't pay the HTTP overhead of each request.
+// Up to maxConcurrency requests are in flight (one per router model if it's not positive), and they are routed
+// just like separate requests. Results keep the order of requests, and failures of some requests don't fail others.
+// Once the context is done, requests that are not dispatched yet fail with the context error
+func (r *LangRouter) ChatBatch(ctx context.Context, requests []*schemas.UnifiedChatRequest, maxConcurrency int) ([]BatchResult, error) {
+	if r == nil {
+		return nil, ErrRouterNotFound
+	}
+
+	if err := r.checkBatchSize(len(requests)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(requests))
+	slots := make(chan struct{}, r.batchConcurrency(maxConcurrency))
+
+	var wg sync.WaitGroup
+
+	for idx, request := range requests {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if err := ctx.Err(); err != nil {
+			// requests that have not been dispatched yet fail right away, so the batch doesn't outlive its context
+			for pending := idx; pending < len(requests); pending++ {
+				results[pending] = BatchResult{Err: err}
+			}
+
+			break
+		}
+
+		wg.Add(1)
+
+		go func(idx int, request *schemas.UnifiedChatRequest) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			resp, err := r.Chat(ctx, request)
+
+			results[idx] = BatchResult{Response: resp, Err: err}
+		}(idx, request)
+	}
+
+	wg.Wait()
+
+	return results, nil
+}
+
+// batchConcurrency bounds the requested concurrency of batches
+func (r *LangRouter) batchConcurrency(requested int) int {
+	if requested <= 0 {
+		requested = len(r.models)
+	}
+
+	return max(1, min(requested, MaxBatchConcurrency))
+}
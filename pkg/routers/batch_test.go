@@ -0,0 +1,151 @@
+package routers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+)
+
+// echoProviderMock responds with the request message & tracks how many requests it serves at the same time
+type echoProviderMock struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *echoProviderMock) Chat(_ context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	inFlight := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+
+	for {
+		maxInFlight := c.maxInFlight.Load()
+		if inFlight <= maxInFlight || c.maxInFlight.CompareAndSwap(maxInFlight, inFlight) {
+			break
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	return &schemas.UnifiedChatResponse{
+		ModelResponse: schemas.ProviderResponse{
+			Message: schemas.ChatMessage{Role: "assistant", Content: request.Message.Content},
+		},
+	}, nil
+}
+
+func (c *echoProviderMock) Provider() string {
+	return "echo_mock"
+}
+
+// slowProviderMock takes its time to respond regardless of the request context
+type slowProviderMock struct {
+	calls atomic.Int32
+}
+
+func (c *slowProviderMock) Chat(_ context.Context, _ *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	c.calls.Add(1)
+
+	time.Sleep(50 * time.Millisecond)
+
+	return &schemas.UnifiedChatResponse{
+		ModelResponse: schemas.ProviderResponse{
+			Message: schemas.ChatMessage{Role: "assistant", Content: "slow"},
+		},
+	}, nil
+}
+
+func (c *slowProviderMock) Provider() string {
+	return "slow_mock"
+}
+
+func TestLangRouter_ChatBatch(t *testing.T) {
+	provider := &echoProviderMock{}
+	router := newTestRouter(t, []providers.LanguageModel{newMockModel("first", provider)}, withLimits(&LimitsConfig{MaxMessages: 1}))
+
+	tooLong := schemas.NewChatFromStr("second")
+	tooLong.MessageHistory = []schemas.ChatMessage{{Role: "user", Content: "hi"}}
+
+	requests := []*schemas.UnifiedChatRequest{
+		schemas.NewChatFromStr("first"),
+		tooLong,
+		schemas.NewChatFromStr("third"),
+		schemas.NewChatFromStr("fourth"),
+		schemas.NewChatFromStr("fifth"),
+	}
+
+	results, err := router.ChatBatch(context.Background(), requests, 2)
+	require.NoError(t, err)
+	require.Len(t, results, len(requests))
+
+	// results keep the order of requests, while the failed one doesn't fail others
+	for idx, content := range []string{"first", "", "third", "fourth", "fifth"} {
+		if content == "" {
+			require.ErrorIs(t, results[idx].Err, ErrRequestTooLarge)
+			require.Nil(t, results[idx].Response)
+
+			continue
+		}
+
+		require.NoError(t, results[idx].Err)
+		require.Equal(t, content, results[idx].Response.ModelResponse.Message.Content)
+	}
+
+	require.LessOrEqual(t, provider.maxInFlight.Load(), int32(2))
+}
+
+func TestLangRouter_ChatBatchLimited(t *testing.T) {
+	router := newTestRouter(t, []providers.LanguageModel{newMockModel("first", &echoProviderMock{})}, withLimits(&LimitsConfig{MaxBatchSize: 2}))
+
+	requests := []*schemas.UnifiedChatRequest{
+		schemas.NewChatFromStr("first"),
+		schemas.NewChatFromStr("second"),
+		schemas.NewChatFromStr("third"),
+	}
+
+	_, err := router.ChatBatch(context.Background(), requests, 0)
+	require.ErrorIs(t, err, ErrRequestTooLarge)
+
+	results, err := router.ChatBatch(context.Background(), requests[:2], 0)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// batches are bounded even if the limit is not configured
+	unlimitedRouter := newTestRouter(t, []providers.LanguageModel{newMockModel("first", &echoProviderMock{})})
+
+	_, err = unlimitedRouter.ChatBatch(context.Background(), make([]*schemas.UnifiedChatRequest, DefaultMaxBatchSize+1), 0)
+	require.ErrorIs(t, err, ErrRequestTooLarge)
+
+	// concurrency defaults to the number of router models & is bounded
+	require.Equal(t, 1, router.batchConcurrency(0))
+	require.Equal(t, MaxBatchConcurrency, router.batchConcurrency(1000))
+}
+
+func TestLangRouter_ChatBatchCancelled(t *testing.T) {
+	provider := &slowProviderMock{}
+	router := newTestRouter(t, []providers.LanguageModel{newMockModel("first", provider)})
+
+	requests := []*schemas.UnifiedChatRequest{
+		schemas.NewChatFromStr("first"),
+		schemas.NewChatFromStr("second"),
+		schemas.NewChatFromStr("third"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	results, err := router.ChatBatch(ctx, requests, 1)
+	require.NoError(t, err)
+	require.Len(t, results, len(requests))
+
+	// the batch stops dispatching requests once the client is gone
+	require.Equal(t, int32(1), provider.calls.Load())
+
+	for _, result := range results[1:] {
+		require.Equal(t, context.DeadlineExceeded, result.Err)
+		require.Nil(t, result.Response)
+	}
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"glide/pkg/api/schemas"
+)
+
+// Cache stores chat responses by request keys (see Key())
+type Cache interface {
+	// Get returns the cached response. Each call returns a new copy marked as cached
+	Get(ctx context.Context, key string) (*schemas.UnifiedChatResponse, bool)
+	Set(ctx context.Context, key string, response *schemas.UnifiedChatResponse)
+}
+
+// cacheKey is everything that affects the response of the router
+type cacheKey struct {
+	RouterID         string                      `json:"router"`
+	RouteHint        string                      `json:"route_hint,omitempty"`
+	Messages         []schemas.ChatMessage       `json:"messages"`
+	Override         schemas.OverrideChatRequest `json:"override"`
+	Seed             *int                        `json:"seed,omitempty"`
+	PresencePenalty  *float64                    `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64                    `json:"frequency_penalty,omitempty"`
+	LogitBias        map[string]float64          `json:"logit_bias,omitempty"`
+	Logprobs         bool                        `json:"logprobs,omitempty"`
+	TopLogprobs      *int                        `json:"top_logprobs,omitempty"`
+	Tools            []schemas.ToolDefinition    `json:"tools,omitempty"`
+	ToolChoice       *schemas.ToolChoice         `json:"tool_choice,omitempty"`
+	ResponseFormat   *schemas.ResponseFormat     `json:"response_format,omitempty"`
+	N                int                         `json:"n,omitempty"`
+	TemplateVars     map[string]string           `json:"template_vars,omitempty"`
+	SkipTemplate     bool                        `json:"skip_template,omitempty"`
+}
+
+// Key hashes the router ID, the route hint (as hints route over different models), the conversation & params of the request.
+// The conversation is hashed the same way whether it's passed as messages or as the message with its history
+func Key(routerID string, request *schemas.UnifiedChatRequest) (string, error) {
+	key, err := json.Marshal(cacheKey{
+		RouterID:         routerID,
+		RouteHint:        request.RouteHint,
+		Messages:         request.ChatMessages(),
+		Override:         request.Override,
+		Seed:             request.Seed,
+		PresencePenalty:  request.PresencePenalty,
+		FrequencyPenalty: request.FrequencyPenalty,
+		LogitBias:        request.LogitBias,
+		Logprobs:         request.Logprobs,
+		TopLogprobs:      request.TopLogprobs,
+		Tools:            request.Tools,
+		ToolChoice:       request.ToolChoice,
+		ResponseFormat:   request.ResponseFormat,
+		N:                request.N,
+		TemplateVars:     request.TemplateVars,
+		SkipTemplate:     request.SkipTemplate,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(key)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Cacheable checks if the request response could be cached.
+// Requests with temperature > 0 are expected to get different responses, so they are cached only if forced.
+// Temperature defaults of models are not taken into account
+func (c *Config) Cacheable(request *schemas.UnifiedChatRequest) bool {
+	if c.Force {
+		return true
+	}
+
+	params := request.Override.Params
+
+	return params == nil || params.Temperature == nil || *params.Temperature == 0
+}
+
+type refreshKey struct{}
+
+// WithRefresh marks the request context, so the cached response is skipped & replaced by a fresh one
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// RefreshRequested checks if the cached response should be skipped (see WithRefresh())
+func RefreshRequested(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+
+	return refresh
+}
@@ -0,0 +1,28 @@
+package cache
+
+import "time"
+
+// Config defines the response cache of the router
+type Config struct {
+	TTL        time.Duration `yaml:"ttl,omitempty" json:"ttl" swaggertype:"primitive,integer" validate:"gt=0"` // how long responses are served from the cache
+	MaxEntries int           `yaml:"max_entries,omitempty" json:"max_entries" validate:"gt=0"`                 // the least recently used responses are evicted over this limit
+	// Force caches requests with temperature > 0 too (they are expected to get different responses, so they are not cached by default)
+	Force bool `yaml:"force,omitempty" json:"force"`
+	// Semantic also serves responses of similar prompts (disabled by default)
+	Semantic *SemanticConfig `yaml:"semantic,omitempty" json:"semantic,omitempty"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		TTL:        5 * time.Minute,
+		MaxEntries: 1000,
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"glide/pkg/api/schemas"
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key has been already used with a different request")
+
+// IdempotencyConfig defines how long responses are replayed to requests repeated with the same idempotency key
+type IdempotencyConfig struct {
+	TTL        time.Duration `yaml:"ttl,omitempty" json:"ttl" swaggertype:"primitive,integer" validate:"gt=0"` // how long responses are kept for replays
+	MaxEntries int           `yaml:"max_entries,omitempty" json:"max_entries" validate:"gt=0"`                 // the least recently used keys are evicted over this limit
+}
+
+func DefaultIdempotencyConfig() *IdempotencyConfig {
+	return &IdempotencyConfig{
+		TTL:        10 * time.Minute,
+		MaxEntries: 1000,
+	}
+}
+
+func (c *IdempotencyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultIdempotencyConfig()
+
+	type plain IdempotencyConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey marks the request context with the key the client has given to the request,
+// so repeated requests are served with the response of the first one
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKey returns the key set by WithIdempotencyKey (empty if the request has none)
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+
+	return key
+}
+
+// IdempotencyEntry is the response stored under the idempotency key together with the fingerprint (see Key())
+// of the request it has been given to, so repeated requests could be told from different ones reusing the key
+type IdempotencyEntry struct {
+	Fingerprint string                       `json:"fingerprint"`
+	Response    *schemas.UnifiedChatResponse `json:"response"`
+}
+
+// IdempotencyCache stores idempotency entries by their keys
+type IdempotencyCache interface {
+	Get(ctx context.Context, key string) (*IdempotencyEntry, bool)
+	Set(ctx context.Context, key string, entry *IdempotencyEntry)
+}
+
+// MemoryIdempotencyCache is an in-memory LRU cache of idempotency entries with expiration
+type MemoryIdempotencyCache struct {
+	*memoryLRU[IdempotencyEntry]
+}
+
+func NewMemoryIdempotencyCache(ttl time.Duration, maxEntries int) *MemoryIdempotencyCache {
+	return &MemoryIdempotencyCache{
+		memoryLRU: newMemoryLRU[IdempotencyEntry](ttl, maxEntries),
+	}
+}
+
+func (c *MemoryIdempotencyCache) Get(_ context.Context, key string) (*IdempotencyEntry, bool) {
+	entry, found := c.get(key)
+	if !found {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+func (c *MemoryIdempotencyCache) Set(_ context.Context, key string, entry *IdempotencyEntry) {
+	response := *entry.Response
+
+	c.set(key, IdempotencyEntry{Fingerprint: entry.Fingerprint, Response: &response})
+}
+
+// IdempotencyStore replays responses of requests repeated with the same idempotency key.
+// Entries are keyed on the idempotency key rather than on the request. Each one keeps the response
+// together with the request fingerprint, so both are evicted at once and a repeated request
+// is never mistaken for a different one
+type IdempotencyStore struct {
+	cache IdempotencyCache
+
+	mu       sync.Mutex
+	inFlight map[string]*idempotentCall
+}
+
+// idempotentCall is the request being served, so its repeats wait for its response instead of making another one
+type idempotentCall struct {
+	fingerprint string
+	done        chan struct{}
+	response    *schemas.UnifiedChatResponse
+	err         error
+}
+
+func NewIdempotencyStore(cache IdempotencyCache) *IdempotencyStore {
+	return &IdempotencyStore{
+		cache:    cache,
+		inFlight: make(map[string]*idempotentCall),
+	}
+}
+
+// Do serves the request by the chat func unless the key has been already used.
+// Responses of repeated requests are replayed, while different requests reusing the key are rejected.
+// Failed requests are not stored, so they could be retried with the same key
+func (s *IdempotencyStore) Do(
+	ctx context.Context,
+	routerID string,
+	key string,
+	request *schemas.UnifiedChatRequest,
+	chat func(context.Context) (*schemas.UnifiedChatResponse, error),
+) (*schemas.UnifiedChatResponse, error) {
+	fingerprint, err := Key(routerID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	entryKey := fmt.Sprintf("idempotency:%v:%v", routerID, key)
+
+	if entry, found := s.cache.Get(ctx, entryKey); found && entry.Response != nil {
+		if entry.Fingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+
+		response := *entry.Response
+		response.Cached = true
+
+		return &response, nil
+	}
+
+	s.mu.Lock()
+
+	if call, found := s.inFlight[entryKey]; found {
+		s.mu.Unlock()
+
+		return s.wait(ctx, call, fingerprint)
+	}
+
+	call := &idempotentCall{fingerprint: fingerprint, done: make(chan struct{})}
+	s.inFlight[entryKey] = call
+
+	s.mu.Unlock()
+
+	call.response, call.err = chat(ctx)
+
+	if call.err == nil {
+		s.cache.Set(ctx, entryKey, &IdempotencyEntry{Fingerprint: fingerprint, Response: call.response})
+	}
+
+	s.mu.Lock()
+	delete(s.inFlight, entryKey)
+	s.mu.Unlock()
+
+	close(call.done)
+
+	return call.response, call.err
+}
+
+// wait serves the repeated request with the response of the in-flight one
+func (s *IdempotencyStore) wait(
+	ctx context.Context,
+	call *idempotentCall,
+	fingerprint string,
+) (*schemas.UnifiedChatResponse, error) {
+	if call.fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	response := *call.response
+	response.Cached = true
+
+	return &response, nil
+}
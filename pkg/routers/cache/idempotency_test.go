@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+)
+
+func TestIdempotencyStore_ReplaysRepeatedRequests(t *testing.T) {
+	ctx := context.Background()
+	store := NewIdempotencyStore(NewMemoryIdempotencyCache(time.Minute, 10))
+
+	var calls int
+
+	chat := func(context.Context) (*schemas.UnifiedChatResponse, error) {
+		calls++
+
+		return &schemas.UnifiedChatResponse{ID: "resp"}, nil
+	}
+
+	resp, err := store.Do(ctx, "router", "key", schemas.NewChatFromStr("hello"), chat)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+
+	resp, err = store.Do(ctx, "router", "key", schemas.NewChatFromStr("hello"), chat)
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+	require.Equal(t, "resp", resp.ID)
+	require.Equal(t, 1, calls)
+
+	// keys are scoped by routers
+	_, err = store.Do(ctx, "another_router", "key", schemas.NewChatFromStr("hello"), chat)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotencyStore_RejectsDifferentRequestsWithSameKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewIdempotencyStore(NewMemoryIdempotencyCache(time.Minute, 10))
+
+	chat := func(context.Context) (*schemas.UnifiedChatResponse, error) {
+		return &schemas.UnifiedChatResponse{ID: "resp"}, nil
+	}
+
+	_, err := store.Do(ctx, "router", "key", schemas.NewChatFromStr("hello"), chat)
+	require.NoError(t, err)
+
+	_, err = store.Do(ctx, "router", "key", schemas.NewChatFromStr("bye"), chat)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestIdempotencyStore_DoesNotStoreFailures(t *testing.T) {
+	ctx := context.Background()
+	store := NewIdempotencyStore(NewMemoryIdempotencyCache(time.Minute, 10))
+	errChat := errors.New("provider is down")
+
+	_, err := store.Do(ctx, "router", "key", schemas.NewChatFromStr("hello"), func(context.Context) (*schemas.UnifiedChatResponse, error) {
+		return nil, errChat
+	})
+	require.ErrorIs(t, err, errChat)
+
+	resp, err := store.Do(ctx, "router", "key", schemas.NewChatFromStr("hello"), func(context.Context) (*schemas.UnifiedChatResponse, error) {
+		return &schemas.UnifiedChatResponse{ID: "resp"}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "resp", resp.ID)
+}
+
+func TestIdempotencyStore_RepeatsWaitForInFlightRequest(t *testing.T) {
+	ctx := context.Background()
+	store := NewIdempotencyStore(NewMemoryIdempotencyCache(time.Minute, 10))
+
+	var calls atomic.Int32
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+
+	chat := func(context.Context) (*schemas.UnifiedChatResponse, error) {
+		calls.Add(1)
+		close(started)
+		<-unblock
+
+		return &schemas.UnifiedChatResponse{ID: "resp"}, nil
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		_, err := store.Do(ctx, "router", "key", schemas.NewChatFromStr("hello"), chat)
+		require.NoError(t, err)
+	}()
+
+	<-started
+
+	_, err := store.Do(ctx, "router", "key", schemas.NewChatFromStr("bye"), chat)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		resp, err := store.Do(ctx, "router", "key", schemas.NewChatFromStr("hello"), chat)
+		require.NoError(t, err)
+		require.Equal(t, "resp", resp.ID)
+	}()
+
+	close(unblock)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyStore_EvictsKeysWithTheirFingerprints(t *testing.T) {
+	ctx := context.Background()
+	idempotencyCache := NewMemoryIdempotencyCache(time.Minute, 2)
+	store := NewIdempotencyStore(idempotencyCache)
+
+	var calls int
+
+	chat := func(context.Context) (*schemas.UnifiedChatResponse, error) {
+		calls++
+
+		return &schemas.UnifiedChatResponse{ID: "resp"}, nil
+	}
+
+	for _, key := range []string{"first", "second"} {
+		_, err := store.Do(ctx, "router", key, schemas.NewChatFromStr("hello"), chat)
+		require.NoError(t, err)
+	}
+
+	// each key takes a single entry
+	require.Equal(t, 2, idempotencyCache.Len())
+
+	// the conflicting reuse makes the first key the most recently used one
+	_, err := store.Do(ctx, "router", "first", schemas.NewChatFromStr("bye"), chat)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	_, err = store.Do(ctx, "router", "third", schemas.NewChatFromStr("hello"), chat)
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	resp, err := store.Do(ctx, "router", "first", schemas.NewChatFromStr("hello"), chat)
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+
+	// the evicted key is served as a new one rather than rejected
+	resp, err = store.Do(ctx, "router", "second", schemas.NewChatFromStr("hello"), chat)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, 4, calls)
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"glide/pkg/api/schemas"
+)
+
+// MemoryCache is an in-memory LRU cache of responses with expiration
+type MemoryCache struct {
+	*memoryLRU[schemas.UnifiedChatResponse]
+}
+
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		memoryLRU: newMemoryLRU[schemas.UnifiedChatResponse](ttl, maxEntries),
+	}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) (*schemas.UnifiedChatResponse, bool) {
+	response, found := c.get(key)
+	if !found {
+		return nil, false
+	}
+
+	response.Cached = true
+
+	return &response, true
+}
+
+func (c *MemoryCache) Set(_ context.Context, key string, response *schemas.UnifiedChatResponse) {
+	c.set(key, *response)
+}
+
+// memoryLRU is an in-memory LRU map with expiration that backs typed in-memory caches
+type memoryLRU[V any] struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // the most recently used entries go first
+}
+
+type memoryEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+func newMemoryLRU[V any](ttl time.Duration, maxEntries int) *memoryLRU[V] {
+	return &memoryLRU[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element, maxEntries),
+		lru:        list.New(),
+	}
+}
+
+// get returns a copy of the stored value
+func (c *memoryLRU[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var value V
+
+	element, found := c.entries[key]
+	if !found {
+		return value, false
+	}
+
+	entry := element.Value.(*memoryEntry[V])
+
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+
+		return value, false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return entry.value, true
+}
+
+func (c *memoryLRU[V]) set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry[V]{
+		key:       key,
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+
+	if element, found := c.entries[key]; found {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Len returns the number of cached entries (including expired ones that haven't been evicted yet)
+func (c *memoryLRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *memoryLRU[V]) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*memoryEntry[V]).key)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+)
+
+func TestMemoryCache_ExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	cache := NewMemoryCache(time.Minute, 10)
+	cache.now = func() time.Time { return now }
+
+	cache.Set(ctx, "key", &schemas.UnifiedChatResponse{ID: "resp"})
+
+	resp, found := cache.Get(ctx, "key")
+	require.True(t, found)
+	require.True(t, resp.Cached)
+	require.Equal(t, "resp", resp.ID)
+
+	now = now.Add(time.Minute)
+
+	_, found = cache.Get(ctx, "key")
+	require.False(t, found)
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(time.Minute, 2)
+
+	cache.Set(ctx, "first", &schemas.UnifiedChatResponse{ID: "first"})
+	cache.Set(ctx, "second", &schemas.UnifiedChatResponse{ID: "second"})
+
+	// the first entry becomes the most recently used one
+	_, found := cache.Get(ctx, "first")
+	require.True(t, found)
+
+	cache.Set(ctx, "third", &schemas.UnifiedChatResponse{ID: "third"})
+
+	require.Equal(t, 2, cache.Len())
+
+	_, found = cache.Get(ctx, "second")
+	require.False(t, found)
+
+	_, found = cache.Get(ctx, "first")
+	require.True(t, found)
+
+	_, found = cache.Get(ctx, "third")
+	require.True(t, found)
+}
+
+func TestKey_DependsOnRouterAndParams(t *testing.T) {
+	req := schemas.NewChatFromStr("tell me a dad joke")
+
+	key, err := Key("router", req)
+	require.NoError(t, err)
+
+	otherRouterKey, err := Key("other_router", req)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherRouterKey)
+
+	seed := 42
+	req.Seed = &seed
+
+	seededKey, err := Key("router", req)
+	require.NoError(t, err)
+	require.NotEqual(t, key, seededKey)
+
+	req.RouteHint = "quality"
+
+	hintedKey, err := Key("router", req)
+	require.NoError(t, err)
+	require.NotEqual(t, seededKey, hintedKey)
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"sync/atomic"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kinds of cache lookups
+const (
+	KindExact    = "exact"
+	KindSemantic = "semantic"
+)
+
+// Metrics tracks how effective the router cache is. Savings are estimated from token usage of cached responses,
+// as that's what the provider would have billed if the request was not served from the cache.
+// Methods are no-op on the nil metrics
+type Metrics struct {
+	routerID    string
+	hits        *prometheus.CounterVec
+	misses      *prometheus.CounterVec
+	savedTokens *prometheus.CounterVec
+	hitRatio    prometheus.Gauge
+
+	hitCount    atomic.Int64
+	lookupCount atomic.Int64
+}
+
+func NewMetrics(routerID string, tel *telemetry.Telemetry) *Metrics {
+	return &Metrics{
+		routerID: routerID,
+		hits: tel.Metrics.CounterVec(
+			"cache_hits_total",
+			"Number of chat requests served from the router cache",
+			"router", "model", "cache",
+		),
+		misses: tel.Metrics.CounterVec(
+			"cache_misses_total",
+			"Number of cacheable chat requests that were not found in the router cache and were served by models",
+			"router", "model",
+		),
+		savedTokens: tel.Metrics.CounterVec(
+			"cache_saved_tokens_total",
+			"Estimated number of tokens that were not spent because responses were served from the router cache",
+			"router", "model", "type",
+		),
+		hitRatio: tel.Metrics.GaugeVec(
+			"cache_hit_ratio",
+			"Share of cacheable chat requests served from the router cache since the router was (re)built",
+			"router",
+		).WithLabelValues(routerID),
+	}
+}
+
+// Hit records the response served from the cache
+func (m *Metrics) Hit(kind string, resp *schemas.UnifiedChatResponse) {
+	if m == nil {
+		return
+	}
+
+	usage := resp.ModelResponse.TokenUsage
+
+	m.hits.WithLabelValues(m.routerID, resp.ModelID, kind).Inc()
+	m.savedTokens.WithLabelValues(m.routerID, resp.ModelID, "prompt").Add(usage.PromptTokens)
+	m.savedTokens.WithLabelValues(m.routerID, resp.ModelID, "response").Add(usage.ResponseTokens)
+
+	m.observe(true)
+}
+
+// Miss records the response that had to be requested from the model
+func (m *Metrics) Miss(resp *schemas.UnifiedChatResponse) {
+	if m == nil {
+		return
+	}
+
+	m.misses.WithLabelValues(m.routerID, resp.ModelID).Inc()
+
+	m.observe(false)
+}
+
+func (m *Metrics) observe(hit bool) {
+	hits := m.hitCount.Load()
+
+	if hit {
+		hits = m.hitCount.Add(1)
+	}
+
+	lookups := m.lookupCount.Add(1)
+
+	m.hitRatio.Set(float64(hits) / float64(lookups))
+}
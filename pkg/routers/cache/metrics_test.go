@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"testing"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_EstimatesSavings(t *testing.T) {
+	tel := telemetry.NewTelemetryMock()
+	metrics := NewMetrics("router", tel)
+
+	resp := &schemas.UnifiedChatResponse{
+		ModelID: "model",
+		ModelResponse: schemas.ProviderResponse{
+			TokenUsage: schemas.TokenUsage{PromptTokens: 10, ResponseTokens: 5, TotalTokens: 15},
+		},
+	}
+
+	metrics.Miss(resp)
+	metrics.Hit(KindExact, resp)
+	metrics.Hit(KindSemantic, resp)
+
+	require.InDelta(t, 20.0, testutil.ToFloat64(metrics.savedTokens.WithLabelValues("router", "model", "prompt")), 0.0001)
+	require.InDelta(t, 10.0, testutil.ToFloat64(metrics.savedTokens.WithLabelValues("router", "model", "response")), 0.0001)
+	require.InDelta(t, 2.0/3.0, testutil.ToFloat64(metrics.hitRatio), 0.0001)
+
+	var nilMetrics *Metrics
+
+	nilMetrics.Hit(KindExact, resp)
+	nilMetrics.Miss(resp)
+}
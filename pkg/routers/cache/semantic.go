@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+// SemanticConfig defines the semantic cache that serves responses of similar prompts (e.g. rephrased questions)
+type SemanticConfig struct {
+	// Threshold is the min cosine similarity of prompts to serve the cached response
+	Threshold  float64                   `yaml:"threshold" json:"threshold" validate:"gt=0,lte=1"`
+	MaxEntries int                       `yaml:"max_entries,omitempty" json:"max_entries" validate:"gt=0"` // the oldest prompts are evicted over this limit
+	Embedder   *providers.EmbedderConfig `yaml:"embedder" json:"embedder" validate:"required"`             // the model to embed prompts with
+}
+
+func DefaultSemanticConfig() *SemanticConfig {
+	return &SemanticConfig{
+		Threshold:  0.95,
+		MaxEntries: 1000,
+	}
+}
+
+func (c *SemanticConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultSemanticConfig()
+
+	type plain SemanticConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// SemanticCache compares the last user message of requests by embeddings.
+// Only requests that are the same except for that message are compared (the same router, earlier messages & params)
+type SemanticCache struct {
+	embedder  providers.Embedder
+	store     VectorStore
+	threshold float64
+	logger    *zap.Logger
+}
+
+func NewSemanticCache(embedder providers.Embedder, store VectorStore, threshold float64, tel *telemetry.Telemetry) *SemanticCache {
+	return &SemanticCache{
+		embedder:  embedder,
+		store:     store,
+		threshold: threshold,
+		logger:    tel.Logger,
+	}
+}
+
+// NewSemanticCacheFromConfig creates the semantic cache with the in-memory vector store
+func NewSemanticCacheFromConfig(cfg *SemanticConfig, ttl time.Duration, tel *telemetry.Telemetry) (*SemanticCache, error) {
+	embedder, err := cfg.Embedder.ToEmbedder(tel)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSemanticCache(embedder, NewMemoryVectorStore(ttl, cfg.MaxEntries), cfg.Threshold, tel), nil
+}
+
+// SemanticLookup is the embedded prompt of the request
+type SemanticLookup struct {
+	partition string
+	prompt    string
+	vector    []float64
+}
+
+// Embed embeds the last user message of the request.
+// It returns nil if the request could not be cached semantically (e.g. the last message is a tool result or has images)
+func (c *SemanticCache) Embed(ctx context.Context, routerID string, request *schemas.UnifiedChatRequest) *SemanticLookup {
+	messages := request.ChatMessages()
+	lastMessage := messages[len(messages)-1]
+
+	switch lastMessage.Role {
+	case schemas.RoleSystem, schemas.RoleAssistant, schemas.RoleTool:
+		return nil
+	}
+
+	if len(lastMessage.ContentParts) > 0 || lastMessage.Content == "" {
+		return nil
+	}
+
+	contextRequest := *request
+	contextRequest.Message = schemas.ChatMessage{}
+	contextRequest.MessageHistory = nil
+	contextRequest.Messages = messages[:len(messages)-1]
+
+	partition, err := Key(routerID, &contextRequest)
+	if err != nil {
+		c.logger.Warn("failed to hash the request context, skipping the semantic cache", zap.Error(err))
+
+		return nil
+	}
+
+	vector, err := c.embedder.Embed(ctx, lastMessage.Content)
+	if err != nil {
+		c.logger.Warn("failed to embed the prompt, skipping the semantic cache", zap.String("routerID", routerID), zap.Error(err))
+
+		return nil
+	}
+
+	return &SemanticLookup{
+		partition: partition,
+		prompt:    lastMessage.Content,
+		vector:    vector,
+	}
+}
+
+// Get returns the response cached for the most similar prompt
+func (c *SemanticCache) Get(ctx context.Context, lookup *SemanticLookup) (*schemas.UnifiedChatResponse, bool) {
+	if lookup == nil {
+		return nil, false
+	}
+
+	match, found := c.store.Search(ctx, lookup.partition, lookup.vector, c.threshold)
+	if !found {
+		return nil, false
+	}
+
+	response := match.Entry.Response
+	response.Cached = true
+	response.SemanticCache = &schemas.SemanticCacheHit{
+		Prompt:     match.Entry.Prompt,
+		Similarity: match.Similarity,
+	}
+
+	return &response, true
+}
+
+// Set caches the response under the embedded prompt. It's a no-op if the prompt has not been embedded
+func (c *SemanticCache) Set(ctx context.Context, lookup *SemanticLookup, response *schemas.UnifiedChatResponse) {
+	if c == nil || lookup == nil {
+		return
+	}
+
+	c.store.Add(ctx, lookup.partition, &VectorEntry{
+		Prompt:   lookup.prompt,
+		Vector:   lookup.vector,
+		Response: *response,
+	})
+}
+
+type semanticBypassKey struct{}
+
+// WithoutSemantic marks the request context, so only exact cache matches are served
+func WithoutSemantic(ctx context.Context) context.Context {
+	return context.WithValue(ctx, semanticBypassKey{}, true)
+}
+
+// SemanticBypassed checks if the semantic cache should be skipped (see WithoutSemantic())
+func SemanticBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(semanticBypassKey{}).(bool)
+
+	return bypass
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+)
+
+// embedderMock returns predefined vectors of texts
+type embedderMock map[string][]float64
+
+func (e embedderMock) Embed(_ context.Context, text string) ([]float64, error) {
+	return e[text], nil
+}
+
+func TestSemanticCache_ServesSimilarPrompts(t *testing.T) {
+	ctx := context.Background()
+
+	embedder := embedderMock{
+		"What's the biggest animal?":    {1, 0, 0},
+		"Which animal is the biggest?":  {0.98, 0.2, 0},
+		"What's the smallest animal?":   {0.5, 0.5, 0.7},
+		"Tell me about the blue whale.": {0, 0, 1},
+	}
+
+	semanticCache := NewSemanticCache(embedder, NewMemoryVectorStore(time.Minute, 10), 0.95, telemetry.NewTelemetryMock())
+
+	lookup := semanticCache.Embed(ctx, "router", schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NotNil(t, lookup)
+
+	_, found := semanticCache.Get(ctx, lookup)
+	require.False(t, found)
+
+	semanticCache.Set(ctx, lookup, &schemas.UnifiedChatResponse{ID: "blue whale"})
+
+	request := &schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{{Role: schemas.RoleUser, Content: "Which animal is the biggest?"}},
+	}
+
+	resp, found := semanticCache.Get(ctx, semanticCache.Embed(ctx, "router", request))
+	require.True(t, found)
+	require.True(t, resp.Cached)
+	require.Equal(t, "blue whale", resp.ID)
+	require.Equal(t, "What's the biggest animal?", resp.SemanticCache.Prompt)
+	require.InDelta(t, 0.98, resp.SemanticCache.Similarity, 0.01)
+
+	// different prompts are not served
+	_, found = semanticCache.Get(ctx, semanticCache.Embed(ctx, "router", schemas.NewChatFromStr("What's the smallest animal?")))
+	require.False(t, found)
+
+	// prompts of other routers or conversations are not compared
+	_, found = semanticCache.Get(ctx, semanticCache.Embed(ctx, "other_router", request))
+	require.False(t, found)
+
+	request.Messages = append([]schemas.ChatMessage{
+		{Role: schemas.RoleUser, Content: "Tell me about the blue whale."},
+		{Role: schemas.RoleAssistant, Content: "It's the biggest animal."},
+	}, request.Messages...)
+
+	_, found = semanticCache.Get(ctx, semanticCache.Embed(ctx, "router", request))
+	require.False(t, found)
+}
+
+func TestSemanticCache_SkipsNonUserPrompts(t *testing.T) {
+	semanticCache := NewSemanticCache(embedderMock{}, NewMemoryVectorStore(time.Minute, 10), 0.95, telemetry.NewTelemetryMock())
+
+	request := &schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{
+			{Role: schemas.RoleUser, Content: "What's the weather in Paris?"},
+			{Role: schemas.RoleTool, Content: "sunny", ToolCallID: "call_1"},
+		},
+	}
+
+	require.Nil(t, semanticCache.Embed(context.Background(), "router", request))
+}
+
+func TestMemoryVectorStore_EvictsOldestEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryVectorStore(time.Minute, 2)
+
+	store.Add(ctx, "partition", &VectorEntry{Prompt: "first", Vector: []float64{1, 0}})
+	store.Add(ctx, "partition", &VectorEntry{Prompt: "second", Vector: []float64{0, 1}})
+	store.Add(ctx, "other_partition", &VectorEntry{Prompt: "third", Vector: []float64{1, 0}})
+
+	require.Equal(t, 2, store.Len())
+
+	_, found := store.Search(ctx, "partition", []float64{1, 0}, 0.99)
+	require.False(t, found)
+
+	match, found := store.Search(ctx, "other_partition", []float64{1, 0}, 0.99)
+	require.True(t, found)
+	require.Equal(t, "third", match.Entry.Prompt)
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"glide/pkg/api/schemas"
+)
+
+// VectorEntry is a response cached along with the embedding of its prompt
+type VectorEntry struct {
+	Prompt   string
+	Vector   []float64
+	Response schemas.UnifiedChatResponse
+}
+
+// VectorMatch is the cached entry similar to the searched vector
+type VectorMatch struct {
+	Entry      *VectorEntry
+	Similarity float64
+}
+
+// VectorStore keeps prompt embeddings. Entries are partitioned, so only prompts of the same context are compared
+type VectorStore interface {
+	// Search returns the most similar entry of the partition if its cosine similarity reaches the threshold
+	Search(ctx context.Context, partition string, vector []float64, threshold float64) (*VectorMatch, bool)
+	Add(ctx context.Context, partition string, entry *VectorEntry)
+}
+
+// MemoryVectorStore searches vectors by brute force, which is fine for thousands of entries.
+// The oldest entries are evicted over the limit
+type MemoryVectorStore struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu         sync.RWMutex
+	partitions map[string]map[*list.Element]struct{}
+	entries    *list.List // the newest entries go first
+}
+
+type memoryVectorEntry struct {
+	partition string
+	entry     *VectorEntry
+	norm      float64
+	expiresAt time.Time
+}
+
+func NewMemoryVectorStore(ttl time.Duration, maxEntries int) *MemoryVectorStore {
+	return &MemoryVectorStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		partitions: make(map[string]map[*list.Element]struct{}),
+		entries:    list.New(),
+	}
+}
+
+func (s *MemoryVectorStore) Search(_ context.Context, partition string, vector []float64, threshold float64) (*VectorMatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	norm := vectorNorm(vector)
+	if norm == 0 {
+		return nil, false
+	}
+
+	now := s.now()
+
+	var match *VectorMatch
+
+	for element := range s.partitions[partition] {
+		candidate := element.Value.(*memoryVectorEntry)
+
+		if !now.Before(candidate.expiresAt) || len(candidate.entry.Vector) != len(vector) {
+			continue
+		}
+
+		similarity := dotProduct(vector, candidate.entry.Vector) / (norm * candidate.norm)
+
+		if similarity >= threshold && (match == nil || similarity > match.Similarity) {
+			match = &VectorMatch{Entry: candidate.entry, Similarity: similarity}
+		}
+	}
+
+	return match, match != nil
+}
+
+func (s *MemoryVectorStore) Add(_ context.Context, partition string, entry *VectorEntry) {
+	norm := vectorNorm(entry.Vector)
+	if norm == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element := s.entries.PushFront(&memoryVectorEntry{
+		partition: partition,
+		entry:     entry,
+		norm:      norm,
+		expiresAt: s.now().Add(s.ttl),
+	})
+
+	if _, found := s.partitions[partition]; !found {
+		s.partitions[partition] = make(map[*list.Element]struct{})
+	}
+
+	s.partitions[partition][element] = struct{}{}
+
+	for s.entries.Len() > s.maxEntries {
+		s.remove(s.entries.Back())
+	}
+}
+
+// Len returns the number of stored entries (including expired ones that haven't been evicted yet)
+func (s *MemoryVectorStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.entries.Len()
+}
+
+func (s *MemoryVectorStore) remove(element *list.Element) {
+	partition := element.Value.(*memoryVectorEntry).partition
+
+	s.entries.Remove(element)
+	delete(s.partitions[partition], element)
+
+	if len(s.partitions[partition]) == 0 {
+		delete(s.partitions, partition)
+	}
+}
+
+func dotProduct(a []float64, b []float64) float64 {
+	var product float64
+
+	for idx := range a {
+		product += a[idx] * b[idx]
+	}
+
+	return product
+}
+
+func vectorNorm(vector []float64) float64 {
+	return math.Sqrt(dotProduct(vector, vector))
+}
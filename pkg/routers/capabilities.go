@@ -0,0 +1,274 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/routing"
+)
+
+var (
+	ErrNoCapableModel = fmt.Errorf(
+		"%w: no model of the router has the capabilities the request needs",
+		clients.ErrCapabilityNotSupported,
+	)
+	ErrContextLengthExceeded = errors.New("request exceeds the context window of router models")
+)
+
+// unlimitedContext is the context tier of requests that don't fit any declared context window,
+// so only models with unknown context windows could serve them
+const unlimitedContext = math.MaxInt
+
+// requirements describe what a model must have to serve the request
+type requirements struct {
+	capabilities  []string
+	contextTokens int // size of the conversation (counted only if some models declare their context windows)
+}
+
+// capableRouting keeps routing over models that meet each possible set of requirements.
+// Routing is prebuilt rather than filtered per request, because strategies keep their state between requests
+// (e.g. the round-robin position) and the sticky priority strategy would keep picking an incapable model
+type capableRouting struct {
+	models []providers.LanguageModel
+	// contextTiers are distinct context windows of the models in the ascending order
+	contextTiers []int
+	// tokenizers are distinct tokenizers of the models. Requests are counted by all of them,
+	// so the request fits the context of the model it's routed to whatever tokenizer the model uses
+	tokenizers []clients.Tokenizer
+	// routing is keyed by requirement sets (see requirementKey()). Sets no model meets are missing
+	routing map[string]routing.LangModelRouting
+}
+
+// buildCapableRouting builds routing for each combination of capabilities & context tiers requests may need
+func buildCapableRouting(cfg *LangRouterConfig, models []providers.LanguageModel) (*capableRouting, error) {
+	capable := &capableRouting{
+		models:       models,
+		contextTiers: contextTiers(models),
+		tokenizers:   tokenizers(models),
+		routing:      make(map[string]routing.LangModelRouting),
+	}
+
+	tiers := append([]int{0}, capable.contextTiers...)
+	tiers = append(tiers, unlimitedContext)
+
+	combinations := append([][]string{nil}, capabilityCombinations(clients.Capabilities)...)
+
+	for _, capabilities := range combinations {
+		for _, tier := range tiers {
+			if len(capabilities) == 0 && tier == 0 {
+				// requests without requirements go over the default router routing
+				continue
+			}
+
+			capableModels := make([]providers.LanguageModel, 0, len(models))
+
+			for _, model := range models {
+				if hasCapabilities(model, capabilities) && fitsContext(model, tier) {
+					capableModels = append(capableModels, model)
+				}
+			}
+
+			if len(capableModels) == 0 {
+				continue
+			}
+
+			modelRouting, err := cfg.BuildRouting(capableModels)
+			if err != nil {
+				return nil, err
+			}
+
+			capable.routing[requirementKey(capabilities, tier)] = modelRouting
+		}
+	}
+
+	return capable, nil
+}
+
+// requirements lists what the request needs from the model
+func (c *capableRouting) requirements(request *schemas.UnifiedChatRequest) requirements {
+	req := requirements{
+		capabilities: providers.RequiredCapabilities(request),
+	}
+
+	if c != nil && len(c.contextTiers) > 0 {
+		req.contextTokens = c.countTokens(request.ChatMessages())
+	}
+
+	return req
+}
+
+// countTokens counts the conversation with the most pessimistic of model tokenizers
+func (c *capableRouting) countTokens(messages []schemas.ChatMessage) int {
+	var maxTokens int
+
+	for _, tokenizer := range c.tokenizers {
+		maxTokens = max(maxTokens, providers.CountContextTokens(tokenizer, &schemas.UnifiedChatRequest{Messages: messages}))
+	}
+
+	return maxTokens
+}
+
+// Routing returns routing over models that meet the requirements (the default routing if there are none)
+func (c *capableRouting) Routing(defaultRouting routing.LangModelRouting, req requirements) (routing.LangModelRouting, error) {
+	tier := c.contextTier(req.contextTokens)
+
+	if len(req.capabilities) == 0 && tier == 0 {
+		return defaultRouting, nil
+	}
+
+	if c != nil {
+		if modelRouting, found := c.routing[requirementKey(req.capabilities, tier)]; found {
+			return modelRouting, nil
+		}
+	}
+
+	return nil, c.missingError(req, tier)
+}
+
+// contextTier returns the smallest context window the request fits in.
+// Zero means the request fits all models
+func (c *capableRouting) contextTier(contextTokens int) int {
+	if c == nil || contextTokens <= 0 || len(c.contextTiers) == 0 {
+		return 0
+	}
+
+	idx, _ := slices.BinarySearch(c.contextTiers, contextTokens)
+
+	switch idx {
+	case 0:
+		return 0
+	case len(c.contextTiers):
+		return unlimitedContext
+	default:
+		return c.contextTiers[idx]
+	}
+}
+
+// missingError lists requirements no model of the router meets
+func (c *capableRouting) missingError(req requirements, tier int) error {
+	var models []providers.LanguageModel
+
+	if c != nil {
+		models = c.models
+	}
+
+	var missing []string
+
+	for _, capability := range req.capabilities {
+		if !slices.ContainsFunc(models, func(model providers.LanguageModel) bool {
+			return providers.HasCapability(model, capability)
+		}) {
+			missing = append(missing, capability)
+		}
+	}
+
+	fitsAnyModel := slices.ContainsFunc(models, func(model providers.LanguageModel) bool {
+		return fitsContext(model, tier)
+	})
+
+	if len(missing) == 0 && !fitsAnyModel {
+		return fmt.Errorf(
+			"%w: the request takes ~%v tokens, while the largest context window is %v tokens",
+			ErrContextLengthExceeded,
+			req.contextTokens,
+			c.contextTiers[len(c.contextTiers)-1],
+		)
+	}
+
+	if !fitsAnyModel {
+		missing = append(missing, fmt.Sprintf("%v>=%v", clients.CapabilityMaxContextTokens, req.contextTokens))
+	}
+
+	if len(missing) == 0 {
+		// every requirement is met by some model, but not by the same one
+		return fmt.Errorf("%w: no model meets %v at the same time", ErrNoCapableModel, strings.Join(req.capabilities, ", "))
+	}
+
+	return fmt.Errorf("%w: missing %v", ErrNoCapableModel, strings.Join(missing, ", "))
+}
+
+func hasCapabilities(model providers.LanguageModel, capabilities []string) bool {
+	for _, capability := range capabilities {
+		if !providers.HasCapability(model, capability) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fitsContext checks if requests of the context tier fit the model context window
+func fitsContext(model providers.LanguageModel, tier int) bool {
+	maxContextTokens := providers.MaxContextTokens(model)
+
+	return maxContextTokens == 0 || maxContextTokens >= tier
+}
+
+// contextTiers returns distinct context windows the models declare
+func contextTiers(models []providers.LanguageModel) []int {
+	var tiers []int
+
+	for _, model := range models {
+		if maxContextTokens := providers.MaxContextTokens(model); maxContextTokens > 0 {
+			tiers = append(tiers, maxContextTokens)
+		}
+	}
+
+	slices.Sort(tiers)
+
+	return slices.Compact(tiers)
+}
+
+// tokenizers returns distinct tokenizers of the models
+func tokenizers(models []providers.LanguageModel) []clients.Tokenizer {
+	var tokenizers []clients.Tokenizer
+
+	seen := make(map[string]struct{}, len(models))
+
+	for _, model := range models {
+		tokenizer := providers.ModelTokenizer(model)
+
+		if _, found := seen[tokenizer.Name()]; found {
+			continue
+		}
+
+		seen[tokenizer.Name()] = struct{}{}
+		tokenizers = append(tokenizers, tokenizer)
+	}
+
+	return tokenizers
+}
+
+// capabilityCombinations returns all non-empty subsets of capabilities keeping their order
+func capabilityCombinations(capabilities []string) [][]string {
+	var combinations [][]string
+
+	for mask := 1; mask < 1<<len(capabilities); mask++ {
+		var combination []string
+
+		for idx, capability := range capabilities {
+			if mask&(1<<idx) != 0 {
+				combination = append(combination, capability)
+			}
+		}
+
+		combinations = append(combinations, combination)
+	}
+
+	return combinations
+}
+
+// requirementKey identifies the requirement set regardless of the capability order
+func requirementKey(capabilities []string, contextTier int) string {
+	sorted := slices.Clone(capabilities)
+	slices.Sort(sorted)
+
+	return strings.Join(sorted, ",") + "|" + strconv.Itoa(contextTier)
+}
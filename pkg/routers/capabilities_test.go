@@ -0,0 +1,182 @@
+package routers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+)
+
+func TestLangRouter_Priority_ToolsSkipIncapableModels(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	incapableModel := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	langModels := []providers.LanguageModel{
+		incapableModel,
+		providers.NewLangModel(
+			"second",
+			providers.NewToolCallingProviderMock([]providers.ResponseMock{{Msg: "2"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	router := newTestRouter(t, langModels)
+
+	req := schemas.NewChatFromStr("what's the weather like in Boston?")
+	req.Tools = []schemas.ToolDefinition{{
+		Type:     schemas.ToolTypeFunction,
+		Function: schemas.FunctionDefinition{Name: "get_current_weather"},
+	}}
+
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "second", resp.ModelID)
+	require.True(t, incapableModel.Healthy())
+
+	// no model could serve requests with tools & structured output at the same time
+	req.ResponseFormat = &schemas.ResponseFormat{Type: schemas.ResponseFormatJSONObject}
+
+	_, err = router.Chat(context.Background(), req)
+	require.ErrorIs(t, err, clients.ErrCapabilityNotSupported)
+}
+
+func TestLangRouter_Priority_ImagesRoutedToVisionModels(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	textModel := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	langModels := []providers.LanguageModel{
+		textModel,
+		providers.NewLangModel(
+			"second",
+			providers.NewVisionProviderMock([]providers.ResponseMock{{Msg: "2"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	router := newTestRouter(t, langModels)
+
+	req := &schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{{
+			Role: schemas.RoleUser,
+			ContentParts: []schemas.ContentPart{
+				{Type: schemas.ContentPartText, Text: "What's in this image?"},
+				{Type: schemas.ContentPartImageURL, ImageURL: &schemas.ImageURL{URL: "https://example.com/cat.png"}},
+			},
+		}},
+	}
+
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "second", resp.ModelID)
+	require.True(t, textModel.Healthy())
+
+	// the text-only model refuses images itself too, without spending its error budget
+	_, err = textModel.Chat(context.Background(), req)
+	require.ErrorIs(t, err, clients.ErrCapabilityNotSupported)
+	require.True(t, textModel.Healthy())
+}
+
+func TestLangRouter_Priority_DeclaredCapabilitiesFilterModels(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+	noVision := false
+
+	smallModel := providers.NewLangModel(
+		"small",
+		providers.NewVisionProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "1"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	require.NoError(t, smallModel.SetCapabilities(&providers.CapabilitiesConfig{MaxContextTokens: 10}))
+
+	largeModel := providers.NewLangModel(
+		"large",
+		providers.NewVisionProviderMock([]providers.ResponseMock{{Msg: "2"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	require.NoError(t, largeModel.SetCapabilities(&providers.CapabilitiesConfig{Vision: &noVision, MaxContextTokens: 1000}))
+
+	// capabilities the provider can't translate could not be declared
+	tools := true
+	require.Error(t, largeModel.SetCapabilities(&providers.CapabilitiesConfig{Tools: &tools}))
+
+	langModels := []providers.LanguageModel{smallModel, largeModel}
+
+	router := newTestRouter(t, langModels)
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("Hello"))
+	require.NoError(t, err)
+	require.Equal(t, "small", resp.ModelID)
+
+	// the conversation doesn't fit the small model context
+	longText := strings.Repeat("word ", 100)
+
+	resp, err = router.Chat(context.Background(), schemas.NewChatFromStr(longText))
+	require.NoError(t, err)
+	require.Equal(t, "large", resp.ModelID)
+
+	imageMessage := func(text string) *schemas.UnifiedChatRequest {
+		return &schemas.UnifiedChatRequest{
+			Messages: []schemas.ChatMessage{{
+				Role:    schemas.RoleUser,
+				Content: text,
+				ContentParts: []schemas.ContentPart{
+					{Type: schemas.ContentPartText, Text: text},
+					{Type: schemas.ContentPartImageURL, ImageURL: &schemas.ImageURL{URL: "https://example.com/cat.png"}},
+				},
+			}},
+		}
+	}
+
+	resp, err = router.Chat(context.Background(), imageMessage("What's that?"))
+	require.NoError(t, err)
+	require.Equal(t, "small", resp.ModelID)
+
+	// there are models with vision and with the large context, but not both
+	_, err = router.Chat(context.Background(), imageMessage(longText))
+	require.ErrorIs(t, err, ErrNoCapableModel)
+	require.ErrorIs(t, err, clients.ErrCapabilityNotSupported)
+	require.ErrorContains(t, err, "at the same time")
+
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr(strings.Repeat(longText, 20)))
+	require.ErrorIs(t, err, ErrContextLengthExceeded)
+
+	toolRequest := schemas.NewChatFromStr("what's the weather like in Boston?")
+	toolRequest.Tools = []schemas.ToolDefinition{{
+		Type:     schemas.ToolTypeFunction,
+		Function: schemas.FunctionDefinition{Name: "get_current_weather"},
+	}}
+
+	_, err = router.Chat(context.Background(), toolRequest)
+	require.ErrorIs(t, err, ErrNoCapableModel)
+	require.ErrorContains(t, err, "missing tools")
+}
@@ -2,8 +2,13 @@ package routers
 
 import (
 	"fmt"
+	"reflect"
+	"time"
 
+	"glide/pkg/cluster"
 	"glide/pkg/providers"
+	"glide/pkg/routers/cache"
+	"glide/pkg/routers/prompts"
 	"glide/pkg/routers/retry"
 	"glide/pkg/routers/routing"
 	"glide/pkg/telemetry"
@@ -11,11 +16,62 @@ import (
 	"go.uber.org/zap"
 )
 
+// RouterConfigError is the error of the language router that could not be built out of its config
+type RouterConfigError struct {
+	Index    int // the position of the router in routers.language
+	RouterID string
+	Err      error
+}
+
+func (e *RouterConfigError) Error() string {
+	return fmt.Sprintf("router \"%v\": %v", e.RouterID, e.Err)
+}
+
+func (e *RouterConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ModelConfigError is the error of the router model that could not be built out of its config
+type ModelConfigError struct {
+	Index   int // the position of the model in the router models
+	ModelID string
+	Err     error
+}
+
+func (e *ModelConfigError) Error() string {
+	return fmt.Sprintf("model \"%v\": %v", e.ModelID, e.Err)
+}
+
+func (e *ModelConfigError) Unwrap() error {
+	return e.Err
+}
+
 type Config struct {
-	LanguageRouters []LangRouterConfig `yaml:"language" validate:"required,min=1"` // the list of language routers
+	Limits          *LimitsConfig          `yaml:"limits,omitempty"`                            // request & response size limits of all routers (unlimited by default except batches)
+	Hooks           []HookConfig           `yaml:"hooks,omitempty" validate:"omitempty,dive"`   // hooks all requests go through in the given order
+	Aliases         providers.ModelAliases `yaml:"aliases,omitempty" validate:"omitempty,dive"` // provider model names models could refer to instead of concrete ones
+	Prompts         prompts.Library        `yaml:"prompts,omitempty"`                           // named system prompts requests could refer to
+	LanguageRouters []LangRouterConfig     `yaml:"language" validate:"required,min=1,dive"`     // the list of language routers
 }
 
 func (c *Config) BuildLangRouters(tel *telemetry.Telemetry) ([]*LangRouter, error) {
+	return c.RebuildLangRouters(tel, nil, nil)
+}
+
+// RebuildLangRouters creates routers out of the config reusing models of the previously built routers
+// when their configs haven't changed, so they keep their health & latency stats across config reloads.
+// Routers share their state with other gateway replicas if the cluster is given
+func (c *Config) RebuildLangRouters(
+	tel *telemetry.Telemetry,
+	cl *cluster.Cluster,
+	prevRouters []*LangRouter,
+) ([]*LangRouter, error) {
+	prevModels := make(map[string]reusableModels, len(prevRouters))
+
+	for _, router := range prevRouters {
+		prevModels[router.ID()] = router.reusableModels()
+	}
+
 	seenIDs := make(map[string]bool, len(c.LanguageRouters))
 	routers := make([]*LangRouter, 0, len(c.LanguageRouters))
 
@@ -35,9 +91,9 @@ func (c *Config) BuildLangRouters(tel *telemetry.Telemetry) ([]*LangRouter, erro
 
 		tel.Logger.Debug("init router", zap.String("routerID", routerConfig.ID))
 
-		router, err := NewLangRouter(&c.LanguageRouters[idx], tel)
+		router, err := newLangRouter(&c.LanguageRouters[idx], c.Limits, c.Aliases, c.Prompts, tel, cl, prevModels[routerConfig.ID])
 		if err != nil {
-			errs = multierr.Append(errs, err)
+			errs = multierr.Append(errs, &RouterConfigError{Index: idx, RouterID: routerConfig.ID, Err: err})
 			continue
 		}
 
@@ -55,21 +111,74 @@ func (c *Config) BuildLangRouters(tel *telemetry.Telemetry) ([]*LangRouter, erro
 // TODO: Had to keep RoutingStrategy because of https://github.com/swaggo/swag/issues/1738
 // LangRouterConfig
 type LangRouterConfig struct {
-	ID              string                      `yaml:"id" json:"routers" validate:"required"`                                       // Unique router ID
-	Enabled         bool                        `yaml:"enabled" json:"enabled" validate:"required"`                                  // Is router enabled?
-	Retry           *retry.ExpRetryConfig       `yaml:"retry" json:"retry" validate:"required"`                                      // retry when no healthy model is available to router
-	RoutingStrategy routing.Strategy            `yaml:"strategy" json:"strategy" swaggertype:"primitive,string" validate:"required"` // strategy on picking the next model to serve the request
-	Models          []providers.LangModelConfig `yaml:"models" json:"models" validate:"required,min=1"`                              // the list of models that could handle requests
+	ID               string                         `yaml:"id" json:"routers" validate:"required"`                                                                      // Unique router ID
+	Enabled          bool                           `yaml:"enabled" json:"enabled"`                                                                                     // Is router enabled?
+	Retry            *retry.ExpRetryConfig          `yaml:"retry" json:"retry" validate:"required"`                                                                     // retry when no healthy model is available to router
+	RequestTimeout   *time.Duration                 `yaml:"request_timeout,omitempty" json:"request_timeout" swaggertype:"primitive,integer"`                           // time budget for the whole request including retries & fallbacks (unlimited by default)
+	DeadlineAware    bool                           `yaml:"deadline_aware,omitempty" json:"deadline_aware"`                                                             // skip models that are not expected to respond before the request deadline & fail fast if none could (disabled by default)
+	HashUser         bool                           `yaml:"hash_user,omitempty" json:"hash_user"`                                                                       // forward hashes of end user IDs to providers instead of raw IDs (disabled by default)
+	RoutingStrategy  routing.Strategy               `yaml:"strategy" json:"strategy" swaggertype:"primitive,string" validate:"required"`                                // strategy on picking the next model to serve the request
+	SessionAffinity  *routing.SessionAffinityConfig `yaml:"session_affinity,omitempty" json:"session_affinity,omitempty"`                                               // pin requests of the same session to the same model while it's healthy (disabled by default)
+	Hints            map[string]*RouteHintConfig    `yaml:"hints,omitempty" json:"hints,omitempty" validate:"omitempty,dive,required"`                                  // route requests with hints (e.g. cheap or quality) over subsets of models
+	StreamFailover   bool                           `yaml:"stream_failover,omitempty" json:"stream_failover"`                                                           // restart streams dropped before any content was sent on the next model (disabled by default)
+	Truncation       Truncation                     `yaml:"truncation,omitempty" json:"truncation" swaggertype:"primitive,string" validate:"omitempty,oneof=none auto"` // drop the oldest messages of requests that don't fit model context windows (auto) or reject them (none, default)
+	Cache            *cache.Config                  `yaml:"cache,omitempty" json:"cache,omitempty"`                                                                     // serve repeated requests from the cache (disabled by default)
+	Idempotency      *cache.IdempotencyConfig       `yaml:"idempotency,omitempty" json:"idempotency,omitempty"`                                                         // replay responses to requests repeated with the same Idempotency-Key header
+	QueueOnRateLimit *QueueConfig                   `yaml:"queue_on_rate_limit,omitempty" json:"queue_on_rate_limit,omitempty"`                                         // wait for the soonest rate limit reset when all models are limited (instead of the retry backoff)
+	Warmup           *WarmupConfig                  `yaml:"warmup,omitempty" json:"warmup,omitempty"`                                                                   // send tiny requests to models when the gateway starts (disabled by default)
+	IdleLatency      *IdleLatencyConfig             `yaml:"idle_latency,omitempty" json:"idle_latency,omitempty"`                                                       // refresh latencies of models that get no traffic by probes or decay (disabled by default)
+	Limits           *LimitsConfig                  `yaml:"limits,omitempty" json:"limits,omitempty"`                                                                   // overrides global request & response size limits
+	Hooks            []HookConfig                   `yaml:"hooks,omitempty" json:"hooks,omitempty" validate:"omitempty,dive"`                                           // built-in hooks requests of the router go through after the routers.hooks ones
+	DegradedResponse *DegradedResponseConfig        `yaml:"degraded_response,omitempty" json:"degraded_response,omitempty"`                                             // respond with the static message when no model could serve the request (errors by default)
+	Moderation       *ModerationConfig              `yaml:"moderation,omitempty" json:"moderation,omitempty"`                                                           // check user messages by the moderation model before routing (disabled by default)
+	PromptTemplate   *prompts.Config                `yaml:"prompt_template,omitempty" json:"prompt_template,omitempty"`                                                 // scaffolds requests to models that have no templates of their own
+	Models           []providers.LangModelConfig    `yaml:"models" json:"models" validate:"required,min=1,dive"`                                                        // the list of models that could handle requests
+}
+
+// QueueConfig defines how long requests could wait for the soonest rate limit reset when all router models are rate limited
+type QueueConfig struct {
+	MaxWait time.Duration `yaml:"max_wait,omitempty" json:"max_wait" swaggertype:"primitive,integer" validate:"gt=0"`
+}
+
+func DefaultQueueConfig() *QueueConfig {
+	return &QueueConfig{
+		MaxWait: 500 * time.Millisecond,
+	}
+}
+
+func (c *QueueConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultQueueConfig()
+
+	type plain QueueConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// reusableModels maps model IDs to models built by the previous router revision
+type reusableModels map[string]reusableModel
+
+type reusableModel struct {
+	config providers.LangModelConfig
+	alias  *providers.ModelAlias // the alias the model refers to (nil if it's not aliased)
+	model  providers.LanguageModel
 }
 
 // BuildModels creates LanguageModel slice out of the given config
 func (c *LangRouterConfig) BuildModels(tel *telemetry.Telemetry) ([]providers.LanguageModel, error) {
+	return c.buildModels(tel, nil, nil)
+}
+
+func (c *LangRouterConfig) buildModels(
+	tel *telemetry.Telemetry,
+	aliases providers.ModelAliases,
+	prevModels reusableModels,
+) ([]providers.LanguageModel, error) {
 	var errs error
 
 	seenIDs := make(map[string]bool, len(c.Models))
 	models := make([]providers.LanguageModel, 0, len(c.Models))
 
-	for _, modelConfig := range c.Models {
+	for idx, modelConfig := range c.Models {
 		if _, ok := seenIDs[modelConfig.ID]; ok {
 			return nil, fmt.Errorf(
 				"ID \"%v\" is specified for more than one model in router \"%v\", while it should be unique in scope of that pool",
@@ -90,15 +199,30 @@ func (c *LangRouterConfig) BuildModels(tel *telemetry.Telemetry) ([]providers.La
 			continue
 		}
 
+		alias := aliases[modelConfig.ProviderModel()]
+
+		if prevModel, found := prevModels[modelConfig.ID]; found &&
+			reflect.DeepEqual(prevModel.config, modelConfig) && reflect.DeepEqual(prevModel.alias, alias) {
+			tel.Logger.Debug(
+				"lang model config is unchanged, reusing the model",
+				zap.String("router", c.ID),
+				zap.String("model", modelConfig.ID),
+			)
+
+			models = append(models, prevModel.model)
+
+			continue
+		}
+
 		tel.Logger.Debug(
 			"init lang model",
 			zap.String("router", c.ID),
 			zap.String("model", modelConfig.ID),
 		)
 
-		model, err := modelConfig.ToModel(tel)
+		model, err := modelConfig.ToAliasedModel(aliases, tel)
 		if err != nil {
-			errs = multierr.Append(errs, err)
+			errs = multierr.Append(errs, &ModelConfigError{Index: idx, ModelID: modelConfig.ID, Err: err})
 			continue
 		}
 
@@ -142,15 +266,30 @@ func (c *LangRouterConfig) BuildRouting(models []providers.LanguageModel) (routi
 		m = append(m, model)
 	}
 
+	modelRouting, err := c.buildStrategy(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.SessionAffinity != nil {
+		return routing.NewSessionAffinityRouting(modelRouting, m, c.SessionAffinity), nil
+	}
+
+	return modelRouting, nil
+}
+
+func (c *LangRouterConfig) buildStrategy(models []providers.Model) (routing.LangModelRouting, error) {
 	switch c.RoutingStrategy {
 	case routing.Priority:
-		return routing.NewPriority(m), nil
+		return routing.NewPriority(models), nil
 	case routing.RoundRobin:
-		return routing.NewRoundRobinRouting(m), nil
+		return routing.NewRoundRobinRouting(models), nil
 	case routing.WeightedRoundRobin:
-		return routing.NewWeightedRoundRobin(m), nil
+		return routing.NewWeightedRoundRobin(models), nil
 	case routing.LeastLatency:
-		return routing.NewLeastLatencyRouting(m), nil
+		return routing.NewLeastLatencyRouting(models), nil
+	case routing.P2C:
+		return routing.NewP2CRouting(models), nil
 	}
 
 	return nil, fmt.Errorf("routing strategy \"%v\" is not supported, please make sure there is no typo", c.RoutingStrategy)
@@ -160,7 +299,9 @@ func DefaultLangRouterConfig() LangRouterConfig {
 	return LangRouterConfig{
 		Enabled:         true,
 		RoutingStrategy: routing.Priority,
+		Truncation:      TruncationNone,
 		Retry:           retry.DefaultExpRetryConfig(),
+		Idempotency:     cache.DefaultIdempotencyConfig(),
 	}
 }
 
@@ -1,9 +1,18 @@
 package routers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
 	"glide/pkg/providers"
 	"glide/pkg/providers/clients"
 	"glide/pkg/providers/openai"
@@ -12,6 +21,7 @@ import (
 	"glide/pkg/routers/retry"
 	"glide/pkg/routers/routing"
 	"glide/pkg/telemetry"
+	"gopkg.in/yaml.v3"
 )
 
 func TestRouterConfig_BuildModels(t *testing.T) {
@@ -181,3 +191,120 @@ func TestRouterConfig_InvalidSetups(t *testing.T) {
 		require.Error(t, err)
 	}
 }
+
+func TestRouterConfig_ModelAliases(t *testing.T) {
+	var aliasesCfg struct {
+		Aliases providers.ModelAliases `yaml:"aliases"`
+	}
+
+	err := yaml.Unmarshal([]byte(`
+aliases:
+  gpt4: gpt-4o-2024-05-13
+  gpt4-mini:
+    target: gpt-4o-mini-2024-07-18
+    cutovers:
+      - target: gpt-5-mini
+        effective_from: 2099-01-01T00:00:00Z
+      - target: gpt-4.1-mini
+        effective_from: 2024-01-01T00:00:00Z
+`), &aliasesCfg)
+	require.NoError(t, err)
+
+	aliases := aliasesCfg.Aliases
+	require.Equal(t, "gpt-4o-2024-05-13", aliases["gpt4"].Resolve(time.Now()))
+
+	// cutovers are applied in the chronological order
+	miniAlias := aliases["gpt4-mini"]
+	require.Equal(t, "gpt-4o-mini-2024-07-18", miniAlias.Resolve(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.Equal(t, "gpt-4.1-mini", miniAlias.Resolve(time.Now()))
+	require.Equal(t, "gpt-5-mini", miniAlias.Resolve(time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	var requestedModels []string
+
+	openAIMock := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var chatRequest struct {
+			Model string `json:"model"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&chatRequest))
+		requestedModels = append(requestedModels, chatRequest.Model)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{
+			"id": "chatcmpl-1", "object": "chat.completion", "created": 1, "model": %q,
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "Blue whale"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 2, "total_tokens": 7}
+		}`, chatRequest.Model)
+	})
+
+	openAIServer := httptest.NewServer(openAIMock)
+	defer openAIServer.Close()
+
+	defaultParams := openai.DefaultParams()
+	openAIConfig := openai.DefaultConfig()
+	openAIConfig.BaseURL = openAIServer.URL
+	openAIConfig.APIKey = "ABC"
+	openAIConfig.DefaultParams = &defaultParams
+	openAIConfig.Model = "gpt4-mini"
+
+	tel := telemetry.NewTelemetryMock()
+	cfg := Config{
+		Aliases: aliases,
+		LanguageRouters: []LangRouterConfig{
+			{
+				ID:              "first_router",
+				Enabled:         true,
+				RoutingStrategy: routing.Priority,
+				Retry:           retry.DefaultExpRetryConfig(),
+				Models: []providers.LangModelConfig{
+					{
+						ID:          "first_model",
+						Enabled:     true,
+						Client:      clients.DefaultClientConfig(),
+						ErrorBudget: health.DefaultErrorBudget(),
+						Latency:     latency.DefaultConfig(),
+						OpenAI:      openAIConfig,
+					},
+				},
+			},
+		},
+	}
+
+	routers, err := cfg.BuildLangRouters(tel)
+	require.NoError(t, err)
+
+	model, ok := routers[0].models[0].(providers.Aliased)
+	require.True(t, ok)
+
+	alias, target := model.Alias()
+	require.Equal(t, "gpt4-mini", alias)
+	require.Equal(t, "gpt-4.1-mini", target)
+
+	// requests go to the concrete model the alias resolves to & responses report it
+	resp, err := routers[0].Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+	require.Equal(t, "gpt-4.1-mini", resp.Model)
+	require.Equal(t, []string{"gpt-4.1-mini"}, requestedModels)
+
+	expectedMetrics := `
+# HELP glide_model_alias_requests_total Number of chat requests served by models referring to aliases per the concrete model the alias resolved to
+# TYPE glide_model_alias_requests_total counter
+glide_model_alias_requests_total{alias="gpt4-mini",model="first_model",router="first_router",target="gpt-4.1-mini"} 1
+`
+
+	require.NoError(t, testutil.GatherAndCompare(
+		tel.Metrics.Registry(),
+		strings.NewReader(expectedMetrics),
+		"glide_model_alias_requests_total",
+	))
+
+	// models are rebuilt when their aliases change
+	cfg.Aliases = providers.ModelAliases{"gpt4-mini": {Target: "gpt-4o-mini-2024-07-18"}}
+
+	reloadedRouters, err := cfg.RebuildLangRouters(tel, nil, routers)
+	require.NoError(t, err)
+	require.NotSame(t, routers[0].models[0], reloadedRouters[0].models[0])
+
+	_, target = reloadedRouters[0].models[0].(providers.Aliased).Alias()
+	require.Equal(t, "gpt-4o-mini-2024-07-18", target)
+}
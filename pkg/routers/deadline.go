@@ -0,0 +1,73 @@
+package routers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"glide/pkg/providers"
+	"glide/pkg/routers/routing"
+)
+
+// ErrDeadlineTooShort is returned by deadline-aware routers right away when no model is expected to respond before the request deadline
+var ErrDeadlineTooShort = fmt.Errorf("%w: no router model is expected to respond before the request deadline", context.DeadlineExceeded)
+
+// deadlineIterator skips models that are not expected to respond before the request deadline.
+// Models with unknown response times are given a chance
+type deadlineIterator struct {
+	models   routing.LangModelIterator
+	pool     *modelPool
+	req      requirements
+	tier     int
+	deadline time.Time
+}
+
+// withDeadline makes the iterator skip models that would not make it before the context deadline (if any)
+func withDeadline(ctx context.Context, models routing.LangModelIterator, pool *modelPool, req requirements) routing.LangModelIterator {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return models
+	}
+
+	return &deadlineIterator{
+		models:   models,
+		pool:     pool,
+		req:      req,
+		tier:     pool.capableRouting.contextTier(req.contextTokens),
+		deadline: deadline,
+	}
+}
+
+func (it *deadlineIterator) Next() (providers.Model, error) {
+	model, err := it.models.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := time.Until(it.deadline)
+
+	if respondsWithin(model, remaining) {
+		return model, nil
+	}
+
+	// the routing has picked the model that is too slow, so the first capable model that could make it in time is picked instead
+	for _, model := range it.pool.models {
+		if model.Healthy() && hasCapabilities(model, it.req.capabilities) && fitsContext(model, it.tier) && respondsWithin(model, remaining) {
+			return model, nil
+		}
+	}
+
+	return nil, ErrDeadlineTooShort
+}
+
+// respondsWithin checks if the model is expected to respond within the given time
+func respondsWithin(model providers.Model, remaining time.Duration) bool {
+	timed, ok := model.(providers.ResponseTimed)
+	if !ok {
+		return true
+	}
+
+	expected, known := timed.ExpectedResponseTime()
+
+	return !known || expected <= remaining
+}
@@ -0,0 +1,59 @@
+package routers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+)
+
+// withDeadlineAware skips models that are not expected to respond before the request deadline
+func withDeadlineAware() testRouterOption {
+	return withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.DeadlineAware = true
+	})
+}
+
+func TestLangRouter_DeadlineAwareSkipsSlowModels(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.Config{Decay: 0.5, WarmupSamples: 1}
+
+	slowProvider := providers.NewProviderMock([]providers.ResponseMock{
+		{Msg: "1", Delay: 50 * time.Millisecond},
+		{Msg: "2", Delay: 50 * time.Millisecond},
+		{Msg: "3", Delay: 50 * time.Millisecond},
+	})
+	slowModel := providers.NewLangModel("slow", slowProvider, *budget, latConfig, 1)
+	fastModel := providers.NewLangModel("fast", providers.NewProviderMock([]providers.ResponseMock{{Msg: "4"}}), *budget, latConfig, 1)
+
+	router := newTestRouter(t, []providers.LanguageModel{slowModel, fastModel}, withDeadlineAware())
+
+	// requests without deadlines let the slow model show how long it takes
+	for idx := 0; idx < 2; idx++ {
+		resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+		require.NoError(t, err)
+		require.Equal(t, "slow", resp.ModelID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	resp, err := router.Chat(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "fast", resp.ModelID)
+
+	// no model could make it in time, so the request fails right away
+	router = newTestRouter(t, []providers.LanguageModel{slowModel}, withDeadlineAware())
+
+	startedAt := time.Now()
+
+	_, err = router.Chat(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrDeadlineTooShort)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(startedAt), 10*time.Millisecond)
+}
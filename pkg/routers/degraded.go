@@ -0,0 +1,104 @@
+package routers
+
+import (
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers/prompts"
+	"glide/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DegradedResponseConfig defines the response the router gives when no model could serve the request,
+// so chat products could show a graceful message instead of an error page
+type DegradedResponseConfig struct {
+	// Message is a Go text/template that could refer to the request like prompt templates (e.g. {{ .Vars.name }})
+	Message string `yaml:"message" json:"message" validate:"required"`
+}
+
+func (c *DegradedResponseConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain DegradedResponseConfig // to avoid recursion
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	// the message is parsed on load, so syntax errors fail the config rather than requests
+	_, err := prompts.NewText("degraded_response", c.Message)
+
+	return err
+}
+
+// degradedResponse renders the degraded response of the router. Degraded responses are given outside of caches,
+// so they are never cached & replayed once models are back
+type degradedResponse struct {
+	routerID  string
+	message   *prompts.Text
+	responses *prometheus.CounterVec
+}
+
+func newDegradedResponse(routerID string, cfg *DegradedResponseConfig, tel *telemetry.Telemetry) (*degradedResponse, error) {
+	message, err := prompts.NewText("degraded_response", cfg.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &degradedResponse{
+		routerID: routerID,
+		message:  message,
+		responses: tel.Metrics.CounterVec(
+			"degraded_responses_total",
+			"Number of chat requests answered by the degraded response of the router as no model could serve them",
+			"router",
+		),
+	}, nil
+}
+
+// respond renders the degraded response for the request
+func (d *degradedResponse) respond(request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	content, err := d.message.Render(request)
+	if err != nil {
+		return nil, err
+	}
+
+	d.responses.WithLabelValues(d.routerID).Inc()
+
+	return &schemas.UnifiedChatResponse{
+		Created:  int(time.Now().UTC().Unix()),
+		RouterID: d.routerID,
+		Degraded: true,
+		ModelResponse: schemas.ProviderResponse{
+			Message: schemas.ChatMessage{
+				Role:    schemas.RoleAssistant,
+				Content: content,
+			},
+		},
+	}, nil
+}
+
+// respondStream streams the degraded response as the only chunk
+func (d *degradedResponse) respondStream(request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error) {
+	resp, err := d.respond(request)
+	if err != nil {
+		return nil, err
+	}
+
+	streamC := make(chan *schemas.ChatStreamResult, 1)
+
+	streamC <- &schemas.ChatStreamResult{
+		Chunk: &schemas.UnifiedChatStreamChunk{
+			Created:  resp.Created,
+			RouterID: resp.RouterID,
+			Degraded: true,
+			ModelResponse: schemas.ProviderChunkResponse{
+				Message: resp.ModelResponse.Message,
+			},
+			FinishReason: schemas.FinishReasonStop,
+		},
+	}
+
+	close(streamC)
+
+	return streamC, nil
+}
@@ -0,0 +1,48 @@
+package routers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/cache"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+)
+
+func TestLangRouter_DegradedResponse(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.MIN)
+	provider := providers.NewStreamingProviderMock([]providers.ResponseMock{{Err: &clients.ErrProviderUnavailable}})
+	model := providers.NewLangModel("first", provider, *budget, *latency.DefaultConfig(), 1)
+
+	router := newTestRouter(t, []providers.LanguageModel{model}, withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.Retry.MaxRetries = 1
+		cfg.Cache = cache.DefaultConfig()
+		cfg.DegradedResponse = &DegradedResponseConfig{Message: "Sorry {{ .Vars.name }}, I'm having trouble right now"}
+	}))
+
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.TemplateVars = map[string]string{"name": "Ann"}
+
+	// degraded responses are never cached, so requests are routed to models again
+	for i := 1; i <= 2; i++ {
+		resp, err := router.Chat(context.Background(), req)
+		require.NoError(t, err)
+		require.True(t, resp.Degraded)
+		require.False(t, resp.Cached)
+		require.Equal(t, "Sorry Ann, I'm having trouble right now", resp.ModelResponse.Message.Content)
+		require.InDelta(t, float64(i), testutil.ToFloat64(router.degraded.responses.WithLabelValues("test_router")), 0.0001)
+	}
+
+	streamC, err := router.ChatStream(context.Background(), req)
+	require.NoError(t, err)
+
+	result := <-streamC
+	require.NoError(t, result.Err)
+	require.True(t, result.Chunk.Degraded)
+	require.Equal(t, "Sorry Ann, I'm having trouble right now", result.Chunk.ModelResponse.Message.Content)
+}
@@ -62,6 +62,11 @@ func (b *TokenBucket) Take(tokens uint64) error {
 	}
 }
 
+// Drain takes all tokens out of the bucket, so it has to refill over time before tokens could be taken again
+func (b *TokenBucket) Drain() {
+	atomic.StoreUint64(&b.timePointer, b.nowInMicro())
+}
+
 func (b *TokenBucket) HasTokens() bool {
 	return b.Tokens() >= 1.0
 }
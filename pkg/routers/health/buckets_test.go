@@ -77,3 +77,17 @@ func TestTokenBucket_TakeBurstly(t *testing.T) {
 
 	require.NoError(t, bucket.Take(10))
 }
+
+func TestTokenBucket_Drain(t *testing.T) {
+	bucket := NewTokenBucket(1000, 10)
+	require.True(t, bucket.HasTokens())
+
+	bucket.Drain()
+
+	require.False(t, bucket.HasTokens())
+	require.ErrorIs(t, bucket.Take(1), ErrNoTokens)
+
+	// the bucket refills over time
+	time.Sleep(2 * time.Millisecond)
+	require.True(t, bucket.HasTokens())
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ConcurrencyLimiter caps the number of simultaneous in-flight requests.
+// Instead of queueing requests over the limit, it rejects them right away,
+// so the caller could pick another resource to serve the request
+type ConcurrencyLimiter struct {
+	limit    int64
+	inFlight atomic.Int64
+	gauge    atomic.Pointer[prometheus.Gauge] // mirrors the in-flight count in metrics (nil if not set)
+}
+
+// NewConcurrencyLimiter creates a new limiter. Zero or negative limit means no limit
+func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		limit: int64(limit),
+	}
+}
+
+// TryAcquire reserves a slot for one more in-flight request if the limit has not been reached yet
+func (l *ConcurrencyLimiter) TryAcquire() bool {
+	if l.limit <= 0 {
+		l.inFlight.Add(1)
+		l.observe(1)
+
+		return true
+	}
+
+	for {
+		current := l.inFlight.Load()
+
+		if current >= l.limit {
+			return false
+		}
+
+		if l.inFlight.CompareAndSwap(current, current+1) {
+			l.observe(1)
+
+			return true
+		}
+	}
+}
+
+// Release frees a slot reserved by TryAcquire()
+func (l *ConcurrencyLimiter) Release() {
+	l.inFlight.Add(-1)
+	l.observe(-1)
+}
+
+// SetGauge makes the limiter report its in-flight count to the gauge (nil stops reporting)
+func (l *ConcurrencyLimiter) SetGauge(gauge prometheus.Gauge) {
+	if gauge == nil {
+		l.gauge.Store(nil)
+
+		return
+	}
+
+	gauge.Set(float64(l.inFlight.Load()))
+	l.gauge.Store(&gauge)
+}
+
+func (l *ConcurrencyLimiter) observe(delta float64) {
+	if gauge := l.gauge.Load(); gauge != nil {
+		(*gauge).Add(delta)
+	}
+}
+
+// Saturated is true when no more in-flight requests could be accepted
+func (l *ConcurrencyLimiter) Saturated() bool {
+	return l.limit > 0 && l.inFlight.Load() >= l.limit
+}
+
+// InFlight returns the current number of in-flight requests
+func (l *ConcurrencyLimiter) InFlight() int64 {
+	return l.inFlight.Load()
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter_AcquireAndRelease(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2)
+
+	require.True(t, limiter.TryAcquire())
+	require.True(t, limiter.TryAcquire())
+	require.False(t, limiter.TryAcquire())
+	require.True(t, limiter.Saturated())
+	require.Equal(t, int64(2), limiter.InFlight())
+
+	limiter.Release()
+
+	require.False(t, limiter.Saturated())
+	require.True(t, limiter.TryAcquire())
+}
+
+func TestConcurrencyLimiter_NoLimit(t *testing.T) {
+	limiter := NewConcurrencyLimiter(0)
+
+	for i := 0; i < 100; i++ {
+		require.True(t, limiter.TryAcquire())
+	}
+
+	require.False(t, limiter.Saturated())
+	require.Equal(t, int64(100), limiter.InFlight())
+}
+
+func TestConcurrencyLimiter_LimitRespectedConcurrently(t *testing.T) {
+	limiter := NewConcurrencyLimiter(5)
+	wg := &sync.WaitGroup{}
+
+	var acquired atomic.Int64
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if limiter.TryAcquire() {
+				acquired.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, int64(5), acquired.Load())
+	require.Equal(t, int64(5), limiter.InFlight())
+}
+
+func TestConcurrencyLimiter_ReportsInFlightToGauge(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2)
+
+	require.True(t, limiter.TryAcquire())
+
+	// the gauge picks up requests that were in-flight before it was set
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "in_flight"})
+	limiter.SetGauge(gauge)
+	require.InDelta(t, 1, testutil.ToFloat64(gauge), 0)
+
+	require.True(t, limiter.TryAcquire())
+	require.False(t, limiter.TryAcquire())
+	require.InDelta(t, 2, testutil.ToFloat64(gauge), 0)
+
+	limiter.Release()
+	limiter.Release()
+	require.InDelta(t, 0, testutil.ToFloat64(gauge), 0)
+}
@@ -1,29 +1,88 @@
 package health
 
-import "time"
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
 
 // RateLimitTracker handles rate/quota limits that often represented via 429 errors and
 // has some well-defined cooldown period
 type RateLimitTracker struct {
-	resetAt *time.Time
+	// resetAt is updated by requests & by rate limits shared by other gateway replicas concurrently
+	resetAt atomic.Pointer[time.Time]
+	// rampUpEndsAt is when traffic is fully back after the last reset (nil if there is no ramp-up in progress)
+	rampUpEndsAt atomic.Pointer[time.Time]
+	// buffer extends resets, so the limit is not hit again right away if the provider reset comes a bit late
+	buffer time.Duration
+	// rampUp is the period after the reset during which traffic is let through gradually
+	rampUp time.Duration
 }
 
 func NewRateLimitTracker() *RateLimitTracker {
-	return &RateLimitTracker{
-		resetAt: nil,
-	}
+	return &RateLimitTracker{}
+}
+
+// SetCooldown extends resets by the buffer and ramps traffic back over the ramp-up period after resets.
+// It's meant to be called before the tracker is in use
+func (t *RateLimitTracker) SetCooldown(buffer time.Duration, rampUp time.Duration) {
+	t.buffer = buffer
+	t.rampUp = rampUp
 }
 
+// Limited is true until the limit is reset. During the ramp-up after the reset it's true for a share of calls
+// that goes down to zero by the end of the ramp-up, so routing sends more and more traffic to the resource
 func (t *RateLimitTracker) Limited() bool {
-	if t.resetAt != nil && time.Now().After(*t.resetAt) {
-		t.resetAt = nil
+	if t.limited() {
+		return true
+	}
+
+	rampUpEndsAt := t.rampUpEndsAt.Load()
+	if rampUpEndsAt == nil {
+		return false
 	}
 
-	return t.resetAt != nil
+	untilRampedUp := time.Until(*rampUpEndsAt)
+	if untilRampedUp <= 0 {
+		t.rampUpEndsAt.CompareAndSwap(rampUpEndsAt, nil)
+
+		return false
+	}
+
+	return rand.Float64() < float64(untilRampedUp)/float64(t.rampUp) //nolint:gosec
+}
+
+// limited checks if the limit has not been reset yet
+func (t *RateLimitTracker) limited() bool {
+	resetAt := t.resetAt.Load()
+
+	if resetAt != nil && time.Now().After(*resetAt) {
+		if t.resetAt.CompareAndSwap(resetAt, nil) && t.rampUp > 0 {
+			rampUpEndsAt := resetAt.Add(t.rampUp)
+			t.rampUpEndsAt.Store(&rampUpEndsAt)
+		}
+
+		return false
+	}
+
+	return resetAt != nil
 }
 
 func (t *RateLimitTracker) SetLimited(untilReset time.Duration) {
-	resetAt := time.Now().Add(untilReset)
+	resetAt := time.Now().Add(untilReset + t.buffer)
+
+	t.resetAt.Store(&resetAt)
+	t.rampUpEndsAt.Store(nil)
+}
+
+// UntilReset returns how long is left until the limit is reset (zero if it's not limited).
+// The ramp-up after the reset is not taken into account
+func (t *RateLimitTracker) UntilReset() time.Duration {
+	resetAt := t.resetAt.Load()
+
+	if resetAt == nil || !t.limited() {
+		return 0
+	}
 
-	t.resetAt = &resetAt
+	return time.Until(*resetAt)
 }
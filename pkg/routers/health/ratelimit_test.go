@@ -13,7 +13,60 @@ func TestRateLimitTracker_ResetCorrectly(t *testing.T) {
 
 	tracker.SetLimited(10 * time.Millisecond)
 	require.True(t, tracker.Limited())
+	require.Positive(t, tracker.UntilReset())
 
 	time.Sleep(11 * time.Millisecond)
 	require.False(t, tracker.Limited())
+	require.Zero(t, tracker.UntilReset())
+}
+
+func TestRateLimitTracker_CooldownBufferExtendsReset(t *testing.T) {
+	tracker := NewRateLimitTracker()
+	tracker.SetCooldown(50*time.Millisecond, 0)
+
+	tracker.SetLimited(10 * time.Millisecond)
+	require.Greater(t, tracker.UntilReset(), 50*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+	require.True(t, tracker.Limited())
+
+	time.Sleep(45 * time.Millisecond)
+	require.False(t, tracker.Limited())
+	require.Zero(t, tracker.UntilReset())
+}
+
+func TestRateLimitTracker_RampsTrafficUpAfterReset(t *testing.T) {
+	tracker := NewRateLimitTracker()
+	tracker.SetCooldown(0, time.Hour)
+
+	tracker.SetLimited(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	// right after the reset, almost all traffic is still held back
+	limited := 0
+
+	for i := 0; i < 100; i++ {
+		if tracker.Limited() {
+			limited++
+		}
+	}
+
+	require.Greater(t, limited, 90)
+	require.Zero(t, tracker.UntilReset())
+
+	// a new limit cancels the ramp-up
+	tracker.SetLimited(time.Hour)
+	require.Positive(t, tracker.UntilReset())
+}
+
+func TestRateLimitTracker_RampUpEnds(t *testing.T) {
+	tracker := NewRateLimitTracker()
+	tracker.SetCooldown(0, 10*time.Millisecond)
+
+	tracker.SetLimited(time.Millisecond)
+	time.Sleep(15 * time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		require.False(t, tracker.Limited())
+	}
 }
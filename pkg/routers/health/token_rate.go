@@ -0,0 +1,91 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenRateLimiter keeps usage of the resource within its tokens-per-minute limit (e.g. provider TPM limits).
+// Requests reserve their estimated tokens up front and reconcile reservations with the actual usage afterwards,
+// so the budget could go into debt when requests turn out to be larger than estimated
+type TokenRateLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	available float64
+	updatedAt time.Time
+	now       func() time.Time
+}
+
+// NewTokenRateLimiter creates a limiter with the full budget
+func NewTokenRateLimiter(tokensPerMinute int) *TokenRateLimiter {
+	limiter := &TokenRateLimiter{
+		perMinute: float64(tokensPerMinute),
+		available: float64(tokensPerMinute),
+		now:       time.Now,
+	}
+
+	limiter.updatedAt = limiter.now()
+
+	return limiter
+}
+
+// Reserve takes tokens from the budget if there are enough of them. Otherwise, it returns how long it takes to refill the budget.
+// Requests larger than the whole budget are let through once the budget is full, so they are not rejected forever
+func (l *TokenRateLimiter) Reserve(tokens int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+
+	needed := min(float64(tokens), l.perMinute)
+
+	if l.available < needed {
+		return false, l.untilAvailable(needed)
+	}
+
+	l.available -= float64(tokens)
+
+	return true, 0
+}
+
+// Reconcile corrects the reservation by the actual usage. It returns how long it takes to get out of debt (zero if there is none)
+func (l *TokenRateLimiter) Reconcile(reserved int, used int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+
+	l.available = min(l.available+float64(reserved-used), l.perMinute)
+
+	if l.available >= 0 {
+		return 0
+	}
+
+	return l.untilAvailable(0)
+}
+
+// Available returns the number of tokens left in the budget (negative if it's in debt)
+func (l *TokenRateLimiter) Available() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+
+	return l.available
+}
+
+func (l *TokenRateLimiter) refill() {
+	now := l.now()
+
+	if now.Before(l.updatedAt) {
+		return
+	}
+
+	l.available = min(l.available+now.Sub(l.updatedAt).Minutes()*l.perMinute, l.perMinute)
+	l.updatedAt = now
+}
+
+// untilAvailable returns how long it takes to refill the budget up to the given number of tokens
+func (l *TokenRateLimiter) untilAvailable(tokens float64) time.Duration {
+	return time.Duration((tokens - l.available) / l.perMinute * float64(time.Minute))
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenRateLimiter_ReservesAndRefills(t *testing.T) {
+	limiter := NewTokenRateLimiter(600)
+
+	now := limiter.updatedAt
+	limiter.now = func() time.Time { return now }
+
+	reserved, _ := limiter.Reserve(400)
+	require.True(t, reserved)
+
+	reserved, untilAvailable := limiter.Reserve(400)
+	require.False(t, reserved)
+	require.InDelta(t, 20*time.Second, untilAvailable, float64(time.Millisecond))
+
+	now = now.Add(20 * time.Second)
+
+	reserved, _ = limiter.Reserve(400)
+	require.True(t, reserved)
+	require.InDelta(t, 0, limiter.Available(), 0.001)
+
+	// the budget never grows beyond the limit
+	now = now.Add(time.Hour)
+	require.InDelta(t, 600, limiter.Available(), 0.001)
+
+	// requests larger than the whole budget get through once it's full
+	reserved, _ = limiter.Reserve(1000)
+	require.True(t, reserved)
+	require.InDelta(t, -400, limiter.Available(), 0.001)
+}
+
+func TestTokenRateLimiter_ReconcilesReservations(t *testing.T) {
+	limiter := NewTokenRateLimiter(600)
+
+	now := limiter.updatedAt
+	limiter.now = func() time.Time { return now }
+
+	reserved, _ := limiter.Reserve(300)
+	require.True(t, reserved)
+
+	// the response has been shorter than estimated
+	require.Zero(t, limiter.Reconcile(300, 100))
+	require.InDelta(t, 500, limiter.Available(), 0.001)
+
+	reserved, _ = limiter.Reserve(100)
+	require.True(t, reserved)
+
+	// the response has been much longer than estimated, so the budget is in debt
+	require.InDelta(t, 10*time.Second, limiter.Reconcile(100, 600), float64(time.Millisecond))
+	require.InDelta(t, -100, limiter.Available(), 0.001)
+}
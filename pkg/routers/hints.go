@@ -0,0 +1,131 @@
+package routers
+
+import (
+	"fmt"
+	"slices"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/routers/routing"
+	"go.uber.org/zap"
+)
+
+// RouteHintConfig narrows routing of requests with the hint down to the subset of router models
+// and optionally overrides the router strategy for them
+type RouteHintConfig struct {
+	Models   []string         `yaml:"models,omitempty" json:"models,omitempty"`                                    // IDs of models serving the hint (all router models if empty)
+	Strategy routing.Strategy `yaml:"strategy,omitempty" json:"strategy,omitempty" swaggertype:"primitive,string"` // the router strategy if empty
+}
+
+// UnmarshalYAML accepts either the list of model IDs (e.g. cheap: [haiku, gpt-4o-mini]) or the models along with the strategy
+func (c *RouteHintConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var models []string
+
+	if err := unmarshal(&models); err == nil {
+		*c = RouteHintConfig{Models: models}
+
+		return nil
+	}
+
+	type plain RouteHintConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// AlternativeForms lists forms the hint could be given in besides the mapping (used by the config schema)
+func (c *RouteHintConfig) AlternativeForms() []interface{} {
+	return []interface{}{[]string{}}
+}
+
+// modelPool is the set of models requests are routed over along with the routing over them
+type modelPool struct {
+	routing        routing.LangModelRouting
+	capableRouting *capableRouting
+	models         []providers.LanguageModel
+}
+
+// buildHintPools builds model pools of route hints, so each hint keeps the state of its own routing
+func buildHintPools(cfg *LangRouterConfig, models []providers.LanguageModel) (map[string]*modelPool, error) {
+	pools := make(map[string]*modelPool, len(cfg.Hints))
+
+	for hint, hintConfig := range cfg.Hints {
+		hintModels, err := hintConfig.selectModels(cfg, models)
+		if err != nil {
+			return nil, fmt.Errorf("route hint %q: %w", hint, err)
+		}
+
+		hintRouterConfig := *cfg
+		if hintConfig.Strategy != "" {
+			hintRouterConfig.RoutingStrategy = hintConfig.Strategy
+		}
+
+		pool := &modelPool{models: hintModels}
+
+		if pool.routing, err = hintRouterConfig.BuildRouting(hintModels); err != nil {
+			return nil, fmt.Errorf("route hint %q: %w", hint, err)
+		}
+
+		if pool.capableRouting, err = buildCapableRouting(&hintRouterConfig, hintModels); err != nil {
+			return nil, fmt.Errorf("route hint %q: %w", hint, err)
+		}
+
+		pools[hint] = pool
+	}
+
+	return pools, nil
+}
+
+// selectModels picks the hint models in the router order. Disabled models are skipped
+func (c *RouteHintConfig) selectModels(cfg *LangRouterConfig, models []providers.LanguageModel) ([]providers.LanguageModel, error) {
+	if len(c.Models) == 0 {
+		return models, nil
+	}
+
+	for _, modelID := range c.Models {
+		if !slices.ContainsFunc(cfg.Models, func(modelConfig providers.LangModelConfig) bool { return modelConfig.ID == modelID }) {
+			return nil, fmt.Errorf("model %q is not defined in the router", modelID)
+		}
+	}
+
+	hintModels := make([]providers.LanguageModel, 0, len(c.Models))
+
+	for _, model := range models {
+		if slices.Contains(c.Models, model.ID()) {
+			hintModels = append(hintModels, model)
+		}
+	}
+
+	if len(hintModels) == 0 {
+		return nil, ErrNoModels
+	}
+
+	return hintModels, nil
+}
+
+// HasRouteHint checks if the router maps the hint to models
+func (r *LangRouter) HasRouteHint(hint string) bool {
+	_, found := r.hintPools[hint]
+
+	return found
+}
+
+// modelPool returns the pool of the request route hint. Requests without hints or with unknown ones go over all router models
+func (r *LangRouter) modelPool(request *schemas.UnifiedChatRequest) *modelPool {
+	if request.RouteHint != "" {
+		if pool, found := r.hintPools[request.RouteHint]; found {
+			return pool
+		}
+
+		r.telemetry.Logger.Warn(
+			"unknown route hint, routing over all models",
+			zap.String("routerID", r.ID()),
+			zap.String("routeHint", request.RouteHint),
+		)
+	}
+
+	return &modelPool{
+		routing:        r.routing,
+		capableRouting: r.capableRouting,
+		models:         r.models,
+	}
+}
@@ -0,0 +1,162 @@
+package routers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/routers/cache"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+	"glide/pkg/routers/routing"
+	"gopkg.in/yaml.v3"
+)
+
+// newHintModels are the premium & cheap models hints route requests over
+func newHintModels() []providers.LanguageModel {
+	models := make([]providers.LanguageModel, 0, 3)
+
+	for _, modelID := range []string{"premium", "cheap1", "cheap2"} {
+		models = append(models, newMockModel(
+			modelID,
+			providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}, {Msg: "3"}}),
+		))
+	}
+
+	return models
+}
+
+// withHints routes requests with the hints over the model subsets
+func withHints(hints map[string]*RouteHintConfig) testRouterOption {
+	return withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.Hints = hints
+	})
+}
+
+func TestRouteHintConfig_Unmarshal(t *testing.T) {
+	var hints map[string]*RouteHintConfig
+
+	err := yaml.Unmarshal([]byte(`
+cheap: [haiku, gpt-4o-mini]
+quality:
+  models: [opus, gpt-4o]
+  strategy: least_latency
+`), &hints)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"haiku", "gpt-4o-mini"}, hints["cheap"].Models)
+	require.Empty(t, hints["cheap"].Strategy)
+	require.Equal(t, []string{"opus", "gpt-4o"}, hints["quality"].Models)
+	require.Equal(t, routing.LeastLatency, hints["quality"].Strategy)
+}
+
+func TestLangRouter_RoutesByHints(t *testing.T) {
+	router := newTestRouter(t, newHintModels(), withHints(map[string]*RouteHintConfig{
+		"cheap":   {Models: []string{"cheap1", "cheap2"}, Strategy: routing.RoundRobin},
+		"quality": {Models: []string{"premium"}},
+	}))
+
+	chat := func(hint string) string {
+		request := schemas.NewChatFromStr("tell me a dad joke")
+		request.RouteHint = hint
+
+		resp, err := router.Chat(context.Background(), request)
+		require.NoError(t, err)
+
+		return resp.ModelID
+	}
+
+	require.Equal(t, "premium", chat("quality"))
+
+	// hints could override the router strategy
+	require.Equal(t, "cheap1", chat("cheap"))
+	require.Equal(t, "cheap2", chat("cheap"))
+
+	// unknown hints fall back to all models
+	require.False(t, router.HasRouteHint("fast"))
+	require.Equal(t, "premium", chat("fast"))
+	require.Equal(t, "premium", chat(""))
+}
+
+func TestLangRouter_CachesResponsesPerHint(t *testing.T) {
+	router := newTestRouter(
+		t,
+		newHintModels(),
+		withHints(map[string]*RouteHintConfig{
+			"cheap":   {Models: []string{"cheap1"}},
+			"quality": {Models: []string{"premium"}},
+		}),
+		withRouterConfig(func(cfg *LangRouterConfig) {
+			cfg.Cache = cache.DefaultConfig()
+		}),
+	)
+
+	chat := func(hint string) *schemas.UnifiedChatResponse {
+		request := schemas.NewChatFromStr("tell me a dad joke")
+		request.RouteHint = hint
+
+		resp, err := router.Chat(context.Background(), request)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	require.Equal(t, "cheap1", chat("cheap").ModelID)
+
+	// the same conversation with another hint is routed over other models rather than served from the cache
+	resp := chat("quality")
+	require.False(t, resp.Cached)
+	require.Equal(t, "premium", resp.ModelID)
+
+	resp = chat("cheap")
+	require.True(t, resp.Cached)
+	require.Equal(t, "cheap1", resp.ModelID)
+}
+
+func TestLangRouter_QueuesOnRateLimitsOfHintModels(t *testing.T) {
+	router := newTestRouter(
+		t,
+		newHintModels(),
+		withHints(map[string]*RouteHintConfig{
+			"quality": {Models: []string{"premium"}},
+		}),
+		withRouterConfig(func(cfg *LangRouterConfig) {
+			cfg.QueueOnRateLimit = &QueueConfig{MaxWait: 500 * time.Millisecond}
+			cfg.Retry.MaxRetries = 1
+		}),
+	)
+
+	// only models outside of the hint pool reset within the wait window
+	router.applyRateLimit("premium", 10*time.Second)
+	router.applyRateLimit("cheap1", 200*time.Millisecond)
+
+	request := schemas.NewChatFromStr("tell me a dad joke")
+	request.RouteHint = "quality"
+
+	startedAt := time.Now()
+
+	_, err := router.Chat(context.Background(), request)
+	require.ErrorIs(t, err, ErrNoModelAvailable)
+	require.Less(t, time.Since(startedAt), 200*time.Millisecond)
+}
+
+func TestLangRouter_InvalidHints(t *testing.T) {
+	cfg := &LangRouterConfig{
+		RoutingStrategy: routing.Priority,
+		Models:          []providers.LangModelConfig{{ID: "premium"}},
+		Hints:           map[string]*RouteHintConfig{"cheap": {Models: []string{"haiku"}}},
+	}
+
+	_, err := buildHintPools(cfg, nil)
+	require.ErrorContains(t, err, `model "haiku" is not defined`)
+
+	cfg.Hints = map[string]*RouteHintConfig{"cheap": {Strategy: "cheapest"}}
+
+	_, err = buildHintPools(cfg, []providers.LanguageModel{
+		providers.NewLangModel("premium", providers.NewProviderMock(nil), *health.DefaultErrorBudget(), *latency.DefaultConfig(), 1),
+	})
+	require.ErrorContains(t, err, "not supported")
+}
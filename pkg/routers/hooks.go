@@ -0,0 +1,357 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers/pii"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRequestRejected       = errors.New("request is rejected")
+	ErrResponseRejected      = errors.New("response is rejected")
+	ErrHookAlreadyRegistered = errors.New("hook with the same name is already registered")
+)
+
+// Policies on hook errors
+const (
+	HookFailClosed = "fail_closed" // reject the request
+	HookFailOpen   = "fail_open"   // log the error and let the request through
+)
+
+// Hook processes chat requests of all routers before they are served and their outcomes after that.
+// Hooks run in the order of the routers.hooks config. They could modify the request, so the later hooks
+// and models see the modified one. The response could be modified in AfterResponse as well, but its slices
+// (e.g. Choices) should be replaced rather than modified in place. AfterResponse is called for every request that has gone through hooks
+// (including rejected ones). Streamed responses are reported once the stream is over without the response
+type Hook interface {
+	BeforeRequest(ctx context.Context, request *schemas.UnifiedChatRequest) error
+	AfterResponse(ctx context.Context, response *schemas.UnifiedChatResponse, err error)
+}
+
+// ResponseFilter is implemented by hooks that could reject responses (e.g. ones violating the content policy).
+// Filters run after the response is served in the hook order, and the first rejection fails the request.
+// Streamed responses are not filtered, as their chunks have been sent to the client by then
+type ResponseFilter interface {
+	FilterResponse(ctx context.Context, response *schemas.UnifiedChatResponse) error
+}
+
+// HookConfig enables either a built-in hook or a hook registered via RouterManager.RegisterHook
+type HookConfig struct {
+	Name     string      `yaml:"name" json:"name" validate:"required"`
+	OnError  string      `yaml:"on_error,omitempty" json:"on_error" validate:"oneof=fail_closed fail_open"` // what to do when the hook fails the request
+	Keywords []string    `yaml:"keywords,omitempty" json:"keywords,omitempty"`                              // phrases rejected by the keyword_blocklist hook
+	Patterns []string    `yaml:"patterns,omitempty" json:"patterns,omitempty"`                              // regular expressions rejected by the banned_phrases hook in requests & responses
+	PII      *pii.Config `yaml:"pii,omitempty" json:"pii,omitempty"`                                        // personal data handling of the pii_redaction hook (all entities are redacted by default)
+}
+
+func DefaultHookConfig() *HookConfig {
+	return &HookConfig{
+		OnError: HookFailClosed,
+	}
+}
+
+func (c *HookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultHookConfig()
+
+	type plain HookConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// hookContext is the state of the request shared by its hooks
+type hookContext struct {
+	routerID string
+	values   sync.Map
+}
+
+type hookContextKey struct{}
+
+func withHookContext(ctx context.Context, routerID string) context.Context {
+	return context.WithValue(ctx, hookContextKey{}, &hookContext{routerID: routerID})
+}
+
+// RouterID returns the ID of the router serving the request, so hooks could tell requests of different routers apart
+func RouterID(ctx context.Context) string {
+	if hookCtx, ok := ctx.Value(hookContextKey{}).(*hookContext); ok {
+		return hookCtx.routerID
+	}
+
+	return ""
+}
+
+// SetHookValue keeps the value for the rest of the request lifetime, so the hook could pick it up in AfterResponse
+func SetHookValue(ctx context.Context, key any, value any) {
+	if hookCtx, ok := ctx.Value(hookContextKey{}).(*hookContext); ok {
+		hookCtx.values.Store(key, value)
+	}
+}
+
+// HookValue returns the value set by SetHookValue during the same request
+func HookValue(ctx context.Context, key any) (any, bool) {
+	if hookCtx, ok := ctx.Value(hookContextKey{}).(*hookContext); ok {
+		return hookCtx.values.Load(key)
+	}
+
+	return nil, false
+}
+
+// boundHook is the hook along with its error policy
+type boundHook struct {
+	name     string
+	hook     Hook
+	failOpen bool
+}
+
+// Hooks is the ordered chain of hooks shared by all routers of the manager.
+// The chain is rebuilt when hooks are registered or the config is reloaded
+type Hooks struct {
+	telemetry *telemetry.Telemetry
+
+	mu         sync.Mutex
+	configs    []HookConfig
+	builtins   map[string]Hook
+	registered map[string]Hook
+	order      []string // names of registered hooks in the order of registration
+
+	chain atomic.Pointer[[]boundHook]
+}
+
+func newHooks(tel *telemetry.Telemetry) *Hooks {
+	return &Hooks{
+		telemetry:  tel,
+		builtins:   make(map[string]Hook),
+		registered: make(map[string]Hook),
+	}
+}
+
+// buildHooks creates the built-in hooks enabled in the config
+func buildHooks(configs []HookConfig, tel *telemetry.Telemetry) (map[string]Hook, error) {
+	builtins := make(map[string]Hook, len(configs))
+	seenNames := make(map[string]bool, len(configs))
+
+	for idx, hookConfig := range configs {
+		if seenNames[hookConfig.Name] {
+			return nil, fmt.Errorf("hook \"%v\" is specified more than once while each hook could run only once", hookConfig.Name)
+		}
+
+		seenNames[hookConfig.Name] = true
+
+		newHook, found := builtinHooks[hookConfig.Name]
+		if !found {
+			// the hook is expected to be registered by the embedding application
+			continue
+		}
+
+		hook, err := newHook(&configs[idx], tel)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing \"%v\" hook: %w", hookConfig.Name, err)
+		}
+
+		builtins[hookConfig.Name] = hook
+	}
+
+	return builtins, nil
+}
+
+// buildRouterHooks creates the chain of hooks the router runs after the manager ones.
+// Router hooks could be built-in ones only, as hooks registered by embedding applications run for all routers
+func buildRouterHooks(configs []HookConfig, tel *telemetry.Telemetry) (*Hooks, error) {
+	for _, hookConfig := range configs {
+		if _, found := builtinHooks[hookConfig.Name]; !found {
+			return nil, fmt.Errorf("hook \"%v\" is not built-in, while router hooks could be built-in ones only", hookConfig.Name)
+		}
+	}
+
+	builtins, err := buildHooks(configs, tel)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := newHooks(tel)
+	hooks.apply(configs, builtins)
+
+	return hooks, nil
+}
+
+// apply swaps the hook config along with the built-in hooks created out of it
+func (h *Hooks) apply(configs []HookConfig, builtins map[string]Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.configs = configs
+	h.builtins = builtins
+
+	h.rebuildChain()
+}
+
+// register adds the hook to the chain. Hooks not mentioned in the config run after the configured ones and fail closed
+func (h *Hooks) register(name string, hook Hook) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, found := h.registered[name]; found {
+		return fmt.Errorf("%w: %v", ErrHookAlreadyRegistered, name)
+	}
+
+	if _, found := builtinHooks[name]; found {
+		return fmt.Errorf("%w: %v (built-in)", ErrHookAlreadyRegistered, name)
+	}
+
+	h.registered[name] = hook
+	h.order = append(h.order, name)
+
+	h.rebuildChain()
+
+	return nil
+}
+
+func (h *Hooks) rebuildChain() {
+	chain := make([]boundHook, 0, len(h.configs)+len(h.order))
+	configured := make(map[string]bool, len(h.configs))
+
+	for _, hookConfig := range h.configs {
+		configured[hookConfig.Name] = true
+
+		hook, found := h.builtins[hookConfig.Name]
+		if !found {
+			hook, found = h.registered[hookConfig.Name]
+		}
+
+		if !found {
+			// not registered yet
+			continue
+		}
+
+		chain = append(chain, boundHook{
+			name:     hookConfig.Name,
+			hook:     hook,
+			failOpen: hookConfig.OnError == HookFailOpen,
+		})
+	}
+
+	for _, name := range h.order {
+		if configured[name] {
+			continue
+		}
+
+		chain = append(chain, boundHook{name: name, hook: h.registered[name]})
+	}
+
+	h.chain.Store(&chain)
+}
+
+func (h *Hooks) enabled() bool {
+	if h == nil {
+		return false
+	}
+
+	chain := h.chain.Load()
+
+	return chain != nil && len(*chain) > 0
+}
+
+// before runs BeforeRequest of hooks in order. The first error of a fail-closed hook rejects the request
+func (h *Hooks) before(ctx context.Context, request *schemas.UnifiedChatRequest) error {
+	if !h.enabled() {
+		return nil
+	}
+
+	for _, bound := range *h.chain.Load() {
+		err := bound.hook.BeforeRequest(ctx, request)
+		if err == nil {
+			continue
+		}
+
+		if !bound.failOpen {
+			return fmt.Errorf("%w by the \"%v\" hook: %w", ErrRequestRejected, bound.name, err)
+		}
+
+		h.telemetry.Logger.Warn(
+			"hook failed, letting the request through",
+			zap.String("routerID", RouterID(ctx)),
+			zap.String("hook", bound.name),
+			zap.Error(err),
+		)
+	}
+
+	return nil
+}
+
+// after runs AfterResponse of hooks in order
+func (h *Hooks) after(ctx context.Context, response *schemas.UnifiedChatResponse, err error) {
+	if !h.enabled() {
+		return
+	}
+
+	for _, bound := range *h.chain.Load() {
+		bound.hook.AfterResponse(ctx, response, err)
+	}
+}
+
+// filter runs FilterResponse of hooks in order. The first error of a fail-closed hook rejects the response
+func (h *Hooks) filter(ctx context.Context, response *schemas.UnifiedChatResponse) error {
+	if !h.enabled() {
+		return nil
+	}
+
+	for _, bound := range *h.chain.Load() {
+		filter, ok := bound.hook.(ResponseFilter)
+		if !ok {
+			continue
+		}
+
+		err := filter.FilterResponse(ctx, response)
+		if err == nil {
+			continue
+		}
+
+		if !bound.failOpen {
+			return fmt.Errorf("%w by the \"%v\" hook: %w", ErrResponseRejected, bound.name, err)
+		}
+
+		h.telemetry.Logger.Warn(
+			"hook failed, letting the response through",
+			zap.String("routerID", RouterID(ctx)),
+			zap.String("hook", bound.name),
+			zap.Error(err),
+		)
+	}
+
+	return nil
+}
+
+// hooksEnabled checks if the request goes through any manager or router hook
+func (r *LangRouter) hooksEnabled() bool {
+	return r.hooks.enabled() || r.routerHooks.enabled()
+}
+
+// beforeHooks runs the manager hooks and then the router ones. Rejections short-circuit the chain
+func (r *LangRouter) beforeHooks(ctx context.Context, request *schemas.UnifiedChatRequest) error {
+	if err := r.hooks.before(ctx, request); err != nil {
+		return err
+	}
+
+	return r.routerHooks.before(ctx, request)
+}
+
+// filterHooks runs response filters of the manager hooks and then of the router ones
+func (r *LangRouter) filterHooks(ctx context.Context, response *schemas.UnifiedChatResponse) error {
+	if err := r.hooks.filter(ctx, response); err != nil {
+		return err
+	}
+
+	return r.routerHooks.filter(ctx, response)
+}
+
+// afterHooks reports the request outcome to the manager hooks and then to the router ones
+func (r *LangRouter) afterHooks(ctx context.Context, response *schemas.UnifiedChatResponse, err error) {
+	r.hooks.after(ctx, response, err)
+	r.routerHooks.after(ctx, response, err)
+}
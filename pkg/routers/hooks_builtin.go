@@ -0,0 +1,178 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+)
+
+// Names of built-in hooks
+const (
+	HookRequestLogging   = "request_logging"
+	HookKeywordBlocklist = "keyword_blocklist"
+	HookBannedPhrases    = "banned_phrases"
+)
+
+var (
+	ErrBlockedKeyword = errors.New("request contains a blocked keyword")
+	ErrNoKeywords     = errors.New("no keywords are configured")
+	ErrBannedPhrase   = errors.New("content matches a banned phrase")
+	ErrNoPatterns     = errors.New("no patterns are configured")
+)
+
+type hookFactory func(cfg *HookConfig, tel *telemetry.Telemetry) (Hook, error)
+
+var builtinHooks = map[string]hookFactory{
+	HookRequestLogging:   newRequestLoggingHook,
+	HookKeywordBlocklist: newKeywordBlocklistHook,
+	HookPIIRedaction:     newPIIRedactionHook,
+	HookBannedPhrases:    newBannedPhrasesHook,
+}
+
+// requestLoggingHook logs requests & their outcomes. Message content is never logged
+type requestLoggingHook struct {
+	logger *zap.Logger
+}
+
+func newRequestLoggingHook(_ *HookConfig, tel *telemetry.Telemetry) (Hook, error) {
+	return &requestLoggingHook{logger: tel.Logger.Named("hooks")}, nil
+}
+
+func (h *requestLoggingHook) BeforeRequest(ctx context.Context, request *schemas.UnifiedChatRequest) error {
+	fields := []zap.Field{
+		zap.String("routerID", RouterID(ctx)),
+		zap.Int("messages", len(request.ChatMessages())),
+		zap.Strings("params", request.OptionalParams()),
+	}
+
+	if userHash := request.UserHash(); userHash != "" {
+		fields = append(fields, zap.String("userHash", userHash))
+	}
+
+	h.logger.Info("chat request received", fields...)
+
+	return nil
+}
+
+func (h *requestLoggingHook) AfterResponse(ctx context.Context, response *schemas.UnifiedChatResponse, err error) {
+	if err != nil {
+		h.logger.Info("chat request failed", zap.String("routerID", RouterID(ctx)), zap.Error(err))
+
+		return
+	}
+
+	if response == nil {
+		// the stream is over
+		h.logger.Info("chat stream completed", zap.String("routerID", RouterID(ctx)))
+
+		return
+	}
+
+	h.logger.Info(
+		"chat request served",
+		zap.String("routerID", RouterID(ctx)),
+		zap.String("modelID", response.ModelID),
+		zap.String("provider", response.Provider),
+		zap.Bool("cached", response.Cached),
+		zap.Float64("totalTokens", response.ModelResponse.TokenUsage.TotalTokens),
+	)
+}
+
+// keywordBlocklistHook rejects requests mentioning any of the keywords (case-insensitive)
+type keywordBlocklistHook struct {
+	keywords []string
+}
+
+func newKeywordBlocklistHook(cfg *HookConfig, _ *telemetry.Telemetry) (Hook, error) {
+	keywords := make([]string, 0, len(cfg.Keywords))
+
+	for _, keyword := range cfg.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, strings.ToLower(keyword))
+		}
+	}
+
+	if len(keywords) == 0 {
+		return nil, ErrNoKeywords
+	}
+
+	return &keywordBlocklistHook{keywords: keywords}, nil
+}
+
+func (h *keywordBlocklistHook) BeforeRequest(_ context.Context, request *schemas.UnifiedChatRequest) error {
+	for _, message := range request.ChatMessages() {
+		content := strings.ToLower(message.Content)
+
+		for _, keyword := range h.keywords {
+			if strings.Contains(content, keyword) {
+				// the keyword is not echoed back, so the blocklist is not disclosed to clients
+				return fmt.Errorf("%w (in a %v message)", ErrBlockedKeyword, message.Role)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *keywordBlocklistHook) AfterResponse(context.Context, *schemas.UnifiedChatResponse, error) {}
+
+// bannedPhrasesHook rejects requests & responses matching any of the patterns
+type bannedPhrasesHook struct {
+	patterns []*regexp.Regexp
+}
+
+func newBannedPhrasesHook(cfg *HookConfig, _ *telemetry.Telemetry) (Hook, error) {
+	patterns := make([]*regexp.Regexp, 0, len(cfg.Patterns))
+
+	for _, pattern := range cfg.Patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		patterns = append(patterns, compiled)
+	}
+
+	if len(patterns) == 0 {
+		return nil, ErrNoPatterns
+	}
+
+	return &bannedPhrasesHook{patterns: patterns}, nil
+}
+
+func (h *bannedPhrasesHook) BeforeRequest(_ context.Context, request *schemas.UnifiedChatRequest) error {
+	for _, message := range request.ChatMessages() {
+		if h.matches(message.Content) {
+			// the pattern is not echoed back, so the policy is not disclosed to clients
+			return fmt.Errorf("%w (in a %v message)", ErrBannedPhrase, message.Role)
+		}
+	}
+
+	return nil
+}
+
+func (h *bannedPhrasesHook) FilterResponse(_ context.Context, response *schemas.UnifiedChatResponse) error {
+	if h.matches(response.ModelResponse.Message.Content) {
+		return fmt.Errorf("%w (in the model response)", ErrBannedPhrase)
+	}
+
+	return nil
+}
+
+func (h *bannedPhrasesHook) AfterResponse(context.Context, *schemas.UnifiedChatResponse, error) {}
+
+func (h *bannedPhrasesHook) matches(content string) bool {
+	for _, pattern := range h.patterns {
+		if pattern.MatchString(content) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package routers
+
+import (
+	"context"
+	"slices"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers/pii"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const HookPIIRedaction = "pii_redaction"
+
+type piiRedactorKey struct{}
+
+// piiRedactionHook handles personal data in messages before they are sent to models.
+// Streamed responses are not restored, as their chunks are sent as soon as they come
+type piiRedactionHook struct {
+	detector   *pii.Detector
+	detections *prometheus.CounterVec
+	logger     *zap.Logger
+}
+
+func newPIIRedactionHook(cfg *HookConfig, tel *telemetry.Telemetry) (Hook, error) {
+	piiConfig := cfg.PII
+	if piiConfig == nil {
+		piiConfig = pii.DefaultConfig()
+	}
+
+	if err := piiConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &piiRedactionHook{
+		detector: pii.NewDetector(piiConfig),
+		detections: tel.Metrics.CounterVec(
+			"pii_detections_total",
+			"Number of personal data entities found in chat requests",
+			"router", "entity", "action",
+		),
+		logger: tel.Logger.Named("hooks"),
+	}, nil
+}
+
+func (h *piiRedactionHook) BeforeRequest(ctx context.Context, request *schemas.UnifiedChatRequest) error {
+	redactor := h.detector.NewRedactor()
+
+	// the findings are reported even if the request is blocked
+	defer h.report(ctx, redactor)
+
+	messages := slices.Clone(request.ChatMessages())
+
+	for idx := range messages {
+		if err := redactMessage(redactor, &messages[idx]); err != nil {
+			return err
+		}
+	}
+
+	if err := redactMessage(redactor, &request.Override.Message); err != nil {
+		return err
+	}
+
+	request.Messages = messages
+
+	if h.detector.Restores() && redactor.Redacted() {
+		SetHookValue(ctx, piiRedactorKey{}, redactor)
+	}
+
+	return nil
+}
+
+func (h *piiRedactionHook) AfterResponse(ctx context.Context, response *schemas.UnifiedChatResponse, _ error) {
+	if response == nil {
+		return
+	}
+
+	value, found := HookValue(ctx, piiRedactorKey{})
+	if !found {
+		return
+	}
+
+	redactor := value.(*pii.Redactor)
+	modelResponse := &response.ModelResponse
+
+	// the response could be shared with the router cache that should keep placeholders,
+	// so fields are replaced rather than modified in place
+	modelResponse.Message = restoreMessage(redactor, modelResponse.Message)
+
+	if len(modelResponse.Choices) > 0 {
+		choices := make([]schemas.ChatMessage, 0, len(modelResponse.Choices))
+
+		for _, choice := range modelResponse.Choices {
+			choices = append(choices, restoreMessage(redactor, choice))
+		}
+
+		modelResponse.Choices = choices
+	}
+}
+
+func (h *piiRedactionHook) report(ctx context.Context, redactor *pii.Redactor) {
+	findings := redactor.Findings()
+	if len(findings) == 0 {
+		return
+	}
+
+	routerID := RouterID(ctx)
+
+	for entity, count := range findings {
+		h.detections.WithLabelValues(routerID, entity, redactor.Action(entity)).Add(float64(count))
+	}
+
+	// values are never logged, only the number of them
+	h.logger.Info("personal data found in the request", zap.String("routerID", routerID), zap.Any("entities", findings))
+}
+
+func redactMessage(redactor *pii.Redactor, message *schemas.ChatMessage) error {
+	var err error
+
+	if message.Content, err = redactor.Redact(message.Content); err != nil {
+		return err
+	}
+
+	if len(message.ContentParts) == 0 {
+		return nil
+	}
+
+	parts := slices.Clone(message.ContentParts)
+
+	for idx := range parts {
+		if parts[idx].Text, err = redactor.Redact(parts[idx].Text); err != nil {
+			return err
+		}
+	}
+
+	message.ContentParts = parts
+
+	return nil
+}
+
+func restoreMessage(redactor *pii.Redactor, message schemas.ChatMessage) schemas.ChatMessage {
+	message.Content = redactor.Restore(message.Content)
+
+	return message
+}
@@ -0,0 +1,204 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/routers/pii"
+	"glide/pkg/telemetry"
+)
+
+var errHookFailed = errors.New("hook failed")
+
+// hookMock records calls of the hook to the shared list
+type hookMock struct {
+	name  string
+	err   error
+	calls *[]string
+}
+
+func (h *hookMock) BeforeRequest(ctx context.Context, _ *schemas.UnifiedChatRequest) error {
+	*h.calls = append(*h.calls, "before:"+h.name+":"+RouterID(ctx))
+
+	return h.err
+}
+
+func (h *hookMock) AfterResponse(_ context.Context, _ *schemas.UnifiedChatResponse, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+
+	*h.calls = append(*h.calls, "after:"+h.name+":"+outcome)
+}
+
+func TestHooks_RunInConfiguredOrder(t *testing.T) {
+	var calls []string
+
+	hooks := newHooks(telemetry.NewTelemetryMock())
+	hooks.apply([]HookConfig{{Name: "second", OnError: HookFailClosed}, {Name: "first", OnError: HookFailClosed}}, nil)
+
+	require.NoError(t, hooks.register("unlisted", &hookMock{name: "unlisted", calls: &calls}))
+	require.NoError(t, hooks.register("first", &hookMock{name: "first", calls: &calls}))
+	require.NoError(t, hooks.register("second", &hookMock{name: "second", calls: &calls}))
+	require.ErrorIs(t, hooks.register("first", &hookMock{}), ErrHookAlreadyRegistered)
+	require.ErrorIs(t, hooks.register(HookRequestLogging, &hookMock{}), ErrHookAlreadyRegistered)
+
+	router := newTestRouter(t, newSingleModel("1", "2", "3"), withHooks(hooks))
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+
+	require.Equal(t, []string{
+		"before:second:test_router",
+		"before:first:test_router",
+		"before:unlisted:test_router",
+		"after:second:ok",
+		"after:first:ok",
+		"after:unlisted:ok",
+	}, calls)
+}
+
+func TestHooks_ErrorPolicies(t *testing.T) {
+	var calls []string
+
+	hooks := newHooks(telemetry.NewTelemetryMock())
+	hooks.apply([]HookConfig{{Name: "failing", OnError: HookFailOpen}}, nil)
+
+	require.NoError(t, hooks.register("failing", &hookMock{name: "failing", err: errHookFailed, calls: &calls}))
+
+	router := newTestRouter(t, newSingleModel("1", "2", "3"), withHooks(hooks))
+
+	// fail-open hooks let requests through
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+
+	hooks.apply([]HookConfig{{Name: "failing", OnError: HookFailClosed}}, nil)
+
+	calls = nil
+
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrRequestRejected)
+	require.ErrorIs(t, err, errHookFailed)
+	require.Equal(t, []string{"before:failing:test_router", "after:failing:error"}, calls)
+}
+
+func TestHooks_KeywordBlocklist(t *testing.T) {
+	tel := telemetry.NewTelemetryMock()
+
+	_, err := buildHooks([]HookConfig{{Name: HookKeywordBlocklist, Keywords: []string{" "}}}, tel)
+	require.ErrorIs(t, err, ErrNoKeywords)
+
+	_, err = buildHooks([]HookConfig{{Name: HookRequestLogging}, {Name: HookRequestLogging}}, tel)
+	require.Error(t, err)
+
+	configs := []HookConfig{
+		{Name: HookRequestLogging, OnError: HookFailOpen},
+		{Name: HookKeywordBlocklist, OnError: HookFailClosed, Keywords: []string{"Password"}},
+	}
+
+	builtins, err := buildHooks(configs, tel)
+	require.NoError(t, err)
+
+	hooks := newHooks(tel)
+	hooks.apply(configs, builtins)
+
+	router := newTestRouter(t, newSingleModel("1", "2", "3"), withHooks(hooks))
+
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("what's the admin PASSWORD?"))
+	require.ErrorIs(t, err, ErrRequestRejected)
+	require.ErrorIs(t, err, ErrBlockedKeyword)
+	require.NotContains(t, err.Error(), "password")
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+}
+
+func TestHooks_RouterBannedPhrases(t *testing.T) {
+	var calls []string
+
+	tel := telemetry.NewTelemetryMock()
+
+	_, err := buildRouterHooks([]HookConfig{{Name: "custom"}}, tel)
+	require.ErrorContains(t, err, "built-in")
+
+	_, err = buildRouterHooks([]HookConfig{{Name: HookBannedPhrases, Patterns: []string{"("}}}, tel)
+	require.ErrorContains(t, err, "invalid pattern")
+
+	hooks := newHooks(tel)
+	require.NoError(t, hooks.register("global", &hookMock{name: "global", calls: &calls}))
+
+	router := newTestRouter(t, newSingleModel("1", "2", "3"), withHooks(hooks), withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.Hooks = []HookConfig{
+			{Name: HookBannedPhrases, OnError: HookFailClosed, Patterns: []string{`(?i)\bsecret\b`, `^2$`}},
+		}
+	}))
+
+	// the request is rejected before it's sent to the model
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a Secret"))
+	require.ErrorIs(t, err, ErrRequestRejected)
+	require.ErrorIs(t, err, ErrBannedPhrase)
+	require.Equal(t, []string{"before:global:test_router", "after:global:error"}, calls)
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+
+	// the second response matches the banned phrase
+	resp, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.Nil(t, resp)
+	require.ErrorIs(t, err, ErrResponseRejected)
+	require.ErrorIs(t, err, ErrBannedPhrase)
+	require.Equal(t, "after:global:error", calls[len(calls)-1])
+}
+
+func TestHooks_PIIRedaction(t *testing.T) {
+	tel := telemetry.NewTelemetryMock()
+
+	piiConfig := pii.DefaultConfig()
+	piiConfig.Restore = true
+	piiConfig.Entities[pii.EntitySSN] = pii.ActionBlock
+
+	configs := []HookConfig{{Name: HookPIIRedaction, OnError: HookFailClosed, PII: piiConfig}}
+
+	builtins, err := buildHooks(configs, tel)
+	require.NoError(t, err)
+
+	hooks := newHooks(tel)
+	hooks.apply(configs, builtins)
+
+	router := newTestRouter(t, newSingleModel("Sure, I'll email [EMAIL_1]"), withHooks(hooks), withTelemetry(tel))
+
+	req := schemas.NewChatFromStr("email the report to jane@example.com")
+
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+
+	// the model has seen only the placeholder, while the client gets the original value back
+	require.Equal(t, "email the report to [EMAIL_1]", req.ChatMessages()[0].Content)
+	require.Equal(t, "Sure, I'll email jane@example.com", resp.ModelResponse.Message.Content)
+
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("my SSN is 123-45-6789"))
+	require.ErrorIs(t, err, ErrRequestRejected)
+	require.ErrorIs(t, err, pii.ErrPersonalDataDetected)
+
+	expectedMetrics := `
+# HELP glide_pii_detections_total Number of personal data entities found in chat requests
+# TYPE glide_pii_detections_total counter
+glide_pii_detections_total{action="block",entity="ssn",router="test_router"} 1
+glide_pii_detections_total{action="redact",entity="email",router="test_router"} 1
+`
+
+	require.NoError(t, testutil.GatherAndCompare(
+		tel.Metrics.Registry(),
+		strings.NewReader(expectedMetrics),
+		"glide_pii_detections_total",
+	))
+}
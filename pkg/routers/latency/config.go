@@ -6,7 +6,7 @@ import "time"
 type Config struct {
 	Decay          float64        `yaml:"decay" json:"decay"`                                                              // Weight of new latency measurements
 	WarmupSamples  uint8          `yaml:"warmup_samples" json:"warmup_samples"`                                            // The number of latency probes required to init moving average
-	UpdateInterval *time.Duration `yaml:"update_interval,omitempty" json:"update_interval" swaggertype:"primitive,string"` // How often gateway should probe models with not the lowest response latency
+	UpdateInterval *time.Duration `yaml:"update_interval,omitempty" json:"update_interval" swaggertype:"primitive,string"` // How often gateway should probe models with not the lowest response latency. Latency samples are also batched & averaged over this interval
 }
 
 func DefaultConfig() *Config {
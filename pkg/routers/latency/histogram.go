@@ -0,0 +1,72 @@
+package latency
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// histogramBuckets cover durations from 1ms up to ~17min, each bucket is ~19% wider than the previous one
+	histogramBuckets = 81
+	// bucketsPerDoubling is the number of buckets each doubling of the duration is split into
+	bucketsPerDoubling = 4
+	// minHistogramSamples is the number of samples required to estimate quantiles
+	minHistogramSamples = 20
+)
+
+// Histogram counts durations in exponential buckets, so quantiles (e.g. p95) could be estimated without keeping samples.
+// Estimates are the upper bounds of buckets, so they are a bit pessimistic
+type Histogram struct {
+	buckets [histogramBuckets]atomic.Uint64
+	count   atomic.Uint64
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Observe counts the duration
+func (h *Histogram) Observe(duration time.Duration) {
+	h.buckets[bucketIndex(duration)].Add(1)
+	h.count.Add(1)
+}
+
+// Quantile estimates the duration the given share of observations (e.g. 0.95) fits in.
+// False is returned until the histogram has enough samples
+func (h *Histogram) Quantile(q float64) (time.Duration, bool) {
+	count := h.count.Load()
+	if count < minHistogramSamples {
+		return 0, false
+	}
+
+	rank := uint64(math.Ceil(q * float64(count)))
+
+	var seen uint64
+
+	for idx := range h.buckets {
+		seen += h.buckets[idx].Load()
+
+		if seen >= rank {
+			return bucketBound(idx), true
+		}
+	}
+
+	// observations have been counted while the buckets were walked
+	return bucketBound(histogramBuckets - 1), true
+}
+
+func bucketIndex(duration time.Duration) int {
+	if duration <= time.Millisecond {
+		return 0
+	}
+
+	idx := int(math.Ceil(bucketsPerDoubling * math.Log2(float64(duration)/float64(time.Millisecond))))
+
+	return min(idx, histogramBuckets-1)
+}
+
+// bucketBound returns the upper bound of the bucket
+func bucketBound(idx int) time.Duration {
+	return time.Duration(float64(time.Millisecond) * math.Exp2(float64(idx)/bucketsPerDoubling))
+}
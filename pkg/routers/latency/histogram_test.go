@@ -0,0 +1,38 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogram_Quantile(t *testing.T) {
+	histogram := NewHistogram()
+
+	for idx := 0; idx < 19; idx++ {
+		histogram.Observe(100 * time.Millisecond)
+	}
+
+	_, known := histogram.Quantile(0.95)
+	require.False(t, known)
+
+	for idx := 0; idx < 80; idx++ {
+		histogram.Observe(100 * time.Millisecond)
+	}
+
+	histogram.Observe(3 * time.Second)
+
+	p50, known := histogram.Quantile(0.5)
+	require.True(t, known)
+	require.InDelta(t, 100*time.Millisecond, p50, float64(20*time.Millisecond))
+
+	p100, _ := histogram.Quantile(1)
+	require.InDelta(t, 3*time.Second, p100, float64(600*time.Millisecond))
+
+	// durations beyond the last bucket are counted in it
+	histogram.Observe(time.Hour)
+
+	p100, _ = histogram.Quantile(1)
+	require.Greater(t, p100, 15*time.Minute)
+}
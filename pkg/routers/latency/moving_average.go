@@ -56,7 +56,8 @@ func (e *MovingAverage) Value() float64 {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	if !e.WarmedUp() {
+	// the read lock is not reentrant (it would deadlock with a pending writer), so WarmedUp() is not used here
+	if e.count <= e.warmupSamples {
 		return 0.0
 	}
 
@@ -66,10 +67,11 @@ func (e *MovingAverage) Value() float64 {
 // Set sets the moving average value
 func (e *MovingAverage) Set(value float64) {
 	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	e.value = value
-	e.mu.Unlock()
 
-	if !e.WarmedUp() {
+	if e.count <= e.warmupSamples {
 		e.count = e.warmupSamples + 1
 	}
 }
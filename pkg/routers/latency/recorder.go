@@ -0,0 +1,155 @@
+package latency
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// recorderShards spreads buffered samples over several counters, so concurrent requests don't fight over one cache line
+const recorderShards = 16
+
+// sampleShard keeps a part of buffered samples. Samples are rounded to integers to be summed atomically
+// (latencies are measured in nanoseconds per token, so the precision loss doesn't matter)
+type sampleShard struct {
+	sum   atomic.Uint64
+	count atomic.Uint64
+	_     [48]byte // pads the shard to the cache line size
+}
+
+// Recorder buffers latency samples and flushes their mean into the moving average at most once per the update interval,
+// so the average lock is not taken on each request under high load. Samples go straight to the average
+// until it's warmed up, so the warmup doesn't take several intervals
+type Recorder struct {
+	average   *MovingAverage
+	interval  time.Duration
+	now       func() time.Time
+	shards    [recorderShards]sampleShard
+	flushAt   atomic.Int64 // unix nanoseconds
+	sampledAt atomic.Int64 // unix nanoseconds of the last sample, so idle models could be told apart
+	warmedUp  atomic.Bool
+}
+
+// NewRecorder creates a recorder of the moving average. Nil or zero interval means every sample updates the average
+func NewRecorder(average *MovingAverage, interval *time.Duration) *Recorder {
+	recorder := &Recorder{
+		average: average,
+		now:     time.Now,
+	}
+
+	if interval != nil {
+		recorder.interval = *interval
+	}
+
+	recorder.Touch()
+
+	return recorder
+}
+
+// Add buffers the sample and flushes the batch if the update interval is over
+func (r *Recorder) Add(value float64) {
+	if r.interval <= 0 {
+		r.average.Add(value)
+
+		return
+	}
+
+	now := r.now()
+	r.sampledAt.Store(now.UnixNano())
+
+	if !r.warmedUp.Load() {
+		r.average.Add(value)
+
+		if r.average.WarmedUp() {
+			r.flushAt.Store(now.Add(r.interval).UnixNano())
+			r.warmedUp.Store(true)
+		}
+
+		return
+	}
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		// e.g. responses without token usage. Such samples would spoil the whole batch
+		return
+	}
+
+	shard := &r.shards[rand.Intn(recorderShards)] //nolint:gosec
+	shard.sum.Add(uint64(math.Round(max(value, 0))))
+	shard.count.Add(1)
+
+	flushAt := r.flushAt.Load()
+
+	if now.UnixNano() < flushAt {
+		return
+	}
+
+	// only one of concurrent callers gets to flush the batch
+	if !r.flushAt.CompareAndSwap(flushAt, now.Add(r.interval).UnixNano()) {
+		return
+	}
+
+	r.Flush()
+}
+
+// Flush adds the mean of buffered samples to the moving average.
+// The batch is collected without locking, so samples added during the flush may go to the next batch
+func (r *Recorder) Flush() {
+	var sum, count uint64
+
+	for idx := range r.shards {
+		shard := &r.shards[idx]
+
+		shardCount := shard.count.Swap(0)
+		if shardCount == 0 {
+			continue
+		}
+
+		count += shardCount
+		sum += shard.sum.Swap(0)
+	}
+
+	if count == 0 {
+		return
+	}
+
+	r.average.Add(float64(sum) / float64(count))
+}
+
+// Idle checks if no samples have been added over the update interval (recorders without the interval are never idle)
+func (r *Recorder) Idle() bool {
+	if r.interval <= 0 {
+		return false
+	}
+
+	return r.now().UnixNano()-r.sampledAt.Load() >= int64(r.interval)
+}
+
+// Touch marks the recorder as sampled, so it's not idle for another update interval (e.g. while the model is being probed)
+func (r *Recorder) Touch() {
+	r.sampledAt.Store(r.now().UnixNano())
+}
+
+// Decay moves the warmed up average toward the target by the rate share of the gap between them.
+// The decay counts as a sample, so idle averages are decayed once per the update interval
+func (r *Recorder) Decay(target float64, rate float64) {
+	r.Touch()
+
+	if !r.average.WarmedUp() {
+		return
+	}
+
+	value := r.average.Value()
+	r.average.Set(value + (target-value)*rate)
+}
+
+// Pending returns the number of buffered samples that have not been flushed yet
+func (r *Recorder) Pending() uint64 {
+	var count uint64
+
+	for idx := range r.shards {
+		count += r.shards[idx].count.Load()
+	}
+
+	return count
+}
@@ -0,0 +1,125 @@
+package latency
+
+import (
+	"runtime/metrics"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_WarmUpWithoutBatching(t *testing.T) {
+	interval := time.Minute
+	movingAverage := NewMovingAverage(0.5, 2)
+	recorder := NewRecorder(movingAverage, &interval)
+
+	recorder.Add(100)
+	recorder.Add(200)
+	recorder.Add(300)
+
+	require.True(t, movingAverage.WarmedUp())
+	require.InDelta(t, 225.0, movingAverage.Value(), 0.0001)
+	require.Zero(t, recorder.Pending())
+}
+
+func TestRecorder_BatchesSamplesWithinInterval(t *testing.T) {
+	interval := time.Minute
+	now := time.Now()
+
+	movingAverage := NewMovingAverage(0.5, 1)
+	recorder := NewRecorder(movingAverage, &interval)
+	recorder.now = func() time.Time { return now }
+
+	// warms the average up
+	recorder.Add(100)
+	recorder.Add(200)
+
+	recorder.Add(300)
+	recorder.Add(400)
+
+	// the average is not updated until the interval is over
+	require.InDelta(t, 150.0, movingAverage.Value(), 0.0001)
+	require.Equal(t, uint64(2), recorder.Pending())
+
+	now = now.Add(interval)
+	recorder.Add(500)
+
+	// the batch mean (400) is added as one sample
+	require.InDelta(t, 275.0, movingAverage.Value(), 0.0001)
+	require.Zero(t, recorder.Pending())
+}
+
+func TestRecorder_NoInterval(t *testing.T) {
+	movingAverage := NewMovingAverage(0.5, 1)
+	recorder := NewRecorder(movingAverage, nil)
+
+	recorder.Add(100)
+	recorder.Add(200)
+
+	require.InDelta(t, 150.0, movingAverage.Value(), 0.0001)
+}
+
+// mutexWait returns the total time goroutines have been blocked on mutexes so far
+func mutexWait() time.Duration {
+	samples := []metrics.Sample{{Name: "/sync/mutex/wait/total:seconds"}}
+	metrics.Read(samples)
+
+	return time.Duration(samples[0].Value.Float64() * float64(time.Second))
+}
+
+// benchmarkLatencyUpdates simulates routing reading the average while requests record their latencies.
+// Apart from the time, it reports how long goroutines were blocked on the average lock per operation
+func benchmarkLatencyUpdates(b *testing.B, movingAverage *MovingAverage, add func(float64)) {
+	waitBefore := mutexWait()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+
+		for pb.Next() {
+			if i%2 == 0 {
+				add(float64(i % 100))
+			} else {
+				_ = movingAverage.Value()
+			}
+
+			i++
+		}
+	})
+
+	b.ReportMetric(float64(mutexWait()-waitBefore)/float64(b.N), "lock-wait-ns/op")
+}
+
+func BenchmarkMovingAverage_PerRequestUpdates(b *testing.B) {
+	movingAverage := NewMovingAverage(0.06, 3)
+
+	benchmarkLatencyUpdates(b, movingAverage, movingAverage.Add)
+}
+
+func BenchmarkRecorder_BatchedUpdates(b *testing.B) {
+	interval := 30 * time.Second
+	movingAverage := NewMovingAverage(0.06, 3)
+	recorder := NewRecorder(movingAverage, &interval)
+
+	benchmarkLatencyUpdates(b, movingAverage, recorder.Add)
+}
+
+func TestRecorder_DecaysIdleAverage(t *testing.T) {
+	interval := time.Minute
+	now := time.Now()
+
+	movingAverage := NewMovingAverage(0.5, 1)
+	recorder := NewRecorder(movingAverage, &interval)
+	recorder.now = func() time.Time { return now }
+
+	recorder.Add(300)
+	recorder.Add(300)
+	require.False(t, recorder.Idle())
+
+	now = now.Add(interval)
+	require.True(t, recorder.Idle())
+
+	recorder.Decay(100, 0.5)
+
+	require.InDelta(t, 200.0, movingAverage.Value(), 0.0001)
+	require.False(t, recorder.Idle())
+}
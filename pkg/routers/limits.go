@@ -0,0 +1,134 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var ErrRequestTooLarge = errors.New("request is larger than allowed")
+
+// DefaultMaxBatchSize bounds batches when no max batch size is configured, so one request could not queue unbounded work
+const DefaultMaxBatchSize = 1000
+
+// Kinds of router limits
+const (
+	LimitRequestBodySize = "request_body_size"
+	LimitMessages        = "messages"
+	LimitResponseSize    = "response_size"
+	LimitBatchSize       = "batch_size"
+)
+
+// LimitsConfig bounds sizes of requests & provider responses. Limits are set for all routers
+// and could be overridden per router. Zero means no limit (or DefaultMaxBatchSize for batches)
+type LimitsConfig struct {
+	MaxRequestBodySize int   `yaml:"max_request_body_size,omitempty" json:"max_request_body_size" validate:"gte=0"` // max request body size in bytes (requests are still bound by api.http.max_request_body_size)
+	MaxMessages        int   `yaml:"max_messages,omitempty" json:"max_messages" validate:"gte=0"`                   // max number of messages in one request including the message history
+	MaxResponseSize    int64 `yaml:"max_response_size,omitempty" json:"max_response_size" validate:"gte=0"`         // max size of provider responses in bytes (bigger responses fail the model)
+	MaxBatchSize       int   `yaml:"max_batch_size,omitempty" json:"max_batch_size" validate:"gte=0"`               // max number of requests in one batch (1000 by default)
+}
+
+// Override returns limits with the given ones taking precedence over the current ones
+func (c *LimitsConfig) Override(override *LimitsConfig) LimitsConfig {
+	var limits LimitsConfig
+
+	if c != nil {
+		limits = *c
+	}
+
+	if override == nil {
+		return limits
+	}
+
+	if override.MaxRequestBodySize > 0 {
+		limits.MaxRequestBodySize = override.MaxRequestBodySize
+	}
+
+	if override.MaxMessages > 0 {
+		limits.MaxMessages = override.MaxMessages
+	}
+
+	if override.MaxResponseSize > 0 {
+		limits.MaxResponseSize = override.MaxResponseSize
+	}
+
+	if override.MaxBatchSize > 0 {
+		limits.MaxBatchSize = override.MaxBatchSize
+	}
+
+	return limits
+}
+
+func newLimitViolations(tel *telemetry.Telemetry) *prometheus.CounterVec {
+	return tel.Metrics.CounterVec(
+		"router_limit_violations_total",
+		"Number of requests & provider responses that exceeded router limits",
+		"router", "limit",
+	)
+}
+
+// CheckRequestBodySize rejects request bodies bigger than the router accepts
+func (r *LangRouter) CheckRequestBodySize(size int) error {
+	if r.limits.MaxRequestBodySize == 0 || size <= r.limits.MaxRequestBodySize {
+		return nil
+	}
+
+	r.limitViolated(LimitRequestBodySize)
+
+	return fmt.Errorf(
+		"%w: the request body is %v bytes while the router accepts up to %v bytes",
+		ErrRequestTooLarge,
+		size,
+		r.limits.MaxRequestBodySize,
+	)
+}
+
+// checkMessages rejects requests with more messages than the router accepts
+func (r *LangRouter) checkMessages(request *schemas.UnifiedChatRequest) error {
+	if r.limits.MaxMessages == 0 {
+		return nil
+	}
+
+	messages := len(request.ChatMessages())
+	if messages <= r.limits.MaxMessages {
+		return nil
+	}
+
+	r.limitViolated(LimitMessages)
+
+	return fmt.Errorf(
+		"%w: the request has %v messages while the router accepts up to %v messages",
+		ErrRequestTooLarge,
+		messages,
+		r.limits.MaxMessages,
+	)
+}
+
+// checkBatchSize rejects batches with more requests than the router accepts
+func (r *LangRouter) checkBatchSize(size int) error {
+	maxBatchSize := r.limits.MaxBatchSize
+	if maxBatchSize == 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
+	if size <= maxBatchSize {
+		return nil
+	}
+
+	r.limitViolated(LimitBatchSize)
+
+	return fmt.Errorf(
+		"%w: the batch has %v requests while the router accepts up to %v requests",
+		ErrRequestTooLarge,
+		size,
+		maxBatchSize,
+	)
+}
+
+func (r *LangRouter) limitViolated(limit string) {
+	r.limitViolations.WithLabelValues(r.routerID, limit).Inc()
+}
@@ -0,0 +1,68 @@
+package routers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+	"glide/pkg/telemetry"
+)
+
+func TestLangRouter_EnforcesLimits(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+	tel := telemetry.NewTelemetryMock()
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel(
+			"first",
+			providers.NewProviderMock([]providers.ResponseMock{{Err: &clients.ErrResponseTooLarge}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+		providers.NewLangModel(
+			"second",
+			providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	router := newTestRouter(
+		t,
+		langModels,
+		withLimits(&LimitsConfig{MaxRequestBodySize: 100, MaxMessages: 5, MaxResponseSize: 1024}),
+		withRouterConfig(func(cfg *LangRouterConfig) {
+			cfg.Limits = &LimitsConfig{MaxMessages: 2}
+		}),
+		withTelemetry(tel),
+	)
+
+	require.Equal(t, LimitsConfig{MaxRequestBodySize: 100, MaxMessages: 2, MaxResponseSize: 1024}, router.limits)
+
+	require.NoError(t, router.CheckRequestBodySize(100))
+	require.ErrorIs(t, router.CheckRequestBodySize(101), ErrRequestTooLarge)
+
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.MessageHistory = []schemas.ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hi"}}
+
+	_, err := router.Chat(context.Background(), req)
+	require.ErrorIs(t, err, ErrRequestTooLarge)
+
+	// the oversized response fails the model, so the request falls back to the next one
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "second", resp.ModelID)
+
+	for _, limit := range []string{LimitRequestBodySize, LimitMessages, LimitResponseSize} {
+		require.Equal(t, 1.0, testutil.ToFloat64(router.limitViolations.WithLabelValues("test_router", limit)), limit)
+	}
+}
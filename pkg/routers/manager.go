@@ -1,52 +1,216 @@
 package routers
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 
+	"glide/pkg/cluster"
 	"glide/pkg/telemetry"
+	"go.uber.org/zap"
 )
 
 var ErrRouterNotFound = errors.New("no router found with given ID")
 
-type RouterManager struct {
-	Config        *Config
-	telemetry     *telemetry.Telemetry
-	langRouterMap *map[string]*LangRouter
+// routerSet is an immutable snapshot of routers built out of one config revision
+type routerSet struct {
+	config        *Config
+	langRouterMap map[string]*LangRouter
 	langRouters   []*LangRouter
 }
 
-// NewManager creates a new instance of Router Manager that creates, holds and returns all routers
-func NewManager(cfg *Config, tel *telemetry.Telemetry) (*RouterManager, error) {
-	langRouters, err := cfg.BuildLangRouters(tel)
-	if err != nil {
-		return nil, err
-	}
-
+func newRouterSet(cfg *Config, langRouters []*LangRouter) *routerSet {
 	langRouterMap := make(map[string]*LangRouter, len(langRouters))
 
 	for _, router := range langRouters {
 		langRouterMap[router.ID()] = router
 	}
 
-	manager := RouterManager{
-		Config:        cfg,
-		telemetry:     tel,
+	return &routerSet{
+		config:        cfg,
+		langRouterMap: langRouterMap,
 		langRouters:   langRouters,
-		langRouterMap: &langRouterMap,
+	}
+}
+
+// RouterManager holds routers and swaps them on config reloads. It's the entry point of Glide embedded as a library.
+// All its methods are safe for concurrent use: routers are swapped atomically on reloads,
+// while requests in-flight are finished by routers they have started with
+type RouterManager struct {
+	telemetry *telemetry.Telemetry
+	cluster   *cluster.Cluster // shares router state with other gateway replicas (nil if the gateway runs alone)
+	hooks     *Hooks
+	routers   atomic.Pointer[routerSet]
+	reloadMu  sync.Mutex
+	// background warmup loops run between Start & Close
+	lifecycleMu    sync.Mutex
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
+}
+
+// NewManager creates a new instance of Router Manager that creates, holds and returns all routers.
+// The config is taken as is, so it should be parsed (e.g. by the config provider) or built with defaults in code.
+// Routers share their state with other gateway replicas if the cluster is given.
+// Telemetry is optional: logs & error reports are discarded without it
+func NewManager(cfg *Config, tel *telemetry.Telemetry, cl *cluster.Cluster) (*RouterManager, error) {
+	if tel == nil {
+		tel = telemetry.NewNopTelemetry()
+	}
+
+	builtinHooks, err := buildHooks(cfg.Hooks, tel)
+	if err != nil {
+		return nil, err
+	}
+
+	langRouters, err := cfg.RebuildLangRouters(tel, cl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := RouterManager{
+		telemetry: tel,
+		cluster:   cl,
+		hooks:     newHooks(tel),
+	}
+
+	manager.hooks.apply(cfg.Hooks, builtinHooks)
+	manager.routers.Store(manager.newRouterSet(cfg, langRouters))
+
+	if cl.SharesRateLimits() {
+		cl.SubscribeRateLimits(manager.applyRateLimit)
 	}
 
 	return &manager, err
 }
 
+// Reload rebuilds routers out of the new config and atomically swaps them with the current ones.
+// Models with unchanged configs are reused, so they keep their health & latency stats.
+// Requests in-flight are finished by routers they have started with.
+// The current routers keep serving if the new config could not be applied
+func (r *RouterManager) Reload(cfg *Config) error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	builtinHooks, err := buildHooks(cfg.Hooks, r.telemetry)
+	if err != nil {
+		return err
+	}
+
+	langRouters, err := cfg.RebuildLangRouters(r.telemetry, r.cluster, r.routers.Load().langRouters)
+	if err != nil {
+		return err
+	}
+
+	r.hooks.apply(cfg.Hooks, builtinHooks)
+	r.routers.Store(r.newRouterSet(cfg, langRouters))
+
+	r.telemetry.Logger.Info("routers reloaded", zap.Int("langRouters", len(langRouters)))
+
+	return nil
+}
+
+// newRouterSet makes the routers run requests through the manager hooks
+func (r *RouterManager) newRouterSet(cfg *Config, langRouters []*LangRouter) *routerSet {
+	for _, router := range langRouters {
+		router.hooks = r.hooks
+	}
+
+	return newRouterSet(cfg, langRouters)
+}
+
+// RegisterHook adds the hook all requests go through. The hook runs at the position of its name in the routers.hooks config
+// (with the configured error policy), otherwise it runs after the configured hooks and rejects requests it fails
+func (r *RouterManager) RegisterHook(name string, hook Hook) error {
+	return r.hooks.register(name, hook)
+}
+
+// Config returns the config the current routers were built from
+func (r *RouterManager) Config() *Config {
+	return r.routers.Load().config
+}
+
 func (r *RouterManager) GetLangRouters() []*LangRouter {
-	return r.langRouters
+	return r.routers.Load().langRouters
+}
+
+// Router returns the router by ID. Chat requests to unknown routers fail with ErrRouterNotFound,
+// so the router could be called right away (e.g. manager.Router("mychat").Chat(ctx, request))
+func (r *RouterManager) Router(routerID string) *LangRouter {
+	router, _ := r.GetLangRouter(routerID)
+
+	return router
 }
 
 // GetLangRouter returns a router by type and ID
 func (r *RouterManager) GetLangRouter(routerID string) (*LangRouter, error) {
-	if router, found := (*r.langRouterMap)[routerID]; found {
+	if router, found := r.routers.Load().langRouterMap[routerID]; found {
 		return router, nil
 	}
 
 	return nil, ErrRouterNotFound
 }
+
+// applyRateLimit applies the rate limit another replica has run into, so this replica doesn't hit it too
+func (r *RouterManager) applyRateLimit(event *cluster.RateLimitEvent) {
+	router, err := r.GetLangRouter(event.RouterID)
+	if err != nil {
+		return
+	}
+
+	if router.applyRateLimit(event.ModelID, event.UntilReset()) {
+		r.telemetry.Logger.Debug(
+			"model is rate limited by another replica",
+			zap.String("routerID", event.RouterID),
+			zap.String("modelID", event.ModelID),
+			zap.Time("resetAt", event.ResetAt),
+		)
+	}
+}
+
+// Start warms up routers and keeps their provider connections & idle model latencies fresh in the background
+// until the context is done or Close is called. It doesn't depend on API servers or OS signals, so it's fine to call it
+// when Glide is embedded as a library. Routers serve requests without Start too, just without the warmup
+func (r *RouterManager) Start(ctx context.Context) {
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+
+	if r.stopBackground != nil {
+		return
+	}
+
+	r.Warmup(ctx)
+
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	r.stopBackground = stopBackground
+
+	r.background.Add(2)
+
+	go func() {
+		defer r.background.Done()
+
+		r.KeepConnectionsWarm(backgroundCtx)
+	}()
+
+	go func() {
+		defer r.background.Done()
+
+		r.RefreshIdleLatencies(backgroundCtx)
+	}()
+}
+
+// Close stops the background work started by Start & waits for it to finish.
+// The cluster given to the manager is not closed, as it's owned by the caller
+func (r *RouterManager) Close() {
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+
+	if r.stopBackground == nil {
+		return
+	}
+
+	r.stopBackground()
+	r.background.Wait()
+
+	r.stopBackground = nil
+}
@@ -0,0 +1,136 @@
+package routers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/cluster"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/providers/openai"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+	"glide/pkg/routers/retry"
+	"glide/pkg/routers/routing"
+	"glide/pkg/telemetry"
+)
+
+func buildManagerConfig(modelIDs ...string) *Config {
+	defaultParams := openai.DefaultParams()
+	models := make([]providers.LangModelConfig, 0, len(modelIDs))
+
+	for _, modelID := range modelIDs {
+		models = append(models, providers.LangModelConfig{
+			ID:          modelID,
+			Enabled:     true,
+			Client:      clients.DefaultClientConfig(),
+			ErrorBudget: health.DefaultErrorBudget(),
+			Latency:     latency.DefaultConfig(),
+			OpenAI: &openai.Config{
+				APIKey:        "ABC",
+				DefaultParams: &defaultParams,
+			},
+		})
+	}
+
+	return &Config{
+		LanguageRouters: []LangRouterConfig{
+			{
+				ID:              "first_router",
+				Enabled:         true,
+				RoutingStrategy: routing.Priority,
+				Retry:           retry.DefaultExpRetryConfig(),
+				Models:          models,
+			},
+		},
+	}
+}
+
+func TestRouterManager_ReloadReusesUnchangedModels(t *testing.T) {
+	manager, err := NewManager(buildManagerConfig("first", "second"), telemetry.NewTelemetryMock(), nil)
+	require.NoError(t, err)
+
+	prevRouter, err := manager.GetLangRouter("first_router")
+	require.NoError(t, err)
+
+	newConfig := buildManagerConfig("first", "second", "third")
+	newConfig.LanguageRouters[0].Models[1].Weight = 5
+
+	require.NoError(t, manager.Reload(newConfig))
+
+	router, err := manager.GetLangRouter("first_router")
+	require.NoError(t, err)
+
+	require.NotSame(t, prevRouter, router)
+	require.Same(t, newConfig, manager.Config())
+	require.Len(t, router.models, 3)
+	require.Same(t, prevRouter.models[0], router.models[0])
+	require.NotSame(t, prevRouter.models[1], router.models[1])
+}
+
+func TestRouterManager_InvalidReloadKeepsRouters(t *testing.T) {
+	cfg := buildManagerConfig("first")
+
+	manager, err := NewManager(cfg, telemetry.NewTelemetryMock(), nil)
+	require.NoError(t, err)
+
+	prevRouter, err := manager.GetLangRouter("first_router")
+	require.NoError(t, err)
+
+	newConfig := buildManagerConfig("first", "first")
+
+	require.Error(t, manager.Reload(newConfig))
+
+	router, err := manager.GetLangRouter("first_router")
+	require.NoError(t, err)
+
+	require.Same(t, prevRouter, router)
+	require.Same(t, cfg, manager.Config())
+}
+
+func TestRouterManager_AppliesRateLimitsOfOtherReplicas(t *testing.T) {
+	manager, err := NewManager(buildManagerConfig("first", "second"), telemetry.NewTelemetryMock(), nil)
+	require.NoError(t, err)
+
+	manager.applyRateLimit(&cluster.RateLimitEvent{
+		RouterID: "first_router",
+		ModelID:  "second",
+		ResetAt:  time.Now().Add(time.Minute),
+	})
+
+	// events of unknown routers are ignored
+	manager.applyRateLimit(&cluster.RateLimitEvent{
+		RouterID: "unknown_router",
+		ModelID:  "first",
+		ResetAt:  time.Now().Add(time.Minute),
+	})
+
+	router, err := manager.GetLangRouter("first_router")
+	require.NoError(t, err)
+
+	require.True(t, router.models[0].Healthy())
+	require.False(t, router.models[1].Healthy())
+}
+
+func TestRouterManager_EmbeddedLifecycle(t *testing.T) {
+	// telemetry is optional when Glide is embedded
+	manager, err := NewManager(buildManagerConfig("first"), nil, nil)
+	require.NoError(t, err)
+
+	require.NotNil(t, manager.Router("first_router"))
+
+	_, err = manager.Router("unknown_router").Chat(context.Background(), schemas.NewChatFromStr("Hello"))
+	require.ErrorIs(t, err, ErrRouterNotFound)
+
+	_, err = manager.Router("unknown_router").ChatStream(context.Background(), schemas.NewChatFromStr("Hello"))
+	require.ErrorIs(t, err, ErrRouterNotFound)
+
+	manager.Start(context.Background())
+	manager.Start(context.Background())
+
+	manager.Close()
+	manager.Close()
+}
@@ -0,0 +1,177 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/telemetry"
+	"go.uber.org/zap"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ErrContentPolicyViolation = errors.New("request violates the content policy")
+	ErrModerationUnavailable  = errors.New("content moderation is not available")
+)
+
+// Outcomes of the content moderation
+const (
+	moderationPassed  = "passed"
+	moderationFlagged = "flagged"
+	moderationFailed  = "failed"
+)
+
+// ModerationConfig defines the content moderation of user messages before requests are routed to models
+type ModerationConfig struct {
+	Backend *providers.ModeratorConfig `yaml:"backend" json:"backend" validate:"required"` // the moderation model
+	// Threshold is the min category score to flag the request (applies to categories without thresholds of their own)
+	Threshold  float64            `yaml:"threshold,omitempty" json:"threshold" validate:"gt=0,lte=1"`
+	Thresholds map[string]float64 `yaml:"thresholds,omitempty" json:"thresholds,omitempty" validate:"omitempty,dive,gt=0,lte=1"` // per-category thresholds (e.g. violence: 0.8)
+	Timeout    time.Duration      `yaml:"timeout,omitempty" json:"timeout" swaggertype:"primitive,integer" validate:"gt=0"`      // how long the moderation could take
+	OnError    string             `yaml:"on_error,omitempty" json:"on_error" validate:"oneof=fail_closed fail_open"`             // what to do when the moderation backend fails
+}
+
+func DefaultModerationConfig() *ModerationConfig {
+	return &ModerationConfig{
+		Threshold: 0.5,
+		Timeout:   2 * time.Second,
+		OnError:   HookFailOpen,
+	}
+}
+
+func (c *ModerationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultModerationConfig()
+
+	type plain ModerationConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// threshold returns the min score of the category to flag the request
+func (c *ModerationConfig) threshold(category string) float64 {
+	if threshold, found := c.Thresholds[category]; found {
+		return threshold
+	}
+
+	return c.Threshold
+}
+
+// contentModeration checks user messages by the moderation backend.
+// It runs before routing, so flagged requests never reach models and don't burn their error budgets
+type contentModeration struct {
+	routerID  string
+	config    *ModerationConfig
+	moderator providers.Moderator
+	checks    *prometheus.CounterVec
+	logger    *zap.Logger
+}
+
+func newContentModeration(routerID string, cfg *ModerationConfig, tel *telemetry.Telemetry) (*contentModeration, error) {
+	moderator, err := cfg.Backend.ToModerator(tel)
+	if err != nil {
+		return nil, err
+	}
+
+	return newModeratedBy(routerID, cfg, moderator, tel), nil
+}
+
+// newModeratedBy checks requests by the moderator that has been built already
+func newModeratedBy(routerID string, cfg *ModerationConfig, moderator providers.Moderator, tel *telemetry.Telemetry) *contentModeration {
+	return &contentModeration{
+		routerID:  routerID,
+		config:    cfg,
+		moderator: moderator,
+		checks: tel.Metrics.CounterVec(
+			"moderation_checks_total",
+			"Number of chat requests checked by the content moderation per outcome (passed, flagged or failed)",
+			"router", "outcome",
+		),
+		logger: tel.Logger.Named("moderation"),
+	}
+}
+
+// check fails requests with user messages flagged in any category with ErrContentPolicyViolation.
+// Backend failures let requests through or fail them with ErrModerationUnavailable depending on the on_error policy
+func (m *contentModeration) check(ctx context.Context, request *schemas.UnifiedChatRequest) error {
+	texts := userTexts(request)
+	if len(texts) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	defer cancel()
+
+	scores, err := m.moderator.Moderate(ctx, texts)
+	if err != nil {
+		m.checks.WithLabelValues(m.routerID, moderationFailed).Inc()
+
+		m.logger.Warn(
+			"content moderation has failed",
+			zap.String("routerID", m.routerID),
+			zap.String("onError", m.config.OnError),
+			zap.Error(err),
+		)
+
+		if m.config.OnError == HookFailOpen {
+			return nil
+		}
+
+		return fmt.Errorf("%w: %w", ErrModerationUnavailable, err)
+	}
+
+	flagged := make([]string, 0)
+
+	for category, score := range scores {
+		if score >= m.config.threshold(category) {
+			flagged = append(flagged, category)
+		}
+	}
+
+	if len(flagged) == 0 {
+		m.checks.WithLabelValues(m.routerID, moderationPassed).Inc()
+
+		return nil
+	}
+
+	sort.Strings(flagged)
+
+	m.checks.WithLabelValues(m.routerID, moderationFlagged).Inc()
+	m.logger.Info("request is flagged by the content moderation", zap.String("routerID", m.routerID), zap.Strings("categories", flagged))
+
+	return fmt.Errorf("%w (categories: %v)", ErrContentPolicyViolation, strings.Join(flagged, ", "))
+}
+
+// userTexts collects texts of messages users have sent (system, assistant & tool messages are not moderated)
+func userTexts(request *schemas.UnifiedChatRequest) []string {
+	messages := make([]schemas.ChatMessage, 0, len(request.ChatMessages())+1)
+	messages = append(messages, request.ChatMessages()...)
+	messages = append(messages, request.Override.Message)
+
+	texts := make([]string, 0, len(messages))
+
+	for _, message := range messages {
+		switch message.Role {
+		case schemas.RoleSystem, schemas.RoleAssistant, schemas.RoleTool:
+			continue
+		}
+
+		if message.Content != "" {
+			texts = append(texts, message.Content)
+		}
+
+		for _, part := range message.ContentParts {
+			if part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+
+	return texts
+}
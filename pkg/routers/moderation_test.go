@@ -0,0 +1,117 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/telemetry"
+)
+
+// moderatorMock flags texts containing the word with the score
+type moderatorMock struct {
+	word  string
+	score float64
+	err   error
+	delay time.Duration
+	texts []string
+}
+
+func (m *moderatorMock) Moderate(ctx context.Context, texts []string) (map[string]float64, error) {
+	m.texts = texts
+
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	scores := map[string]float64{"violence": 0.01, "harassment": 0.01}
+
+	for _, text := range texts {
+		if strings.Contains(text, m.word) {
+			scores["violence"] = m.score
+		}
+	}
+
+	return scores, nil
+}
+
+func TestContentModeration_RejectsFlaggedRequests(t *testing.T) {
+	tel := telemetry.NewTelemetryMock()
+	moderator := &moderatorMock{word: "fight", score: 0.7}
+
+	cfg := DefaultModerationConfig()
+	router := newTestRouter(t, newSingleModel("1", "2"), withModerator(cfg, moderator), withTelemetry(tel))
+
+	request := schemas.NewChatFromStr("let's fight")
+	request.MessageHistory = []schemas.ChatMessage{{Role: schemas.RoleSystem, Content: "Be nice"}}
+
+	// flagged requests are rejected before routing
+	for i := 0; i < 2; i++ {
+		_, err := router.Chat(context.Background(), request)
+		require.ErrorIs(t, err, ErrContentPolicyViolation)
+		require.Contains(t, err.Error(), "violence")
+	}
+
+	// only user messages are moderated
+	require.Equal(t, []string{"let's fight"}, moderator.texts)
+
+	// models are not tried, so their error budgets are intact
+	require.True(t, router.models[0].Healthy())
+
+	// categories could have thresholds of their own
+	cfg.Thresholds = map[string]float64{"violence": 0.8}
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("let's fight"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+
+	expectedMetrics := `
+# HELP glide_moderation_checks_total Number of chat requests checked by the content moderation per outcome (passed, flagged or failed)
+# TYPE glide_moderation_checks_total counter
+glide_moderation_checks_total{outcome="flagged",router="test_router"} 2
+glide_moderation_checks_total{outcome="passed",router="test_router"} 1
+`
+
+	require.NoError(t, testutil.GatherAndCompare(
+		tel.Metrics.Registry(),
+		strings.NewReader(expectedMetrics),
+		"glide_moderation_checks_total",
+	))
+}
+
+func TestContentModeration_ErrorPolicies(t *testing.T) {
+	errBackendDown := errors.New("moderation backend is down")
+
+	cfg := DefaultModerationConfig()
+	cfg.Timeout = 10 * time.Millisecond
+
+	// slow backends are cut off by the moderation timeout
+	router := newTestRouter(t, newSingleModel("1", "2"), withModerator(cfg, &moderatorMock{delay: time.Second}))
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+
+	cfg.OnError = HookFailClosed
+
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrModerationUnavailable)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	router = newTestRouter(t, newSingleModel("1", "2"), withModerator(cfg, &moderatorMock{err: errBackendDown}))
+
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrModerationUnavailable)
+	require.ErrorIs(t, err, errBackendDown)
+}
@@ -0,0 +1,324 @@
+package pii
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrPersonalDataDetected = errors.New("request contains personal data")
+
+// Types of personal data entities
+const (
+	EntityEmail      = "email"
+	EntityPhone      = "phone"
+	EntityCreditCard = "credit_card"
+	EntitySSN        = "ssn"
+)
+
+// Actions on detected entities
+const (
+	ActionRedact = "redact" // replace the entity with a placeholder
+	ActionBlock  = "block"  // reject the request
+	ActionLog    = "log"    // only log & count the entity
+	ActionOff    = "off"    // don't detect the entity
+)
+
+// Config defines what to do with each type of personal data found in requests
+type Config struct {
+	Entities map[string]string `yaml:"entities,omitempty" json:"entities"` // entity type to the action (all entities are redacted by default)
+	// Restore puts the original values back into the response instead of placeholders the model has repeated.
+	// Values are kept in memory for the request lifetime only
+	Restore bool `yaml:"restore,omitempty" json:"restore"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Entities: map[string]string{
+			EntityEmail:      ActionRedact,
+			EntityPhone:      ActionRedact,
+			EntityCreditCard: ActionRedact,
+			EntitySSN:        ActionRedact,
+		},
+	}
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultConfig()
+
+	type plain Config // to avoid recursion
+
+	// configured entities are merged into the default ones
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
+
+// Validate checks that entity types & actions are known
+func (c *Config) Validate() error {
+	for entity, action := range c.Entities {
+		if _, found := detectors[entity]; !found {
+			return fmt.Errorf("unknown personal data entity \"%v\" (supported: %v)", entity, strings.Join(entityTypes(), ", "))
+		}
+
+		switch action {
+		case ActionRedact, ActionBlock, ActionLog, ActionOff:
+		default:
+			return fmt.Errorf(
+				"unknown action \"%v\" for the \"%v\" entity (supported: %v, %v, %v, %v)",
+				action, entity, ActionRedact, ActionBlock, ActionLog, ActionOff,
+			)
+		}
+	}
+
+	return nil
+}
+
+// detector finds entities of one type. Matches are validated (e.g. by checksums) to cut false positives
+type detector struct {
+	pattern *regexp.Regexp
+	valid   func(match string) bool
+}
+
+// entityOrder makes longer digit sequences (e.g. card numbers) go before the shorter ones that could be a part of them
+var entityOrder = []string{EntityEmail, EntityCreditCard, EntitySSN, EntityPhone}
+
+var detectors = map[string]detector{
+	EntityEmail: {
+		pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	},
+	EntityCreditCard: {
+		pattern: regexp.MustCompile(`\d(?:[ -]?\d){12,18}`),
+		valid:   validCardNumber,
+	},
+	EntitySSN: {
+		pattern: regexp.MustCompile(`\d{3}-\d{2}-\d{4}`),
+		valid:   validSSN,
+	},
+	EntityPhone: {
+		pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)[ .-]?|\d{3}[ .-])\d{3}[ .-]\d{4}`),
+	},
+}
+
+func entityTypes() []string {
+	types := make([]string, 0, len(detectors))
+
+	for entity := range detectors {
+		types = append(types, entity)
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+// Detector handles personal data according to the config
+type Detector struct {
+	config *Config
+}
+
+func NewDetector(cfg *Config) *Detector {
+	return &Detector{config: cfg}
+}
+
+// Restores tells if the original values should be put back into responses
+func (d *Detector) Restores() bool {
+	return d.config.Restore
+}
+
+// NewRedactor creates the redactor for one request
+func (d *Detector) NewRedactor() *Redactor {
+	return &Redactor{
+		detector:     d,
+		placeholders: make(map[string]string),
+		values:       make(map[string]string),
+		counts:       make(map[string]int),
+		findings:     make(map[string]int),
+	}
+}
+
+// Redactor handles personal data of one request. It keeps the values behind placeholders, so they could be restored.
+// The same value gets the same placeholder across all messages of the request
+type Redactor struct {
+	detector     *Detector
+	placeholders map[string]string // placeholder to the original value
+	values       map[string]string // original value to its placeholder
+	counts       map[string]int    // number of distinct values per entity (to number placeholders)
+	findings     map[string]int    // number of entity occurrences per entity
+}
+
+// Redact replaces entities with placeholders or only counts them depending on their actions.
+// Entities that should block the request fail it with ErrPersonalDataDetected
+func (r *Redactor) Redact(text string) (string, error) {
+	for _, entity := range entityOrder {
+		action, found := r.detector.config.Entities[entity]
+		if !found || action == ActionOff {
+			continue
+		}
+
+		var err error
+
+		text, err = r.redactEntity(text, entity, action)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return text, nil
+}
+
+func (r *Redactor) redactEntity(text string, entity string, action string) (string, error) {
+	entityDetector := detectors[entity]
+	matches := entityDetector.pattern.FindAllStringIndex(text, -1)
+
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var redacted strings.Builder
+
+	last := 0
+
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		value := text[start:end]
+
+		if !atBoundary(text, start, end) || (entityDetector.valid != nil && !entityDetector.valid(value)) {
+			continue
+		}
+
+		r.findings[entity]++
+
+		switch action {
+		case ActionBlock:
+			return "", fmt.Errorf("%w (%v)", ErrPersonalDataDetected, entity)
+		case ActionLog:
+			continue
+		}
+
+		redacted.WriteString(text[last:start])
+		redacted.WriteString(r.placeholder(entity, value))
+
+		last = end
+	}
+
+	redacted.WriteString(text[last:])
+
+	return redacted.String(), nil
+}
+
+func (r *Redactor) placeholder(entity string, value string) string {
+	if placeholder, found := r.values[value]; found {
+		return placeholder
+	}
+
+	r.counts[entity]++
+
+	placeholder := fmt.Sprintf("[%v_%v]", strings.ToUpper(entity), r.counts[entity])
+
+	r.values[value] = placeholder
+	r.placeholders[placeholder] = value
+
+	return placeholder
+}
+
+// Restore puts the original values back in place of placeholders
+func (r *Redactor) Restore(text string) string {
+	if len(r.placeholders) == 0 || !strings.Contains(text, "[") {
+		return text
+	}
+
+	replacements := make([]string, 0, 2*len(r.placeholders))
+
+	for placeholder, value := range r.placeholders {
+		replacements = append(replacements, placeholder, value)
+	}
+
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+// Redacted tells if any value has been replaced by a placeholder
+func (r *Redactor) Redacted() bool {
+	return len(r.placeholders) > 0
+}
+
+// Findings returns the number of detected occurrences per entity type
+func (r *Redactor) Findings() map[string]int {
+	return r.findings
+}
+
+// Action returns the action configured for the entity
+func (r *Redactor) Action(entity string) string {
+	return r.detector.config.Entities[entity]
+}
+
+// atBoundary checks that the match is not a part of a longer word or number
+func atBoundary(text string, start int, end int) bool {
+	if start > 0 {
+		if prev, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(prev) {
+			return false
+		}
+	}
+
+	if end < len(text) {
+		if next, _ := utf8.DecodeRuneInString(text[end:]); isWordRune(next) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// validCardNumber checks the length & the Luhn checksum of the card number
+func validCardNumber(number string) bool {
+	digits := make([]int, 0, len(number))
+
+	for _, char := range number {
+		if char >= '0' && char <= '9' {
+			digits = append(digits, int(char-'0'))
+		}
+	}
+
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+
+	for idx := len(digits) - 1; idx >= 0; idx-- {
+		digit := digits[idx]
+
+		if (len(digits)-idx)%2 == 0 {
+			digit *= 2
+
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
+
+// validSSN rejects numbers that are never issued as US Social Security Numbers
+func validSSN(ssn string) bool {
+	area, group, serial := ssn[0:3], ssn[4:6], ssn[7:11]
+
+	if area == "000" || area == "666" || area[0] == '9' {
+		return false
+	}
+
+	return group != "00" && serial != "0000"
+}
@@ -0,0 +1,75 @@
+package pii
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRedactor_RedactsEntities(t *testing.T) {
+	redactor := NewDetector(DefaultConfig()).NewRedactor()
+
+	redacted, err := redactor.Redact(
+		"Mail john.doe@example.com or call (555) 123-4567. Card: 4111 1111 1111 1111, SSN 123-45-6789. " +
+			"Again: john.doe@example.com",
+	)
+	require.NoError(t, err)
+
+	require.Equal(
+		t,
+		"Mail [EMAIL_1] or call [PHONE_1]. Card: [CREDIT_CARD_1], SSN [SSN_1]. Again: [EMAIL_1]",
+		redacted,
+	)
+
+	require.Equal(t, map[string]int{EntityEmail: 2, EntityPhone: 1, EntityCreditCard: 1, EntitySSN: 1}, redactor.Findings())
+
+	require.Equal(
+		t,
+		"Dear john.doe@example.com, your card 4111 1111 1111 1111 is blocked",
+		redactor.Restore("Dear [EMAIL_1], your card [CREDIT_CARD_1] is blocked"),
+	)
+}
+
+func TestRedactor_SkipsInvalidNumbers(t *testing.T) {
+	redactor := NewDetector(DefaultConfig()).NewRedactor()
+
+	text := "Order 4111 1111 1111 1112 (bad checksum), ticket 000-12-3456 (not an SSN), build 20240101123456"
+
+	redacted, err := redactor.Redact(text)
+	require.NoError(t, err)
+	require.Equal(t, text, redacted)
+	require.Empty(t, redactor.Findings())
+}
+
+func TestRedactor_Actions(t *testing.T) {
+	cfg := &Config{Entities: map[string]string{
+		EntityEmail:      ActionLog,
+		EntityCreditCard: ActionBlock,
+		EntityPhone:      ActionOff,
+	}}
+
+	redactor := NewDetector(cfg).NewRedactor()
+
+	redacted, err := redactor.Redact("Mail john@example.com or call 555-123-4567")
+	require.NoError(t, err)
+	require.Equal(t, "Mail john@example.com or call 555-123-4567", redacted)
+	require.Equal(t, map[string]int{EntityEmail: 1}, redactor.Findings())
+	require.False(t, redactor.Redacted())
+
+	_, err = redactor.Redact("Charge 5555555555554444")
+	require.ErrorIs(t, err, ErrPersonalDataDetected)
+}
+
+func TestConfig_MergesEntitiesWithDefaults(t *testing.T) {
+	var cfg Config
+
+	require.NoError(t, yaml.Unmarshal([]byte("entities:\n  ssn: block\nrestore: true\n"), &cfg))
+
+	require.True(t, cfg.Restore)
+	require.Equal(t, ActionBlock, cfg.Entities[EntitySSN])
+	require.Equal(t, ActionRedact, cfg.Entities[EntityEmail])
+
+	require.Error(t, yaml.Unmarshal([]byte("entities:\n  iban: redact\n"), &cfg))
+	require.Error(t, yaml.Unmarshal([]byte("entities:\n  ssn: hide\n"), &cfg))
+}
@@ -0,0 +1,52 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrPromptNotFound   = errors.New("prompt is not defined")
+	ErrPromptVarMissing = errors.New("prompt variables are not given")
+)
+
+// placeholderPattern matches {{var}} placeholders of named prompts
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+
+// Library maps names to system prompts requests could refer to instead of sending them each time.
+// Prompts could have {{var}} placeholders substituted by variables of the request
+type Library map[string]string
+
+// Render substitutes placeholders of the named prompt. All missing variables are reported at once
+func (l Library) Render(name string, vars map[string]string) (string, error) {
+	prompt, found := l[name]
+	if !found {
+		return "", fmt.Errorf("%w: %v", ErrPromptNotFound, name)
+	}
+
+	var missingVars []string
+
+	rendered := placeholderPattern.ReplaceAllStringFunc(prompt, func(placeholder string) string {
+		varName := placeholderPattern.FindStringSubmatch(placeholder)[1]
+
+		value, found := vars[varName]
+		if !found {
+			if !slices.Contains(missingVars, varName) {
+				missingVars = append(missingVars, varName)
+			}
+
+			return placeholder
+		}
+
+		return value
+	})
+
+	if len(missingVars) > 0 {
+		return "", fmt.Errorf("%w: %v (prompt: %v)", ErrPromptVarMissing, strings.Join(missingVars, ", "), name)
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,42 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibrary_Render(t *testing.T) {
+	library := Library{
+		"support": "You are a support agent of {{company}}. Answer in {{ language }}. {{company}} cares about you.",
+		"plain":   "You are a helpful assistant.",
+	}
+
+	prompt, err := library.Render("support", map[string]string{"company": "Acme", "language": "Dutch"})
+	require.NoError(t, err)
+	require.Equal(t, "You are a support agent of Acme. Answer in Dutch. Acme cares about you.", prompt)
+
+	// values are not substituted recursively
+	prompt, err = library.Render("support", map[string]string{"company": "{{language}}", "language": "Dutch"})
+	require.NoError(t, err)
+	require.Equal(t, "You are a support agent of {{language}}. Answer in Dutch. {{language}} cares about you.", prompt)
+
+	prompt, err = library.Render("plain", nil)
+	require.NoError(t, err)
+	require.Equal(t, "You are a helpful assistant.", prompt)
+}
+
+func TestLibrary_RenderErrors(t *testing.T) {
+	library := Library{"support": "You are a support agent of {{company}}. Answer in {{language}}. {{company}} cares."}
+
+	_, err := library.Render("support", map[string]string{"language": "Dutch", "tone": "friendly"})
+	require.ErrorIs(t, err, ErrPromptVarMissing)
+	require.ErrorContains(t, err, "company (prompt: support)")
+
+	_, err = library.Render("support", nil)
+	require.ErrorIs(t, err, ErrPromptVarMissing)
+	require.ErrorContains(t, err, "company, language")
+
+	_, err = library.Render("sales", nil)
+	require.ErrorIs(t, err, ErrPromptNotFound)
+}
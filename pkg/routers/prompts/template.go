@@ -0,0 +1,219 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"glide/pkg/api/schemas"
+)
+
+var ErrTemplateRendering = errors.New("failed to render the prompt template")
+
+// Config defines the prompt scaffolding added to requests before they are sent to models.
+// Templates use Go text/template syntax (https://pkg.go.dev/text/template) and could refer to:
+//   - .Content - the content of the wrapped user message (in the user template only)
+//   - .Vars - variables passed via template_vars of the request (e.g. {{ .Vars.language }})
+//   - .Request - the whole chat request (e.g. {{ len .Request.Tools }})
+type Config struct {
+	System   string          `yaml:"system,omitempty" json:"system,omitempty"`     // the system message added at the beginning of the conversation
+	Examples []ExampleConfig `yaml:"examples,omitempty" json:"examples,omitempty"` // few-shot messages added after the system message
+	User     string          `yaml:"user,omitempty" json:"user,omitempty"`         // wraps the content of the last user message
+	Cache    bool            `yaml:"cache,omitempty" json:"cache,omitempty"`       // mark the system message & examples as the prefix providers with prompt caching could cache
+}
+
+// ExampleConfig is a few-shot message of the template
+type ExampleConfig struct {
+	Role    string `yaml:"role" json:"role" validate:"required,oneof=user assistant"`
+	Content string `yaml:"content" json:"content" validate:"required"`
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Config // to avoid recursion
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	// templates are parsed on load, so syntax errors fail the config rather than requests
+	_, err := NewTemplate(c)
+
+	return err
+}
+
+// Template is the parsed prompt template
+type Template struct {
+	system   *template.Template
+	examples []exampleTemplate
+	user     *template.Template
+	cache    bool
+}
+
+type exampleTemplate struct {
+	role    string
+	content *template.Template
+}
+
+// templateData is what templates could refer to
+type templateData struct {
+	Content string
+	Vars    map[string]string
+	Request *schemas.UnifiedChatRequest
+}
+
+func NewTemplate(cfg *Config) (*Template, error) {
+	var err error
+
+	tmpl := &Template{cache: cfg.Cache}
+
+	if tmpl.system, err = parse("system", cfg.System); err != nil {
+		return nil, err
+	}
+
+	if tmpl.user, err = parse("user", cfg.User); err != nil {
+		return nil, err
+	}
+
+	for idx, example := range cfg.Examples {
+		content, err := parse(fmt.Sprintf("examples[%v]", idx), example.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl.examples = append(tmpl.examples, exampleTemplate{role: example.Role, content: content})
+	}
+
+	return tmpl, nil
+}
+
+func parse(name string, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v prompt template: %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+// Apply returns the request with the conversation scaffolded by the template. The given request is not modified
+func (t *Template) Apply(request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatRequest, error) {
+	data := templateData{
+		Vars:    request.TemplateVars,
+		Request: request,
+	}
+
+	if data.Vars == nil {
+		data.Vars = map[string]string{}
+	}
+
+	conversation := request.ChatMessages()
+	messages := make([]schemas.ChatMessage, 0, len(conversation)+len(t.examples)+1)
+
+	if t.system != nil {
+		content, err := render(t.system, &data)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, schemas.ChatMessage{Role: schemas.RoleSystem, Content: content})
+	}
+
+	for _, example := range t.examples {
+		content, err := render(example.content, &data)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, schemas.ChatMessage{Role: example.role, Content: content})
+	}
+
+	if t.cache && len(messages) > 0 {
+		// the scaffolding is the same across requests, so it's cached as the whole
+		messages[len(messages)-1].Cache = true
+	}
+
+	messages = append(messages, conversation...)
+
+	if t.user != nil {
+		if idx := lastUserMessage(messages); idx >= 0 {
+			data.Content = messages[idx].Content
+
+			content, err := render(t.user, &data)
+			if err != nil {
+				return nil, err
+			}
+
+			messages[idx].Content = content
+		}
+	}
+
+	templatedRequest := *request
+	templatedRequest.Messages = messages
+
+	return &templatedRequest, nil
+}
+
+// Text is a single template (e.g. the degraded response of the router) that could refer to the same data as prompt templates.
+// .Content is the content of the last user message
+type Text struct {
+	tmpl *template.Template
+}
+
+// NewText parses the text template. Empty texts are rendered as is
+func NewText(name string, text string) (*Text, error) {
+	tmpl, err := parse(name, text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Text{tmpl: tmpl}, nil
+}
+
+// Render renders the text for the request
+func (t *Text) Render(request *schemas.UnifiedChatRequest) (string, error) {
+	if t.tmpl == nil {
+		return "", nil
+	}
+
+	data := templateData{
+		Vars:    request.TemplateVars,
+		Request: request,
+	}
+
+	if data.Vars == nil {
+		data.Vars = map[string]string{}
+	}
+
+	messages := request.ChatMessages()
+
+	if idx := lastUserMessage(messages); idx >= 0 {
+		data.Content = messages[idx].Content
+	}
+
+	return render(t.tmpl, &data)
+}
+
+func render(tmpl *template.Template, data *templateData) (string, error) {
+	var content strings.Builder
+
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrTemplateRendering, err)
+	}
+
+	return content.String(), nil
+}
+
+func lastUserMessage(messages []schemas.ChatMessage) int {
+	for idx := len(messages) - 1; idx >= 0; idx-- {
+		if messages[idx].Role == schemas.RoleUser {
+			return idx
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,73 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTemplate_ScaffoldsConversation(t *testing.T) {
+	tmpl, err := NewTemplate(&Config{
+		System: "You are a {{ .Vars.persona }}.",
+		Examples: []ExampleConfig{
+			{Role: schemas.RoleUser, Content: "Translate: hello"},
+			{Role: schemas.RoleAssistant, Content: "hola"},
+		},
+		User: "Translate: {{ .Content }}",
+	})
+	require.NoError(t, err)
+
+	request := &schemas.UnifiedChatRequest{
+		Message:      schemas.ChatMessage{Role: schemas.RoleUser, Content: "goodbye"},
+		TemplateVars: map[string]string{"persona": "translator"},
+	}
+
+	templatedRequest, err := tmpl.Apply(request)
+	require.NoError(t, err)
+
+	require.Equal(t, []schemas.ChatMessage{
+		{Role: schemas.RoleSystem, Content: "You are a translator."},
+		{Role: schemas.RoleUser, Content: "Translate: hello"},
+		{Role: schemas.RoleAssistant, Content: "hola"},
+		{Role: schemas.RoleUser, Content: "Translate: goodbye"},
+	}, templatedRequest.ChatMessages())
+
+	// the original request is kept as is
+	require.Equal(t, "goodbye", request.Message.Content)
+	require.Empty(t, request.Messages)
+}
+
+func TestTemplate_MarksScaffoldingCached(t *testing.T) {
+	tmpl, err := NewTemplate(&Config{
+		System:   "You are a translator.",
+		Examples: []ExampleConfig{{Role: schemas.RoleUser, Content: "Translate: hello"}, {Role: schemas.RoleAssistant, Content: "hola"}},
+		Cache:    true,
+	})
+	require.NoError(t, err)
+
+	templatedRequest, err := tmpl.Apply(schemas.NewChatFromStr("goodbye"))
+	require.NoError(t, err)
+
+	messages := templatedRequest.ChatMessages()
+	require.Len(t, messages, 4)
+	require.False(t, messages[0].Cache)
+	require.True(t, messages[2].Cache)
+	require.False(t, messages[3].Cache)
+}
+
+func TestTemplate_MissingVarsFailRendering(t *testing.T) {
+	tmpl, err := NewTemplate(&Config{System: "You are a {{ .Vars.persona }}."})
+	require.NoError(t, err)
+
+	_, err = tmpl.Apply(schemas.NewChatFromStr("hello"))
+	require.ErrorIs(t, err, ErrTemplateRendering)
+}
+
+func TestConfig_TemplatesValidatedOnLoad(t *testing.T) {
+	var cfg Config
+
+	require.NoError(t, yaml.Unmarshal([]byte(`system: "You are {{ .Vars.persona }}"`), &cfg))
+	require.ErrorContains(t, yaml.Unmarshal([]byte(`user: "Answer: {{ .Content "`), &cfg), "invalid user prompt template")
+}
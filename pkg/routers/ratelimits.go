@@ -0,0 +1,97 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+)
+
+// RateLimitedError is returned when the router got exhausted by rate limits only,
+// so clients could come back once the soonest limit resets instead of treating the router as down
+type RateLimitedError struct {
+	RouterID   string
+	UntilReset time.Duration // until the soonest rate limit reset of router models
+	lastErr    error
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("all models of the router \"%v\" are rate limited, the soonest limit resets in %v", e.RouterID, e.UntilReset)
+}
+
+// Unwrap keeps the router exhaustion & the last model failure matchable via errors.Is/errors.As
+func (e *RateLimitedError) Unwrap() []error {
+	if e.lastErr == nil {
+		return []error{ErrNoModelAvailable}
+	}
+
+	return []error{ErrNoModelAvailable, e.lastErr}
+}
+
+// isRateLimit tells if the model has failed because of the provider rate limit
+func isRateLimit(err error) bool {
+	var rle *clients.RateLimitError
+
+	return errors.As(err, &rle)
+}
+
+// exhaustedError explains why none of the models has served the request.
+// The router is rate limited when all attempts have run into rate limits
+// (or when all models have been skipped as rate limited), otherwise the last model failure is wrapped
+func (r *LangRouter) exhaustedError(models []providers.LanguageModel, lastErr error, rateLimitsOnly bool) error {
+	if untilReset, limited := soonestRateLimitReset(models, lastErr, rateLimitsOnly); limited {
+		return &RateLimitedError{
+			RouterID:   r.ID(),
+			UntilReset: untilReset,
+			lastErr:    lastErr,
+		}
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("%w: %w", ErrNoModelAvailable, lastErr)
+	}
+
+	return ErrNoModelAvailable
+}
+
+// soonestRateLimitReset returns when the first of the models gets back if rate limits are the reason they have not served the request
+func soonestRateLimitReset(models []providers.LanguageModel, lastErr error, rateLimitsOnly bool) (time.Duration, bool) {
+	var soonestReset time.Duration
+
+	limitedModels := 0
+
+	for _, model := range models {
+		rateLimited, ok := model.(providers.RateLimited)
+		if !ok {
+			continue
+		}
+
+		if untilReset := rateLimited.UntilRateLimitReset(); untilReset > 0 {
+			limitedModels++
+
+			if soonestReset == 0 || untilReset < soonestReset {
+				soonestReset = untilReset
+			}
+		}
+	}
+
+	if lastErr == nil {
+		// no model has been tried, so the router is rate limited only if all its models are
+		return soonestReset, len(models) > 0 && limitedModels == len(models)
+	}
+
+	if !rateLimitsOnly {
+		return 0, false
+	}
+
+	var rle *clients.RateLimitError
+
+	if soonestReset == 0 && errors.As(lastErr, &rle) {
+		// models that don't track rate limits could only tell about the last one
+		soonestReset = rle.UntilReset()
+	}
+
+	return soonestReset, true
+}
@@ -0,0 +1,260 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+	"glide/pkg/routers/retry"
+	"glide/pkg/routers/routing"
+	"glide/pkg/telemetry"
+)
+
+func TestLangRouter_Priority_AllModelsRateLimited(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	var rateLimitErr error = clients.NewRateLimitError(nil)
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel(
+			"first",
+			providers.NewProviderMock([]providers.ResponseMock{{Err: &rateLimitErr}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+
+	var rle *clients.RateLimitError
+
+	require.ErrorIs(t, err, ErrNoModelAvailable)
+	require.ErrorAs(t, err, &rle)
+
+	// all attempts have run into rate limits, so the router tells when to come back
+	var rateLimitedErr *RateLimitedError
+
+	require.ErrorAs(t, err, &rateLimitedErr)
+	require.InDelta(t, clients.DefaultRateLimitCooldown, rateLimitedErr.UntilReset, float64(time.Second))
+
+	// models stay rate limited, so the next request is not even tried
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorAs(t, err, &rateLimitedErr)
+}
+
+func TestLangRouter_Priority_MixedFailuresNotRateLimited(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	var rateLimitErr error = clients.NewRateLimitError(nil)
+
+	unavailableErr := clients.ErrProviderUnavailable
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel("first", providers.NewProviderMock([]providers.ResponseMock{{Err: &unavailableErr}}), *budget, *latConfig, 1),
+		providers.NewLangModel("second", providers.NewProviderMock([]providers.ResponseMock{{Err: &rateLimitErr}}), *budget, *latConfig, 1),
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{langModels[0], langModels[1]}),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+
+	var rateLimitedErr *RateLimitedError
+
+	// the last failure is the rate limit, but the router is unavailable for other reasons too
+	require.ErrorIs(t, err, ErrNoModelAvailable)
+	require.False(t, errors.As(err, &rateLimitedErr))
+}
+
+func TestLangRouter_QueueOnRateLimit_WaitsForSoonestReset(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	soonReset := 50 * time.Millisecond
+	lateReset := 10 * time.Second
+
+	var soonRateLimitErr error = clients.NewRateLimitError(&soonReset)
+
+	var lateRateLimitErr error = clients.NewRateLimitError(&lateReset)
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel(
+			"first",
+			providers.NewProviderMock([]providers.ResponseMock{{Err: &lateRateLimitErr}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+		providers.NewLangModel(
+			"second",
+			providers.NewProviderMock([]providers.ResponseMock{{Err: &soonRateLimitErr}, {Msg: "2"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{QueueOnRateLimit: &QueueConfig{MaxWait: 500 * time.Millisecond}},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	startedAt := time.Now()
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "second", resp.ModelID)
+	require.GreaterOrEqual(t, time.Since(startedAt), soonReset)
+}
+
+func TestLangRouter_QueueOnRateLimit_SkipsLateResets(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	untilReset := 10 * time.Second
+
+	var rateLimitErr error = clients.NewRateLimitError(&untilReset)
+
+	model := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Err: &rateLimitErr}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{QueueOnRateLimit: &QueueConfig{MaxWait: 500 * time.Millisecond}},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{model}),
+		models:    []providers.LanguageModel{model},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	startedAt := time.Now()
+
+	// the reset is beyond the wait window, so there is no point in waiting
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrNoModelAvailable)
+	require.Less(t, time.Since(startedAt), 500*time.Millisecond)
+}
+
+func TestLangRouter_QueueOnRateLimit_SkipsModelsUnhealthyForOtherReasons(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.HOUR)
+	latConfig := latency.DefaultConfig()
+
+	lateReset := 10 * time.Second
+
+	var lateRateLimitErr error = clients.NewRateLimitError(&lateReset)
+
+	failingModel := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	limitedModel := providers.NewLangModel(
+		"second",
+		providers.NewProviderMock([]providers.ResponseMock{{Err: &lateRateLimitErr}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	// the first model resets within the wait window, but it has run out of its error budget as well
+	failingModel.ChargeErrorBudget(errors.New("provider is down"))
+	failingModel.SetRateLimited(200 * time.Millisecond)
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{QueueOnRateLimit: &QueueConfig{MaxWait: 500 * time.Millisecond}},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{failingModel, limitedModel}),
+		models:    []providers.LanguageModel{failingModel, limitedModel},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	startedAt := time.Now()
+
+	// the second model resets beyond the wait window, so none of resets is worth waiting for
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrNoModelAvailable)
+	require.Less(t, time.Since(startedAt), 200*time.Millisecond)
+}
+
+func TestLangRouter_QueueOnRateLimit_RespectsCancellation(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	untilReset := 300 * time.Millisecond
+
+	var rateLimitErr error = clients.NewRateLimitError(&untilReset)
+
+	model := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Err: &rateLimitErr}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{QueueOnRateLimit: &QueueConfig{MaxWait: 500 * time.Millisecond}},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{model}),
+		models:    []providers.LanguageModel{model},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	startedAt := time.Now()
+
+	_, err := router.Chat(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(startedAt), untilReset)
+}
@@ -7,15 +7,27 @@ type ExpRetryConfig struct {
 	BaseMultiplier int            `yaml:"base_multiplier,omitempty" json:"base_multiplier"`
 	MinDelay       time.Duration  `yaml:"min_delay,omitempty" json:"min_delay" swaggertype:"primitive,integer"`
 	MaxDelay       *time.Duration `yaml:"max_delay,omitempty" json:"max_delay" swaggertype:"primitive,integer"`
+	// CountAgainstBudget makes every failed attempt burn the model error budget. Otherwise, models are tried once per retry
+	// and only their final failure in the request is counted, so one flaky request doesn't mark the model unhealthy
+	CountAgainstBudget bool `yaml:"count_against_budget" json:"count_against_budget"`
 }
 
 func DefaultExpRetryConfig() *ExpRetryConfig {
 	maxDelay := 5 * time.Second
 
 	return &ExpRetryConfig{
-		MaxRetries:     3,
-		BaseMultiplier: 2,
-		MinDelay:       2 * time.Second,
-		MaxDelay:       &maxDelay,
+		MaxRetries:         3,
+		BaseMultiplier:     2,
+		MinDelay:           2 * time.Second,
+		MaxDelay:           &maxDelay,
+		CountAgainstBudget: true,
 	}
 }
+
+func (c *ExpRetryConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultExpRetryConfig()
+
+	type plain ExpRetryConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
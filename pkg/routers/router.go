@@ -3,49 +3,203 @@ package routers
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"glide/pkg/cluster"
+	"glide/pkg/routers/cache"
+	"glide/pkg/routers/prompts"
 	"glide/pkg/routers/retry"
 	"go.uber.org/zap"
 
 	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
 
 	"glide/pkg/api/schemas"
 	"glide/pkg/routers/routing"
 	"glide/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
 	ErrNoModels         = errors.New("no models configured for router")
 	ErrNoModelAvailable = errors.New("could not handle request because all providers are not available")
+	ErrRequestTimeout   = errors.New("request timeout budget is exhausted")
 )
 
 type LangRouter struct {
-	routerID  string
-	Config    *LangRouterConfig
-	routing   routing.LangModelRouting
+	routerID string
+	Config   *LangRouterConfig
+	routing  routing.LangModelRouting
+	// capableRouting routes requests that need specific capabilities (e.g. tool calling) over the models that have them
+	capableRouting *capableRouting
+	// hintPools route requests with route hints over subsets of models
+	hintPools map[string]*modelPool
 	retry     *retry.ExpRetry
-	models    []providers.LanguageModel
-	telemetry *telemetry.Telemetry
+	// requestTimeout bounds the whole attempt sequence including retries & fallbacks (zero means unlimited)
+	requestTimeout time.Duration
+	// cache keeps responses of repeated requests (nil if caching is disabled)
+	cache cache.Cache
+	// semanticCache serves responses of similar prompts (nil if it's disabled)
+	semanticCache *cache.SemanticCache
+	// cacheMetrics tracks cache hits & savings (nil if caching is disabled)
+	cacheMetrics *cache.Metrics
+	// promptTemplate scaffolds requests to models without templates of their own (nil if the router has no template)
+	promptTemplate *prompts.Template
+	// prompts are named system prompts requests could refer to
+	prompts prompts.Library
+	// idempotency replays responses of requests repeated with the same idempotency key (nil if it's disabled)
+	idempotency *cache.IdempotencyStore
+	// moderation checks user messages before routing (nil if it's disabled)
+	moderation *contentModeration
+	// degraded answers requests no model could serve (nil if it's disabled)
+	degraded *degradedResponse
+	// hooks are shared by all routers of the manager (nil if the router is created on its own)
+	hooks *Hooks
+	// routerHooks run after the manager hooks for requests of this router only (nil if the router has no hooks)
+	routerHooks *Hooks
+	// limits are the global limits overridden by the router ones
+	limits          LimitsConfig
+	limitViolations *prometheus.CounterVec
+	// aliases are model aliases the models have been built with
+	aliases       providers.ModelAliases
+	aliasRequests *prometheus.CounterVec
+	models        []providers.LanguageModel
+	telemetry     *telemetry.Telemetry
 }
 
 func NewLangRouter(cfg *LangRouterConfig, tel *telemetry.Telemetry) (*LangRouter, error) {
-	models, err := cfg.BuildModels(tel)
+	return newLangRouter(cfg, nil, nil, nil, tel, nil, nil)
+}
+
+func newLangRouter(
+	cfg *LangRouterConfig,
+	globalLimits *LimitsConfig,
+	aliases providers.ModelAliases,
+	library prompts.Library,
+	tel *telemetry.Telemetry,
+	cl *cluster.Cluster,
+	prevModels reusableModels,
+) (*LangRouter, error) {
+	models, err := cfg.buildModels(tel, aliases, prevModels)
 	if err != nil {
 		return nil, err
 	}
 
+	return newLangRouterWithModels(cfg, models, globalLimits, aliases, library, tel, cl)
+}
+
+// newLangRouterWithModels wires up the router & its features around the models that are already built
+func newLangRouterWithModels(
+	cfg *LangRouterConfig,
+	models []providers.LanguageModel,
+	globalLimits *LimitsConfig,
+	aliases providers.ModelAliases,
+	library prompts.Library,
+	tel *telemetry.Telemetry,
+	cl *cluster.Cluster,
+) (*LangRouter, error) {
 	strategy, err := cfg.BuildRouting(models)
 	if err != nil {
 		return nil, err
 	}
 
 	router := &LangRouter{
-		routerID:  cfg.ID,
-		Config:    cfg,
-		models:    models,
-		retry:     cfg.BuildRetry(),
-		routing:   strategy,
-		telemetry: tel,
+		routerID:        cfg.ID,
+		Config:          cfg,
+		models:          models,
+		retry:           cfg.BuildRetry(),
+		routing:         strategy,
+		limits:          globalLimits.Override(cfg.Limits),
+		limitViolations: newLimitViolations(tel),
+		aliases:         aliases,
+		aliasRequests:   newAliasRequests(tel),
+		prompts:         library,
+		telemetry:       tel,
+	}
+
+	setInFlightGauges(cfg.ID, models, tel)
+
+	router.capableRouting, err = buildCapableRouting(cfg, models)
+	if err != nil {
+		return nil, err
+	}
+
+	router.hintPools, err = buildHintPools(cfg, models)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.RequestTimeout != nil {
+		router.requestTimeout = *cfg.RequestTimeout
+	}
+
+	if cfg.Cache != nil {
+		router.cache = cache.NewMemoryCache(cfg.Cache.TTL, cfg.Cache.MaxEntries)
+		router.cacheMetrics = cache.NewMetrics(cfg.ID, tel)
+
+		if cl.SharesCache() {
+			router.cache = cl.Cache(router.cache, cfg.Cache.TTL)
+		}
+
+		if cfg.Cache.Semantic != nil {
+			router.semanticCache, err = cache.NewSemanticCacheFromConfig(cfg.Cache.Semantic, cfg.Cache.TTL, tel)
+			if err != nil {
+				return nil, fmt.Errorf("error initializing semantic cache: %w", err)
+			}
+		}
+	}
+
+	if len(cfg.Hooks) > 0 {
+		router.routerHooks, err = buildRouterHooks(cfg.Hooks, tel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Moderation != nil {
+		router.moderation, err = newContentModeration(cfg.ID, cfg.Moderation, tel)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing content moderation: %w", err)
+		}
+	}
+
+	if cfg.DegradedResponse != nil {
+		router.degraded, err = newDegradedResponse(cfg.ID, cfg.DegradedResponse, tel)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing degraded response: %w", err)
+		}
+	}
+
+	if cfg.PromptTemplate != nil {
+		router.promptTemplate, err = prompts.NewTemplate(cfg.PromptTemplate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Idempotency != nil {
+		var idempotencyCache cache.IdempotencyCache = cache.NewMemoryIdempotencyCache(cfg.Idempotency.TTL, cfg.Idempotency.MaxEntries)
+
+		if cl.SharesCache() {
+			idempotencyCache = cl.IdempotencyCache(idempotencyCache, cfg.Idempotency.TTL)
+		}
+
+		router.idempotency = cache.NewIdempotencyStore(idempotencyCache)
+	}
+
+	if cl.SharesRateLimits() {
+		for _, model := range models {
+			langModel, ok := model.(*providers.LangModel)
+			if !ok {
+				continue
+			}
+
+			langModel.OnRateLimited(func(untilReset time.Duration) {
+				cl.PublishRateLimit(cfg.ID, langModel.ID(), untilReset)
+			})
+		}
 	}
 
 	return router, err
@@ -55,35 +209,319 @@ func (r *LangRouter) ID() string {
 	return r.routerID
 }
 
+// reusableModels returns router's models along with configs they were built from
+func (r *LangRouter) reusableModels() reusableModels {
+	modelConfigs := make(map[string]providers.LangModelConfig, len(r.Config.Models))
+
+	for _, modelConfig := range r.Config.Models {
+		modelConfigs[modelConfig.ID] = modelConfig
+	}
+
+	models := make(reusableModels, len(r.models))
+
+	for _, model := range r.models {
+		modelConfig, found := modelConfigs[model.ID()]
+		if !found {
+			continue
+		}
+
+		models[model.ID()] = reusableModel{
+			config: modelConfig,
+			alias:  r.aliases[modelConfig.ProviderModel()],
+			model:  model,
+		}
+	}
+
+	return models
+}
+
+// Chat serves the request from the cache if possible, otherwise it's routed to models
 func (r *LangRouter) Chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if r == nil {
+		// the router has been looked up by an unknown ID
+		return nil, ErrRouterNotFound
+	}
+
 	if len(r.models) == 0 {
 		return nil, ErrNoModels
 	}
 
+	ctx = withHookContext(ctx, r.ID())
+
+	resp, err := r.hookedChat(ctx, request)
+
+	if errors.Is(err, ErrNoModelAvailable) && r.degraded != nil {
+		resp, err = r.degradedChat(request, err)
+	}
+
+	if resp != nil && r.hooksEnabled() {
+		// hooks could modify the response, while it may be shared with the cache
+		hookedResp := *resp
+		resp = &hookedResp
+
+		if err = r.filterHooks(ctx, resp); err != nil {
+			resp = nil
+		}
+	}
+
+	r.afterHooks(ctx, resp, err)
+
+	return resp, err
+}
+
+// degradedChat answers the request with the degraded response. The original error is kept if the response can't be rendered
+func (r *LangRouter) degradedChat(request *schemas.UnifiedChatRequest, err error) (*schemas.UnifiedChatResponse, error) {
+	resp, renderErr := r.degraded.respond(request)
+	if renderErr != nil {
+		r.telemetry.Logger.Warn("failed to render the degraded response", zap.String("routerID", r.ID()), zap.Error(renderErr))
+
+		return nil, err
+	}
+
+	r.telemetry.Logger.Warn("no model could serve the request, responding with the degraded response", zap.String("routerID", r.ID()), zap.Error(err))
+
+	return resp, nil
+}
+
+// degradedChatStream streams the degraded response. The original error is kept if the response can't be rendered
+func (r *LangRouter) degradedChatStream(request *schemas.UnifiedChatRequest, err error) (<-chan *schemas.ChatStreamResult, error) {
+	streamC, renderErr := r.degraded.respondStream(request)
+	if renderErr != nil {
+		r.telemetry.Logger.Warn("failed to render the degraded response", zap.String("routerID", r.ID()), zap.Error(renderErr))
+
+		return nil, err
+	}
+
+	r.telemetry.Logger.Warn("no model could serve the request, streaming the degraded response", zap.String("routerID", r.ID()), zap.Error(err))
+
+	return streamC, nil
+}
+
+// hookedChat runs the request through hooks & limits before serving it
+func (r *LangRouter) hookedChat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	request, err := r.applyNamedPrompt(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.beforeHooks(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkMessages(request); err != nil {
+		return nil, err
+	}
+
+	if err := r.moderate(ctx, request); err != nil {
+		return nil, err
+	}
+
+	request = r.applyUserHash(request)
+
+	if key := cache.IdempotencyKey(ctx); key != "" && r.idempotency != nil {
+		return r.idempotency.Do(ctx, r.ID(), key, request, func(ctx context.Context) (*schemas.UnifiedChatResponse, error) {
+			return r.cachedChat(ctx, request)
+		})
+	}
+
+	return r.cachedChat(ctx, request)
+}
+
+// cachedChat serves the request from the router cache if it's there
+func (r *LangRouter) cachedChat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if r.cache == nil || !r.Config.Cache.Cacheable(request) {
+		return r.chat(ctx, request)
+	}
+
+	// the key is taken before the request is truncated or overridden
+	cacheKey, err := cache.Key(r.ID(), request)
+	if err != nil {
+		r.telemetry.Logger.Warn("failed to hash the request, skipping the cache", zap.String("routerID", r.ID()), zap.Error(err))
+
+		return r.chat(ctx, request)
+	}
+
+	refresh := cache.RefreshRequested(ctx)
+
+	if !refresh {
+		if resp, found := r.cache.Get(ctx, cacheKey); found {
+			resp.Routing = nil // no model was tried this time
+			r.cacheMetrics.Hit(cache.KindExact, resp)
+
+			return resp, nil
+		}
+	}
+
+	var semanticLookup *cache.SemanticLookup
+
+	if r.semanticCache != nil && !cache.SemanticBypassed(ctx) {
+		semanticLookup = r.semanticCache.Embed(ctx, r.ID(), request)
+
+		if !refresh {
+			if resp, found := r.semanticCache.Get(ctx, semanticLookup); found {
+				resp.Routing = nil
+				r.cacheMetrics.Hit(cache.KindSemantic, resp)
+
+				return resp, nil
+			}
+		}
+	}
+
+	resp, err := r.chat(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if !refresh {
+		// refreshes skip the lookup, so they are not counted as misses
+		r.cacheMetrics.Miss(resp)
+	}
+
+	r.cache.Set(ctx, cacheKey, resp)
+	r.semanticCache.Set(ctx, semanticLookup, resp)
+
+	return resp, nil
+}
+
+func (r *LangRouter) chat(ctx context.Context, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	if r.requestTimeout > 0 {
+		// the client deadline (if any) is kept when it's shorter than the router budget
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, r.requestTimeout)
+		defer cancel()
+	}
+
+	ctx = clients.WithMaxResponseSize(ctx, r.limits.MaxResponseSize)
+
+	pool := r.modelPool(request)
+	req := pool.capableRouting.requirements(request)
+
+	// models without the capabilities the request needs are skipped, otherwise they would fail the request
+	modelRouting, err := pool.capableRouting.Routing(pool.routing, req)
+	if errors.Is(err, ErrContextLengthExceeded) && r.Config.Truncation == TruncationAuto {
+		request, err = pool.capableRouting.truncate(request)
+		if err == nil {
+			req = pool.capableRouting.requirements(request)
+			modelRouting, err = pool.capableRouting.Routing(pool.routing, req)
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w (router: %v)", err, r.ID())
+	}
+
+	routeModels := func() routing.LangModelIterator {
+		return routeSession(modelRouting, request)
+	}
+
+	// the last failures of models in the request (tracked only when retries don't count against error budgets)
+	var failures map[providers.LanguageModel]error
+
+	if r.Config.Retry != nil && !r.Config.Retry.CountAgainstBudget {
+		// models are tried once per retry and charged for their last failure only when the request is over
+		failures = make(map[providers.LanguageModel]error, len(pool.models))
+		ctx = providers.WithDeferredErrorBudget(ctx)
+
+		defer chargeErrorBudgets(failures)
+
+		routeModels = func() routing.LangModelIterator {
+			return newOnceIterator(routeSession(modelRouting, request), pool, req)
+		}
+	}
+
+	if r.Config.DeadlineAware {
+		// models that would not respond in time are skipped instead of making doomed calls
+		routeRetries := routeModels
+
+		routeModels = func() routing.LangModelIterator {
+			return withDeadline(ctx, routeRetries(), pool, req)
+		}
+	}
+
 	retryIterator := r.retry.Iterator()
 
+	// how long the request could still wait for rate limits to reset
+	var queueBudget time.Duration
+
+	if r.Config.QueueOnRateLimit != nil {
+		queueBudget = r.Config.QueueOnRateLimit.MaxWait
+	}
+
+	// the last model failure explains why the router got exhausted (e.g. rate limits or timeouts)
+	var lastErr error
+
+	// whether all model failures have been rate limits, so the router is rate limited rather than unavailable
+	rateLimitsOnly := true
+
+	// attempts are traced only on demand as they grow the response
+	var trace *schemas.RoutingTrace
+
+	if request.IncludeRouting {
+		trace = &schemas.RoutingTrace{}
+	}
+
 	for retryIterator.HasNext() {
-		modelIterator := r.routing.Iterator()
+		modelIterator := routeModels()
 
 		for {
+			if err := ctx.Err(); err != nil {
+				return nil, r.budgetError(err)
+			}
+
 			model, err := modelIterator.Next()
 
+			if errors.Is(err, ErrDeadlineTooShort) {
+				r.telemetry.Logger.Warn("no model could respond before the request deadline", zap.String("routerID", r.ID()))
+
+				return nil, err
+			}
+
 			if errors.Is(err, routing.ErrNoHealthyModels) {
+				queued, err := r.waitRateLimitReset(ctx, pool.models, &queueBudget)
+				if err != nil {
+					return nil, r.budgetError(err)
+				}
+
+				if queued {
+					modelIterator = routeModels()
+
+					continue
+				}
+
 				// no healthy model in the pool. Let's retry after some time
 				break
 			}
 
 			langModel := model.(providers.LanguageModel)
 
+			attemptRequest := request
+
 			// Check if there is an override in the request
-			if request.Override != (schemas.OverrideChatRequest{}) {
-				// Override the message if the language model ID matches the override model ID
-				if langModel.ID() == request.Override.Model {
-					request.Message = request.Override.Message
-				}
+			if request.Override.Model != "" && langModel.ID() == request.Override.Model {
+				// Override the message if the language model ID matches the override model ID.
+				// Only this attempt gets the override, so fallback models still receive the original message
+				attemptRequest = request.WithOverriddenMessage(request.Override.Message)
+			}
+
+			modelRequest, err := r.applyPromptTemplate(langModel, attemptRequest)
+			if err != nil {
+				return nil, err
+			}
+
+			startedAt := time.Now()
+			resp, err := langModel.Chat(ctx, modelRequest)
+			trace.AddAttempt(langModel.ID(), langModel.Provider(), time.Since(startedAt), err)
+
+			if errors.Is(err, providers.ErrUnsupportedParams) {
+				// the model is configured to reject requests it can't fully honor, so there is no point in retrying
+				return nil, err
+			}
+
+			if errors.Is(err, clients.ErrResponseTooLarge) {
+				r.limitViolated(LimitResponseSize)
 			}
 
-			resp, err := langModel.Chat(ctx, request)
 			if err != nil {
 				r.telemetry.Logger.Warn(
 					"lang model failed processing chat request",
@@ -93,10 +531,26 @@ func (r *LangRouter) Chat(ctx context.Context, request *schemas.UnifiedChatReque
 					zap.Error(err),
 				)
 
+				lastErr = err
+				rateLimitsOnly = rateLimitsOnly && isRateLimit(err)
+
+				if failures != nil {
+					failures[langModel] = err
+				}
+
 				continue
 			}
 
+			// the model has served the request in the end, so its failed attempts are not counted
+			delete(failures, langModel)
+
 			resp.RouterID = r.routerID
+			r.aliasServed(langModel)
+
+			if trace != nil {
+				trace.SelectedModel = langModel.ID()
+				resp.Routing = trace
+			}
 
 			return resp, nil
 		}
@@ -107,13 +561,488 @@ func (r *LangRouter) Chat(ctx context.Context, request *schemas.UnifiedChatReque
 
 		err := retryIterator.WaitNext(ctx)
 		if err != nil {
-			// something has cancelled the context
-			return nil, err
+			// something has cancelled the context or the time budget is over
+			return nil, r.budgetError(err)
 		}
 	}
 
 	// if we reach this part, then we are in trouble
 	r.telemetry.Logger.Error("no model was available to handle request", zap.String("routerID", r.ID()))
 
-	return nil, ErrNoModelAvailable
+	return nil, r.exhaustedError(pool.models, lastErr, rateLimitsOnly)
+}
+
+// ChatStream streams the response of the first healthy model that could stream. The router falls back to other models
+// only if the stream could not be started, as chunks that have been already sent can't be taken back
+func (r *LangRouter) ChatStream(ctx context.Context, request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error) {
+	if r == nil {
+		// the router has been looked up by an unknown ID
+		return nil, ErrRouterNotFound
+	}
+
+	if len(r.models) == 0 {
+		return nil, ErrNoModels
+	}
+
+	ctx = withHookContext(ctx, r.ID())
+
+	streamC, err := r.hookedChatStream(ctx, request)
+
+	if errors.Is(err, ErrNoModelAvailable) && r.degraded != nil {
+		streamC, err = r.degradedChatStream(request, err)
+	}
+
+	if err != nil {
+		r.afterHooks(ctx, nil, err)
+
+		return nil, err
+	}
+
+	if !r.hooksEnabled() {
+		return streamC, nil
+	}
+
+	hookedStreamC := make(chan *schemas.ChatStreamResult)
+
+	go func() {
+		defer close(hookedStreamC)
+
+		var streamErr error
+
+		for result := range streamC {
+			if result.Err != nil {
+				streamErr = result.Err
+			}
+
+			select {
+			case hookedStreamC <- result:
+			case <-ctx.Done():
+				r.afterHooks(ctx, nil, ctx.Err())
+
+				return
+			}
+		}
+
+		r.afterHooks(ctx, nil, streamErr)
+	}()
+
+	return hookedStreamC, nil
+}
+
+// hookedChatStream runs the request through hooks & limits before streaming the response of the first model that could do that
+func (r *LangRouter) hookedChatStream(ctx context.Context, request *schemas.UnifiedChatRequest) (<-chan *schemas.ChatStreamResult, error) {
+	request, err := r.applyNamedPrompt(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.beforeHooks(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkMessages(request); err != nil {
+		return nil, err
+	}
+
+	if err := r.moderate(ctx, request); err != nil {
+		return nil, err
+	}
+
+	request = r.applyUserHash(request)
+
+	ctx = clients.WithMaxResponseSize(ctx, r.limits.MaxResponseSize)
+
+	pool := r.modelPool(request)
+
+	req := pool.capableRouting.requirements(request)
+	req.capabilities = append(req.capabilities, clients.CapabilityStreaming)
+
+	modelRouting, err := pool.capableRouting.Routing(pool.routing, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w (router: %v)", err, r.ID())
+	}
+
+	modelIterator := newOnceIterator(routeSession(modelRouting, request), pool, req)
+
+	// startStream starts streaming from the next model that could do that
+	startStream := func() (providers.LanguageModel, <-chan *schemas.ChatStreamResult, error) {
+		var lastErr error
+
+		rateLimitsOnly := true
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, r.budgetError(err)
+			}
+
+			model, err := modelIterator.Next()
+			if err != nil {
+				break
+			}
+
+			langModel := model.(providers.LanguageModel)
+
+			streamer, ok := langModel.(providers.ChatStreamer)
+			if !ok {
+				continue
+			}
+
+			modelRequest, err := r.applyPromptTemplate(langModel, request)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			modelStreamC, err := streamer.ChatStream(ctx, modelRequest)
+			if errors.Is(err, providers.ErrUnsupportedParams) {
+				return nil, nil, err
+			}
+
+			if err != nil {
+				r.telemetry.Logger.Warn(
+					"lang model failed to start chat stream",
+					zap.String("routerID", r.ID()),
+					zap.String("modelID", langModel.ID()),
+					zap.String("provider", langModel.Provider()),
+					zap.Error(err),
+				)
+
+				lastErr = err
+				rateLimitsOnly = rateLimitsOnly && isRateLimit(err)
+
+				continue
+			}
+
+			r.aliasServed(langModel)
+
+			return langModel, modelStreamC, nil
+		}
+
+		r.telemetry.Logger.Error("no model was available to stream the response", zap.String("routerID", r.ID()))
+
+		return nil, nil, r.exhaustedError(pool.models, lastErr, rateLimitsOnly)
+	}
+
+	langModel, modelStreamC, err := startStream()
+	if err != nil {
+		return nil, err
+	}
+
+	streamC := make(chan *schemas.ChatStreamResult)
+
+	go func() {
+		defer close(streamC)
+
+		for {
+			lastChunk, contentSent, streamErr := r.relayStream(ctx, modelStreamC, streamC)
+			if streamErr == nil {
+				return
+			}
+
+			r.telemetry.Logger.Warn(
+				"lang model chat stream has failed",
+				zap.String("routerID", r.ID()),
+				zap.String("modelID", langModel.ID()),
+				zap.String("provider", langModel.Provider()),
+				zap.Bool("contentSent", contentSent),
+				zap.Error(streamErr),
+			)
+
+			if r.Config.StreamFailover && !contentSent {
+				// the client has seen nothing yet, so the response could be streamed by another model from scratch
+				nextLangModel, nextStreamC, err := startStream()
+				if err == nil {
+					langModel, modelStreamC = nextLangModel, nextStreamC
+
+					continue
+				}
+
+				r.telemetry.Logger.Warn("failed to fail over the chat stream", zap.String("routerID", r.ID()), zap.Error(err))
+			}
+
+			errorChunk := &schemas.UnifiedChatStreamChunk{
+				Created:      int(time.Now().UTC().Unix()),
+				Provider:     langModel.Provider(),
+				RouterID:     r.routerID,
+				ModelID:      langModel.ID(),
+				FinishReason: schemas.FinishReasonError,
+			}
+
+			if lastChunk != nil {
+				errorChunk.ID = lastChunk.ID
+				errorChunk.Model = lastChunk.Model
+			}
+
+			select {
+			case streamC <- &schemas.ChatStreamResult{Chunk: errorChunk, Err: streamErr}:
+			case <-ctx.Done():
+			}
+
+			return
+		}
+	}()
+
+	return streamC, nil
+}
+
+// relayStream forwards model chunks until the model stream is over. It returns the last forwarded chunk,
+// whether any content has reached the client & the error that has interrupted the stream
+func (r *LangRouter) relayStream(
+	ctx context.Context,
+	modelStreamC <-chan *schemas.ChatStreamResult,
+	streamC chan<- *schemas.ChatStreamResult,
+) (*schemas.UnifiedChatStreamChunk, bool, error) {
+	var lastChunk *schemas.UnifiedChatStreamChunk
+
+	contentSent := false
+
+	for result := range modelStreamC {
+		if result.Err != nil {
+			// let the model stream wind down without blocking on the rest of results
+			go func() {
+				for range modelStreamC { //nolint:revive
+				}
+			}()
+
+			return lastChunk, contentSent, result.Err
+		}
+
+		result.Chunk.RouterID = r.routerID
+
+		select {
+		case streamC <- result:
+		case <-ctx.Done():
+			return lastChunk, contentSent, nil
+		}
+
+		lastChunk = result.Chunk
+		contentSent = contentSent || result.Chunk.ModelResponse.Message.Content != ""
+	}
+
+	return lastChunk, contentSent, nil
+}
+
+// moderate checks the request by the content moderation if the router has it
+func (r *LangRouter) moderate(ctx context.Context, request *schemas.UnifiedChatRequest) error {
+	if r.moderation == nil {
+		return nil
+	}
+
+	return r.moderation.check(ctx, request)
+}
+
+// aliasServed counts requests served by models referring to aliases by the concrete models the aliases resolve to
+func (r *LangRouter) aliasServed(model providers.LanguageModel) {
+	aliased, ok := model.(providers.Aliased)
+	if !ok {
+		return
+	}
+
+	if alias, target := aliased.Alias(); alias != "" {
+		r.aliasRequests.WithLabelValues(r.routerID, model.ID(), alias, target).Inc()
+	}
+}
+
+func newAliasRequests(tel *telemetry.Telemetry) *prometheus.CounterVec {
+	return tel.Metrics.CounterVec(
+		"model_alias_requests_total",
+		"Number of chat requests served by models referring to aliases per the concrete model the alias resolved to",
+		"router", "model", "alias", "target",
+	)
+}
+
+func newModelsInFlight(tel *telemetry.Telemetry) *prometheus.GaugeVec {
+	return tel.Metrics.GaugeVec(
+		"model_requests_in_flight",
+		"Number of chat requests language models are processing at the moment",
+		"router", "model",
+	)
+}
+
+// setInFlightGauges makes models report their in-flight requests to metrics
+func setInFlightGauges(routerID string, models []providers.LanguageModel, tel *telemetry.Telemetry) {
+	inFlight := newModelsInFlight(tel)
+
+	for _, model := range models {
+		if gauged, ok := model.(providers.InFlightGauged); ok {
+			gauged.SetInFlightGauge(inFlight.WithLabelValues(routerID, model.ID()))
+		}
+	}
+}
+
+// chargeErrorBudgets charges error budgets of models for their deferred failures
+func chargeErrorBudgets(failures map[providers.LanguageModel]error) {
+	for model, err := range failures {
+		if budgeted, ok := model.(providers.ErrorBudgeted); ok {
+			budgeted.ChargeErrorBudget(err)
+		}
+	}
+}
+
+// onceIterator returns models the routing picks until it comes back to the tried ones
+// (e.g. the priority routing keeps picking the first healthy model), then the rest of capable models in order,
+// so each model is tried once
+type onceIterator struct {
+	models routing.LangModelIterator
+	pool   *modelPool
+	req    requirements
+	tier   int
+	tried  map[string]bool
+}
+
+func newOnceIterator(models routing.LangModelIterator, pool *modelPool, req requirements) *onceIterator {
+	return &onceIterator{
+		models: models,
+		pool:   pool,
+		req:    req,
+		tier:   pool.capableRouting.contextTier(req.contextTokens),
+		tried:  make(map[string]bool, len(pool.models)),
+	}
+}
+
+func (it *onceIterator) Next() (providers.Model, error) {
+	if model, err := it.models.Next(); err == nil && !it.tried[model.ID()] {
+		it.tried[model.ID()] = true
+
+		return model, nil
+	}
+
+	for _, model := range it.pool.models {
+		if !it.tried[model.ID()] && model.Healthy() && hasCapabilities(model, it.req.capabilities) && fitsContext(model, it.tier) {
+			it.tried[model.ID()] = true
+
+			return model, nil
+		}
+	}
+
+	return nil, routing.ErrNoHealthyModels
+}
+
+// routeSession returns the model iterator starting with the model the request session is pinned to when the router has session affinity
+func routeSession(modelRouting routing.LangModelRouting, request *schemas.UnifiedChatRequest) routing.LangModelIterator {
+	if sessionRouting, ok := modelRouting.(routing.SessionRouting); ok && request.SessionID != "" {
+		return sessionRouting.SessionIterator(request.SessionID)
+	}
+
+	return modelRouting.Iterator()
+}
+
+// applyNamedPrompt puts the named system prompt the request refers to at the beginning of the conversation
+// (before system messages of the request & prompt templates). The given request is not modified
+func (r *LangRouter) applyNamedPrompt(request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatRequest, error) {
+	if request.Prompt == nil {
+		return request, nil
+	}
+
+	system, err := r.prompts.Render(request.Prompt.Name, request.Prompt.Vars)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation := request.ChatMessages()
+
+	messages := make([]schemas.ChatMessage, 0, len(conversation)+1)
+	messages = append(messages, schemas.ChatMessage{Role: schemas.RoleSystem, Content: system})
+	messages = append(messages, conversation...)
+
+	promptedRequest := *request
+	promptedRequest.Messages = messages
+	promptedRequest.Prompt = nil
+
+	return &promptedRequest, nil
+}
+
+// applyUserHash replaces the end user ID by its hash, so providers could attribute abuse without getting raw user IDs.
+// The given request is not modified
+func (r *LangRouter) applyUserHash(request *schemas.UnifiedChatRequest) *schemas.UnifiedChatRequest {
+	if !r.Config.HashUser || request.User == "" {
+		return request
+	}
+
+	hashedRequest := *request
+	hashedRequest.User = request.UserHash()
+
+	return &hashedRequest
+}
+
+// applyPromptTemplate scaffolds the request by the template of the model or, if it has none, by the router one
+func (r *LangRouter) applyPromptTemplate(model providers.LanguageModel, request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatRequest, error) {
+	if request.SkipTemplate {
+		return request, nil
+	}
+
+	tmpl := r.promptTemplate
+
+	if templated, ok := model.(providers.PromptTemplated); ok && templated.PromptTemplate() != nil {
+		tmpl = templated.PromptTemplate()
+	}
+
+	if tmpl == nil {
+		return request, nil
+	}
+
+	return tmpl.Apply(request)
+}
+
+// waitRateLimitReset waits for the soonest rate limit reset of the given models (e.g. models of the route hint pool)
+// if it comes within the queue budget. Only models held back by rate limits alone are considered,
+// so it returns false right away if there is no point in waiting (e.g. models are unhealthy for other reasons)
+func (r *LangRouter) waitRateLimitReset(ctx context.Context, models []providers.LanguageModel, queueBudget *time.Duration) (bool, error) {
+	var untilReset time.Duration
+
+	for _, model := range models {
+		rateLimited, ok := model.(providers.RateLimited)
+		if !ok || !rateLimited.OnlyRateLimited() {
+			continue
+		}
+
+		if modelUntilReset := rateLimited.UntilRateLimitReset(); modelUntilReset > 0 && (untilReset == 0 || modelUntilReset < untilReset) {
+			untilReset = modelUntilReset
+		}
+	}
+
+	if untilReset == 0 || untilReset > *queueBudget {
+		return false, nil
+	}
+
+	r.telemetry.Logger.Debug(
+		"all models are rate limited, waiting for the soonest reset",
+		zap.String("routerID", r.ID()),
+		zap.Duration("untilReset", untilReset),
+	)
+
+	*queueBudget -= untilReset
+
+	timer := time.NewTimer(untilReset)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// applyRateLimit marks the model rate limited (e.g. when another gateway replica has hit the limit)
+func (r *LangRouter) applyRateLimit(modelID string, untilReset time.Duration) bool {
+	for _, model := range r.models {
+		if langModel, ok := model.(*providers.LangModel); ok && langModel.ID() == modelID {
+			langModel.SetRateLimited(untilReset)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// budgetError explains why the request context is done
+func (r *LangRouter) budgetError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		r.telemetry.Logger.Warn("request timeout budget is exhausted, no more attempts", zap.String("routerID", r.ID()))
+
+		return fmt.Errorf("%w: %w", ErrRequestTimeout, err)
+	}
+
+	return err
 }
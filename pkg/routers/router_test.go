@@ -2,6 +2,10 @@ package routers
 
 import (
 	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -9,15 +13,131 @@ import (
 
 	"glide/pkg/providers/clients"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"glide/pkg/api/schemas"
 	"glide/pkg/providers"
+	"glide/pkg/routers/cache"
 	"glide/pkg/routers/health"
+	"glide/pkg/routers/prompts"
 	"glide/pkg/routers/retry"
 	"glide/pkg/routers/routing"
 	"glide/pkg/telemetry"
+	"go.uber.org/zap"
 )
 
+// testRouterBuild is what newTestRouter builds the router from
+type testRouterBuild struct {
+	config     *LangRouterConfig
+	limits     *LimitsConfig
+	prompts    prompts.Library
+	hooks      *Hooks
+	moderation *ModerationConfig
+	moderator  providers.Moderator
+	telemetry  *telemetry.Telemetry
+}
+
+// testRouterOption customizes routers built by newTestRouter
+type testRouterOption func(build *testRouterBuild)
+
+// withRouterConfig tweaks the router config before the router is built
+func withRouterConfig(configure func(cfg *LangRouterConfig)) testRouterOption {
+	return func(build *testRouterBuild) {
+		configure(build.config)
+	}
+}
+
+// withLimits sets the global limits the router limits override
+func withLimits(limits *LimitsConfig) testRouterOption {
+	return func(build *testRouterBuild) {
+		build.limits = limits
+	}
+}
+
+// withPrompts lets requests refer to the named system prompts
+func withPrompts(library prompts.Library) testRouterOption {
+	return func(build *testRouterBuild) {
+		build.prompts = library
+	}
+}
+
+// withHooks shares the hooks with the router the same way the manager does
+func withHooks(hooks *Hooks) testRouterOption {
+	return func(build *testRouterBuild) {
+		build.hooks = hooks
+	}
+}
+
+// withModerator checks requests by the moderator instead of the one the moderation backend config would build
+func withModerator(cfg *ModerationConfig, moderator providers.Moderator) testRouterOption {
+	return func(build *testRouterBuild) {
+		build.moderation = cfg
+		build.moderator = moderator
+	}
+}
+
+func withTelemetry(tel *telemetry.Telemetry) testRouterOption {
+	return func(build *testRouterBuild) {
+		build.telemetry = tel
+	}
+}
+
+// newTestRouter builds the "test_router" router over the models the same way routers are built from their configs.
+// By default, it routes by priority & retries 3 times with millisecond delays
+func newTestRouter(t *testing.T, models []providers.LanguageModel, opts ...testRouterOption) *LangRouter {
+	t.Helper()
+
+	build := &testRouterBuild{
+		config: &LangRouterConfig{
+			ID:              "test_router",
+			Enabled:         true,
+			RoutingStrategy: routing.Priority,
+			Retry: &retry.ExpRetryConfig{
+				MaxRetries:         3,
+				BaseMultiplier:     2,
+				MinDelay:           time.Millisecond,
+				CountAgainstBudget: true,
+			},
+		},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	for _, model := range models {
+		build.config.Models = append(build.config.Models, providers.LangModelConfig{ID: model.ID(), Enabled: true})
+	}
+
+	for _, opt := range opts {
+		opt(build)
+	}
+
+	router, err := newLangRouterWithModels(build.config, models, build.limits, nil, build.prompts, build.telemetry, nil)
+	require.NoError(t, err)
+
+	router.hooks = build.hooks
+
+	if build.moderator != nil {
+		router.moderation = newModeratedBy(router.ID(), build.moderation, build.moderator, build.telemetry)
+	}
+
+	return router
+}
+
+// newMockModel serves the model by the provider with the default latency config & the budget of 3 errors per second
+func newMockModel(modelID string, provider providers.LangModelProvider) *providers.LangModel {
+	return providers.NewLangModel(modelID, provider, *health.NewErrorBudget(3, health.SEC), *latency.DefaultConfig(), 1)
+}
+
+// newSingleModel is the only "first" model of the router serving the messages in turn
+func newSingleModel(msgs ...string) []providers.LanguageModel {
+	responses := make([]providers.ResponseMock, 0, len(msgs))
+
+	for _, msg := range msgs {
+		responses = append(responses, providers.ResponseMock{Msg: msg})
+	}
+
+	return []providers.LanguageModel{newMockModel("first", providers.NewProviderMock(responses))}
+}
+
 func TestLangRouter_Priority_PickFistHealthy(t *testing.T) {
 	budget := health.NewErrorBudget(3, health.SEC)
 	latConfig := latency.DefaultConfig()
@@ -120,6 +240,68 @@ func TestLangRouter_Priority_PickThirdHealthy(t *testing.T) {
 	}
 }
 
+func TestLangRouter_Priority_TracesFallbacks(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel(
+			"first",
+			providers.NewProviderMock([]providers.ResponseMock{{Err: &ErrNoModelAvailable}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+		providers.NewLangModel(
+			"second",
+			providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Second, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	ctx := context.Background()
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.IncludeRouting = true
+
+	resp, err := router.Chat(ctx, req)
+	require.NoError(t, err)
+
+	require.NotNil(t, resp.Routing)
+	require.Equal(t, "second", resp.Routing.SelectedModel)
+	require.Len(t, resp.Routing.Attempts, 2)
+
+	require.Equal(t, "first", resp.Routing.Attempts[0].ModelID)
+	require.Equal(t, "provider_mock", resp.Routing.Attempts[0].Provider)
+	require.Equal(t, schemas.AttemptFailed, resp.Routing.Attempts[0].Outcome)
+	require.Equal(t, ErrNoModelAvailable.Error(), resp.Routing.Attempts[0].Error)
+
+	require.Equal(t, "second", resp.Routing.Attempts[1].ModelID)
+	require.Equal(t, schemas.AttemptSucceeded, resp.Routing.Attempts[1].Outcome)
+	require.Empty(t, resp.Routing.Attempts[1].Error)
+
+	// routing is not traced unless it's asked for
+	req.IncludeRouting = false
+
+	resp, err = router.Chat(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp.Routing)
+}
+
 func TestLangRouter_Priority_SuccessOnRetry(t *testing.T) {
 	budget := health.NewErrorBudget(1, health.MILLI)
 	latConfig := latency.DefaultConfig()
@@ -161,6 +343,54 @@ func TestLangRouter_Priority_SuccessOnRetry(t *testing.T) {
 	require.Equal(t, "test_router", resp.RouterID)
 }
 
+func TestLangRouter_Priority_RetriesCountAgainstBudget(t *testing.T) {
+	buildRouter := func(countAgainstBudget bool) (*LangRouter, *providers.LangModel) {
+		budget := health.NewErrorBudget(2, health.MIN)
+		responses := []providers.ResponseMock{
+			{Err: &clients.ErrProviderUnavailable},
+			{Err: &clients.ErrProviderUnavailable},
+			{Msg: "1"},
+			{Err: &clients.ErrProviderUnavailable},
+			{Err: &clients.ErrProviderUnavailable},
+			{Err: &clients.ErrProviderUnavailable},
+		}
+
+		model := providers.NewLangModel("first", providers.NewProviderMock(responses), *budget, *latency.DefaultConfig(), 1)
+
+		retryConfig := retry.DefaultExpRetryConfig()
+		retryConfig.CountAgainstBudget = countAgainstBudget
+
+		return &LangRouter{
+			routerID:  "test_router",
+			Config:    &LangRouterConfig{Retry: retryConfig},
+			retry:     retry.NewExpRetry(3, 2, 1*time.Millisecond, nil),
+			routing:   routing.NewPriority([]providers.Model{model}),
+			models:    []providers.LanguageModel{model},
+			telemetry: telemetry.NewTelemetryMock(),
+		}, model
+	}
+
+	// every failed attempt burns the budget, so the flaky model becomes unhealthy before it could recover
+	router, model := buildRouter(true)
+
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrNoModelAvailable)
+	require.False(t, model.Healthy())
+
+	// the model is tried once per retry & failures are not counted when the model serves the request in the end
+	router, model = buildRouter(false)
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+	require.True(t, model.Healthy())
+
+	// only the final failure of the failed request is counted
+	_, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, clients.ErrProviderUnavailable)
+	require.True(t, model.Healthy())
+}
+
 func TestLangRouter_Priority_UnhealthyModelInThePool(t *testing.T) {
 	budget := health.NewErrorBudget(1, health.MIN)
 	latConfig := latency.DefaultConfig()
@@ -242,3 +472,1275 @@ func TestLangRouter_Priority_AllModelsUnavailable(t *testing.T) {
 
 	require.Error(t, err)
 }
+
+func TestLangRouter_OverrideAppliesToOverriddenModelOnly(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	unavailableErr := clients.ErrProviderUnavailable
+
+	overriddenProvider := providers.NewProviderMock([]providers.ResponseMock{{Err: &unavailableErr}})
+	fallbackProvider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "2"}})
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel("first", overriddenProvider, *budget, *latConfig, 1),
+		providers.NewLangModel("second", fallbackProvider, *budget, *latConfig, 1),
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{langModels[0], langModels[1]}),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	request := schemas.NewChatFromStr("tell me a dad joke")
+	request.Messages = request.ChatMessages()
+	request.Override.Model = "first"
+	request.Override.Message = schemas.ChatMessage{Role: "user", Content: "tell me a dad joke in the first model style"}
+
+	resp, err := router.Chat(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, "second", resp.ModelID)
+
+	require.Equal(t, "tell me a dad joke in the first model style", overriddenProvider.LastRequest().ChatMessages()[0].Content)
+
+	// the overridden model has failed, so the fallback model gets the original message
+	require.Equal(t, "tell me a dad joke", fallbackProvider.LastRequest().ChatMessages()[0].Content)
+	require.Equal(t, "tell me a dad joke", request.Messages[0].Content)
+}
+
+// rateLimitedProviderMock is always rate limited for a short time, so the router has to wait and retry
+type rateLimitedProviderMock struct {
+	calls atomic.Int64
+}
+
+func (p *rateLimitedProviderMock) Chat(_ context.Context, _ *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	p.calls.Add(1)
+
+	untilReset := 1 * time.Millisecond
+
+	return nil, clients.NewRateLimitError(&untilReset)
+}
+
+func (p *rateLimitedProviderMock) Provider() string {
+	return "provider_mock"
+}
+
+func TestLangRouter_RequestTimeoutCutsOffRetries(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+	provider := &rateLimitedProviderMock{}
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel("first", provider, *budget, *latConfig, 1),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID: "test_router",
+		Config:   &LangRouterConfig{},
+		// attempts happen at ~0ms, ~50ms, ~100ms and ~200ms
+		retry:          retry.NewExpRetry(5, 1, 50*time.Millisecond, nil),
+		requestTimeout: 150 * time.Millisecond,
+		routing:        routing.NewPriority(models),
+		models:         langModels,
+		telemetry:      telemetry.NewTelemetryMock(),
+	}
+
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+
+	require.ErrorIs(t, err, ErrRequestTimeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, int64(3), provider.calls.Load())
+}
+
+func TestLangRouter_ClientDeadlineBoundsRequestTimeout(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+	provider := &rateLimitedProviderMock{}
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel("first", provider, *budget, *latConfig, 1),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:       "test_router",
+		Config:         &LangRouterConfig{},
+		retry:          retry.NewExpRetry(5, 1, 50*time.Millisecond, nil),
+		requestTimeout: 10 * time.Second,
+		routing:        routing.NewPriority(models),
+		models:         langModels,
+		telemetry:      telemetry.NewTelemetryMock(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+	defer cancel()
+
+	_, err := router.Chat(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+
+	require.ErrorIs(t, err, ErrRequestTimeout)
+	require.Equal(t, int64(2), provider.calls.Load())
+}
+
+type blockingProviderMock struct {
+	release     chan struct{}
+	inFlight    atomic.Int64
+	maxInFlight atomic.Int64
+	served      atomic.Int64
+}
+
+func (p *blockingProviderMock) Chat(_ context.Context, _ *schemas.UnifiedChatRequest) (*schemas.UnifiedChatResponse, error) {
+	current := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+
+	for {
+		maxInFlight := p.maxInFlight.Load()
+
+		if current <= maxInFlight || p.maxInFlight.CompareAndSwap(maxInFlight, current) {
+			break
+		}
+	}
+
+	if p.release != nil {
+		<-p.release
+	}
+
+	p.served.Add(1)
+
+	return &schemas.UnifiedChatResponse{
+		ModelResponse: schemas.ProviderResponse{
+			Message:    schemas.ChatMessage{Content: "ok"},
+			TokenUsage: schemas.TokenUsage{ResponseTokens: 1},
+		},
+	}, nil
+}
+
+func (p *blockingProviderMock) Provider() string {
+	return "blocking_provider_mock"
+}
+
+func TestLangRouter_Priority_MaxConcurrencyRespected(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	firstProvider := &blockingProviderMock{release: make(chan struct{})}
+	secondProvider := &blockingProviderMock{}
+
+	firstModel := providers.NewLangModel("first", firstProvider, *budget, *latConfig, 1)
+	firstModel.SetMaxConcurrency(2)
+
+	langModels := []providers.LanguageModel{
+		firstModel,
+		providers.NewLangModel("second", secondProvider, *budget, *latConfig, 1),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	tel := telemetry.NewTelemetryMock()
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: tel,
+	}
+
+	setInFlightGauges(router.routerID, langModels, tel)
+
+	inFlight := newModelsInFlight(tel)
+
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+			require.NoError(t, err)
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		return secondProvider.served.Load() == 8
+	}, 5*time.Second, 5*time.Millisecond)
+
+	require.Equal(t, int64(2), firstModel.InFlight())
+	require.InDelta(t, 2, testutil.ToFloat64(inFlight.WithLabelValues("test_router", "first")), 0)
+
+	close(firstProvider.release)
+	wg.Wait()
+
+	require.InDelta(t, 0, testutil.ToFloat64(inFlight.WithLabelValues("test_router", "first")), 0)
+	require.InDelta(t, 0, testutil.ToFloat64(inFlight.WithLabelValues("test_router", "second")), 0)
+
+	require.Equal(t, int64(2), firstProvider.maxInFlight.Load())
+	require.Equal(t, int64(2), firstProvider.served.Load())
+	require.Equal(t, int64(0), firstModel.InFlight())
+}
+
+func TestLangRouter_Priority_StrictParamsRejectRequest(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	strictModel := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	strictModel.SetStrictParams(true)
+
+	langModels := []providers.LanguageModel{
+		strictModel,
+		providers.NewLangModel(
+			"second",
+			providers.NewProviderMock([]providers.ResponseMock{{Msg: "2"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Second, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	seed := 42
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.Seed = &seed
+
+	_, err := router.Chat(context.Background(), req)
+	require.ErrorIs(t, err, providers.ErrUnsupportedParams)
+	require.True(t, strictModel.Healthy())
+
+	req.Seed = nil
+
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "first", resp.ModelID)
+}
+
+func TestLangRouter_Priority_MultipleCompletionsRequestedOneByOne(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	provider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}, {Msg: "3"}})
+	model := providers.NewLangModel("first", provider, *budget, *latConfig, 1)
+
+	buildRouter := func(model providers.LanguageModel) *LangRouter {
+		return newTestRouter(t, []providers.LanguageModel{model})
+	}
+
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.N = 3
+
+	// fan-out is disabled by default, as each request is billed
+	_, err := buildRouter(model).Chat(context.Background(), req)
+	require.ErrorIs(t, err, ErrNoCapableModel)
+
+	model.SetFanOut(&providers.FanOutConfig{MaxParallel: 1})
+
+	resp, err := buildRouter(model).Chat(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+	require.Len(t, resp.ModelResponse.Choices, 3)
+	require.Equal(t, "3", resp.ModelResponse.Choices[2].Content)
+
+	strictModel := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	strictModel.SetStrictParams(true)
+	strictModel.SetFanOut(&providers.FanOutConfig{MaxParallel: 1})
+
+	_, err = buildRouter(strictModel).Chat(context.Background(), req)
+	require.ErrorIs(t, err, providers.ErrUnsupportedParams)
+}
+
+func TestLangRouter_MultipleCompletionsFannedOutInParallel(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	usage := schemas.TokenUsage{PromptTokens: 10, ResponseTokens: 5, TotalTokens: 15}
+
+	responses := make([]providers.ResponseMock, 0, 4)
+	for idx := 0; idx < 4; idx++ {
+		responses = append(responses, providers.ResponseMock{Msg: "joke", Delay: 50 * time.Millisecond, TokenUsage: usage})
+	}
+
+	model := providers.NewLangModel("first", providers.NewProviderMock(responses), *budget, *latency.DefaultConfig(), 1)
+	model.SetFanOut(&providers.FanOutConfig{MaxParallel: 2})
+
+	router := newTestRouter(t, []providers.LanguageModel{model})
+
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.N = 4
+
+	startedAt := time.Now()
+
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+
+	// two batches of two requests
+	elapsed := time.Since(startedAt)
+	require.GreaterOrEqual(t, elapsed, 100*time.Millisecond)
+	require.Less(t, elapsed, 150*time.Millisecond)
+	require.Len(t, resp.ModelResponse.Choices, 4)
+
+	// every request is billed, so usage is summed up across them
+	require.Equal(t, schemas.TokenUsage{PromptTokens: 40, ResponseTokens: 20, TotalTokens: 60}, resp.ModelResponse.TokenUsage)
+}
+
+func TestLangRouter_Priority_StructuredOutputFallback(t *testing.T) {
+	// the budget outlasts the invalid response, so the model is retried right away
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	fallbackModel := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{
+			{Msg: "Sure! Here is the whale: {\"animal\""},
+			{Msg: "```json\n{\"animal\": \"blue whale\"}\n```"},
+		}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	fallbackModel.SetStructuredOutputFallback(true)
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel(
+			"incapable",
+			providers.NewProviderMock([]providers.ResponseMock{{Msg: "not JSON"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+		fallbackModel,
+	}
+
+	router := newTestRouter(t, langModels)
+
+	req := schemas.NewChatFromStr("what's the biggest animal?")
+	req.ResponseFormat = &schemas.ResponseFormat{
+		Type: schemas.ResponseFormatJSONSchema,
+		JSONSchema: &schemas.JSONSchemaFormat{
+			Name: "animal",
+			Schema: map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"animal"},
+			},
+		},
+	}
+
+	// the first response is not valid JSON, so the request is retried
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "first", resp.ModelID)
+	require.Equal(t, `{"animal": "blue whale"}`, resp.ModelResponse.Message.Content)
+}
+
+func TestLangRouter_ReplaysIdempotentRequests(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	provider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}})
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel("first", provider, *budget, *latConfig, 1),
+	}
+
+	router := newTestRouter(t, langModels, withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.Idempotency = &cache.IdempotencyConfig{TTL: time.Minute, MaxEntries: 10}
+	}))
+
+	ctx := cache.WithIdempotencyKey(context.Background(), "retry-me")
+
+	resp, err := router.Chat(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+
+	// the retry is served with the first response
+	resp, err = router.Chat(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+	require.True(t, resp.Cached)
+
+	_, err = router.Chat(ctx, schemas.NewChatFromStr("tell me another joke"))
+	require.ErrorIs(t, err, cache.ErrIdempotencyKeyReused)
+
+	// requests without keys are not deduplicated
+	resp, err = router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+	require.Equal(t, "2", resp.ModelResponse.Message.Content)
+}
+
+func TestLangRouter_AppliesNamedPrompts(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	provider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}})
+	model := providers.NewLangModel("first", provider, *budget, *latency.DefaultConfig(), 1)
+
+	router := newTestRouter(
+		t,
+		[]providers.LanguageModel{model},
+		withPrompts(prompts.Library{"support": "You are a support agent of {{company}}"}),
+	)
+
+	req := schemas.NewChatFromStr("where is my order?")
+	req.Prompt = &schemas.PromptRef{Name: "support"}
+
+	// missing variables fail the request before it's routed
+	_, err := router.Chat(context.Background(), req)
+	require.ErrorIs(t, err, prompts.ErrPromptVarMissing)
+
+	req.Prompt.Vars = map[string]string{"company": "Acme"}
+
+	_, err = router.Chat(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Equal(t, []schemas.ChatMessage{
+		{Role: schemas.RoleSystem, Content: "You are a support agent of Acme"},
+		req.Message,
+	}, provider.LastRequest().ChatMessages())
+	require.NotNil(t, req.Prompt)
+}
+
+func TestLangRouter_HashesUsers(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	provider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}})
+	model := providers.NewLangModel("first", provider, *budget, *latency.DefaultConfig(), 1)
+
+	router := newTestRouter(t, []providers.LanguageModel{model})
+
+	req := schemas.NewChatFromStr("where is my order?")
+	req.User = "user-42"
+
+	_, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "user-42", provider.LastRequest().User)
+
+	router.Config.HashUser = true
+
+	_, err = router.Chat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, req.UserHash(), provider.LastRequest().User)
+	require.Equal(t, "user-42", req.User)
+}
+
+func TestLangRouter_AppliesPromptTemplates(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	routerProvider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}})
+	modelProvider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}})
+
+	routerTemplated := providers.NewLangModel("router_templated", routerProvider, *budget, *latConfig, 1)
+	modelTemplated := providers.NewLangModel("model_templated", modelProvider, *budget, *latConfig, 1)
+
+	require.NoError(t, modelTemplated.SetPromptTemplate(&prompts.Config{System: "Model template"}))
+
+	routerTemplate, err := prompts.NewTemplate(&prompts.Config{System: "Router template for {{ .Vars.team }}"})
+	require.NoError(t, err)
+
+	router := LangRouter{
+		routerID:       "test_router",
+		Config:         &LangRouterConfig{},
+		promptTemplate: routerTemplate,
+		telemetry:      telemetry.NewTelemetryMock(),
+	}
+
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.TemplateVars = map[string]string{"team": "support"}
+
+	templatedReq, err := router.applyPromptTemplate(routerTemplated, req)
+	require.NoError(t, err)
+	require.Equal(t, "Router template for support", templatedReq.ChatMessages()[0].Content)
+
+	// model templates take precedence over the router one
+	templatedReq, err = router.applyPromptTemplate(modelTemplated, req)
+	require.NoError(t, err)
+	require.Equal(t, "Model template", templatedReq.ChatMessages()[0].Content)
+
+	req.SkipTemplate = true
+
+	templatedReq, err = router.applyPromptTemplate(routerTemplated, req)
+	require.NoError(t, err)
+	require.Same(t, req, templatedReq)
+}
+
+func TestLangRouter_CachesResponses(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	provider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}, {Msg: "3"}})
+
+	model := providers.NewLangModel("first", provider, *budget, *latConfig, 1)
+
+	tel := telemetry.NewTelemetryMock()
+	router := newTestRouter(
+		t,
+		[]providers.LanguageModel{model},
+		withRouterConfig(func(cfg *LangRouterConfig) {
+			cfg.Cache = cache.DefaultConfig()
+		}),
+		withTelemetry(tel),
+	)
+
+	ctx := context.Background()
+	req := schemas.NewChatFromStr("tell me a dad joke")
+
+	resp, err := router.Chat(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+
+	resp, err = router.Chat(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+
+	// the same conversation passed as the list of messages hits the cache too
+	resp, err = router.Chat(ctx, &schemas.UnifiedChatRequest{Messages: []schemas.ChatMessage{req.Message}})
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+
+	// refresh skips the cached response and replaces it
+	resp, err = router.Chat(cache.WithRefresh(ctx), req)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, "2", resp.ModelResponse.Message.Content)
+
+	resp, err = router.Chat(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+	require.Equal(t, "2", resp.ModelResponse.Message.Content)
+
+	expectedMetrics := `
+# HELP glide_cache_hit_ratio Share of cacheable chat requests served from the router cache since the router was (re)built
+# TYPE glide_cache_hit_ratio gauge
+glide_cache_hit_ratio{router="test_router"} 0.75
+# HELP glide_cache_hits_total Number of chat requests served from the router cache
+# TYPE glide_cache_hits_total counter
+glide_cache_hits_total{cache="exact",model="first",router="test_router"} 3
+# HELP glide_cache_misses_total Number of cacheable chat requests that were not found in the router cache and were served by models
+# TYPE glide_cache_misses_total counter
+glide_cache_misses_total{model="first",router="test_router"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(
+		tel.Metrics.Registry(),
+		strings.NewReader(expectedMetrics),
+		"glide_cache_hit_ratio", "glide_cache_hits_total", "glide_cache_misses_total",
+	))
+
+	// non-deterministic requests are not cached by default
+	temperature := 0.7
+	req.Override.Params = &schemas.ChatParams{Temperature: &temperature}
+
+	resp, err = router.Chat(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, "3", resp.ModelResponse.Message.Content)
+}
+
+// embedderMock returns predefined vectors of texts
+type embedderMock map[string][]float64
+
+func (e embedderMock) Embed(_ context.Context, text string) ([]float64, error) {
+	return e[text], nil
+}
+
+func TestLangRouter_ServesSemanticallyCachedResponses(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	provider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}, {Msg: "2"}})
+
+	model := providers.NewLangModel("first", provider, *budget, *latConfig, 1)
+
+	cfg := &LangRouterConfig{RoutingStrategy: routing.Priority, Cache: cache.DefaultConfig()}
+
+	embedder := embedderMock{
+		"What's the biggest animal?":   {1, 0},
+		"Which animal is the biggest?": {0.99, 0.1},
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    cfg,
+		retry:     retry.NewExpRetry(3, 2, 1*time.Second, nil),
+		routing:   routing.NewPriority([]providers.Model{model}),
+		cache:     cache.NewMemoryCache(cfg.Cache.TTL, cfg.Cache.MaxEntries),
+		models:    []providers.LanguageModel{model},
+		telemetry: telemetry.NewTelemetryMock(),
+		semanticCache: cache.NewSemanticCache(
+			embedder,
+			cache.NewMemoryVectorStore(cfg.Cache.TTL, 10),
+			0.95,
+			telemetry.NewTelemetryMock(),
+		),
+	}
+
+	ctx := context.Background()
+
+	resp, err := router.Chat(ctx, schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+
+	resp, err = router.Chat(ctx, schemas.NewChatFromStr("Which animal is the biggest?"))
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+	require.Equal(t, "1", resp.ModelResponse.Message.Content)
+	require.Equal(t, "What's the biggest animal?", resp.SemanticCache.Prompt)
+
+	// callers could ask for exact matches only
+	resp, err = router.Chat(cache.WithoutSemantic(ctx), schemas.NewChatFromStr("Which animal is the biggest?"))
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, "2", resp.ModelResponse.Message.Content)
+}
+
+func TestLangRouter_ChatStream_PicksStreamingModels(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel(
+			"first",
+			providers.NewProviderMock([]providers.ResponseMock{{Msg: "not streamed"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+		providers.NewLangModel(
+			"second",
+			providers.NewStreamingProviderMock([]providers.ResponseMock{{Err: &ErrNoModelAvailable}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+		providers.NewLangModel(
+			"third",
+			providers.NewStreamingProviderMock([]providers.ResponseMock{{Msg: "why did the chicken"}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Second, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	router.capableRouting, _ = buildCapableRouting(&LangRouterConfig{RoutingStrategy: routing.Priority}, langModels)
+
+	streamC, err := router.ChatStream(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+
+	var words []string
+
+	for result := range streamC {
+		require.NoError(t, result.Err)
+		require.Equal(t, "test_router", result.Chunk.RouterID)
+		require.Equal(t, "third", result.Chunk.ModelID)
+
+		words = append(words, result.Chunk.ModelResponse.Message.Content)
+	}
+
+	require.Equal(t, []string{"why", "did", "the", "chicken"}, words)
+
+	// concurrency slots are released once the stream is over
+	require.Eventually(t, func() bool {
+		return langModels[2].(*providers.LangModel).InFlight() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLangRouter_ChatStream_CancelledByClient(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	model := providers.NewLangModel(
+		"first",
+		providers.NewStreamingProviderMock([]providers.ResponseMock{{Msg: "why did the chicken cross the road"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Second, nil),
+		routing:   routing.NewPriority([]providers.Model{model}),
+		models:    []providers.LanguageModel{model},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	router.capableRouting, _ = buildCapableRouting(&LangRouterConfig{RoutingStrategy: routing.Priority}, router.models)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	streamC, err := router.ChatStream(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+
+	<-streamC
+	require.Equal(t, int64(1), model.InFlight())
+
+	cancel()
+
+	for range streamC { //nolint:revive
+		// the stream is closed once the cancellation is noticed
+	}
+
+	require.Eventually(t, func() bool { return model.InFlight() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+// newStreamingModels are the "first", "second" & "third" models streaming the responses
+func newStreamingModels(responses ...[]providers.ResponseMock) []providers.LanguageModel {
+	models := make([]providers.LanguageModel, 0, len(responses))
+
+	for idx, modelResponses := range responses {
+		models = append(models, newMockModel(
+			[]string{"first", "second", "third"}[idx],
+			providers.NewStreamingProviderMock(modelResponses),
+		))
+	}
+
+	return models
+}
+
+// withStreamFailover restarts streams dropped before any content was sent on the next model
+func withStreamFailover() testRouterOption {
+	return withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.StreamFailover = true
+	})
+}
+
+func TestLangRouter_ChatStream_EndsDroppedStreamWithErrorChunk(t *testing.T) {
+	dropAfter := 2
+
+	router := newTestRouter(
+		t,
+		newStreamingModels(
+			[]providers.ResponseMock{{Msg: "why did the chicken cross the road", DropAfter: &dropAfter}},
+			[]providers.ResponseMock{{Msg: "knock knock"}},
+		),
+		withStreamFailover(),
+	)
+
+	streamC, err := router.ChatStream(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+
+	var words []string
+
+	var results []*schemas.ChatStreamResult
+
+	for result := range streamC {
+		results = append(results, result)
+
+		if result.Err == nil {
+			words = append(words, result.Chunk.ModelResponse.Message.Content)
+		}
+	}
+
+	// the content has been already sent, so the stream is not failed over
+	require.Equal(t, []string{"why", "did"}, words)
+	require.Len(t, results, 3)
+
+	last := results[2]
+	require.ErrorIs(t, last.Err, clients.ErrStreamInterrupted)
+	require.NotNil(t, last.Chunk)
+	require.Equal(t, schemas.FinishReasonError, last.Chunk.FinishReason)
+	require.Equal(t, "rsp0001", last.Chunk.ID)
+	require.Equal(t, "first", last.Chunk.ModelID)
+	require.Equal(t, "test_router", last.Chunk.RouterID)
+
+	require.Eventually(t, func() bool {
+		return router.models[0].(*providers.LangModel).InFlight() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLangRouter_ChatStream_FailsOverStreamsDroppedBeforeContent(t *testing.T) {
+	dropAfter := 0
+
+	responses := [][]providers.ResponseMock{
+		{{Msg: "why did the chicken cross the road", DropAfter: &dropAfter}},
+		{{Msg: "knock knock"}},
+	}
+
+	router := newTestRouter(t, newStreamingModels(responses...), withStreamFailover())
+
+	streamC, err := router.ChatStream(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+
+	var words []string
+
+	for result := range streamC {
+		require.NoError(t, result.Err)
+		require.Equal(t, "second", result.Chunk.ModelID)
+
+		words = append(words, result.Chunk.ModelResponse.Message.Content)
+	}
+
+	require.Equal(t, []string{"knock", "knock"}, words)
+
+	// without the failover, the client gets the error chunk right away
+	router = newTestRouter(t, newStreamingModels(responses...))
+
+	streamC, err = router.ChatStream(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+
+	result := <-streamC
+	require.ErrorIs(t, result.Err, clients.ErrStreamInterrupted)
+	require.Equal(t, schemas.FinishReasonError, result.Chunk.FinishReason)
+	require.Equal(t, "first", result.Chunk.ModelID)
+
+	_, ok := <-streamC
+	require.False(t, ok)
+}
+
+func TestLangRouter_OutputGuardCutsRunawayResponses(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	guardedModel := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{
+			{Msg: "one two three four five six seven eight"},
+			{Msg: "one two three four five six seven eight"},
+		}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	fallbackModel := providers.NewLangModel(
+		"second",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "short one"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	guardConfig := &providers.OutputGuardConfig{MaxOutputTokens: 4, OnExceed: providers.OutputGuardTruncate}
+	guardedModel.SetOutputGuard(guardConfig, zap.NewNop())
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Second, nil),
+		routing:   routing.NewPriority([]providers.Model{guardedModel, fallbackModel}),
+		models:    []providers.LanguageModel{guardedModel, fallbackModel},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("count to ten"))
+	require.NoError(t, err)
+	require.Equal(t, "first", resp.ModelID)
+	require.Equal(t, "one two three", resp.ModelResponse.Message.Content)
+	require.Equal(t, schemas.FinishReasonLength, resp.ModelResponse.FinishReason)
+
+	// the runaway response fails the model, so the request falls back to the next one
+	guardConfig.OnExceed = providers.OutputGuardError
+
+	resp, err = router.Chat(context.Background(), schemas.NewChatFromStr("count to ten"))
+	require.NoError(t, err)
+	require.Equal(t, "second", resp.ModelID)
+	require.Equal(t, "short one", resp.ModelResponse.Message.Content)
+	require.Empty(t, resp.ModelResponse.FinishReason)
+}
+
+func TestLangRouter_ChatStream_OutputGuardStopsStream(t *testing.T) {
+	router := newTestRouter(t, newStreamingModels([]providers.ResponseMock{{Msg: "why did the chicken cross the road"}}))
+
+	model := router.models[0].(*providers.LangModel)
+	model.SetOutputGuard(&providers.OutputGuardConfig{MaxOutputTokens: 4, OnExceed: providers.OutputGuardError}, zap.NewNop())
+
+	streamC, err := router.ChatStream(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.NoError(t, err)
+
+	var chunks []*schemas.UnifiedChatStreamChunk
+
+	for result := range streamC {
+		require.NoError(t, result.Err)
+
+		chunks = append(chunks, result.Chunk)
+	}
+
+	// each word takes two tokens by the heuristic tokenizer, so the third one doesn't fit
+	require.Len(t, chunks, 3)
+	require.Equal(t, "did", chunks[1].ModelResponse.Message.Content)
+	require.Empty(t, chunks[2].ModelResponse.Message.Content)
+	require.Equal(t, schemas.FinishReasonLength, chunks[2].FinishReason)
+
+	require.Eventually(t, func() bool { return model.InFlight() == 0 }, time.Second, 10*time.Millisecond)
+	require.True(t, model.Healthy())
+}
+
+func TestLangRouter_TokenRateLimitExhaustedByLargeRequests(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	responses := make([]providers.ResponseMock, 0, 10)
+	for idx := 0; idx < 10; idx++ {
+		responses = append(responses, providers.ResponseMock{Msg: "1"})
+	}
+
+	limitedModel := providers.NewLangModel("first", providers.NewProviderMock(responses), *budget, *latConfig, 1)
+	limitedModel.SetTokenRateLimit(&providers.TokenRateLimitConfig{TokensPerMinute: 2000, ExpectedOutputTokens: 100})
+
+	langModels := []providers.LanguageModel{
+		limitedModel,
+		providers.NewLangModel("second", providers.NewProviderMock([]providers.ResponseMock{{Msg: "2"}}), *budget, *latConfig, 1),
+	}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	// small requests fit the TPM budget
+	for idx := 0; idx < 3; idx++ {
+		resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+		require.NoError(t, err)
+		require.Equal(t, "first", resp.ModelID)
+	}
+
+	largeRequest := strings.Repeat("tell me a really long dad joke, ", 100)
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr(largeRequest))
+	require.NoError(t, err)
+	require.Equal(t, "first", resp.ModelID)
+
+	// the provider could serve more requests, but the TPM budget is exhausted, so the model is rate limited
+	resp, err = router.Chat(context.Background(), schemas.NewChatFromStr(largeRequest))
+	require.NoError(t, err)
+	require.Equal(t, "second", resp.ModelID)
+
+	require.False(t, limitedModel.Healthy())
+	require.Greater(t, limitedModel.UntilRateLimitReset(), time.Duration(0))
+}
+
+func TestLangRouter_RetryAfterCooldown(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	header := http.Header{}
+	header.Set("Retry-After", "30")
+
+	var retryAfterErr error = clients.NewRetryAfterError(header)
+
+	var defaultErr error = clients.NewRateLimitError(nil)
+
+	honoringModel := providers.NewLangModel(
+		"honoring",
+		providers.NewProviderMock([]providers.ResponseMock{{Err: &retryAfterErr}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	ignoringModel := providers.NewLangModel(
+		"ignoring",
+		providers.NewProviderMock([]providers.ResponseMock{{Err: &retryAfterErr}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	ignoringModel.SetRetryAfter(5*time.Second, false)
+
+	defaultModel := providers.NewLangModel(
+		"default",
+		providers.NewProviderMock([]providers.ResponseMock{{Err: &defaultErr}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	defaultModel.SetRetryAfter(10*time.Second, true)
+
+	langModels := []providers.LanguageModel{honoringModel, ignoringModel, defaultModel}
+
+	models := make([]providers.Model, 0, len(langModels))
+	for _, model := range langModels {
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority(models),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	_, err := router.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, ErrNoModelAvailable)
+
+	// the provider window is honored unless the model is configured to ignore it
+	require.InDelta(t, 30*time.Second, honoringModel.UntilRateLimitReset(), float64(time.Second))
+	require.InDelta(t, 5*time.Second, ignoringModel.UntilRateLimitReset(), float64(time.Second))
+	require.InDelta(t, 10*time.Second, defaultModel.UntilRateLimitReset(), float64(time.Second))
+}
+
+type stopLimitedProviderMock struct {
+	*providers.ProviderMock
+	maxStopSequences int
+}
+
+func (c *stopLimitedProviderMock) MaxStopSequences() int {
+	return c.maxStopSequences
+}
+
+func TestLangRouter_StopSequencesNormalized(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	provider := &stopLimitedProviderMock{
+		ProviderMock:     providers.NewProviderMock([]providers.ResponseMock{{Msg: "Hello\n\nHuman:"}, {Msg: "Hi"}}),
+		maxStopSequences: 2,
+	}
+	model := providers.NewLangModel("first", provider, *budget, *latConfig, 1)
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{model}),
+		models:    []providers.LanguageModel{model},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	req := schemas.NewChatFromStr("tell me a dad joke")
+	req.Override.Params = &schemas.ChatParams{Stop: []string{"", "\n\nHuman:", "\n\nHuman:", "END", "STOP"}}
+
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+
+	// empty & repeated sequences are dropped, the rest are truncated to the provider limit
+	require.Equal(t, []string{"\n\nHuman:", "END"}, provider.LastRequest().Override.Params.Stop)
+	require.Equal(t, 1, resp.ModelResponse.DroppedStopSequences)
+
+	// the matched sequence the provider has kept in the response is reported apart from the message
+	require.Equal(t, "Hello", resp.ModelResponse.Message.Content)
+	require.Equal(t, "\n\nHuman:", resp.ModelResponse.StopSequence)
+	require.Equal(t, schemas.FinishReasonStop, resp.ModelResponse.FinishReason)
+
+	model.SetStrictParams(true)
+
+	_, err = router.Chat(context.Background(), req)
+	require.ErrorIs(t, err, providers.ErrUnsupportedParams)
+	require.ErrorContains(t, err, providers.ParamStop)
+	require.True(t, model.Healthy())
+}
+
+func TestLangRouter_EstimatesOmittedUsage(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	usage := schemas.TokenUsage{PromptTokens: 12, ResponseTokens: 3, TotalTokens: 15}
+
+	model := providers.NewLangModel(
+		"first",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "The blue whale"}, {Msg: "The blue whale", TokenUsage: usage}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+	require.NoError(t, model.SetTokenizer("cl100k_base"))
+
+	streamingModel := providers.NewLangModel(
+		"streaming",
+		providers.NewStreamingProviderMock([]providers.ResponseMock{{Msg: "The blue whale"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	langModels := []providers.LanguageModel{model, streamingModel}
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(3, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{model, streamingModel}),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	router.capableRouting, _ = buildCapableRouting(&LangRouterConfig{RoutingStrategy: routing.Priority}, langModels)
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+
+	estimated := resp.ModelResponse.TokenUsage
+	require.True(t, estimated.Estimated)
+	require.Equal(t, float64(13), estimated.PromptTokens)
+	require.Equal(t, float64(3), estimated.ResponseTokens)
+	require.Equal(t, float64(16), estimated.TotalTokens)
+
+	// the usage reported by the provider is kept as is
+	resp, err = router.Chat(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+	require.Equal(t, usage, resp.ModelResponse.TokenUsage)
+
+	streamC, err := router.ChatStream(context.Background(), schemas.NewChatFromStr("What's the biggest animal?"))
+	require.NoError(t, err)
+
+	var lastChunk *schemas.UnifiedChatStreamChunk
+
+	for result := range streamC {
+		require.NoError(t, result.Err)
+
+		lastChunk = result.Chunk
+	}
+
+	require.NotNil(t, lastChunk.ModelResponse.TokenUsage)
+	require.True(t, lastChunk.ModelResponse.TokenUsage.Estimated)
+	require.Positive(t, lastChunk.ModelResponse.TokenUsage.ResponseTokens)
+}
+
+func TestLangRouter_ChaosExercisesFallbacks(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	chaosModel := providers.NewLangModel(
+		"chaos",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "chaos"}, {Msg: "chaos"}, {Msg: "chaos"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	fallbackModel := providers.NewLangModel(
+		"fallback",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "fallback"}, {Msg: "fallback"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	langModels := []providers.LanguageModel{chaosModel, fallbackModel}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		retry:     retry.NewExpRetry(1, 2, 1*time.Millisecond, nil),
+		routing:   routing.NewPriority([]providers.Model{chaosModel, fallbackModel}),
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	// slow responses still come from the model
+	chaosConfig := providers.DefaultChaosConfig()
+	chaosConfig.SlowRate = 1
+	chaosConfig.SlowLatency = 20 * time.Millisecond
+	chaosModel.SetChaos(chaosConfig)
+
+	startedAt := time.Now()
+
+	resp, err := router.Chat(context.Background(), schemas.NewChatFromStr("hello"))
+	require.NoError(t, err)
+	require.Equal(t, "chaos", resp.ModelID)
+	require.GreaterOrEqual(t, time.Since(startedAt), 20*time.Millisecond)
+
+	// injected errors burn the error budget, so the model becomes unhealthy
+	chaosConfig = providers.DefaultChaosConfig()
+	chaosConfig.ErrorRate = 1
+	chaosModel.SetChaos(chaosConfig)
+
+	resp, err = router.Chat(context.Background(), schemas.NewChatFromStr("hello"))
+	require.NoError(t, err)
+	require.Equal(t, "fallback", resp.ModelID)
+	require.False(t, chaosModel.Healthy())
+
+	// injected rate limits are handled like the provider ones
+	rateLimitedModel := providers.NewLangModel(
+		"rate_limited",
+		providers.NewProviderMock([]providers.ResponseMock{{Msg: "rate limited"}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	chaosConfig = providers.DefaultChaosConfig()
+	chaosConfig.RateLimitRate = 1
+	chaosConfig.RateLimitReset = 30 * time.Second
+	rateLimitedModel.SetChaos(chaosConfig)
+
+	_, err = rateLimitedModel.Chat(context.Background(), schemas.NewChatFromStr("hello"))
+	require.ErrorIs(t, err, providers.ErrInjectedFault)
+	require.InDelta(t, 30*time.Second, rateLimitedModel.UntilRateLimitReset(), float64(time.Second))
+}
+
+func TestLangRouter_ChatStream_ChaosTimeout(t *testing.T) {
+	router := newTestRouter(t, newStreamingModels([]providers.ResponseMock{{Msg: "never streamed"}}))
+
+	model := router.models[0].(*providers.LangModel)
+
+	chaosConfig := providers.DefaultChaosConfig()
+	chaosConfig.TimeoutRate = 1
+	chaosConfig.Timeout = 10 * time.Millisecond
+	model.SetChaos(chaosConfig)
+
+	_, err := model.ChatStream(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, providers.ErrInjectedFault)
+	require.Zero(t, model.InFlight())
+
+	// hanging requests are cut by the request context
+	chaosConfig.Timeout = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = model.ChatStream(ctx, schemas.NewChatFromStr("tell me a dad joke"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
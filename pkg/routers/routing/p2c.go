@@ -0,0 +1,72 @@
+package routing
+
+import (
+	"math/rand"
+
+	"glide/pkg/providers"
+)
+
+const (
+	P2C Strategy = "p2c"
+)
+
+// P2CRouting implements "power of two choices" strategy.
+// It picks two random healthy models and routes request to the one with the lower latency.
+// That balances the load without scanning the whole pool on each request
+// and doesn't herd all traffic to the single fastest model like the least latency strategy does
+type P2CRouting struct {
+	models []providers.Model
+}
+
+func NewP2CRouting(models []providers.Model) *P2CRouting {
+	return &P2CRouting{
+		models: models,
+	}
+}
+
+func (r *P2CRouting) Iterator() LangModelIterator {
+	return r
+}
+
+func (r *P2CRouting) Next() (providers.Model, error) {
+	first := r.pickHealthy(nil)
+	if first == nil {
+		return nil, ErrNoHealthyModels
+	}
+
+	second := r.pickHealthy(first)
+	if second == nil {
+		// the only healthy model left
+		return first, nil
+	}
+
+	if second.Latency().Value() < first.Latency().Value() {
+		return second, nil
+	}
+
+	return first, nil
+}
+
+// pickHealthy picks a random healthy model other than the excluded one.
+// The pool is scanned from a random position, so it takes O(1) when most models are healthy
+func (r *P2CRouting) pickHealthy(exclude providers.Model) providers.Model {
+	modelLen := len(r.models)
+
+	if modelLen == 0 {
+		return nil
+	}
+
+	offset := rand.Intn(modelLen) //nolint:gosec
+
+	for i := 0; i < modelLen; i++ {
+		model := r.models[(offset+i)%modelLen]
+
+		if model == exclude || !model.Healthy() {
+			continue
+		}
+
+		return model
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/providers"
+)
+
+func TestP2CRouting_AvoidHerdingOnFastestModel(t *testing.T) {
+	models := []providers.Model{
+		providers.NewLangModelMock("fastest", true, 50.0, 1),
+		providers.NewLangModelMock("average", true, 100.0, 1),
+		providers.NewLangModelMock("slowest", true, 200.0, 1),
+	}
+
+	routing := NewP2CRouting(models)
+	iterator := routing.Iterator()
+
+	picks := make(map[string]int, len(models))
+
+	for i := 0; i < 1000; i++ {
+		model, err := iterator.Next()
+		require.NoError(t, err)
+
+		picks[model.ID()]++
+	}
+
+	// the fastest model wins only when it's one of the two choices
+	require.Greater(t, picks["fastest"], 0)
+	require.Less(t, picks["fastest"], 1000)
+	require.Greater(t, picks["average"], 0)
+	// the slowest model always loses the comparison
+	require.Zero(t, picks["slowest"])
+}
+
+func TestP2CRouting_SkipUnhealthy(t *testing.T) {
+	models := []providers.Model{
+		providers.NewLangModelMock("first", false, 50.0, 1),
+		providers.NewLangModelMock("second", true, 200.0, 1),
+		providers.NewLangModelMock("third", false, 100.0, 1),
+	}
+
+	iterator := NewP2CRouting(models).Iterator()
+
+	for i := 0; i < 10; i++ {
+		model, err := iterator.Next()
+		require.NoError(t, err)
+
+		require.Equal(t, "second", model.ID())
+	}
+}
+
+func TestP2CRouting_NoHealthyModels(t *testing.T) {
+	models := []providers.Model{
+		providers.NewLangModelMock("first", false, 0.0, 1),
+		providers.NewLangModelMock("second", false, 0.0, 1),
+	}
+
+	_, err := NewP2CRouting(models).Iterator().Next()
+	require.ErrorIs(t, err, ErrNoHealthyModels)
+}
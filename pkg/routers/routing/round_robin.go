@@ -26,20 +26,41 @@ func (r *RoundRobinRouting) Iterator() LangModelIterator {
 	return r
 }
 
+// Next picks the next healthy model. The cursor goes over healthy models only, so unhealthy models don't hand
+// their turns to their neighbours and concurrent requests can't take all turns of healthy models from each other
 func (r *RoundRobinRouting) Next() (providers.Model, error) {
-	modelLen := len(r.models)
+	healthyModels := 0
 
-	// in order to avoid infinite loop in case of no healthy model is available,
-	// we need to track whether we made a whole cycle around the model slice looking for a healthy model
-	for i := 0; i < modelLen; i++ {
-		idx := r.idx.Add(1) - 1
-		model := r.models[idx%uint64(modelLen)]
+	for _, model := range r.models {
+		if model.Healthy() {
+			healthyModels++
+		}
+	}
+
+	if healthyModels == 0 {
+		return nil, ErrNoHealthyModels
+	}
+
+	turn := (r.idx.Add(1) - 1) % uint64(healthyModels)
 
+	var fallback providers.Model
+
+	for _, model := range r.models {
 		if !model.Healthy() {
 			continue
 		}
 
-		return model, nil
+		if turn == 0 {
+			return model, nil
+		}
+
+		turn--
+		fallback = model
+	}
+
+	if fallback != nil {
+		// some models have got unhealthy since they were counted
+		return fallback, nil
 	}
 
 	return nil, ErrNoHealthyModels
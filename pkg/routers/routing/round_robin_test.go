@@ -1,7 +1,10 @@
 package routing
 
 import (
+	"math"
+	"sync"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/require"
 	"glide/pkg/providers"
@@ -59,5 +62,72 @@ func TestRoundRobinRouting_NoHealthyModels(t *testing.T) {
 	iterator := routing.Iterator()
 
 	_, err := iterator.Next()
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrNoHealthyModels)
+}
+
+func TestRoundRobinRouting_FairUnderConcurrency(t *testing.T) {
+	const selections = 10_000
+
+	// each healthy model gets its share whatever models are unhealthy
+	fair := func(health [5]bool) bool {
+		models := make([]providers.Model, 0, len(health))
+		healthyModels := 0
+
+		for idx, healthy := range health {
+			models = append(models, providers.NewLangModelMock(string(rune('a'+idx)), healthy, 100, 1))
+
+			if healthy {
+				healthyModels++
+			}
+		}
+
+		if healthyModels == 0 {
+			return true
+		}
+
+		routing := NewRoundRobinRouting(models)
+
+		var mu sync.Mutex
+
+		var wg sync.WaitGroup
+
+		picks := make(map[string]int, len(models))
+
+		for i := 0; i < selections; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				model, err := routing.Iterator().Next()
+				if err != nil {
+					return
+				}
+
+				mu.Lock()
+				picks[model.ID()]++
+				mu.Unlock()
+			}()
+		}
+
+		wg.Wait()
+
+		share := float64(selections) / float64(healthyModels)
+
+		for idx, healthy := range health {
+			count := picks[string(rune('a'+idx))]
+
+			if healthy && math.Abs(float64(count)-share) > share*0.01 {
+				return false
+			}
+
+			if !healthy && count > 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	require.NoError(t, quick.Check(fair, &quick.Config{MaxCount: 20}))
 }
@@ -0,0 +1,133 @@
+package routing
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+
+	"glide/pkg/providers"
+)
+
+// SessionAffinityConfig pins requests of the same session to the same model (e.g. to keep the conversation style
+// or hit provider-side prompt caches). Requests without a session are routed by the router strategy
+type SessionAffinityConfig struct {
+	// VirtualNodes is the number of ring points per model weight unit. More points spread sessions more evenly
+	VirtualNodes int `yaml:"virtual_nodes,omitempty" json:"virtual_nodes" validate:"gt=0"`
+}
+
+func DefaultSessionAffinityConfig() *SessionAffinityConfig {
+	return &SessionAffinityConfig{
+		VirtualNodes: 100,
+	}
+}
+
+func (c *SessionAffinityConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultSessionAffinityConfig()
+
+	type plain SessionAffinityConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// SessionRouting could route requests of the session to the model the session is pinned to
+type SessionRouting interface {
+	LangModelRouting
+	SessionIterator(sessionID string) LangModelIterator
+}
+
+type ringNode struct {
+	hash  uint64
+	model providers.Model
+}
+
+// SessionAffinityRouting maps sessions to models via a consistent hash ring, so sessions keep their models
+// when models are added or removed (except for sessions of the removed ones).
+// The session model is tried first while it's healthy, otherwise the request is routed by the underlying routing
+type SessionAffinityRouting struct {
+	routing LangModelRouting
+	ring    []ringNode
+}
+
+func NewSessionAffinityRouting(routing LangModelRouting, models []providers.Model, cfg *SessionAffinityConfig) *SessionAffinityRouting {
+	ring := make([]ringNode, 0, len(models)*cfg.VirtualNodes)
+
+	for _, model := range models {
+		// heavier models take bigger share of sessions
+		points := cfg.VirtualNodes * max(model.Weight(), 1)
+
+		for idx := 0; idx < points; idx++ {
+			ring = append(ring, ringNode{
+				hash:  hashKey(model.ID() + "#" + strconv.Itoa(idx)),
+				model: model,
+			})
+		}
+	}
+
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+
+	return &SessionAffinityRouting{
+		routing: routing,
+		ring:    ring,
+	}
+}
+
+func (r *SessionAffinityRouting) Iterator() LangModelIterator {
+	return r.routing.Iterator()
+}
+
+// SessionIterator picks the session model first (if it's healthy), then models picked by the underlying routing
+func (r *SessionAffinityRouting) SessionIterator(sessionID string) LangModelIterator {
+	return &sessionIterator{
+		model:    r.Model(sessionID),
+		fallback: r.routing.Iterator(),
+	}
+}
+
+// Model returns the model the session is pinned to regardless of its health
+func (r *SessionAffinityRouting) Model(sessionID string) providers.Model {
+	if len(r.ring) == 0 {
+		return nil
+	}
+
+	hash := hashKey(sessionID)
+
+	// the first node clockwise from the session hash
+	idx := sort.Search(len(r.ring), func(i int) bool {
+		return r.ring[i].hash >= hash
+	})
+
+	if idx == len(r.ring) {
+		idx = 0
+	}
+
+	return r.ring[idx].model
+}
+
+type sessionIterator struct {
+	model    providers.Model
+	tried    bool
+	fallback LangModelIterator
+}
+
+func (it *sessionIterator) Next() (providers.Model, error) {
+	if !it.tried {
+		it.tried = true
+
+		if it.model != nil && it.model.Healthy() {
+			return it.model, nil
+		}
+	}
+
+	return it.fallback.Next()
+}
+
+// hashKey spreads keys over the ring evenly. Cheap hashes (e.g. FNV) place keys that differ in the last chars
+// (like virtual nodes of one model) close to each other, so models would own uneven arcs
+func hashKey(key string) uint64 {
+	hash := sha256.Sum256([]byte(key))
+
+	return binary.BigEndian.Uint64(hash[:8])
+}
@@ -0,0 +1,86 @@
+package routing
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/providers"
+)
+
+func TestSessionAffinityRouting_PinsSessionsToModels(t *testing.T) {
+	models := []providers.Model{
+		providers.NewLangModelMock("first", true, 0, 1),
+		providers.NewLangModelMock("second", true, 0, 1),
+		providers.NewLangModelMock("third", true, 0, 1),
+	}
+
+	routing := NewSessionAffinityRouting(NewPriority(models), models, DefaultSessionAffinityConfig())
+
+	picks := make(map[string]int, len(models))
+
+	for i := 0; i < 300; i++ {
+		sessionID := fmt.Sprintf("session-%v", i)
+
+		model, err := routing.SessionIterator(sessionID).Next()
+		require.NoError(t, err)
+
+		// the same session keeps hitting the same model
+		for j := 0; j < 5; j++ {
+			repeated, err := routing.SessionIterator(sessionID).Next()
+			require.NoError(t, err)
+			require.Equal(t, model.ID(), repeated.ID())
+		}
+
+		picks[model.ID()]++
+	}
+
+	// sessions are spread over all models rather than going to the priority one
+	for _, model := range models {
+		require.Greater(t, picks[model.ID()], 50, model.ID())
+	}
+}
+
+func TestSessionAffinityRouting_FallbackOnUnhealthyModel(t *testing.T) {
+	healthyModels := []providers.Model{
+		providers.NewLangModelMock("first", true, 0, 1),
+		providers.NewLangModelMock("second", true, 0, 1),
+	}
+
+	sessionID := "session-1"
+	sessionModel := NewSessionAffinityRouting(NewPriority(healthyModels), healthyModels, DefaultSessionAffinityConfig()).Model(sessionID)
+
+	// the same models where the session model is unhealthy (the ring depends on model IDs only)
+	models := make([]providers.Model, 0, len(healthyModels))
+
+	for _, model := range healthyModels {
+		models = append(models, providers.NewLangModelMock(model.ID(), model.ID() != sessionModel.ID(), 0, 1))
+	}
+
+	routing := NewSessionAffinityRouting(NewPriority(models), models, DefaultSessionAffinityConfig())
+
+	model, err := routing.SessionIterator(sessionID).Next()
+	require.NoError(t, err)
+	require.NotEqual(t, sessionModel.ID(), model.ID())
+}
+
+func TestSessionAffinityRouting_KeepsSessionsOnNewModels(t *testing.T) {
+	models := []providers.Model{
+		providers.NewLangModelMock("first", true, 0, 1),
+		providers.NewLangModelMock("second", true, 0, 1),
+	}
+
+	routing := NewSessionAffinityRouting(NewPriority(models), models, DefaultSessionAffinityConfig())
+
+	extendedModels := append([]providers.Model{providers.NewLangModelMock("third", true, 0, 1)}, models...)
+	extendedRouting := NewSessionAffinityRouting(NewPriority(extendedModels), extendedModels, DefaultSessionAffinityConfig())
+
+	for i := 0; i < 100; i++ {
+		sessionID := fmt.Sprintf("session-%v", i)
+
+		if model := extendedRouting.Model(sessionID); model.ID() != "third" {
+			// sessions that have not moved to the new model keep their models
+			require.Equal(t, routing.Model(sessionID).ID(), model.ID())
+		}
+	}
+}
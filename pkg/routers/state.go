@@ -0,0 +1,95 @@
+package routers
+
+import (
+	"time"
+
+	"glide/pkg/providers"
+)
+
+// RouterState is a snapshot of the live router state for inspection
+type RouterState struct {
+	ID       string       `json:"id"`
+	Strategy string       `json:"strategy"`
+	Models   []ModelState `json:"models"`
+}
+
+// ModelState is a snapshot of the live model health, latency & limits
+type ModelState struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Healthy  bool   `json:"healthy"`
+	Weight   int    `json:"weight"`
+	// Latency is the moving average of the model latency. It's not representative until the model is warmed up
+	Latency         float64 `json:"latency"`
+	LatencyWarmedUp bool    `json:"latency_warmed_up"`
+	// ErrorBudgetRemaining is the number of failures the model could tolerate before it's considered unhealthy
+	ErrorBudgetRemaining *float64 `json:"error_budget_remaining,omitempty"`
+	InFlight             *int64   `json:"in_flight,omitempty"`
+	// RateLimitResetAt is set while the model is rate limited
+	RateLimitResetAt *time.Time `json:"rate_limit_reset_at,omitempty"`
+}
+
+// inFlightTracker is implemented by models that count requests they are processing
+type inFlightTracker interface {
+	InFlight() int64
+}
+
+// State returns the live state of all routers
+func (r *RouterManager) State() []RouterState {
+	langRouters := r.GetLangRouters()
+	states := make([]RouterState, 0, len(langRouters))
+
+	for _, router := range langRouters {
+		states = append(states, router.State())
+	}
+
+	return states
+}
+
+// State returns the live state of the router & its models
+func (r *LangRouter) State() RouterState {
+	models := make([]ModelState, 0, len(r.models))
+
+	for _, model := range r.models {
+		models = append(models, modelState(model))
+	}
+
+	return RouterState{
+		ID:       r.routerID,
+		Strategy: string(r.Config.RoutingStrategy),
+		Models:   models,
+	}
+}
+
+func modelState(model providers.LanguageModel) ModelState {
+	state := ModelState{
+		ID:       model.ID(),
+		Provider: model.Provider(),
+		Healthy:  model.Healthy(),
+		Weight:   model.Weight(),
+	}
+
+	if modelLatency := model.Latency(); modelLatency != nil {
+		state.Latency = modelLatency.Value()
+		state.LatencyWarmedUp = modelLatency.WarmedUp()
+	}
+
+	if budgeted, ok := model.(providers.ErrorBudgeted); ok {
+		remaining := budgeted.ErrorBudgetRemaining()
+		state.ErrorBudgetRemaining = &remaining
+	}
+
+	if tracker, ok := model.(inFlightTracker); ok {
+		inFlight := tracker.InFlight()
+		state.InFlight = &inFlight
+	}
+
+	if rateLimited, ok := model.(providers.RateLimited); ok {
+		if untilReset := rateLimited.UntilRateLimitReset(); untilReset > 0 {
+			resetAt := time.Now().Add(untilReset)
+			state.RateLimitResetAt = &resetAt
+		}
+	}
+
+	return state
+}
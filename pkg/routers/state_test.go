@@ -0,0 +1,45 @@
+package routers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+)
+
+func TestLangRouter_StateReflectsModelHealth(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.MIN)
+	latConfig := latency.Config{Decay: 0.5, WarmupSamples: 1}
+
+	failingModel := providers.NewLangModel("failing", providers.NewProviderMock(nil), *budget, latConfig, 1)
+	rateLimitedModel := providers.NewLangModel("rate_limited", providers.NewProviderMock(nil), *budget, latConfig, 2)
+
+	router := newTestRouter(t, []providers.LanguageModel{failingModel, rateLimitedModel})
+
+	failingModel.ChargeErrorBudget(clients.ErrProviderUnavailable)
+	rateLimitedModel.SetRateLimited(time.Minute)
+
+	state := router.State()
+
+	require.Equal(t, "test_router", state.ID)
+	require.Equal(t, "priority", state.Strategy)
+	require.Len(t, state.Models, 2)
+
+	failing := state.Models[0]
+	require.Equal(t, "failing", failing.ID)
+	require.True(t, failing.Healthy)
+	require.NotNil(t, failing.ErrorBudgetRemaining)
+	require.InDelta(t, 2, *failing.ErrorBudgetRemaining, 0.1)
+	require.Nil(t, failing.RateLimitResetAt)
+
+	rateLimited := state.Models[1]
+	require.Equal(t, "rate_limited", rateLimited.ID)
+	require.False(t, rateLimited.Healthy)
+	require.Equal(t, 2, rateLimited.Weight)
+	require.NotNil(t, rateLimited.RateLimitResetAt)
+	require.WithinDuration(t, time.Now().Add(time.Minute), *rateLimited.RateLimitResetAt, time.Second)
+}
@@ -0,0 +1,114 @@
+package routers
+
+import (
+	"fmt"
+
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+)
+
+// Truncation defines what to do with requests that don't fit the context window of any router model
+type Truncation string
+
+const (
+	TruncationNone Truncation = "none" // reject such requests
+	TruncationAuto Truncation = "auto" // drop the oldest non-system messages until the request fits
+)
+
+// truncate drops the oldest non-system messages until the conversation fits the largest context window
+// of models that have capabilities the request needs. System messages & the last message are always kept
+func (c *capableRouting) truncate(request *schemas.UnifiedChatRequest) (*schemas.UnifiedChatRequest, error) {
+	maxContextTokens := c.largestContext(providers.RequiredCapabilities(request))
+	messages := request.ChatMessages()
+
+	// message sizes by each tokenizer, so the truncated conversation fits models whatever tokenizer they use
+	messageTokens := make([][]int, len(c.tokenizers))
+	totalTokens := make([]int, len(c.tokenizers))
+
+	for idx, tokenizer := range c.tokenizers {
+		messageTokens[idx] = make([]int, len(messages))
+		totalTokens[idx] = providers.CountContextTokens(tokenizer, &schemas.UnifiedChatRequest{})
+
+		for msgIdx := range messages {
+			messageTokens[idx][msgIdx] = providers.CountMessageTokens(tokenizer, &messages[msgIdx])
+			totalTokens[idx] += messageTokens[idx][msgIdx]
+		}
+	}
+
+	fits := func() bool {
+		for _, tokens := range totalTokens {
+			if tokens > maxContextTokens {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	dropped := make([]bool, len(messages))
+
+	drop := func(msgIdx int) {
+		dropped[msgIdx] = true
+
+		for idx := range totalTokens {
+			totalTokens[idx] -= messageTokens[idx][msgIdx]
+		}
+	}
+
+	last := len(messages) - 1
+
+	for msgIdx := 0; msgIdx < last && !fits(); msgIdx++ {
+		if messages[msgIdx].Role != schemas.RoleSystem {
+			drop(msgIdx)
+		}
+	}
+
+	// the conversation should not start with assistant messages or results of dropped tool calls
+	for msgIdx := 0; msgIdx < last; msgIdx++ {
+		role := messages[msgIdx].Role
+
+		if dropped[msgIdx] || role == schemas.RoleSystem {
+			continue
+		}
+
+		if role == schemas.RoleUser {
+			break
+		}
+
+		drop(msgIdx)
+	}
+
+	if !fits() {
+		return nil, fmt.Errorf(
+			"%w: the request doesn't fit %v tokens even with the oldest messages truncated",
+			ErrContextLengthExceeded,
+			maxContextTokens,
+		)
+	}
+
+	truncatedMessages := make([]schemas.ChatMessage, 0, len(messages))
+
+	for msgIdx, message := range messages {
+		if !dropped[msgIdx] {
+			truncatedMessages = append(truncatedMessages, message)
+		}
+	}
+
+	truncatedRequest := *request
+	truncatedRequest.Messages = truncatedMessages
+
+	return &truncatedRequest, nil
+}
+
+// largestContext returns the largest context window of models with the capabilities
+func (c *capableRouting) largestContext(capabilities []string) int {
+	var largest int
+
+	for _, model := range c.models {
+		if hasCapabilities(model, capabilities) {
+			largest = max(largest, providers.MaxContextTokens(model))
+		}
+	}
+
+	return largest
+}
@@ -0,0 +1,63 @@
+package routers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+)
+
+func TestLangRouter_Priority_TruncatesLongConversations(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	provider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "1"}})
+
+	model := providers.NewLangModel("first", provider, *budget, *latConfig, 1)
+	require.NoError(t, model.SetCapabilities(&providers.CapabilitiesConfig{MaxContextTokens: 50}))
+
+	langModels := []providers.LanguageModel{model}
+	router := newTestRouter(t, langModels, withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.Truncation = TruncationNone
+	}))
+
+	req := &schemas.UnifiedChatRequest{
+		Messages: []schemas.ChatMessage{
+			{Role: schemas.RoleSystem, Content: "You are a helpful assistant."},
+			{Role: schemas.RoleUser, Content: strings.Repeat("word ", 30)},
+			{Role: schemas.RoleAssistant, Content: strings.Repeat("word ", 10)},
+			{Role: schemas.RoleUser, Content: "What's the biggest animal?"},
+		},
+	}
+
+	// the request is rejected up front without spending the model error budget
+	_, err := router.Chat(context.Background(), req)
+	require.ErrorIs(t, err, ErrContextLengthExceeded)
+	require.True(t, model.Healthy())
+
+	router.Config.Truncation = TruncationAuto
+
+	resp, err := router.Chat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "first", resp.ModelID)
+
+	// the oldest user message is dropped along with the assistant reply, so the conversation starts with the user message
+	require.Equal(t, []schemas.ChatMessage{
+		{Role: schemas.RoleSystem, Content: "You are a helpful assistant."},
+		{Role: schemas.RoleUser, Content: "What's the biggest animal?"},
+	}, provider.LastRequest().Messages)
+
+	// the original request is kept intact
+	require.Len(t, req.Messages, 4)
+
+	// the last message alone doesn't fit
+	req.Messages[3].Content = strings.Repeat("word ", 100)
+
+	_, err = router.Chat(context.Background(), req)
+	require.ErrorIs(t, err, ErrContextLengthExceeded)
+}
@@ -0,0 +1,207 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"go.uber.org/zap"
+)
+
+// ErrStartupVerificationFailed is returned when the fail-fast startup verification finds providers rejecting credentials
+var ErrStartupVerificationFailed = errors.New("startup verification has failed")
+
+// VerifyMode defines whether & how provider connectivity is checked on startup
+type VerifyMode string
+
+const (
+	VerifyOff      VerifyMode = "false"     // don't check providers
+	VerifyOn       VerifyMode = "true"      // check providers & start failing models unhealthy
+	VerifyFailFast VerifyMode = "fail-fast" // also abort startup if any provider rejects credentials
+)
+
+// Error classes of the startup verification
+const (
+	VerifyClassOK           = "ok"
+	VerifyClassUnauthorized = "unauthorized"
+	VerifyClassRateLimited  = "rate_limited"
+	VerifyClassTimeout      = "timeout"
+	VerifyClassUnavailable  = "unavailable"
+	VerifyClassError        = "error"
+)
+
+// StartupConfig defines checks the gateway runs before serving traffic
+type StartupConfig struct {
+	// Verify sends a tiny authenticated request to each model, so broken credentials & unreachable providers
+	// show up on startup rather than on the first requests
+	Verify VerifyMode `yaml:"verify,omitempty" json:"verify" swaggertype:"primitive,string" validate:"oneof=true false fail-fast"`
+	// Timeout limits how long the gateway startup could be delayed by the verification
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout" swaggertype:"primitive,integer" validate:"gt=0"`
+}
+
+func DefaultStartupConfig() *StartupConfig {
+	return &StartupConfig{
+		Verify:  VerifyOff,
+		Timeout: 10 * time.Second,
+	}
+}
+
+func (c *StartupConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultStartupConfig()
+
+	type plain StartupConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// VerifyResult is the outcome of the model check
+type VerifyResult struct {
+	RouterID string
+	ModelID  string
+	Provider string
+	Class    string // one of VerifyClass* constants
+	Err      error
+}
+
+// OK tells if the model has passed the check
+func (r *VerifyResult) OK() bool {
+	return r.Err == nil
+}
+
+// verifyClassOf groups check failures by their cause
+func verifyClassOf(err error) string {
+	var rle *clients.RateLimitError
+
+	switch {
+	case err == nil:
+		return VerifyClassOK
+	case errors.Is(err, clients.ErrUnauthorized):
+		return VerifyClassUnauthorized
+	case errors.As(err, &rle):
+		return VerifyClassRateLimited
+	case errors.Is(err, context.DeadlineExceeded):
+		return VerifyClassTimeout
+	case errors.Is(err, clients.ErrProviderUnavailable):
+		return VerifyClassUnavailable
+	default:
+		return VerifyClassError
+	}
+}
+
+// Verify checks connectivity of all router models at once & logs the report.
+// Failing models start unhealthy until they recover. In the fail-fast mode, providers rejecting credentials
+// make the verification fail, so the gateway doesn't start with a config that could never work
+func (r *RouterManager) Verify(ctx context.Context, cfg *StartupConfig) ([]VerifyResult, error) {
+	if cfg == nil || cfg.Verify == VerifyOff {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var (
+		results   []VerifyResult
+		verifiers []providers.Verifiable
+	)
+
+	for _, router := range r.GetLangRouters() {
+		for _, model := range router.models {
+			verifiable, ok := model.(providers.Verifiable)
+			if !ok {
+				continue
+			}
+
+			results = append(results, VerifyResult{
+				RouterID: router.ID(),
+				ModelID:  model.ID(),
+				Provider: model.Provider(),
+			})
+
+			verifiers = append(verifiers, verifiable)
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	startedAt := time.Now()
+
+	for i, verifiable := range verifiers {
+		wg.Add(1)
+
+		go func(result *VerifyResult, verifiable providers.Verifiable) {
+			defer wg.Done()
+
+			result.Err = verifiable.Verify(ctx)
+			result.Class = verifyClassOf(result.Err)
+		}(&results[i], verifiable)
+	}
+
+	wg.Wait()
+
+	var unauthorized []string
+
+	failed := 0
+
+	for _, result := range results {
+		if result.OK() {
+			continue
+		}
+
+		failed++
+
+		if result.Class == VerifyClassUnauthorized {
+			unauthorized = append(unauthorized, fmt.Sprintf("%v/%v (%v)", result.RouterID, result.ModelID, result.Provider))
+		}
+	}
+
+	logger := r.telemetry.Logger.With(
+		zap.Int("models", len(results)),
+		zap.Int("failed", failed),
+		zap.Duration("took", time.Since(startedAt)),
+	)
+
+	if failed > 0 {
+		logger.Warn("startup verification:\n" + verifyReport(results))
+	} else {
+		logger.Info("startup verification:\n" + verifyReport(results))
+	}
+
+	if cfg.Verify == VerifyFailFast && len(unauthorized) > 0 {
+		return results, fmt.Errorf(
+			"%w: providers have rejected credentials of %v",
+			ErrStartupVerificationFailed,
+			strings.Join(unauthorized, ", "),
+		)
+	}
+
+	return results, nil
+}
+
+// verifyReport renders check results as a table
+func verifyReport(results []VerifyResult) string {
+	var report strings.Builder
+
+	table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(table, "ROUTER\tMODEL\tPROVIDER\tSTATUS\tCLASS\tERROR")
+
+	for _, result := range results {
+		status, errMsg := "OK", ""
+
+		if !result.OK() {
+			status, errMsg = "FAILED", result.Err.Error()
+		}
+
+		_, _ = fmt.Fprintf(table, "%v\t%v\t%v\t%v\t%v\t%v\n", result.RouterID, result.ModelID, result.Provider, status, result.Class, errMsg)
+	}
+
+	_ = table.Flush()
+
+	return report.String()
+}
@@ -0,0 +1,135 @@
+package routers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+	"glide/pkg/telemetry"
+	"gopkg.in/yaml.v3"
+)
+
+func buildVerifyManager(t *testing.T, responses map[string]providers.ResponseMock) (*RouterManager, map[string]*providers.LangModel) {
+	t.Helper()
+
+	budget := health.NewErrorBudget(3, health.HOUR)
+	latConfig := latency.DefaultConfig()
+
+	langModels := make([]providers.LanguageModel, 0, len(responses))
+	modelMap := make(map[string]*providers.LangModel, len(responses))
+
+	for _, modelID := range []string{"first", "second", "third"} {
+		response, found := responses[modelID]
+		if !found {
+			continue
+		}
+
+		model := providers.NewLangModel(modelID, providers.NewProviderMock([]providers.ResponseMock{response}), *budget, *latConfig, 1)
+
+		langModels = append(langModels, model)
+		modelMap[modelID] = model
+	}
+
+	router := newTestRouter(t, langModels, withRouterConfig(func(cfg *LangRouterConfig) {
+		cfg.Retry.MaxRetries = 1
+	}))
+
+	manager := &RouterManager{telemetry: telemetry.NewTelemetryMock()}
+	manager.routers.Store(newRouterSet(&Config{}, []*LangRouter{router}))
+
+	return manager, modelMap
+}
+
+func TestRouterManager_VerifyStartsFailingModelsUnhealthy(t *testing.T) {
+	unauthorizedErr := clients.NewProviderError(http.StatusUnauthorized)
+	unavailableErr := clients.NewProviderError(http.StatusServiceUnavailable)
+
+	manager, models := buildVerifyManager(t, map[string]providers.ResponseMock{
+		"first":  {Msg: "pong"},
+		"second": {Err: &unauthorizedErr},
+		"third":  {Err: &unavailableErr},
+	})
+
+	results, err := manager.Verify(context.Background(), &StartupConfig{Verify: VerifyOn, Timeout: time.Second})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	require.True(t, results[0].OK())
+	require.Equal(t, VerifyClassOK, results[0].Class)
+	require.Equal(t, VerifyClassUnauthorized, results[1].Class)
+	require.Equal(t, VerifyClassUnavailable, results[2].Class)
+
+	require.True(t, models["first"].Healthy())
+	require.False(t, models["second"].Healthy())
+	require.False(t, models["third"].Healthy())
+
+	require.Contains(t, verifyReport(results), "FAILED")
+}
+
+func TestRouterManager_VerifyFailFastOnRejectedCredentials(t *testing.T) {
+	unauthorizedErr := clients.NewProviderError(http.StatusForbidden)
+
+	manager, _ := buildVerifyManager(t, map[string]providers.ResponseMock{
+		"first":  {Msg: "pong"},
+		"second": {Err: &unauthorizedErr},
+	})
+
+	_, err := manager.Verify(context.Background(), &StartupConfig{Verify: VerifyFailFast, Timeout: time.Second})
+	require.ErrorIs(t, err, ErrStartupVerificationFailed)
+	require.Contains(t, err.Error(), "test_router/second")
+}
+
+func TestRouterManager_VerifyFailFastToleratesUnavailableProviders(t *testing.T) {
+	rateLimitErr := error(clients.NewRateLimitError(nil))
+
+	manager, models := buildVerifyManager(t, map[string]providers.ResponseMock{
+		"first":  {Msg: "pong", Delay: time.Second},
+		"second": {Err: &rateLimitErr},
+	})
+
+	results, err := manager.Verify(context.Background(), &StartupConfig{Verify: VerifyFailFast, Timeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	require.Equal(t, VerifyClassTimeout, results[0].Class)
+	require.Equal(t, VerifyClassRateLimited, results[1].Class)
+
+	// timed out checks are not held against models
+	require.True(t, models["first"].Healthy())
+	require.False(t, models["second"].Healthy())
+}
+
+func TestRouterManager_VerifyDisabled(t *testing.T) {
+	unauthorizedErr := clients.NewProviderError(http.StatusUnauthorized)
+
+	manager, models := buildVerifyManager(t, map[string]providers.ResponseMock{"first": {Err: &unauthorizedErr}})
+
+	results, err := manager.Verify(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	results, err = manager.Verify(context.Background(), DefaultStartupConfig())
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	require.True(t, models["first"].Healthy())
+}
+
+func TestStartupConfig_VerifyModes(t *testing.T) {
+	for rawMode, mode := range map[string]VerifyMode{
+		"true":      VerifyOn,
+		"false":     VerifyOff,
+		"fail-fast": VerifyFailFast,
+	} {
+		var cfg StartupConfig
+
+		require.NoError(t, yaml.Unmarshal([]byte("verify: "+rawMode+"\n"), &cfg))
+		require.Equal(t, mode, cfg.Verify)
+		require.Equal(t, 10*time.Second, cfg.Timeout)
+	}
+}
@@ -0,0 +1,282 @@
+package routers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"glide/pkg/providers"
+	"go.uber.org/zap"
+)
+
+const (
+	// connWarmupTick is how often models are checked for being due for the connection warmup
+	connWarmupTick = time.Second
+	// idleLatencyTick is how often models are checked for not having latency samples over their update intervals
+	idleLatencyTick = time.Second
+)
+
+// IdleLatencyMode defines how latencies of idle models are refreshed
+type IdleLatencyMode string
+
+const (
+	IdleLatencyProbe IdleLatencyMode = "probe" // send tiny requests to idle models to sample their latency
+	IdleLatencyDecay IdleLatencyMode = "decay" // move latencies of idle models toward the router median
+)
+
+// IdleLatencyConfig defines how latencies of models that get no traffic are refreshed. Otherwise, a model that got slow
+// loses least latency traffic and never re-enters the rotation even if it has recovered on the provider side
+type IdleLatencyConfig struct {
+	Mode IdleLatencyMode `yaml:"mode,omitempty" json:"mode" swaggertype:"primitive,string" validate:"oneof=probe decay"`
+	// DecayRate is the share of the gap to the router median idle latencies are decayed by on each update interval
+	DecayRate float64 `yaml:"decay_rate,omitempty" json:"decay_rate" validate:"gt=0,lte=1"`
+}
+
+func DefaultIdleLatencyConfig() *IdleLatencyConfig {
+	return &IdleLatencyConfig{
+		Mode:      IdleLatencyDecay,
+		DecayRate: 0.3,
+	}
+}
+
+func (c *IdleLatencyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultIdleLatencyConfig()
+
+	type plain IdleLatencyConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// WarmupConfig defines how router models are warmed up when the gateway starts
+type WarmupConfig struct {
+	// Requests is the number of tiny requests sent to each model. Set it to the latency warmup samples
+	// to have the latency moving average initialized before the first real request
+	Requests int `yaml:"requests,omitempty" json:"requests" validate:"min=1"`
+	// Timeout limits how long the gateway startup could be delayed by the warmup
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout" swaggertype:"primitive,integer" validate:"gt=0"`
+}
+
+func DefaultWarmupConfig() *WarmupConfig {
+	return &WarmupConfig{
+		Requests: 1,
+		Timeout:  10 * time.Second,
+	}
+}
+
+func (c *WarmupConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultWarmupConfig()
+
+	type plain WarmupConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// Warmup warms up router models at once if the router is configured to.
+// Warmup failures are logged only, as models are going to be tried by real requests anyway
+func (r *LangRouter) Warmup(ctx context.Context) {
+	cfg := r.Config.Warmup
+	if cfg == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	for _, model := range r.models {
+		warmable, ok := model.(providers.Warmable)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(model providers.LanguageModel) {
+			defer wg.Done()
+
+			for i := 0; i < cfg.Requests; i++ {
+				if err := warmable.Warmup(ctx); err != nil {
+					r.telemetry.Logger.Warn(
+						"lang model failed to warm up",
+						zap.String("routerID", r.ID()),
+						zap.String("modelID", model.ID()),
+						zap.String("provider", model.Provider()),
+						zap.Error(err),
+					)
+
+					return
+				}
+			}
+		}(model)
+	}
+
+	wg.Wait()
+}
+
+// warmupConnections re-establishes connections of models that are due for the connection warmup
+func (r *LangRouter) warmupConnections(ctx context.Context, wg *sync.WaitGroup) {
+	for _, model := range r.models {
+		warmable, ok := model.(providers.ConnWarmable)
+		if !ok || !warmable.ConnWarmupDue() {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(model providers.LanguageModel) {
+			defer wg.Done()
+
+			if err := warmable.WarmupConnections(ctx); err != nil {
+				r.telemetry.Logger.Warn(
+					"lang model failed to warm up provider connections",
+					zap.String("routerID", r.ID()),
+					zap.String("modelID", model.ID()),
+					zap.String("provider", model.Provider()),
+					zap.Error(err),
+				)
+			}
+		}(model)
+	}
+}
+
+// Warmup warms up models of all routers & connections to their providers, so the first requests don't pay for cold starts
+func (r *RouterManager) Warmup(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	startedAt := time.Now()
+
+	for _, router := range r.GetLangRouters() {
+		router.warmupConnections(ctx, &wg)
+
+		if router.Config.Warmup == nil {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(router *LangRouter) {
+			defer wg.Done()
+
+			router.Warmup(ctx)
+		}(router)
+	}
+
+	wg.Wait()
+
+	r.telemetry.Logger.Debug("routers warmed up", zap.Duration("took", time.Since(startedAt)))
+}
+
+// KeepConnectionsWarm re-establishes provider connections of models with the connection warmup on their intervals
+// until the context is done. Routers are picked on each tick, so models of reloaded routers are warmed up too
+func (r *RouterManager) KeepConnectionsWarm(ctx context.Context) {
+	ticker := time.NewTicker(connWarmupTick)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, router := range r.GetLangRouters() {
+				router.warmupConnections(ctx, &wg)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// refreshIdleLatencies probes or decays latencies of models that have not been sampled over their update intervals.
+// Probes are sent to healthy models only, as there is no point to spend requests on models that can't serve traffic anyway
+func (r *LangRouter) refreshIdleLatencies(ctx context.Context, wg *sync.WaitGroup) {
+	cfg := r.Config.IdleLatency
+	if cfg == nil {
+		return
+	}
+
+	median := r.medianLatency()
+
+	for _, model := range r.models {
+		refreshable, ok := model.(providers.LatencyRefreshable)
+		if !ok || !refreshable.LatencyIdle() {
+			continue
+		}
+
+		if cfg.Mode == IdleLatencyDecay {
+			if median > 0 {
+				refreshable.DecayLatency(median, cfg.DecayRate)
+			}
+
+			continue
+		}
+
+		if !model.Healthy() {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(model providers.LanguageModel) {
+			defer wg.Done()
+
+			if err := refreshable.ProbeLatency(ctx); err != nil {
+				r.telemetry.Logger.Warn(
+					"lang model failed to respond to the latency probe",
+					zap.String("routerID", r.ID()),
+					zap.String("modelID", model.ID()),
+					zap.String("provider", model.Provider()),
+					zap.Error(err),
+				)
+			}
+		}(model)
+	}
+}
+
+// medianLatency returns the median of warmed up model latencies (zero if no model has warmed up yet)
+func (r *LangRouter) medianLatency() float64 {
+	latencies := make([]float64, 0, len(r.models))
+
+	for _, model := range r.models {
+		if model.Latency().WarmedUp() {
+			latencies = append(latencies, model.Latency().Value())
+		}
+	}
+
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sort.Float64s(latencies)
+
+	middle := len(latencies) / 2
+
+	if len(latencies)%2 == 0 {
+		return (latencies[middle-1] + latencies[middle]) / 2
+	}
+
+	return latencies[middle]
+}
+
+// RefreshIdleLatencies refreshes latencies of idle models of routers configured to until the context is done.
+// Routers are picked on each tick, so models of reloaded routers are refreshed too
+func (r *RouterManager) RefreshIdleLatencies(ctx context.Context) {
+	ticker := time.NewTicker(idleLatencyTick)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, router := range r.GetLangRouters() {
+				router.refreshIdleLatencies(ctx, &wg)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package routers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"glide/pkg/api/schemas"
+	"glide/pkg/providers"
+	"glide/pkg/providers/clients"
+	"glide/pkg/routers/health"
+	"glide/pkg/routers/latency"
+	"glide/pkg/telemetry"
+)
+
+func TestLangRouter_WarmupSeedsLatency(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.SEC)
+	latConfig := latency.DefaultConfig()
+	latConfig.WarmupSamples = 1
+
+	warmProvider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "pong"}, {Msg: "pong"}})
+
+	langModels := []providers.LanguageModel{
+		providers.NewLangModel("first", warmProvider, *budget, *latConfig, 1),
+		providers.NewLangModel(
+			"second",
+			providers.NewProviderMock([]providers.ResponseMock{{Err: &ErrNoModelAvailable}}),
+			*budget,
+			*latConfig,
+			1,
+		),
+	}
+
+	router := LangRouter{
+		routerID: "test_router",
+		Config: &LangRouterConfig{
+			Warmup: &WarmupConfig{Requests: 2, Timeout: time.Second},
+		},
+		models:    langModels,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	router.Warmup(context.Background())
+
+	require.True(t, langModels[0].Latency().WarmedUp())
+	require.Equal(t, 1, *warmProvider.LastRequest().Override.Params.MaxTokens)
+
+	// warmup failures are not counted against the error budget
+	require.False(t, langModels[1].Latency().WarmedUp())
+	require.True(t, langModels[1].Healthy())
+}
+
+func TestLangRouter_DecaysIdleLatencies(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+	updateInterval := 10 * time.Millisecond
+	latConfig.UpdateInterval = &updateInterval
+
+	models := make([]providers.LanguageModel, 0, 3)
+
+	for modelID, avgLatency := range map[string]float64{"fast": 100, "median": 200, "slow": 1000} {
+		model := providers.NewLangModel(modelID, providers.NewProviderMock(nil), *budget, *latConfig, 1)
+		model.Latency().Set(avgLatency)
+
+		models = append(models, model)
+	}
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{IdleLatency: &IdleLatencyConfig{Mode: IdleLatencyDecay, DecayRate: 0.5}},
+		models:    models,
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	require.Eventually(t, func() bool {
+		return models[0].(providers.LatencyRefreshable).LatencyIdle()
+	}, time.Second, updateInterval)
+
+	var wg sync.WaitGroup
+
+	router.refreshIdleLatencies(context.Background(), &wg)
+	wg.Wait()
+
+	latencyOf := func(modelID string) float64 {
+		for _, model := range models {
+			if model.ID() == modelID {
+				return model.Latency().Value()
+			}
+		}
+
+		return 0
+	}
+
+	require.InDelta(t, 150.0, latencyOf("fast"), 0.0001)
+	require.InDelta(t, 200.0, latencyOf("median"), 0.0001)
+	require.InDelta(t, 600.0, latencyOf("slow"), 0.0001)
+
+	// decayed models are not idle until the next update interval
+	router.refreshIdleLatencies(context.Background(), &wg)
+	wg.Wait()
+
+	require.InDelta(t, 600.0, latencyOf("slow"), 0.0001)
+}
+
+func TestLangRouter_ProbesIdleModels(t *testing.T) {
+	budget := health.NewErrorBudget(1, health.MIN)
+	latConfig := latency.DefaultConfig()
+	latConfig.WarmupSamples = 1
+	updateInterval := 10 * time.Millisecond
+	latConfig.UpdateInterval = &updateInterval
+
+	idleProvider := providers.NewProviderMock([]providers.ResponseMock{{Msg: "pong"}})
+	idleModel := providers.NewLangModel("idle", idleProvider, *budget, *latConfig, 1)
+	idleModel.Latency().Set(1e12) // the model got slow once
+
+	unhealthyModel := providers.NewLangModel(
+		"unhealthy",
+		providers.NewProviderMock([]providers.ResponseMock{{Err: &clients.ErrProviderUnavailable}}),
+		*budget,
+		*latConfig,
+		1,
+	)
+
+	_, err := unhealthyModel.Chat(context.Background(), schemas.NewChatFromStr("tell me a dad joke"))
+	require.Error(t, err)
+	require.False(t, unhealthyModel.Healthy())
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{IdleLatency: &IdleLatencyConfig{Mode: IdleLatencyProbe}},
+		models:    []providers.LanguageModel{idleModel, unhealthyModel},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	require.Eventually(t, idleModel.LatencyIdle, time.Second, updateInterval)
+
+	var wg sync.WaitGroup
+
+	router.refreshIdleLatencies(context.Background(), &wg)
+	wg.Wait()
+
+	require.Less(t, idleModel.Latency().Value(), 1e12)
+	require.Equal(t, 1, *idleProvider.LastRequest().Override.Params.MaxTokens)
+
+	// probes are not sent to unhealthy models & don't go through the model chat (so they don't count as served requests)
+	require.False(t, unhealthyModel.Latency().WarmedUp())
+	require.Zero(t, idleModel.InFlight())
+}
+
+type connWarmingProviderMock struct {
+	*providers.ProviderMock
+	connections atomic.Int64
+}
+
+func (m *connWarmingProviderMock) WarmupConnections(_ context.Context, connections int) error {
+	m.connections.Add(int64(connections))
+
+	return nil
+}
+
+func TestLangRouter_WarmsUpConnectionsOnInterval(t *testing.T) {
+	budget := health.NewErrorBudget(3, health.SEC)
+	latConfig := latency.DefaultConfig()
+
+	provider := &connWarmingProviderMock{ProviderMock: providers.NewProviderMock(nil)}
+
+	model := providers.NewLangModel("first", provider, *budget, *latConfig, 1)
+	model.SetConnWarmup(&clients.ConnWarmupConfig{Connections: 2, Interval: time.Hour})
+
+	router := LangRouter{
+		routerID:  "test_router",
+		Config:    &LangRouterConfig{},
+		models:    []providers.LanguageModel{model},
+		telemetry: telemetry.NewTelemetryMock(),
+	}
+
+	require.True(t, model.ConnWarmupDue())
+
+	var wg sync.WaitGroup
+
+	router.warmupConnections(context.Background(), &wg)
+	wg.Wait()
+
+	require.Equal(t, int64(2), provider.connections.Load())
+	require.False(t, model.ConnWarmupDue())
+
+	// connections are not warmed up again until the interval is over
+	router.warmupConnections(context.Background(), &wg)
+	wg.Wait()
+
+	require.Equal(t, int64(2), provider.connections.Load())
+
+	// warmup requests are not counted as latency samples
+	require.Zero(t, model.Latency().Value())
+	require.Zero(t, model.InFlight())
+}
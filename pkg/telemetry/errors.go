@@ -0,0 +1,47 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+)
+
+// ErrorContext describes the request the error happened in
+type ErrorContext struct {
+	RequestID string
+	Method    string
+	Path      string
+	Tags      map[string]string
+}
+
+// ErrorReporter sends unexpected errors & recovered panics to an error tracker (e.g. Sentry)
+type ErrorReporter interface {
+	CaptureError(ctx context.Context, err error, errCtx *ErrorContext)
+	CapturePanic(ctx context.Context, recovered interface{}, errCtx *ErrorContext)
+	// Flush waits until buffered reports are sent or the timeout is over
+	Flush(timeout time.Duration) bool
+}
+
+// ErrorReportingConfig configures the error tracker. Errors are not reported anywhere if it's not configured
+type ErrorReportingConfig struct {
+	Sentry *SentryConfig `yaml:"sentry,omitempty"`
+}
+
+// NewErrorReporter creates the configured error reporter or the no-op one
+func NewErrorReporter(cfg *ErrorReportingConfig) (ErrorReporter, error) {
+	if cfg == nil || cfg.Sentry == nil {
+		return NoopErrorReporter{}, nil
+	}
+
+	return NewSentryReporter(cfg.Sentry)
+}
+
+// NoopErrorReporter drops all errors
+type NoopErrorReporter struct{}
+
+func (NoopErrorReporter) CaptureError(context.Context, error, *ErrorContext) {}
+
+func (NoopErrorReporter) CapturePanic(context.Context, interface{}, *ErrorContext) {}
+
+func (NoopErrorReporter) Flush(time.Duration) bool {
+	return true
+}
@@ -1,6 +1,8 @@
 package telemetry
 
 import (
+	"time"
+
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	hertzzap "github.com/hertz-contrib/logger/zap"
 	"go.uber.org/zap"
@@ -28,6 +30,24 @@ type LogConfig struct {
 
 	// InitialFields is a collection of fields to add to the root logger.
 	InitialFields map[string]interface{} `yaml:"initial_fields"`
+
+	// Sampling caps the volume of repeated logs. Errors are never sampled.
+	// Set it to null to log everything.
+	Sampling *SamplingConfig `yaml:"sampling"`
+}
+
+// SamplingConfig logs the first Initial entries with the same level & message each second
+// and then every Thereafter-th of them
+type SamplingConfig struct {
+	Initial    int `yaml:"initial" validate:"gte=0"`
+	Thereafter int `yaml:"thereafter" validate:"gte=0"` // zero drops all entries over the initial ones
+}
+
+func DefaultSamplingConfig() *SamplingConfig {
+	return &SamplingConfig{
+		Initial:    100,
+		Thereafter: 100,
+	}
 }
 
 func DefaultLogConfig() *LogConfig {
@@ -38,6 +58,7 @@ func DefaultLogConfig() *LogConfig {
 		DisableStacktrace: false,
 		OutputPaths:       []string{"stdout"},
 		InitialFields:     make(map[string]interface{}),
+		Sampling:          DefaultSamplingConfig(),
 	}
 }
 
@@ -58,11 +79,56 @@ func (c *LogConfig) ToZapConfig() *zap.Config {
 	zapConfig.DisableStacktrace = c.DisableStacktrace
 	zapConfig.OutputPaths = c.OutputPaths
 	zapConfig.InitialFields = c.InitialFields
+	// zap samples errors too, so logs are sampled by the wrapping core instead (see ZapOptions())
+	zapConfig.Sampling = nil
 
 	return &zapConfig
 }
 
-func NewHertzLogger(zapConfig *zap.Config) (*hertzzap.Logger, error) {
+// ZapOptions returns options that apply the config to loggers built out of the zap config (see ToZapConfig())
+func (c *LogConfig) ZapOptions() []zap.Option {
+	if c.Sampling == nil {
+		return nil
+	}
+
+	return []zap.Option{
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewSampledCore(core, c.Sampling)
+		}),
+	}
+}
+
+// errorExemptCore samples entries below the error level, so repeated debug & info logs are capped
+// while no error is lost
+type errorExemptCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+}
+
+// NewSampledCore samples entries of the core except for errors
+func NewSampledCore(core zapcore.Core, cfg *SamplingConfig) zapcore.Core {
+	return &errorExemptCore{
+		Core:    core,
+		sampled: zapcore.NewSamplerWithOptions(core, time.Second, cfg.Initial, cfg.Thereafter),
+	}
+}
+
+func (c *errorExemptCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorExemptCore{
+		Core:    c.Core.With(fields),
+		sampled: c.sampled.With(fields),
+	}
+}
+
+func (c *errorExemptCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level >= zapcore.ErrorLevel {
+		return c.Core.Check(entry, checked)
+	}
+
+	return c.sampled.Check(entry, checked)
+}
+
+func NewHertzLogger(zapConfig *zap.Config, options ...zap.Option) (*hertzzap.Logger, error) {
 	// Both hertzzap and zap have a set of private methods that prevents from leveraging
 	//  their native encoder & sink building functionality
 	//  We had to copy & paste some of those to get it working
@@ -83,19 +149,19 @@ func NewHertzLogger(zapConfig *zap.Config) (*hertzzap.Logger, error) {
 		hertzzap.WithCoreEnc(encoder),
 		hertzzap.WithCoreWs(sink),
 		hertzzap.WithCoreLevel(zapConfig.Level),
-		hertzzap.WithZapOptions(zap.AddCallerSkip(3)),
+		hertzzap.WithZapOptions(append(options, zap.AddCallerSkip(3))...),
 	), nil
 }
 
 func NewLogger(cfg *LogConfig) (*zap.Logger, error) {
 	zapConfig := cfg.ToZapConfig()
 
-	logger, err := zapConfig.Build()
+	logger, err := zapConfig.Build(cfg.ZapOptions()...)
 	if err != nil {
 		return nil, err
 	}
 
-	hertzLogger, err := NewHertzLogger(zapConfig)
+	hertzLogger, err := NewHertzLogger(zapConfig, cfg.ZapOptions()...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampledCore_CapsRepeatedLogs(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(NewSampledCore(core, &SamplingConfig{Initial: 2, Thereafter: 5}))
+
+	for i := 0; i < 10; i++ {
+		logger.Info("the same message")
+	}
+
+	// the first 2 entries and then the 7th one
+	require.Equal(t, 3, logs.FilterMessage("the same message").Len())
+
+	// different messages are sampled separately
+	logger.Info("another message")
+	require.Equal(t, 1, logs.FilterMessage("another message").Len())
+}
+
+func TestSampledCore_ErrorsAreNotSampled(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(NewSampledCore(core, &SamplingConfig{Initial: 1, Thereafter: 0})).With(zap.String("routerID", "router"))
+
+	for i := 0; i < 10; i++ {
+		logger.Error("something went wrong")
+		logger.Warn("something looks wrong")
+	}
+
+	require.Equal(t, 10, logs.FilterMessage("something went wrong").Len())
+	require.Equal(t, 1, logs.FilterMessage("something looks wrong").Len())
+}
+
+func TestNewLogger_SamplesLogs(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "glide.log")
+
+	cfg := DefaultLogConfig()
+	cfg.Level = zap.DebugLevel
+	cfg.OutputPaths = []string{logPath}
+	cfg.Sampling = &SamplingConfig{Initial: 3, Thereafter: 100}
+
+	logger, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		logger.Debug("picked the model")
+	}
+
+	require.NoError(t, logger.Sync())
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	require.Equal(t, 3, strings.Count(string(content), "picked the model"))
+}
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricNamespace = "glide"
+
+// Metrics holds the Prometheus registry all gateway metrics are registered in.
+// Components create their metrics via the helper methods, so the same metric could be safely requested
+// by several component instances (e.g. by every router)
+type Metrics struct {
+	registry *prometheus.Registry
+}
+
+func NewMetrics() *Metrics {
+	registry := prometheus.NewRegistry()
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return &Metrics{
+		registry: registry,
+	}
+}
+
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Counter returns a counter with the given name, registering it on the first call
+func (m *Metrics) Counter(name string, help string) prometheus.Counter {
+	return register(m.registry, prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricNamespace,
+		Name:      name,
+		Help:      help,
+	}))
+}
+
+// CounterVec returns a counter vector with the given name, registering it on the first call
+func (m *Metrics) CounterVec(name string, help string, labels ...string) *prometheus.CounterVec {
+	return register(m.registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricNamespace,
+		Name:      name,
+		Help:      help,
+	}, labels))
+}
+
+// GaugeVec returns a gauge vector with the given name, registering it on the first call
+func (m *Metrics) GaugeVec(name string, help string, labels ...string) *prometheus.GaugeVec {
+	return register(m.registry, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricNamespace,
+		Name:      name,
+		Help:      help,
+	}, labels))
+}
+
+func register[T prometheus.Collector](registry *prometheus.Registry, collector T) T {
+	if err := registry.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+
+		panic(err)
+	}
+
+	return collector
+}
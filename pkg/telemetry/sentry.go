@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"glide/pkg/config/fields"
+)
+
+// DefaultRelease is the release errors are reported with. It's set to the gateway version on startup
+var DefaultRelease = "glide@devel"
+
+type SentryConfig struct {
+	DSN         fields.Secret `yaml:"dsn" validate:"required"`
+	Environment string        `yaml:"environment,omitempty"`
+	// SampleRate is the share of errors to report (from 0 to 1)
+	SampleRate float64 `yaml:"sample_rate" validate:"gte=0,lte=1"`
+}
+
+func DefaultSentryConfig() *SentryConfig {
+	return &SentryConfig{
+		SampleRate: 1.0,
+	}
+}
+
+func (c *SentryConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = *DefaultSentryConfig()
+
+	type plain SentryConfig // to avoid recursion
+
+	return unmarshal((*plain)(c))
+}
+
+// SentryReporter reports errors to Sentry
+type SentryReporter struct {
+	hub *sentry.Hub
+}
+
+func NewSentryReporter(cfg *SentryConfig) (*SentryReporter, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              cfg.DSN.Value(),
+		Environment:      cfg.Environment,
+		Release:          DefaultRelease,
+		SampleRate:       cfg.SampleRate,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to init sentry client: %w", err)
+	}
+
+	return &SentryReporter{
+		hub: sentry.NewHub(client, sentry.NewScope()),
+	}, nil
+}
+
+func (r *SentryReporter) CaptureError(_ context.Context, err error, errCtx *ErrorContext) {
+	hub := r.hub.Clone() // the scope is per report, as reports come from concurrent requests
+	applyErrorContext(hub.Scope(), errCtx)
+
+	hub.CaptureException(err)
+}
+
+func (r *SentryReporter) CapturePanic(ctx context.Context, recovered interface{}, errCtx *ErrorContext) {
+	hub := r.hub.Clone()
+	applyErrorContext(hub.Scope(), errCtx)
+
+	hub.RecoverWithContext(ctx, recovered)
+}
+
+func (r *SentryReporter) Flush(timeout time.Duration) bool {
+	return r.hub.Flush(timeout)
+}
+
+func applyErrorContext(scope *sentry.Scope, errCtx *ErrorContext) {
+	if errCtx == nil {
+		return
+	}
+
+	scope.SetTags(errCtx.Tags)
+	scope.SetTag("request_id", errCtx.RequestID)
+	scope.SetContext("request", sentry.Context{
+		"method": errCtx.Method,
+		"path":   errCtx.Path,
+	})
+}
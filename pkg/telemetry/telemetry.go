@@ -4,13 +4,17 @@ import "go.uber.org/zap"
 
 type Config struct {
 	LogConfig *LogConfig `yaml:"logging" validate:"required"`
+	// ErrorReporting sends panics & unexpected errors to the error tracker (disabled by default)
+	ErrorReporting *ErrorReportingConfig `yaml:"error_reporting,omitempty"`
 	// TODO: add OTEL config
 }
 
 type Telemetry struct {
-	Config *Config
-	Logger *zap.Logger
-	// TODO: add OTEL meter, tracer
+	Config  *Config
+	Logger  *zap.Logger
+	Metrics *Metrics
+	Errors  ErrorReporter
+	// TODO: add OTEL tracer
 }
 
 func DefaultConfig() *Config {
@@ -25,16 +29,30 @@ func NewTelemetry(cfg *Config) (*Telemetry, error) {
 		return nil, err
 	}
 
+	errorReporter, err := NewErrorReporter(cfg.ErrorReporting)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Telemetry{
-		Config: cfg,
-		Logger: logger,
+		Config:  cfg,
+		Logger:  logger,
+		Metrics: NewMetrics(),
+		Errors:  errorReporter,
 	}, nil
 }
 
 // NewTelemetryMock returns Telemetry object with NoOp loggers, meters, tracers
 func NewTelemetryMock() *Telemetry {
+	return NewNopTelemetry()
+}
+
+// NewNopTelemetry discards logs & error reports (metrics are still collected). It's the default of Glide embedded as a library
+func NewNopTelemetry() *Telemetry {
 	return &Telemetry{
-		Config: DefaultConfig(),
-		Logger: zap.NewNop(),
+		Config:  DefaultConfig(),
+		Logger:  zap.NewNop(),
+		Metrics: NewMetrics(),
+		Errors:  NoopErrorReporter{},
 	}
 }
@@ -3,6 +3,9 @@ package pkg
 import (
 	"fmt"
 	"runtime"
+
+	"glide/pkg/providers/clients"
+	"glide/pkg/telemetry"
 )
 
 // version must be set from the contents of VERSION file by go build's
@@ -26,4 +29,7 @@ func init() {
 		runtime.Version(),
 		buildDate,
 	)
+
+	clients.DefaultUserAgent = "glide/" + version
+	telemetry.DefaultRelease = "glide@" + version
 }